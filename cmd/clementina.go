@@ -1,15 +1,23 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
 	"github.com/fran150/clementina-6502/pkg/components/mia"
+	"github.com/fran150/clementina-6502/pkg/components/panel"
 	"github.com/fran150/clementina-6502/pkg/computers/beneater"
 	"github.com/fran150/clementina-6502/pkg/computers/clementina"
 	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
 	"github.com/spf13/cobra"
 	"go.bug.st/serial"
 )
@@ -20,18 +28,43 @@ const (
 )
 
 var (
-	model             string
-	serialPort        string
-	gpioChipName      string
-	romFile           string
-	videoUDPAddress   string
-	inputUDPAddress   string
-	sdFolder          string
-	charset           string
-	palette           string
-	targetMhz         float64
-	targetFps         int
-	emulateModemLines bool
+	model              string
+	cpuName            string
+	serialPort         string
+	gpioChipName       string
+	romFile            string
+	loadFiles          []string
+	eeprom             bool
+	persistRom         bool
+	watchFiles         bool
+	buttonSpecs        []string
+	symbolsFile        string
+	stateFile          string
+	traceFile          string
+	waveformFile       string
+	profileFile        string
+	coverageFile       string
+	recordFile         string
+	replayFile         string
+	videoUDPAddress    string
+	inputUDPAddress    string
+	sdFolder           string
+	charset            string
+	palette            string
+	targetMhz          float64
+	virtualTimeMhz     float64
+	targetFps          int
+	emulateModemLines  bool
+	verifyCpu          bool
+	fastMode           bool
+	headless           bool
+	maxCycles          uint64
+	stopAddresses      []string
+	stopWriteAddresses []string
+	stopOnBreak        bool
+	stopOnStop         bool
+	dumpRanges         []string
+	exitCodeAddress    string
 )
 
 var rootCmd = &cobra.Command{
@@ -43,6 +76,9 @@ var rootCmd = &cobra.Command{
 
 func init() {
 	rootCmd.Flags().StringVarP(&model, "model", "m", "clementina", "Computer model to emulate (clementina / beneater / clementina-gpio)")
+	rootCmd.Flags().StringVar(&cpuName, "cpu", cpu.Cpu65C02S, "Processor to emulate (65c02 / 6502)")
+	rootCmd.Flags().BoolVar(&verifyCpu, "verify-cpu", false, "Check the length, cycles and bus reads of the undefined opcodes of the processor and exit")
+	rootCmd.Flags().BoolVar(&fastMode, "fast", false, "Execute whole instructions directly on memory and only go through the bus to access I/O (not cycle accurate)")
 	rootCmd.Flags().StringVarP(&serialPort, "port", "p", "", "Serial port to connect to (e.g., /dev/ttys004)")
 	rootCmd.Flags().StringVar(&gpioChipName, "gpio-chip", "gpiochip4", "GPIO chip to use for clementina-gpio")
 	rootCmd.Flags().StringVar(&videoUDPAddress, "video-udp", mia.DefaultVideoUDPAddress, "UDP address for emulated Clementina MIA video; empty disables video UDP")
@@ -50,9 +86,31 @@ func init() {
 	rootCmd.Flags().StringVar(&sdFolder, "sd", "", "Host folder used as the emulated Clementina MIA SD card; empty leaves the slot empty")
 	rootCmd.Flags().StringVar(&charset, "charset", "clascii", "Character set MIA loads into CHR bank 0 (name under assets/computer/mia/charsets)")
 	rootCmd.Flags().StringVar(&palette, "palette", "clementina-text", "Palette MIA loads into video palette RAM (name under assets/computer/mia/palettes)")
-	rootCmd.Flags().StringVarP(&romFile, "rom", "r", "./assets/computer/beneater/eater.bin", "ROM file to load")
+	rootCmd.Flags().StringVarP(&romFile, "rom", "r", "./assets/computer/beneater/eater.bin", "ROM file to load (raw binary, Intel HEX, S-record, PRG or o65)")
+	rootCmd.Flags().BoolVar(&eeprom, "eeprom", false, "Emulate the ROM of the beneater model as an AT28C256 EEPROM that programs can write")
+	rootCmd.Flags().BoolVar(&persistRom, "persist-rom", false, "Save the writes to the --eeprom in the raw ROM image file")
+	rootCmd.Flags().BoolVar(&watchFiles, "watch", false, "Reload the --rom of the beneater model and the --load programs when their files change and reset the computer")
+	rootCmd.Flags().StringArrayVar(&buttonSpecs, "button", nil, "Button of the I/O panel attached to the VIA as KEY=PIN[,toggle][,high] (e.g. 0=PA0 or i=CA1,toggle), pressed with KEY from the panel menu; can be repeated")
+	rootCmd.Flags().StringArrayVar(&loadFiles, "load", nil, "Program to load in memory as FILE[@ADDRESS] (raw binary, Intel HEX, S-record, PRG or o65), the address is required for raw binaries; can be repeated")
+	rootCmd.Flags().StringVar(&stateFile, "load-state", "", "State file to load on start, it is also the file saved and loaded from the menu")
+	rootCmd.Flags().StringVar(&traceFile, "trace", "", "File where the executed instructions are logged from the start, it is also the file used by the trace menu option")
+	rootCmd.Flags().StringVar(&waveformFile, "vcd", "", "File where the address and data buses, control lines, chip selects and VIA ports are dumped on every cycle from the start, in Value Change Dump format")
+	rootCmd.Flags().StringVar(&profileFile, "profile", "", "File where the profile collected from the start is exported on exit, it is also the file exported by the profiler menu option")
+	rootCmd.Flags().StringVar(&coverageFile, "coverage", "", "File where the coverage collected from the start is written on exit, the lcov line coverage is written to the same file plus \".info\" if the symbols include line information")
+	rootCmd.Flags().StringVar(&recordFile, "record", "", "File where the input received by the computer (serial bytes, keys typed on the keyboard, panel buttons, MIA input packets, reset and speed changes) is recorded with the cycle it's applied at")
+	rootCmd.Flags().StringVar(&replayFile, "replay", "", "Input recording to inject at the recorded cycles, the input received meanwhile is ignored until the last one is injected")
+	rootCmd.Flags().StringVar(&symbolsFile, "symbols", "", "ld65 debug info (.dbg) or VICE label (.lbl) file with the labels shown by the debugger")
 	rootCmd.Flags().Float64VarP(&targetMhz, "speed", "s", 1.2, "Target emulation speed in MHz")
 	rootCmd.Flags().IntVarP(&targetFps, "fps", "f", 15, "Target display refresh rate")
+	rootCmd.Flags().Float64Var(&virtualTimeMhz, "virtual-time", 0, "Derive the time of the components from the cycles executed at this PHI2 frequency in MHz instead of the wall clock, making runs reproducible (0 uses the wall clock)")
+	rootCmd.Flags().BoolVar(&headless, "headless", false, "Run without the terminal UI, as fast as possible, until a stop condition is met and print the result as JSON")
+	rootCmd.Flags().Uint64Var(&maxCycles, "max-cycles", 0, "Headless: stop after executing this number of cycles (0 for no limit)")
+	rootCmd.Flags().StringSliceVar(&stopAddresses, "stop-at", nil, "Headless: stop when an opcode is fetched from any of these addresses or labels")
+	rootCmd.Flags().StringSliceVar(&stopWriteAddresses, "stop-on-write", nil, "Headless: stop when the processor writes to any of these addresses or labels")
+	rootCmd.Flags().BoolVar(&stopOnBreak, "stop-on-brk", false, "Headless: stop when a BRK opcode is fetched")
+	rootCmd.Flags().BoolVar(&stopOnStop, "stop-on-stp", false, "Headless: stop when a STP (or JAM on the 6502) opcode is fetched")
+	rootCmd.Flags().StringSliceVar(&dumpRanges, "dump", nil, "Headless: memory ranges included in the result as START-END (e.g. 0200-02FF)")
+	rootCmd.Flags().StringVar(&exitCodeAddress, "exit-code", "", "Headless: address or label of the memory location whose value is the exit code")
 	rootCmd.Flags().BoolVarP(&emulateModemLines, "emulate-modem", "e", false, "Enable modem lines emulation for serial port (RTS, CTS, DTR, DSR)")
 }
 
@@ -64,6 +122,69 @@ type ComputerRunner interface {
 
 func runEmulator(cmd *cobra.Command, args []string) {
 	var emulator core.BaseEmulator
+	var computer core.Snapshotable
+	var memory core.MemoryPeeker
+	var programLoader core.ProgramLoadable
+	var loadRom func() error
+	var symbols core.SymbolTable
+	var sourceMap core.SourceMap
+
+	processor, err := cpu.NewCpu(cpuName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating processor: %v\n", err)
+		os.Exit(1)
+	}
+
+	if verifyCpu {
+		runCpuVerification()
+	}
+
+	if symbolsFile != "" {
+		var err error
+
+		symbols, err = managers.LoadSymbolTable(symbolsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading symbols file: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Only ld65 debug info files have source line information
+		sourceMap, err = managers.LoadSourceMap(symbolsFile)
+		if err != nil && !errors.Is(err, managers.ErrNoLineInformation) {
+			fmt.Fprintf(os.Stderr, "Error loading source lines: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	buttons, err := parseButtons(buttonSpecs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in panel buttons: %v\n", err)
+		os.Exit(1)
+	}
+
+	var stopConditions core.StopConditions
+	var reportRanges []memoryRange
+	var exitCode *uint16
+
+	if headless {
+		var err error
+
+		stopConditions, err = parseStopConditions(symbols)
+		if err == nil {
+			reportRanges, err = parseMemoryRanges(symbols, dumpRanges)
+		}
+
+		if err == nil && exitCodeAddress != "" {
+			var address uint16
+			address, err = parseAddress(symbols, exitCodeAddress)
+			exitCode = &address
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error in headless options: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
 	switch model {
 	case beneaterModel:
@@ -87,6 +208,9 @@ func runEmulator(cmd *cobra.Command, args []string) {
 		benEaterComputer, err := beneater.NewBenEaterComputer(&beneater.BenEaterComputerConfig{
 			Port:              port,
 			EmulateModemLines: emulateModemLines,
+			Processor:         processor,
+			Eeprom:            eeprom,
+			Buttons:           buttons,
 		})
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating computer: %v\n", err)
@@ -101,25 +225,56 @@ func runEmulator(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 
+		if persistRom {
+			if err := benEaterComputer.PersistRomWrites(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error persisting ROM writes: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		benEaterComputer.SetSymbolTable(symbols)
+		benEaterComputer.SetSourceMap(sourceMap)
+		benEaterComputer.SetStateFile(stateFile)
+		benEaterComputer.SetTraceFile(traceFile)
+		benEaterComputer.SetProfileFile(profileFile)
+		benEaterComputer.SetFastMode(fastMode)
+		computer = benEaterComputer
+		memory = benEaterComputer
+		programLoader = benEaterComputer
+		loadRom = func() error { return benEaterComputer.LoadRom(romFile) }
+
 		emulator, err = beneater.NewBenEaterEmulator(benEaterComputer, targetMhz, targetFps)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating emulator: %v\n", err)
 			os.Exit(1)
 		}
 	case clementinaGPIOModel:
-		clementinaComputer, err := clementina.NewClementinaGPIOComputer(gpioChipName)
+		clementinaComputer, err := clementina.NewClementinaGPIOComputer(processor, gpioChipName)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating computer: %v\n", err)
 			os.Exit(1)
 		}
 
+		if err := clementinaComputer.SetPanelButtons(buttons); err != nil {
+			fmt.Fprintf(os.Stderr, "Error in panel buttons: %v\n", err)
+			os.Exit(1)
+		}
+
+		clementinaComputer.SetSymbolTable(symbols)
+		clementinaComputer.SetSourceMap(sourceMap)
+		clementinaComputer.SetStateFile(stateFile)
+		clementinaComputer.SetTraceFile(traceFile)
+		clementinaComputer.SetProfileFile(profileFile)
+		computer = clementinaComputer
+		memory = clementinaComputer
+
 		emulator, err = clementina.NewClemetinaGPIOEmulator(clementinaComputer, targetFps, gpioChipName)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating GPIO emulator: %v\n", err)
 			os.Exit(1)
 		}
 	default:
-		clementinaComputer, err := clementina.NewClementinaComputerWithUDP(videoUDPAddress, inputUDPAddress)
+		clementinaComputer, err := clementina.NewClementinaComputerWithUDP(processor, videoUDPAddress, inputUDPAddress)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating computer: %v\n", err)
 			os.Exit(1)
@@ -129,6 +284,11 @@ func runEmulator(cmd *cobra.Command, args []string) {
 		clementinaComputer.SetMiaCharset(charset)
 		clementinaComputer.SetMiaPalette(palette)
 
+		if err := clementinaComputer.SetPanelButtons(buttons); err != nil {
+			fmt.Fprintf(os.Stderr, "Error in panel buttons: %v\n", err)
+			os.Exit(1)
+		}
+
 		if sdFolder != "" {
 			info, err := os.Stat(sdFolder)
 			if err != nil || !info.IsDir() {
@@ -157,6 +317,16 @@ func runEmulator(cmd *cobra.Command, args []string) {
 			}
 		}
 
+		clementinaComputer.SetSymbolTable(symbols)
+		clementinaComputer.SetSourceMap(sourceMap)
+		clementinaComputer.SetStateFile(stateFile)
+		clementinaComputer.SetTraceFile(traceFile)
+		clementinaComputer.SetProfileFile(profileFile)
+		clementinaComputer.SetFastMode(fastMode)
+		computer = clementinaComputer
+		memory = clementinaComputer
+		programLoader = clementinaComputer
+
 		emulator, err = clementina.NewClemetinaEmulator(clementinaComputer, targetMhz, targetFps)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error creating emulator: %v\n", err)
@@ -164,14 +334,180 @@ func runEmulator(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if headless {
+		if err := checkFastStopWrites(computer, stopConditions); err != nil {
+			fmt.Fprintf(os.Stderr, "Error in headless options: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if len(loadFiles) > 0 && programLoader == nil {
+		fmt.Fprintf(os.Stderr, "Error: programs can't be loaded in the memory of the %s model\n", model)
+		os.Exit(1)
+	}
+
+	for _, loadFile := range loadFiles {
+		if err := loadProgram(programLoader, symbols, loadFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading program: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if watchFiles {
+		if err := checkWatchOptions(programLoader); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if err := watchPrograms(emulator, loadRom, programLoader, symbols); err != nil {
+			fmt.Fprintf(os.Stderr, "Error watching program files: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if virtualTimeMhz < 0 {
+		fmt.Fprintf(os.Stderr, "Error: --virtual-time must not be negative\n")
+		os.Exit(1)
+	}
+
+	emulator.SetVirtualTime(uint64(math.Round(virtualTimeMhz * 1_000_000)))
+
+	loadStateErr := make(chan error, 1)
+
+	if stateFile != "" {
+		// Loading the state into the computer reports any error before starting the terminal UI,
+		// the emulator loads it again once started to also restore the cycle and time of the emulation
+		if err := checkStateFile(computer, stateFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading state file: %v\n", err)
+			os.Exit(1)
+		}
+
+		emulator.LoadStateFile(stateFile, func(err error) {
+			if err != nil {
+				loadStateErr <- err
+				emulator.Stop()
+			}
+		})
+	}
+
+	if recordFile != "" && replayFile != "" {
+		fmt.Fprintf(os.Stderr, "Error: --record and --replay can't be used together\n")
+		os.Exit(1)
+	}
+
+	if (recordFile != "" || replayFile != "") && model == clementinaGPIOModel {
+		fmt.Fprintf(os.Stderr, "Error: the input of the %s model can't be recorded or replayed\n", model)
+		os.Exit(1)
+	}
+
+	if recordFile != "" {
+		if err := emulator.StartRecording(recordFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating input recording file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if replayFile != "" {
+		stimuli, err := managers.LoadInputRecording(replayFile)
+		if err == nil {
+			err = emulator.StartReplay(stimuli)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading input recording: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if traceFile != "" {
+		if err := emulator.StartTrace(traceFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating trace file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if waveformFile != "" {
+		if err := emulator.StartWaveform(waveformFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating waveform file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if profileFile != "" {
+		if err := emulator.StartProfiling(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting profiler: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if coverageFile != "" {
+		if err := emulator.StartCoverage(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting coverage: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	t := time.Now()
 
-	context, err := emulator.Start()
+	var context *common.StepContext
+	var result core.RunResult
+
+	if headless {
+		context, result, err = emulator.RunHeadless(stopConditions)
+	} else {
+		context, err = emulator.Start()
+	}
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running application: %v\n", err)
 		os.Exit(1)
 	}
 
+	if err := emulator.StopTrace(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing trace file: %v\n", err)
+	}
+
+	if err := emulator.StopWaveform(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing waveform file: %v\n", err)
+	}
+
+	if err := emulator.StopRecording(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing input recording file: %v\n", err)
+	}
+
+	if err := emulator.GetLastError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error while emulating: %v\n", err)
+	}
+
+	if profileFile != "" {
+		if err := emulator.ExportProfile(profileFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing profile file: %v\n", err)
+		}
+	}
+
+	if coverageFile != "" {
+		if err := emulator.ExportCoverage(coverageFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing coverage file: %v\n", err)
+		}
+	}
+
+	select {
+	case err := <-loadStateErr:
+		fmt.Fprintf(os.Stderr, "Error loading state file: %v\n", err)
+		os.Exit(1)
+	default:
+	}
+
+	if headless {
+		code, err := writeHeadlessReport(os.Stdout, context, result, processor, memory, reportRanges, exitCode)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing headless report: %v\n", err)
+			os.Exit(1)
+		}
+
+		os.Exit(code)
+	}
+
 	// Print statistics
 	elapsed := time.Since(t)
 	total := (float64(context.Cycle) / elapsed.Seconds()) / 1_000_000
@@ -180,6 +516,156 @@ func runEmulator(cmd *cobra.Command, args []string) {
 	fmt.Printf("Computer ran at %v MHz\n", total)
 }
 
+// loadProgram loads a program file in the memory of the computer.
+//
+// Parameters:
+//   - loader: The computer where the program is loaded
+//   - symbols: The symbols used to resolve the address, nil if not loaded
+//   - spec: The program file, optionally followed by @ and the address where it's loaded
+//
+// Returns:
+//   - An error if the address is not valid or the program can't be loaded
+func loadProgram(loader core.ProgramLoadable, symbols core.SymbolTable, spec string) error {
+	path, address, err := parseLoadSpec(symbols, spec)
+	if err != nil {
+		return err
+	}
+
+	program, err := managers.LoadProgram(path, address)
+	if err != nil {
+		return err
+	}
+
+	if err := loader.LoadProgram(program); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseLoadSpec splits the FILE[@ADDRESS] specification of --load into the path and the
+// address, which is nil if not specified.
+func parseLoadSpec(symbols core.SymbolTable, spec string) (string, *uint16, error) {
+	index := strings.LastIndex(spec, "@")
+	if index < 0 {
+		return spec, nil, nil
+	}
+
+	address, err := parseAddress(symbols, spec[index+1:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return spec[:index], &address, nil
+}
+
+// parseButtons parses the specifications of the --button flags, each key can only press one
+// button.
+func parseButtons(specs []string) ([]components.PanelButton, error) {
+	buttons := make([]components.PanelButton, 0, len(specs))
+	keys := make(map[rune]bool)
+
+	for _, spec := range specs {
+		button, err := panel.ParseButton(spec)
+		if err != nil {
+			return nil, err
+		}
+
+		if keys[button.Key] {
+			return nil, fmt.Errorf("the key %q is used by more than one button", button.Key)
+		}
+
+		keys[button.Key] = true
+		buttons = append(buttons, button)
+	}
+
+	return buttons, nil
+}
+
+// checkWatchOptions returns an error if the files can't be watched with the selected options.
+func checkWatchOptions(loader core.ProgramLoadable) error {
+	switch {
+	case headless:
+		return errors.New("--watch is not available in headless mode")
+	case persistRom:
+		return errors.New("--watch can't be used with --persist-rom, the writes to the ROM would reload it")
+	case loader == nil:
+		return fmt.Errorf("the programs of the %s model can't be reloaded", model)
+	case model != beneaterModel && len(loadFiles) == 0:
+		return fmt.Errorf("--watch requires --load on the %s model", model)
+	}
+
+	return nil
+}
+
+// watchPrograms makes the emulator reload the ROM, if loadRom is not nil, and the --load
+// programs when any of their files changes. The ROM is loaded first, so the programs loaded
+// over it are kept.
+func watchPrograms(emulator core.BaseEmulator, loadRom func() error, loader core.ProgramLoadable, symbols core.SymbolTable) error {
+	var paths []string
+	if loadRom != nil {
+		paths = append(paths, romFile)
+	}
+
+	for _, spec := range loadFiles {
+		path, _, err := parseLoadSpec(symbols, spec)
+		if err != nil {
+			return err
+		}
+
+		paths = append(paths, path)
+	}
+
+	return emulator.WatchPrograms(paths, func() error {
+		if loadRom != nil {
+			if err := loadRom(); err != nil {
+				return err
+			}
+		}
+
+		for _, spec := range loadFiles {
+			if err := loadProgram(loader, symbols, spec); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// checkStateFile loads the state file into the computer.
+func checkStateFile(computer core.Snapshotable, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return computer.LoadState(bufio.NewReader(file))
+}
+
+// runCpuVerification checks the undefined opcodes of the selected processor against the real
+// hardware, prints the differences and exits with an error status if any is found.
+func runCpuVerification() {
+	report, err := cpu.VerifyUndefinedOpCodes(cpuName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error verifying processor: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, mismatch := range report.Mismatches {
+		fmt.Println(mismatch)
+	}
+
+	if !report.Passed() {
+		fmt.Fprintf(os.Stderr, "%d differences found in %d undefined opcodes of the %s\n", len(report.Mismatches), report.Verified, cpuName)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%d undefined opcodes of the %s verified\n", report.Verified, cpuName)
+	os.Exit(0)
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// memoryRange is a range of addresses included in the headless report, both ends included.
+type memoryRange struct {
+	start uint16
+	end   uint16
+}
+
+// headlessReport is written as JSON when a headless run ends.
+type headlessReport struct {
+	Reason    core.StopReason   `json:"reason"`
+	Address   *uint16           `json:"address,omitempty"`
+	Value     *uint8            `json:"value,omitempty"`
+	Cycles    uint64            `json:"cycles"`
+	Registers headlessRegisters `json:"registers"`
+	Memory    []headlessMemory  `json:"memory"`
+	ExitCode  int               `json:"exitCode"`
+}
+
+// headlessRegisters are the registers of the processor when the run ended.
+type headlessRegisters struct {
+	A  uint8  `json:"a"`
+	X  uint8  `json:"x"`
+	Y  uint8  `json:"y"`
+	SP uint8  `json:"sp"`
+	P  uint8  `json:"p"`
+	PC uint16 `json:"pc"`
+}
+
+// headlessMemory is the content of one of the memory ranges requested, as hexadecimal bytes.
+type headlessMemory struct {
+	Start uint16 `json:"start"`
+	End   uint16 `json:"end"`
+	Data  string `json:"data"`
+}
+
+// fastWriter is implemented by the computers whose fast mode writes to memory without going
+// through the bus.
+type fastWriter interface {
+	WritesBypassBus(address uint16) bool
+}
+
+// parseStopConditions builds the conditions of the headless run from the command line flags.
+// Addresses can be labels of the symbol table or hexadecimal values with an optional "$".
+// The fast mode executes most instructions without fetching their opcodes through the bus,
+// so the conditions on opcodes are not allowed with it. The writes are checked once the
+// computer is created, with checkFastStopWrites.
+func parseStopConditions(symbols core.SymbolTable) (core.StopConditions, error) {
+	if fastMode && (len(stopAddresses) > 0 || stopOnBreak || stopOnStop) {
+		return core.StopConditions{}, errors.New("--stop-at, --stop-on-brk and --stop-on-stp are not available with --fast")
+	}
+
+	conditions := core.StopConditions{
+		MaxCycles: maxCycles,
+		OnBreak:   stopOnBreak,
+		OnStop:    stopOnStop,
+	}
+
+	for _, text := range stopAddresses {
+		address, err := parseAddress(symbols, text)
+		if err != nil {
+			return conditions, err
+		}
+
+		conditions.Addresses = append(conditions.Addresses, address)
+	}
+
+	for _, text := range stopWriteAddresses {
+		address, err := parseAddress(symbols, text)
+		if err != nil {
+			return conditions, err
+		}
+
+		conditions.WriteAddresses = append(conditions.WriteAddresses, address)
+	}
+
+	if conditions.MaxCycles == 0 && !conditions.OnBreak && !conditions.OnStop &&
+		len(conditions.Addresses) == 0 && len(conditions.WriteAddresses) == 0 {
+		return conditions, errors.New("headless mode requires at least one stop condition")
+	}
+
+	return conditions, nil
+}
+
+// checkFastStopWrites returns an error if in fast mode the computer writes any of the stop
+// addresses directly on its memory, as the write is not seen on the bus and the run would
+// never stop on it.
+func checkFastStopWrites(computer any, conditions core.StopConditions) error {
+	writer, ok := computer.(fastWriter)
+	if !ok {
+		return nil
+	}
+
+	for _, address := range conditions.WriteAddresses {
+		if writer.WritesBypassBus(address) {
+			return fmt.Errorf("--stop-on-write $%04X is not available with --fast, its writes don't go through the bus", address)
+		}
+	}
+
+	return nil
+}
+
+// parseMemoryRanges parses the memory ranges of the report, in START-END format.
+func parseMemoryRanges(symbols core.SymbolTable, values []string) ([]memoryRange, error) {
+	var ranges []memoryRange
+
+	for _, text := range values {
+		startText, endText, ok := strings.Cut(text, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid memory range %q, expected START-END", text)
+		}
+
+		start, err := parseAddress(symbols, startText)
+		if err != nil {
+			return nil, err
+		}
+
+		end, err := parseAddress(symbols, endText)
+		if err != nil {
+			return nil, err
+		}
+
+		if end < start {
+			return nil, fmt.Errorf("invalid memory range %q, the end is before the start", text)
+		}
+
+		ranges = append(ranges, memoryRange{start: start, end: end})
+	}
+
+	return ranges, nil
+}
+
+// parseAddress converts a label of the symbol table, or an hexadecimal value with an optional
+// "$" prefix, to an address.
+func parseAddress(symbols core.SymbolTable, text string) (uint16, error) {
+	text = strings.TrimSpace(text)
+
+	if symbols != nil {
+		if address, ok := symbols.GetAddress(text); ok {
+			return address, nil
+		}
+	}
+
+	value, err := strconv.ParseUint(strings.TrimPrefix(text, "$"), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", text)
+	}
+
+	return uint16(value), nil
+}
+
+// writeHeadlessReport writes the result of the run, the registers of the processor and the
+// memory ranges as JSON. The exit code is read from the exit code address, if any.
+//
+// Returns:
+//   - The exit code of the run
+//   - An error if the report can't be written
+func writeHeadlessReport(writer io.Writer, context *common.StepContext, result core.RunResult, processor components.Cpu65C02,
+	memory core.MemoryPeeker, ranges []memoryRange, exitCodeAddress *uint16) (int, error) {
+
+	report := headlessReport{
+		Reason: result.Reason,
+		Cycles: context.Cycle,
+		Registers: headlessRegisters{
+			A:  processor.GetAccumulatorRegister(),
+			X:  processor.GetXRegister(),
+			Y:  processor.GetYRegister(),
+			SP: processor.GetStackPointer(),
+			P:  processor.GetProcessorStatusRegister().GetValue(),
+			PC: processor.GetProgramCounter(),
+		},
+		Memory: []headlessMemory{},
+	}
+
+	if result.Reason != core.StopMaxCycles {
+		report.Address = &result.Address
+	}
+
+	if result.Reason == core.StopWrite {
+		report.Value = &result.Value
+	}
+
+	for _, r := range ranges {
+		data := make([]byte, 0, int(r.end-r.start)+1)
+		for address := int(r.start); address <= int(r.end); address++ {
+			data = append(data, memory.PeekMemory(uint16(address)))
+		}
+
+		report.Memory = append(report.Memory, headlessMemory{
+			Start: r.start,
+			End:   r.end,
+			Data:  strings.ToUpper(hex.EncodeToString(data)),
+		})
+	}
+
+	if exitCodeAddress != nil {
+		report.ExitCode = int(memory.PeekMemory(*exitCodeAddress))
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return report.ExitCode, encoder.Encode(&report)
+}
@@ -13,6 +13,11 @@ Usage:
 	context := NewStepContext()
 	// Pass to component Tick functions
 	component.Tick(&context)
+
+By default the time of the context is taken from the wall clock. A context created with
+NewVirtualStepContext derives the time from the cycle count and the frequency of the clock
+instead, so the components that depend on time behave the same way on every run, no matter
+how fast the emulation is executed.
 */
 package common
 
@@ -35,7 +40,19 @@ type StepContext struct {
 	// This is used for timing and synchronization purposes.
 	// Components can store previous cycle T and compare it with the current one
 	// to calculate the time passed between both cycles.
+	// In virtual time it is the time the cycles executed take at the frequency of the clock.
 	T int64
+
+	// WallT represents the wall clock time in nanoseconds since the emulation started. It is
+	// always real time, even in virtual time, and it is used to control the speed of the
+	// emulation and measure it. Emulated components must use T.
+	WallT int64
+
+	// offset is added to the elapsed time to calculate T, it allows moving the time forward
+	offset int64
+
+	// frequency of the clock in Hz used to calculate T from the cycles, 0 uses the wall clock
+	frequency uint64
 }
 
 // beginning stores the timestamp when the emulation started.
@@ -52,11 +69,36 @@ func NewStepContext() StepContext {
 	}
 }
 
+// NewVirtualStepContext creates a StepContext where the time is derived from the cycle count
+// and the frequency of the clock instead of the wall clock. Runs with the same inputs produce
+// the same results, regardless of the speed of the emulation.
+//
+// Parameters:
+//   - frequency: The frequency of the clock in Hz, 0 uses the wall clock
+//
+// Returns:
+//   - A StepContext with the time starting at 0
+func NewVirtualStepContext(frequency uint64) StepContext {
+	context := NewStepContext()
+	context.frequency = frequency
+
+	return context
+}
+
+// IsVirtualTime returns true if the time is derived from the cycle count.
+func (context *StepContext) IsVirtualTime() bool {
+	return context.frequency > 0
+}
+
 // SkipCycle updates the timing information without incrementing the cycle counter.
 // This is used by the emulation when skipping emulation cycles. It shouldn't be called by any components
 // as it is used directly by the EmulationLoop in the computers package.
 func (context *StepContext) SkipCycle() {
-	context.T = now()
+	context.WallT = now()
+
+	if !context.IsVirtualTime() {
+		context.T = context.WallT + context.offset
+	}
 }
 
 // NextCycle advances the emulation by one cycle and updates the timing information.
@@ -64,10 +106,48 @@ func (context *StepContext) SkipCycle() {
 // as it is used directly by the EmulationLoop in the computers package.
 func (context *StepContext) NextCycle() {
 	context.Cycle++
-	context.T = now()
+	context.WallT = now()
+
+	if context.IsVirtualTime() {
+		context.T = context.virtualTime() + context.offset
+	} else {
+		context.T = context.WallT + context.offset
+	}
+
 	context.CycleT = context.T
 }
 
+// AdvanceTime moves the time of the context forward to the specified time, the following
+// cycles continue counting the time from it. This is used to continue the emulation of a
+// saved state, where the components keep times taken from the context when it was saved.
+// If the specified time is not after the current time, this method has no effect.
+// In virtual time the current time is calculated from the cycle count, which might have
+// been changed, for example, when the state is restored.
+//
+// Parameters:
+//   - t: The time in nanoseconds to move forward to
+func (context *StepContext) AdvanceTime(t int64) {
+	if context.IsVirtualTime() {
+		context.T = context.virtualTime() + context.offset
+		context.CycleT = context.T
+	}
+
+	if t > context.T {
+		context.offset += t - context.T
+		context.T = t
+		context.CycleT = t
+	}
+}
+
+// virtualTime returns the nanoseconds taken by the cycles executed at the frequency of the clock.
+// The whole seconds are calculated apart to avoid overflows on long runs.
+func (context *StepContext) virtualTime() int64 {
+	seconds := context.Cycle / context.frequency
+	remainder := context.Cycle % context.frequency
+
+	return int64(seconds*uint64(time.Second) + remainder*uint64(time.Second)/context.frequency)
+}
+
 // now returns the number of nanoseconds that have elapsed since the emulation started.
 // It is used internally to maintain accurate timing information.
 func now() int64 {
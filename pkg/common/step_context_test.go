@@ -74,3 +74,82 @@ func TestStepContext_TimeProgression(t *testing.T) {
 		}
 	}
 }
+
+func TestStepContext_AdvanceTime(t *testing.T) {
+	ctx := NewStepContext()
+	ctx.NextCycle()
+
+	target := ctx.T + int64(time.Hour)
+	ctx.AdvanceTime(target)
+
+	if ctx.T != target || ctx.CycleT != target {
+		t.Errorf("Expected T and CycleT to be %d, got %d and %d", target, ctx.T, ctx.CycleT)
+	}
+
+	ctx.NextCycle()
+
+	if ctx.T <= target || ctx.T > target+int64(time.Minute) {
+		t.Errorf("Expected T to continue from %d, got %d", target, ctx.T)
+	}
+
+	// Time never goes backwards
+	current := ctx.T
+	ctx.AdvanceTime(0)
+
+	if ctx.T != current {
+		t.Errorf("Expected T to remain %d, got %d", current, ctx.T)
+	}
+}
+
+func TestStepContext_VirtualTime(t *testing.T) {
+	ctx := NewVirtualStepContext(1_000_000)
+
+	if !ctx.IsVirtualTime() {
+		t.Errorf("Expected the context to use virtual time")
+	}
+
+	ctx.NextCycle()
+	time.Sleep(time.Millisecond)
+	ctx.SkipCycle()
+	ctx.NextCycle()
+
+	// At 1 MHz each cycle takes 1 microsecond, the time between cycles is not counted
+	if ctx.T != 2*int64(time.Microsecond) || ctx.CycleT != ctx.T {
+		t.Errorf("Expected T and CycleT to be 2us, got %d and %d", ctx.T, ctx.CycleT)
+	}
+
+	if ctx.WallT < int64(time.Millisecond) {
+		t.Errorf("Expected WallT to follow the wall clock, got %d", ctx.WallT)
+	}
+
+	target := ctx.T + int64(time.Second)
+	ctx.AdvanceTime(target)
+	ctx.NextCycle()
+
+	if ctx.T != target+int64(time.Microsecond) {
+		t.Errorf("Expected T to continue from %d, got %d", target, ctx.T)
+	}
+
+	// Restoring a state sets the cycle and moves the time to the one saved
+	ctx.Cycle = 1
+	ctx.AdvanceTime(target)
+	ctx.NextCycle()
+
+	if ctx.T != target+int64(time.Microsecond) {
+		t.Errorf("Expected T to continue from %d, got %d", target, ctx.T)
+	}
+}
+
+func TestStepContext_VirtualTimeLongRuns(t *testing.T) {
+	ctx := NewVirtualStepContext(3_000_000)
+	ctx.Cycle = 1 << 45
+
+	ctx.NextCycle()
+
+	// Multiplying 2^45 + 1 cycles by the nanoseconds of a second overflows an uint64
+	seconds, remainder := int64((1<<45+1)/3_000_000), int64((1<<45+1)%3_000_000)
+	expected := seconds*int64(time.Second) + remainder*int64(time.Second)/3_000_000
+	if ctx.T != expected {
+		t.Errorf("Expected T to be %d, got %d", expected, ctx.T)
+	}
+}
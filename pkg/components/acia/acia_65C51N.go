@@ -121,6 +121,10 @@ type acia65C51N struct {
 	pollersStarted atomic.Bool
 	txNotify       chan struct{}
 
+	// When set, the bytes read from the port are passed to the handler instead of being
+	// received, so they can be received later at a known cycle with ReceiveByte
+	receiveHandler func(value uint8)
+
 	emulateModemLines bool
 }
 
@@ -208,6 +212,25 @@ func (acia *acia65C51N) Close() {
 	acia.wg.Wait()
 }
 
+// SetReceiveHandler routes the bytes read from the serial port to the handler instead of the
+// receive register, nil receives them as soon as they are read. The handler is called from the
+// goroutine that reads the port.
+func (acia *acia65C51N) SetReceiveHandler(handler func(value uint8)) {
+	acia.stateMu.Lock()
+	defer acia.stateMu.Unlock()
+
+	acia.receiveHandler = handler
+}
+
+// ReceiveByte puts the byte in the receive register as if it was read from the serial port,
+// setting the overrun flag if the previous byte wasn't read and echoing it if enabled.
+func (acia *acia65C51N) ReceiveByte(value uint8) {
+	acia.stateMu.Lock()
+	defer acia.stateMu.Unlock()
+
+	acia.receive(value)
+}
+
 func (acia *acia65C51N) startPollers() {
 	if !acia.pollersStarted.CompareAndSwap(false, true) {
 		return
@@ -648,6 +648,53 @@ func TestReadFromRxOverrunning(t *testing.T) {
 	})
 }
 
+// Intercepts the bytes sent by the terminal and receives them later, as done when recording the input
+func TestReceiveHandlerInterceptsBytes(t *testing.T) {
+	var step common.StepContext
+
+	acia, circuit, mock := newTestCircuit()
+	defer acia.Close()
+	defer mock.Close()
+
+	if err := circuit.wire(acia, mock); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan uint8, 1)
+	acia.SetReceiveHandler(func(value uint8) {
+		received <- value
+	})
+
+	mock.TerminalSend([]byte("A"))
+
+	select {
+	case value := <-received:
+		assert.Equal(t, uint8('A'), value)
+	case <-time.After(time.Second):
+		t.Fatal("the byte was not passed to the handler")
+	}
+
+	// The byte is not in the receive register until it's received
+	assert.True(t, acia.IsRXRegisterEmpty())
+
+	acia.ReceiveByte('A')
+	status := readFromAcia(acia, circuit, 0x01, &step)
+	assert.Equal(t, statusRDRF, status&statusRDRF)
+	assert.Equal(t, uint8('A'), readFromAcia(acia, circuit, 0x00, &step))
+}
+
+// Receiving a byte before the previous one is read sets the overrun flag
+func TestReceiveByteOverrunning(t *testing.T) {
+	acia, _, _ := newTestCircuit()
+
+	acia.ReceiveByte('A')
+	assert.Zero(t, acia.GetStatusRegister()&statusOverrun)
+
+	acia.ReceiveByte('B')
+	assert.Equal(t, statusOverrun, acia.GetStatusRegister()&statusOverrun)
+	assert.Equal(t, uint8('B'), acia.GetRXRegister())
+}
+
 // Test reading from status register RS = 0x01 (RS1 = L, RS1 = H)
 func TestReadFromStatusRegister(t *testing.T) {
 	var step common.StepContext
@@ -48,9 +48,8 @@ func (acia *acia65C51N) writeBytes() {
 // readBytes is a goroutine that handles the reception of bytes through the ACIA.
 // It continuously reads from the serial port and processes incoming data as follows:
 // - Reads one byte at a time from the configured port
-// - If the receive register is not empty when new data arrives, sets the overrun flag
-// - Stores the received byte in the receive register
-// - If echo mode is enabled, copies the received byte to the transmit register
+// - If a receive handler is set, passes the byte to it
+// - Otherwise receives the byte as described in receive
 // The goroutine runs until acia.running is set to false.
 //
 // The function uses mutexes to ensure thread-safe access to shared registers:
@@ -70,25 +69,41 @@ func (acia *acia65C51N) readBytes() {
 
 			if n > 0 {
 				acia.stateMu.Lock()
+				handler := acia.receiveHandler
 
-				if !acia.rxRegisterEmpty {
-					acia.statusRegister |= statusOverrun
+				if handler == nil {
+					acia.receive(uint8(buff[0]))
 				}
 
-				acia.rxRegisterEmpty = false
-				acia.rxRegister = uint8(buff[0])
-				rxRegister := acia.rxRegister
+				acia.stateMu.Unlock()
 
-				if acia.isReceiverEchoModeEnabled() {
-					acia.txRegister = rxRegister
-					acia.txRegisterEmpty = false
-					acia.notifyTX()
+				if handler != nil {
+					handler(uint8(buff[0]))
 				}
-
-				acia.stateMu.Unlock()
 			}
 		} else {
 			time.Sleep(time.Millisecond)
 		}
 	}
 }
+
+// receive processes a byte that arrived from the serial port:
+// - If the receive register is not empty when new data arrives, sets the overrun flag
+// - Stores the received byte in the receive register
+// - If echo mode is enabled, copies the received byte to the transmit register
+//
+// The caller must hold stateMu.
+func (acia *acia65C51N) receive(value uint8) {
+	if !acia.rxRegisterEmpty {
+		acia.statusRegister |= statusOverrun
+	}
+
+	acia.rxRegisterEmpty = false
+	acia.rxRegister = value
+
+	if acia.isReceiverEchoModeEnabled() {
+		acia.txRegister = value
+		acia.txRegisterEmpty = false
+		acia.notifyTX()
+	}
+}
@@ -0,0 +1,78 @@
+package acia
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Values of the ACIA registers. This struct is written and read with encoding/binary,
+// so it must only have fixed size fields.
+type aciaState struct {
+	StatusRegister  uint8
+	ControlRegister uint8
+	CommandRegister uint8
+
+	TXRegisterEmpty bool
+	RXRegisterEmpty bool
+
+	TXRegister uint8
+	RXRegister uint8
+}
+
+// SaveState writes the registers of the ACIA. The connection to the serial port is not
+// part of the state.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (acia *acia65C51N) SaveState(writer io.Writer) error {
+	acia.lockState()
+
+	state := aciaState{
+		StatusRegister:  acia.statusRegister,
+		ControlRegister: acia.controlRegister,
+		CommandRegister: acia.commandRegister,
+
+		TXRegisterEmpty: acia.txRegisterEmpty,
+		RXRegisterEmpty: acia.rxRegisterEmpty,
+
+		TXRegister: acia.txRegister,
+		RXRegister: acia.rxRegister,
+	}
+
+	acia.unlockState()
+
+	return binary.Write(writer, binary.LittleEndian, &state)
+}
+
+// LoadState restores the registers of the ACIA from a state previously written by SaveState.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read
+func (acia *acia65C51N) LoadState(reader io.Reader) error {
+	var state aciaState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	acia.lockState()
+	defer acia.unlockState()
+
+	acia.statusRegister = state.StatusRegister
+	acia.controlRegister = state.ControlRegister
+	acia.commandRegister = state.CommandRegister
+
+	acia.txRegisterEmpty = state.TXRegisterEmpty
+	acia.rxRegisterEmpty = state.RXRegisterEmpty
+
+	acia.txRegister = state.TXRegister
+	acia.rxRegister = state.RXRegister
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package components
 
 import (
+	"fmt"
+
+	"github.com/fran150/clementina-6502/pkg/common"
 	"github.com/fran150/clementina-6502/pkg/components/buses"
 	"github.com/fran150/clementina-6502/pkg/core"
 	"go.bug.st/serial"
@@ -65,6 +68,13 @@ type SerialPortConnectable interface {
 	Close()
 }
 
+// SerialReceivable defines components whose bytes received from a serial port can be
+// intercepted and received later
+type SerialReceivable interface {
+	SetReceiveHandler(handler func(value uint8))
+	ReceiveByte(value uint8)
+}
+
 // AciaRegisters defines ACIA register access methods
 type AciaRegisters interface {
 	GetStatusRegister() uint8
@@ -85,6 +95,7 @@ type Acia65C51 interface {
 	InterruptCapable
 	RegisterSelectable
 	SerialPortConnectable
+	SerialReceivable
 	AciaRegisters
 
 	// ACIA-specific methods
@@ -132,6 +143,7 @@ type StatusBit uint8
 // It provides methods to check the state of individual status bits in the processor status register.
 type StatusRegister interface {
 	Flag(bit StatusBit) bool
+	GetValue() uint8
 }
 
 // CpuControlLines defines CPU control signal interfaces
@@ -178,6 +190,57 @@ type Cpu65C02 interface {
 	CpuState
 }
 
+// Cpu65C816ControlLines defines the control signals of the 65C816S. It has no SYNC and SOB pins,
+// instead VDA and VPA signal the type of each cycle and the E and MX pins show the processor mode.
+// The bank address is multiplexed on the data bus while PHI2 is low.
+type Cpu65C816ControlLines interface {
+	Abort() *buses.ConnectorEnabledLow
+	BankAddress() *buses.BusConnector[uint8]
+	BusEnable() *buses.ConnectorEnabledHigh
+	Emulation() *buses.ConnectorEnabledHigh
+	InterruptRequest() *buses.ConnectorEnabledLow
+	MemoryIndexSelect() *buses.ConnectorEnabledHigh
+	MemoryLock() *buses.ConnectorEnabledLow
+	NonMaskableInterrupt() *buses.ConnectorEnabledLow
+	Ready() *buses.ConnectorEnabledHigh
+	ValidDataAddress() *buses.ConnectorEnabledHigh
+	ValidProgramAddress() *buses.ConnectorEnabledHigh
+	VectorPull() *buses.ConnectorEnabledLow
+}
+
+// Cpu65C816Registers defines access to the full value of the registers of the 65C816S. The methods
+// of CpuRegisters return the low byte of the 16 bit registers.
+type Cpu65C816Registers interface {
+	GetAccumulatorRegister16() uint16
+	GetXRegister16() uint16
+	GetYRegister16() uint16
+	GetStackPointer16() uint16
+	GetDirectRegister() uint16
+	GetDataBankRegister() uint8
+	GetProgramBankRegister() uint8
+	IsEmulationMode() bool
+}
+
+// Cpu65C816 defines the interface for the W65C816S CPU emulation.
+// It provides access to all CPU pins, internal registers, and execution control.
+type Cpu65C816 interface {
+	AddressBusConnected
+	DataBusConnected
+	ReadWriteControlled
+	Resettable
+	core.Ticker
+	core.PostTicker
+	Cpu65C816ControlLines
+	CpuRegisters
+	Cpu65C816Registers
+	CpuState
+
+	// TickPhi2Low executes the first half of the cycle, putting the bank of the address on the
+	// data bus. Computers that latch the bank call it before ticking the latch and the
+	// components, otherwise Tick calls it.
+	TickPhi2Low(context *common.StepContext)
+}
+
 // Returns the data needed to display the cursor on the LCD
 type CursorStatus struct {
 	CursorVisible      bool
@@ -224,6 +287,7 @@ type MemoryAccess interface {
 	Poke(address uint16, value uint8)
 	Load(binFilePath string) error
 	Size() int
+	Contents() []uint8
 }
 
 // MemoryControlLines defines memory control signal interfaces
@@ -246,6 +310,90 @@ type Memory interface {
 	HiAddressBus() *buses.BusConnector[uint16]
 }
 
+// EEPROM defines the interface for the memories that can be written in-circuit. Writes take
+// time to complete and can be persisted to the file of the image loaded.
+type EEPROM interface {
+	Memory
+
+	IsBusy() bool
+	IsProtected() bool
+	PersistTo(path string) error
+	GetPersistError() error
+	Close() error
+}
+
+// PS2Keyboard defines the interface for a PS/2 keyboard attached through a serial to parallel
+// interface, as in Ben Eater's keyboard circuit. Each scan code sent by the keyboard is presented
+// on the data bus and signaled with a pulse on the interrupt line.
+type PS2Keyboard interface {
+	core.Ticker
+
+	DataBus() *buses.BusConnector[uint8]
+	Interrupt() *buses.ConnectorEnabledHigh
+
+	IsBusy() bool
+	SetKeyHandler(handler func(codes []uint8))
+	TypeScanCodes(codes []uint8)
+	SendScanCodes(codes []uint8)
+}
+
+// PanelPin identifies a pin of the ports or a control line of the VIA an I/O panel is attached to.
+type PanelPin uint8
+
+// Pins of the VIA, the pins of each port are numbered from the first one
+const (
+	PanelPortA    PanelPin = 0  // PA0, PA1 is PanelPortA + 1 and so on
+	PanelPortB    PanelPin = 8  // PB0, PB1 is PanelPortB + 1 and so on
+	PanelCA1      PanelPin = 16 // Control line CA1
+	PanelCA2      PanelPin = 17 // Control line CA2
+	PanelCB1      PanelPin = 18 // Control line CB1
+	PanelCB2      PanelPin = 19 // Control line CB2
+	PanelPinCount int      = 20
+)
+
+// String returns the name of the pin as in the datasheet, e.g. PA0 or CB2.
+func (pin PanelPin) String() string {
+	switch {
+	case pin < PanelPortB:
+		return fmt.Sprintf("PA%d", pin-PanelPortA)
+	case pin < PanelCA1:
+		return fmt.Sprintf("PB%d", pin-PanelPortB)
+	case int(pin) < PanelPinCount:
+		return [...]string{"CA1", "CA2", "CB1", "CB2"}[pin-PanelCA1]
+	default:
+		return fmt.Sprintf("pin %d", pin)
+	}
+}
+
+// PanelButton defines a button of an I/O panel, the key of the host that presses it and the pin
+// of the VIA it's connected to.
+type PanelButton struct {
+	Key        rune     // Key of the host that presses the button
+	Pin        PanelPin // Pin of the VIA connected to the button
+	Toggle     bool     // The button stays pressed until the key is pressed again
+	ActiveHigh bool     // Pressing drives the pin high, otherwise the pin is pulled up and pressing drives it low
+}
+
+// PanelPinStatus defines the status of a pin of the VIA shown by an I/O panel.
+type PanelPinStatus struct {
+	Connected bool // The pin is connected to the circuit
+	Output    bool // The pin is driven by the VIA, the panel shows its level with a LED
+	Level     bool // The pin is high
+}
+
+// IOPanel defines the interface for a panel of LEDs and push buttons attached to the ports and
+// control lines of a VIA.
+type IOPanel interface {
+	core.Ticker
+	Buttons() []PanelButton
+	IsPressed(button int) bool
+	IsBusy() bool
+	GetPinStatus(pin PanelPin) PanelPinStatus
+	SetPressHandler(handler func(buttons []uint8))
+	PressKey(key rune)
+	PressButtons(buttons []uint8)
+}
+
 // ViaPeripheralPorts defines VIA peripheral port access
 type ViaPeripheralPorts interface {
 	PeripheralPortA() *buses.BusConnector[uint8]
@@ -275,6 +423,13 @@ type ViaRegisters interface {
 	GetInterruptEnabledFlag() uint8
 }
 
+// ViaPorts defines the access to the ports and control lines of a VIA and to the registers
+// that set the direction of their pins, used by the devices attached to the ports.
+type ViaPorts interface {
+	ViaPeripheralPorts
+	ViaRegisters
+}
+
 // Via65C22 defines the interface for the 65C22S Versatile Interface Adapter.
 // This chip provides parallel I/O ports, timers, and shift register functionality.
 type Via65C22 interface {
@@ -44,6 +44,36 @@ const (
 	AddressModeIRQ
 	AddressModeNMI
 	AddressModeReset
+
+	// Address modes only used by the illegal opcodes of the NMOS 6502. These are added at the
+	// end to keep the values of the previous modes, which are stored in saved states.
+	AddressModeAbsoluteYRMW
+	AddressModeZeroPageIndexedIndirectXRMW
+	AddressModeZeroPageIndirectIndexedYRMW
+
+	// Address modes only used by the undefined opcodes of the 65C02S
+	AddressModeSingleCycle
+	AddressModeAbsoluteNOP
+
+	// Address modes only used by the 65C816S. The 65C816S also uses the previous modes, where zero
+	// page is the direct page, without the RMW and W variants.
+	AddressModeAbsoluteLong
+	AddressModeAbsoluteLongX
+	AddressModeAbsoluteLongJump
+	AddressModeAbsoluteIndirectLong
+	AddressModeJumpToSubroutineLong
+	AddressModeJumpToSubroutineIndexedIndirect
+	AddressModeReturnFromSubroutineLong
+	AddressModeDirectIndirectLong
+	AddressModeDirectIndirectLongY
+	AddressModeStackRelative
+	AddressModeStackRelativeIndirectY
+	AddressModeRelativeLong
+	AddressModeBlockMove
+	AddressModePushEffectiveAbsolute
+	AddressModePushEffectiveIndirect
+	AddressModePushEffectiveRelative
+	AddressModeAbort
 )
 
 // ----------------------------------------------------------------------
@@ -132,7 +162,7 @@ func (data *AddressModeData) MemSize() uint8 {
 // AddressModeSet contains the complete set of address modes supported by the processor.
 // It provides lookup capabilities to find address mode data by name.
 type AddressModeSet struct {
-	nameIndex [40]*AddressModeData
+	nameIndex [AddressModeAbsoluteNOP + 1]*AddressModeData
 }
 
 // GetByName retrieves address mode data for a specific mode by its name.
@@ -144,7 +174,7 @@ func (addressModeSet *AddressModeSet) GetByName(name components.AddressMode) *Ad
 // This includes standard 6502 modes, RMW variants, and special modes for interrupts and stack operations.
 func newAddressModesSet() *AddressModeSet {
 	addressModeSet := AddressModeSet{
-		nameIndex: [40]*AddressModeData{},
+		nameIndex: [AddressModeAbsoluteNOP + 1]*AddressModeData{},
 	}
 
 	data := []AddressModeData{
@@ -195,6 +225,17 @@ func newAddressModesSet() *AddressModeSet {
 		// See discussions about BBR and BBS correct timing here:
 		// https://www.reddit.com/r/beneater/comments/1cac3ly/clarification_of_65c02_instruction_execution_times/
 		{AddressModeRelativeExtended, "zp, r", "$%02x, $%02X", addressModeRelativeExtendedActions, 3},
+
+		// RMW versions of indexed modes only used by the illegal opcodes of the NMOS 6502. In the
+		// 65C02S set they follow the same pattern of the other RMW modes.
+		{AddressModeAbsoluteYRMW, "a,y", "$%04X, Y", addressModeAbsoluteYRMWActions, 3},
+		{AddressModeZeroPageIndexedIndirectXRMW, "(zp,x)", "($%02X, X)", addressModeZeroPageIndexedIndirectXRMWActions, 2},
+		{AddressModeZeroPageIndirectIndexedYRMW, "(zp),y", "($%02X), Y", addressModeZeroPageIndirectIndexedYRMWActions, 2},
+
+		// Undefined opcodes of the 65C02S. Most of them are NOPs of 1 byte executed in a single cycle, and
+		// $5C is a 3 bytes NOP that takes 8 cycles.
+		{AddressModeSingleCycle, "i", "", addressModeSingleCycleActions, 1},
+		{AddressModeAbsoluteNOP, "a", "$%04X", addressModeAbsoluteNOPActions, 3},
 	}
 
 	for i := range data {
@@ -204,6 +245,36 @@ func newAddressModesSet() *AddressModeSet {
 	return &addressModeSet
 }
 
+// newNMOSAddressModesSet creates the set of address modes of the NMOS 6502. It has the same modes of the
+// 65C02S set but RMW instructions write the unmodified value instead of doing an extra read, and the indirect
+// mode used by JMP has the page boundary bug. The 65C02S only modes are kept as they are not used by
+// the NMOS instruction set.
+func newNMOSAddressModesSet() *AddressModeSet {
+	addressModeSet := newAddressModesSet()
+
+	rmwModes := []components.AddressMode{
+		AddressModeZeroPageRMW,
+		AddressModeZeroPageXRMW,
+		AddressModeAbsoluteRMW,
+		AddressModeAbsoluteXRMW,
+		AddressModeAbsoluteYRMW,
+		AddressModeZeroPageIndexedIndirectXRMW,
+		AddressModeZeroPageIndirectIndexedYRMW,
+	}
+
+	for _, name := range rmwModes {
+		data := *addressModeSet.nameIndex[name]
+		data.microInstructions = toNMOSRMWActions(data.microInstructions)
+		addressModeSet.nameIndex[name] = &data
+	}
+
+	indirect := *addressModeSet.nameIndex[AddressModeIndirect]
+	indirect.microInstructions = addressModeIndirectNMOSActions
+	addressModeSet.nameIndex[AddressModeIndirect] = &indirect
+
+	return addressModeSet
+}
+
 // GetAddressMode returns details about the specified address mode from the global address mode set.
 // It provides a convenient way to access address mode data without creating a new set.
 func GetAddressMode(name components.AddressMode) *AddressModeData {
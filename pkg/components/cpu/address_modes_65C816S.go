@@ -0,0 +1,1200 @@
+package cpu
+
+import "github.com/fran150/clementina-6502/pkg/components"
+
+// step65C816S is a cycle of the 65C816S. As in the 65C02S, cycle sets the buses and lines on Tick
+// and postCycle reads the result on PostTick. If cycle returns false the step doesn't take a cycle
+// and the processor moves to the next one on the same tick. This is used for the cycles added by
+// the direct register, page boundary crossing or taken branches, and for the steps that only
+// calculate the effective address.
+type step65C816S struct {
+	cycle      func(cpu *cpu65C816S) bool
+	postCycle  func(cpu *cpu65C816S)
+	memoryLock bool
+}
+
+// addressModeData65C816S contains the data of an address mode of the 65C816S. The cycles of each
+// instruction depend on the width of the registers, the direct register and the emulation mode, so
+// instead of a fixed list of cycles each address mode has a function that plans them when the opcode
+// is decoded.
+type addressModeData65C816S struct {
+	name    components.AddressMode
+	text    string
+	format  string
+	cycles  int
+	memSize uint8
+	plan    func(cpu *cpu65C816S, instruction *instructionData65C816S)
+}
+
+// Returns the name of the address mode
+func (data *addressModeData65C816S) Name() components.AddressMode {
+	return data.name
+}
+
+// Returns the typical text abbreviation of the address mode found on manuals or books.
+func (data *addressModeData65C816S) Text() string {
+	return data.text
+}
+
+// Returns an string usable with fmt.Printf function to write the assembler version of the
+// instruction being executed.
+func (data *addressModeData65C816S) Format() string {
+	return data.format
+}
+
+// Returns the number of cycles of the instructions in this address mode with 8 bits registers,
+// the low byte of the direct register set to 0 and no page boundary crossed.
+func (data *addressModeData65C816S) Cycles() int {
+	return data.cycles
+}
+
+// Returns the number of bytes required to read to execute the instruction in this address mode.
+// Immediate mode reads one more byte with 16 bits registers.
+func (data *addressModeData65C816S) MemSize() uint8 {
+	return data.memSize
+}
+
+// ----------------------------------------------------------------------
+
+// addressModeSet65C816S contains the address modes used by the 65C816S.
+type addressModeSet65C816S struct {
+	nameIndex [AddressModeAbort + 1]*addressModeData65C816S
+}
+
+// GetByName retrieves address mode data for a specific mode by its name.
+func (addressModeSet *addressModeSet65C816S) GetByName(name components.AddressMode) *addressModeData65C816S {
+	return addressModeSet.nameIndex[name]
+}
+
+// newAddressModeSet65C816S creates the address modes of the 65C816S. Zero page modes of the 65C02S
+// are the direct page modes. The cycles follow table 5-7 of the W65C816S datasheet.
+func newAddressModeSet65C816S() *addressModeSet65C816S {
+	addressModeSet := addressModeSet65C816S{
+		nameIndex: [AddressModeAbort + 1]*addressModeData65C816S{},
+	}
+
+	data := []addressModeData65C816S{
+		{AddressModeImplicit, "i", "", 2, 1, planImplied},
+		{AddressModeAccumulator, "A", "a", 2, 1, planAccumulator},
+		{AddressModeImmediate, "#", "#$%02X", 2, 2, planImmediate},
+		{AddressModeZeroPage, "d", "$%02X", 3, 2, planDirect},
+		{AddressModeZeroPageX, "d,x", "$%02X, X", 4, 2, planDirectX},
+		{AddressModeZeroPageY, "d,y", "$%02X, Y", 4, 2, planDirectY},
+		{AddressModeAbsolute, "a", "$%04X", 4, 3, planAbsolute},
+		{AddressModeAbsoluteX, "a,x", "$%04X, X", 4, 3, planAbsoluteX},
+		{AddressModeAbsoluteY, "a,y", "$%04X, Y", 4, 3, planAbsoluteY},
+		{AddressModeAbsoluteLong, "al", "$%06X", 5, 4, planAbsoluteLong},
+		{AddressModeAbsoluteLongX, "al,x", "$%06X, X", 5, 4, planAbsoluteLongX},
+		{AddressModeIndirectZeroPage, "(d)", "($%02X)", 5, 2, planDirectIndirect},
+		{AddressModeZeroPageIndexedIndirectX, "(d,x)", "($%02X, X)", 6, 2, planDirectIndexedIndirect},
+		{AddressModeZeroPageIndirectIndexedY, "(d),y", "($%02X), Y", 5, 2, planDirectIndirectIndexed},
+		{AddressModeDirectIndirectLong, "[d]", "[$%02X]", 6, 2, planDirectIndirectLong},
+		{AddressModeDirectIndirectLongY, "[d],y", "[$%02X], Y", 6, 2, planDirectIndirectLongIndexed},
+		{AddressModeStackRelative, "d,s", "$%02X, S", 4, 2, planStackRelative},
+		{AddressModeStackRelativeIndirectY, "(d,s),y", "($%02X, S), Y", 7, 2, planStackRelativeIndirectIndexed},
+		{AddressModeRelative, "r", "$%02X", 2, 2, planRelative},
+		{AddressModeRelativeLong, "rl", "$%04X", 4, 3, planRelativeLong},
+		{AddressModeAbsoluteJump, "a", "$%04X", 3, 3, planAbsoluteJump},
+		{AddressModeAbsoluteLongJump, "al", "$%06X", 4, 4, planAbsoluteLongJump},
+		{AddressModeIndirect, "(a)", "($%04X)", 5, 3, planAbsoluteIndirect},
+		{AddressModeAbsoluteIndexedIndirect, "(a,x)", "($%04X, X)", 6, 3, planAbsoluteIndexedIndirect},
+		{AddressModeAbsoluteIndirectLong, "[a]", "[$%04X]", 6, 3, planAbsoluteIndirectLong},
+		{AddressModeJumpToSubroutine, "a", "$%04X", 6, 3, planJumpToSubroutine},
+		{AddressModeJumpToSubroutineLong, "al", "$%06X", 8, 4, planJumpToSubroutineLong},
+		{AddressModeJumpToSubroutineIndexedIndirect, "(a,x)", "($%04X, X)", 8, 3, planJumpToSubroutineIndexedIndirect},
+		{AddressModeReturnFromSubroutine, "s", "", 6, 1, planReturnFromSubroutine},
+		{AddressModeReturnFromSubroutineLong, "s", "", 6, 1, planReturnFromSubroutineLong},
+		{AddressModeReturnFromInterrupt, "s", "", 6, 1, planReturnFromInterrupt},
+		{AddressModePushStack, "s", "", 3, 1, planPushStack},
+		{AddressModePullStack, "s", "", 4, 1, planPullStack},
+		{AddressModePushEffectiveAbsolute, "s", "$%04X", 5, 3, planPushEffectiveAbsolute},
+		{AddressModePushEffectiveIndirect, "s", "($%02X)", 6, 2, planPushEffectiveIndirect},
+		{AddressModePushEffectiveRelative, "s", "$%04X", 6, 3, planPushEffectiveRelative},
+		{AddressModeBlockMove, "xyc", "$%02X, $%02X", 7, 3, planBlockMove},
+		{AddressModeBreak, "s", "", 7, 2, planBreak},
+
+		// Non Instruction address modes. These are planned by the processor when the interrupt
+		// is served.
+		{AddressModeIRQ, "irq", "", 7, 0, nil},
+		{AddressModeNMI, "nmi", "", 7, 0, nil},
+		{AddressModeAbort, "abort", "", 7, 0, nil},
+		{AddressModeReset, "reset", "", 7, 0, nil},
+	}
+
+	for i := range data {
+		addressModeSet.nameIndex[data[i].name] = &data[i]
+	}
+
+	return &addressModeSet
+}
+
+/*
+ ****************************************************
+ * Steps
+ ****************************************************
+ */
+
+// Steps that read the instruction and its operands
+var (
+	step65C816SReadOpCode                  = step65C816S{cycle: (*cpu65C816S).readOpCode, postCycle: (*cpu65C816S).decodeOpCode}
+	step65C816SReadOperand                 = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadOperand}
+	step65C816SReadOperandAndJump          = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadOperandAndJump}
+	step65C816SReadOperandAndJumpLong      = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadOperandAndJumpLong}
+	step65C816SReadBranchOffset            = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadBranchOffset}
+	step65C816SReadImmediateLow            = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadImmediateLow}
+	step65C816SReadImmediateLowAndExecute  = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadImmediateLowAndExecute}
+	step65C816SReadImmediateHighAndExecute = step65C816S{cycle: (*cpu65C816S).readProgram, postCycle: (*cpu65C816S).loadImmediateHighAndExecute}
+	step65C816SInternal                    = step65C816S{cycle: (*cpu65C816S).internalOperation, postCycle: (*cpu65C816S).doNothing}
+	step65C816SInternalAndExecute          = step65C816S{cycle: (*cpu65C816S).internalOperation, postCycle: (*cpu65C816S).execute}
+	step65C816SInternalOnAccumulator       = step65C816S{cycle: (*cpu65C816S).internalOperation, postCycle: (*cpu65C816S).executeOnAccumulator}
+	step65C816SInternalAndBranchLong       = step65C816S{cycle: (*cpu65C816S).internalOperation, postCycle: (*cpu65C816S).branchLong}
+	step65C816SInternalAndReturn           = step65C816S{cycle: (*cpu65C816S).internalOperation, postCycle: (*cpu65C816S).returnFromSubroutine}
+	step65C816SDirectPenalty               = step65C816S{cycle: (*cpu65C816S).directPenalty, postCycle: (*cpu65C816S).doNothing}
+	step65C816SIndexPenalty                = step65C816S{cycle: (*cpu65C816S).indexPenalty, postCycle: (*cpu65C816S).doNothing}
+	step65C816SBranchTaken                 = step65C816S{cycle: (*cpu65C816S).branchTakenCycle, postCycle: (*cpu65C816S).takeBranch}
+	step65C816SBranchPageCrossed           = step65C816S{cycle: (*cpu65C816S).branchPageCrossedCycle, postCycle: (*cpu65C816S).doNothing}
+	step65C816SReadPointerLow              = step65C816S{cycle: (*cpu65C816S).readPointerLow, postCycle: (*cpu65C816S).loadPointerLow}
+	step65C816SReadPointerHigh             = step65C816S{cycle: (*cpu65C816S).readPointerHigh, postCycle: (*cpu65C816S).loadPointerHigh}
+	step65C816SReadPointerHighAndJump      = step65C816S{cycle: (*cpu65C816S).readPointerHigh, postCycle: (*cpu65C816S).loadPointerHighAndJump}
+	step65C816SReadPointerBank             = step65C816S{cycle: (*cpu65C816S).readPointerBank, postCycle: (*cpu65C816S).loadPointerBank}
+	step65C816SReadPointerBankAndJump      = step65C816S{cycle: (*cpu65C816S).readPointerBank, postCycle: (*cpu65C816S).loadPointerBankAndJump}
+	step65C816SReadBlockSource             = step65C816S{cycle: (*cpu65C816S).readBlockSource, postCycle: (*cpu65C816S).loadDataLow}
+	step65C816SWriteBlockDestination       = step65C816S{cycle: (*cpu65C816S).writeBlockDestination, postCycle: (*cpu65C816S).doNothing}
+	step65C816SReadVectorLow               = step65C816S{cycle: (*cpu65C816S).readVectorLow, postCycle: (*cpu65C816S).loadDataLow}
+	step65C816SReadVectorHigh              = step65C816S{cycle: (*cpu65C816S).readVectorHigh, postCycle: (*cpu65C816S).loadVectorHigh}
+	step65C816SReadStack                   = step65C816S{cycle: (*cpu65C816S).readStack, postCycle: (*cpu65C816S).doNothing}
+	step65C816SReadDataLow                 = step65C816S{cycle: (*cpu65C816S).readDataLow, postCycle: (*cpu65C816S).loadDataLow}
+	step65C816SReadDataLowAndExecute       = step65C816S{cycle: (*cpu65C816S).readDataLow, postCycle: (*cpu65C816S).loadDataLowAndExecute}
+	step65C816SReadDataHighAndExecute      = step65C816S{cycle: (*cpu65C816S).readDataHigh, postCycle: (*cpu65C816S).loadDataHighAndExecute}
+	step65C816SStoreDataLow                = step65C816S{cycle: (*cpu65C816S).storeDataLow, postCycle: (*cpu65C816S).doNothing}
+	step65C816SStoreDataHigh               = step65C816S{cycle: (*cpu65C816S).writeDataHigh, postCycle: (*cpu65C816S).doNothing}
+	step65C816SModifyReadDataLow           = step65C816S{cycle: (*cpu65C816S).readDataLow, postCycle: (*cpu65C816S).loadDataLow, memoryLock: true}
+	step65C816SModifyReadDataHigh          = step65C816S{cycle: (*cpu65C816S).readDataHigh, postCycle: (*cpu65C816S).loadDataHigh, memoryLock: true}
+	step65C816SModify                      = step65C816S{cycle: (*cpu65C816S).modify, postCycle: (*cpu65C816S).execute, memoryLock: true}
+	step65C816SModifyWriteDataHigh         = step65C816S{cycle: (*cpu65C816S).writeDataHigh, postCycle: (*cpu65C816S).doNothing, memoryLock: true}
+	step65C816SModifyWriteDataLow          = step65C816S{cycle: (*cpu65C816S).writeDataLow, postCycle: (*cpu65C816S).doNothing, memoryLock: true}
+	step65C816SPushDataHigh                = step65C816S{cycle: (*cpu65C816S).pushDataHigh, postCycle: (*cpu65C816S).pushed}
+	step65C816SPushDataLow                 = step65C816S{cycle: (*cpu65C816S).pushDataLow, postCycle: (*cpu65C816S).pushed}
+	step65C816SPushProgramBank             = step65C816S{cycle: (*cpu65C816S).pushProgramBank, postCycle: (*cpu65C816S).pushed}
+	step65C816SPushReturnHigh              = step65C816S{cycle: (*cpu65C816S).pushReturnHigh, postCycle: (*cpu65C816S).pushed}
+	step65C816SPushReturnLow               = step65C816S{cycle: (*cpu65C816S).pushReturnLow, postCycle: (*cpu65C816S).pushed}
+	step65C816SPushReturnLowAndJump        = step65C816S{cycle: (*cpu65C816S).pushReturnLow, postCycle: (*cpu65C816S).pushedAndJump}
+	step65C816SPushReturnLowAndJumpLong    = step65C816S{cycle: (*cpu65C816S).pushReturnLow, postCycle: (*cpu65C816S).pushedAndJumpLong}
+	step65C816SPushStatus                  = step65C816S{cycle: (*cpu65C816S).pushStatus, postCycle: (*cpu65C816S).pushedStatus}
+	step65C816SPullDataLow                 = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullDataLow}
+	step65C816SPullDataLowAndExecute       = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullDataLowAndExecute}
+	step65C816SPullDataHigh                = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullDataHigh}
+	step65C816SPullDataHighAndExecute      = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullDataHighAndExecute}
+	step65C816SPullDataHighAndJump         = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullDataHighAndJump}
+	step65C816SPullStatus                  = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullStatus}
+	step65C816SPullProgramBank             = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullProgramBank}
+	step65C816SPullProgramBankAndReturn    = step65C816S{cycle: (*cpu65C816S).pull, postCycle: (*cpu65C816S).pullProgramBankAndReturn}
+)
+
+// Steps that don't take a cycle, they calculate the effective address or the value to push
+var (
+	step65C816SDirect            = computeStep((*cpu65C816S).computeDirect)
+	step65C816SDirectX           = computeStep((*cpu65C816S).computeDirectX)
+	step65C816SDirectY           = computeStep((*cpu65C816S).computeDirectY)
+	step65C816SAbsolute          = computeStep((*cpu65C816S).computeAbsolute)
+	step65C816SAbsoluteX         = computeStep((*cpu65C816S).computeAbsoluteX)
+	step65C816SAbsoluteY         = computeStep((*cpu65C816S).computeAbsoluteY)
+	step65C816SAbsoluteLong      = computeStep((*cpu65C816S).computeAbsoluteLong)
+	step65C816SAbsoluteLongX     = computeStep((*cpu65C816S).computeAbsoluteLongX)
+	step65C816SStackRelative     = computeStep((*cpu65C816S).computeStackRelative)
+	step65C816SDirectPointer     = computeStep((*cpu65C816S).computeDirectPointer)
+	step65C816SDirectPointerX    = computeStep((*cpu65C816S).computeDirectPointerX)
+	step65C816SDirectPointerLong = computeStep((*cpu65C816S).computeDirectPointerLong)
+	step65C816SStackPointer      = computeStep((*cpu65C816S).computeStackPointer)
+	step65C816SAbsolutePointer   = computeStep((*cpu65C816S).computeAbsolutePointer)
+	step65C816SProgramPointerX   = computeStep((*cpu65C816S).computeProgramPointerX)
+	step65C816SPointerData       = computeStep((*cpu65C816S).computePointerData)
+	step65C816SPointerDataY      = computeStep((*cpu65C816S).computePointerDataY)
+	step65C816SPointerLongY      = computeStep((*cpu65C816S).computePointerLongY)
+	step65C816SPushOperand       = computeStep((*cpu65C816S).computePushOperand)
+	step65C816SPushPointer       = computeStep((*cpu65C816S).computePushPointer)
+	step65C816SPushRelative      = computeStep((*cpu65C816S).computePushRelative)
+)
+
+// Returns a step that executes the specified function without taking a cycle
+func computeStep(compute func(cpu *cpu65C816S)) step65C816S {
+	return step65C816S{
+		cycle: func(cpu *cpu65C816S) bool {
+			compute(cpu)
+			return false
+		},
+		postCycle: (*cpu65C816S).doNothing,
+	}
+}
+
+/*
+ ****************************************************
+ * Plans
+ ****************************************************
+ */
+
+// Appends the cycles that access the effective address. Reads execute the instruction when the last
+// byte is read, stores when the first byte is written and RMW instructions on the modify cycle.
+// RMW instructions write the high byte first and assert the memory lock line on all the cycles.
+func (cpu *cpu65C816S) planDataAccess(instruction *instructionData65C816S) {
+	switch instruction.access() {
+	case accessWrite:
+		cpu.steps = append(cpu.steps, step65C816SStoreDataLow)
+		if cpu.width16 {
+			cpu.steps = append(cpu.steps, step65C816SStoreDataHigh)
+		}
+	case accessModify:
+		if cpu.width16 {
+			cpu.steps = append(cpu.steps,
+				step65C816SModifyReadDataLow,
+				step65C816SModifyReadDataHigh,
+				step65C816SModify,
+				step65C816SModifyWriteDataHigh,
+				step65C816SModifyWriteDataLow,
+			)
+		} else {
+			cpu.steps = append(cpu.steps,
+				step65C816SModifyReadDataLow,
+				step65C816SModify,
+				step65C816SModifyWriteDataLow,
+			)
+		}
+	default:
+		if cpu.width16 {
+			cpu.steps = append(cpu.steps, step65C816SReadDataLow, step65C816SReadDataHighAndExecute)
+		} else {
+			cpu.steps = append(cpu.steps, step65C816SReadDataLowAndExecute)
+		}
+	}
+}
+
+// Implied: XBA, WAI and STP take an extra internal cycle.
+func planImplied(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	switch instruction.mnemonic {
+	case XBA, WAI, STP:
+		cpu.steps = append(cpu.steps, step65C816SInternal)
+	}
+
+	cpu.steps = append(cpu.steps, step65C816SInternalAndExecute)
+}
+
+// Accumulator: the instruction modifies the accumulator as it would modify the memory.
+func planAccumulator(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SInternalOnAccumulator)
+}
+
+// Immediate: 16 bits registers read 2 bytes. REP and SEP update the status on an extra cycle.
+func planImmediate(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	switch {
+	case instruction.mnemonic == REP || instruction.mnemonic == SEP:
+		cpu.steps = append(cpu.steps, step65C816SReadImmediateLow, step65C816SInternalAndExecute)
+	case cpu.width16:
+		cpu.steps = append(cpu.steps, step65C816SReadImmediateLow, step65C816SReadImmediateHighAndExecute)
+	default:
+		cpu.steps = append(cpu.steps, step65C816SReadImmediateLowAndExecute)
+	}
+}
+
+// Direct d: an extra cycle is taken when the low byte of the direct register is not 0.
+func planDirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SDirect, step65C816SDirectPenalty)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indexed with X d,x
+func planDirectX(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SDirectX, step65C816SDirectPenalty, step65C816SInternal)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indexed with Y d,y
+func planDirectY(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SDirectY, step65C816SDirectPenalty, step65C816SInternal)
+	cpu.planDataAccess(instruction)
+}
+
+// Absolute a: the address is on the data bank.
+func planAbsolute(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SAbsolute)
+	cpu.planDataAccess(instruction)
+}
+
+// Absolute indexed with X a,x: reads take an extra cycle when a page boundary is crossed or the
+// index registers are 16 bits, writes and RMW instructions always take it.
+func planAbsoluteX(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SAbsoluteX, step65C816SIndexPenalty)
+	cpu.planDataAccess(instruction)
+}
+
+// Absolute indexed with Y a,y
+func planAbsoluteY(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SAbsoluteY, step65C816SIndexPenalty)
+	cpu.planDataAccess(instruction)
+}
+
+// Absolute long al: the operand is the 24 bits address.
+func planAbsoluteLong(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SReadOperand, step65C816SAbsoluteLong)
+	cpu.planDataAccess(instruction)
+}
+
+// Absolute long indexed with X al,x
+func planAbsoluteLongX(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SReadOperand, step65C816SAbsoluteLongX)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indirect (d): the pointer is on the direct page and the address on the data bank.
+func planDirectIndirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SDirectPointer,
+		step65C816SDirectPenalty,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SPointerData,
+	)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indexed indirect (d,x)
+func planDirectIndexedIndirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SDirectPointerX,
+		step65C816SDirectPenalty,
+		step65C816SInternal,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SPointerData,
+	)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indirect indexed (d),y: takes an extra cycle in the same cases as a,y
+func planDirectIndirectIndexed(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SDirectPointer,
+		step65C816SDirectPenalty,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SPointerDataY,
+		step65C816SIndexPenalty,
+	)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indirect long [d]: the pointer on the direct page has the 24 bits address.
+func planDirectIndirectLong(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SDirectPointerLong,
+		step65C816SDirectPenalty,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SReadPointerBank,
+	)
+	cpu.planDataAccess(instruction)
+}
+
+// Direct indirect long indexed [d],y
+func planDirectIndirectLongIndexed(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SDirectPointerLong,
+		step65C816SDirectPenalty,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SReadPointerBank,
+		step65C816SPointerLongY,
+	)
+	cpu.planDataAccess(instruction)
+}
+
+// Stack relative d,s: the address is the stack pointer plus the operand on bank 0.
+func planStackRelative(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SStackRelative, step65C816SInternal)
+	cpu.planDataAccess(instruction)
+}
+
+// Stack relative indirect indexed (d,s),y
+func planStackRelativeIndirectIndexed(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SStackPointer,
+		step65C816SInternal,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SPointerDataY,
+		step65C816SInternal,
+	)
+	cpu.planDataAccess(instruction)
+}
+
+// Relative r: taken branches take an extra cycle, and one more if they cross a page in emulation mode.
+func planRelative(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadBranchOffset, step65C816SBranchTaken, step65C816SBranchPageCrossed)
+}
+
+// Relative long rl: BRL always branches.
+func planRelativeLong(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SInternalAndBranchLong)
+}
+
+// JMP a: jumps within the program bank.
+func planAbsoluteJump(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperandAndJump)
+}
+
+// JML al: jumps to any bank.
+func planAbsoluteLongJump(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SReadOperand, step65C816SReadOperand, step65C816SReadOperandAndJumpLong)
+}
+
+// JMP (a): the pointer is on bank 0.
+func planAbsoluteIndirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SAbsolutePointer,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHighAndJump,
+	)
+}
+
+// JMP (a,x): the pointer is on the program bank.
+func planAbsoluteIndexedIndirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SProgramPointerX,
+		step65C816SInternal,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHighAndJump,
+	)
+}
+
+// JML [a]: the pointer on bank 0 has the 24 bits address.
+func planAbsoluteIndirectLong(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SAbsolutePointer,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SReadPointerBankAndJump,
+	)
+}
+
+// JSR a: pushes the address of the last byte of the instruction.
+func planJumpToSubroutine(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.returnAddress = cpu.programCounter + 1
+
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SInternal,
+		step65C816SPushReturnHigh,
+		step65C816SPushReturnLowAndJump,
+	)
+}
+
+// JSL al: pushes the program bank before reading the bank of the subroutine.
+func planJumpToSubroutineLong(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.returnAddress = cpu.programCounter + 2
+
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SPushProgramBank,
+		step65C816SInternal,
+		step65C816SReadOperand,
+		step65C816SPushReturnHigh,
+		step65C816SPushReturnLowAndJumpLong,
+	)
+}
+
+// JSR (a,x): pushes the return address between the reads of the operand.
+func planJumpToSubroutineIndexedIndirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.returnAddress = cpu.programCounter + 1
+
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SPushReturnHigh,
+		step65C816SPushReturnLow,
+		step65C816SReadOperand,
+		step65C816SInternal,
+		step65C816SProgramPointerX,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHighAndJump,
+	)
+}
+
+// RTS
+func planReturnFromSubroutine(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SInternal,
+		step65C816SInternal,
+		step65C816SPullDataLow,
+		step65C816SPullDataHigh,
+		step65C816SInternalAndReturn,
+	)
+}
+
+// RTL: pulls the program bank as well.
+func planReturnFromSubroutineLong(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SInternal,
+		step65C816SInternal,
+		step65C816SPullDataLow,
+		step65C816SPullDataHigh,
+		step65C816SPullProgramBankAndReturn,
+	)
+}
+
+// RTI: the program bank is only pulled in native mode.
+func planReturnFromInterrupt(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SInternal,
+		step65C816SInternal,
+		step65C816SPullStatus,
+		step65C816SPullDataLow,
+		step65C816SPullDataHighAndJump,
+	)
+
+	if !cpu.emulationMode {
+		cpu.steps = append(cpu.steps, step65C816SPullProgramBank)
+	}
+}
+
+// Push: the instruction sets the value to push, the high byte is pushed first.
+func planPushStack(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SInternalAndExecute)
+
+	if cpu.width16 {
+		cpu.steps = append(cpu.steps, step65C816SPushDataHigh)
+	}
+
+	cpu.steps = append(cpu.steps, step65C816SPushDataLow)
+}
+
+// Pull: the instruction is executed with the value pulled.
+func planPullStack(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps, step65C816SInternal, step65C816SInternal)
+
+	if cpu.width16 {
+		cpu.steps = append(cpu.steps, step65C816SPullDataLow, step65C816SPullDataHighAndExecute)
+	} else {
+		cpu.steps = append(cpu.steps, step65C816SPullDataLowAndExecute)
+	}
+}
+
+// PEA: pushes the operand.
+func planPushEffectiveAbsolute(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SPushOperand,
+		step65C816SPushDataHigh,
+		step65C816SPushDataLow,
+	)
+}
+
+// PEI: pushes the 16 bits value on the direct page.
+func planPushEffectiveIndirect(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SDirectPointerLong,
+		step65C816SDirectPenalty,
+		step65C816SReadPointerLow,
+		step65C816SReadPointerHigh,
+		step65C816SPushPointer,
+		step65C816SPushDataHigh,
+		step65C816SPushDataLow,
+	)
+}
+
+// PER: pushes the address of the next instruction plus the operand.
+func planPushEffectiveRelative(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SPushRelative,
+		step65C816SInternal,
+		step65C816SPushDataHigh,
+		step65C816SPushDataLow,
+	)
+}
+
+// MVN and MVP move one byte each time they are executed. The instruction moves the program counter
+// back to itself until all the bytes are moved.
+func planBlockMove(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.steps = append(cpu.steps,
+		step65C816SReadOperand,
+		step65C816SReadOperand,
+		step65C816SReadBlockSource,
+		step65C816SWriteBlockDestination,
+		step65C816SInternal,
+		step65C816SInternalAndExecute,
+	)
+}
+
+// BRK and COP: read the signature byte and execute the interrupt sequence. In emulation mode BRK
+// uses the IRQ vector and pushes the status with the B flag set.
+func planBreak(cpu *cpu65C816S, instruction *instructionData65C816S) {
+	cpu.returnAddress = cpu.programCounter + 1
+	cpu.breakFlag = instruction.mnemonic == BRK
+
+	cpu.steps = append(cpu.steps, step65C816SReadOperand)
+
+	if instruction.mnemonic == COP {
+		cpu.planInterruptSequence(vectorNativeCOP, vectorEmulationCOP)
+	} else {
+		cpu.planInterruptSequence(vectorNativeBRK, vectorEmulationIRQ)
+	}
+}
+
+/*
+ ****************************************************
+ * Cycles
+ ****************************************************
+ */
+
+// Reads the opcode at the program counter.
+func (cpu *cpu65C816S) readOpCode() bool {
+	cpu.setReadBus(cpu.programAddress(), true, true)
+	return true
+}
+
+// Reads an operand at the program counter.
+func (cpu *cpu65C816S) readProgram() bool {
+	cpu.setReadBus(cpu.programAddress(), false, true)
+	return true
+}
+
+// Internal operation, VDA and VPA are low so the address is not valid.
+func (cpu *cpu65C816S) internalOperation() bool {
+	cpu.setReadBus(cpu.programAddress(), false, false)
+	return true
+}
+
+// Extra cycle taken when the low byte of the direct register is not 0.
+func (cpu *cpu65C816S) directPenalty() bool {
+	if cpu.directRegister&0x00FF == 0 {
+		return false
+	}
+
+	return cpu.internalOperation()
+}
+
+// Extra cycle of indexed address modes, the address has the low byte indexed.
+func (cpu *cpu65C816S) indexPenalty() bool {
+	if !cpu.indexCarry {
+		return false
+	}
+
+	cpu.setReadBus(cpu.pointer, false, false)
+	return true
+}
+
+// Extra cycle of a taken branch.
+func (cpu *cpu65C816S) branchTakenCycle() bool {
+	if !cpu.branchTaken {
+		return false
+	}
+
+	return cpu.internalOperation()
+}
+
+// Extra cycle of a taken branch that crosses a page in emulation mode.
+func (cpu *cpu65C816S) branchPageCrossedCycle() bool {
+	if !cpu.branchTaken || !cpu.pageCrossed || !cpu.emulationMode {
+		return false
+	}
+
+	return cpu.internalOperation()
+}
+
+// Reads the low byte of the effective address.
+func (cpu *cpu65C816S) readDataLow() bool {
+	cpu.setReadBus(cpu.address, true, false)
+	return true
+}
+
+// Reads the high byte of the effective address.
+func (cpu *cpu65C816S) readDataHigh() bool {
+	cpu.setReadBus(cpu.nextDataAddress(), true, false)
+	return true
+}
+
+// Executes the store instruction and writes the low byte of the value.
+func (cpu *cpu65C816S) storeDataLow() bool {
+	cpu.currentInstruction.action(cpu)
+	return cpu.writeDataLow()
+}
+
+// Writes the low byte of the value to the effective address.
+func (cpu *cpu65C816S) writeDataLow() bool {
+	cpu.setWriteBus(cpu.address, uint8(cpu.data))
+	return true
+}
+
+// Writes the high byte of the value to the effective address.
+func (cpu *cpu65C816S) writeDataHigh() bool {
+	cpu.setWriteBus(cpu.nextDataAddress(), uint8(cpu.data>>8))
+	return true
+}
+
+// Modify cycle of RMW instructions. In emulation mode the unmodified value is written back as in
+// the NMOS 6502, in native mode it is an internal operation.
+func (cpu *cpu65C816S) modify() bool {
+	if cpu.emulationMode {
+		return cpu.writeDataLow()
+	}
+
+	return cpu.internalOperation()
+}
+
+// Reads the low byte of the pointer.
+func (cpu *cpu65C816S) readPointerLow() bool {
+	cpu.setReadBus(cpu.pointerAddress(0), true, false)
+	return true
+}
+
+// Reads the high byte of the pointer.
+func (cpu *cpu65C816S) readPointerHigh() bool {
+	cpu.setReadBus(cpu.pointerAddress(1), true, false)
+	return true
+}
+
+// Reads the bank of a long pointer.
+func (cpu *cpu65C816S) readPointerBank() bool {
+	cpu.setReadBus(cpu.pointerAddress(2), true, false)
+	return true
+}
+
+// Reads the byte to move from the source bank, the first operand is the destination bank.
+func (cpu *cpu65C816S) readBlockSource() bool {
+	cpu.setReadBus(uint32(cpu.operand&0xFF00)<<8|uint32(cpu.xRegister), true, false)
+	return true
+}
+
+// Writes the byte moved to the destination bank.
+func (cpu *cpu65C816S) writeBlockDestination() bool {
+	cpu.setWriteBus(uint32(cpu.operand&0x00FF)<<16|uint32(cpu.yRegister), uint8(cpu.data))
+	return true
+}
+
+// Reads the low byte of the interrupt vector.
+func (cpu *cpu65C816S) readVectorLow() bool {
+	cpu.setReadBus(uint32(cpu.vector), true, false)
+	cpu.vectorPull.SetEnable(true)
+	return true
+}
+
+// Reads the high byte of the interrupt vector.
+func (cpu *cpu65C816S) readVectorHigh() bool {
+	cpu.setReadBus(uint32(cpu.vector+1), true, false)
+	cpu.vectorPull.SetEnable(true)
+	return true
+}
+
+// Reads the top of the stack without pulling, done by reset instead of pushing.
+func (cpu *cpu65C816S) readStack() bool {
+	cpu.setReadBus(cpu.stackAddress(), true, false)
+	return true
+}
+
+// Pushes the high byte of the value.
+func (cpu *cpu65C816S) pushDataHigh() bool {
+	cpu.setWriteBus(cpu.stackAddress(), uint8(cpu.data>>8))
+	return true
+}
+
+// Pushes the low byte of the value.
+func (cpu *cpu65C816S) pushDataLow() bool {
+	cpu.setWriteBus(cpu.stackAddress(), uint8(cpu.data))
+	return true
+}
+
+// Pushes the program bank.
+func (cpu *cpu65C816S) pushProgramBank() bool {
+	cpu.setWriteBus(cpu.stackAddress(), cpu.programBankRegister)
+	return true
+}
+
+// Pushes the high byte of the return address.
+func (cpu *cpu65C816S) pushReturnHigh() bool {
+	cpu.setWriteBus(cpu.stackAddress(), uint8(cpu.returnAddress>>8))
+	return true
+}
+
+// Pushes the low byte of the return address.
+func (cpu *cpu65C816S) pushReturnLow() bool {
+	cpu.setWriteBus(cpu.stackAddress(), uint8(cpu.returnAddress))
+	return true
+}
+
+// Pushes the status register. In emulation mode bit 4 is the B flag, set only by BRK.
+func (cpu *cpu65C816S) pushStatus() bool {
+	value := uint8(cpu.processorStatusRegister)
+
+	if cpu.emulationMode {
+		value = value&^0x10 | 0x20
+		if cpu.breakFlag {
+			value |= 0x10
+		}
+	}
+
+	cpu.setWriteBus(cpu.stackAddress(), value)
+	return true
+}
+
+// Reads the byte over the top of the stack.
+func (cpu *cpu65C816S) pull() bool {
+	cpu.setReadBus(uint32(cpu.nextStackPointer()), true, false)
+	return true
+}
+
+/*
+ ****************************************************
+ * Post cycles
+ ****************************************************
+ */
+
+// Nothing to do after the cycle
+func (cpu *cpu65C816S) doNothing() {
+}
+
+// Decodes the opcode read and plans the instruction.
+func (cpu *cpu65C816S) decodeOpCode() {
+	cpu.decode(cpu.dataBus.Read())
+}
+
+// Executes the current instruction
+func (cpu *cpu65C816S) execute() {
+	cpu.currentInstruction.action(cpu)
+}
+
+// Executes the current instruction on the accumulator.
+func (cpu *cpu65C816S) executeOnAccumulator() {
+	cpu.data = cpu.accumulatorRegister
+	cpu.currentInstruction.action(cpu)
+	cpu.setAccumulator(cpu.data)
+}
+
+// Adds the byte read to the operand.
+func (cpu *cpu65C816S) loadOperand() {
+	cpu.operand |= uint32(cpu.dataBus.Read()) << (8 * cpu.operandBytes)
+	cpu.operandBytes++
+	cpu.programCounter++
+}
+
+// Loads the last byte of the operand and jumps to it.
+func (cpu *cpu65C816S) loadOperandAndJump() {
+	cpu.loadOperand()
+	cpu.programCounter = uint16(cpu.operand)
+}
+
+// Loads the bank of the operand and jumps to it.
+func (cpu *cpu65C816S) loadOperandAndJumpLong() {
+	cpu.loadOperand()
+	cpu.programCounter = uint16(cpu.operand)
+	cpu.programBankRegister = uint8(cpu.operand >> 16)
+}
+
+// Loads the offset of the branch and evaluates if it is taken.
+func (cpu *cpu65C816S) loadBranchOffset() {
+	cpu.loadOperand()
+	cpu.currentInstruction.action(cpu)
+
+	target := cpu.programCounter + uint16(int8(cpu.operand))
+	cpu.address = uint32(target)
+	cpu.pageCrossed = target&0xFF00 != cpu.programCounter&0xFF00
+}
+
+// Moves the program counter to the target of the branch.
+func (cpu *cpu65C816S) takeBranch() {
+	cpu.programCounter = uint16(cpu.address)
+}
+
+// Adds the 16 bits offset of BRL to the program counter.
+func (cpu *cpu65C816S) branchLong() {
+	cpu.programCounter += uint16(cpu.operand)
+}
+
+// Returns to the address pulled plus 1.
+func (cpu *cpu65C816S) returnFromSubroutine() {
+	cpu.programCounter = cpu.data + 1
+}
+
+// Loads the low byte of an immediate value.
+func (cpu *cpu65C816S) loadImmediateLow() {
+	cpu.data = uint16(cpu.dataBus.Read())
+	cpu.programCounter++
+}
+
+// Loads the 8 bits immediate value and executes the instruction.
+func (cpu *cpu65C816S) loadImmediateLowAndExecute() {
+	cpu.loadImmediateLow()
+	cpu.execute()
+}
+
+// Loads the high byte of the immediate value and executes the instruction.
+func (cpu *cpu65C816S) loadImmediateHighAndExecute() {
+	cpu.data |= uint16(cpu.dataBus.Read()) << 8
+	cpu.programCounter++
+	cpu.execute()
+}
+
+// Loads the low byte of the value read.
+func (cpu *cpu65C816S) loadDataLow() {
+	cpu.data = uint16(cpu.dataBus.Read())
+}
+
+// Loads the high byte of the value read.
+func (cpu *cpu65C816S) loadDataHigh() {
+	cpu.data |= uint16(cpu.dataBus.Read()) << 8
+}
+
+// Loads the 8 bits value read and executes the instruction.
+func (cpu *cpu65C816S) loadDataLowAndExecute() {
+	cpu.loadDataLow()
+	cpu.execute()
+}
+
+// Loads the high byte of the value read and executes the instruction.
+func (cpu *cpu65C816S) loadDataHighAndExecute() {
+	cpu.loadDataHigh()
+	cpu.execute()
+}
+
+// Loads the high byte of the vector and jumps to the interrupt handler.
+func (cpu *cpu65C816S) loadVectorHigh() {
+	cpu.loadDataHigh()
+	cpu.programCounter = cpu.data
+}
+
+// Loads the low byte of the pointer value.
+func (cpu *cpu65C816S) loadPointerLow() {
+	cpu.address = uint32(cpu.dataBus.Read())
+}
+
+// Loads the high byte of the pointer value.
+func (cpu *cpu65C816S) loadPointerHigh() {
+	cpu.address |= uint32(cpu.dataBus.Read()) << 8
+}
+
+// Loads the high byte of the pointer value and jumps to it.
+func (cpu *cpu65C816S) loadPointerHighAndJump() {
+	cpu.loadPointerHigh()
+	cpu.programCounter = uint16(cpu.address)
+}
+
+// Loads the bank of the pointer value.
+func (cpu *cpu65C816S) loadPointerBank() {
+	cpu.address |= uint32(cpu.dataBus.Read()) << 16
+}
+
+// Loads the bank of the pointer value and jumps to it.
+func (cpu *cpu65C816S) loadPointerBankAndJump() {
+	cpu.loadPointerBank()
+	cpu.programCounter = uint16(cpu.address)
+	cpu.programBankRegister = uint8(cpu.address >> 16)
+}
+
+// Moves the stack pointer after a push.
+func (cpu *cpu65C816S) pushed() {
+	cpu.decrementStackPointer()
+}
+
+// Moves the stack pointer and jumps to the subroutine.
+func (cpu *cpu65C816S) pushedAndJump() {
+	cpu.decrementStackPointer()
+	cpu.programCounter = uint16(cpu.operand)
+}
+
+// Moves the stack pointer and jumps to the subroutine on another bank.
+func (cpu *cpu65C816S) pushedAndJumpLong() {
+	cpu.decrementStackPointer()
+	cpu.programCounter = uint16(cpu.operand)
+	cpu.programBankRegister = uint8(cpu.operand >> 16)
+}
+
+// Moves the stack pointer after pushing the status and sets the state of the interrupt handler.
+func (cpu *cpu65C816S) pushedStatus() {
+	cpu.decrementStackPointer()
+
+	cpu.processorStatusRegister.SetFlag(IrqDisableFlagBit, true)
+	cpu.processorStatusRegister.SetFlag(DecimalModeFlagBit, false)
+	cpu.programBankRegister = 0x00
+}
+
+// Loads the low byte pulled.
+func (cpu *cpu65C816S) pullDataLow() {
+	cpu.stackPointer = cpu.nextStackPointer()
+	cpu.loadDataLow()
+}
+
+// Loads the 8 bits value pulled and executes the instruction.
+func (cpu *cpu65C816S) pullDataLowAndExecute() {
+	cpu.pullDataLow()
+	cpu.execute()
+}
+
+// Loads the high byte pulled.
+func (cpu *cpu65C816S) pullDataHigh() {
+	cpu.stackPointer = cpu.nextStackPointer()
+	cpu.loadDataHigh()
+}
+
+// Loads the high byte pulled and executes the instruction.
+func (cpu *cpu65C816S) pullDataHighAndExecute() {
+	cpu.pullDataHigh()
+	cpu.execute()
+}
+
+// Loads the high byte of the address pulled and jumps to it.
+func (cpu *cpu65C816S) pullDataHighAndJump() {
+	cpu.pullDataHigh()
+	cpu.programCounter = cpu.data
+}
+
+// Sets the status register with the value pulled.
+func (cpu *cpu65C816S) pullStatus() {
+	cpu.stackPointer = cpu.nextStackPointer()
+	cpu.setStatus(cpu.dataBus.Read())
+}
+
+// Sets the program bank with the value pulled.
+func (cpu *cpu65C816S) pullProgramBank() {
+	cpu.stackPointer = cpu.nextStackPointer()
+	cpu.programBankRegister = cpu.dataBus.Read()
+}
+
+// Sets the program bank with the value pulled and returns to the address pulled plus 1.
+func (cpu *cpu65C816S) pullProgramBankAndReturn() {
+	cpu.pullProgramBank()
+	cpu.programCounter = cpu.data + 1
+}
+
+/*
+ ****************************************************
+ * Address calculation
+ ****************************************************
+ */
+
+// Effective address of d
+func (cpu *cpu65C816S) computeDirect() {
+	cpu.address = cpu.directAddress(uint16(cpu.operand))
+	cpu.dataInBank0 = true
+}
+
+// Effective address of d,x
+func (cpu *cpu65C816S) computeDirectX() {
+	cpu.address = cpu.directIndexedAddress(uint16(cpu.operand), cpu.xRegister)
+	cpu.dataInBank0 = true
+}
+
+// Effective address of d,y
+func (cpu *cpu65C816S) computeDirectY() {
+	cpu.address = cpu.directIndexedAddress(uint16(cpu.operand), cpu.yRegister)
+	cpu.dataInBank0 = true
+}
+
+// Effective address of a, on the data bank
+func (cpu *cpu65C816S) computeAbsolute() {
+	cpu.address = uint32(cpu.dataBankRegister)<<16 | cpu.operand
+}
+
+// Effective address of a,x
+func (cpu *cpu65C816S) computeAbsoluteX() {
+	cpu.computeAbsolute()
+	cpu.addIndex(cpu.xRegister)
+}
+
+// Effective address of a,y
+func (cpu *cpu65C816S) computeAbsoluteY() {
+	cpu.computeAbsolute()
+	cpu.addIndex(cpu.yRegister)
+}
+
+// Effective address of al
+func (cpu *cpu65C816S) computeAbsoluteLong() {
+	cpu.address = cpu.operand
+}
+
+// Effective address of al,x
+func (cpu *cpu65C816S) computeAbsoluteLongX() {
+	cpu.address = (cpu.operand + uint32(cpu.xRegister)) & 0xFFFFFF
+}
+
+// Effective address of d,s, on bank 0
+func (cpu *cpu65C816S) computeStackRelative() {
+	cpu.address = uint32(cpu.stackPointer + uint16(cpu.operand))
+	cpu.dataInBank0 = true
+}
+
+// Pointer of (d) and (d),y. In emulation mode it wraps on the direct page.
+func (cpu *cpu65C816S) computeDirectPointer() {
+	cpu.pointer = cpu.directAddress(uint16(cpu.operand))
+	cpu.pointerWrapPage = cpu.directPageWraps()
+}
+
+// Pointer of (d,x). In emulation mode it wraps on the direct page.
+func (cpu *cpu65C816S) computeDirectPointerX() {
+	cpu.pointer = cpu.directIndexedAddress(uint16(cpu.operand), cpu.xRegister)
+	cpu.pointerWrapPage = cpu.directPageWraps()
+}
+
+// Pointer of [d], [d],y and PEI, these never wrap on the direct page.
+func (cpu *cpu65C816S) computeDirectPointerLong() {
+	cpu.pointer = cpu.directAddress(uint16(cpu.operand))
+}
+
+// Pointer of (d,s),y, on bank 0
+func (cpu *cpu65C816S) computeStackPointer() {
+	cpu.pointer = uint32(cpu.stackPointer + uint16(cpu.operand))
+}
+
+// Pointer of JMP (a) and JML [a], on bank 0
+func (cpu *cpu65C816S) computeAbsolutePointer() {
+	cpu.pointer = cpu.operand
+}
+
+// Pointer of JMP (a,x) and JSR (a,x), on the program bank
+func (cpu *cpu65C816S) computeProgramPointerX() {
+	cpu.pointer = uint32(cpu.programBankRegister)<<16 | uint32(uint16(cpu.operand)+cpu.xRegister)
+}
+
+// Effective address pointed on the data bank
+func (cpu *cpu65C816S) computePointerData() {
+	cpu.address = uint32(cpu.dataBankRegister)<<16 | cpu.address&0xFFFF
+}
+
+// Effective address pointed on the data bank indexed by Y
+func (cpu *cpu65C816S) computePointerDataY() {
+	cpu.computePointerData()
+	cpu.addIndex(cpu.yRegister)
+}
+
+// Effective address of the long pointer indexed by Y
+func (cpu *cpu65C816S) computePointerLongY() {
+	cpu.address = (cpu.address + uint32(cpu.yRegister)) & 0xFFFFFF
+}
+
+// Value pushed by PEA
+func (cpu *cpu65C816S) computePushOperand() {
+	cpu.data = uint16(cpu.operand)
+}
+
+// Value pushed by PEI
+func (cpu *cpu65C816S) computePushPointer() {
+	cpu.data = uint16(cpu.address)
+}
+
+// Value pushed by PER
+func (cpu *cpu65C816S) computePushRelative() {
+	cpu.data = cpu.programCounter + uint16(cpu.operand)
+}
@@ -180,6 +180,28 @@ func readFromNextAddressInBus() cycleAction {
 	}
 }
 
+// Increment the LSB of the current value in the bus by one and sets it to read. The MSB is not changed
+// so reading after the last byte of a page wraps to the start of the same page. This emulates the
+// NMOS 6502 bug reading the address of JMP ($xxFF).
+func readFromNextAddressInPage() cycleAction {
+	return func(cpu *cpu65C02S) bool {
+		address := cpu.addressBus.Read()
+		cpu.setReadBus((address & 0xFF00) | uint16(uint8(address)+1))
+
+		return true
+	}
+}
+
+// Sets the LSB of the instruction register on the bus for reading from the last page of memory ($FFxx).
+// This is done by the undefined opcode $5C of the 65C02S.
+func readFromInstructionRegisterLSBInLastPage() cycleAction {
+	return func(cpu *cpu65C02S) bool {
+		cpu.setReadBus(0xFF00 | (cpu.instructionRegister & 0x00FF))
+
+		return true
+	}
+}
+
 // Sets a specific address on the bus for reading. This is commonly used to read from IRQ, NMI or reset
 // vectors.
 func readFromAddress(address uint16) cycleAction {
@@ -290,6 +312,16 @@ func writeProcessorStatusRegisterToStack(hardwareInterrupt bool, disableIrq bool
 	}
 }
 
+// Writes the value of the data register to the address in the instruction register. On the NMOS 6502
+// RMW instructions write the unmodified value before writing the updated one.
+func writeDataRegisterToInstructionRegister() cycleAction {
+	return func(cpu *cpu65C02S) bool {
+		cpu.setWriteBus(cpu.instructionRegister, cpu.dataRegister)
+
+		return true
+	}
+}
+
 /**********************************************************************************************************
 * Cycle Post Actions
 ***********************************************************************************************************/
@@ -300,10 +332,10 @@ func intoOpCode() cyclePostAction {
 	return func(cpu *cpu65C02S) {
 		cpu.currentOpCode = components.OpCode(cpu.dataBus.Read())
 
-		cpu.currentInstruction = instructionSet.GetByOpCode(cpu.currentOpCode)
+		cpu.currentInstruction = cpu.instructionSet.GetByOpCode(cpu.currentOpCode)
 
 		addressModeName := cpu.currentInstruction.AddressMode()
-		cpu.currentAddressMode = addressModeSet.GetByName(addressModeName)
+		cpu.currentAddressMode = cpu.addressModeSet.GetByName(addressModeName)
 	}
 }
 
@@ -818,6 +850,39 @@ var addressModeAbsoluteYActions []cycleActions = []cycleActions{
 	},
 }
 
+var addressModeAbsoluteYRMWActions []cycleActions = []cycleActions{
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: addToInstructionRegister(fromYRegister),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     extraCycleIfCarryInstructionRegister(),
+		postCycle: doNothing(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoDataRegister(false),
+		signaling: memoryLockRMWSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoDataRegister(false),
+		signaling: memoryLockRMWSignaling,
+	},
+	{
+		cycle:     readFromAddressInBus(true),
+		postCycle: doNothing(),
+		signaling: memoryLockRMWSignaling,
+	},
+}
+
 var addressModeAbsoluteYWActions []cycleActions = []cycleActions{
 	{
 		cycle:     readFromProgramCounter(true),
@@ -926,6 +991,44 @@ var addressModeZeroPageIndexedIndirectXActions []cycleActions = []cycleActions{
 	},
 }
 
+var addressModeZeroPageIndexedIndirectXRMWActions []cycleActions = []cycleActions{
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: addToInstructionRegisterLSB(fromXRegister),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromNextAddressInBus(),
+		postCycle: intoInstructionRegisterMSB(false),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoDataRegister(false),
+		signaling: memoryLockRMWSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoDataRegister(false),
+		signaling: memoryLockRMWSignaling,
+	},
+	{
+		cycle:     readFromAddressInBus(true),
+		postCycle: doNothing(),
+		signaling: memoryLockRMWSignaling,
+	},
+}
+
 var addressModeZeroPageIndexedIndirectXWActions []cycleActions = []cycleActions{
 	{
 		cycle:     readFromProgramCounter(true),
@@ -986,6 +1089,44 @@ var addressModeZeroPageIndirectIndexedYActions []cycleActions = []cycleActions{
 	},
 }
 
+var addressModeZeroPageIndirectIndexedYRMWActions []cycleActions = []cycleActions{
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromNextAddressInBus(),
+		postCycle: addToInstructionRegister(fromYRegister),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     extraCycleIfCarryInstructionRegister(),
+		postCycle: doNothing(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoDataRegister(false),
+		signaling: memoryLockRMWSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoDataRegister(false),
+		signaling: memoryLockRMWSignaling,
+	},
+	{
+		cycle:     readFromAddressInBus(true),
+		postCycle: doNothing(),
+		signaling: memoryLockRMWSignaling,
+	},
+}
+
 var addressModeZeroPageIndirectIndexedYWActions []cycleActions = []cycleActions{
 	{
 		cycle:     readFromProgramCounter(true),
@@ -1387,3 +1528,94 @@ var addressModeResetActions []cycleActions = []cycleActions{
 		signaling: vectorPullingSignaling,
 	},
 }
+
+/**********************************
+* 65C02S undefined opcodes
+***********************************/
+
+// Most undefined opcodes of the 65C02S are NOPs that take a single cycle, only the opcode is read.
+var addressModeSingleCycleActions []cycleActions = []cycleActions{}
+
+// The undefined opcode $5C reads its 2 bytes operand, then reads from $FF followed by the LSB of the
+// operand and does 4 more reads of $FFFF, taking 8 cycles. See the nop_c_aba opcode of MAME's 65C02.
+var addressModeAbsoluteNOPActions []cycleActions = []cycleActions{
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterMSB(false),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegisterLSBInLastPage(),
+		postCycle: doNothing(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromAddress(0xFFFF),
+		postCycle: doNothing(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromAddress(0xFFFF),
+		postCycle: doNothing(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromAddress(0xFFFF),
+		postCycle: doNothing(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromAddress(0xFFFF),
+		postCycle: intoDataRegister(true),
+		signaling: defaultSignaling,
+	},
+}
+
+/**********************************
+* NMOS 6502
+***********************************/
+
+// On the NMOS 6502 JMP ($xxFF) reads the MSB of the target address from the start of the same page
+// and the instruction takes 5 cycles instead of the 6 cycles of the 65C02S.
+var addressModeIndirectNMOSActions []cycleActions = []cycleActions{
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromProgramCounter(true),
+		postCycle: intoInstructionRegisterMSB(false),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromInstructionRegister(),
+		postCycle: intoInstructionRegisterLSB(),
+		signaling: defaultSignaling,
+	},
+	{
+		cycle:     readFromNextAddressInPage(),
+		postCycle: intoInstructionRegisterMSB(true),
+		signaling: defaultSignaling,
+	},
+}
+
+// Returns a copy of the cycles of a 65C02S RMW address mode where the extra read of the effective
+// address is replaced by the write of the unmodified value done by the NMOS 6502.
+func toNMOSRMWActions(actions []cycleActions) []cycleActions {
+	nmosActions := make([]cycleActions, len(actions))
+	copy(nmosActions, actions)
+
+	nmosActions[len(nmosActions)-2] = cycleActions{
+		cycle:     writeDataRegisterToInstructionRegister(),
+		postCycle: doNothing(),
+		signaling: memoryLockRMWSignaling,
+	}
+
+	return nmosActions
+}
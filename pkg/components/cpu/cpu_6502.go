@@ -0,0 +1,66 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+)
+
+var nmosAddressModeSet *AddressModeSet = newNMOSAddressModesSet()
+var nmosInstructionSet *CpuInstructionSet = NewNMOSInstructionSet()
+
+// The NMOS 6502 takes the extra cycle on indexed addressing for all RMW and store instructions,
+// even if no page boundary is crossed.
+var nmosAlwaysExtra []uint8 = []uint8{
+	0x1E, 0x3E, 0x5E, 0x7E, 0xDE, 0xFE, // ASL, ROL, LSR, ROR, DEC, INC a,x
+	0x1F, 0x3F, 0x5F, 0x7F, 0xDF, 0xFF, // SLO, RLA, SRE, RRA, DCP, ISC a,x
+	0x1B, 0x3B, 0x5B, 0x7B, 0xDB, 0xFB, // SLO, RLA, SRE, RRA, DCP, ISC a,y
+	0x13, 0x33, 0x53, 0x73, 0xD3, 0xF3, // SLO, RLA, SRE, RRA, DCP, ISC (zp),y
+	0x9D, 0x99, 0x91, // STA a,x / a,y / (zp),y
+	0x9C, 0x9E, 0x9F, 0x9B, 0x93, // SHY, SHX, SHA, TAS, SHA
+}
+
+// Names of the processors that can be created with NewCpu
+const (
+	Cpu65C02S string = "65c02"
+	Cpu6502   string = "6502"
+)
+
+// NewCpu6502 creates a new instance of the original NMOS 6502 processor. It has the same pins and
+// registers of the 65C02S but executes the NMOS instruction set, including the illegal opcodes, and
+// emulates the NMOS behaviour of the decimal mode flags, the JMP ($xxFF) page boundary bug and the
+// extra write of the RMW instructions.
+func NewCpu6502() components.Cpu65C02 {
+	return newCpu6502()
+}
+
+// Creates an NMOS 6502 with typical values for all registers, address and data bus are not connected
+func newCpu6502() *cpu65C02S {
+	cpu := newCpu65C02S()
+
+	cpu.variant = variantNMOS6502
+	cpu.instructionSet = nmosInstructionSet
+	cpu.addressModeSet = nmosAddressModeSet
+	cpu.alwaysExtra = nmosAlwaysExtra
+
+	return cpu
+}
+
+// NewCpu creates the processor with the specified name.
+//
+// Parameters:
+//   - name: Name of the processor, Cpu65C02S or Cpu6502
+//
+// Returns:
+//   - The processor
+//   - An error if the name is not a known processor
+func NewCpu(name string) (components.Cpu65C02, error) {
+	switch name {
+	case Cpu65C02S:
+		return NewCpu65C02S(), nil
+	case Cpu6502:
+		return NewCpu6502(), nil
+	default:
+		return nil, fmt.Errorf("unknown processor %q, expected %s or %s", name, Cpu65C02S, Cpu6502)
+	}
+}
@@ -0,0 +1,269 @@
+package cpu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/stretchr/testify/assert"
+)
+
+// Creates a test computer with an NMOS 6502 processor
+func newNMOSComputer() (*cpu65C02S, components.Memory) {
+	cpu, ram := newComputer()
+	setNMOSVariant(cpu)
+
+	return cpu, ram
+}
+
+// Changes the processor of a test computer to an NMOS 6502
+func setNMOSVariant(cpu *cpu65C02S) {
+	nmos := newCpu6502()
+
+	cpu.variant = nmos.variant
+	cpu.instructionSet = nmos.instructionSet
+	cpu.addressModeSet = nmos.addressModeSet
+	cpu.alwaysExtra = nmos.alwaysExtra
+}
+
+func TestCpu6502_IllegalLoadAndStore(t *testing.T) {
+	cpu, ram := newNMOSComputer()
+
+	cpu.accumulatorRegister = 0xF0
+	cpu.xRegister = 0x3C
+
+	ram.Poke(0xC000, 0x87) // SAX $20 -> $F0 & $3C = $30
+	ram.Poke(0xC001, 0x20)
+	ram.Poke(0xC002, 0xA7) // LAX $10 -> A = X = $80
+	ram.Poke(0xC003, 0x10)
+	ram.Poke(0x0010, 0x80)
+
+	evaluateRMWInstruction(t, cpu, ram, 3, "", 0x0020, 0x30)
+	evaluateAccumulatorInstruction(t, cpu, ram, 3, "zN", 0x80)
+	evaluateRegisterValue(t, cpu, "X Register", cpu.xRegister, 0x80)
+}
+
+func TestCpu6502_IllegalRMW(t *testing.T) {
+	tests := []struct {
+		name        string
+		opcode      uint8
+		accumulator uint8
+		carry       bool
+		memory      uint8
+		cycles      uint64
+		flags       string
+		expectedA   uint8
+		expectedM   uint8
+	}{
+		{"SLO", 0x07, 0x01, false, 0x81, 5, "Czn", 0x03, 0x02},
+		{"RLA", 0x27, 0x0F, true, 0x81, 5, "Czn", 0x03, 0x03},
+		{"SRE", 0x47, 0xFF, false, 0x03, 5, "CzN", 0xFE, 0x01},
+		{"RRA", 0x67, 0x10, true, 0x02, 5, "czN", 0x91, 0x81},
+		{"DCP", 0xC7, 0x42, false, 0x43, 5, "CZn", 0x42, 0x42},
+		{"ISC", 0xE7, 0x20, true, 0x0F, 5, "Czn", 0x10, 0x10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram := newNMOSComputer()
+
+			cpu.accumulatorRegister = test.accumulator
+			cpu.processorStatusRegister.SetFlag(CarryFlagBit, test.carry)
+
+			ram.Poke(0xC000, test.opcode)
+			ram.Poke(0xC001, 0x10)
+			ram.Poke(0x0010, test.memory)
+
+			evaluateAccumulatorInstruction(t, cpu, ram, test.cycles, test.flags, test.expectedA)
+			evaluateAddress(t, cpu, ram, 0x0010, test.expectedM)
+		})
+	}
+}
+
+func TestCpu6502_IllegalImmediate(t *testing.T) {
+	tests := []struct {
+		name        string
+		opcode      uint8
+		accumulator uint8
+		x           uint8
+		carry       bool
+		operand     uint8
+		flags       string
+		expectedA   uint8
+		expectedX   uint8
+	}{
+		{"ANC", 0x0B, 0xFF, 0x00, false, 0x80, "CzN", 0x80, 0x00},
+		{"ALR", 0x4B, 0xFF, 0x00, false, 0x03, "Czn", 0x01, 0x00},
+		{"ARR", 0x6B, 0xFF, 0x00, true, 0xFF, "CzNv", 0xFF, 0x00},
+		{"ARR overflow", 0x6B, 0xFF, 0x00, false, 0x80, "CznV", 0x40, 0x00},
+		{"SBX", 0xCB, 0x0F, 0x3C, false, 0x02, "Czn", 0x0F, 0x0A},
+		{"ANE", 0x8B, 0x00, 0xFF, false, 0x0F, "zn", 0x0E, 0xFF},
+		{"LXA", 0xAB, 0x00, 0x00, false, 0xFF, "zN", 0xEE, 0xEE},
+		{"USBC", 0xEB, 0x10, 0x00, true, 0x01, "Czn", 0x0F, 0x00},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, ram := newNMOSComputer()
+
+			cpu.accumulatorRegister = test.accumulator
+			cpu.xRegister = test.x
+			cpu.processorStatusRegister.SetFlag(CarryFlagBit, test.carry)
+
+			ram.Poke(0xC000, test.opcode)
+			ram.Poke(0xC001, test.operand)
+
+			evaluateAccumulatorInstruction(t, cpu, ram, 2, test.flags, test.expectedA)
+			evaluateRegisterValue(t, cpu, "X Register", cpu.xRegister, test.expectedX)
+		})
+	}
+}
+
+func TestCpu6502_IllegalNOPs(t *testing.T) {
+	tests := []struct {
+		opcode uint8
+		cycles uint64
+		size   uint16
+	}{
+		{0x1A, 2, 1}, // NOP
+		{0x80, 2, 2}, // NOP #
+		{0x04, 3, 2}, // NOP zp
+		{0x14, 4, 2}, // NOP zp,x
+		{0x0C, 4, 3}, // NOP a
+		{0x1C, 4, 3}, // NOP a,x
+	}
+
+	for _, test := range tests {
+		cpu, ram := newNMOSComputer()
+
+		cpu.processorStatusRegister.SetValue(0x00)
+
+		ram.Poke(0xC000, test.opcode)
+		ram.Poke(0xC001, 0xFF)
+		ram.Poke(0xC002, 0xFF)
+
+		evaluateBranchInstruction(t, cpu, ram, test.cycles, "czidvn", 0xC000+test.size)
+	}
+}
+
+func TestCpu6502_JMPIndirectPageBug(t *testing.T) {
+	cpu, ram := newNMOSComputer()
+
+	ram.Poke(0xC000, 0x6C) // JMP ($02FF)
+	ram.Poke(0xC001, 0xFF)
+	ram.Poke(0xC002, 0x02)
+
+	ram.Poke(0x02FF, 0x34)
+	ram.Poke(0x0200, 0x12) // MSB is read from the start of the page
+	ram.Poke(0x0300, 0x56)
+
+	evaluateBranchInstruction(t, cpu, ram, 5, "", 0x1234)
+
+	// The 65C02S reads the MSB from the next page and takes one more cycle
+	cpu, ram = newComputer()
+
+	ram.Poke(0xC000, 0x6C)
+	ram.Poke(0xC001, 0xFF)
+	ram.Poke(0xC002, 0x02)
+
+	ram.Poke(0x02FF, 0x34)
+	ram.Poke(0x0200, 0x12)
+	ram.Poke(0x0300, 0x56)
+
+	evaluateBranchInstruction(t, cpu, ram, 6, "", 0x5634)
+}
+
+func TestCpu6502_DecimalFlags(t *testing.T) {
+	cpu, ram := newNMOSComputer()
+
+	cpu.processorStatusRegister.SetFlag(DecimalModeFlagBit, true)
+	cpu.accumulatorRegister = 0x99
+
+	ram.Poke(0xC000, 0x69) // ADC #$01 -> $99 + $01 = $00, N from the intermediate result and Z from the binary result
+	ram.Poke(0xC001, 0x01)
+	ram.Poke(0xC002, 0xE9) // SBC #$01 -> $00 - $01 = $99, flags from the binary result
+	ram.Poke(0xC003, 0x01)
+	ram.Poke(0xC004, 0x69) // ADC #$22 -> $99 + $22 + 1 = $22, N is set as the intermediate result is $C2
+	ram.Poke(0xC005, 0x22)
+
+	evaluateAccumulatorInstruction(t, cpu, ram, 2, "CzNv", 0x00)
+	evaluateAccumulatorInstruction(t, cpu, ram, 2, "czNv", 0x99)
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, true)
+	evaluateAccumulatorInstruction(t, cpu, ram, 2, "CzNv", 0x22)
+}
+
+func TestCpu6502_RMWWritesUnmodifiedValue(t *testing.T) {
+	cpu, ram := newNMOSComputer()
+
+	ram.Poke(0xC000, 0xE6) // INC $10
+	ram.Poke(0xC001, 0x10)
+	ram.Poke(0x0010, 0x41)
+
+	// Opcode, operand and read of the value
+	runInstructionTest(cpu, ram, 3)
+	assert.False(t, cpu.readWrite.Enabled())
+
+	// Write of the unmodified value
+	runInstructionTest(cpu, ram, 1)
+	assert.True(t, cpu.readWrite.Enabled())
+	assert.Equal(t, uint16(0x0010), cpu.addressBus.Read())
+	assert.Equal(t, uint8(0x41), cpu.dataBus.Read())
+
+	// Write of the updated value
+	runInstructionTest(cpu, ram, 1)
+	assert.True(t, cpu.readWrite.Enabled())
+	evaluateAddress(t, cpu, ram, 0x0010, 0x42)
+}
+
+func TestCpu6502_IndexedRMWAlwaysTakesExtraCycle(t *testing.T) {
+	cpu, ram := newNMOSComputer()
+
+	ram.Poke(0xC000, 0x1E) // ASL $0200,X
+	ram.Poke(0xC001, 0x00)
+	ram.Poke(0xC002, 0x02)
+	ram.Poke(0xC003, 0xEA) // NOP
+	ram.Poke(0x0200, 0x01)
+
+	runInstructionTest(cpu, ram, 6)
+	evaluateAddress(t, cpu, ram, 0x0200, 0x01)
+
+	runInstructionTest(cpu, ram, 1)
+	evaluateAddress(t, cpu, ram, 0x0200, 0x02)
+	evaluateProgramCounter(t, cpu, 0xC003)
+}
+
+func TestCpu6502_JAMHaltsProcessor(t *testing.T) {
+	// Uses independent control lines as the processor pulls the ready line low when stopped
+	cpu, ram, _, _, _, _ := newComputerWithControlLines()
+	setNMOSVariant(cpu)
+
+	ram.Poke(0xC000, 0x02) // JAM
+
+	runInstructionTest(cpu, ram, 5)
+
+	assert.True(t, cpu.processorStopped)
+	evaluateProgramCounter(t, cpu, 0xC001)
+}
+
+func TestCpu6502_NewCpu(t *testing.T) {
+	processor, err := NewCpu(Cpu6502)
+	assert.NoError(t, err)
+	assert.Equal(t, variantNMOS6502, processor.(*cpu65C02S).variant)
+
+	processor, err = NewCpu(Cpu65C02S)
+	assert.NoError(t, err)
+	assert.Equal(t, variantW65C02S, processor.(*cpu65C02S).variant)
+
+	_, err = NewCpu("z80")
+	assert.Error(t, err)
+}
+
+func TestCpu6502_StateOfOtherVariantIsRejected(t *testing.T) {
+	var buffer bytes.Buffer
+
+	assert.NoError(t, newCpu6502().SaveState(&buffer))
+	state := buffer.Bytes()
+
+	assert.Error(t, newCpu65C02S().LoadState(bytes.NewReader(state)))
+	assert.NoError(t, newCpu6502().LoadState(bytes.NewReader(state)))
+}
@@ -11,6 +11,27 @@ import (
 var addressModeSet *AddressModeSet = newAddressModesSet()
 var instructionSet *CpuInstructionSet = NewInstructionSet()
 
+// Identifies the processor emulated by a core. All variants share the same cycle engine and
+// differ on their instruction set, the cycles of some address modes and the arithmetic.
+type cpuVariant uint8
+
+const (
+	variantW65C02S  cpuVariant = 0 // WDC 65C02S
+	variantNMOS6502 cpuVariant = 1 // Original NMOS 6502
+)
+
+// ControllableProcessor is implemented by the processors whose registers can be forced between
+// instructions. It allows test benches to prepare the processor before running a routine.
+type ControllableProcessor interface {
+	components.Cpu65C02
+
+	// ForceRegisters sets the accumulator, index registers, stack pointer and status register.
+	ForceRegisters(a uint8, x uint8, y uint8, stackPointer uint8, status uint8)
+
+	// IsAtInstructionBoundary returns true if the next cycle reads the opcode of an instruction.
+	IsAtInstructionBoundary() bool
+}
+
 // Represents the WDC 65C02S processor. See https://www.westerndesigncenter.com/wdc/documentation/w65c02s.pdf
 // for details.
 // There is another document for the rockwell processor that has better data about cycle timing here:
@@ -59,6 +80,16 @@ type cpu65C02S struct {
 
 	processorPaused  bool
 	processorStopped bool
+
+	// The variant defines the instructions, the cycles of each address mode and the opcodes
+	// that always take the extra cycle of indexed address modes.
+	variant        cpuVariant
+	instructionSet *CpuInstructionSet
+	addressModeSet *AddressModeSet
+	alwaysExtra    []uint8
+
+	// Memory map where the values are written while executing an instruction in the fast mode
+	fastMemory *MemoryMap
 }
 
 // NewCpu65C02S creates a new instance of the WDC 65C02S processor with default initialization values.
@@ -95,6 +126,11 @@ func newCpu65C02S() *cpu65C02S {
 
 		currentCycle: readOpCode,
 		nextCycle:    readOpCode,
+
+		variant:        variantW65C02S,
+		instructionSet: instructionSet,
+		addressModeSet: addressModeSet,
+		alwaysExtra:    alwaysExtra,
 	}
 
 	cpu.setDefaultValues()
@@ -311,7 +347,7 @@ func (cpu *cpu65C02S) checkReset() {
 		cpu.cyclesWithReset++
 
 		if cpu.cyclesWithReset >= 2 {
-			cpu.nextAddressMode = GetAddressMode(AddressModeReset)
+			cpu.nextAddressMode = cpu.addressModeSet.GetByName(AddressModeReset)
 			cpu.nextCycle = interruptCycle
 
 			cpu.setDefaultValues()
@@ -378,10 +414,10 @@ func (cpu *cpu65C02S) moveToNextCycle() {
 
 		switch {
 		case cpu.nmiRequested:
-			cpu.nextAddressMode = GetAddressMode(AddressModeNMI)
+			cpu.nextAddressMode = cpu.addressModeSet.GetByName(AddressModeNMI)
 			cpu.nextCycle = interruptCycle
 		case cpu.irqRequested:
-			cpu.nextAddressMode = GetAddressMode(AddressModeIRQ)
+			cpu.nextAddressMode = cpu.addressModeSet.GetByName(AddressModeIRQ)
 			cpu.nextCycle = interruptCycle
 		default:
 			cpu.nextCycle = readOpCode
@@ -422,7 +458,7 @@ func (cpu *cpu65C02S) addToInstructionRegister(value uint16) {
 	data := (original & 0xff) + value
 	cpu.instructionRegister += value
 
-	if data > 0xFF || slices.Contains(alwaysExtra, uint8(cpu.currentOpCode)) {
+	if data > 0xFF || slices.Contains(cpu.alwaysExtra, uint8(cpu.currentOpCode)) {
 		cpu.instructionRegisterCarry = true
 	}
 }
@@ -442,7 +478,7 @@ func (cpu *cpu65C02S) addToInstructionRegisterRelative(value uint16) {
 
 	newMSB := cpu.instructionRegister & 0xFF00
 
-	if originalMSB != newMSB || slices.Contains(alwaysExtra, uint8(cpu.currentOpCode)) {
+	if originalMSB != newMSB || slices.Contains(cpu.alwaysExtra, uint8(cpu.currentOpCode)) {
 		cpu.instructionRegisterCarry = true
 	}
 }
@@ -503,6 +539,11 @@ func (cpu *cpu65C02S) setReadBus(address uint16) {
 // Configures the processor to write the data parameter into the
 // specified address.
 func (cpu *cpu65C02S) setWriteBus(address uint16, data uint8) {
+	if cpu.fastMemory != nil {
+		cpu.fastMemory.write(address, data)
+		return
+	}
+
 	if cpu.busEnable.Enabled() {
 		cpu.readWrite.SetEnable(true)
 		cpu.addressBus.Write(address)
@@ -562,6 +603,22 @@ func (cpu *cpu65C02S) ForceProgramCounter(value uint16) {
 	cpu.programCounter = value
 }
 
+// Forces the values of the accumulator, the index registers, the stack pointer and the processor
+// status register. The B and unused flags of the status register are always set.
+func (cpu *cpu65C02S) ForceRegisters(a uint8, x uint8, y uint8, stackPointer uint8, status uint8) {
+	cpu.accumulatorRegister = a
+	cpu.xRegister = x
+	cpu.yRegister = y
+	cpu.stackPointer = stackPointer
+	cpu.processorStatusRegister.SetValue(status)
+}
+
+// Returns if the processor completed an instruction and the next cycle reads the opcode of the
+// following one. It's false while an instruction or an interrupt sequence is in progress.
+func (cpu *cpu65C02S) IsAtInstructionBoundary() bool {
+	return cpu.nextCycleIndex == 0 && cpu.nextCycle.signaling.sync
+}
+
 // Returns the current value of the program counter
 func (cpu *cpu65C02S) GetProgramCounter() uint16 {
 	return cpu.programCounter
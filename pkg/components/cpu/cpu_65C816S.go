@@ -0,0 +1,831 @@
+package cpu
+
+import (
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+)
+
+var addressModes65C816S *addressModeSet65C816S = newAddressModeSet65C816S()
+var instructions65C816S *instructionSet65C816S = newInstructionSet65C816S()
+
+// Interrupt vectors of the 65C816S in native and emulation mode. In emulation mode BRK uses the
+// IRQ vector.
+const (
+	vectorNativeCOP   uint16 = 0xFFE4
+	vectorNativeBRK   uint16 = 0xFFE6
+	vectorNativeAbort uint16 = 0xFFE8
+	vectorNativeNMI   uint16 = 0xFFEA
+	vectorNativeIRQ   uint16 = 0xFFEE
+
+	vectorEmulationCOP   uint16 = 0xFFF4
+	vectorEmulationAbort uint16 = 0xFFF8
+	vectorEmulationNMI   uint16 = 0xFFFA
+	vectorReset          uint16 = 0xFFFC
+	vectorEmulationIRQ   uint16 = 0xFFFE
+)
+
+// Represents the WDC W65C816S processor. See https://www.westerndesigncenter.com/wdc/documentation/w65c816s.pdf
+// for details.
+//
+// The 65C816S starts in emulation mode, where it behaves as a 65C02 with 8 bit registers and the stack
+// on page 1. XCE switches to native mode, where the accumulator and index registers can be 8 or 16 bits
+// wide according to the M and X flags and 24 bits addresses are formed with the data and program bank
+// registers.
+//
+// Unlike the 65C02S core, the cycles of each instruction are not fixed. The instruction is decoded
+// when the opcode is read and the cycles required by the address mode, the width of the registers and
+// the value of the direct register are planned at that moment. Cycles that depend on the values read,
+// such as page boundary crossing, are skipped if not needed.
+type cpu65C816S struct {
+	addressBus           *buses.BusConnector[uint16]
+	bankAddress          *buses.BusConnector[uint8]
+	dataBus              *buses.BusConnector[uint8]
+	abort                *buses.ConnectorEnabledLow
+	busEnable            *buses.ConnectorEnabledHigh
+	emulation            *buses.ConnectorEnabledHigh
+	interruptRequest     *buses.ConnectorEnabledLow
+	memoryIndexSelect    *buses.ConnectorEnabledHigh
+	memoryLock           *buses.ConnectorEnabledLow
+	nonMaskableInterrupt *buses.ConnectorEnabledLow
+	readWrite            *buses.ConnectorEnabledLow
+	ready                *buses.ConnectorEnabledHigh
+	reset                *buses.ConnectorEnabledLow
+	validDataAddress     *buses.ConnectorEnabledHigh
+	validProgramAddress  *buses.ConnectorEnabledHigh
+	vectorPull           *buses.ConnectorEnabledLow
+
+	accumulatorRegister     uint16
+	xRegister               uint16
+	yRegister               uint16
+	stackPointer            uint16
+	directRegister          uint16
+	dataBankRegister        uint8
+	programBankRegister     uint8
+	programCounter          uint16
+	processorStatusRegister statusRegister
+	emulationMode           bool
+
+	// Cycles of the instruction being executed and index of the current one
+	steps     []step65C816S
+	stepIndex int
+
+	currentInstruction *instructionData65C816S
+	currentAddressMode *addressModeData65C816S
+
+	// Values used while executing an instruction. Addresses are 24 bits, the bank is on bits 16 to 23.
+	width16       bool
+	operand       uint32
+	operandBytes  uint8
+	pointer       uint32
+	address       uint32
+	data          uint16
+	returnAddress uint16
+	vector        uint16
+
+	dataInBank0     bool
+	pointerWrapPage bool
+	fullStack       bool
+	indexCarry      bool
+	branchTaken     bool
+	pageCrossed     bool
+	breakFlag       bool
+
+	// Registers when the instruction started, restored if it is aborted
+	abortSnapshot   registers65C816S
+	abortAddress    uint16
+	abortRequested  bool
+	irqRequested    bool
+	nmiRequested    bool
+	previousNMI     bool
+	previousAbort   bool
+	cyclesWithReset uint8
+
+	processorPaused  bool
+	processorStopped bool
+
+	// Phases of the current cycle, the data written is put on the bus when PHI2 goes high
+	phi2LowDone  bool
+	writePending bool
+	writeData    uint8
+}
+
+// Values of the registers that are restored when an instruction is aborted
+type registers65C816S struct {
+	accumulatorRegister     uint16
+	xRegister               uint16
+	yRegister               uint16
+	stackPointer            uint16
+	directRegister          uint16
+	dataBankRegister        uint8
+	programBankRegister     uint8
+	processorStatusRegister statusRegister
+	emulationMode           bool
+}
+
+// NewCpu65C816S creates a new instance of the WDC W65C816S processor in emulation mode.
+// The low 16 bits of the address are on the address bus and the bank is on the data bus while PHI2
+// is low. BankAddress also has the bank, as the output of the latch used to demultiplex it.
+func NewCpu65C816S() components.Cpu65C816 {
+	return newCpu65C816S()
+}
+
+// Creates a 65C816S with typical values for all registers, address and data bus are not connected
+func newCpu65C816S() *cpu65C816S {
+	cpu := &cpu65C816S{
+		addressBus:  buses.NewBusConnector[uint16](),
+		bankAddress: buses.NewBusConnector[uint8](),
+		dataBus:     buses.NewBusConnector[uint8](),
+
+		abort:                buses.NewConnectorEnabledLow(),
+		busEnable:            buses.NewConnectorEnabledHigh(),
+		emulation:            buses.NewConnectorEnabledHigh(),
+		interruptRequest:     buses.NewConnectorEnabledLow(),
+		memoryIndexSelect:    buses.NewConnectorEnabledHigh(),
+		memoryLock:           buses.NewConnectorEnabledLow(),
+		nonMaskableInterrupt: buses.NewConnectorEnabledLow(),
+		readWrite:            buses.NewConnectorEnabledLow(),
+		ready:                buses.NewConnectorEnabledHigh(),
+		reset:                buses.NewConnectorEnabledLow(),
+		validDataAddress:     buses.NewConnectorEnabledHigh(),
+		validProgramAddress:  buses.NewConnectorEnabledHigh(),
+		vectorPull:           buses.NewConnectorEnabledLow(),
+
+		programCounter: 0xFFFC,
+
+		steps: make([]step65C816S, 0, 16),
+	}
+
+	cpu.setDefaultValues()
+	cpu.startNextInstruction()
+
+	return cpu
+}
+
+/*
+ ****************************************************
+ * Control Lines
+ ****************************************************
+ */
+
+// The sixteen bit Address Bus formed by A0-A15. The address lines can be set to the high impedance
+// state by the Bus Enable (BE) signal.
+func (cpu *cpu65C816S) AddressBus() *buses.BusConnector[uint16] {
+	return cpu.addressBus
+}
+
+// Bits 16 to 23 of the address. On the chip they are multiplexed on D0-D7 while PHI2 is low, see
+// TickPhi2Low, and must be latched by the system. For the computers that don't emulate the latch
+// the bank is also written here, as its output.
+func (cpu *cpu65C816S) BankAddress() *buses.BusConnector[uint8] {
+	return cpu.bankAddress
+}
+
+// The eight Data Bus lines D0-D7 are used to transfer data between the processor and memory or
+// I/O devices.
+func (cpu *cpu65C816S) DataBus() *buses.BusConnector[uint8] {
+	return cpu.dataBus
+}
+
+// The Abort input prevents modification of the registers by the current instruction. When it
+// completes, the processor pushes the address of the aborted instruction and jumps to the abort
+// vector, so returning from the handler executes the instruction again.
+func (cpu *cpu65C816S) Abort() *buses.ConnectorEnabledLow {
+	return cpu.abort
+}
+
+// The Bus Enable (BE) input signal provides external control of the Address, Data and the RWB
+// buffers.
+func (cpu *cpu65C816S) BusEnable() *buses.ConnectorEnabledHigh {
+	return cpu.busEnable
+}
+
+// The Emulation Status output (E) is high while the processor is in emulation mode.
+func (cpu *cpu65C816S) Emulation() *buses.ConnectorEnabledHigh {
+	return cpu.emulation
+}
+
+// The Interrupt Request (IRQB) input signal is used to request that an interrupt sequence be
+// initiated. The request is served when the current instruction completes if the I flag is clear.
+func (cpu *cpu65C816S) InterruptRequest() *buses.ConnectorEnabledLow {
+	return cpu.interruptRequest
+}
+
+// The Memory and Index Select Status output (MX) reflects the M flag while PHI2 is high and the X
+// flag while PHI2 is low. The emulation shows the M flag.
+func (cpu *cpu65C816S) MemoryIndexSelect() *buses.ConnectorEnabledHigh {
+	return cpu.memoryIndexSelect
+}
+
+// The Memory Lock (MLB) output is low during the read, modify and write cycles of Read-Modify-Write
+// instructions.
+func (cpu *cpu65C816S) MemoryLock() *buses.ConnectorEnabledLow {
+	return cpu.memoryLock
+}
+
+// A negative transition on the Non-Maskable Interrupt (NMIB) input initiates an interrupt sequence
+// after the current instruction is completed.
+func (cpu *cpu65C816S) NonMaskableInterrupt() *buses.ConnectorEnabledLow {
+	return cpu.nonMaskableInterrupt
+}
+
+// The Read/Write (RWB) output signal is used to control data transfer. When in the high state,
+// the microprocessor is reading data from memory or I/O. When in the low state, the Data Bus
+// contains valid data to be written from the microprocessor.
+func (cpu *cpu65C816S) ReadWrite() *buses.ConnectorEnabledLow {
+	return cpu.readWrite
+}
+
+// A low input logic level on the Ready (RDY) will halt the microprocessor. The processor pulls
+// the line low while executing WAI or after STP.
+func (cpu *cpu65C816S) Ready() *buses.ConnectorEnabledHigh {
+	return cpu.ready
+}
+
+// The Reset (RESB) input is used to initialize the microprocessor and start program execution.
+// It must be held low for at least two clock cycles. The processor starts in emulation mode.
+func (cpu *cpu65C816S) Reset() *buses.ConnectorEnabledLow {
+	return cpu.reset
+}
+
+// The Valid Data Address (VDA) output is high when the address bus holds a valid data address.
+// When VDA and VPA are both high the processor is reading an opcode.
+func (cpu *cpu65C816S) ValidDataAddress() *buses.ConnectorEnabledHigh {
+	return cpu.validDataAddress
+}
+
+// The Valid Program Address (VPA) output is high when the address bus holds a valid program
+// address. When VDA and VPA are both low the processor is executing an internal operation.
+func (cpu *cpu65C816S) ValidProgramAddress() *buses.ConnectorEnabledHigh {
+	return cpu.validProgramAddress
+}
+
+// The Vector Pull (VPB) output indicates that a vector location is being addressed during an
+// interrupt sequence.
+func (cpu *cpu65C816S) VectorPull() *buses.ConnectorEnabledLow {
+	return cpu.vectorPull
+}
+
+/*
+ ****************************************************
+ * Timing
+ ****************************************************
+ */
+
+// TickPhi2Low executes the first half of the cycle, while PHI2 is low. The processor sets the
+// address and control lines and puts the bank of the address on the data bus, where the latch
+// of the bank must capture it before the components tick. If it's not called, Tick does it.
+func (cpu *cpu65C816S) TickPhi2Low(context *common.StepContext) {
+	cpu.phi2LowDone = true
+
+	if cpu.processorPaused || cpu.processorStopped {
+		cpu.ready.SetEnable(false)
+	}
+
+	if !cpu.processorStopped {
+		cpu.checkInterrupts()
+
+		// If the processor was paused by a WAI instruction and an interrupt is requested
+		// then it must be unpaused
+		if cpu.processorPaused {
+			if cpu.nonMaskableInterrupt.Enabled() || cpu.interruptRequest.Enabled() || cpu.abort.Enabled() {
+				cpu.processorPaused = false
+				cpu.ready.SetEnable(true)
+			}
+		}
+
+		if cpu.ready.Enabled() {
+			cpu.emulation.SetEnable(cpu.emulationMode)
+			cpu.memoryIndexSelect.SetEnable(cpu.processorStatusRegister.Flag(MemorySelectFlagBit))
+
+			cpu.executeStep()
+		}
+	}
+}
+
+// As part of the emulation for every cycle we will execute 2 functions:
+// First Tick for all emulated components and then PostTick.
+// The processor sets the buses and lines for the current cycle on Tick, when PHI2 goes high
+// the bank is replaced on the data bus by the value written, if any.
+func (cpu *cpu65C816S) Tick(context *common.StepContext) {
+	if !cpu.phi2LowDone {
+		cpu.TickPhi2Low(context)
+	}
+
+	cpu.phi2LowDone = false
+
+	if cpu.writePending {
+		cpu.dataBus.Write(cpu.writeData)
+		cpu.writePending = false
+	}
+}
+
+// As part of the emulation for every cycle we will execute 2 functions:
+// First Tick for all emulated components and then PostTick.
+// The processor reads the data from the bus and moves to the next cycle on PostTick.
+func (cpu *cpu65C816S) PostTick(context *common.StepContext) {
+	if cpu.ready.Enabled() {
+		cpu.steps[cpu.stepIndex].postCycle(cpu)
+		cpu.moveToNextStep()
+	}
+
+	// If cpu is stopped only reset can remove it from that state
+	cpu.checkReset()
+}
+
+// Executes the current cycle. If the cycle is not needed, for example the extra cycle of a page
+// boundary crossing, it moves to the next one on the same tick.
+func (cpu *cpu65C816S) executeStep() {
+	for {
+		step := &cpu.steps[cpu.stepIndex]
+
+		cpu.memoryLock.SetEnable(step.memoryLock)
+
+		if step.cycle(cpu) {
+			return
+		}
+
+		cpu.moveToNextStep()
+	}
+}
+
+// Moves to the next cycle of the instruction, or starts the next one if there are no more cycles.
+func (cpu *cpu65C816S) moveToNextStep() {
+	cpu.stepIndex++
+
+	if cpu.stepIndex >= len(cpu.steps) {
+		cpu.startNextInstruction()
+	}
+}
+
+// Plans the first cycle of the next instruction. If an interrupt was requested the interrupt
+// sequence is executed instead.
+func (cpu *cpu65C816S) startNextInstruction() {
+	cpu.steps = cpu.steps[:0]
+	cpu.stepIndex = 0
+
+	// The stack is always on page 1 in emulation mode, some instructions can temporarily use
+	// the full stack pointer
+	if cpu.emulationMode {
+		cpu.stackPointer = 0x0100 | cpu.stackPointer&0x00FF
+	}
+
+	switch {
+	case cpu.abortRequested:
+		cpu.abortRequested = false
+		cpu.restoreAbortSnapshot()
+		cpu.planInterrupt(AddressModeAbort, vectorNativeAbort, vectorEmulationAbort)
+	case cpu.nmiRequested:
+		cpu.nmiRequested = false
+		cpu.planInterrupt(AddressModeNMI, vectorNativeNMI, vectorEmulationNMI)
+	case cpu.irqRequested:
+		cpu.irqRequested = false
+		cpu.planInterrupt(AddressModeIRQ, vectorNativeIRQ, vectorEmulationIRQ)
+	default:
+		cpu.steps = append(cpu.steps, step65C816SReadOpCode)
+	}
+}
+
+// Plans the cycles of an interrupt. The first 2 cycles are internal operations done instead of
+// reading the next opcode.
+func (cpu *cpu65C816S) planInterrupt(name components.AddressMode, native uint16, emulation uint16) {
+	cpu.currentAddressMode = addressModes65C816S.GetByName(name)
+	cpu.returnAddress = cpu.programCounter
+	cpu.breakFlag = false
+
+	cpu.steps = append(cpu.steps, step65C816SInternal, step65C816SInternal)
+	cpu.planInterruptSequence(native, emulation)
+}
+
+// Plans the cycles shared by interrupts, BRK and COP that push the return address and the
+// status and load the vector
+func (cpu *cpu65C816S) planInterruptSequence(native uint16, emulation uint16) {
+	if cpu.emulationMode {
+		cpu.vector = emulation
+	} else {
+		cpu.vector = native
+		cpu.steps = append(cpu.steps, step65C816SPushProgramBank)
+	}
+
+	cpu.steps = append(cpu.steps,
+		step65C816SPushReturnHigh,
+		step65C816SPushReturnLow,
+		step65C816SPushStatus,
+		step65C816SReadVectorLow,
+		step65C816SReadVectorHigh,
+	)
+}
+
+// Decodes the opcode and plans the cycles of the instruction
+func (cpu *cpu65C816S) decode(opCode uint8) {
+	cpu.currentInstruction = instructions65C816S.GetByOpCode(components.OpCode(opCode))
+	cpu.currentAddressMode = addressModes65C816S.GetByName(cpu.currentInstruction.addressMode)
+
+	cpu.abortAddress = cpu.programCounter
+	cpu.abortSnapshot = cpu.takeAbortSnapshot()
+
+	cpu.programCounter++
+
+	cpu.width16 = cpu.is16Bit(cpu.currentInstruction.width)
+	cpu.operand = 0
+	cpu.operandBytes = 0
+	cpu.dataInBank0 = false
+	cpu.pointerWrapPage = false
+	cpu.indexCarry = false
+	cpu.branchTaken = false
+	cpu.pageCrossed = false
+	cpu.fullStack = usesFullStack(cpu.currentInstruction.mnemonic)
+
+	cpu.currentAddressMode.plan(cpu, cpu.currentInstruction)
+}
+
+// Returns if the registers or memory accessed with the specified width are 16 bits
+func (cpu *cpu65C816S) is16Bit(width registerWidth) bool {
+	switch width {
+	case widthMemory:
+		return !cpu.processorStatusRegister.Flag(MemorySelectFlagBit)
+	case widthIndex:
+		return !cpu.processorStatusRegister.Flag(IndexRegisterSelectFlagBit)
+	case widthWord:
+		return true
+	default:
+		return false
+	}
+}
+
+// Check the interrupt lines and marks if an interrupt was requested. The interrupts are served once the current
+// instruction completes.
+func (cpu *cpu65C816S) checkInterrupts() {
+	nmiEnabled := cpu.nonMaskableInterrupt.Enabled()
+	abortEnabled := cpu.abort.Enabled()
+
+	if !cpu.irqRequested && cpu.interruptRequest.Enabled() && !cpu.processorStatusRegister.Flag(IrqDisableFlagBit) {
+		cpu.irqRequested = true
+	}
+
+	// NMI and ABORT are edge enabled, only will trigger interrupt when they transition from high to low.
+	if !cpu.previousNMI && nmiEnabled {
+		cpu.nmiRequested = true
+	}
+	cpu.previousNMI = nmiEnabled
+
+	if !cpu.previousAbort && abortEnabled {
+		cpu.abortRequested = true
+	}
+	cpu.previousAbort = abortEnabled
+}
+
+// Reset must be held low for 2 cycles for the processor to reset.
+// This function checks the number of cycles it has been enabled and resets the CPU if needed
+func (cpu *cpu65C816S) checkReset() {
+	if cpu.reset.Enabled() {
+		cpu.cyclesWithReset++
+
+		if cpu.cyclesWithReset >= 2 {
+			cpu.setDefaultValues()
+
+			cpu.currentAddressMode = addressModes65C816S.GetByName(AddressModeReset)
+			cpu.vector = vectorReset
+
+			cpu.steps = append(cpu.steps[:0],
+				step65C816SInternal,
+				step65C816SInternal,
+				step65C816SReadStack,
+				step65C816SReadStack,
+				step65C816SReadStack,
+				step65C816SReadVectorLow,
+				step65C816SReadVectorHigh,
+			)
+			cpu.stepIndex = 0
+		}
+	} else {
+		cpu.cyclesWithReset = 0
+	}
+}
+
+// Sets the values of the registers after a reset. The processor is in emulation mode, with the
+// direct page and both banks set to 0 and the stack on page 1.
+func (cpu *cpu65C816S) setDefaultValues() {
+	cpu.emulationMode = true
+	cpu.directRegister = 0x0000
+	cpu.dataBankRegister = 0x00
+	cpu.programBankRegister = 0x00
+	cpu.stackPointer = 0x01FD
+	cpu.xRegister &= 0x00FF
+	cpu.yRegister &= 0x00FF
+
+	// Set default value for flags M, X and I   (NVMXDIZC) = 0x34
+	cpu.processorStatusRegister = statusRegister(0b00110100)
+
+	cpu.irqRequested = false
+	cpu.nmiRequested = false
+	cpu.abortRequested = false
+	cpu.previousNMI = false
+	cpu.previousAbort = false
+	cpu.cyclesWithReset = 0
+
+	cpu.processorPaused = false
+	cpu.processorStopped = false
+}
+
+// Returns the registers that are restored if the instruction is aborted
+func (cpu *cpu65C816S) takeAbortSnapshot() registers65C816S {
+	return registers65C816S{
+		accumulatorRegister:     cpu.accumulatorRegister,
+		xRegister:               cpu.xRegister,
+		yRegister:               cpu.yRegister,
+		stackPointer:            cpu.stackPointer,
+		directRegister:          cpu.directRegister,
+		dataBankRegister:        cpu.dataBankRegister,
+		programBankRegister:     cpu.programBankRegister,
+		processorStatusRegister: cpu.processorStatusRegister,
+		emulationMode:           cpu.emulationMode,
+	}
+}
+
+// Restores the registers to the values they had before the aborted instruction. The return address
+// of the abort interrupt is the address of the aborted instruction.
+func (cpu *cpu65C816S) restoreAbortSnapshot() {
+	snapshot := &cpu.abortSnapshot
+
+	cpu.accumulatorRegister = snapshot.accumulatorRegister
+	cpu.xRegister = snapshot.xRegister
+	cpu.yRegister = snapshot.yRegister
+	cpu.stackPointer = snapshot.stackPointer
+	cpu.directRegister = snapshot.directRegister
+	cpu.dataBankRegister = snapshot.dataBankRegister
+	cpu.programBankRegister = snapshot.programBankRegister
+	cpu.processorStatusRegister = snapshot.processorStatusRegister
+	cpu.emulationMode = snapshot.emulationMode
+	cpu.programCounter = cpu.abortAddress
+}
+
+/*
+ ****************************************************
+ * Registers
+ ****************************************************
+ */
+
+// Sets the status register. In emulation mode the M and X flags are always set, when X is set
+// the high byte of the index registers is cleared.
+func (cpu *cpu65C816S) setStatus(value uint8) {
+	if cpu.emulationMode {
+		value |= 0x30
+	}
+
+	cpu.processorStatusRegister = statusRegister(value)
+
+	if cpu.processorStatusRegister.Flag(IndexRegisterSelectFlagBit) {
+		cpu.xRegister &= 0x00FF
+		cpu.yRegister &= 0x00FF
+	}
+}
+
+// Switches between native and emulation mode. Entering emulation mode sets the M and X flags
+// and moves the stack to page 1.
+func (cpu *cpu65C816S) setEmulationMode(emulation bool) {
+	cpu.emulationMode = emulation
+
+	if emulation {
+		cpu.stackPointer = 0x0100 | cpu.stackPointer&0x00FF
+		cpu.setStatus(uint8(cpu.processorStatusRegister))
+	}
+}
+
+// Returns the 24 bits address of the program counter
+func (cpu *cpu65C816S) programAddress() uint32 {
+	return uint32(cpu.programBankRegister)<<16 | uint32(cpu.programCounter)
+}
+
+// Returns the address on the direct page at the specified offset, always on bank 0.
+func (cpu *cpu65C816S) directAddress(offset uint16) uint32 {
+	return uint32(cpu.directRegister + offset)
+}
+
+// Returns the address on the direct page indexed by the specified register. In emulation mode
+// when the low byte of the direct register is 0 the address wraps on the direct page.
+func (cpu *cpu65C816S) directIndexedAddress(offset uint16, index uint16) uint32 {
+	if cpu.directPageWraps() {
+		return uint32(cpu.directRegister | (offset+index)&0x00FF)
+	}
+
+	return uint32(cpu.directRegister + offset + index)
+}
+
+// In emulation mode, when the low byte of the direct register is 0, the 65C02 address modes wrap
+// on the direct page as the zero page does.
+func (cpu *cpu65C816S) directPageWraps() bool {
+	return cpu.emulationMode && cpu.directRegister&0x00FF == 0
+}
+
+// Returns the address of the next byte of the effective address. Data on direct page and stack wrap
+// on bank 0, otherwise the address crosses to the next bank.
+func (cpu *cpu65C816S) nextDataAddress() uint32 {
+	if cpu.dataInBank0 {
+		return uint32(uint16(cpu.address) + 1)
+	}
+
+	return (cpu.address + 1) & 0xFFFFFF
+}
+
+// Returns the address of the specified byte of the pointer. Pointers wrap on their bank, on the
+// direct page in emulation mode when the pointer is read by a 65C02 address mode.
+func (cpu *cpu65C816S) pointerAddress(index uint32) uint32 {
+	if cpu.pointerWrapPage {
+		return cpu.pointer&0xFFFF00 | (cpu.pointer+index)&0x0000FF
+	}
+
+	return cpu.pointer&0xFF0000 | (cpu.pointer+index)&0x00FFFF
+}
+
+// Adds the index to the effective address. Reads need the extra cycle if a page boundary is crossed
+// or the index registers are 16 bits, writes and RMW instructions always need it.
+func (cpu *cpu65C816S) addIndex(index uint16) {
+	base := cpu.address
+	cpu.address = (base + uint32(index)) & 0xFFFFFF
+
+	// Address read on the extra cycle, the base with the low byte indexed
+	cpu.pointer = base&0xFFFF00 | (base+uint32(index))&0x0000FF
+
+	cpu.indexCarry = base&0xFFFF00 != cpu.address&0xFFFF00 ||
+		!cpu.processorStatusRegister.Flag(IndexRegisterSelectFlagBit) ||
+		cpu.currentInstruction.access() != accessRead
+}
+
+// Returns the address on top of the stack, the stack is always on bank 0.
+func (cpu *cpu65C816S) stackAddress() uint32 {
+	return uint32(cpu.stackPointer)
+}
+
+// Decrements the stack pointer after a push. In emulation mode it wraps on page 1, except for
+// the instructions added by the 65C816S that can use the full stack pointer.
+func (cpu *cpu65C816S) decrementStackPointer() {
+	if cpu.emulationMode && !cpu.fullStack {
+		cpu.stackPointer = 0x0100 | (cpu.stackPointer-1)&0x00FF
+	} else {
+		cpu.stackPointer--
+	}
+}
+
+// Returns the value of the stack pointer after a pull. In emulation mode it wraps on page 1, except
+// for the instructions added by the 65C816S that can use the full stack pointer.
+func (cpu *cpu65C816S) nextStackPointer() uint16 {
+	if cpu.emulationMode && !cpu.fullStack {
+		return 0x0100 | (cpu.stackPointer+1)&0x00FF
+	}
+
+	return cpu.stackPointer + 1
+}
+
+// In emulation mode the instructions added by the 65C816S can push or pull bytes outside of page 1,
+// the stack pointer is moved back to page 1 when the instruction completes.
+func usesFullStack(mnemonic components.Mnemonic) bool {
+	switch mnemonic {
+	case JSL, PEA, PEI, PER, PHD, PLB, PLD, RTL:
+		return true
+	}
+
+	return false
+}
+
+/*
+ ****************************************************
+ * Internal Bus Handling
+ ****************************************************
+ */
+
+// Configures the processor to read from the specified 24 bits address. VDA and VPA signal the
+// type of cycle: data, program, opcode (both) or internal operation (none).
+func (cpu *cpu65C816S) setReadBus(address uint32, validData bool, validProgram bool) {
+	cpu.validDataAddress.SetEnable(validData)
+	cpu.validProgramAddress.SetEnable(validProgram)
+	cpu.vectorPull.SetEnable(false)
+
+	if cpu.busEnable.Enabled() {
+		cpu.readWrite.SetEnable(false)
+		cpu.setAddress(address)
+	}
+}
+
+// Configures the processor to write the data parameter into the specified 24 bits address. The
+// data is put on the bus when PHI2 goes high.
+func (cpu *cpu65C816S) setWriteBus(address uint32, data uint8) {
+	cpu.validDataAddress.SetEnable(true)
+	cpu.validProgramAddress.SetEnable(false)
+	cpu.vectorPull.SetEnable(false)
+
+	if cpu.busEnable.Enabled() {
+		cpu.readWrite.SetEnable(true)
+		cpu.setAddress(address)
+
+		cpu.writePending = true
+		cpu.writeData = data
+	}
+}
+
+// Sets the low 16 bits of the address on the address bus and the bank on the data bus, as it's
+// done while PHI2 is low, and on the bank address.
+func (cpu *cpu65C816S) setAddress(address uint32) {
+	bank := uint8(address >> 16)
+
+	cpu.addressBus.Write(uint16(address))
+	cpu.dataBus.Write(bank)
+	cpu.bankAddress.Write(bank)
+}
+
+/*
+ ****************************************************
+ * Public Methods
+ ****************************************************
+ */
+
+// Returns the value of the A accumulator, the low byte of C
+func (cpu *cpu65C816S) GetAccumulatorRegister() uint8 {
+	return uint8(cpu.accumulatorRegister)
+}
+
+// Returns the low byte of the X register
+func (cpu *cpu65C816S) GetXRegister() uint8 {
+	return uint8(cpu.xRegister)
+}
+
+// Returns the low byte of the Y register
+func (cpu *cpu65C816S) GetYRegister() uint8 {
+	return uint8(cpu.yRegister)
+}
+
+// Returns the low byte of the stack pointer
+func (cpu *cpu65C816S) GetStackPointer() uint8 {
+	return uint8(cpu.stackPointer)
+}
+
+// Returns the current value of the processor status register
+func (cpu *cpu65C816S) GetProcessorStatusRegister() components.StatusRegister {
+	return cpu.processorStatusRegister
+}
+
+// Returns the current value of the program counter
+func (cpu *cpu65C816S) GetProgramCounter() uint16 {
+	return cpu.programCounter
+}
+
+// Forces the value of the program counter
+func (cpu *cpu65C816S) ForceProgramCounter(value uint16) {
+	cpu.programCounter = value
+}
+
+// Returns the value of the C accumulator, formed by B on the high byte and A on the low byte
+func (cpu *cpu65C816S) GetAccumulatorRegister16() uint16 {
+	return cpu.accumulatorRegister
+}
+
+// Returns the value of the X register
+func (cpu *cpu65C816S) GetXRegister16() uint16 {
+	return cpu.xRegister
+}
+
+// Returns the value of the Y register
+func (cpu *cpu65C816S) GetYRegister16() uint16 {
+	return cpu.yRegister
+}
+
+// Returns the value of the stack pointer
+func (cpu *cpu65C816S) GetStackPointer16() uint16 {
+	return cpu.stackPointer
+}
+
+// Returns the value of the direct register, the start of the direct page
+func (cpu *cpu65C816S) GetDirectRegister() uint16 {
+	return cpu.directRegister
+}
+
+// Returns the value of the data bank register
+func (cpu *cpu65C816S) GetDataBankRegister() uint8 {
+	return cpu.dataBankRegister
+}
+
+// Returns the value of the program bank register
+func (cpu *cpu65C816S) GetProgramBankRegister() uint8 {
+	return cpu.programBankRegister
+}
+
+// Returns true if the processor is in emulation mode
+func (cpu *cpu65C816S) IsEmulationMode() bool {
+	return cpu.emulationMode
+}
+
+// Returns if the processor is reading an opcode, this is when VDA and VPA are both high
+func (cpu *cpu65C816S) IsReadingOpcode() bool {
+	return cpu.validDataAddress.Enabled() && cpu.validProgramAddress.Enabled()
+}
+
+// Returns data about the current instruction being executed by the processor
+func (cpu *cpu65C816S) GetCurrentInstruction() components.CpuInstructionData {
+	return cpu.currentInstruction
+}
+
+// Returns data about the address mode of the current instruction being
+// executed by the processor.
+func (cpu *cpu65C816S) GetCurrentAddressMode() components.AddressModeData {
+	return cpu.currentAddressMode
+}
@@ -0,0 +1,389 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// Creates a 65C816S connected to 64K of RAM, the bank address is connected to its own bus to
+// allow checking it. Returns the IRQ line to allow testing interrupts.
+func newComputer65C816S() (*cpu65C816S, components.Memory, *buses.StandaloneLine) {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+	bankBus := buses.New8BitStandaloneBus()
+
+	alwaysHighLine := buses.NewStandaloneLine(true)
+	alwaysLowLine := buses.NewStandaloneLine(false)
+	writeEnableLine := buses.NewStandaloneLine(true)
+	irqLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(alwaysLowLine)
+	ram.OutputEnable().Connect(alwaysLowLine)
+
+	cpu := newCpu65C816S()
+	cpu.AddressBus().Connect(addressBus)
+	cpu.BankAddress().Connect(bankBus)
+	cpu.DataBus().Connect(dataBus)
+
+	cpu.BusEnable().Connect(alwaysHighLine)
+	cpu.ReadWrite().Connect(writeEnableLine)
+	cpu.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	cpu.ValidDataAddress().Connect(buses.NewStandaloneLine(false))
+	cpu.ValidProgramAddress().Connect(buses.NewStandaloneLine(false))
+	cpu.VectorPull().Connect(buses.NewStandaloneLine(false))
+	cpu.Emulation().Connect(buses.NewStandaloneLine(false))
+	cpu.MemoryIndexSelect().Connect(buses.NewStandaloneLine(false))
+	cpu.Ready().Connect(buses.NewStandaloneLine(true))
+	cpu.Reset().Connect(buses.NewStandaloneLine(true))
+	cpu.Abort().Connect(buses.NewStandaloneLine(true))
+	cpu.NonMaskableInterrupt().Connect(buses.NewStandaloneLine(true))
+	cpu.InterruptRequest().Connect(irqLine)
+
+	cpu.programCounter = 0xC000
+
+	return cpu, ram, irqLine
+}
+
+// Executes the specified number of cycles
+func run65C816SCycles(cpu *cpu65C816S, ram components.Memory, cycles int) {
+	context := common.NewStepContext()
+
+	for range cycles {
+		cpu.Tick(&context)
+		ram.Tick(&context)
+		cpu.PostTick(&context)
+
+		context.NextCycle()
+	}
+}
+
+// Writes the program at $C000
+func load65C816SProgram(ram components.Memory, program ...uint8) {
+	for i, value := range program {
+		ram.Poke(0xC000+uint16(i), value)
+	}
+}
+
+func TestCpu65C816S_StartsInEmulationMode(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+
+	load65C816SProgram(ram, 0xEA) // NOP
+	run65C816SCycles(cpu, ram, 2)
+
+	assert.True(t, cpu.IsEmulationMode())
+	assert.True(t, cpu.Emulation().Enabled())
+	assert.True(t, cpu.processorStatusRegister.Flag(MemorySelectFlagBit))
+	assert.True(t, cpu.processorStatusRegister.Flag(IndexRegisterSelectFlagBit))
+	assert.Equal(t, uint16(0x01FD), cpu.GetStackPointer16())
+	assert.Equal(t, uint16(0xC001), cpu.GetProgramCounter())
+}
+
+func TestCpu65C816S_SwitchToNativeAndBack(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+
+	load65C816SProgram(ram,
+		0x18,       // CLC
+		0xFB,       // XCE
+		0xC2, 0x30, // REP #$30
+		0xA2, 0x34, 0x12, // LDX #$1234
+		0x38, // SEC
+		0xFB, // XCE
+	)
+
+	run65C816SCycles(cpu, ram, 2+2+3)
+	assert.False(t, cpu.IsEmulationMode())
+	assert.True(t, cpu.processorStatusRegister.Flag(CarryFlagBit), "XCE moves E to the carry")
+	assert.False(t, cpu.processorStatusRegister.Flag(MemorySelectFlagBit))
+	assert.False(t, cpu.processorStatusRegister.Flag(IndexRegisterSelectFlagBit))
+
+	run65C816SCycles(cpu, ram, 3)
+	assert.Equal(t, uint16(0x1234), cpu.GetXRegister16())
+
+	// Going back to emulation sets M and X, which clears the high byte of the index registers
+	run65C816SCycles(cpu, ram, 2+2)
+	assert.True(t, cpu.IsEmulationMode())
+	assert.False(t, cpu.processorStatusRegister.Flag(CarryFlagBit))
+	assert.True(t, cpu.processorStatusRegister.Flag(IndexRegisterSelectFlagBit))
+	assert.Equal(t, uint16(0x0034), cpu.GetXRegister16())
+	assert.Equal(t, uint16(0x01FD), cpu.GetStackPointer16())
+}
+
+func TestCpu65C816S_16BitLoadAndStore(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.setEmulationMode(false)
+	cpu.setStatus(0x00)
+
+	load65C816SProgram(ram,
+		0xA9, 0xCD, 0xAB, // LDA #$ABCD
+		0x8D, 0x00, 0x20, // STA $2000
+	)
+
+	run65C816SCycles(cpu, ram, 3)
+	assert.Equal(t, uint16(0xABCD), cpu.GetAccumulatorRegister16())
+	assert.True(t, cpu.processorStatusRegister.Flag(NegativeFlagBit))
+
+	run65C816SCycles(cpu, ram, 5)
+	assert.Equal(t, uint8(0xCD), ram.Peek(0x2000))
+	assert.Equal(t, uint8(0xAB), ram.Peek(0x2001))
+	assert.Equal(t, uint16(0xC006), cpu.GetProgramCounter())
+}
+
+func TestCpu65C816S_8BitAccumulatorKeepsB(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.accumulatorRegister = 0x1200
+
+	load65C816SProgram(ram,
+		0xA9, 0x34, // LDA #$34
+		0xEB, // XBA
+	)
+
+	run65C816SCycles(cpu, ram, 2)
+	assert.Equal(t, uint16(0x1234), cpu.GetAccumulatorRegister16())
+
+	run65C816SCycles(cpu, ram, 3)
+	assert.Equal(t, uint16(0x3412), cpu.GetAccumulatorRegister16())
+	assert.False(t, cpu.processorStatusRegister.Flag(ZeroFlagBit))
+}
+
+func TestCpu65C816S_LongAddressing(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+
+	load65C816SProgram(ram,
+		0xAF, 0x00, 0x10, 0x7F, // LDA $7F1000
+		0x5C, 0x00, 0x80, 0x01, // JML $018000
+	)
+
+	// The RAM ignores the bank, the value is read from $1000
+	ram.Poke(0x1000, 0x42)
+
+	run65C816SCycles(cpu, ram, 4)
+	assert.Equal(t, uint8(0x00), cpu.BankAddress().Read())
+
+	run65C816SCycles(cpu, ram, 1)
+	assert.Equal(t, uint8(0x7F), cpu.BankAddress().Read())
+	assert.Equal(t, uint16(0x1000), cpu.AddressBus().Read())
+	assert.Equal(t, uint8(0x42), cpu.GetAccumulatorRegister())
+
+	run65C816SCycles(cpu, ram, 4)
+	assert.Equal(t, uint8(0x01), cpu.GetProgramBankRegister())
+	assert.Equal(t, uint16(0x8000), cpu.GetProgramCounter())
+
+	// The next opcode is read from the new program bank
+	run65C816SCycles(cpu, ram, 1)
+	assert.Equal(t, uint8(0x01), cpu.BankAddress().Read())
+	assert.True(t, cpu.ValidDataAddress().Enabled())
+	assert.True(t, cpu.ValidProgramAddress().Enabled())
+}
+
+func TestCpu65C816S_BankIsOnDataBusWhilePhi2IsLow(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+
+	load65C816SProgram(ram,
+		0xA9, 0x42, // LDA #$42
+		0x8F, 0x00, 0x10, 0x7F, // STA $7F1000
+	)
+
+	run65C816SCycles(cpu, ram, 6)
+
+	// The latch captures the bank from the data bus before PHI2 goes high
+	context := common.NewStepContext()
+	cpu.TickPhi2Low(&context)
+
+	assert.Equal(t, uint8(0x7F), cpu.DataBus().Read())
+	assert.Equal(t, uint16(0x1000), cpu.AddressBus().Read())
+	assert.True(t, cpu.ReadWrite().Enabled())
+
+	// When PHI2 goes high the data written replaces the bank
+	cpu.Tick(&context)
+	assert.Equal(t, uint8(0x42), cpu.DataBus().Read())
+	assert.Equal(t, uint8(0x7F), cpu.BankAddress().Read())
+
+	ram.Tick(&context)
+	cpu.PostTick(&context)
+	assert.Equal(t, uint8(0x42), ram.Peek(0x1000))
+
+	// The fetch of the next opcode puts its bank on the bus too
+	context.NextCycle()
+	cpu.TickPhi2Low(&context)
+	assert.Equal(t, uint8(0x00), cpu.DataBus().Read())
+	assert.False(t, cpu.ReadWrite().Enabled())
+}
+
+func TestCpu65C816S_DirectRegisterPenalty(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+
+	load65C816SProgram(ram,
+		0xA5, 0x10, // LDA $10
+		0xA5, 0x10, // LDA $10
+	)
+
+	ram.Poke(0x0010, 0x11)
+	ram.Poke(0x0111, 0x22)
+
+	run65C816SCycles(cpu, ram, 3)
+	assert.Equal(t, uint8(0x11), cpu.GetAccumulatorRegister())
+
+	// With the low byte of D not 0 the instruction takes an extra cycle
+	cpu.directRegister = 0x0101
+
+	run65C816SCycles(cpu, ram, 3)
+	assert.Equal(t, uint8(0x11), cpu.GetAccumulatorRegister())
+
+	run65C816SCycles(cpu, ram, 1)
+	assert.Equal(t, uint8(0x22), cpu.GetAccumulatorRegister())
+}
+
+func TestCpu65C816S_IndexedWithPageCrossing(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.xRegister = 0x01
+
+	load65C816SProgram(ram,
+		0xBD, 0x00, 0x20, // LDA $2000,X
+		0xBD, 0xFF, 0x20, // LDA $20FF,X
+	)
+
+	ram.Poke(0x2001, 0x11)
+	ram.Poke(0x2100, 0x22)
+
+	run65C816SCycles(cpu, ram, 4)
+	assert.Equal(t, uint8(0x11), cpu.GetAccumulatorRegister())
+
+	run65C816SCycles(cpu, ram, 4)
+	assert.Equal(t, uint8(0x11), cpu.GetAccumulatorRegister())
+
+	run65C816SCycles(cpu, ram, 1)
+	assert.Equal(t, uint8(0x22), cpu.GetAccumulatorRegister())
+}
+
+func TestCpu65C816S_16BitRMW(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.setEmulationMode(false)
+	cpu.setStatus(0x10)
+
+	load65C816SProgram(ram, 0xE6, 0x10) // INC $10
+
+	ram.Poke(0x0010, 0xFF)
+	ram.Poke(0x0011, 0x00)
+
+	// Opcode, operand, data low and high and modify
+	run65C816SCycles(cpu, ram, 5)
+	assert.True(t, cpu.MemoryLock().Enabled())
+
+	// The high byte is written first
+	run65C816SCycles(cpu, ram, 1)
+	assert.Equal(t, uint16(0x0011), cpu.AddressBus().Read())
+	assert.Equal(t, uint8(0x01), ram.Peek(0x0011))
+
+	run65C816SCycles(cpu, ram, 1)
+	assert.Equal(t, uint16(0x0010), cpu.AddressBus().Read())
+	assert.Equal(t, uint8(0x00), ram.Peek(0x0010))
+	assert.False(t, cpu.processorStatusRegister.Flag(ZeroFlagBit))
+}
+
+func TestCpu65C816S_BlockMove(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.setEmulationMode(false)
+	cpu.setStatus(0x00)
+
+	cpu.accumulatorRegister = 0x0002
+	cpu.xRegister = 0x1000
+	cpu.yRegister = 0x2000
+
+	load65C816SProgram(ram, 0x54, 0x00, 0x00) // MVN $00, $00
+
+	ram.Poke(0x1000, 0x01)
+	ram.Poke(0x1001, 0x02)
+	ram.Poke(0x1002, 0x03)
+
+	// Each byte takes 7 cycles
+	run65C816SCycles(cpu, ram, 14)
+	assert.Equal(t, uint16(0xC000), cpu.GetProgramCounter())
+
+	run65C816SCycles(cpu, ram, 7)
+	assert.Equal(t, uint16(0xC003), cpu.GetProgramCounter())
+	assert.Equal(t, uint16(0xFFFF), cpu.GetAccumulatorRegister16())
+	assert.Equal(t, uint16(0x1003), cpu.GetXRegister16())
+	assert.Equal(t, uint16(0x2003), cpu.GetYRegister16())
+
+	for i := range uint32(3) {
+		assert.Equal(t, ram.Peek(0x1000+i), ram.Peek(0x2000+i))
+	}
+}
+
+func TestCpu65C816S_JumpToSubroutineLong(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.setEmulationMode(false)
+
+	load65C816SProgram(ram, 0x22, 0x00, 0x90, 0x00) // JSL $009000
+	ram.Poke(0x9000, 0x6B)                          // RTL
+
+	run65C816SCycles(cpu, ram, 8)
+	assert.Equal(t, uint16(0x9000), cpu.GetProgramCounter())
+	assert.Equal(t, uint16(0x01FA), cpu.GetStackPointer16())
+
+	// Pushes the program bank and the address of the last byte of the instruction
+	assert.Equal(t, uint8(0x00), ram.Peek(0x01FD))
+	assert.Equal(t, uint8(0xC0), ram.Peek(0x01FC))
+	assert.Equal(t, uint8(0x03), ram.Peek(0x01FB))
+
+	run65C816SCycles(cpu, ram, 6)
+	assert.Equal(t, uint16(0xC004), cpu.GetProgramCounter())
+	assert.Equal(t, uint16(0x01FD), cpu.GetStackPointer16())
+}
+
+func TestCpu65C816S_NativeInterrupt(t *testing.T) {
+	cpu, ram, irqLine := newComputer65C816S()
+	cpu.setEmulationMode(false)
+	cpu.setStatus(0x00)
+	cpu.programBankRegister = 0x02
+
+	load65C816SProgram(ram, 0xEA) // NOP
+	ram.Poke(0xFFEE, 0x00)
+	ram.Poke(0xFFEF, 0x80)
+
+	irqLine.Set(false)
+
+	// NOP then 8 cycles of the interrupt sequence
+	run65C816SCycles(cpu, ram, 2+8)
+
+	assert.Equal(t, uint16(0x8000), cpu.GetProgramCounter())
+	assert.Equal(t, uint8(0x00), cpu.GetProgramBankRegister())
+	assert.True(t, cpu.processorStatusRegister.Flag(IrqDisableFlagBit))
+
+	// Program bank, program counter and status are pushed
+	assert.Equal(t, uint8(0x02), ram.Peek(0x01FD))
+	assert.Equal(t, uint8(0xC0), ram.Peek(0x01FC))
+	assert.Equal(t, uint8(0x01), ram.Peek(0x01FB))
+	assert.Equal(t, uint8(0x00), ram.Peek(0x01FA))
+}
+
+func TestCpu65C816S_DecimalMode16Bit(t *testing.T) {
+	cpu, ram, _ := newComputer65C816S()
+	cpu.setEmulationMode(false)
+	cpu.setStatus(0x08)
+	cpu.accumulatorRegister = 0x1999
+
+	load65C816SProgram(ram,
+		0x69, 0x01, 0x80, // ADC #$8001
+		0x38,             // SEC
+		0xE9, 0x01, 0x00, // SBC #$0001
+	)
+
+	run65C816SCycles(cpu, ram, 3)
+	assert.Equal(t, uint16(0x0000), cpu.GetAccumulatorRegister16())
+	assert.True(t, cpu.processorStatusRegister.Flag(CarryFlagBit))
+	assert.True(t, cpu.processorStatusRegister.Flag(ZeroFlagBit))
+
+	run65C816SCycles(cpu, ram, 2+3)
+	assert.Equal(t, uint16(0x9999), cpu.GetAccumulatorRegister16())
+	assert.False(t, cpu.processorStatusRegister.Flag(CarryFlagBit))
+}
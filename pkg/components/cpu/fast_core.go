@@ -0,0 +1,476 @@
+package cpu
+
+import (
+	"slices"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+)
+
+// Number of bytes in each page of the memory map
+const memoryMapPageSize uint32 = 0x100
+
+// MemoryMap describes the memory of a computer for the fast execution mode. Each page of 256 bytes
+// of the address space can be mapped to the contents of a memory chip, where the processor reads
+// and writes directly without going through the bus. Pages that are not mapped are I/O and can
+// only be accessed through the bus.
+type MemoryMap struct {
+	readPages  [0x100][]uint8
+	writePages [0x100][]uint8
+
+	// Writes to read only pages are stored here and never read back
+	discard [memoryMapPageSize]uint8
+}
+
+// NewMemoryMap creates a memory map where all pages are I/O.
+func NewMemoryMap() *MemoryMap {
+	return &MemoryMap{}
+}
+
+// Map maps the pages from the start to the end address (both included) to the contents of a
+// memory. The start address must be the first byte of a page and the end address the last byte
+// of a page. Writes to read only pages are ignored, as the ones done to a ROM.
+//
+// Parameters:
+//   - start: First address of the range
+//   - end: Last address of the range
+//   - contents: Values of the memory, the first value is the one of the start address
+//   - writable: True if the processor can write to the memory
+func (memory *MemoryMap) Map(start uint16, end uint16, contents []uint8, writable bool) {
+	for page := uint32(start >> 8); page <= uint32(end>>8); page++ {
+		offset := (page - uint32(start>>8)) * memoryMapPageSize
+		values := contents[offset : offset+memoryMapPageSize]
+
+		memory.readPages[page] = values
+
+		if writable {
+			memory.writePages[page] = values
+		} else {
+			memory.writePages[page] = memory.discard[:]
+		}
+	}
+}
+
+// MapReads maps the reads of the pages from the start to the end address (both included) to the
+// contents of a memory, while the instructions that write to them go through the bus. It allows
+// executing from memories where writes have effects, as the EEPROMs.
+//
+// Parameters:
+//   - start: First address of the range
+//   - end: Last address of the range
+//   - contents: Values of the memory, the first value is the one of the start address
+func (memory *MemoryMap) MapReads(start uint16, end uint16, contents []uint8) {
+	memory.Map(start, end, contents, false)
+
+	for page := uint32(start >> 8); page <= uint32(end>>8); page++ {
+		memory.writePages[page] = nil
+	}
+}
+
+// Unmap sets the pages from the start to the end address (both included) as I/O.
+//
+// Parameters:
+//   - start: First address of the range
+//   - end: Last address of the range
+func (memory *MemoryMap) Unmap(start uint16, end uint16) {
+	for page := uint32(start >> 8); page <= uint32(end>>8); page++ {
+		memory.readPages[page] = nil
+		memory.writePages[page] = nil
+	}
+}
+
+// Returns the value at the address and true, or false if the address is I/O
+func (memory *MemoryMap) read(address uint16) (uint8, bool) {
+	page := memory.readPages[address>>8]
+	if page == nil {
+		return 0, false
+	}
+
+	return page[address&0xFF], true
+}
+
+// Returns the 2 bytes value stored at the address and true, or false if any of the bytes is I/O.
+// The high byte is read from the next address, without wrapping on the page.
+func (memory *MemoryMap) readWord(address uint16) (uint16, bool) {
+	low, lowMapped := memory.read(address)
+	high, highMapped := memory.read(address + 1)
+
+	return uint16(high)<<8 | uint16(low), lowMapped && highMapped
+}
+
+// WritesBypassBus returns true if the instructions write the address directly on the memory, or
+// ignore the write if it's read only, so the write is not seen on the bus.
+//
+// Parameters:
+//   - address: The address written
+//
+// Returns:
+//   - true if the writes to the address don't go through the bus
+func (memory *MemoryMap) WritesBypassBus(address uint16) bool {
+	return memory.isWritable(address)
+}
+
+// Returns true if the address can be written without going through the bus
+func (memory *MemoryMap) isWritable(address uint16) bool {
+	return memory.writePages[address>>8] != nil
+}
+
+// Writes the value to the address, the address must be writable
+func (memory *MemoryMap) write(address uint16, value uint8) {
+	memory.writePages[address>>8][address&0xFF] = value
+}
+
+// FastProcessor is implemented by the processors that can execute whole instructions at once on
+// a memory map, without going through the bus.
+type FastProcessor interface {
+	// ExecuteInstructions executes instructions reading and writing directly on the memory map
+	// until the number of cycles reaches the maximum or an instruction can't be executed without
+	// the bus. Returns the number of cycles taken by the instructions executed.
+	ExecuteInstructions(memory *MemoryMap, maxCycles int) int
+}
+
+// The memory access done by an instruction executed in the fast mode
+type fastAccess struct {
+	address uint16 // Effective address of the instruction
+	read    bool   // The value at the address is read into the data register
+	write   bool   // The instruction writes to the address
+	carry   bool   // Indexing crossed a page, the instruction takes an extra cycle
+}
+
+// ExecuteInstructions executes instructions at once reading and writing directly on the memory map.
+// It is the fast execution mode of the processor, where the instructions are executed with their
+// results and number of cycles but without the bus activity of each cycle.
+//
+// Execution stops before an instruction that accesses an I/O page, as it must be executed cycle
+// by cycle through the bus. It also stops if the processor is not about to read an opcode, if an
+// interrupt or reset must be served, or if it's paused or stopped. As nothing else is emulated
+// while the instructions are executed, the control lines are read only once. The dummy reads done
+// by some address modes are not performed.
+// The NMOS 6502 doesn't support the fast mode, it never executes instructions.
+//
+// Parameters:
+//   - memory: The memory map of the computer
+//   - maxCycles: Cycles after which the execution stops
+//
+// Returns:
+//   - The number of cycles taken by the instructions executed, 0 if none was executed
+func (cpu *cpu65C02S) ExecuteInstructions(memory *MemoryMap, maxCycles int) int {
+	if !cpu.canExecuteInstructions() {
+		return 0
+	}
+
+	irqRequested := cpu.interruptRequest.Enabled()
+	total := 0
+
+	for total < maxCycles {
+		if cpu.processorPaused || cpu.processorStopped {
+			break
+		}
+
+		if irqRequested && !cpu.processorStatusRegister.Flag(IrqDisableFlagBit) {
+			break
+		}
+
+		cycles, ok := cpu.executeInstruction(memory)
+		if !ok {
+			break
+		}
+
+		total += cycles
+	}
+
+	return total
+}
+
+// Returns true if the processor is about to read an opcode and no interrupt or reset is pending.
+func (cpu *cpu65C02S) canExecuteInstructions() bool {
+	if cpu.variant != variantW65C02S {
+		return false
+	}
+
+	if cpu.nextCycleIndex != 0 || !cpu.nextCycle.signaling.sync {
+		return false
+	}
+
+	if cpu.irqRequested || cpu.nmiRequested || cpu.cyclesWithReset > 0 {
+		return false
+	}
+
+	if !cpu.ready.Enabled() || !cpu.busEnable.Enabled() || cpu.reset.Enabled() || cpu.setOverflow.Enabled() {
+		return false
+	}
+
+	// A falling edge of NMI is served on the next cycle
+	return !cpu.nonMaskableInterrupt.Enabled() || cpu.previousNMIStatus
+}
+
+// Executes the instruction at the program counter on the memory map. Returns the number of cycles
+// of the instruction and true, or false without changing the processor if it can't be executed
+// without going through the bus.
+func (cpu *cpu65C02S) executeInstruction(memory *MemoryMap) (int, bool) {
+	address := cpu.programCounter
+
+	opCode, ok := memory.read(address)
+	if !ok {
+		return 0, false
+	}
+
+	instruction := cpu.instructionSet.GetByOpCode(components.OpCode(opCode))
+	addressMode := cpu.addressModeSet.GetByName(instruction.addressMode)
+
+	var operand [2]uint8
+	for i := range addressMode.memSize - 1 {
+		if operand[i], ok = memory.read(address + 1 + uint16(i)); !ok {
+			return 0, false
+		}
+	}
+
+	access, ok := cpu.resolveFastAccess(memory, opCode, addressMode.name, operand)
+	if !ok {
+		return 0, false
+	}
+
+	if access.read {
+		if cpu.dataRegister, ok = memory.read(access.address); !ok {
+			cpu.dataRegister = 0x00
+			return 0, false
+		}
+	}
+
+	if access.write && !memory.isWritable(access.address) {
+		cpu.dataRegister = 0x00
+		return 0, false
+	}
+
+	cycles := addressMode.Cycles()
+	if cpu.hasExtraCycleIfCarry(addressMode.name) && !access.carry {
+		cycles--
+	}
+
+	cpu.currentOpCode = components.OpCode(opCode)
+	cpu.currentInstruction = instruction
+	cpu.currentAddressMode = addressMode
+	cpu.instructionRegister = access.address
+	cpu.programCounter += uint16(addressMode.memSize)
+
+	// Writes done by the actions are done on the memory map
+	cpu.fastMemory = memory
+
+	switch addressMode.name {
+	case AddressModeRelative:
+		cpu.dataRegister = operand[0]
+		cycles = cpu.executeFastBranch(instruction, cycles)
+
+	case AddressModeRelativeExtended:
+		instruction.execute(cpu)
+		cpu.dataRegister = operand[1]
+		cycles = cpu.executeFastBranch(nil, cycles)
+
+	case AddressModePullStack:
+		cpu.stackPointer++
+		cpu.dataRegister, _ = memory.read(cpu.stackAddress())
+		instruction.execute(cpu)
+
+	case AddressModeJumpToSubroutine:
+		cpu.programCounter--
+		cpu.pushFast(uint8(cpu.programCounter >> 8))
+		cpu.pushFast(uint8(cpu.programCounter))
+		cpu.programCounter = access.address
+
+	case AddressModeReturnFromSubroutine:
+		cpu.programCounter = uint16(cpu.pullFast(memory)) | uint16(cpu.pullFast(memory))<<8
+		cpu.programCounter++
+
+	case AddressModeReturnFromInterrupt:
+		cpu.processorStatusRegister.SetValue(cpu.pullFast(memory))
+		cpu.programCounter = uint16(cpu.pullFast(memory)) | uint16(cpu.pullFast(memory))<<8
+
+	case AddressModeBreak:
+		cpu.pushFast(uint8(cpu.programCounter >> 8))
+		cpu.pushFast(uint8(cpu.programCounter))
+		cpu.pushFast(cpu.processorStatusRegister.ReadValue())
+		cpu.processorStatusRegister.SetFlag(IrqDisableFlagBit, true)
+		cpu.programCounter = access.address
+
+	default:
+		instruction.execute(cpu)
+	}
+
+	cpu.fastMemory = nil
+	cpu.completeFastInstruction(addressMode)
+
+	return cycles, true
+}
+
+// Resolves the memory access of the instruction. Returns false if it accesses I/O to read the
+// address or the stack, or if the address mode is not supported in the fast mode.
+func (cpu *cpu65C02S) resolveFastAccess(memory *MemoryMap, opCode uint8, addressMode components.AddressMode, operand [2]uint8) (fastAccess, bool) {
+	zeroPage := uint16(operand[0])
+	absolute := uint16(operand[1])<<8 | uint16(operand[0])
+
+	switch addressMode {
+	case AddressModeImplicit, AddressModeAccumulator, AddressModeSingleCycle, AddressModeRelative:
+		return fastAccess{}, true
+
+	case AddressModeImmediate:
+		return fastAccess{address: cpu.programCounter + 1, read: true}, true
+
+	case AddressModeZeroPage, AddressModeRelativeExtended:
+		return fastAccess{address: zeroPage, read: true}, true
+	case AddressModeZeroPageRMW:
+		return fastAccess{address: zeroPage, read: true, write: true}, true
+	case AddressModeZeroPageW:
+		return fastAccess{address: zeroPage, write: true}, true
+
+	case AddressModeZeroPageX:
+		return fastAccess{address: uint16(operand[0] + cpu.xRegister), read: true}, true
+	case AddressModeZeroPageXRMW:
+		return fastAccess{address: uint16(operand[0] + cpu.xRegister), read: true, write: true}, true
+	case AddressModeZeroPageXW:
+		return fastAccess{address: uint16(operand[0] + cpu.xRegister), write: true}, true
+	case AddressModeZeroPageY:
+		return fastAccess{address: uint16(operand[0] + cpu.yRegister), read: true}, true
+	case AddressModeZeroPageYW:
+		return fastAccess{address: uint16(operand[0] + cpu.yRegister), write: true}, true
+
+	case AddressModeAbsolute:
+		return fastAccess{address: absolute, read: true}, true
+	case AddressModeAbsoluteRMW:
+		return fastAccess{address: absolute, read: true, write: true}, true
+	case AddressModeAbsoluteW:
+		return fastAccess{address: absolute, write: true}, true
+	case AddressModeAbsoluteJump:
+		return fastAccess{address: absolute}, true
+
+	case AddressModeAbsoluteX:
+		return cpu.indexedFastAccess(opCode, absolute, cpu.xRegister, true, false), true
+	case AddressModeAbsoluteXRMW:
+		return cpu.indexedFastAccess(opCode, absolute, cpu.xRegister, true, true), true
+	case AddressModeAbsoluteXW:
+		return cpu.indexedFastAccess(opCode, absolute, cpu.xRegister, false, true), true
+	case AddressModeAbsoluteY:
+		return cpu.indexedFastAccess(opCode, absolute, cpu.yRegister, true, false), true
+	case AddressModeAbsoluteYW:
+		return cpu.indexedFastAccess(opCode, absolute, cpu.yRegister, false, true), true
+
+	case AddressModeZeroPageIndexedIndirectX, AddressModeZeroPageIndexedIndirectXW:
+		pointer, ok := memory.readWord(uint16(operand[0] + cpu.xRegister))
+		return fastAccess{address: pointer, read: addressMode == AddressModeZeroPageIndexedIndirectX, write: addressMode == AddressModeZeroPageIndexedIndirectXW}, ok
+
+	case AddressModeZeroPageIndirectIndexedY, AddressModeZeroPageIndirectIndexedYW:
+		pointer, ok := memory.readWord(zeroPage)
+		read := addressMode == AddressModeZeroPageIndirectIndexedY
+		return cpu.indexedFastAccess(opCode, pointer, cpu.yRegister, read, !read), ok
+
+	case AddressModeIndirectZeroPage, AddressModeIndirectZeroPageW:
+		pointer, ok := memory.readWord(zeroPage)
+		return fastAccess{address: pointer, read: addressMode == AddressModeIndirectZeroPage, write: addressMode == AddressModeIndirectZeroPageW}, ok
+
+	case AddressModeIndirect:
+		pointer, ok := memory.readWord(absolute)
+		return fastAccess{address: pointer}, ok
+
+	case AddressModeAbsoluteIndexedIndirect:
+		pointer, ok := memory.readWord(absolute + uint16(cpu.xRegister))
+		return fastAccess{address: pointer}, ok
+
+	case AddressModePushStack, AddressModeJumpToSubroutine:
+		return fastAccess{address: absolute}, memory.isWritable(cpu.stackAddress())
+
+	case AddressModePullStack, AddressModeReturnFromSubroutine, AddressModeReturnFromInterrupt:
+		_, ok := memory.read(cpu.stackAddress())
+		return fastAccess{}, ok
+
+	case AddressModeBreak:
+		vector, ok := memory.readWord(irqVectorLSB)
+		return fastAccess{address: vector}, ok && memory.isWritable(cpu.stackAddress())
+	}
+
+	return fastAccess{}, false
+}
+
+// Returns the access to the base address plus the index. Adding the index takes an extra cycle
+// if it crosses a page or if the instruction always takes it.
+func (cpu *cpu65C02S) indexedFastAccess(opCode uint8, base uint16, index uint8, read bool, write bool) fastAccess {
+	return fastAccess{
+		address: base + uint16(index),
+		read:    read,
+		write:   write,
+		carry:   uint16(uint8(base))+uint16(index) > 0xFF || slices.Contains(cpu.alwaysExtra, opCode),
+	}
+}
+
+// Returns true if the address mode takes an extra cycle only when indexing crosses a page
+func (cpu *cpu65C02S) hasExtraCycleIfCarry(addressMode components.AddressMode) bool {
+	switch addressMode {
+	case AddressModeAbsoluteX, AddressModeAbsoluteXRMW, AddressModeAbsoluteXW,
+		AddressModeAbsoluteY, AddressModeAbsoluteYW,
+		AddressModeZeroPageIndirectIndexedY, AddressModeZeroPageIndirectIndexedYW:
+		return true
+	}
+
+	return false
+}
+
+// Executes the branch instruction, or only evaluates the branch taken by BBR and BBS if the instruction
+// is nil, and returns the cycles taken. The cycles of the address mode include the extra cycles
+// of taking the branch and of crossing a page, which are removed if not taken.
+func (cpu *cpu65C02S) executeFastBranch(instruction *CpuInstructionData, cycles int) int {
+	if instruction != nil {
+		instruction.execute(cpu)
+	}
+
+	cycles -= 2
+
+	if cpu.branchTaken {
+		cycles++
+
+		target := cpu.programCounter + uint16(int8(cpu.dataRegister))
+		if target&0xFF00 != cpu.programCounter&0xFF00 {
+			cycles++
+		}
+
+		cpu.programCounter = target
+	}
+
+	return cycles
+}
+
+// Returns the address on the stack pointed by the stack pointer
+func (cpu *cpu65C02S) stackAddress() uint16 {
+	return uint16(cpu.stackPointer) + 0x100
+}
+
+// Pushes the value on the stack of the memory map
+func (cpu *cpu65C02S) pushFast(value uint8) {
+	cpu.writeToStack(value)
+	cpu.stackPointer--
+}
+
+// Pulls a value from the stack of the memory map
+func (cpu *cpu65C02S) pullFast(memory *MemoryMap) uint8 {
+	cpu.stackPointer++
+	value, _ := memory.read(cpu.stackAddress())
+
+	return value
+}
+
+// Leaves the processor as if the instruction was executed cycle by cycle, in the last cycle of the
+// instruction and ready to read the next opcode.
+func (cpu *cpu65C02S) completeFastInstruction(addressMode *AddressModeData) {
+	cpu.currentCycleIndex = addressMode.Cycles() - 1
+	if cpu.currentCycleIndex > 0 {
+		cpu.currentCycle = addressMode.cycle(cpu.currentCycleIndex - 1)
+	} else {
+		cpu.currentCycle = readOpCode
+	}
+
+	cpu.nextInstruction = cpu.currentInstruction
+	cpu.nextAddressMode = cpu.currentAddressMode
+	cpu.nextCycleIndex = 0
+	cpu.nextCycle = readOpCode
+
+	cpu.instructionRegister = 0x0000
+	cpu.dataRegister = 0x00
+	cpu.instructionRegisterCarry = false
+	cpu.branchTaken = false
+}
@@ -0,0 +1,206 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/stretchr/testify/assert"
+)
+
+// Loads a program that uses indexed, indirect, stack, branch and interrupt instructions
+func loadFastModeProgram(ram components.Memory) {
+	program := map[uint16][]uint8{
+		0xC000: {
+			0xA2, 0x05, // LDX #$05
+			0xBD, 0xFD, 0x10, // LDA $10FD,X
+			0x9D, 0xFD, 0x20, // STA $20FD,X
+			0x1E, 0x00, 0x30, // ASL $3000,X
+			0xCA,       // DEX
+			0xD0, 0xF4, // BNE $C002
+			0x20, 0x20, 0xC0, // JSR $C020
+			0x00, 0xEA, // BRK
+			0x0F, 0x40, 0x02, // BBR0 $40, $C018
+			0xEA, 0xEA, // NOP NOP
+			0xA0, 0x10, // LDY #$10
+			0xB1, 0x50, // LDA ($50),Y
+			0x4C, 0x1C, 0xC0, // JMP $C01C
+		},
+		0xC020: {
+			0x48,       // PHA
+			0x08,       // PHP
+			0xA9, 0x80, // LDA #$80
+			0x69, 0x90, // ADC #$90
+			0x28, // PLP
+			0x68, // PLA
+			0x60, // RTS
+		},
+		0xC100: {
+			0xE8, // INX
+			0x40, // RTI
+		},
+		0x10FE: {0x11, 0x12, 0x13, 0x14, 0x15},
+		0x3001: {0x21, 0x22, 0x23, 0x24, 0x25},
+		0x0040: {0x02},
+		0x0050: {0xF8, 0x12},
+		0x1308: {0x99},
+		0xFFFE: {0x00, 0xC1},
+	}
+
+	for address, values := range program {
+		for i, value := range values {
+			ram.Poke(address+uint16(i), value)
+		}
+	}
+}
+
+// Executes the specified number of cycles
+func runCycles(cpu *cpu65C02S, ram components.Memory, cycles int) {
+	context := common.NewStepContext()
+
+	for range cycles {
+		cpu.Tick(&context)
+		ram.Tick(&context)
+		cpu.PostTick(&context)
+
+		context.NextCycle()
+	}
+}
+
+func TestFastModeMatchesCycleExecution(t *testing.T) {
+	cycleCpu, cycleRam := newComputer()
+	fastCpu, fastRam := newComputer()
+
+	loadFastModeProgram(cycleRam)
+	loadFastModeProgram(fastRam)
+
+	memoryMap := NewMemoryMap()
+	memoryMap.Map(0x0000, 0xFFFF, fastRam.Contents(), true)
+
+	cycles := fastCpu.ExecuteInstructions(memoryMap, 300)
+	assert.GreaterOrEqual(t, cycles, 300)
+
+	runCycles(cycleCpu, cycleRam, cycles)
+
+	assert.Equal(t, uint16(0xC01C), fastCpu.programCounter)
+	assert.Equal(t, cycleCpu.programCounter, fastCpu.programCounter)
+	assert.Equal(t, cycleCpu.accumulatorRegister, fastCpu.accumulatorRegister)
+	assert.Equal(t, cycleCpu.xRegister, fastCpu.xRegister)
+	assert.Equal(t, cycleCpu.yRegister, fastCpu.yRegister)
+	assert.Equal(t, cycleCpu.stackPointer, fastCpu.stackPointer)
+	assert.Equal(t, cycleCpu.processorStatusRegister.ReadValue(), fastCpu.processorStatusRegister.ReadValue())
+	assert.Equal(t, cycleRam.Contents(), fastRam.Contents())
+
+	// The processor continues cycle by cycle after the fast execution
+	runCycles(fastCpu, fastRam, 3)
+	assert.Equal(t, uint16(0xC01C), fastCpu.programCounter)
+}
+
+func TestFastModeStopsBeforeAccessingIO(t *testing.T) {
+	cpu, ram := newComputer()
+
+	ram.Poke(0xC000, 0xA9) // LDA #$42
+	ram.Poke(0xC001, 0x42)
+	ram.Poke(0xC002, 0x8D) // STA $6000
+	ram.Poke(0xC003, 0x00)
+	ram.Poke(0xC004, 0x60)
+
+	memoryMap := NewMemoryMap()
+	memoryMap.Map(0x0000, 0x3FFF, ram.Contents()[0x0000:0x4000], true)
+	memoryMap.Map(0x8000, 0xFFFF, ram.Contents()[0x8000:], false)
+
+	cycles := cpu.ExecuteInstructions(memoryMap, 100)
+
+	assert.Equal(t, 2, cycles)
+	assert.Equal(t, uint16(0xC002), cpu.programCounter)
+	assert.Equal(t, uint8(0x42), cpu.accumulatorRegister)
+
+	// Executing the store through the bus
+	runCycles(cpu, ram, 4)
+	assert.Equal(t, uint8(0x42), ram.Peek(0x6000))
+	assert.Equal(t, uint16(0xC005), cpu.programCounter)
+}
+
+func TestFastModeIgnoresWritesToReadOnlyPages(t *testing.T) {
+	cpu, ram := newComputer()
+
+	ram.Poke(0xC000, 0xA9) // LDA #$42
+	ram.Poke(0xC001, 0x42)
+	ram.Poke(0xC002, 0x8D) // STA $C010
+	ram.Poke(0xC003, 0x10)
+	ram.Poke(0xC004, 0xC0)
+
+	memoryMap := NewMemoryMap()
+	memoryMap.Map(0x8000, 0xFFFF, ram.Contents()[0x8000:], false)
+
+	cycles := cpu.ExecuteInstructions(memoryMap, 6)
+
+	assert.Equal(t, 6, cycles)
+	assert.Equal(t, uint8(0x00), ram.Peek(0xC010))
+}
+
+func TestFastModeWritesToReadMappedPagesGoThroughTheBus(t *testing.T) {
+	cpu, ram := newComputer()
+
+	ram.Poke(0xC000, 0xA9) // LDA #$42
+	ram.Poke(0xC001, 0x42)
+	ram.Poke(0xC002, 0x8D) // STA $C010
+	ram.Poke(0xC003, 0x10)
+	ram.Poke(0xC004, 0xC0)
+
+	memoryMap := NewMemoryMap()
+	memoryMap.MapReads(0x8000, 0xFFFF, ram.Contents()[0x8000:])
+
+	cycles := cpu.ExecuteInstructions(memoryMap, 100)
+
+	assert.Equal(t, 2, cycles)
+	assert.Equal(t, uint16(0xC002), cpu.programCounter)
+
+	// Executing the store through the bus
+	runCycles(cpu, ram, 4)
+	assert.Equal(t, uint8(0x42), ram.Peek(0xC010))
+}
+
+func TestMemoryMapWritesBypassBus(t *testing.T) {
+	contents := make([]uint8, 0x10000)
+
+	memoryMap := NewMemoryMap()
+	memoryMap.Map(0x0000, 0x3FFF, contents, true)
+	memoryMap.MapReads(0x8000, 0xBFFF, contents[0x8000:])
+	memoryMap.Map(0xC000, 0xFFFF, contents[0xC000:], false)
+
+	assert.True(t, memoryMap.WritesBypassBus(0x0200))
+	assert.False(t, memoryMap.WritesBypassBus(0x6000))
+	assert.False(t, memoryMap.WritesBypassBus(0x8000))
+	assert.True(t, memoryMap.WritesBypassBus(0xFFFC))
+}
+
+func TestFastModeIsNotUsedWhenInterruptIsPending(t *testing.T) {
+	cpu, ram, _, irqLine, _, _ := newComputerWithControlLines()
+
+	ram.Poke(0xC000, 0xEA) // NOP
+
+	memoryMap := NewMemoryMap()
+	memoryMap.Map(0x0000, 0xFFFF, ram.Contents(), true)
+
+	cpu.processorStatusRegister.SetFlag(IrqDisableFlagBit, false)
+	irqLine.Set(false)
+
+	assert.Equal(t, 0, cpu.ExecuteInstructions(memoryMap, 100))
+
+	// With interrupts disabled the instructions are executed
+	cpu.processorStatusRegister.SetFlag(IrqDisableFlagBit, true)
+	assert.Equal(t, 2, cpu.ExecuteInstructions(memoryMap, 1))
+}
+
+func TestFastModeIsNotSupportedByNMOS6502(t *testing.T) {
+	cpu, ram := newNMOSComputer()
+
+	ram.Poke(0xC000, 0xEA) // NOP
+
+	memoryMap := NewMemoryMap()
+	memoryMap.Map(0x0000, 0xFFFF, ram.Contents(), true)
+
+	assert.Equal(t, 0, cpu.ExecuteInstructions(memoryMap, 100))
+	assert.Equal(t, uint16(0xC000), cpu.programCounter)
+}
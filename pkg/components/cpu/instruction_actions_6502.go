@@ -0,0 +1,310 @@
+package cpu
+
+/**************************************************************************************************
+* NMOS 6502 decimal mode
+*
+* On the NMOS 6502 the flags of ADC and SBC in decimal mode are not valid BCD results. Z is always
+* evaluated on the binary result, N and V on the intermediate result of ADC and all the flags of SBC
+* on the binary result. See appendix A of http://www.6502.org/tutorials/decimal_mode.html
+**************************************************************************************************/
+
+// A,Z,C,N = A+M+C
+// Adds the memory to the accumulator together with the carry bit. In decimal mode the result and
+// the flags are the ones of the NMOS 6502.
+func actionADCNMOS(cpu *cpu65C02S) {
+	if !cpu.processorStatusRegister.Flag(DecimalModeFlagBit) {
+		actionADC(cpu)
+		return
+	}
+
+	var carry uint16 = 0
+	if cpu.processorStatusRegister.Flag(CarryFlagBit) {
+		carry = 1
+	}
+
+	accumulator := uint16(cpu.accumulatorRegister)
+	data := uint16(cpu.dataRegister)
+
+	binary := accumulator + data + carry
+
+	low := (accumulator & 0x0F) + (data & 0x0F) + carry
+	if low >= 0x0A {
+		low = ((low + 0x06) & 0x0F) + 0x10
+	}
+
+	value := (accumulator & 0xF0) + (data & 0xF0) + low
+
+	setZeroFlag16(cpu, binary)
+	setNegativeFlag16(cpu, value)
+	setOverflowFlagAddition(cpu, cpu.dataRegister, cpu.accumulatorRegister, value)
+
+	if value >= 0xA0 {
+		value += 0x60
+	}
+
+	setCarryFlag(cpu, value)
+
+	cpu.accumulatorRegister = uint8(value)
+}
+
+// A,Z,C,N = A-M-(1-C)
+// Subtracts the memory from the accumulator together with the not of the carry bit. In decimal
+// mode the flags are set from the binary result as done by the NMOS 6502.
+func actionSBCNMOS(cpu *cpu65C02S) {
+	if !cpu.processorStatusRegister.Flag(DecimalModeFlagBit) {
+		actionSBC(cpu)
+		return
+	}
+
+	var borrow int = 0
+	if !cpu.processorStatusRegister.Flag(CarryFlagBit) {
+		borrow = 1
+	}
+
+	accumulator := int(cpu.accumulatorRegister)
+	data := int(cpu.dataRegister)
+
+	low := (accumulator & 0x0F) - (data & 0x0F) - borrow
+	if low < 0 {
+		low = ((low - 0x06) & 0x0F) - 0x10
+	}
+
+	value := (accumulator & 0xF0) - (data & 0xF0) + low
+	if value < 0 {
+		value -= 0x60
+	}
+
+	// Flags are the ones of the binary subtraction
+	cpu.processorStatusRegister.SetFlag(DecimalModeFlagBit, false)
+	actionSBC(cpu)
+	cpu.processorStatusRegister.SetFlag(DecimalModeFlagBit, true)
+
+	cpu.accumulatorRegister = uint8(value)
+}
+
+/**************************************************************************************************
+* NMOS 6502 illegal opcodes
+*
+* Most illegal opcodes execute 2 official instructions at the same time, for example an RMW
+* instruction followed by an accumulator instruction on the modified value.
+* See https://www.masswerk.at/6502/6502_instruction_set.html
+**************************************************************************************************/
+
+// The value of the accumulator OR'ed with this value is used by the unstable ANE and LXA opcodes.
+// It changes between chips and temperature, $EE is the most common.
+const nmosMagicConstant uint8 = 0xEE
+
+// M = M*2, A,Z,C,N = A|M
+// Shifts the memory one bit left and then ORs the result with the accumulator.
+func actionSLO(cpu *cpu65C02S) {
+	actionASL(cpu)
+	actionORA(cpu)
+}
+
+// M = M ROL 1, A,Z,C,N = A&M
+// Rotates the memory one bit left and then ANDs the result with the accumulator.
+func actionRLA(cpu *cpu65C02S) {
+	actionROL(cpu)
+	actionAND(cpu)
+}
+
+// M = M/2, A,Z,C,N = A^M
+// Shifts the memory one bit right and then EORs the result with the accumulator.
+func actionSRE(cpu *cpu65C02S) {
+	actionLSR(cpu)
+	actionEOR(cpu)
+}
+
+// M = M ROR 1, A,Z,C,N,V = A+M+C
+// Rotates the memory one bit right and then adds the result to the accumulator using the carry
+// of the rotation.
+func actionRRA(cpu *cpu65C02S) {
+	actionROR(cpu)
+	actionADCNMOS(cpu)
+}
+
+// M = A&X
+// Stores the result of AND'ing the accumulator and X register. No flags are affected.
+func actionSAX(cpu *cpu65C02S) {
+	cpu.setWriteBus(cpu.instructionRegister, cpu.accumulatorRegister&cpu.xRegister)
+}
+
+// A,X,Z,N = M
+// Loads the memory into both the accumulator and X register.
+func actionLAX(cpu *cpu65C02S) {
+	cpu.accumulatorRegister = cpu.dataRegister
+	cpu.xRegister = cpu.dataRegister
+
+	setZeroFlag(cpu, cpu.xRegister)
+	setNegativeFlag(cpu, cpu.xRegister)
+}
+
+// M = M-1, Z,C,N = A-M
+// Decrements the memory and then compares the result with the accumulator.
+func actionDCP(cpu *cpu65C02S) {
+	actionDEC(cpu)
+	actionCMP(cpu)
+}
+
+// M = M+1, A,Z,C,N,V = A-M-(1-C)
+// Increments the memory and then subtracts the result from the accumulator.
+func actionISC(cpu *cpu65C02S) {
+	actionINC(cpu)
+	actionSBCNMOS(cpu)
+}
+
+// A,Z,N = A&M, C = N
+// ANDs the immediate value with the accumulator and copies the negative flag to the carry.
+func actionANC(cpu *cpu65C02S) {
+	actionAND(cpu)
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, cpu.processorStatusRegister.Flag(NegativeFlagBit))
+}
+
+// A,Z,C,N = (A&M)/2
+// ANDs the immediate value with the accumulator and then shifts the accumulator one bit right.
+func actionALR(cpu *cpu65C02S) {
+	value := cpu.accumulatorRegister & cpu.dataRegister
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, value&0x01 > 0)
+	cpu.accumulatorRegister = value >> 1
+
+	setZeroFlag(cpu, cpu.accumulatorRegister)
+	setNegativeFlag(cpu, cpu.accumulatorRegister)
+}
+
+// A,Z,C,N,V = (A&M) ROR 1
+// ANDs the immediate value with the accumulator and then rotates the accumulator one bit right.
+// C is bit 6 of the result and V is bit 6 XOR bit 5. In decimal mode the result is adjusted as
+// if it was a BCD value and C is set by the adjustment of the high nibble.
+func actionARR(cpu *cpu65C02S) {
+	var carry uint8 = 0
+	if cpu.processorStatusRegister.Flag(CarryFlagBit) {
+		carry = 1 << 7
+	}
+
+	and := cpu.accumulatorRegister & cpu.dataRegister
+	value := carry | (and >> 1)
+
+	setZeroFlag(cpu, value)
+	setNegativeFlag(cpu, value)
+
+	if !cpu.processorStatusRegister.Flag(DecimalModeFlagBit) {
+		cpu.processorStatusRegister.SetFlag(CarryFlagBit, value&0x40 > 0)
+		cpu.processorStatusRegister.SetFlag(OverflowFlagBit, (value^(value<<1))&0x40 > 0)
+
+		cpu.accumulatorRegister = value
+		return
+	}
+
+	cpu.processorStatusRegister.SetFlag(OverflowFlagBit, (and^value)&0x40 > 0)
+
+	low := and & 0x0F
+	high := and >> 4
+
+	if low+(low&0x01) > 0x05 {
+		value = (value & 0xF0) | ((value + 0x06) & 0x0F)
+	}
+
+	decimalCarry := high+(high&0x01) > 0x05
+	if decimalCarry {
+		value += 0x60
+	}
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, decimalCarry)
+
+	cpu.accumulatorRegister = value
+}
+
+// X,Z,C,N = (A&X)-M
+// Subtracts the immediate value from the accumulator AND'ed with the X register, without borrow
+// and ignoring the decimal mode. The result is stored in X and flags are set as in CMP.
+func actionSBX(cpu *cpu65C02S) {
+	temp := cpu.accumulatorRegister
+	cpu.accumulatorRegister &= cpu.xRegister
+	actionCMP(cpu)
+
+	cpu.xRegister = cpu.accumulatorRegister - cpu.dataRegister
+	cpu.accumulatorRegister = temp
+}
+
+// A,X,SP,Z,N = M&SP
+// ANDs the memory with the stack pointer and stores the result in the accumulator, X register and
+// stack pointer.
+func actionLAS(cpu *cpu65C02S) {
+	value := cpu.dataRegister & cpu.stackPointer
+
+	cpu.accumulatorRegister = value
+	cpu.xRegister = value
+	cpu.stackPointer = value
+
+	setZeroFlag(cpu, value)
+	setNegativeFlag(cpu, value)
+}
+
+// A,Z,N = (A|Magic)&X&M
+// Unstable, the result depends on a magic constant that changes between chips.
+func actionANE(cpu *cpu65C02S) {
+	cpu.accumulatorRegister = (cpu.accumulatorRegister | nmosMagicConstant) & cpu.xRegister & cpu.dataRegister
+
+	setZeroFlag(cpu, cpu.accumulatorRegister)
+	setNegativeFlag(cpu, cpu.accumulatorRegister)
+}
+
+// A,X,Z,N = (A|Magic)&M
+// Unstable, the result depends on a magic constant that changes between chips.
+func actionLXA(cpu *cpu65C02S) {
+	cpu.accumulatorRegister = (cpu.accumulatorRegister | nmosMagicConstant) & cpu.dataRegister
+	cpu.xRegister = cpu.accumulatorRegister
+
+	setZeroFlag(cpu, cpu.accumulatorRegister)
+	setNegativeFlag(cpu, cpu.accumulatorRegister)
+}
+
+// M = A&X&(H+1)
+// Stores the accumulator AND'ed with the X register and the high byte of the base address plus one.
+func actionSHA(cpu *cpu65C02S) {
+	storeAndHighByte(cpu, cpu.accumulatorRegister&cpu.xRegister, cpu.yRegister)
+}
+
+// M = X&(H+1)
+// Stores the X register AND'ed with the high byte of the base address plus one.
+func actionSHX(cpu *cpu65C02S) {
+	storeAndHighByte(cpu, cpu.xRegister, cpu.yRegister)
+}
+
+// M = Y&(H+1)
+// Stores the Y register AND'ed with the high byte of the base address plus one.
+func actionSHY(cpu *cpu65C02S) {
+	storeAndHighByte(cpu, cpu.yRegister, cpu.xRegister)
+}
+
+// SP = A&X, M = SP&(H+1)
+// Transfers the accumulator AND'ed with the X register to the stack pointer and stores it AND'ed
+// with the high byte of the base address plus one.
+func actionTAS(cpu *cpu65C02S) {
+	cpu.stackPointer = cpu.accumulatorRegister & cpu.xRegister
+
+	storeAndHighByte(cpu, cpu.stackPointer, cpu.yRegister)
+}
+
+// Halts the processor, only a reset can restart it.
+func actionJAM(cpu *cpu65C02S) {
+	cpu.processorStopped = true
+}
+
+// Stores the value AND'ed with the high byte of the address before indexing plus one. This is how
+// SHA, SHX, SHY and TAS behave on most chips. If adding the index crossed a page boundary, the high
+// byte of the effective address is replaced by the value stored.
+func storeAndHighByte(cpu *cpu65C02S, value uint8, index uint8) {
+	address := cpu.instructionRegister
+	base := address - uint16(index)
+
+	value &= uint8(base>>8) + 1
+
+	if base&0xFF00 != address&0xFF00 {
+		address = uint16(value)<<8 | address&0x00FF
+	}
+
+	cpu.setWriteBus(address, value)
+}
@@ -0,0 +1,642 @@
+package cpu
+
+/**************************************************************************************************
+* Registers and flags of the 65C816S
+*
+* The actions use the width selected by the M or X flag for the instruction. 8 bits operations on
+* the accumulator keep the value of B, the high byte of C.
+**************************************************************************************************/
+
+// Returns the mask of the bits used by the current instruction
+func (cpu *cpu65C816S) widthMask() uint16 {
+	if cpu.width16 {
+		return 0xFFFF
+	}
+
+	return 0x00FF
+}
+
+// Returns the sign bit of the values used by the current instruction
+func (cpu *cpu65C816S) signBit() uint16 {
+	if cpu.width16 {
+		return 0x8000
+	}
+
+	return 0x0080
+}
+
+// Sets the zero and negative flags according to the value
+func (cpu *cpu65C816S) setZeroAndNegativeFlags(value uint16) {
+	cpu.processorStatusRegister.SetFlag(ZeroFlagBit, value&cpu.widthMask() == 0)
+	cpu.processorStatusRegister.SetFlag(NegativeFlagBit, value&cpu.signBit() != 0)
+}
+
+// Returns the accumulator, A or C depending on the width
+func (cpu *cpu65C816S) accumulator() uint16 {
+	return cpu.accumulatorRegister & cpu.widthMask()
+}
+
+// Sets the accumulator. With 8 bits only A is set and B keeps its value.
+func (cpu *cpu65C816S) setAccumulator(value uint16) {
+	if cpu.width16 {
+		cpu.accumulatorRegister = value
+	} else {
+		cpu.accumulatorRegister = cpu.accumulatorRegister&0xFF00 | value&0x00FF
+	}
+}
+
+// Sets an index register. With 8 bits the high byte is 0.
+func (cpu *cpu65C816S) setIndex(register *uint16, value uint16) {
+	*register = value & cpu.widthMask()
+}
+
+// Adds the data to the accumulator with carry. Subtraction adds the complement of the data.
+// In decimal mode each digit is adjusted as it is added, the flags are evaluated on the BCD result
+// except for overflow that is evaluated before adjusting the last digit.
+// Decimal mode based on the wdc65816 core of https://github.com/bsnes-emu/bsnes
+func (cpu *cpu65C816S) addWithCarry(subtract bool) {
+	mask := int(cpu.widthMask())
+	sign := int(cpu.signBit())
+
+	accumulator := int(cpu.accumulator())
+	data := int(cpu.data) & mask
+	if subtract {
+		data ^= mask
+	}
+
+	carry := 0
+	if cpu.processorStatusRegister.Flag(CarryFlagBit) {
+		carry = 1
+	}
+
+	var result int
+	decimal := cpu.processorStatusRegister.Flag(DecimalModeFlagBit)
+
+	if !decimal {
+		result = accumulator + data + carry
+	} else {
+		digits := 2
+		if cpu.width16 {
+			digits = 4
+		}
+
+		for digit := range digits {
+			shift := 4 * digit
+			nibble := 0xF << shift
+
+			result = accumulator&nibble + data&nibble + carry<<shift + result&(1<<shift-1)
+
+			if digit == digits-1 {
+				break
+			}
+
+			result = adjustDigit(result, shift, subtract)
+
+			carry = 0
+			if result > 0x10<<shift-1 {
+				carry = 1
+			}
+		}
+	}
+
+	overflow := ^(accumulator ^ data) & (accumulator ^ result) & sign
+	cpu.processorStatusRegister.SetFlag(OverflowFlagBit, overflow != 0)
+
+	if decimal {
+		result = adjustDigit(result, 4*(bitsOf(mask)/4-1), subtract)
+	}
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, result > mask)
+
+	cpu.setAccumulator(uint16(result))
+	cpu.setZeroAndNegativeFlags(uint16(result))
+}
+
+// Adjusts the decimal digit at the specified shift after adding it
+func adjustDigit(result int, shift int, subtract bool) int {
+	if subtract {
+		if result <= 0x10<<shift-1 {
+			result -= 0x06 << shift
+		}
+	} else {
+		if result > 0x0A<<shift-1 {
+			result += 0x06 << shift
+		}
+	}
+
+	return result
+}
+
+// Returns the number of bits of the mask
+func bitsOf(mask int) int {
+	if mask == 0xFFFF {
+		return 16
+	}
+
+	return 8
+}
+
+// Compares the register with the data, setting the flags as the subtraction would
+func (cpu *cpu65C816S) compare(register uint16) {
+	register &= cpu.widthMask()
+	data := cpu.data & cpu.widthMask()
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, register >= data)
+	cpu.setZeroAndNegativeFlags(register - data)
+}
+
+/**************************************************************************************************
+* Instructions shared with the 65C02
+**************************************************************************************************/
+
+// A,Z,C,N,V = A+M+C
+func actionADC816(cpu *cpu65C816S) {
+	cpu.addWithCarry(false)
+}
+
+// A,Z,C,N,V = A-M-(1-C)
+func actionSBC816(cpu *cpu65C816S) {
+	cpu.addWithCarry(true)
+}
+
+// A,Z,N = A&M
+func actionAND816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.accumulator() & cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.accumulator())
+}
+
+// A,Z,N = A|M
+func actionORA816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.accumulator() | cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.accumulator())
+}
+
+// A,Z,N = A^M
+func actionEOR816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.accumulator() ^ cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.accumulator())
+}
+
+// Z = A & M, N = M7 (M15), V = M6 (M14)
+// In immediate mode only the zero flag is affected.
+func actionBIT816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(ZeroFlagBit, cpu.accumulator()&cpu.data == 0)
+
+	if cpu.currentAddressMode.name != AddressModeImmediate {
+		cpu.processorStatusRegister.SetFlag(NegativeFlagBit, cpu.data&cpu.signBit() != 0)
+		cpu.processorStatusRegister.SetFlag(OverflowFlagBit, cpu.data&(cpu.signBit()>>1) != 0)
+	}
+}
+
+// Z,C,N = A-M
+func actionCMP816(cpu *cpu65C816S) {
+	cpu.compare(cpu.accumulatorRegister)
+}
+
+// Z,C,N = X-M
+func actionCPX816(cpu *cpu65C816S) {
+	cpu.compare(cpu.xRegister)
+}
+
+// Z,C,N = Y-M
+func actionCPY816(cpu *cpu65C816S) {
+	cpu.compare(cpu.yRegister)
+}
+
+// A,Z,N = M
+func actionLDA816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// X,Z,N = M
+func actionLDX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// Y,Z,N = M
+func actionLDY816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.yRegister, cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// M = A
+func actionSTA816(cpu *cpu65C816S) {
+	cpu.data = cpu.accumulator()
+}
+
+// M = X
+func actionSTX816(cpu *cpu65C816S) {
+	cpu.data = cpu.xRegister
+}
+
+// M = Y
+func actionSTY816(cpu *cpu65C816S) {
+	cpu.data = cpu.yRegister
+}
+
+// M = 0
+func actionSTZ816(cpu *cpu65C816S) {
+	cpu.data = 0
+}
+
+// M,Z,C,N = M*2
+func actionASL816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, cpu.data&cpu.signBit() != 0)
+	cpu.data = (cpu.data << 1) & cpu.widthMask()
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// M,Z,C,N = M/2
+func actionLSR816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, cpu.data&0x0001 != 0)
+	cpu.data = (cpu.data & cpu.widthMask()) >> 1
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// Moves each of the bits one place to the left, the carry goes into bit 0
+func actionROL816(cpu *cpu65C816S) {
+	carry := cpu.processorStatusRegister.Flag(CarryFlagBit)
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, cpu.data&cpu.signBit() != 0)
+	cpu.data = (cpu.data << 1) & cpu.widthMask()
+	if carry {
+		cpu.data |= 0x0001
+	}
+
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// Moves each of the bits one place to the right, the carry goes into the highest bit
+func actionROR816(cpu *cpu65C816S) {
+	carry := cpu.processorStatusRegister.Flag(CarryFlagBit)
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, cpu.data&0x0001 != 0)
+	cpu.data = (cpu.data & cpu.widthMask()) >> 1
+	if carry {
+		cpu.data |= cpu.signBit()
+	}
+
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// M,Z,N = M+1
+func actionINC816(cpu *cpu65C816S) {
+	cpu.data = (cpu.data + 1) & cpu.widthMask()
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// M,Z,N = M-1
+func actionDEC816(cpu *cpu65C816S) {
+	cpu.data = (cpu.data - 1) & cpu.widthMask()
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// Z = A & M, M = M | A
+func actionTSB816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(ZeroFlagBit, cpu.accumulator()&cpu.data == 0)
+	cpu.data = (cpu.data | cpu.accumulator()) & cpu.widthMask()
+}
+
+// Z = A & M, M = M & ~A
+func actionTRB816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(ZeroFlagBit, cpu.accumulator()&cpu.data == 0)
+	cpu.data = cpu.data &^ cpu.accumulator() & cpu.widthMask()
+}
+
+// Branch if carry clear
+func actionBCC816(cpu *cpu65C816S) {
+	cpu.branchTaken = !cpu.processorStatusRegister.Flag(CarryFlagBit)
+}
+
+// Branch if carry set
+func actionBCS816(cpu *cpu65C816S) {
+	cpu.branchTaken = cpu.processorStatusRegister.Flag(CarryFlagBit)
+}
+
+// Branch if equal
+func actionBEQ816(cpu *cpu65C816S) {
+	cpu.branchTaken = cpu.processorStatusRegister.Flag(ZeroFlagBit)
+}
+
+// Branch if not equal
+func actionBNE816(cpu *cpu65C816S) {
+	cpu.branchTaken = !cpu.processorStatusRegister.Flag(ZeroFlagBit)
+}
+
+// Branch if minus
+func actionBMI816(cpu *cpu65C816S) {
+	cpu.branchTaken = cpu.processorStatusRegister.Flag(NegativeFlagBit)
+}
+
+// Branch if positive
+func actionBPL816(cpu *cpu65C816S) {
+	cpu.branchTaken = !cpu.processorStatusRegister.Flag(NegativeFlagBit)
+}
+
+// Branch if overflow clear
+func actionBVC816(cpu *cpu65C816S) {
+	cpu.branchTaken = !cpu.processorStatusRegister.Flag(OverflowFlagBit)
+}
+
+// Branch if overflow set
+func actionBVS816(cpu *cpu65C816S) {
+	cpu.branchTaken = cpu.processorStatusRegister.Flag(OverflowFlagBit)
+}
+
+// Branch always
+func actionBRA816(cpu *cpu65C816S) {
+	cpu.branchTaken = true
+}
+
+// C = 0
+func actionCLC816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, false)
+}
+
+// D = 0
+func actionCLD816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(DecimalModeFlagBit, false)
+}
+
+// I = 0
+func actionCLI816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(IrqDisableFlagBit, false)
+}
+
+// V = 0
+func actionCLV816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(OverflowFlagBit, false)
+}
+
+// C = 1
+func actionSEC816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, true)
+}
+
+// D = 1
+func actionSED816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(DecimalModeFlagBit, true)
+}
+
+// I = 1
+func actionSEI816(cpu *cpu65C816S) {
+	cpu.processorStatusRegister.SetFlag(IrqDisableFlagBit, true)
+}
+
+// X,Z,N = X+1
+func actionINX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.xRegister+1)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// Y,Z,N = Y+1
+func actionINY816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.yRegister, cpu.yRegister+1)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// X,Z,N = X-1
+func actionDEX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.xRegister-1)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// Y,Z,N = Y-1
+func actionDEY816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.yRegister, cpu.yRegister-1)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// X,Z,N = A
+func actionTAX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.accumulatorRegister)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// Y,Z,N = A
+func actionTAY816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.yRegister, cpu.accumulatorRegister)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// A,Z,N = X
+func actionTXA816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.xRegister)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// A,Z,N = Y
+func actionTYA816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.yRegister)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// X,Z,N = S
+func actionTSX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.stackPointer)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// S = X. In emulation mode the stack stays on page 1.
+func actionTXS816(cpu *cpu65C816S) {
+	cpu.stackPointer = cpu.xRegister
+	if cpu.emulationMode {
+		cpu.stackPointer = 0x0100 | cpu.xRegister&0x00FF
+	}
+}
+
+// M = A
+func actionPHA816(cpu *cpu65C816S) {
+	cpu.data = cpu.accumulator()
+}
+
+// M = X
+func actionPHX816(cpu *cpu65C816S) {
+	cpu.data = cpu.xRegister
+}
+
+// M = Y
+func actionPHY816(cpu *cpu65C816S) {
+	cpu.data = cpu.yRegister
+}
+
+// M = P. In emulation mode bit 4 is the B flag which is always pushed as 1.
+func actionPHP816(cpu *cpu65C816S) {
+	cpu.data = uint16(cpu.processorStatusRegister)
+}
+
+// A,Z,N = M
+func actionPLA816(cpu *cpu65C816S) {
+	cpu.setAccumulator(cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// X,Z,N = M
+func actionPLX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// Y,Z,N = M
+func actionPLY816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.yRegister, cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// P = M
+func actionPLP816(cpu *cpu65C816S) {
+	cpu.setStatus(uint8(cpu.data))
+}
+
+// No operation
+func actionNOP816(cpu *cpu65C816S) {
+}
+
+// Stops the processor until an interrupt is requested
+func actionWAI816(cpu *cpu65C816S) {
+	cpu.processorPaused = true
+}
+
+// Stops the processor until it is reset
+func actionSTP816(cpu *cpu65C816S) {
+	cpu.processorStopped = true
+}
+
+/**************************************************************************************************
+* Instructions added by the 65C816
+**************************************************************************************************/
+
+// M = B
+// Pushes the data bank register.
+func actionPHB816(cpu *cpu65C816S) {
+	cpu.data = uint16(cpu.dataBankRegister)
+}
+
+// M = D
+// Pushes the direct register.
+func actionPHD816(cpu *cpu65C816S) {
+	cpu.data = cpu.directRegister
+}
+
+// M = K
+// Pushes the program bank register.
+func actionPHK816(cpu *cpu65C816S) {
+	cpu.data = uint16(cpu.programBankRegister)
+}
+
+// B,Z,N = M
+// Pulls the data bank register.
+func actionPLB816(cpu *cpu65C816S) {
+	cpu.dataBankRegister = uint8(cpu.data)
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// D,Z,N = M
+// Pulls the direct register.
+func actionPLD816(cpu *cpu65C816S) {
+	cpu.directRegister = cpu.data
+	cpu.setZeroAndNegativeFlags(cpu.data)
+}
+
+// P = P & ~M
+// Clears the flags set on the operand.
+func actionREP816(cpu *cpu65C816S) {
+	cpu.setStatus(uint8(cpu.processorStatusRegister) &^ uint8(cpu.data))
+}
+
+// P = P | M
+// Sets the flags set on the operand.
+func actionSEP816(cpu *cpu65C816S) {
+	cpu.setStatus(uint8(cpu.processorStatusRegister) | uint8(cpu.data))
+}
+
+// S = C
+// Transfers the 16 bits accumulator to the stack pointer. In emulation mode the stack stays on page 1.
+func actionTCS816(cpu *cpu65C816S) {
+	cpu.stackPointer = cpu.accumulatorRegister
+	if cpu.emulationMode {
+		cpu.stackPointer = 0x0100 | cpu.accumulatorRegister&0x00FF
+	}
+}
+
+// C,Z,N = S
+// Transfers the stack pointer to the 16 bits accumulator.
+func actionTSC816(cpu *cpu65C816S) {
+	cpu.accumulatorRegister = cpu.stackPointer
+	cpu.setZeroAndNegativeFlags(cpu.accumulatorRegister)
+}
+
+// D,Z,N = C
+// Transfers the 16 bits accumulator to the direct register.
+func actionTCD816(cpu *cpu65C816S) {
+	cpu.directRegister = cpu.accumulatorRegister
+	cpu.setZeroAndNegativeFlags(cpu.directRegister)
+}
+
+// C,Z,N = D
+// Transfers the direct register to the 16 bits accumulator.
+func actionTDC816(cpu *cpu65C816S) {
+	cpu.accumulatorRegister = cpu.directRegister
+	cpu.setZeroAndNegativeFlags(cpu.accumulatorRegister)
+}
+
+// Y,Z,N = X
+func actionTXY816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.yRegister, cpu.xRegister)
+	cpu.setZeroAndNegativeFlags(cpu.yRegister)
+}
+
+// X,Z,N = Y
+func actionTYX816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.yRegister)
+	cpu.setZeroAndNegativeFlags(cpu.xRegister)
+}
+
+// B <-> A, Z,N = A
+// Exchanges the bytes of the accumulator, the flags are set with the new value of A.
+func actionXBA816(cpu *cpu65C816S) {
+	cpu.accumulatorRegister = cpu.accumulatorRegister<<8 | cpu.accumulatorRegister>>8
+	cpu.setZeroAndNegativeFlags(cpu.accumulatorRegister)
+}
+
+// C <-> E
+// Exchanges the carry and emulation flags to switch between native and emulation mode.
+func actionXCE816(cpu *cpu65C816S) {
+	carry := cpu.processorStatusRegister.Flag(CarryFlagBit)
+
+	cpu.processorStatusRegister.SetFlag(CarryFlagBit, cpu.emulationMode)
+	cpu.setEmulationMode(carry)
+}
+
+// Reserved for future expansion, executes as a 2 bytes NOP
+func actionWDM816(cpu *cpu65C816S) {
+}
+
+// Moves a byte from X on the source bank to Y on the destination bank incrementing both, until C
+// is $FFFF. The data bank is set to the destination bank.
+func actionMVN816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.xRegister+1)
+	cpu.setIndex(&cpu.yRegister, cpu.yRegister+1)
+	cpu.moveBlock()
+}
+
+// Moves a byte from X on the source bank to Y on the destination bank decrementing both, until C
+// is $FFFF. The data bank is set to the destination bank.
+func actionMVP816(cpu *cpu65C816S) {
+	cpu.setIndex(&cpu.xRegister, cpu.xRegister-1)
+	cpu.setIndex(&cpu.yRegister, cpu.yRegister-1)
+	cpu.moveBlock()
+}
+
+// Decrements the count of bytes to move and executes the instruction again if there are more
+// bytes to move
+func (cpu *cpu65C816S) moveBlock() {
+	cpu.dataBankRegister = uint8(cpu.operand)
+	cpu.accumulatorRegister--
+
+	if cpu.accumulatorRegister != 0xFFFF {
+		cpu.programCounter -= 3
+	}
+}
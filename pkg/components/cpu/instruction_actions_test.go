@@ -25,8 +25,8 @@ func runInstructionTest(cpu *cpu65C02S, ram components.Memory, cycles uint64) {
 
 func evaluateRegisterValue(t *testing.T, cpu *cpu65C02S, name string, value uint8, expected uint8) {
 	if value != expected {
-		instruction := instructionSet.GetByOpCode(cpu.currentOpCode)
-		addressMode := addressModeSet.GetByName(instruction.addressMode)
+		instruction := cpu.instructionSet.GetByOpCode(cpu.currentOpCode)
+		addressMode := cpu.addressModeSet.GetByName(instruction.addressMode)
 
 		t.Errorf("%s - %s - Current value of %s (%02X) doesnt match the expected value of (%02X)", instruction.Mnemonic(), addressMode.Text(), name, value, expected)
 	}
@@ -36,8 +36,8 @@ func evaluateAddress(t *testing.T, cpu *cpu65C02S, ram components.Memory, addres
 	value := ram.Peek(uint32(address))
 
 	if value != expected {
-		instruction := instructionSet.GetByOpCode(cpu.currentOpCode)
-		addressMode := addressModeSet.GetByName(instruction.addressMode)
+		instruction := cpu.instructionSet.GetByOpCode(cpu.currentOpCode)
+		addressMode := cpu.addressModeSet.GetByName(instruction.addressMode)
 
 		t.Errorf("%s - %s - Current value (%02X) of address %04X doesnt match the expected value of (%02X)", instruction.Mnemonic(), addressMode.Text(), value, address, expected)
 	}
@@ -46,8 +46,8 @@ func evaluateAddress(t *testing.T, cpu *cpu65C02S, ram components.Memory, addres
 func evaluateFlag(t *testing.T, cpu *cpu65C02S, flagString string) {
 	const flags string = "czidb-vn"
 
-	instruction := instructionSet.GetByOpCode(cpu.currentOpCode)
-	addressMode := addressModeSet.GetByName(instruction.addressMode)
+	instruction := cpu.instructionSet.GetByOpCode(cpu.currentOpCode)
+	addressMode := cpu.addressModeSet.GetByName(instruction.addressMode)
 
 	for i, flag := range flags {
 		ucFlag := unicode.ToUpper(flag)
@@ -69,8 +69,8 @@ func evaluateFlag(t *testing.T, cpu *cpu65C02S, flagString string) {
 
 func evaluateProgramCounter(t *testing.T, cpu *cpu65C02S, expectedValue uint16) {
 	if cpu.programCounter != expectedValue {
-		instruction := instructionSet.GetByOpCode(cpu.currentOpCode)
-		addressMode := addressModeSet.GetByName(instruction.addressMode)
+		instruction := cpu.instructionSet.GetByOpCode(cpu.currentOpCode)
+		addressMode := cpu.addressModeSet.GetByName(instruction.addressMode)
 
 		t.Errorf("%s - %s - Current value (%04X) of PC doesnt match the expected value of (%04X)", instruction.Mnemonic(), addressMode.Text(), cpu.programCounter, expectedValue)
 	}
@@ -41,7 +41,7 @@ type CpuInstructionSet struct {
 }
 
 // GetByOpCode retrieves instruction data for a specific opcode.
-// This emulation treats invalid opcodes as NOP (No Operation).
+// Opcodes missing from the set are treated as NOP (No Operation).
 func (instruction *CpuInstructionSet) GetByOpCode(opCode components.OpCode) *CpuInstructionData {
 	value := instruction.opCodeIndex[opCode]
 	if value != nil {
@@ -52,11 +52,14 @@ func (instruction *CpuInstructionSet) GetByOpCode(opCode components.OpCode) *Cpu
 	}
 }
 
-// Creates the instruction set supported by this CPU
+// Creates the instruction set supported by this CPU. The undefined opcodes of the 65C02S are
+// NOPs with the length, cycles and bus reads of the real processor.
 func NewInstructionSet() *CpuInstructionSet {
 	var instructionData = []CpuInstructionData{
 		{0x00, BRK, nil, AddressModeBreak},
 		{0x01, ORA, actionORA, AddressModeZeroPageIndexedIndirectX},
+		{0x02, NOP, actionNOP, AddressModeImmediate},
+		{0x03, NOP, actionNOP, AddressModeSingleCycle},
 		{0x04, TSB, actionTSB, AddressModeZeroPageRMW},
 		{0x05, ORA, actionORA, AddressModeZeroPage},
 		{0x06, ASL, actionASL, AddressModeZeroPageRMW},
@@ -64,6 +67,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x08, PHP, actionPHP, AddressModePushStack},
 		{0x09, ORA, actionORA, AddressModeImmediate},
 		{0x0A, ASL, actionASL, AddressModeAccumulator},
+		{0x0B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x0C, TSB, actionTSB, AddressModeAbsoluteRMW},
 		{0x0D, ORA, actionORA, AddressModeAbsolute},
 		{0x0E, ASL, actionASL, AddressModeAbsoluteRMW},
@@ -72,6 +76,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x10, BPL, actionBPL, AddressModeRelative},
 		{0x11, ORA, actionORA, AddressModeZeroPageIndirectIndexedY},
 		{0x12, ORA, actionORA, AddressModeIndirectZeroPage},
+		{0x13, NOP, actionNOP, AddressModeSingleCycle},
 		{0x14, TRB, actionTRB, AddressModeZeroPageRMW},
 		{0x15, ORA, actionORA, AddressModeZeroPageX},
 		{0x16, ASL, actionASL, AddressModeZeroPageXRMW},
@@ -79,6 +84,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x18, CLC, actionCLC, AddressModeImplicit},
 		{0x19, ORA, actionORA, AddressModeAbsoluteY},
 		{0x1A, INC, actionINC, AddressModeAccumulator},
+		{0x1B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x1C, TRB, actionTRB, AddressModeAbsoluteRMW},
 		{0x1D, ORA, actionORA, AddressModeAbsoluteX},
 		{0x1E, ASL, actionASL, AddressModeAbsoluteXRMW},
@@ -86,6 +92,8 @@ func NewInstructionSet() *CpuInstructionSet {
 
 		{0x20, JSR, nil, AddressModeJumpToSubroutine},
 		{0x21, AND, actionAND, AddressModeZeroPageIndexedIndirectX},
+		{0x22, NOP, actionNOP, AddressModeImmediate},
+		{0x23, NOP, actionNOP, AddressModeSingleCycle},
 		{0x24, BIT, actionBIT, AddressModeZeroPage},
 		{0x25, AND, actionAND, AddressModeZeroPage},
 		{0x26, ROL, actionROL, AddressModeZeroPageRMW},
@@ -93,6 +101,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x28, PLP, actionPLP, AddressModePullStack},
 		{0x29, AND, actionAND, AddressModeImmediate},
 		{0x2A, ROL, actionROL, AddressModeAccumulator},
+		{0x2B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x2C, BIT, actionBIT, AddressModeAbsolute},
 		{0x2D, AND, actionAND, AddressModeAbsolute},
 		{0x2E, ROL, actionROL, AddressModeAbsoluteRMW},
@@ -101,6 +110,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x30, BMI, actionBMI, AddressModeRelative},
 		{0x31, AND, actionAND, AddressModeZeroPageIndirectIndexedY},
 		{0x32, AND, actionAND, AddressModeIndirectZeroPage},
+		{0x33, NOP, actionNOP, AddressModeSingleCycle},
 		{0x34, BIT, actionBIT, AddressModeZeroPageX},
 		{0x35, AND, actionAND, AddressModeZeroPageX},
 		{0x36, ROL, actionROL, AddressModeZeroPageXRMW},
@@ -108,6 +118,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x38, SEC, actionSEC, AddressModeImplicit},
 		{0x39, AND, actionAND, AddressModeAbsoluteY},
 		{0x3A, DEC, actionDEC, AddressModeAccumulator},
+		{0x3B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x3C, BIT, actionBIT, AddressModeAbsoluteX},
 		{0x3D, AND, actionAND, AddressModeAbsoluteX},
 		{0x3E, ROL, actionROL, AddressModeAbsoluteXRMW},
@@ -115,12 +126,16 @@ func NewInstructionSet() *CpuInstructionSet {
 
 		{0x40, RTI, nil, AddressModeReturnFromInterrupt},
 		{0x41, EOR, actionEOR, AddressModeZeroPageIndexedIndirectX},
+		{0x42, NOP, actionNOP, AddressModeImmediate},
+		{0x43, NOP, actionNOP, AddressModeSingleCycle},
+		{0x44, NOP, actionNOP, AddressModeZeroPage},
 		{0x45, EOR, actionEOR, AddressModeZeroPage},
 		{0x46, LSR, actionLSR, AddressModeZeroPageRMW},
 		{0x47, RMB4, actionRMB, AddressModeZeroPageRMW},
 		{0x48, PHA, actionPHA, AddressModePushStack},
 		{0x49, EOR, actionEOR, AddressModeImmediate},
 		{0x4A, LSR, actionLSR, AddressModeAccumulator},
+		{0x4B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x4C, JMP, actionJMP, AddressModeAbsoluteJump},
 		{0x4D, EOR, actionEOR, AddressModeAbsolute},
 		{0x4E, LSR, actionLSR, AddressModeAbsoluteRMW},
@@ -129,18 +144,24 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x50, BVC, actionBVC, AddressModeRelative},
 		{0x51, EOR, actionEOR, AddressModeZeroPageIndirectIndexedY},
 		{0x52, EOR, actionEOR, AddressModeIndirectZeroPage},
+		{0x53, NOP, actionNOP, AddressModeSingleCycle},
+		{0x54, NOP, actionNOP, AddressModeZeroPageX},
 		{0x55, EOR, actionEOR, AddressModeZeroPageX},
 		{0x56, LSR, actionLSR, AddressModeZeroPageXRMW},
 		{0x57, RMB5, actionRMB, AddressModeZeroPageRMW},
 		{0x58, CLI, actionCLI, AddressModeImplicit},
 		{0x59, EOR, actionEOR, AddressModeAbsoluteY},
 		{0x5A, PHY, actionPHY, AddressModePushStack},
+		{0x5B, NOP, actionNOP, AddressModeSingleCycle},
+		{0x5C, NOP, actionNOP, AddressModeAbsoluteNOP},
 		{0x5D, EOR, actionEOR, AddressModeAbsoluteX},
 		{0x5E, LSR, actionLSR, AddressModeAbsoluteXRMW},
 		{0x5F, BBR5, actionBBR, AddressModeRelativeExtended},
 
 		{0x60, RTS, nil, AddressModeReturnFromSubroutine},
 		{0x61, ADC, actionADC, AddressModeZeroPageIndexedIndirectX},
+		{0x62, NOP, actionNOP, AddressModeImmediate},
+		{0x63, NOP, actionNOP, AddressModeSingleCycle},
 		{0x64, STZ, actionSTZ, AddressModeZeroPageW},
 		{0x65, ADC, actionADC, AddressModeZeroPage},
 		{0x66, ROR, actionROR, AddressModeZeroPageRMW},
@@ -148,6 +169,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x68, PLA, actionPLA, AddressModePullStack},
 		{0x69, ADC, actionADC, AddressModeImmediate},
 		{0x6A, ROR, actionROR, AddressModeAccumulator},
+		{0x6B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x6C, JMP, actionJMP, AddressModeIndirect},
 		{0x6D, ADC, actionADC, AddressModeAbsolute},
 		{0x6E, ROR, actionROR, AddressModeAbsoluteRMW},
@@ -156,6 +178,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x70, BVS, actionBVS, AddressModeRelative},
 		{0x71, ADC, actionADC, AddressModeZeroPageIndirectIndexedY},
 		{0x72, ADC, actionADC, AddressModeIndirectZeroPage},
+		{0x73, NOP, actionNOP, AddressModeSingleCycle},
 		{0x74, STZ, actionSTZ, AddressModeZeroPageXW},
 		{0x75, ADC, actionADC, AddressModeZeroPageX},
 		{0x76, ROR, actionROR, AddressModeZeroPageXRMW},
@@ -163,6 +186,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x78, SEI, actionSEI, AddressModeImplicit},
 		{0x79, ADC, actionADC, AddressModeAbsoluteY},
 		{0x7A, PLY, actionPLY, AddressModePullStack},
+		{0x7B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x7C, JMP, actionJMP, AddressModeAbsoluteIndexedIndirect},
 		{0x7D, ADC, actionADC, AddressModeAbsoluteX},
 		{0x7E, ROR, actionROR, AddressModeAbsoluteXRMW},
@@ -170,6 +194,8 @@ func NewInstructionSet() *CpuInstructionSet {
 
 		{0x80, BRA, actionBRA, AddressModeRelative},
 		{0x81, STA, actionSTA, AddressModeZeroPageIndexedIndirectXW},
+		{0x82, NOP, actionNOP, AddressModeImmediate},
+		{0x83, NOP, actionNOP, AddressModeSingleCycle},
 		{0x84, STY, actionSTY, AddressModeZeroPageW},
 		{0x85, STA, actionSTA, AddressModeZeroPageW},
 		{0x86, STX, actionSTX, AddressModeZeroPageW},
@@ -177,6 +203,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x88, DEY, actionDEY, AddressModeImplicit},
 		{0x89, BIT, actionBIT, AddressModeImmediate},
 		{0x8A, TXA, actionTXA, AddressModeImplicit},
+		{0x8B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x8C, STY, actionSTY, AddressModeAbsoluteW},
 		{0x8D, STA, actionSTA, AddressModeAbsoluteW},
 		{0x8E, STX, actionSTX, AddressModeAbsoluteW},
@@ -185,6 +212,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x90, BCC, actionBCC, AddressModeRelative},
 		{0x91, STA, actionSTA, AddressModeZeroPageIndirectIndexedYW},
 		{0x92, STA, actionSTA, AddressModeIndirectZeroPageW},
+		{0x93, NOP, actionNOP, AddressModeSingleCycle},
 		{0x94, STY, actionSTY, AddressModeZeroPageXW},
 		{0x95, STA, actionSTA, AddressModeZeroPageXW},
 		{0x96, STX, actionSTX, AddressModeZeroPageYW},
@@ -192,6 +220,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0x98, TYA, actionTYA, AddressModeImplicit},
 		{0x99, STA, actionSTA, AddressModeAbsoluteYW},
 		{0x9A, TXS, actionTXS, AddressModeImplicit},
+		{0x9B, NOP, actionNOP, AddressModeSingleCycle},
 		{0x9C, STZ, actionSTZ, AddressModeAbsoluteW},
 		{0x9D, STA, actionSTA, AddressModeAbsoluteXW},
 		{0x9E, STZ, actionSTZ, AddressModeAbsoluteXW},
@@ -200,6 +229,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xA0, LDY, actionLDY, AddressModeImmediate},
 		{0xA1, LDA, actionLDA, AddressModeZeroPageIndexedIndirectX},
 		{0xA2, LDX, actionLDX, AddressModeImmediate},
+		{0xA3, NOP, actionNOP, AddressModeSingleCycle},
 		{0xA4, LDY, actionLDY, AddressModeZeroPage},
 		{0xA5, LDA, actionLDA, AddressModeZeroPage},
 		{0xA6, LDX, actionLDX, AddressModeZeroPage},
@@ -207,6 +237,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xA8, TAY, actionTAY, AddressModeImplicit},
 		{0xA9, LDA, actionLDA, AddressModeImmediate},
 		{0xAA, TAX, actionTAX, AddressModeImplicit},
+		{0xAB, NOP, actionNOP, AddressModeSingleCycle},
 		{0xAC, LDY, actionLDY, AddressModeAbsolute},
 		{0xAD, LDA, actionLDA, AddressModeAbsolute},
 		{0xAE, LDX, actionLDX, AddressModeAbsolute},
@@ -215,6 +246,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xB0, BCS, actionBCS, AddressModeRelative},
 		{0xB1, LDA, actionLDA, AddressModeZeroPageIndirectIndexedY},
 		{0xB2, LDA, actionLDA, AddressModeIndirectZeroPage},
+		{0xB3, NOP, actionNOP, AddressModeSingleCycle},
 		{0xB4, LDY, actionLDY, AddressModeZeroPageX},
 		{0xB5, LDA, actionLDA, AddressModeZeroPageX},
 		{0xB6, LDX, actionLDX, AddressModeZeroPageY},
@@ -222,6 +254,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xB8, CLV, actionCLV, AddressModeImplicit},
 		{0xB9, LDA, actionLDA, AddressModeAbsoluteY},
 		{0xBA, TSX, actionTSX, AddressModeImplicit},
+		{0xBB, NOP, actionNOP, AddressModeSingleCycle},
 		{0xBC, LDY, actionLDY, AddressModeAbsoluteX},
 		{0xBD, LDA, actionLDA, AddressModeAbsoluteX},
 		{0xBE, LDX, actionLDX, AddressModeAbsoluteY},
@@ -229,6 +262,8 @@ func NewInstructionSet() *CpuInstructionSet {
 
 		{0xC0, CPY, actionCPY, AddressModeImmediate},
 		{0xC1, CMP, actionCMP, AddressModeZeroPageIndexedIndirectX},
+		{0xC2, NOP, actionNOP, AddressModeImmediate},
+		{0xC3, NOP, actionNOP, AddressModeSingleCycle},
 		{0xC4, CPY, actionCPY, AddressModeZeroPage},
 		{0xC5, CMP, actionCMP, AddressModeZeroPage},
 		{0xC6, DEC, actionDEC, AddressModeZeroPageRMW},
@@ -245,6 +280,8 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xD0, BNE, actionBNE, AddressModeRelative},
 		{0xD1, CMP, actionCMP, AddressModeZeroPageIndirectIndexedY},
 		{0xD2, CMP, actionCMP, AddressModeIndirectZeroPage},
+		{0xD3, NOP, actionNOP, AddressModeSingleCycle},
+		{0xD4, NOP, actionNOP, AddressModeZeroPageX},
 		{0xD5, CMP, actionCMP, AddressModeZeroPageX},
 		{0xD6, DEC, actionDEC, AddressModeZeroPageXRMW},
 		{0xD7, SMB5, actionSMB, AddressModeZeroPageRMW},
@@ -252,12 +289,15 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xD9, CMP, actionCMP, AddressModeAbsoluteY},
 		{0xDA, PHX, actionPHX, AddressModePushStack},
 		{0xDB, STP, actionSTP, AddressModeImplicit},
+		{0xDC, NOP, actionNOP, AddressModeAbsolute},
 		{0xDD, CMP, actionCMP, AddressModeAbsoluteX},
 		{0xDE, DEC, actionDEC, AddressModeAbsoluteXRMW},
 		{0xDF, BBS5, actionBBS, AddressModeRelativeExtended},
 
 		{0xE0, CPX, actionCPX, AddressModeImmediate},
 		{0xE1, SBC, actionSBC, AddressModeZeroPageIndexedIndirectX},
+		{0xE2, NOP, actionNOP, AddressModeImmediate},
+		{0xE3, NOP, actionNOP, AddressModeSingleCycle},
 		{0xE4, CPX, actionCPX, AddressModeZeroPage},
 		{0xE5, SBC, actionSBC, AddressModeZeroPage},
 		{0xE6, INC, actionINC, AddressModeZeroPageRMW},
@@ -265,6 +305,7 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xE8, INX, actionINX, AddressModeImplicit},
 		{0xE9, SBC, actionSBC, AddressModeImmediate},
 		{0xEA, NOP, actionNOP, AddressModeImplicit},
+		{0xEB, NOP, actionNOP, AddressModeSingleCycle},
 		{0xEC, CPX, actionCPX, AddressModeAbsolute},
 		{0xED, SBC, actionSBC, AddressModeAbsolute},
 		{0xEE, INC, actionINC, AddressModeAbsoluteRMW},
@@ -273,12 +314,16 @@ func NewInstructionSet() *CpuInstructionSet {
 		{0xF0, BEQ, actionBEQ, AddressModeRelative},
 		{0xF1, SBC, actionSBC, AddressModeZeroPageIndirectIndexedY},
 		{0xF2, SBC, actionSBC, AddressModeIndirectZeroPage},
+		{0xF3, NOP, actionNOP, AddressModeSingleCycle},
+		{0xF4, NOP, actionNOP, AddressModeZeroPageX},
 		{0xF5, SBC, actionSBC, AddressModeZeroPageX},
 		{0xF6, INC, actionINC, AddressModeZeroPageXRMW},
 		{0xF7, SMB7, actionSMB, AddressModeZeroPageRMW},
 		{0xF8, SED, actionSED, AddressModeImplicit},
 		{0xF9, SBC, actionSBC, AddressModeAbsoluteY},
 		{0xFA, PLX, actionPLX, AddressModePullStack},
+		{0xFB, NOP, actionNOP, AddressModeSingleCycle},
+		{0xFC, NOP, actionNOP, AddressModeAbsolute},
 		{0xFD, SBC, actionSBC, AddressModeAbsoluteX},
 		{0xFE, INC, actionINC, AddressModeAbsoluteXRMW},
 		{0xFF, BBS7, actionBBS, AddressModeRelativeExtended},
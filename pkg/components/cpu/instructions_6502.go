@@ -0,0 +1,293 @@
+package cpu
+
+// NewNMOSInstructionSet creates the instruction set of the original NMOS 6502. It doesn't have the
+// instructions added by the 65C02 and every undefined opcode executes its "illegal" behaviour.
+// Illegal opcodes with unstable results (ANE, LXA, SHA, SHX, SHY and TAS) use the values seen on
+// most chips. JAM opcodes halt the processor until it is reset.
+// See https://www.masswerk.at/6502/6502_instruction_set.html and "No More Secrets" NMOS 6510
+// Unintended Opcodes for details.
+func NewNMOSInstructionSet() *CpuInstructionSet {
+	var instructionData = []CpuInstructionData{
+		{0x00, BRK, nil, AddressModeBreak},
+		{0x01, ORA, actionORA, AddressModeZeroPageIndexedIndirectX},
+		{0x02, JAM, actionJAM, AddressModeImplicit},
+		{0x03, SLO, actionSLO, AddressModeZeroPageIndexedIndirectXRMW},
+		{0x04, NOP, actionNOP, AddressModeZeroPage},
+		{0x05, ORA, actionORA, AddressModeZeroPage},
+		{0x06, ASL, actionASL, AddressModeZeroPageRMW},
+		{0x07, SLO, actionSLO, AddressModeZeroPageRMW},
+		{0x08, PHP, actionPHP, AddressModePushStack},
+		{0x09, ORA, actionORA, AddressModeImmediate},
+		{0x0A, ASL, actionASL, AddressModeAccumulator},
+		{0x0B, ANC, actionANC, AddressModeImmediate},
+		{0x0C, NOP, actionNOP, AddressModeAbsolute},
+		{0x0D, ORA, actionORA, AddressModeAbsolute},
+		{0x0E, ASL, actionASL, AddressModeAbsoluteRMW},
+		{0x0F, SLO, actionSLO, AddressModeAbsoluteRMW},
+
+		{0x10, BPL, actionBPL, AddressModeRelative},
+		{0x11, ORA, actionORA, AddressModeZeroPageIndirectIndexedY},
+		{0x12, JAM, actionJAM, AddressModeImplicit},
+		{0x13, SLO, actionSLO, AddressModeZeroPageIndirectIndexedYRMW},
+		{0x14, NOP, actionNOP, AddressModeZeroPageX},
+		{0x15, ORA, actionORA, AddressModeZeroPageX},
+		{0x16, ASL, actionASL, AddressModeZeroPageXRMW},
+		{0x17, SLO, actionSLO, AddressModeZeroPageXRMW},
+		{0x18, CLC, actionCLC, AddressModeImplicit},
+		{0x19, ORA, actionORA, AddressModeAbsoluteY},
+		{0x1A, NOP, actionNOP, AddressModeImplicit},
+		{0x1B, SLO, actionSLO, AddressModeAbsoluteYRMW},
+		{0x1C, NOP, actionNOP, AddressModeAbsoluteX},
+		{0x1D, ORA, actionORA, AddressModeAbsoluteX},
+		{0x1E, ASL, actionASL, AddressModeAbsoluteXRMW},
+		{0x1F, SLO, actionSLO, AddressModeAbsoluteXRMW},
+
+		{0x20, JSR, nil, AddressModeJumpToSubroutine},
+		{0x21, AND, actionAND, AddressModeZeroPageIndexedIndirectX},
+		{0x22, JAM, actionJAM, AddressModeImplicit},
+		{0x23, RLA, actionRLA, AddressModeZeroPageIndexedIndirectXRMW},
+		{0x24, BIT, actionBIT, AddressModeZeroPage},
+		{0x25, AND, actionAND, AddressModeZeroPage},
+		{0x26, ROL, actionROL, AddressModeZeroPageRMW},
+		{0x27, RLA, actionRLA, AddressModeZeroPageRMW},
+		{0x28, PLP, actionPLP, AddressModePullStack},
+		{0x29, AND, actionAND, AddressModeImmediate},
+		{0x2A, ROL, actionROL, AddressModeAccumulator},
+		{0x2B, ANC, actionANC, AddressModeImmediate},
+		{0x2C, BIT, actionBIT, AddressModeAbsolute},
+		{0x2D, AND, actionAND, AddressModeAbsolute},
+		{0x2E, ROL, actionROL, AddressModeAbsoluteRMW},
+		{0x2F, RLA, actionRLA, AddressModeAbsoluteRMW},
+
+		{0x30, BMI, actionBMI, AddressModeRelative},
+		{0x31, AND, actionAND, AddressModeZeroPageIndirectIndexedY},
+		{0x32, JAM, actionJAM, AddressModeImplicit},
+		{0x33, RLA, actionRLA, AddressModeZeroPageIndirectIndexedYRMW},
+		{0x34, NOP, actionNOP, AddressModeZeroPageX},
+		{0x35, AND, actionAND, AddressModeZeroPageX},
+		{0x36, ROL, actionROL, AddressModeZeroPageXRMW},
+		{0x37, RLA, actionRLA, AddressModeZeroPageXRMW},
+		{0x38, SEC, actionSEC, AddressModeImplicit},
+		{0x39, AND, actionAND, AddressModeAbsoluteY},
+		{0x3A, NOP, actionNOP, AddressModeImplicit},
+		{0x3B, RLA, actionRLA, AddressModeAbsoluteYRMW},
+		{0x3C, NOP, actionNOP, AddressModeAbsoluteX},
+		{0x3D, AND, actionAND, AddressModeAbsoluteX},
+		{0x3E, ROL, actionROL, AddressModeAbsoluteXRMW},
+		{0x3F, RLA, actionRLA, AddressModeAbsoluteXRMW},
+
+		{0x40, RTI, nil, AddressModeReturnFromInterrupt},
+		{0x41, EOR, actionEOR, AddressModeZeroPageIndexedIndirectX},
+		{0x42, JAM, actionJAM, AddressModeImplicit},
+		{0x43, SRE, actionSRE, AddressModeZeroPageIndexedIndirectXRMW},
+		{0x44, NOP, actionNOP, AddressModeZeroPage},
+		{0x45, EOR, actionEOR, AddressModeZeroPage},
+		{0x46, LSR, actionLSR, AddressModeZeroPageRMW},
+		{0x47, SRE, actionSRE, AddressModeZeroPageRMW},
+		{0x48, PHA, actionPHA, AddressModePushStack},
+		{0x49, EOR, actionEOR, AddressModeImmediate},
+		{0x4A, LSR, actionLSR, AddressModeAccumulator},
+		{0x4B, ALR, actionALR, AddressModeImmediate},
+		{0x4C, JMP, actionJMP, AddressModeAbsoluteJump},
+		{0x4D, EOR, actionEOR, AddressModeAbsolute},
+		{0x4E, LSR, actionLSR, AddressModeAbsoluteRMW},
+		{0x4F, SRE, actionSRE, AddressModeAbsoluteRMW},
+
+		{0x50, BVC, actionBVC, AddressModeRelative},
+		{0x51, EOR, actionEOR, AddressModeZeroPageIndirectIndexedY},
+		{0x52, JAM, actionJAM, AddressModeImplicit},
+		{0x53, SRE, actionSRE, AddressModeZeroPageIndirectIndexedYRMW},
+		{0x54, NOP, actionNOP, AddressModeZeroPageX},
+		{0x55, EOR, actionEOR, AddressModeZeroPageX},
+		{0x56, LSR, actionLSR, AddressModeZeroPageXRMW},
+		{0x57, SRE, actionSRE, AddressModeZeroPageXRMW},
+		{0x58, CLI, actionCLI, AddressModeImplicit},
+		{0x59, EOR, actionEOR, AddressModeAbsoluteY},
+		{0x5A, NOP, actionNOP, AddressModeImplicit},
+		{0x5B, SRE, actionSRE, AddressModeAbsoluteYRMW},
+		{0x5C, NOP, actionNOP, AddressModeAbsoluteX},
+		{0x5D, EOR, actionEOR, AddressModeAbsoluteX},
+		{0x5E, LSR, actionLSR, AddressModeAbsoluteXRMW},
+		{0x5F, SRE, actionSRE, AddressModeAbsoluteXRMW},
+
+		{0x60, RTS, nil, AddressModeReturnFromSubroutine},
+		{0x61, ADC, actionADCNMOS, AddressModeZeroPageIndexedIndirectX},
+		{0x62, JAM, actionJAM, AddressModeImplicit},
+		{0x63, RRA, actionRRA, AddressModeZeroPageIndexedIndirectXRMW},
+		{0x64, NOP, actionNOP, AddressModeZeroPage},
+		{0x65, ADC, actionADCNMOS, AddressModeZeroPage},
+		{0x66, ROR, actionROR, AddressModeZeroPageRMW},
+		{0x67, RRA, actionRRA, AddressModeZeroPageRMW},
+		{0x68, PLA, actionPLA, AddressModePullStack},
+		{0x69, ADC, actionADCNMOS, AddressModeImmediate},
+		{0x6A, ROR, actionROR, AddressModeAccumulator},
+		{0x6B, ARR, actionARR, AddressModeImmediate},
+		{0x6C, JMP, actionJMP, AddressModeIndirect},
+		{0x6D, ADC, actionADCNMOS, AddressModeAbsolute},
+		{0x6E, ROR, actionROR, AddressModeAbsoluteRMW},
+		{0x6F, RRA, actionRRA, AddressModeAbsoluteRMW},
+
+		{0x70, BVS, actionBVS, AddressModeRelative},
+		{0x71, ADC, actionADCNMOS, AddressModeZeroPageIndirectIndexedY},
+		{0x72, JAM, actionJAM, AddressModeImplicit},
+		{0x73, RRA, actionRRA, AddressModeZeroPageIndirectIndexedYRMW},
+		{0x74, NOP, actionNOP, AddressModeZeroPageX},
+		{0x75, ADC, actionADCNMOS, AddressModeZeroPageX},
+		{0x76, ROR, actionROR, AddressModeZeroPageXRMW},
+		{0x77, RRA, actionRRA, AddressModeZeroPageXRMW},
+		{0x78, SEI, actionSEI, AddressModeImplicit},
+		{0x79, ADC, actionADCNMOS, AddressModeAbsoluteY},
+		{0x7A, NOP, actionNOP, AddressModeImplicit},
+		{0x7B, RRA, actionRRA, AddressModeAbsoluteYRMW},
+		{0x7C, NOP, actionNOP, AddressModeAbsoluteX},
+		{0x7D, ADC, actionADCNMOS, AddressModeAbsoluteX},
+		{0x7E, ROR, actionROR, AddressModeAbsoluteXRMW},
+		{0x7F, RRA, actionRRA, AddressModeAbsoluteXRMW},
+
+		{0x80, NOP, actionNOP, AddressModeImmediate},
+		{0x81, STA, actionSTA, AddressModeZeroPageIndexedIndirectXW},
+		{0x82, NOP, actionNOP, AddressModeImmediate},
+		{0x83, SAX, actionSAX, AddressModeZeroPageIndexedIndirectXW},
+		{0x84, STY, actionSTY, AddressModeZeroPageW},
+		{0x85, STA, actionSTA, AddressModeZeroPageW},
+		{0x86, STX, actionSTX, AddressModeZeroPageW},
+		{0x87, SAX, actionSAX, AddressModeZeroPageW},
+		{0x88, DEY, actionDEY, AddressModeImplicit},
+		{0x89, NOP, actionNOP, AddressModeImmediate},
+		{0x8A, TXA, actionTXA, AddressModeImplicit},
+		{0x8B, ANE, actionANE, AddressModeImmediate},
+		{0x8C, STY, actionSTY, AddressModeAbsoluteW},
+		{0x8D, STA, actionSTA, AddressModeAbsoluteW},
+		{0x8E, STX, actionSTX, AddressModeAbsoluteW},
+		{0x8F, SAX, actionSAX, AddressModeAbsoluteW},
+
+		{0x90, BCC, actionBCC, AddressModeRelative},
+		{0x91, STA, actionSTA, AddressModeZeroPageIndirectIndexedYW},
+		{0x92, JAM, actionJAM, AddressModeImplicit},
+		{0x93, SHA, actionSHA, AddressModeZeroPageIndirectIndexedYW},
+		{0x94, STY, actionSTY, AddressModeZeroPageXW},
+		{0x95, STA, actionSTA, AddressModeZeroPageXW},
+		{0x96, STX, actionSTX, AddressModeZeroPageYW},
+		{0x97, SAX, actionSAX, AddressModeZeroPageYW},
+		{0x98, TYA, actionTYA, AddressModeImplicit},
+		{0x99, STA, actionSTA, AddressModeAbsoluteYW},
+		{0x9A, TXS, actionTXS, AddressModeImplicit},
+		{0x9B, TAS, actionTAS, AddressModeAbsoluteYW},
+		{0x9C, SHY, actionSHY, AddressModeAbsoluteXW},
+		{0x9D, STA, actionSTA, AddressModeAbsoluteXW},
+		{0x9E, SHX, actionSHX, AddressModeAbsoluteYW},
+		{0x9F, SHA, actionSHA, AddressModeAbsoluteYW},
+
+		{0xA0, LDY, actionLDY, AddressModeImmediate},
+		{0xA1, LDA, actionLDA, AddressModeZeroPageIndexedIndirectX},
+		{0xA2, LDX, actionLDX, AddressModeImmediate},
+		{0xA3, LAX, actionLAX, AddressModeZeroPageIndexedIndirectX},
+		{0xA4, LDY, actionLDY, AddressModeZeroPage},
+		{0xA5, LDA, actionLDA, AddressModeZeroPage},
+		{0xA6, LDX, actionLDX, AddressModeZeroPage},
+		{0xA7, LAX, actionLAX, AddressModeZeroPage},
+		{0xA8, TAY, actionTAY, AddressModeImplicit},
+		{0xA9, LDA, actionLDA, AddressModeImmediate},
+		{0xAA, TAX, actionTAX, AddressModeImplicit},
+		{0xAB, LXA, actionLXA, AddressModeImmediate},
+		{0xAC, LDY, actionLDY, AddressModeAbsolute},
+		{0xAD, LDA, actionLDA, AddressModeAbsolute},
+		{0xAE, LDX, actionLDX, AddressModeAbsolute},
+		{0xAF, LAX, actionLAX, AddressModeAbsolute},
+
+		{0xB0, BCS, actionBCS, AddressModeRelative},
+		{0xB1, LDA, actionLDA, AddressModeZeroPageIndirectIndexedY},
+		{0xB2, JAM, actionJAM, AddressModeImplicit},
+		{0xB3, LAX, actionLAX, AddressModeZeroPageIndirectIndexedY},
+		{0xB4, LDY, actionLDY, AddressModeZeroPageX},
+		{0xB5, LDA, actionLDA, AddressModeZeroPageX},
+		{0xB6, LDX, actionLDX, AddressModeZeroPageY},
+		{0xB7, LAX, actionLAX, AddressModeZeroPageY},
+		{0xB8, CLV, actionCLV, AddressModeImplicit},
+		{0xB9, LDA, actionLDA, AddressModeAbsoluteY},
+		{0xBA, TSX, actionTSX, AddressModeImplicit},
+		{0xBB, LAS, actionLAS, AddressModeAbsoluteY},
+		{0xBC, LDY, actionLDY, AddressModeAbsoluteX},
+		{0xBD, LDA, actionLDA, AddressModeAbsoluteX},
+		{0xBE, LDX, actionLDX, AddressModeAbsoluteY},
+		{0xBF, LAX, actionLAX, AddressModeAbsoluteY},
+
+		{0xC0, CPY, actionCPY, AddressModeImmediate},
+		{0xC1, CMP, actionCMP, AddressModeZeroPageIndexedIndirectX},
+		{0xC2, NOP, actionNOP, AddressModeImmediate},
+		{0xC3, DCP, actionDCP, AddressModeZeroPageIndexedIndirectXRMW},
+		{0xC4, CPY, actionCPY, AddressModeZeroPage},
+		{0xC5, CMP, actionCMP, AddressModeZeroPage},
+		{0xC6, DEC, actionDEC, AddressModeZeroPageRMW},
+		{0xC7, DCP, actionDCP, AddressModeZeroPageRMW},
+		{0xC8, INY, actionINY, AddressModeImplicit},
+		{0xC9, CMP, actionCMP, AddressModeImmediate},
+		{0xCA, DEX, actionDEX, AddressModeImplicit},
+		{0xCB, SBX, actionSBX, AddressModeImmediate},
+		{0xCC, CPY, actionCPY, AddressModeAbsolute},
+		{0xCD, CMP, actionCMP, AddressModeAbsolute},
+		{0xCE, DEC, actionDEC, AddressModeAbsoluteRMW},
+		{0xCF, DCP, actionDCP, AddressModeAbsoluteRMW},
+
+		{0xD0, BNE, actionBNE, AddressModeRelative},
+		{0xD1, CMP, actionCMP, AddressModeZeroPageIndirectIndexedY},
+		{0xD2, JAM, actionJAM, AddressModeImplicit},
+		{0xD3, DCP, actionDCP, AddressModeZeroPageIndirectIndexedYRMW},
+		{0xD4, NOP, actionNOP, AddressModeZeroPageX},
+		{0xD5, CMP, actionCMP, AddressModeZeroPageX},
+		{0xD6, DEC, actionDEC, AddressModeZeroPageXRMW},
+		{0xD7, DCP, actionDCP, AddressModeZeroPageXRMW},
+		{0xD8, CLD, actionCLD, AddressModeImplicit},
+		{0xD9, CMP, actionCMP, AddressModeAbsoluteY},
+		{0xDA, NOP, actionNOP, AddressModeImplicit},
+		{0xDB, DCP, actionDCP, AddressModeAbsoluteYRMW},
+		{0xDC, NOP, actionNOP, AddressModeAbsoluteX},
+		{0xDD, CMP, actionCMP, AddressModeAbsoluteX},
+		{0xDE, DEC, actionDEC, AddressModeAbsoluteXRMW},
+		{0xDF, DCP, actionDCP, AddressModeAbsoluteXRMW},
+
+		{0xE0, CPX, actionCPX, AddressModeImmediate},
+		{0xE1, SBC, actionSBCNMOS, AddressModeZeroPageIndexedIndirectX},
+		{0xE2, NOP, actionNOP, AddressModeImmediate},
+		{0xE3, ISC, actionISC, AddressModeZeroPageIndexedIndirectXRMW},
+		{0xE4, CPX, actionCPX, AddressModeZeroPage},
+		{0xE5, SBC, actionSBCNMOS, AddressModeZeroPage},
+		{0xE6, INC, actionINC, AddressModeZeroPageRMW},
+		{0xE7, ISC, actionISC, AddressModeZeroPageRMW},
+		{0xE8, INX, actionINX, AddressModeImplicit},
+		{0xE9, SBC, actionSBCNMOS, AddressModeImmediate},
+		{0xEA, NOP, actionNOP, AddressModeImplicit},
+		{0xEB, SBC, actionSBCNMOS, AddressModeImmediate}, // USBC, same as SBC immediate
+		{0xEC, CPX, actionCPX, AddressModeAbsolute},
+		{0xED, SBC, actionSBCNMOS, AddressModeAbsolute},
+		{0xEE, INC, actionINC, AddressModeAbsoluteRMW},
+		{0xEF, ISC, actionISC, AddressModeAbsoluteRMW},
+
+		{0xF0, BEQ, actionBEQ, AddressModeRelative},
+		{0xF1, SBC, actionSBCNMOS, AddressModeZeroPageIndirectIndexedY},
+		{0xF2, JAM, actionJAM, AddressModeImplicit},
+		{0xF3, ISC, actionISC, AddressModeZeroPageIndirectIndexedYRMW},
+		{0xF4, NOP, actionNOP, AddressModeZeroPageX},
+		{0xF5, SBC, actionSBCNMOS, AddressModeZeroPageX},
+		{0xF6, INC, actionINC, AddressModeZeroPageXRMW},
+		{0xF7, ISC, actionISC, AddressModeZeroPageXRMW},
+		{0xF8, SED, actionSED, AddressModeImplicit},
+		{0xF9, SBC, actionSBCNMOS, AddressModeAbsoluteY},
+		{0xFA, NOP, actionNOP, AddressModeImplicit},
+		{0xFB, ISC, actionISC, AddressModeAbsoluteYRMW},
+		{0xFC, NOP, actionNOP, AddressModeAbsoluteX},
+		{0xFD, SBC, actionSBCNMOS, AddressModeAbsoluteX},
+		{0xFE, INC, actionINC, AddressModeAbsoluteXRMW},
+		{0xFF, ISC, actionISC, AddressModeAbsoluteXRMW},
+	}
+
+	instructionSet := CpuInstructionSet{
+		opCodeIndex: [0x100]*CpuInstructionData{},
+	}
+
+	for i := range instructionData {
+		instructionSet.opCodeIndex[instructionData[i].opcode] = &instructionData[i]
+	}
+
+	return &instructionSet
+}
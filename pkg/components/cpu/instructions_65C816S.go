@@ -0,0 +1,362 @@
+package cpu
+
+import "github.com/fran150/clementina-6502/pkg/components"
+
+// registerWidth indicates which flag selects the width of the registers or memory used by an
+// instruction of the 65C816S.
+type registerWidth uint8
+
+const (
+	widthByte   registerWidth = iota // Always 8 bits
+	widthMemory                      // 8 or 16 bits according to the M flag
+	widthIndex                       // 8 or 16 bits according to the X flag
+	widthWord                        // Always 16 bits
+)
+
+// memoryAccess indicates how an instruction of the 65C816S uses its effective address.
+type memoryAccess uint8
+
+const (
+	accessRead   memoryAccess = iota // Reads the value, for example LDA
+	accessWrite                      // Writes a register, for example STA
+	accessModify                     // Reads, modifies and writes back the value, for example INC
+)
+
+// instructionData65C816S contains the information needed to execute an instruction of the 65C816S.
+// Unlike the 65C02S, the width of the registers is part of the instruction as the same address
+// mode can read 1 or 2 bytes.
+type instructionData65C816S struct {
+	opcode      components.OpCode      // The numeric opcode value (0x00-0xFF)
+	mnemonic    components.Mnemonic    // The assembly language representation
+	action      func(cpu *cpu65C816S)  // Function that implements the instruction's behavior
+	addressMode components.AddressMode // The addressing mode used by this instruction
+	width       registerWidth          // Flag that selects the width of the data
+}
+
+// Returns the OpCode value of this instruction and address mode
+func (data *instructionData65C816S) OpCode() components.OpCode {
+	return data.opcode
+}
+
+// Returns the Mnemonic for the instruction.
+func (data *instructionData65C816S) Mnemonic() components.Mnemonic {
+	return data.mnemonic
+}
+
+// Returns the address mode corresponding to this instruction's OpCode
+func (data *instructionData65C816S) AddressMode() components.AddressMode {
+	return data.addressMode
+}
+
+// ----------------------------------------------------------------------
+
+// instructionSet65C816S contains the 256 opcodes of the 65C816S, all of them are defined.
+type instructionSet65C816S struct {
+	opCodeIndex [0x100]*instructionData65C816S
+}
+
+// GetByOpCode retrieves instruction data for a specific opcode.
+func (instruction *instructionSet65C816S) GetByOpCode(opCode components.OpCode) *instructionData65C816S {
+	return instruction.opCodeIndex[opCode]
+}
+
+// Returns how the instruction uses its effective address
+func (data *instructionData65C816S) access() memoryAccess {
+	switch data.mnemonic {
+	case STA, STX, STY, STZ:
+		return accessWrite
+	case ASL, LSR, ROL, ROR, INC, DEC, TSB, TRB:
+		return accessModify
+	default:
+		return accessRead
+	}
+}
+
+// Creates the instruction set of the 65C816S. The opcodes of the 65C02S keep their meaning except
+// for BBR, BBS, RMB and SMB, whose opcodes are used by the new instructions and address modes.
+// See table 5-4 of https://www.westerndesigncenter.com/wdc/documentation/w65c816s.pdf
+func newInstructionSet65C816S() *instructionSet65C816S {
+	var instructionData = []instructionData65C816S{
+		{0x00, BRK, nil, AddressModeBreak, widthByte},
+		{0x01, ORA, actionORA816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0x02, COP, nil, AddressModeBreak, widthByte},
+		{0x03, ORA, actionORA816, AddressModeStackRelative, widthMemory},
+		{0x04, TSB, actionTSB816, AddressModeZeroPage, widthMemory},
+		{0x05, ORA, actionORA816, AddressModeZeroPage, widthMemory},
+		{0x06, ASL, actionASL816, AddressModeZeroPage, widthMemory},
+		{0x07, ORA, actionORA816, AddressModeDirectIndirectLong, widthMemory},
+		{0x08, PHP, actionPHP816, AddressModePushStack, widthByte},
+		{0x09, ORA, actionORA816, AddressModeImmediate, widthMemory},
+		{0x0A, ASL, actionASL816, AddressModeAccumulator, widthMemory},
+		{0x0B, PHD, actionPHD816, AddressModePushStack, widthWord},
+		{0x0C, TSB, actionTSB816, AddressModeAbsolute, widthMemory},
+		{0x0D, ORA, actionORA816, AddressModeAbsolute, widthMemory},
+		{0x0E, ASL, actionASL816, AddressModeAbsolute, widthMemory},
+		{0x0F, ORA, actionORA816, AddressModeAbsoluteLong, widthMemory},
+
+		{0x10, BPL, actionBPL816, AddressModeRelative, widthByte},
+		{0x11, ORA, actionORA816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0x12, ORA, actionORA816, AddressModeIndirectZeroPage, widthMemory},
+		{0x13, ORA, actionORA816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0x14, TRB, actionTRB816, AddressModeZeroPage, widthMemory},
+		{0x15, ORA, actionORA816, AddressModeZeroPageX, widthMemory},
+		{0x16, ASL, actionASL816, AddressModeZeroPageX, widthMemory},
+		{0x17, ORA, actionORA816, AddressModeDirectIndirectLongY, widthMemory},
+		{0x18, CLC, actionCLC816, AddressModeImplicit, widthByte},
+		{0x19, ORA, actionORA816, AddressModeAbsoluteY, widthMemory},
+		{0x1A, INC, actionINC816, AddressModeAccumulator, widthMemory},
+		{0x1B, TCS, actionTCS816, AddressModeImplicit, widthWord},
+		{0x1C, TRB, actionTRB816, AddressModeAbsolute, widthMemory},
+		{0x1D, ORA, actionORA816, AddressModeAbsoluteX, widthMemory},
+		{0x1E, ASL, actionASL816, AddressModeAbsoluteX, widthMemory},
+		{0x1F, ORA, actionORA816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0x20, JSR, nil, AddressModeJumpToSubroutine, widthByte},
+		{0x21, AND, actionAND816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0x22, JSL, nil, AddressModeJumpToSubroutineLong, widthByte},
+		{0x23, AND, actionAND816, AddressModeStackRelative, widthMemory},
+		{0x24, BIT, actionBIT816, AddressModeZeroPage, widthMemory},
+		{0x25, AND, actionAND816, AddressModeZeroPage, widthMemory},
+		{0x26, ROL, actionROL816, AddressModeZeroPage, widthMemory},
+		{0x27, AND, actionAND816, AddressModeDirectIndirectLong, widthMemory},
+		{0x28, PLP, actionPLP816, AddressModePullStack, widthByte},
+		{0x29, AND, actionAND816, AddressModeImmediate, widthMemory},
+		{0x2A, ROL, actionROL816, AddressModeAccumulator, widthMemory},
+		{0x2B, PLD, actionPLD816, AddressModePullStack, widthWord},
+		{0x2C, BIT, actionBIT816, AddressModeAbsolute, widthMemory},
+		{0x2D, AND, actionAND816, AddressModeAbsolute, widthMemory},
+		{0x2E, ROL, actionROL816, AddressModeAbsolute, widthMemory},
+		{0x2F, AND, actionAND816, AddressModeAbsoluteLong, widthMemory},
+
+		{0x30, BMI, actionBMI816, AddressModeRelative, widthByte},
+		{0x31, AND, actionAND816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0x32, AND, actionAND816, AddressModeIndirectZeroPage, widthMemory},
+		{0x33, AND, actionAND816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0x34, BIT, actionBIT816, AddressModeZeroPageX, widthMemory},
+		{0x35, AND, actionAND816, AddressModeZeroPageX, widthMemory},
+		{0x36, ROL, actionROL816, AddressModeZeroPageX, widthMemory},
+		{0x37, AND, actionAND816, AddressModeDirectIndirectLongY, widthMemory},
+		{0x38, SEC, actionSEC816, AddressModeImplicit, widthByte},
+		{0x39, AND, actionAND816, AddressModeAbsoluteY, widthMemory},
+		{0x3A, DEC, actionDEC816, AddressModeAccumulator, widthMemory},
+		{0x3B, TSC, actionTSC816, AddressModeImplicit, widthWord},
+		{0x3C, BIT, actionBIT816, AddressModeAbsoluteX, widthMemory},
+		{0x3D, AND, actionAND816, AddressModeAbsoluteX, widthMemory},
+		{0x3E, ROL, actionROL816, AddressModeAbsoluteX, widthMemory},
+		{0x3F, AND, actionAND816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0x40, RTI, nil, AddressModeReturnFromInterrupt, widthByte},
+		{0x41, EOR, actionEOR816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0x42, WDM, actionWDM816, AddressModeImmediate, widthByte},
+		{0x43, EOR, actionEOR816, AddressModeStackRelative, widthMemory},
+		{0x44, MVP, actionMVP816, AddressModeBlockMove, widthIndex},
+		{0x45, EOR, actionEOR816, AddressModeZeroPage, widthMemory},
+		{0x46, LSR, actionLSR816, AddressModeZeroPage, widthMemory},
+		{0x47, EOR, actionEOR816, AddressModeDirectIndirectLong, widthMemory},
+		{0x48, PHA, actionPHA816, AddressModePushStack, widthMemory},
+		{0x49, EOR, actionEOR816, AddressModeImmediate, widthMemory},
+		{0x4A, LSR, actionLSR816, AddressModeAccumulator, widthMemory},
+		{0x4B, PHK, actionPHK816, AddressModePushStack, widthByte},
+		{0x4C, JMP, nil, AddressModeAbsoluteJump, widthByte},
+		{0x4D, EOR, actionEOR816, AddressModeAbsolute, widthMemory},
+		{0x4E, LSR, actionLSR816, AddressModeAbsolute, widthMemory},
+		{0x4F, EOR, actionEOR816, AddressModeAbsoluteLong, widthMemory},
+
+		{0x50, BVC, actionBVC816, AddressModeRelative, widthByte},
+		{0x51, EOR, actionEOR816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0x52, EOR, actionEOR816, AddressModeIndirectZeroPage, widthMemory},
+		{0x53, EOR, actionEOR816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0x54, MVN, actionMVN816, AddressModeBlockMove, widthIndex},
+		{0x55, EOR, actionEOR816, AddressModeZeroPageX, widthMemory},
+		{0x56, LSR, actionLSR816, AddressModeZeroPageX, widthMemory},
+		{0x57, EOR, actionEOR816, AddressModeDirectIndirectLongY, widthMemory},
+		{0x58, CLI, actionCLI816, AddressModeImplicit, widthByte},
+		{0x59, EOR, actionEOR816, AddressModeAbsoluteY, widthMemory},
+		{0x5A, PHY, actionPHY816, AddressModePushStack, widthIndex},
+		{0x5B, TCD, actionTCD816, AddressModeImplicit, widthWord},
+		{0x5C, JML, nil, AddressModeAbsoluteLongJump, widthByte},
+		{0x5D, EOR, actionEOR816, AddressModeAbsoluteX, widthMemory},
+		{0x5E, LSR, actionLSR816, AddressModeAbsoluteX, widthMemory},
+		{0x5F, EOR, actionEOR816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0x60, RTS, nil, AddressModeReturnFromSubroutine, widthByte},
+		{0x61, ADC, actionADC816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0x62, PER, nil, AddressModePushEffectiveRelative, widthWord},
+		{0x63, ADC, actionADC816, AddressModeStackRelative, widthMemory},
+		{0x64, STZ, actionSTZ816, AddressModeZeroPage, widthMemory},
+		{0x65, ADC, actionADC816, AddressModeZeroPage, widthMemory},
+		{0x66, ROR, actionROR816, AddressModeZeroPage, widthMemory},
+		{0x67, ADC, actionADC816, AddressModeDirectIndirectLong, widthMemory},
+		{0x68, PLA, actionPLA816, AddressModePullStack, widthMemory},
+		{0x69, ADC, actionADC816, AddressModeImmediate, widthMemory},
+		{0x6A, ROR, actionROR816, AddressModeAccumulator, widthMemory},
+		{0x6B, RTL, nil, AddressModeReturnFromSubroutineLong, widthByte},
+		{0x6C, JMP, nil, AddressModeIndirect, widthByte},
+		{0x6D, ADC, actionADC816, AddressModeAbsolute, widthMemory},
+		{0x6E, ROR, actionROR816, AddressModeAbsolute, widthMemory},
+		{0x6F, ADC, actionADC816, AddressModeAbsoluteLong, widthMemory},
+
+		{0x70, BVS, actionBVS816, AddressModeRelative, widthByte},
+		{0x71, ADC, actionADC816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0x72, ADC, actionADC816, AddressModeIndirectZeroPage, widthMemory},
+		{0x73, ADC, actionADC816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0x74, STZ, actionSTZ816, AddressModeZeroPageX, widthMemory},
+		{0x75, ADC, actionADC816, AddressModeZeroPageX, widthMemory},
+		{0x76, ROR, actionROR816, AddressModeZeroPageX, widthMemory},
+		{0x77, ADC, actionADC816, AddressModeDirectIndirectLongY, widthMemory},
+		{0x78, SEI, actionSEI816, AddressModeImplicit, widthByte},
+		{0x79, ADC, actionADC816, AddressModeAbsoluteY, widthMemory},
+		{0x7A, PLY, actionPLY816, AddressModePullStack, widthIndex},
+		{0x7B, TDC, actionTDC816, AddressModeImplicit, widthWord},
+		{0x7C, JMP, nil, AddressModeAbsoluteIndexedIndirect, widthByte},
+		{0x7D, ADC, actionADC816, AddressModeAbsoluteX, widthMemory},
+		{0x7E, ROR, actionROR816, AddressModeAbsoluteX, widthMemory},
+		{0x7F, ADC, actionADC816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0x80, BRA, actionBRA816, AddressModeRelative, widthByte},
+		{0x81, STA, actionSTA816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0x82, BRL, nil, AddressModeRelativeLong, widthByte},
+		{0x83, STA, actionSTA816, AddressModeStackRelative, widthMemory},
+		{0x84, STY, actionSTY816, AddressModeZeroPage, widthIndex},
+		{0x85, STA, actionSTA816, AddressModeZeroPage, widthMemory},
+		{0x86, STX, actionSTX816, AddressModeZeroPage, widthIndex},
+		{0x87, STA, actionSTA816, AddressModeDirectIndirectLong, widthMemory},
+		{0x88, DEY, actionDEY816, AddressModeImplicit, widthIndex},
+		{0x89, BIT, actionBIT816, AddressModeImmediate, widthMemory},
+		{0x8A, TXA, actionTXA816, AddressModeImplicit, widthMemory},
+		{0x8B, PHB, actionPHB816, AddressModePushStack, widthByte},
+		{0x8C, STY, actionSTY816, AddressModeAbsolute, widthIndex},
+		{0x8D, STA, actionSTA816, AddressModeAbsolute, widthMemory},
+		{0x8E, STX, actionSTX816, AddressModeAbsolute, widthIndex},
+		{0x8F, STA, actionSTA816, AddressModeAbsoluteLong, widthMemory},
+
+		{0x90, BCC, actionBCC816, AddressModeRelative, widthByte},
+		{0x91, STA, actionSTA816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0x92, STA, actionSTA816, AddressModeIndirectZeroPage, widthMemory},
+		{0x93, STA, actionSTA816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0x94, STY, actionSTY816, AddressModeZeroPageX, widthIndex},
+		{0x95, STA, actionSTA816, AddressModeZeroPageX, widthMemory},
+		{0x96, STX, actionSTX816, AddressModeZeroPageY, widthIndex},
+		{0x97, STA, actionSTA816, AddressModeDirectIndirectLongY, widthMemory},
+		{0x98, TYA, actionTYA816, AddressModeImplicit, widthMemory},
+		{0x99, STA, actionSTA816, AddressModeAbsoluteY, widthMemory},
+		{0x9A, TXS, actionTXS816, AddressModeImplicit, widthByte},
+		{0x9B, TXY, actionTXY816, AddressModeImplicit, widthIndex},
+		{0x9C, STZ, actionSTZ816, AddressModeAbsolute, widthMemory},
+		{0x9D, STA, actionSTA816, AddressModeAbsoluteX, widthMemory},
+		{0x9E, STZ, actionSTZ816, AddressModeAbsoluteX, widthMemory},
+		{0x9F, STA, actionSTA816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0xA0, LDY, actionLDY816, AddressModeImmediate, widthIndex},
+		{0xA1, LDA, actionLDA816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0xA2, LDX, actionLDX816, AddressModeImmediate, widthIndex},
+		{0xA3, LDA, actionLDA816, AddressModeStackRelative, widthMemory},
+		{0xA4, LDY, actionLDY816, AddressModeZeroPage, widthIndex},
+		{0xA5, LDA, actionLDA816, AddressModeZeroPage, widthMemory},
+		{0xA6, LDX, actionLDX816, AddressModeZeroPage, widthIndex},
+		{0xA7, LDA, actionLDA816, AddressModeDirectIndirectLong, widthMemory},
+		{0xA8, TAY, actionTAY816, AddressModeImplicit, widthIndex},
+		{0xA9, LDA, actionLDA816, AddressModeImmediate, widthMemory},
+		{0xAA, TAX, actionTAX816, AddressModeImplicit, widthIndex},
+		{0xAB, PLB, actionPLB816, AddressModePullStack, widthByte},
+		{0xAC, LDY, actionLDY816, AddressModeAbsolute, widthIndex},
+		{0xAD, LDA, actionLDA816, AddressModeAbsolute, widthMemory},
+		{0xAE, LDX, actionLDX816, AddressModeAbsolute, widthIndex},
+		{0xAF, LDA, actionLDA816, AddressModeAbsoluteLong, widthMemory},
+
+		{0xB0, BCS, actionBCS816, AddressModeRelative, widthByte},
+		{0xB1, LDA, actionLDA816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0xB2, LDA, actionLDA816, AddressModeIndirectZeroPage, widthMemory},
+		{0xB3, LDA, actionLDA816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0xB4, LDY, actionLDY816, AddressModeZeroPageX, widthIndex},
+		{0xB5, LDA, actionLDA816, AddressModeZeroPageX, widthMemory},
+		{0xB6, LDX, actionLDX816, AddressModeZeroPageY, widthIndex},
+		{0xB7, LDA, actionLDA816, AddressModeDirectIndirectLongY, widthMemory},
+		{0xB8, CLV, actionCLV816, AddressModeImplicit, widthByte},
+		{0xB9, LDA, actionLDA816, AddressModeAbsoluteY, widthMemory},
+		{0xBA, TSX, actionTSX816, AddressModeImplicit, widthIndex},
+		{0xBB, TYX, actionTYX816, AddressModeImplicit, widthIndex},
+		{0xBC, LDY, actionLDY816, AddressModeAbsoluteX, widthIndex},
+		{0xBD, LDA, actionLDA816, AddressModeAbsoluteX, widthMemory},
+		{0xBE, LDX, actionLDX816, AddressModeAbsoluteY, widthIndex},
+		{0xBF, LDA, actionLDA816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0xC0, CPY, actionCPY816, AddressModeImmediate, widthIndex},
+		{0xC1, CMP, actionCMP816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0xC2, REP, actionREP816, AddressModeImmediate, widthByte},
+		{0xC3, CMP, actionCMP816, AddressModeStackRelative, widthMemory},
+		{0xC4, CPY, actionCPY816, AddressModeZeroPage, widthIndex},
+		{0xC5, CMP, actionCMP816, AddressModeZeroPage, widthMemory},
+		{0xC6, DEC, actionDEC816, AddressModeZeroPage, widthMemory},
+		{0xC7, CMP, actionCMP816, AddressModeDirectIndirectLong, widthMemory},
+		{0xC8, INY, actionINY816, AddressModeImplicit, widthIndex},
+		{0xC9, CMP, actionCMP816, AddressModeImmediate, widthMemory},
+		{0xCA, DEX, actionDEX816, AddressModeImplicit, widthIndex},
+		{0xCB, WAI, actionWAI816, AddressModeImplicit, widthByte},
+		{0xCC, CPY, actionCPY816, AddressModeAbsolute, widthIndex},
+		{0xCD, CMP, actionCMP816, AddressModeAbsolute, widthMemory},
+		{0xCE, DEC, actionDEC816, AddressModeAbsolute, widthMemory},
+		{0xCF, CMP, actionCMP816, AddressModeAbsoluteLong, widthMemory},
+
+		{0xD0, BNE, actionBNE816, AddressModeRelative, widthByte},
+		{0xD1, CMP, actionCMP816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0xD2, CMP, actionCMP816, AddressModeIndirectZeroPage, widthMemory},
+		{0xD3, CMP, actionCMP816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0xD4, PEI, nil, AddressModePushEffectiveIndirect, widthWord},
+		{0xD5, CMP, actionCMP816, AddressModeZeroPageX, widthMemory},
+		{0xD6, DEC, actionDEC816, AddressModeZeroPageX, widthMemory},
+		{0xD7, CMP, actionCMP816, AddressModeDirectIndirectLongY, widthMemory},
+		{0xD8, CLD, actionCLD816, AddressModeImplicit, widthByte},
+		{0xD9, CMP, actionCMP816, AddressModeAbsoluteY, widthMemory},
+		{0xDA, PHX, actionPHX816, AddressModePushStack, widthIndex},
+		{0xDB, STP, actionSTP816, AddressModeImplicit, widthByte},
+		{0xDC, JML, nil, AddressModeAbsoluteIndirectLong, widthByte},
+		{0xDD, CMP, actionCMP816, AddressModeAbsoluteX, widthMemory},
+		{0xDE, DEC, actionDEC816, AddressModeAbsoluteX, widthMemory},
+		{0xDF, CMP, actionCMP816, AddressModeAbsoluteLongX, widthMemory},
+
+		{0xE0, CPX, actionCPX816, AddressModeImmediate, widthIndex},
+		{0xE1, SBC, actionSBC816, AddressModeZeroPageIndexedIndirectX, widthMemory},
+		{0xE2, SEP, actionSEP816, AddressModeImmediate, widthByte},
+		{0xE3, SBC, actionSBC816, AddressModeStackRelative, widthMemory},
+		{0xE4, CPX, actionCPX816, AddressModeZeroPage, widthIndex},
+		{0xE5, SBC, actionSBC816, AddressModeZeroPage, widthMemory},
+		{0xE6, INC, actionINC816, AddressModeZeroPage, widthMemory},
+		{0xE7, SBC, actionSBC816, AddressModeDirectIndirectLong, widthMemory},
+		{0xE8, INX, actionINX816, AddressModeImplicit, widthIndex},
+		{0xE9, SBC, actionSBC816, AddressModeImmediate, widthMemory},
+		{0xEA, NOP, actionNOP816, AddressModeImplicit, widthByte},
+		{0xEB, XBA, actionXBA816, AddressModeImplicit, widthByte},
+		{0xEC, CPX, actionCPX816, AddressModeAbsolute, widthIndex},
+		{0xED, SBC, actionSBC816, AddressModeAbsolute, widthMemory},
+		{0xEE, INC, actionINC816, AddressModeAbsolute, widthMemory},
+		{0xEF, SBC, actionSBC816, AddressModeAbsoluteLong, widthMemory},
+
+		{0xF0, BEQ, actionBEQ816, AddressModeRelative, widthByte},
+		{0xF1, SBC, actionSBC816, AddressModeZeroPageIndirectIndexedY, widthMemory},
+		{0xF2, SBC, actionSBC816, AddressModeIndirectZeroPage, widthMemory},
+		{0xF3, SBC, actionSBC816, AddressModeStackRelativeIndirectY, widthMemory},
+		{0xF4, PEA, nil, AddressModePushEffectiveAbsolute, widthWord},
+		{0xF5, SBC, actionSBC816, AddressModeZeroPageX, widthMemory},
+		{0xF6, INC, actionINC816, AddressModeZeroPageX, widthMemory},
+		{0xF7, SBC, actionSBC816, AddressModeDirectIndirectLongY, widthMemory},
+		{0xF8, SED, actionSED816, AddressModeImplicit, widthByte},
+		{0xF9, SBC, actionSBC816, AddressModeAbsoluteY, widthMemory},
+		{0xFA, PLX, actionPLX816, AddressModePullStack, widthIndex},
+		{0xFB, XCE, actionXCE816, AddressModeImplicit, widthByte},
+		{0xFC, JSR, nil, AddressModeJumpToSubroutineIndexedIndirect, widthByte},
+		{0xFD, SBC, actionSBC816, AddressModeAbsoluteX, widthMemory},
+		{0xFE, INC, actionINC816, AddressModeAbsoluteX, widthMemory},
+		{0xFF, SBC, actionSBC816, AddressModeAbsoluteLongX, widthMemory},
+	}
+
+	instructionSet := instructionSet65C816S{
+		opCodeIndex: [0x100]*instructionData65C816S{},
+	}
+
+	for i := range instructionData {
+		instructionSet.opCodeIndex[instructionData[i].opcode] = &instructionData[i]
+	}
+
+	return &instructionSet
+}
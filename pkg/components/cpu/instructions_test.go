@@ -41,13 +41,12 @@ func TestNewInstructionSet(t *testing.T) {
 func TestInvalidOpCode(t *testing.T) {
 	instructionSet := NewInstructionSet()
 
-	// Test an invalid opcode (0x02 is not used in the 65C02)
+	// Undefined opcodes of the 65C02 are NOPs with their own address mode (0x02 is a 2 bytes NOP)
 	invalidOpCode := components.OpCode(0x02)
 	instruction := instructionSet.GetByOpCode(invalidOpCode)
 
-	// Should return NOP (0xEA) for invalid opcodes
-	if instruction.OpCode() != components.OpCode(0xEA) {
-		t.Errorf("Expected invalid opcode to return NOP (0xEA), got %02X",
+	if instruction.OpCode() != invalidOpCode {
+		t.Errorf("Expected undefined opcode to return its own instruction data, got %02X",
 			instruction.OpCode())
 	}
 
@@ -55,6 +54,11 @@ func TestInvalidOpCode(t *testing.T) {
 		t.Errorf("Expected invalid opcode to return NOP mnemonic, got %v",
 			instruction.Mnemonic())
 	}
+
+	if instruction.AddressMode() != AddressModeImmediate {
+		t.Errorf("Expected undefined opcode 0x02 to use immediate address mode, got %v",
+			instruction.AddressMode())
+	}
 }
 
 func TestInstructionData(t *testing.T) {
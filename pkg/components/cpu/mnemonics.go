@@ -107,4 +107,54 @@ const (
 	BBS5 components.Mnemonic = "BBS5" // Branch if Bit 5 Set
 	BBS6 components.Mnemonic = "BBS6" // Branch if Bit 6 Set
 	BBS7 components.Mnemonic = "BBS7" // Branch if Bit 7 Set
+
+	// Illegal opcodes of the NMOS 6502. Names as in "No More Secrets" NMOS 6510 Unintended Opcodes
+	ALR components.Mnemonic = "ALR" // AND then Logical Shift Right
+	ANC components.Mnemonic = "ANC" // AND then copy N to Carry
+	ANE components.Mnemonic = "ANE" // (A OR Magic) AND X AND immediate (unstable)
+	ARR components.Mnemonic = "ARR" // AND then Rotate Right
+	DCP components.Mnemonic = "DCP" // Decrement then Compare
+	ISC components.Mnemonic = "ISC" // Increment then Subtract with Carry
+	JAM components.Mnemonic = "JAM" // Halts the processor
+	LAS components.Mnemonic = "LAS" // AND with Stack Pointer into A, X and Stack Pointer
+	LAX components.Mnemonic = "LAX" // Load Accumulator and X Register
+	LXA components.Mnemonic = "LXA" // (A OR Magic) AND immediate into A and X (unstable)
+	RLA components.Mnemonic = "RLA" // Rotate Left then AND
+	RRA components.Mnemonic = "RRA" // Rotate Right then Add with Carry
+	SAX components.Mnemonic = "SAX" // Store A AND X
+	SBX components.Mnemonic = "SBX" // (A AND X) minus immediate into X
+	SHA components.Mnemonic = "SHA" // Store A AND X AND (high byte of address + 1)
+	SHX components.Mnemonic = "SHX" // Store X AND (high byte of address + 1)
+	SHY components.Mnemonic = "SHY" // Store Y AND (high byte of address + 1)
+	SLO components.Mnemonic = "SLO" // Arithmetic Shift Left then OR
+	SRE components.Mnemonic = "SRE" // Logical Shift Right then Exclusive OR
+	TAS components.Mnemonic = "TAS" // Transfer A AND X to Stack Pointer then SHA
+
+	// Instructions added by the 65C816S
+	BRL components.Mnemonic = "BRL" // Branch Always Long
+	COP components.Mnemonic = "COP" // Co-Processor Enable
+	JML components.Mnemonic = "JML" // Jump Long
+	JSL components.Mnemonic = "JSL" // Jump to Subroutine Long
+	MVN components.Mnemonic = "MVN" // Block Move Next
+	MVP components.Mnemonic = "MVP" // Block Move Previous
+	PEA components.Mnemonic = "PEA" // Push Effective Absolute Address
+	PEI components.Mnemonic = "PEI" // Push Effective Indirect Address
+	PER components.Mnemonic = "PER" // Push Effective Program Counter Relative Address
+	PHB components.Mnemonic = "PHB" // Push Data Bank Register
+	PHD components.Mnemonic = "PHD" // Push Direct Register
+	PHK components.Mnemonic = "PHK" // Push Program Bank Register
+	PLB components.Mnemonic = "PLB" // Pull Data Bank Register
+	PLD components.Mnemonic = "PLD" // Pull Direct Register
+	REP components.Mnemonic = "REP" // Reset Status Bits
+	RTL components.Mnemonic = "RTL" // Return from Subroutine Long
+	SEP components.Mnemonic = "SEP" // Set Status Bits
+	TCD components.Mnemonic = "TCD" // Transfer C Accumulator to Direct Register
+	TCS components.Mnemonic = "TCS" // Transfer C Accumulator to Stack Pointer
+	TDC components.Mnemonic = "TDC" // Transfer Direct Register to C Accumulator
+	TSC components.Mnemonic = "TSC" // Transfer Stack Pointer to C Accumulator
+	TXY components.Mnemonic = "TXY" // Transfer X to Y
+	TYX components.Mnemonic = "TYX" // Transfer Y to X
+	WDM components.Mnemonic = "WDM" // Reserved for future expansion
+	XBA components.Mnemonic = "XBA" // Exchange B and A Accumulators
+	XCE components.Mnemonic = "XCE" // Exchange Carry and Emulation Bits
 )
@@ -0,0 +1,210 @@
+package cpu
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+)
+
+// Identifies the instruction, address mode and micro-cycle of the current or next cycle
+// of the processor. The cycle actions are not stored as they can be recovered from the
+// address mode and the cycle index.
+type cpuCycleState struct {
+	HasInstruction bool  // False before the first opcode is read
+	OpCode         uint8 // Opcode of the instruction
+	HasAddressMode bool  // False before the first opcode is read
+	AddressMode    uint8 // Address mode of the instruction or interrupt being executed
+	CycleIndex     uint8 // Index of the cycle, 0 is the opcode read or the first interrupt cycle
+	Interrupt      bool  // True if cycle 0 is the first cycle of an interrupt sequence
+}
+
+// Values of the processor registers and internal status. This struct is written and read
+// with encoding/binary, so it must only have fixed size fields.
+type cpuState struct {
+	Variant uint8 // Processor that saved the state, states can't be loaded in other variants
+
+	AccumulatorRegister     uint8
+	XRegister               uint8
+	YRegister               uint8
+	StackPointer            uint8
+	ProgramCounter          uint16
+	ProcessorStatusRegister uint8
+
+	Current cpuCycleState
+	Next    cpuCycleState
+
+	InstructionRegisterCarry bool
+	BranchTaken              bool
+	CurrentOpCode            uint8
+	InstructionRegister      uint16
+	DataRegister             uint8
+
+	IrqRequested      bool
+	PreviousNMIStatus bool
+	NmiRequested      bool
+	CyclesWithReset   uint8
+
+	ProcessorPaused  bool
+	ProcessorStopped bool
+}
+
+// SaveState writes the registers of the processor and the state of the instruction in
+// progress, including the micro-cycle being executed. The lines and buses are not part of
+// the processor state and must be saved by the computer.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (cpu *cpu65C02S) SaveState(writer io.Writer) error {
+	state := cpuState{
+		Variant: uint8(cpu.variant),
+
+		AccumulatorRegister:     cpu.accumulatorRegister,
+		XRegister:               cpu.xRegister,
+		YRegister:               cpu.yRegister,
+		StackPointer:            cpu.stackPointer,
+		ProgramCounter:          cpu.programCounter,
+		ProcessorStatusRegister: uint8(cpu.processorStatusRegister),
+
+		Current: saveCycleState(cpu.currentInstruction, cpu.currentAddressMode, cpu.currentCycleIndex, cpu.currentCycle),
+		Next:    saveCycleState(cpu.nextInstruction, cpu.nextAddressMode, cpu.nextCycleIndex, cpu.nextCycle),
+
+		InstructionRegisterCarry: cpu.instructionRegisterCarry,
+		BranchTaken:              cpu.branchTaken,
+		CurrentOpCode:            uint8(cpu.currentOpCode),
+		InstructionRegister:      cpu.instructionRegister,
+		DataRegister:             cpu.dataRegister,
+
+		IrqRequested:      cpu.irqRequested,
+		PreviousNMIStatus: cpu.previousNMIStatus,
+		NmiRequested:      cpu.nmiRequested,
+		CyclesWithReset:   cpu.cyclesWithReset,
+
+		ProcessorPaused:  cpu.processorPaused,
+		ProcessorStopped: cpu.processorStopped,
+	}
+
+	return binary.Write(writer, binary.LittleEndian, &state)
+}
+
+// LoadState restores the registers of the processor and the instruction in progress
+// from a state previously written by SaveState.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read or is not valid
+func (cpu *cpu65C02S) LoadState(reader io.Reader) error {
+	var state cpuState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	if cpuVariant(state.Variant) != cpu.variant {
+		return fmt.Errorf("state saved by a different processor variant (%d), expected %d", state.Variant, cpu.variant)
+	}
+
+	currentInstruction, currentAddressMode, currentCycle, err := state.Current.restore(cpu)
+	if err != nil {
+		return err
+	}
+
+	nextInstruction, nextAddressMode, nextCycle, err := state.Next.restore(cpu)
+	if err != nil {
+		return err
+	}
+
+	cpu.accumulatorRegister = state.AccumulatorRegister
+	cpu.xRegister = state.XRegister
+	cpu.yRegister = state.YRegister
+	cpu.stackPointer = state.StackPointer
+	cpu.programCounter = state.ProgramCounter
+	cpu.processorStatusRegister = statusRegister(state.ProcessorStatusRegister)
+
+	cpu.currentInstruction = currentInstruction
+	cpu.currentAddressMode = currentAddressMode
+	cpu.currentCycleIndex = int(state.Current.CycleIndex)
+	cpu.currentCycle = currentCycle
+
+	cpu.nextInstruction = nextInstruction
+	cpu.nextAddressMode = nextAddressMode
+	cpu.nextCycleIndex = int(state.Next.CycleIndex)
+	cpu.nextCycle = nextCycle
+
+	cpu.instructionRegisterCarry = state.InstructionRegisterCarry
+	cpu.branchTaken = state.BranchTaken
+	cpu.currentOpCode = components.OpCode(state.CurrentOpCode)
+	cpu.instructionRegister = state.InstructionRegister
+	cpu.dataRegister = state.DataRegister
+
+	cpu.irqRequested = state.IrqRequested
+	cpu.previousNMIStatus = state.PreviousNMIStatus
+	cpu.nmiRequested = state.NmiRequested
+	cpu.cyclesWithReset = state.CyclesWithReset
+
+	cpu.processorPaused = state.ProcessorPaused
+	cpu.processorStopped = state.ProcessorStopped
+
+	return nil
+}
+
+// saveCycleState returns the values that identify the specified cycle. The first cycle
+// of an instruction is the opcode read, which is the only one that enables the sync line,
+// any other cycle 0 is the start of an interrupt sequence.
+func saveCycleState(instruction *CpuInstructionData, addressMode *AddressModeData, index int, actions cycleActions) cpuCycleState {
+	state := cpuCycleState{
+		CycleIndex: uint8(index),
+		Interrupt:  index == 0 && !actions.signaling.sync,
+	}
+
+	if instruction != nil {
+		state.HasInstruction = true
+		state.OpCode = uint8(instruction.OpCode())
+	}
+
+	if addressMode != nil {
+		state.HasAddressMode = true
+		state.AddressMode = uint8(addressMode.Name())
+	}
+
+	return state
+}
+
+// restore returns the instruction, address mode and cycle actions identified by the state in the
+// instruction and address mode sets of the processor.
+func (state *cpuCycleState) restore(cpu *cpu65C02S) (*CpuInstructionData, *AddressModeData, cycleActions, error) {
+	var instruction *CpuInstructionData
+	var addressMode *AddressModeData
+
+	if state.HasInstruction {
+		instruction = cpu.instructionSet.GetByOpCode(components.OpCode(state.OpCode))
+	}
+
+	if state.HasAddressMode {
+		if int(state.AddressMode) >= len(cpu.addressModeSet.nameIndex) {
+			return nil, nil, cycleActions{}, fmt.Errorf("invalid address mode %d", state.AddressMode)
+		}
+
+		addressMode = cpu.addressModeSet.GetByName(components.AddressMode(state.AddressMode))
+		if addressMode == nil {
+			return nil, nil, cycleActions{}, fmt.Errorf("invalid address mode %d", state.AddressMode)
+		}
+	}
+
+	switch {
+	case state.CycleIndex == 0 && state.Interrupt:
+		return instruction, addressMode, interruptCycle, nil
+	case state.CycleIndex == 0:
+		return instruction, addressMode, readOpCode, nil
+	case addressMode == nil || int(state.CycleIndex) >= addressMode.Cycles():
+		return nil, nil, cycleActions{}, fmt.Errorf("invalid cycle %d for the address mode", state.CycleIndex)
+	default:
+		return instruction, addressMode, addressMode.cycle(int(state.CycleIndex) - 1), nil
+	}
+}
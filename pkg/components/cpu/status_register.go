@@ -18,6 +18,13 @@ const (
 	NegativeFlagBit     components.StatusBit = 7 // Negative flag (N)
 )
 
+// In native mode the 65C816S uses bits 4 and 5 to select the width of the index registers and the
+// accumulator. In emulation mode both are always set.
+const (
+	IndexRegisterSelectFlagBit components.StatusBit = 4 // Index register select flag (X)
+	MemorySelectFlagBit        components.StatusBit = 5 // Memory and accumulator select flag (M)
+)
+
 // NewStatusRegister creates a new status register with the specified initial value.
 // The BRK (B) and unused (U) flags are always set to 1, regardless of the input value.
 func NewStatusRegister(value uint8) components.StatusRegister {
@@ -35,6 +42,11 @@ func (status statusRegister) Flag(bit components.StatusBit) bool {
 	return (uint8(status) & mask) > 0
 }
 
+// Returns the byte with all the flags of the status register, bits 4 and 5 as they are stored
+func (status statusRegister) GetValue() uint8 {
+	return uint8(status)
+}
+
 // Allows to set or unset an specific bit of the status register
 func (status *statusRegister) SetFlag(bit components.StatusBit, set bool) {
 	mask := uint8(1 << bit)
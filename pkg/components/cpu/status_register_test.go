@@ -19,7 +19,7 @@ func TestGetAndSetFlag(t *testing.T) {
 	assert.Equal(t, true, status.Flag(NegativeFlagBit))
 
 	status.SetFlag(ZeroFlagBit, false)
-	assert.Equal(t, uint8(0xB0), uint8(status))
+	assert.Equal(t, uint8(0xB0), status.GetValue())
 	assert.Equal(t, false, status.Flag(ZeroFlagBit))
 	assert.Equal(t, true, status.Flag(NegativeFlagBit))
 }
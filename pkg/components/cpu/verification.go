@@ -0,0 +1,240 @@
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/memory"
+)
+
+// Address where the verified opcode is placed, followed by the operand $1234
+const verificationAddress uint16 = 0x0200
+
+// Maximum number of cycles executed for each opcode before giving up on finding the next opcode fetch
+const verificationMaxCycles int = 16
+
+// busRead is the read expected on a cycle. Internal operations of the processor read an address
+// that is not documented so any address is accepted.
+type busRead struct {
+	address  uint16
+	internal bool
+}
+
+// Read of the specified address
+func readAt(address uint16) busRead {
+	return busRead{address: address}
+}
+
+// Read done during an internal operation of the processor
+var internalRead busRead = busRead{internal: true}
+
+// undefinedOpCodeTiming is the behaviour of the real processor for a group of undefined opcodes
+// when executed at $0200 with operand $1234 and the X register set to 1.
+type undefinedOpCodeTiming struct {
+	opCodes []uint8
+	size    uint16
+	reads   []busRead
+}
+
+// Undefined opcodes of the W65C02S. See table 3-1 and 5-7 of the W65C02S datasheet and the
+// nop_c_aba opcode of MAME's 65C02 for the reads of $5C.
+var w65C02SUndefinedOpCodes []undefinedOpCodeTiming = []undefinedOpCodeTiming{
+	{
+		opCodes: []uint8{
+			0x03, 0x13, 0x23, 0x33, 0x43, 0x53, 0x63, 0x73, 0x83, 0x93, 0xA3, 0xB3, 0xC3, 0xD3, 0xE3, 0xF3,
+			0x0B, 0x1B, 0x2B, 0x3B, 0x4B, 0x5B, 0x6B, 0x7B, 0x8B, 0x9B, 0xAB, 0xBB, 0xEB, 0xFB,
+		},
+		size:  1,
+		reads: []busRead{readAt(0x0200)},
+	},
+	{
+		opCodes: []uint8{0x02, 0x22, 0x42, 0x62, 0x82, 0xC2, 0xE2},
+		size:    2,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201)},
+	},
+	{
+		opCodes: []uint8{0x44},
+		size:    2,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0034)},
+	},
+	{
+		opCodes: []uint8{0x54, 0xD4, 0xF4},
+		size:    2,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), internalRead, readAt(0x0035)},
+	},
+	{
+		opCodes: []uint8{0xDC, 0xFC},
+		size:    3,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0202), readAt(0x1234)},
+	},
+	{
+		opCodes: []uint8{0x5C},
+		size:    3,
+		reads: []busRead{
+			readAt(0x0200), readAt(0x0201), readAt(0x0202), readAt(0xFF34),
+			readAt(0xFFFF), readAt(0xFFFF), readAt(0xFFFF), readAt(0xFFFF),
+		},
+	},
+}
+
+// NOPs among the illegal opcodes of the NMOS 6502. See "No More Secrets" NMOS 6510 Unintended Opcodes.
+var nmos6502UndefinedOpCodes []undefinedOpCodeTiming = []undefinedOpCodeTiming{
+	{
+		opCodes: []uint8{0x1A, 0x3A, 0x5A, 0x7A, 0xDA, 0xFA},
+		size:    1,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201)},
+	},
+	{
+		opCodes: []uint8{0x80, 0x82, 0x89, 0xC2, 0xE2},
+		size:    2,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201)},
+	},
+	{
+		opCodes: []uint8{0x04, 0x44, 0x64},
+		size:    2,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0034)},
+	},
+	{
+		opCodes: []uint8{0x14, 0x34, 0x54, 0x74, 0xD4, 0xF4},
+		size:    2,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0034), readAt(0x0035)},
+	},
+	{
+		opCodes: []uint8{0x0C},
+		size:    3,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0202), readAt(0x1234)},
+	},
+	{
+		opCodes: []uint8{0x1C, 0x3C, 0x5C, 0x7C, 0xDC, 0xFC},
+		size:    3,
+		reads:   []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0202), readAt(0x1235)},
+	},
+}
+
+// VerificationReport contains the results of verifying the undefined opcodes of a processor.
+type VerificationReport struct {
+	Verified   int      // Number of opcodes executed
+	Mismatches []string // Description of each difference found with the real processor
+}
+
+// Passed returns true if all the opcodes behaved as in the real processor.
+func (report *VerificationReport) Passed() bool {
+	return len(report.Mismatches) == 0
+}
+
+// VerifyUndefinedOpCodes executes each undefined opcode of the processor and compares its length,
+// number of cycles and the address read on each cycle with the ones of the real processor.
+//
+// Parameters:
+//   - name: Name of the processor, Cpu65C02S or Cpu6502
+//
+// Returns:
+//   - The report with the differences found
+//   - An error if the name is not a known processor
+func VerifyUndefinedOpCodes(name string) (*VerificationReport, error) {
+	processor, err := NewCpu(name)
+	if err != nil {
+		return nil, err
+	}
+
+	timings := w65C02SUndefinedOpCodes
+	if processor.(*cpu65C02S).variant == variantNMOS6502 {
+		timings = nmos6502UndefinedOpCodes
+	}
+
+	report := &VerificationReport{}
+
+	for _, timing := range timings {
+		for _, opCode := range timing.opCodes {
+			cpu, _ := NewCpu(name)
+			report.Mismatches = append(report.Mismatches, verifyOpCode(cpu.(*cpu65C02S), opCode, &timing)...)
+			report.Verified++
+		}
+	}
+
+	return report, nil
+}
+
+// verifyOpCode executes the opcode on the processor connected to 64K of RAM and returns the
+// differences with the expected timing.
+func verifyOpCode(cpu *cpu65C02S, opCode uint8, timing *undefinedOpCodeTiming) []string {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+	writeEnableLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(buses.NewStandaloneLine(false))
+	ram.OutputEnable().Connect(buses.NewStandaloneLine(false))
+
+	cpu.AddressBus().Connect(addressBus)
+	cpu.DataBus().Connect(dataBus)
+	cpu.ReadWrite().Connect(writeEnableLine)
+	cpu.BusEnable().Connect(buses.NewStandaloneLine(true))
+	cpu.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	cpu.Sync().Connect(buses.NewStandaloneLine(false))
+	cpu.Ready().Connect(buses.NewStandaloneLine(true))
+	cpu.VectorPull().Connect(buses.NewStandaloneLine(false))
+	cpu.SetOverflow().Connect(buses.NewStandaloneLine(true))
+	cpu.Reset().Connect(buses.NewStandaloneLine(true))
+	cpu.InterruptRequest().Connect(buses.NewStandaloneLine(true))
+	cpu.NonMaskableInterrupt().Connect(buses.NewStandaloneLine(true))
+
+	ram.Poke(verificationAddress, opCode)
+	ram.Poke(verificationAddress+1, 0x34)
+	ram.Poke(verificationAddress+2, 0x12)
+
+	cpu.programCounter = verificationAddress
+	cpu.xRegister = 0x01
+
+	var mismatches []string
+	var reads []uint16
+	next := -1
+
+	context := common.NewStepContext()
+	for range verificationMaxCycles {
+		cpu.Tick(&context)
+		ram.Tick(&context)
+
+		// The address is taken when memory is accessed, post cycle actions can change it
+		address := cpu.addressBus.Read()
+
+		if cpu.IsReadingOpcode() && len(reads) > 0 {
+			next = int(address)
+			break
+		}
+
+		if cpu.readWrite.Enabled() {
+			mismatches = append(mismatches, fmt.Sprintf("$%02X: unexpected write to $%04X on cycle %d", opCode, address, len(reads)+1))
+		}
+
+		reads = append(reads, address)
+
+		cpu.PostTick(&context)
+		context.NextCycle()
+	}
+
+	if next < 0 {
+		return append(mismatches, fmt.Sprintf("$%02X: next opcode not fetched after %d cycles", opCode, verificationMaxCycles))
+	}
+
+	if len(reads) != len(timing.reads) {
+		mismatches = append(mismatches, fmt.Sprintf("$%02X: takes %d cycles, expected %d", opCode, len(reads), len(timing.reads)))
+	}
+
+	for i := range min(len(reads), len(timing.reads)) {
+		expected := timing.reads[i]
+		if !expected.internal && reads[i] != expected.address {
+			mismatches = append(mismatches, fmt.Sprintf("$%02X: reads $%04X on cycle %d, expected $%04X", opCode, reads[i], i+1, expected.address))
+		}
+	}
+
+	if expected := int(verificationAddress + timing.size); next != expected {
+		mismatches = append(mismatches, fmt.Sprintf("$%02X: length is %d bytes, expected %d", opCode, next-int(verificationAddress), timing.size))
+	}
+
+	return mismatches
+}
@@ -0,0 +1,53 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerification_UndefinedOpCodesMatchRealProcessor(t *testing.T) {
+	for _, name := range []string{Cpu65C02S, Cpu6502} {
+		report, err := VerifyUndefinedOpCodes(name)
+
+		assert.NoError(t, err)
+		assert.True(t, report.Passed(), "%s: %v", name, report.Mismatches)
+	}
+
+	report, _ := VerifyUndefinedOpCodes(Cpu65C02S)
+	assert.Equal(t, 44, report.Verified)
+}
+
+func TestVerification_UnknownProcessor(t *testing.T) {
+	_, err := VerifyUndefinedOpCodes("z80")
+	assert.Error(t, err)
+}
+
+func TestVerification_ReportsDifferences(t *testing.T) {
+	// Expects $5C to be a 2 bytes NOP of 3 cycles
+	timing := &undefinedOpCodeTiming{
+		size:  2,
+		reads: []busRead{readAt(0x0200), readAt(0x0201), readAt(0x0034)},
+	}
+
+	mismatches := verifyOpCode(newCpu65C02S(), 0x5C, timing)
+
+	assert.Equal(t, []string{
+		"$5C: takes 8 cycles, expected 3",
+		"$5C: reads $0202 on cycle 3, expected $0034",
+		"$5C: length is 3 bytes, expected 2",
+	}, mismatches)
+}
+
+func TestVerification_SingleCycleNOP(t *testing.T) {
+	cpu, ram := newComputer()
+
+	ram.Poke(0xC000, 0x03) // NOP
+	ram.Poke(0xC001, 0xFB) // NOP
+	ram.Poke(0xC002, 0xE8) // INX
+
+	runInstructionTest(cpu, ram, 4)
+
+	evaluateProgramCounter(t, cpu, 0xC003)
+	evaluateRegisterValue(t, cpu, "X Register", cpu.xRegister, 0x01)
+}
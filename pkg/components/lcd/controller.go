@@ -260,7 +260,7 @@ func (ctrl *lcdHD44780U) setBusy(duration int64, busyStart int64) {
 // Checks if the busy period completed and if so, lowers the "busy" flag
 func (ctrl *lcdHD44780U) checkBusy(context *common.StepContext) {
 	if ctrl.isBusy {
-		elapsed := (context.T - ctrl.blinkingStart) / int64(time.Microsecond)
+		elapsed := (context.T - ctrl.busyStart) / int64(time.Microsecond)
 
 		if elapsed >= ctrl.busyDuration {
 			ctrl.isBusy = false
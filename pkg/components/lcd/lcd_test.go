@@ -793,6 +793,22 @@ func TestBusyFlag(t *testing.T) {
 	assert.GreaterOrEqual(t, elapsed, lcd.timingConfig.clearDisplayMicro)
 }
 
+func TestBusyFlagInVirtualTime(t *testing.T) {
+	context := common.NewVirtualStepContext(1_000_000)
+
+	lcd, circuit := newTestCircuitCorrectTiming()
+
+	sendInstruction(lcd, circuit, 0x01, &context)
+	context.NextCycle()
+
+	for readInstruction(lcd, circuit, &context)&0x80 == 0x80 {
+		context.NextCycle()
+	}
+
+	// At 1 MHz each cycle takes 1 us, the busy period lasts the same cycles on every run
+	assert.Equal(t, uint64(lcd.timingConfig.clearDisplayMicro), context.Cycle)
+}
+
 func TestCursorBlinking(t *testing.T) {
 	context := common.NewStepContext()
 
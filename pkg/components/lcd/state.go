@@ -0,0 +1,139 @@
+package lcd
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Values of the LCD controller registers, memories, address counter and input buffer.
+// This struct is written and read with encoding/binary, so it must only have fixed
+// size fields.
+type lcdState struct {
+	PreviousEnable bool
+
+	InstructionRegister uint8
+	DataRegister        uint8
+
+	DisplayOn      bool
+	DisplayCursor  bool
+	CharacterBlink bool
+	Is5x10Font     bool
+
+	DDRAM [DDRAM_SIZE]uint8
+	CGRAM [CGRAM_SIZE]uint8
+
+	IsBusy       bool
+	BusyStart    int64
+	BusyDuration int64
+
+	BlinkingVisible bool
+	BlinkingStart   int64
+
+	ToCGRAM        bool
+	MustMoveRight  bool
+	Is2LineDisplay bool
+	DisplayShift   bool
+	AddressCounter uint8
+	Line1Shift     uint8
+	Line2Shift     uint8
+
+	Is8BitMode  bool
+	BufferValue uint8
+	BufferIndex uint8
+}
+
+// SaveState writes the registers, DDRAM, CGRAM, address counter and the status of the
+// 4 bit mode buffer of the LCD controller. The timing configuration is not saved.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (ctrl *lcdHD44780U) SaveState(writer io.Writer) error {
+	state := lcdState{
+		PreviousEnable: ctrl.previousEnable,
+
+		InstructionRegister: ctrl.instructionRegister,
+		DataRegister:        ctrl.dataRegister,
+
+		DisplayOn:      ctrl.displayOn,
+		DisplayCursor:  ctrl.displayCursor,
+		CharacterBlink: ctrl.characterBlink,
+		Is5x10Font:     ctrl.is5x10Font,
+
+		DDRAM: ctrl.ddram,
+		CGRAM: ctrl.cgram,
+
+		IsBusy:       ctrl.isBusy,
+		BusyStart:    ctrl.busyStart,
+		BusyDuration: ctrl.busyDuration,
+
+		BlinkingVisible: ctrl.blinkingVisible,
+		BlinkingStart:   ctrl.blinkingStart,
+
+		ToCGRAM:        ctrl.addressCounter.toCGRAM,
+		MustMoveRight:  ctrl.addressCounter.mustMoveRight,
+		Is2LineDisplay: ctrl.addressCounter.is2LineDisplay,
+		DisplayShift:   ctrl.addressCounter.displayShift,
+		AddressCounter: ctrl.addressCounter.value,
+		Line1Shift:     ctrl.addressCounter.line1Shift,
+		Line2Shift:     ctrl.addressCounter.line2Shift,
+
+		Is8BitMode:  ctrl.buffer.is8BitMode,
+		BufferValue: ctrl.buffer.value,
+		BufferIndex: ctrl.buffer.index,
+	}
+
+	return binary.Write(writer, binary.LittleEndian, &state)
+}
+
+// LoadState restores the registers, memories, address counter and buffer of the LCD
+// controller from a state previously written by SaveState.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read
+func (ctrl *lcdHD44780U) LoadState(reader io.Reader) error {
+	var state lcdState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	ctrl.previousEnable = state.PreviousEnable
+
+	ctrl.instructionRegister = state.InstructionRegister
+	ctrl.dataRegister = state.DataRegister
+
+	ctrl.displayOn = state.DisplayOn
+	ctrl.displayCursor = state.DisplayCursor
+	ctrl.characterBlink = state.CharacterBlink
+	ctrl.is5x10Font = state.Is5x10Font
+
+	ctrl.ddram = state.DDRAM
+	ctrl.cgram = state.CGRAM
+
+	ctrl.isBusy = state.IsBusy
+	ctrl.busyStart = state.BusyStart
+	ctrl.busyDuration = state.BusyDuration
+
+	ctrl.blinkingVisible = state.BlinkingVisible
+	ctrl.blinkingStart = state.BlinkingStart
+
+	ctrl.addressCounter.toCGRAM = state.ToCGRAM
+	ctrl.addressCounter.mustMoveRight = state.MustMoveRight
+	ctrl.addressCounter.is2LineDisplay = state.Is2LineDisplay
+	ctrl.addressCounter.displayShift = state.DisplayShift
+	ctrl.addressCounter.value = state.AddressCounter
+	ctrl.addressCounter.line1Shift = state.Line1Shift
+	ctrl.addressCounter.line2Shift = state.Line2Shift
+
+	ctrl.buffer.is8BitMode = state.Is8BitMode
+	ctrl.buffer.value = state.BufferValue
+	ctrl.buffer.index = state.BufferIndex
+
+	return nil
+}
@@ -0,0 +1,571 @@
+package memory
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+)
+
+// Geometry and timing of the AT28C256
+const (
+	at28c256Size     int   = 0x8000                        // 32K bytes
+	at28c256PageSize int   = 64                            // Bytes written by each write cycle
+	byteLoadTimeout  int64 = int64(150 * time.Microsecond) // Time to load the next byte of a page (tBLC)
+	writeCycleTime   int64 = int64(10 * time.Millisecond)  // Duration of the internal write cycle (tWC)
+)
+
+// eepromWrite is a byte written by the processor to the EEPROM.
+type eepromWrite struct {
+	Address uint16
+	Value   uint8
+}
+
+// Software data protection sequences, the first 3 bytes of the disable sequence are the same
+// as the enable sequence except for the last value.
+var (
+	sdpEnableSequence = []eepromWrite{{0x5555, 0xAA}, {0x2AAA, 0x55}, {0x5555, 0xA0}}
+
+	sdpDisableSequence = []eepromWrite{
+		{0x5555, 0xAA}, {0x2AAA, 0x55}, {0x5555, 0x80},
+		{0x5555, 0xAA}, {0x2AAA, 0x55}, {0x5555, 0x20},
+	}
+)
+
+// at28c256 emulates the AT28C256 parallel EEPROM. It's read like a ROM but can be written
+// in-circuit. Bytes written are loaded in the page buffer and, when no other byte is written
+// in 150 us, the internal write cycle writes the page to the memory in 10 ms. During the write
+// cycle the chip ignores writes and the reads return the DATA polling and toggle bit status:
+// bit 7 is the complement of the last byte written and bit 6 toggles on each read.
+//
+// Writing AA, 55 and A0 to $5555, $2AAA and $5555 enables the software data protection, and
+// from then on each write must be preceded by the same sequence. A write without it starts a
+// write cycle that doesn't change the memory. The sequence AA, 55, 80, AA, 55, 20 to the same
+// addresses disables the protection. The chip is written when the chip select and write enable
+// are low and the output enable is high.
+type at28c256 struct {
+	values       []uint8                     // Memory contents
+	hiAddressBus *buses.BusConnector[uint16] // Not connected, the chip has 15 address pins
+	addressBus   *buses.BusConnector[uint16] // Connection to the address bus
+	dataBus      *buses.BusConnector[uint8]  // Connection to the data bus
+	writeEnable  *buses.ConnectorEnabledLow  // Write Enable signal (active low)
+	chipSelect   *buses.ConnectorEnabledLow  // Chip Select signal (active low)
+	outputEnable *buses.ConnectorEnabledLow  // Output Enable signal (active low)
+
+	protected bool // Software data protection enabled
+	unlocked  bool // The bytes loaded were preceded by the protection sequence
+
+	// Writes that match the start of a protection sequence, they are loaded in the page as
+	// data if the sequence is not completed
+	sequence       [6]eepromWrite
+	sequenceLength int
+
+	page       uint16                  // Address of the first byte of the page loaded
+	pageData   [at28c256PageSize]uint8 // Bytes loaded in the page
+	pageLoaded [at28c256PageSize]bool  // Bytes of the page that are written
+	hasPage    bool                    // A byte of data was loaded
+
+	enableProtection  bool // The write cycle enables the protection
+	disableProtection bool // The write cycle disables the protection
+
+	loading    bool  // Bytes are being loaded
+	lastLoad   int64 // Time when the last byte was loaded
+	writing    bool  // The internal write cycle is in progress
+	writeStart int64 // Time when the write cycle started
+	lastValue  uint8 // Last byte written, returned by DATA polling
+	toggleBit  bool  // Value of the toggle bit for the next read
+
+	persistFile *os.File // File where the pages written are saved, nil to keep them in memory
+	persistErr  error    // Error that stopped saving the pages, nil if there was none
+}
+
+// NewEepromAT28C256 creates a new AT28C256 EEPROM of 32K with the software data protection
+// disabled, as shipped from the factory.
+func NewEepromAT28C256() components.EEPROM {
+	return newEepromAT28C256()
+}
+
+// newEepromAT28C256 creates a new AT28C256 EEPROM initializing its bus connectors and control
+// signals.
+func newEepromAT28C256() *at28c256 {
+	return &at28c256{
+		values:       make([]uint8, at28c256Size),
+		hiAddressBus: buses.NewBusConnector[uint16](),
+		addressBus:   buses.NewBusConnector[uint16](),
+		dataBus:      buses.NewBusConnector[uint8](),
+		writeEnable:  buses.NewConnectorEnabledLow(),
+		chipSelect:   buses.NewConnectorEnabledLow(),
+		outputEnable: buses.NewConnectorEnabledLow(),
+	}
+}
+
+/************************************************************************************
+* Getters / Setters
+*************************************************************************************/
+
+// HiAddressBus returns the connector to the most significant 16 bits of address bus. The chip
+// only has 15 address pins, so it's not used.
+func (e *at28c256) HiAddressBus() *buses.BusConnector[uint16] {
+	return e.hiAddressBus
+}
+
+// AddressBus returns the connector to the address bus.
+// The address bus determines the memory location for read/write operations.
+func (e *at28c256) AddressBus() *buses.BusConnector[uint16] {
+	return e.addressBus
+}
+
+// DataBus returns the connector to the data bus.
+// The data bus carries the value being read from or written to memory.
+func (e *at28c256) DataBus() *buses.BusConnector[uint8] {
+	return e.dataBus
+}
+
+// WriteEnable returns the write enable signal connector.
+// When low, and the output enable is high, the value of the data bus is written.
+func (e *at28c256) WriteEnable() *buses.ConnectorEnabledLow {
+	return e.writeEnable
+}
+
+// ChipSelect returns the chip select signal connector.
+// When low, indicates this chip is selected and should respond to operations.
+func (e *at28c256) ChipSelect() *buses.ConnectorEnabledLow {
+	return e.chipSelect
+}
+
+// OutputEnable returns the output enable signal connector.
+// When low, allows the chip to put data on the data bus and inhibits the writes.
+func (e *at28c256) OutputEnable() *buses.ConnectorEnabledLow {
+	return e.outputEnable
+}
+
+// IsBusy returns true while bytes are being loaded or the write cycle is in progress, as the
+// reads return the status of the write instead of the contents of the memory.
+func (e *at28c256) IsBusy() bool {
+	return e.loading || e.writing
+}
+
+// IsProtected returns true if the software data protection is enabled.
+func (e *at28c256) IsProtected() bool {
+	return e.protected
+}
+
+/************************************************************************************
+* Utility functions
+*************************************************************************************/
+
+// Peek returns the value at the specified memory address without
+// going through the normal bus operations.
+func (e *at28c256) Peek(address uint32) uint8 {
+	return e.values[address]
+}
+
+// PeekRange returns a slice of memory values between startAddress and endAddress.
+// Useful for debugging and memory dumps.
+func (e *at28c256) PeekRange(startAddress uint16, endAddress uint16) []uint8 {
+	return e.values[startAddress:endAddress]
+}
+
+// Poke writes a value directly to the specified memory address without going through the
+// normal bus operations, as done by an external programmer. It is not persisted.
+func (e *at28c256) Poke(address uint16, value uint8) {
+	e.values[address] = value
+}
+
+// Load reads a binary file into memory starting at address 0x0000.
+// Returns an error if the file is too large for the available memory
+// or if there are any I/O errors.
+func (e *at28c256) Load(binFilePath string) error {
+	data, err := os.ReadFile(binFilePath)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > len(e.values) {
+		return fmt.Errorf("the file is too large for this eeprom (file size: %v, eeprom size: %v)", len(data), len(e.values))
+	}
+
+	copy(e.values, data)
+
+	return nil
+}
+
+// Size returns the total size of the EEPROM in bytes.
+func (e *at28c256) Size() int {
+	return len(e.values)
+}
+
+// Contents returns the slice that holds the values of the memory. It allows reading the memory
+// directly, without going through the bus, on the fast execution mode of the computers.
+func (e *at28c256) Contents() []uint8 {
+	return e.values
+}
+
+// PersistTo sets the file where the pages are saved at the end of each write cycle, usually
+// the image loaded in the EEPROM, so the writes done by the programs are kept between runs.
+// An empty path stops persisting the writes.
+//
+// Parameters:
+//   - path: The path of the image file, it must exist and be writable
+//
+// Returns:
+//   - An error if the file can't be opened for writing
+func (e *at28c256) PersistTo(path string) error {
+	if err := e.Close(); err != nil {
+		return err
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+
+	e.persistFile = file
+
+	return nil
+}
+
+// Close closes the file where the writes are persisted, if any. If a page couldn't be saved,
+// the error is returned.
+func (e *at28c256) Close() error {
+	err := e.persistErr
+	e.persistErr = nil
+
+	if e.persistFile == nil {
+		return err
+	}
+
+	if closeErr := e.persistFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	e.persistFile = nil
+
+	return err
+}
+
+// GetPersistError returns the error that stopped saving the pages written to the file, nil if
+// there was none.
+func (e *at28c256) GetPersistError() error {
+	return e.persistErr
+}
+
+/************************************************************************************
+* State
+*************************************************************************************/
+
+// Status of the writes of the EEPROM. This struct is written and read with encoding/binary,
+// so it must only have fixed size fields.
+type at28c256State struct {
+	Protected bool
+	Unlocked  bool
+
+	Sequence       [6]eepromWrite
+	SequenceLength uint8
+
+	Page       uint16
+	PageData   [at28c256PageSize]uint8
+	PageLoaded [at28c256PageSize]bool
+	HasPage    bool
+
+	EnableProtection  bool
+	DisableProtection bool
+
+	Loading    bool
+	LastLoad   int64
+	Writing    bool
+	WriteStart int64
+	LastValue  uint8
+	ToggleBit  bool
+}
+
+// SaveState writes the complete contents of the memory and the status of the write in
+// progress and of the software data protection.
+func (e *at28c256) SaveState(writer io.Writer) error {
+	if _, err := writer.Write(e.values); err != nil {
+		return err
+	}
+
+	state := at28c256State{
+		Protected:         e.protected,
+		Unlocked:          e.unlocked,
+		Sequence:          e.sequence,
+		SequenceLength:    uint8(e.sequenceLength),
+		Page:              e.page,
+		PageData:          e.pageData,
+		PageLoaded:        e.pageLoaded,
+		HasPage:           e.hasPage,
+		EnableProtection:  e.enableProtection,
+		DisableProtection: e.disableProtection,
+		Loading:           e.loading,
+		LastLoad:          e.lastLoad,
+		Writing:           e.writing,
+		WriteStart:        e.writeStart,
+		LastValue:         e.lastValue,
+		ToggleBit:         e.toggleBit,
+	}
+
+	return binary.Write(writer, binary.LittleEndian, &state)
+}
+
+// LoadState restores the contents of the memory and the status of the writes previously
+// written by SaveState. The restored contents are not persisted.
+func (e *at28c256) LoadState(reader io.Reader) error {
+	if _, err := io.ReadFull(reader, e.values); err != nil {
+		return fmt.Errorf("error loading memory contents: %w", err)
+	}
+
+	var state at28c256State
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	e.protected = state.Protected
+	e.unlocked = state.Unlocked
+	e.sequence = state.Sequence
+	e.sequenceLength = min(int(state.SequenceLength), len(e.sequence))
+	e.page = state.Page
+	e.pageData = state.PageData
+	e.pageLoaded = state.PageLoaded
+	e.hasPage = state.HasPage
+	e.enableProtection = state.EnableProtection
+	e.disableProtection = state.DisableProtection
+	e.loading = state.Loading
+	e.lastLoad = state.LastLoad
+	e.writing = state.Writing
+	e.writeStart = state.WriteStart
+	e.lastValue = state.LastValue
+	e.toggleBit = state.ToggleBit
+
+	return nil
+}
+
+/************************************************************************************
+* Internal functions
+*************************************************************************************/
+
+// getAddress returns the current address from the 15 address pins.
+func (e *at28c256) getAddress() uint16 {
+	return e.addressBus.Read() & uint16(at28c256Size-1)
+}
+
+// read puts on the data bus the value of the current address or, while writing, the DATA
+// polling and toggle bit status.
+func (e *at28c256) read() {
+	if !e.IsBusy() {
+		e.dataBus.Write(e.values[e.getAddress()])
+		return
+	}
+
+	value := (e.lastValue & 0x3F) | (^e.lastValue & 0x80)
+	if e.toggleBit {
+		value |= 0x40
+	}
+
+	e.toggleBit = !e.toggleBit
+	e.dataBus.Write(value)
+}
+
+// write loads the value of the data bus at the current address. Writes are ignored during the
+// write cycle.
+func (e *at28c256) write(t int64) {
+	if e.writing {
+		return
+	}
+
+	e.loading = true
+	e.lastLoad = t
+
+	current := eepromWrite{Address: e.getAddress(), Value: e.dataBus.Read()}
+
+	if e.matchSequence(current) {
+		return
+	}
+
+	e.loadByte(current)
+}
+
+// matchSequence adds the write to the protection sequence being written. When it completes a
+// sequence the command is applied and, if it doesn't match any, the writes held are loaded as
+// data. Returns true if the write is part of a sequence.
+func (e *at28c256) matchSequence(current eepromWrite) bool {
+	e.sequence[e.sequenceLength] = current
+	length := e.sequenceLength + 1
+	written := e.sequence[:length]
+
+	switch {
+	case isSequence(written, sdpEnableSequence):
+		e.sequenceLength = 0
+		e.unlocked = true
+		e.enableProtection = true
+		return true
+	case isSequence(written, sdpDisableSequence):
+		e.sequenceLength = 0
+		e.disableProtection = true
+		return true
+	case isPrefix(written, sdpEnableSequence) || isPrefix(written, sdpDisableSequence):
+		e.sequenceLength = length
+		return true
+	}
+
+	e.flushSequence()
+
+	// The write might start a new sequence
+	if isPrefix([]eepromWrite{current}, sdpEnableSequence) {
+		e.sequence[0] = current
+		e.sequenceLength = 1
+		return true
+	}
+
+	return false
+}
+
+// flushSequence loads as data the writes held by an incomplete protection sequence.
+func (e *at28c256) flushSequence() {
+	held := e.sequence[:e.sequenceLength]
+	e.sequenceLength = 0
+
+	for _, write := range held {
+		e.loadByte(write)
+	}
+}
+
+// loadByte loads the byte in the page buffer. With the protection enabled, if the write was not
+// preceded by the protection sequence, it's ignored. Only the bytes of the page of the first
+// byte loaded are written.
+func (e *at28c256) loadByte(write eepromWrite) {
+	e.lastValue = write.Value
+
+	if e.protected && !e.unlocked {
+		return
+	}
+
+	page := write.Address &^ uint16(at28c256PageSize-1)
+	if !e.hasPage {
+		e.page = page
+		e.hasPage = true
+	}
+
+	if page != e.page {
+		return
+	}
+
+	offset := write.Address - page
+	e.pageData[offset] = write.Value
+	e.pageLoaded[offset] = true
+}
+
+// updateWriteCycle starts the write cycle when no byte was loaded for the byte load time, and
+// completes it when the write cycle time elapses.
+func (e *at28c256) updateWriteCycle(t int64) {
+	if e.loading && t-e.lastLoad >= byteLoadTimeout {
+		e.flushSequence()
+
+		e.loading = false
+		e.writing = true
+		e.writeStart = e.lastLoad + byteLoadTimeout
+	}
+
+	if e.writing && t-e.writeStart >= writeCycleTime {
+		e.completeWriteCycle()
+	}
+}
+
+// completeWriteCycle writes the bytes loaded in the page, applies the protection commands and
+// gets ready to load the next page.
+func (e *at28c256) completeWriteCycle() {
+	if e.hasPage {
+		for offset, loaded := range e.pageLoaded {
+			if loaded {
+				e.values[int(e.page)+offset] = e.pageData[offset]
+			}
+		}
+
+		e.persistPage()
+	}
+
+	if e.enableProtection {
+		e.protected = true
+	}
+
+	if e.disableProtection {
+		e.protected = false
+	}
+
+	e.writing = false
+	e.unlocked = false
+	e.hasPage = false
+	e.enableProtection = false
+	e.disableProtection = false
+	e.pageLoaded = [at28c256PageSize]bool{}
+}
+
+// persistPage writes the page written to the persistence file, if any. As the bus can't
+// report errors, if the page can't be written the file is closed and the error is kept to be
+// returned by GetPersistError and Close. The writes are no longer persisted.
+func (e *at28c256) persistPage() {
+	if e.persistFile == nil {
+		return
+	}
+
+	page := e.values[e.page : int(e.page)+at28c256PageSize]
+	if _, err := e.persistFile.WriteAt(page, int64(e.page)); err != nil {
+		e.persistFile.Close()
+		e.persistFile = nil
+		e.persistErr = err
+	}
+}
+
+// isPrefix returns true if the writes are the start of the sequence.
+func isPrefix(writes []eepromWrite, sequence []eepromWrite) bool {
+	if len(writes) > len(sequence) {
+		return false
+	}
+
+	for i, write := range writes {
+		if write != sequence[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSequence returns true if the writes are the complete sequence.
+func isSequence(writes []eepromWrite, sequence []eepromWrite) bool {
+	return len(writes) == len(sequence) && isPrefix(writes, sequence)
+}
+
+/************************************************************************************
+ * Timer Tick
+*************************************************************************************/
+
+// Tick performs one emulation step. It advances the write cycle and then, if the chip is
+// selected, writes the data bus when the write enable is low and the output enable high, or
+// puts the value read on the data bus when the output enable is low.
+func (e *at28c256) Tick(context *common.StepContext) {
+	e.updateWriteCycle(context.T)
+
+	if !e.chipSelect.Enabled() {
+		return
+	}
+
+	writeEnable := e.writeEnable.Enabled()
+	outputEnable := e.outputEnable.Enabled()
+
+	if writeEnable && !outputEnable {
+		e.write(context.T)
+	} else if outputEnable && !writeEnable {
+		e.read()
+	}
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Circuit to test the EEPROM, each access takes 1 us
+type eepromTestCircuit struct {
+	addressBus   buses.Bus[uint16]
+	dataBus      buses.Bus[uint8]
+	writeEnable  *buses.StandaloneLine
+	outputEnable *buses.StandaloneLine
+	chipSelect   *buses.StandaloneLine
+
+	eeprom  *at28c256
+	context common.StepContext
+}
+
+// Creates the test circuit and connects the EEPROM to it
+func newEepromTestCircuit() *eepromTestCircuit {
+	circuit := &eepromTestCircuit{
+		addressBus:   buses.New16BitStandaloneBus(),
+		dataBus:      buses.New8BitStandaloneBus(),
+		writeEnable:  buses.NewStandaloneLine(true),
+		outputEnable: buses.NewStandaloneLine(true),
+		chipSelect:   buses.NewStandaloneLine(true),
+		eeprom:       newEepromAT28C256(),
+		context:      common.NewStepContext(),
+	}
+
+	circuit.eeprom.AddressBus().Connect(circuit.addressBus)
+	circuit.eeprom.DataBus().Connect(circuit.dataBus)
+	circuit.eeprom.WriteEnable().Connect(circuit.writeEnable)
+	circuit.eeprom.OutputEnable().Connect(circuit.outputEnable)
+	circuit.eeprom.ChipSelect().Connect(circuit.chipSelect)
+
+	return circuit
+}
+
+// Ticks the EEPROM with the control lines set and advances the time 1 us
+func (circuit *eepromTestCircuit) access(address uint16, writeEnable bool, outputEnable bool) {
+	circuit.addressBus.Write(address)
+	circuit.chipSelect.Set(false)
+	circuit.writeEnable.Set(!writeEnable)
+	circuit.outputEnable.Set(!outputEnable)
+
+	circuit.eeprom.Tick(&circuit.context)
+
+	circuit.chipSelect.Set(true)
+	circuit.context.T += 1_000
+}
+
+// Writes the value to the address
+func (circuit *eepromTestCircuit) write(address uint16, value uint8) {
+	circuit.dataBus.Write(value)
+	circuit.access(address, true, false)
+}
+
+// Writes the sequence of values to the protection addresses
+func (circuit *eepromTestCircuit) writeSequence(sequence []eepromWrite) {
+	for _, write := range sequence {
+		circuit.write(write.Address, write.Value)
+	}
+}
+
+// Returns the value read from the address
+func (circuit *eepromTestCircuit) read(address uint16) uint8 {
+	circuit.access(address, false, true)
+	return circuit.dataBus.Read()
+}
+
+// Waits until the write cycle is completed
+func (circuit *eepromTestCircuit) waitWriteCycle() {
+	circuit.context.T += byteLoadTimeout + writeCycleTime
+	circuit.eeprom.Tick(&circuit.context)
+}
+
+func TestEepromByteWriteAndDataPolling(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	circuit.write(0x0123, 0x42)
+	assert.True(t, circuit.eeprom.IsBusy())
+
+	// Bit 7 is the complement of the value written and bit 6 toggles
+	first := circuit.read(0x0123)
+	second := circuit.read(0x0123)
+
+	assert.Equal(t, uint8(0x80), first&0x80)
+	assert.Equal(t, uint8(0x80), second&0x80)
+	assert.NotEqual(t, first&0x40, second&0x40)
+	assert.Equal(t, uint8(0x02), first&0x3F)
+	assert.Equal(t, uint8(0x00), circuit.eeprom.Peek(0x0123))
+
+	// The write cycle starts 150 us after the last byte and takes 10 ms
+	circuit.context.T += byteLoadTimeout + writeCycleTime - 10_000
+	assert.NotEqual(t, uint8(0x42), circuit.read(0x0123))
+
+	circuit.context.T += 10_000
+	assert.Equal(t, uint8(0x42), circuit.read(0x0123))
+	assert.False(t, circuit.eeprom.IsBusy())
+}
+
+func TestEepromPageWrite(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	circuit.write(0x1040, 0x01)
+	circuit.write(0x107F, 0x02)
+	circuit.write(0x1041, 0x03)
+
+	// Other page than the first byte written
+	circuit.write(0x1080, 0x04)
+
+	circuit.waitWriteCycle()
+
+	// Ignored during the write cycle
+	circuit.write(0x1042, 0x05)
+	circuit.waitWriteCycle()
+
+	assert.Equal(t, uint8(0x01), circuit.read(0x1040))
+	assert.Equal(t, uint8(0x03), circuit.read(0x1041))
+	assert.Equal(t, uint8(0x02), circuit.read(0x107F))
+	assert.Equal(t, uint8(0x00), circuit.read(0x1080))
+	assert.Equal(t, uint8(0x05), circuit.read(0x1042))
+}
+
+func TestEepromWritesAreInhibitedByOutputEnable(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	circuit.dataBus.Write(0x42)
+	circuit.access(0x0000, true, true)
+
+	assert.False(t, circuit.eeprom.IsBusy())
+	circuit.waitWriteCycle()
+	assert.Equal(t, uint8(0x00), circuit.eeprom.Peek(0x0000))
+}
+
+func TestEepromSoftwareDataProtection(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	// Enables the protection and writes a byte
+	circuit.writeSequence(sdpEnableSequence)
+	circuit.write(0x0200, 0x11)
+	circuit.waitWriteCycle()
+
+	assert.True(t, circuit.eeprom.IsProtected())
+	assert.Equal(t, uint8(0x11), circuit.read(0x0200))
+
+	// The sequence bytes are not written
+	assert.Equal(t, uint8(0x00), circuit.eeprom.Peek(0x5555))
+	assert.Equal(t, uint8(0x00), circuit.eeprom.Peek(0x2AAA))
+
+	// Writes without the sequence start a write cycle without writing the memory
+	circuit.write(0x0200, 0x22)
+	assert.True(t, circuit.eeprom.IsBusy())
+	circuit.waitWriteCycle()
+	assert.Equal(t, uint8(0x11), circuit.read(0x0200))
+
+	// Protected write
+	circuit.writeSequence(sdpEnableSequence)
+	circuit.write(0x0200, 0x33)
+	circuit.waitWriteCycle()
+	assert.Equal(t, uint8(0x33), circuit.read(0x0200))
+	assert.True(t, circuit.eeprom.IsProtected())
+
+	// Disables the protection
+	circuit.writeSequence(sdpDisableSequence)
+	circuit.waitWriteCycle()
+	assert.False(t, circuit.eeprom.IsProtected())
+
+	circuit.write(0x0200, 0x44)
+	circuit.waitWriteCycle()
+	assert.Equal(t, uint8(0x44), circuit.read(0x0200))
+}
+
+func TestEepromIncompleteSequenceIsWrittenAsData(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	circuit.write(0x5555, 0xAA)
+	circuit.write(0x5556, 0x55)
+	circuit.waitWriteCycle()
+
+	assert.Equal(t, uint8(0xAA), circuit.read(0x5555))
+	assert.Equal(t, uint8(0x55), circuit.read(0x5556))
+
+	// The first byte of a sequence not followed by other byte in time
+	circuit.write(0x5555, 0xAA)
+	circuit.waitWriteCycle()
+
+	assert.Equal(t, uint8(0xAA), circuit.read(0x5555))
+	assert.False(t, circuit.eeprom.IsProtected())
+}
+
+func TestEepromPersistsWrittenPages(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	path := filepath.Join(t.TempDir(), "rom.bin")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{0xEA}, at28c256Size), 0644))
+	require.NoError(t, circuit.eeprom.Load(path))
+	require.NoError(t, circuit.eeprom.PersistTo(path))
+
+	circuit.write(0x7FFC, 0x00)
+	circuit.write(0x7FFD, 0x80)
+	circuit.waitWriteCycle()
+
+	require.NoError(t, circuit.eeprom.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, data, at28c256Size)
+	assert.Equal(t, []byte{0xEA, 0x00, 0x80, 0xEA}, data[0x7FFB:0x7FFF])
+
+	assert.Error(t, circuit.eeprom.PersistTo(filepath.Join(t.TempDir(), "missing.bin")))
+}
+
+func TestEepromStopsPersistingOnWriteError(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	path := filepath.Join(t.TempDir(), "rom.bin")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{0xEA}, at28c256Size), 0644))
+	require.NoError(t, circuit.eeprom.PersistTo(path))
+
+	// The writes fail once the file is closed
+	require.NoError(t, circuit.eeprom.persistFile.Close())
+
+	circuit.write(0x7FFC, 0x00)
+	circuit.waitWriteCycle()
+
+	assert.Equal(t, uint8(0x00), circuit.read(0x7FFC))
+	assert.ErrorIs(t, circuit.eeprom.GetPersistError(), os.ErrClosed)
+	assert.Nil(t, circuit.eeprom.persistFile)
+
+	assert.ErrorIs(t, circuit.eeprom.Close(), os.ErrClosed)
+	assert.NoError(t, circuit.eeprom.Close())
+}
+
+func TestEepromStateRestoresWriteInProgress(t *testing.T) {
+	circuit := newEepromTestCircuit()
+
+	circuit.writeSequence(sdpEnableSequence)
+	circuit.write(0x0300, 0x5A)
+
+	var state bytes.Buffer
+	require.NoError(t, circuit.eeprom.SaveState(&state))
+
+	restored := newEepromTestCircuit()
+	restored.context = circuit.context
+	require.NoError(t, restored.eeprom.LoadState(&state))
+
+	assert.True(t, restored.eeprom.IsBusy())
+	restored.waitWriteCycle()
+
+	assert.Equal(t, uint8(0x5A), restored.read(0x0300))
+	assert.True(t, restored.eeprom.IsProtected())
+}
@@ -3,6 +3,7 @@ package memory
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/fran150/clementina-6502/internal/file_io"
@@ -154,6 +155,28 @@ func (ram *ram) Size() int {
 	return len(ram.values)
 }
 
+// Contents returns the slice that holds the values of the memory. It allows reading and writing
+// the memory directly, without going through the bus, on the fast execution mode of the computers.
+func (ram *ram) Contents() []uint8 {
+	return ram.values
+}
+
+// SaveState writes the complete contents of the memory.
+func (ram *ram) SaveState(writer io.Writer) error {
+	_, err := writer.Write(ram.values)
+	return err
+}
+
+// LoadState restores the contents of the memory previously written by SaveState.
+// Returns an error if the state doesn't have the size of this memory.
+func (ram *ram) LoadState(reader io.Reader) error {
+	if _, err := io.ReadFull(reader, ram.values); err != nil {
+		return fmt.Errorf("error loading memory contents: %w", err)
+	}
+
+	return nil
+}
+
 /************************************************************************************
 * Internal functions
 *************************************************************************************/
@@ -172,6 +172,12 @@ func TestPeekAndPokeReadsAndWritesValuesDirectly(t *testing.T) {
 		assert.Equal(t, uint8(i), values[i])
 	}
 
+	// Changes to the contents are seen by the memory
+	contents := ram.Contents()
+	assert.Len(t, contents, int(RAM_SIZE_1K))
+
+	contents[0x10] = 0xAA
+	assert.Equal(t, uint8(0xAA), ram.Peek(0x10))
 }
 
 /************************************************************************************
@@ -10,6 +10,7 @@ import (
 
 	"github.com/fran150/clementina-6502/pkg/components"
 	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
 	"go.bug.st/serial"
 )
 
@@ -53,6 +54,17 @@ type miaConsoleState struct {
 
 	lastDumpAddr   uint32
 	lastDisasmAddr uint32
+
+	symbols core.SymbolTable
+}
+
+// SetSymbolTable sets the labels the monitor disassembler shows in place of the
+// addresses that have one. A nil table shows only addresses.
+func (c *emulated_mia) SetSymbolTable(symbols core.SymbolTable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.console.symbols = symbols
 }
 
 // ConnectToPort exposes the emulated MIA USB-style console over a host serial port.
@@ -791,6 +803,10 @@ func (c *emulated_mia) monitorDisassembleOneLocked(out *strings.Builder, addr ui
 	op1 := c.monitorByte(addr + 1)
 	op2 := c.monitorByte(addr + 2)
 
+	if label, ok := c.monitorLabel(addr); ok {
+		fmt.Fprintf(out, "%s:\n", label)
+	}
+
 	fmt.Fprintf(out, "$%05X: ", addr)
 	for i := uint8(0); i < 3; i++ {
 		if i < size {
@@ -805,12 +821,50 @@ func (c *emulated_mia) monitorDisassembleOneLocked(out *strings.Builder, addr ui
 		mnemonic = "???"
 	}
 	fmt.Fprintf(out, "%-5s", mnemonic)
-	out.WriteString(monitorInstructionOperand(addr, instruction, known, op1, op2))
+	out.WriteString(c.monitorLabeledOperand(addr, instruction, known, op1, op2))
 	out.WriteByte('\n')
 
 	return addr + uint32(size)
 }
 
+// monitorLabel returns the label of a 16 bit address if a symbol table is loaded.
+func (c *emulated_mia) monitorLabel(addr uint32) (string, bool) {
+	if c.console.symbols == nil || addr > 0xFFFF {
+		return "", false
+	}
+
+	return c.console.symbols.GetLabel(uint16(addr))
+}
+
+// monitorLabeledOperand formats the operand of the instruction replacing the absolute
+// addresses and branch targets that have a label by the label.
+func (c *emulated_mia) monitorLabeledOperand(addr uint32, instruction components.CpuInstructionData, known bool, op1, op2 uint8) string {
+	operand := monitorInstructionOperand(addr, instruction, known, op1, op2)
+	if !known {
+		return operand
+	}
+
+	var target uint16
+
+	switch mode := instruction.AddressMode(); {
+	case mode == cpu.AddressModeRelative:
+		target = uint16(int32(uint16(addr+2)) + int32(int8(op1)))
+	case mode == cpu.AddressModeRelativeExtended:
+		target = uint16(int32(uint16(addr+3)) + int32(int8(op2)))
+	case mode != cpu.AddressModeBreak && cpu.GetAddressMode(mode).MemSize() == 3:
+		target = uint16(op1) | uint16(op2)<<8
+	default:
+		return operand
+	}
+
+	label, ok := c.monitorLabel(uint32(target))
+	if !ok {
+		return operand
+	}
+
+	return strings.Replace(operand, fmt.Sprintf("$%04X", target), label, 1)
+}
+
 func (c *emulated_mia) monitorByte(addr uint32) uint8 {
 	if addr >= miaRAMSize {
 		return 0
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/fran150/clementina-6502/internal/testutils"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.bug.st/serial"
@@ -112,6 +113,30 @@ func TestEmulatedMiaMonitorDisassembleUsesCPUAddressModeData(t *testing.T) {
 	assert.Contains(t, out, "$04003: 7C 34 12 JMP  ($1234,X)\n")
 }
 
+func TestEmulatedMiaMonitorDisassembleShowsLabels(t *testing.T) {
+	chip := NewEmulatedMia().(*emulated_mia)
+
+	symbols := managers.NewSymbolTable()
+	symbols.AddSymbol("reset", 0x4000)
+	symbols.AddSymbol("lcd_wait", 0x8012)
+	chip.SetSymbolTable(symbols)
+
+	chip.memory[0x4000] = 0x20 // JSR $8012
+	chip.memory[0x4001] = 0x12
+	chip.memory[0x4002] = 0x80
+	chip.memory[0x4003] = 0x80 // BRA $4000
+	chip.memory[0x4004] = 0xFB
+	chip.memory[0x4005] = 0xAD // LDA $1234
+	chip.memory[0x4006] = 0x34
+	chip.memory[0x4007] = 0x12
+
+	out, _ := chip.monitorDisassembleLocked(0x4000, 3)
+
+	assert.Contains(t, out, "reset:\n$04000: 20 12 80 JSR  lcd_wait\n")
+	assert.Contains(t, out, "$04003: 80 FB    BRA  reset\n")
+	assert.Contains(t, out, "$04005: AD 34 12 LDA  $1234\n")
+}
+
 func newMiaConsoleTest(t *testing.T) (*emulated_mia, *testutils.SerialPortMock) {
 	t.Helper()
 
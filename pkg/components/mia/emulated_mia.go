@@ -40,6 +40,7 @@ type emulated_mia struct {
 	phi2HzChanged          func(uint32)
 	execPaused             bool
 	execPausedChanged      func(bool)
+	inputPacketHandler     func(remote string, packet []byte)
 
 	// nowNs caches StepContext.T (nanoseconds since emulation start) from the
 	// latest Tick so input services (e.g. key auto-repeat) can time their work
@@ -363,6 +363,71 @@ func TestEmulatedMiaInputWifiSession(t *testing.T) {
 	})
 }
 
+func TestEmulatedMiaInputPacketsRecordedAndInjected(t *testing.T) {
+	type receivedPacket struct {
+		remote string
+		packet []byte
+	}
+
+	newChip := func() *emulated_mia {
+		chip := NewEmulatedMia().(*emulated_mia)
+		require.NoError(t, chip.StartInputUDP("127.0.0.1:0"))
+		chip.state = miaStateNormal
+
+		chip.mu.Lock()
+		require.True(t, chip.inputSetMode(miaInputModeWifi))
+		chip.mu.Unlock()
+
+		return chip
+	}
+
+	chip := newChip()
+	defer chip.Close()
+
+	received := make(chan receivedPacket, 1)
+	chip.SetInputPacketHandler(func(remote string, packet []byte) {
+		received <- receivedPacket{remote: remote, packet: packet}
+	})
+
+	receive := func() receivedPacket {
+		select {
+		case packet := <-received:
+			return packet
+		case <-time.After(time.Second):
+			t.Fatal("the packet was not passed to the handler")
+			return receivedPacket{}
+		}
+	}
+
+	client, serverAddr := newMiaInputUDPClient(t, chip.InputUDPAddress())
+	defer client.Close()
+
+	// The packets are only processed when injected
+	sendMiaInputPacket(t, client, serverAddr, buildMiaInputHelloPacket(1, miaInputCapAll, "tester"))
+	hello := receive()
+	assert.False(t, chip.input.wifiActive)
+
+	require.NoError(t, chip.InjectInputPacket(hello.remote, hello.packet))
+	welcome := readMiaInputPacket(t, client)
+	require.Equal(t, uint8(miaInputWelcomeAccepted), welcome.status)
+
+	sendMiaInputPacket(t, client, serverAddr, buildMiaInputPacket(miaInputPacketText, welcome.payloadSession, 2, []byte{2, 'A', 'B'}))
+	text := receive()
+	require.NoError(t, chip.InjectInputPacket(text.remote, text.packet))
+	assert.Equal(t, uint8(2), chip.registers[miaRegInputCharCount])
+
+	// Injecting the same packets in another chip opens the same session
+	replay := newChip()
+	defer replay.Close()
+
+	require.NoError(t, replay.InjectInputPacket(hello.remote, hello.packet))
+	require.NoError(t, replay.InjectInputPacket(text.remote, text.packet))
+	assert.Equal(t, chip.input.wifiSession, replay.input.wifiSession)
+	assert.Equal(t, uint8(2), replay.registers[miaRegInputCharCount])
+
+	assert.Error(t, replay.InjectInputPacket("not an address", text.packet))
+}
+
 func TestEmulatedMiaInputWifiHelloBusyWhenNotWifiMode(t *testing.T) {
 	chip := NewEmulatedMia().(*emulated_mia)
 	require.NoError(t, chip.StartInputUDP("127.0.0.1:0"))
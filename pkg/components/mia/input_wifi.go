@@ -2,6 +2,7 @@ package mia
 
 import (
 	"encoding/binary"
+	"fmt"
 	"net"
 )
 
@@ -111,6 +112,15 @@ func (c *emulated_mia) inputReadLoop(conn *net.UDPConn) {
 		packet := make([]byte, n)
 		copy(packet, buf[:n])
 
+		c.mu.Lock()
+		handler := c.inputPacketHandler
+		c.mu.Unlock()
+
+		if handler != nil {
+			handler(remote.String(), packet)
+			continue
+		}
+
 		reply := c.inputHandleDatagram(packet, remote)
 		if reply != nil {
 			_, _ = conn.WriteToUDP(reply.data, reply.addr)
@@ -118,6 +128,41 @@ func (c *emulated_mia) inputReadLoop(conn *net.UDPConn) {
 	}
 }
 
+// SetInputPacketHandler routes the datagrams received by the input service to the handler
+// instead of processing them, nil processes them as soon as they are received. The handler is
+// called from the goroutine that reads the UDP socket with the address of the sender.
+func (c *emulated_mia) SetInputPacketHandler(handler func(remote string, packet []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inputPacketHandler = handler
+}
+
+// InjectInputPacket processes a datagram as if it was received by the input service from the
+// remote address. The reply, if any, is sent to that address when the service is running.
+func (c *emulated_mia) InjectInputPacket(remote string, packet []byte) error {
+	addr, err := net.ResolveUDPAddr("udp4", remote)
+	if err != nil {
+		return err
+	}
+
+	if len(packet) > miaInputRxPacketSize {
+		return fmt.Errorf("the input packet has %d bytes, the maximum is %d", len(packet), miaInputRxPacketSize)
+	}
+
+	reply := c.inputHandleDatagram(packet, addr)
+
+	c.mu.Lock()
+	conn := c.input.conn
+	c.mu.Unlock()
+
+	if reply != nil && conn != nil {
+		_, _ = conn.WriteToUDP(reply.data, reply.addr)
+	}
+
+	return nil
+}
+
 func (c *emulated_mia) inputHandleDatagram(packet []byte, remote *net.UDPAddr) *miaInputOutgoing {
 	c.mu.Lock()
 	defer c.mu.Unlock()
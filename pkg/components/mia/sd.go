@@ -217,6 +217,7 @@ type miaSDState struct {
 
 	file *os.File
 
+	dirPath    string
 	dirEntries []os.DirEntry
 	dirIndex   int
 
@@ -346,6 +347,7 @@ func (c *emulated_mia) sdCloseFile() {
 }
 
 func (c *emulated_mia) sdCloseDir() {
+	c.sd.dirPath = ""
 	c.sd.dirEntries = nil
 	c.sd.dirIndex = 0
 	c.sd.dirOpen = false
@@ -602,6 +604,7 @@ func (c *emulated_mia) sdServiceOpendir() (bool, uint8, uint8) {
 		return false, sdErrToFresult(err), miaErrorFSDirFailed
 	}
 
+	c.sd.dirPath = hostPath
 	c.sd.dirEntries = entries
 	c.sd.dirIndex = 0
 	c.sd.dirOpen = true
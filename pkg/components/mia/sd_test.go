@@ -1,6 +1,7 @@
 package mia
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -384,6 +385,52 @@ func TestEmulatedMiaSDRawSectorRoundTrip(t *testing.T) {
 	}
 }
 
+// TestEmulatedMiaSDLoadStateReopensFile verifies that loading a saved state opens the
+// file again at the saved position and restores the virtual card sectors.
+func TestEmulatedMiaSDLoadStateReopensFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "READ.TXT"), []byte("HELLO SD\n"), 0o644))
+
+	circuit := newSDTestCircuit(t, dir)
+	chip := circuit.chip
+
+	circuit.write(miaRegCmdTrigger, miaCmdFSMount)
+	chip.memory[miaSDControlOffset+miaSDControlOpenMode] = miaFSOpenRead
+	sdWritePath(chip, "/READ.TXT")
+	circuit.write(miaRegCmdTrigger, miaCmdFSOpen)
+
+	chip.sdWriteU16(miaSDControlOffset+miaSDControlRequestLenL, 6)
+	circuit.write(miaRegCmdTrigger, miaCmdFSRead)
+	require.Equal(t, uint32(6), chip.sdReadU32(miaSDControlOffset+miaSDControlFilePos0))
+
+	chip.memory[miaSDSectorOffset] = 0xAA
+	chip.sdWriteU32(miaSDControlOffset+miaSDControlLBA0, 5)
+	circuit.write(miaRegCmdTrigger, miaCmdSDWriteSector)
+
+	var state bytes.Buffer
+	require.NoError(t, chip.SaveState(&state))
+	saved := state.Bytes()
+
+	restored := newSDTestCircuit(t, dir)
+	require.NoError(t, restored.chip.LoadState(bytes.NewReader(saved)))
+	require.True(t, restored.chip.sd.fileOpen)
+	assert.Equal(t, []uint8{0xAA}, restored.chip.sd.rawSectors[5][:1])
+
+	restored.chip.sdWriteU16(miaSDControlOffset+miaSDControlRequestLenL, 0)
+	restored.write(miaRegCmdTrigger, miaCmdFSRead)
+	require.Equal(t, uint16(3), restored.chip.sdReadU16(miaSDControlOffset+miaSDControlResultLenL))
+	assert.Equal(t, []byte("SD\n"), restored.chip.memory[miaFSTransferOffset:miaFSTransferOffset+3])
+
+	// The file is left closed if it doesn't exist anymore
+	require.NoError(t, os.Remove(filepath.Join(dir, "READ.TXT")))
+
+	missing := newSDTestCircuit(t, dir)
+	require.NoError(t, missing.chip.LoadState(bytes.NewReader(saved)))
+	assert.False(t, missing.chip.sd.fileOpen)
+	assert.Zero(t, sdControlByte(missing.chip, miaSDControlStatus)&miaSDStatusFileOpen)
+	assert.True(t, missing.chip.sd.mounted)
+}
+
 // TestEmulatedMiaFSReadNoFileOpen verifies FS_READ without an open file reports
 // ERROR_FS_NO_FILE_OPEN.
 func TestEmulatedMiaFSReadNoFileOpen(t *testing.T) {
@@ -0,0 +1,400 @@
+package mia
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Values of a MIA memory index.
+type miaIndexState struct {
+	CurrentAddr uint32
+	DefaultAddr uint32
+	LimitAddr   uint32
+	Step        uint16
+	Flags       uint8
+}
+
+// Values of the MIA register window, memory indexes, error queue and the status of the
+// loader, reset and clock control logic. The IRQ and status flags live in the register
+// window. This struct is written and read with encoding/binary, so it must only have
+// fixed size fields.
+type miaChipState struct {
+	Registers [miaRegisterCount]uint8
+	Indexes   [miaIndexCount]miaIndexState
+
+	ErrorFirst  uint8
+	ErrorLast   uint8
+	ErrorBuffer [16]uint8
+
+	State                  uint8
+	KernelIndex            uint32
+	KernelTargetAddress    uint16
+	CanUpdateKernelPointer bool
+	IrqAsserted            bool
+	CpuResetCycles         uint8
+	ResetRequestAsserted   bool
+	ResetReleasePending    bool
+	StagedPhi2Hz           uint32
+	RequestedPhi2Hz        uint32
+	AppliedPhi2Hz          uint32
+	SpeedChangeRequested   bool
+	ExecPaused             bool
+}
+
+// SaveState writes the registers, memory indexes, error queue, loader and clock control
+// status, the complete MIA memory and the status of the SD card, including the files and
+// directories open on it. The state of the video, input, audio and console services is not
+// saved as it depends on host resources.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (c *emulated_mia) SaveState(writer io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := miaChipState{
+		Registers: c.registers,
+
+		ErrorFirst:  c.errors.first,
+		ErrorLast:   c.errors.last,
+		ErrorBuffer: c.errors.buf,
+
+		State:                  uint8(c.state),
+		KernelIndex:            c.kernelIndex,
+		KernelTargetAddress:    c.kernelTargetAddress,
+		CanUpdateKernelPointer: c.canUpdateKernelPointer,
+		IrqAsserted:            c.irqAsserted,
+		CpuResetCycles:         c.cpuResetCycles,
+		ResetRequestAsserted:   c.resetRequestAsserted,
+		ResetReleasePending:    c.resetReleasePending,
+		StagedPhi2Hz:           c.stagedPhi2Hz,
+		RequestedPhi2Hz:        c.requestedPhi2Hz,
+		AppliedPhi2Hz:          c.appliedPhi2Hz,
+		SpeedChangeRequested:   c.speedChangeRequested,
+		ExecPaused:             c.execPaused,
+	}
+
+	for i, index := range c.indexes {
+		state.Indexes[i] = miaIndexState{
+			CurrentAddr: index.currentAddr,
+			DefaultAddr: index.defaultAddr,
+			LimitAddr:   index.limitAddr,
+			Step:        index.step,
+			Flags:       index.flags,
+		}
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(c.memory); err != nil {
+		return err
+	}
+
+	return c.sdSaveState(writer)
+}
+
+// LoadState restores the MIA from a state previously written by SaveState. The whole
+// memory is marked as changed so the video service sends it again, and the host is
+// notified if the clock speed or the execution pause status are different. Files and
+// directories that were open on the SD card are opened again from the current SD folder,
+// if that's not possible they are left closed.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read
+func (c *emulated_mia) LoadState(reader io.Reader) error {
+	var state miaChipState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := io.ReadFull(reader, c.memory); err != nil {
+		return fmt.Errorf("error loading MIA memory: %w", err)
+	}
+
+	c.registers = state.Registers
+
+	if err := c.sdLoadState(reader); err != nil {
+		return err
+	}
+
+	for i, index := range state.Indexes {
+		c.indexes[i] = miaIndex{
+			currentAddr: index.CurrentAddr,
+			defaultAddr: index.DefaultAddr,
+			limitAddr:   index.LimitAddr,
+			step:        index.Step,
+			flags:       index.Flags,
+		}
+	}
+
+	c.errors = miaErrorQueue{
+		first: state.ErrorFirst,
+		last:  state.ErrorLast,
+		buf:   state.ErrorBuffer,
+	}
+
+	c.state = miaState(state.State)
+	c.kernelIndex = state.KernelIndex
+	c.kernelTargetAddress = state.KernelTargetAddress
+	c.canUpdateKernelPointer = state.CanUpdateKernelPointer
+	c.irqAsserted = state.IrqAsserted
+	c.cpuResetCycles = state.CpuResetCycles
+	c.resetRequestAsserted = state.ResetRequestAsserted
+	c.resetReleasePending = state.ResetReleasePending
+	c.stagedPhi2Hz = state.StagedPhi2Hz
+	c.requestedPhi2Hz = state.RequestedPhi2Hz
+	c.speedChangeRequested = state.SpeedChangeRequested
+
+	if c.appliedPhi2Hz != state.AppliedPhi2Hz {
+		c.appliedPhi2Hz = state.AppliedPhi2Hz
+		c.notifyPhi2HzChanged(c.appliedPhi2Hz)
+	}
+
+	if c.execPaused != state.ExecPaused {
+		c.execPaused = state.ExecPaused
+		c.notifyExecPaused(c.execPaused)
+	}
+
+	c.videoMarkDirtyRange(0, uint32(len(c.memory)))
+
+	return nil
+}
+
+// Status of the SD card and the file and directory open on it. The paths of the file and
+// directory and the sectors written to the virtual block device follow this struct.
+// This struct is written and read with encoding/binary, so it must only have fixed
+// size fields.
+type miaSDChipState struct {
+	Initialized     bool
+	Mounted         bool
+	FileOpen        bool
+	DirOpen         bool
+	EOF             bool
+	LastError       uint8
+	LastFatfsResult uint8
+	CardType        uint8
+	CurrentOpenMode uint8
+	Sectors         uint32
+	FilePosition    int64
+	DirIndex        uint32
+	RawSectorCount  uint32
+}
+
+// sdSaveState writes the status of the SD card. The open file and directory are saved as
+// paths relative to the SD folder so they can be opened again when the state is loaded.
+func (c *emulated_mia) sdSaveState(writer io.Writer) error {
+	var filePath string
+	var filePosition int64
+
+	if c.sd.fileOpen && c.sd.file != nil {
+		filePath = c.sdRelativePath(c.sd.file.Name())
+		filePosition, _ = c.sd.file.Seek(0, io.SeekCurrent)
+	}
+
+	var dirPath string
+	if c.sd.dirOpen {
+		dirPath = c.sdRelativePath(c.sd.dirPath)
+	}
+
+	state := miaSDChipState{
+		Initialized:     c.sd.initialized,
+		Mounted:         c.sd.mounted,
+		FileOpen:        c.sd.fileOpen,
+		DirOpen:         c.sd.dirOpen,
+		EOF:             c.sd.eof,
+		LastError:       c.sd.lastError,
+		LastFatfsResult: c.sd.lastFatfsResult,
+		CardType:        c.sd.cardType,
+		CurrentOpenMode: c.sd.currentOpenMode,
+		Sectors:         c.sd.sectors,
+		FilePosition:    filePosition,
+		DirIndex:        uint32(c.sd.dirIndex),
+		RawSectorCount:  uint32(len(c.sd.rawSectors)),
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	if err := writeStateString(writer, filePath); err != nil {
+		return err
+	}
+
+	if err := writeStateString(writer, dirPath); err != nil {
+		return err
+	}
+
+	// Sorted to always produce the same state for the same contents
+	lbas := make([]uint32, 0, len(c.sd.rawSectors))
+	for lba := range c.sd.rawSectors {
+		lbas = append(lbas, lba)
+	}
+	slices.Sort(lbas)
+
+	for _, lba := range lbas {
+		if err := binary.Write(writer, binary.LittleEndian, lba); err != nil {
+			return err
+		}
+
+		if _, err := writer.Write(c.sd.rawSectors[lba]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sdLoadState restores the status of the SD card, opening again the file and directory
+// that were open when the state was saved. The registers must be restored before as the
+// SD status is published to them.
+func (c *emulated_mia) sdLoadState(reader io.Reader) error {
+	var state miaSDChipState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	filePath, err := readStateString(reader)
+	if err != nil {
+		return err
+	}
+
+	dirPath, err := readStateString(reader)
+	if err != nil {
+		return err
+	}
+
+	rawSectors := make(map[uint32][]uint8, state.RawSectorCount)
+	for range state.RawSectorCount {
+		var lba uint32
+		if err := binary.Read(reader, binary.LittleEndian, &lba); err != nil {
+			return err
+		}
+
+		sector := make([]uint8, miaSDSectorSize)
+		if _, err := io.ReadFull(reader, sector); err != nil {
+			return fmt.Errorf("error loading SD sector %d: %w", lba, err)
+		}
+
+		rawSectors[lba] = sector
+	}
+
+	c.sdCloseFile()
+	c.sdCloseDir()
+
+	c.sd.initialized = state.Initialized
+	c.sd.mounted = state.Mounted
+	c.sd.eof = state.EOF
+	c.sd.lastError = state.LastError
+	c.sd.lastFatfsResult = state.LastFatfsResult
+	c.sd.cardType = state.CardType
+	c.sd.currentOpenMode = state.CurrentOpenMode
+	c.sd.sectors = state.Sectors
+	c.sd.rawSectors = rawSectors
+
+	if state.FileOpen {
+		c.sdReopenFile(filePath, state.FilePosition)
+	}
+
+	if state.DirOpen {
+		c.sdReopenDir(dirPath, int(state.DirIndex))
+	}
+
+	// Reflects the file or directory that couldn't be opened again
+	c.sdPublishState()
+
+	return nil
+}
+
+// sdReopenFile opens again a file of the SD folder with the current open mode, without
+// truncating it, and moves to the specified position.
+func (c *emulated_mia) sdReopenFile(relativePath string, position int64) {
+	flag, ok := sdOpenModeToFlag(c.sd.currentOpenMode)
+	if !ok || c.sd.rootDir == "" {
+		return
+	}
+
+	file, err := os.OpenFile(filepath.Join(c.sd.rootDir, filepath.FromSlash(relativePath)), flag&^os.O_TRUNC, 0o644)
+	if err != nil {
+		return
+	}
+
+	if _, err := file.Seek(position, io.SeekStart); err != nil {
+		_ = file.Close()
+		return
+	}
+
+	c.sd.file = file
+	c.sd.fileOpen = true
+}
+
+// sdReopenDir reads again the entries of a directory of the SD folder and moves to the
+// specified entry.
+func (c *emulated_mia) sdReopenDir(relativePath string, index int) {
+	if c.sd.rootDir == "" {
+		return
+	}
+
+	hostPath := filepath.Join(c.sd.rootDir, filepath.FromSlash(relativePath))
+
+	entries, err := os.ReadDir(hostPath)
+	if err != nil {
+		return
+	}
+
+	c.sd.dirPath = hostPath
+	c.sd.dirEntries = entries
+	c.sd.dirIndex = min(index, len(entries))
+	c.sd.dirOpen = true
+}
+
+// sdRelativePath returns the host path relative to the SD folder using forward slashes.
+func (c *emulated_mia) sdRelativePath(hostPath string) string {
+	relativePath, err := filepath.Rel(c.sd.rootDir, hostPath)
+	if err != nil {
+		return ""
+	}
+
+	return filepath.ToSlash(relativePath)
+}
+
+// writeStateString writes the length of the string followed by its contents.
+func writeStateString(writer io.Writer, value string) error {
+	if err := binary.Write(writer, binary.LittleEndian, uint16(len(value))); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(writer, value)
+	return err
+}
+
+// readStateString reads a string written by writeStateString.
+func readStateString(reader io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
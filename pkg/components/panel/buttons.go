@@ -0,0 +1,79 @@
+package panel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+)
+
+// ParsePin returns the pin of the VIA with the specified name, PA0 to PA7, PB0 to PB7 or one of
+// the control lines CA1, CA2, CB1 and CB2. Names are not case sensitive.
+//
+// Parameters:
+//   - name: The name of the pin
+//
+// Returns:
+//   - The pin
+//   - An error if there is no pin with the name
+func ParsePin(name string) (components.PanelPin, error) {
+	upper := strings.ToUpper(strings.TrimSpace(name))
+
+	for pin := range components.PanelPinCount {
+		if components.PanelPin(pin).String() == upper {
+			return components.PanelPin(pin), nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown pin %q, expected PA0-PA7, PB0-PB7, CA1, CA2, CB1 or CB2", name)
+}
+
+// ParseButton parses the specification of a button as KEY=PIN[,toggle][,high], where KEY is the
+// character of the host key that presses the button and PIN the pin of the VIA it's connected
+// to. Buttons are momentary and active low unless toggle or high are specified.
+//
+// Parameters:
+//   - spec: The specification of the button
+//
+// Returns:
+//   - The button
+//   - An error if the specification is not valid
+func ParseButton(spec string) (components.PanelButton, error) {
+	var button components.PanelButton
+
+	// The key is decoded first as it can be the = character
+	char, size := utf8.DecodeRuneInString(spec)
+	if size == 0 || !strings.HasPrefix(spec[size:], "=") {
+		return button, fmt.Errorf("invalid button %q, expected KEY=PIN[,toggle][,high] with a single character key", spec)
+	}
+
+	if !unicode.IsPrint(char) {
+		return button, fmt.Errorf("invalid key %s of button %q", strconv.QuoteRune(char), spec)
+	}
+
+	options := strings.Split(spec[size+1:], ",")
+
+	pin, err := ParsePin(options[0])
+	if err != nil {
+		return button, err
+	}
+
+	button.Key = char
+	button.Pin = pin
+
+	for _, option := range options[1:] {
+		switch strings.ToLower(strings.TrimSpace(option)) {
+		case "toggle":
+			button.Toggle = true
+		case "high":
+			button.ActiveHigh = true
+		default:
+			return button, fmt.Errorf("unknown option %q of button %q, expected toggle or high", option, spec)
+		}
+	}
+
+	return button, nil
+}
@@ -0,0 +1,337 @@
+package panel
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+)
+
+// Time a momentary button is held after its key is pressed, terminals don't report when keys
+// are released
+const pressTime int64 = int64(100 * time.Millisecond)
+
+// buttonPin is a pin of the VIA with buttons connected. The pin is only driven by the panel
+// while it's an input, and only when its level changes, so other devices connected to the same
+// pins can drive them while the buttons are not used.
+type buttonPin struct {
+	pin     components.PanelPin
+	buttons []int // Index of the buttons connected to the pin
+	idle    bool  // Level of the pin when no button is pressed
+
+	driven bool // The panel drove the pin since it became an input
+	level  bool // Last level the panel drove the pin to
+}
+
+// ioPanel emulates a panel of LEDs and push buttons attached to the ports and control lines of a
+// VIA. The LEDs show the level of the pins the VIA drives as outputs and the buttons drive the
+// pins configured as inputs. Buttons are active low, the pin is pulled up and pressing the button
+// connects it to ground, unless they are configured as active high.
+type ioPanel struct {
+	via     components.ViaPorts
+	buttons []components.PanelButton
+	pins    []*buttonPin
+
+	mutex        sync.Mutex            // Guards the presses and the handler, set from the UI
+	pending      atomic.Int32          // Number of presses, read by the tick without the mutex
+	presses      []uint8               // Buttons pressed since the last tick
+	pressHandler func(buttons []uint8) // Receives the buttons of the keys instead of the presses
+
+	pressed   []bool  // The button is pressed
+	releaseAt []int64 // Time when each momentary button is released
+	holding   int     // Number of momentary buttons pressed
+}
+
+// NewIOPanel creates a new I/O panel attached to the ports and control lines of the VIA.
+//
+// Parameters:
+//   - via: The VIA the panel is attached to
+//   - buttons: The buttons of the panel
+//
+// Returns:
+//   - The I/O panel
+//   - An error if a button is connected to a pin that is not connected to the circuit
+func NewIOPanel(via components.ViaPorts, buttons []components.PanelButton) (components.IOPanel, error) {
+	return newIOPanel(via, buttons)
+}
+
+// newIOPanel creates a new I/O panel grouping the buttons by the pin they are connected to.
+func newIOPanel(via components.ViaPorts, buttons []components.PanelButton) (*ioPanel, error) {
+	panel := &ioPanel{
+		via:       via,
+		buttons:   slices.Clone(buttons),
+		pressed:   make([]bool, len(buttons)),
+		releaseAt: make([]int64, len(buttons)),
+	}
+
+	for index, button := range buttons {
+		if int(button.Pin) >= components.PanelPinCount {
+			return nil, fmt.Errorf("the button of the key %q is connected to an unknown pin", button.Key)
+		}
+
+		if !panel.GetPinStatus(button.Pin).Connected {
+			return nil, fmt.Errorf("%s is not connected to the circuit", button.Pin)
+		}
+
+		panel.addButton(index, button)
+	}
+
+	return panel, nil
+}
+
+// addButton adds the button to the buttons of its pin. The pin is pulled up if any of its
+// buttons is active low.
+func (p *ioPanel) addButton(index int, button components.PanelButton) {
+	for _, pin := range p.pins {
+		if pin.pin == button.Pin {
+			pin.buttons = append(pin.buttons, index)
+			pin.idle = pin.idle || !button.ActiveHigh
+			return
+		}
+	}
+
+	p.pins = append(p.pins, &buttonPin{
+		pin:     button.Pin,
+		buttons: []int{index},
+		idle:    !button.ActiveHigh,
+	})
+}
+
+/************************************************************************************
+* Getters / Setters
+*************************************************************************************/
+
+// Buttons returns the buttons of the panel.
+func (p *ioPanel) Buttons() []components.PanelButton {
+	return p.buttons
+}
+
+// IsPressed returns true if the button with the specified index is pressed.
+func (p *ioPanel) IsPressed(button int) bool {
+	return button >= 0 && button < len(p.pressed) && p.pressed[button]
+}
+
+// IsBusy returns true while there are presses waiting to be applied or a momentary button
+// is held.
+func (p *ioPanel) IsBusy() bool {
+	return p.holding > 0 || p.pending.Load() > 0
+}
+
+// GetPinStatus returns if the pin is connected, if the VIA drives it as an output and its level.
+func (p *ioPanel) GetPinStatus(pin components.PanelPin) components.PanelPinStatus {
+	switch {
+	case pin < components.PanelPortB:
+		return portPinStatus(p.via.PeripheralPortA(), uint8(pin-components.PanelPortA), p.via.GetDataDirectionRegisterA())
+	case pin < components.PanelCA1:
+		return portPinStatus(p.via.PeripheralPortB(), uint8(pin-components.PanelPortB), p.via.GetDataDirectionRegisterB())
+	case int(pin) < components.PanelPinCount:
+		line := p.controlLine(pin)
+
+		return components.PanelPinStatus{
+			Connected: line.GetLine() != nil,
+			Output:    p.isControlLineOutput(pin),
+			Level:     line.Enabled(),
+		}
+	default:
+		return components.PanelPinStatus{}
+	}
+}
+
+// portPinStatus returns the status of the pin of the port, it's an output if its bit of the data
+// direction register is set.
+func portPinStatus(port *buses.BusConnector[uint8], bit uint8, direction uint8) components.PanelPinStatus {
+	mask := uint8(1) << bit
+
+	return components.PanelPinStatus{
+		Connected: port.IsConnected(),
+		Output:    direction&mask != 0,
+		Level:     port.Read()&mask != 0,
+	}
+}
+
+// controlLine returns the connector of the VIA to the control line.
+func (p *ioPanel) controlLine(pin components.PanelPin) *buses.ConnectorEnabledHigh {
+	switch pin {
+	case components.PanelCA1, components.PanelCA2:
+		return p.via.PeripheralAControlLines(int(pin - components.PanelCA1))
+	default:
+		return p.via.PeripheralBControlLines(int(pin - components.PanelCB1))
+	}
+}
+
+// isControlLineOutput returns true if the VIA drives the control line. CA1 is always an input,
+// CA2 and CB2 are outputs when set by the peripheral control register, CB1 is the clock output
+// of the shift register when it's shifted by the timer 2 or the system clock and CB2 its data
+// output when it's shifting out.
+func (p *ioPanel) isControlLineOutput(pin components.PanelPin) bool {
+	shiftMode := (p.via.GetAuxiliaryControl() >> 2) & 0x07
+
+	switch pin {
+	case components.PanelCA2:
+		return p.via.GetPeripheralControl()&0x08 != 0
+	case components.PanelCB1:
+		return shiftMode != 0 && shiftMode != 3 && shiftMode != 7
+	case components.PanelCB2:
+		return p.via.GetPeripheralControl()&0x80 != 0 || shiftMode >= 4
+	default:
+		return false
+	}
+}
+
+/************************************************************************************
+* Input
+*************************************************************************************/
+
+// SetPressHandler routes the buttons pressed with the keys of the host to the handler instead
+// of pressing them, nil presses them as soon as the keys are pressed. The handler is called from
+// the goroutine that presses the keys.
+func (p *ioPanel) SetPressHandler(handler func(buttons []uint8)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.pressHandler = handler
+}
+
+// PressKey presses the buttons of the key, they are passed to the press handler if set. Keys
+// without buttons are ignored.
+func (p *ioPanel) PressKey(key rune) {
+	var buttons []uint8
+
+	for index, button := range p.buttons {
+		if button.Key == key {
+			buttons = append(buttons, uint8(index))
+		}
+	}
+
+	if len(buttons) == 0 {
+		return
+	}
+
+	p.mutex.Lock()
+	handler := p.pressHandler
+	p.mutex.Unlock()
+
+	if handler != nil {
+		handler(buttons)
+		return
+	}
+
+	p.PressButtons(buttons)
+}
+
+// PressButtons presses the buttons with the specified indexes on the next tick. Momentary
+// buttons are held for 100 ms and toggle buttons change between pressed and released.
+func (p *ioPanel) PressButtons(buttons []uint8) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.presses = append(p.presses, buttons...)
+	p.pending.Store(int32(len(p.presses)))
+}
+
+// takePresses removes and returns the presses waiting to be applied.
+func (p *ioPanel) takePresses() []uint8 {
+	if p.pending.Load() == 0 {
+		return nil
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	presses := p.presses
+	p.presses = nil
+	p.pending.Store(0)
+
+	return presses
+}
+
+/************************************************************************************
+* Tick methods
+*************************************************************************************/
+
+// Tick applies the buttons pressed, releases the momentary buttons held for long enough and
+// drives the input pins whose level changed.
+func (p *ioPanel) Tick(context *common.StepContext) {
+	for _, index := range p.takePresses() {
+		p.press(int(index), context.T)
+	}
+
+	if p.holding > 0 {
+		p.releaseMomentaryButtons(context.T)
+	}
+
+	for _, pin := range p.pins {
+		p.drivePin(pin)
+	}
+}
+
+// press presses the button, momentary buttons are released pressTime after the last press.
+// Unknown buttons are ignored.
+func (p *ioPanel) press(index int, t int64) {
+	if index >= len(p.buttons) {
+		return
+	}
+
+	if p.buttons[index].Toggle {
+		p.pressed[index] = !p.pressed[index]
+		return
+	}
+
+	if !p.pressed[index] {
+		p.pressed[index] = true
+		p.holding++
+	}
+
+	p.releaseAt[index] = t + pressTime
+}
+
+// releaseMomentaryButtons releases the momentary buttons held until the current time.
+func (p *ioPanel) releaseMomentaryButtons(t int64) {
+	for index, button := range p.buttons {
+		if !button.Toggle && p.pressed[index] && t >= p.releaseAt[index] {
+			p.pressed[index] = false
+			p.holding--
+		}
+	}
+}
+
+// drivePin sets the level of the pin from its buttons if it's an input and the level changed
+// since it was last driven.
+func (p *ioPanel) drivePin(pin *buttonPin) {
+	if p.GetPinStatus(pin.pin).Output {
+		pin.driven = false
+		return
+	}
+
+	level := pin.idle
+	for _, index := range pin.buttons {
+		if p.pressed[index] {
+			level = p.buttons[index].ActiveHigh
+		}
+	}
+
+	if pin.driven && pin.level == level {
+		return
+	}
+
+	p.setPin(pin.pin, level)
+	pin.driven = true
+	pin.level = level
+}
+
+// setPin sets the level of the line connected to the pin.
+func (p *ioPanel) setPin(pin components.PanelPin, level bool) {
+	switch {
+	case pin < components.PanelPortB:
+		p.via.PeripheralPortA().GetLine(uint8(pin - components.PanelPortA)).Set(level)
+	case pin < components.PanelCA1:
+		p.via.PeripheralPortB().GetLine(uint8(pin - components.PanelPortB)).Set(level)
+	default:
+		p.controlLine(pin).SetEnable(level)
+	}
+}
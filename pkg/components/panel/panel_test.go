@@ -0,0 +1,254 @@
+package panel
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// VIA with its ports and control lines connected to standalone buses and lines, the direction
+// of the pins and the control registers are set directly
+type testVia struct {
+	portA        *buses.BusConnector[uint8]
+	portB        *buses.BusConnector[uint8]
+	controlLines [4]*buses.ConnectorEnabledHigh
+
+	ddra uint8
+	ddrb uint8
+	acr  uint8
+	pcr  uint8
+}
+
+// Creates the VIA connecting all the ports and control lines but CB2
+func newTestVia() *testVia {
+	via := &testVia{
+		portA: buses.NewBusConnector[uint8](),
+		portB: buses.NewBusConnector[uint8](),
+	}
+
+	via.portA.Connect(buses.New8BitStandaloneBus())
+	via.portB.Connect(buses.New8BitStandaloneBus())
+
+	for i := range via.controlLines {
+		via.controlLines[i] = buses.NewConnectorEnabledHigh()
+	}
+
+	for i := range 3 {
+		via.controlLines[i].Connect(buses.NewStandaloneLine(false))
+	}
+
+	return via
+}
+
+func (v *testVia) PeripheralPortA() *buses.BusConnector[uint8] { return v.portA }
+func (v *testVia) PeripheralPortB() *buses.BusConnector[uint8] { return v.portB }
+func (v *testVia) PeripheralAControlLines(num int) *buses.ConnectorEnabledHigh {
+	return v.controlLines[num]
+}
+func (v *testVia) PeripheralBControlLines(num int) *buses.ConnectorEnabledHigh {
+	return v.controlLines[2+num]
+}
+func (v *testVia) GetOutputRegisterA() uint8        { return 0 }
+func (v *testVia) GetOutputRegisterB() uint8        { return 0 }
+func (v *testVia) GetInputRegisterA() uint8         { return 0 }
+func (v *testVia) GetInputRegisterB() uint8         { return 0 }
+func (v *testVia) GetDataDirectionRegisterA() uint8 { return v.ddra }
+func (v *testVia) GetDataDirectionRegisterB() uint8 { return v.ddrb }
+func (v *testVia) GetLowLatches2() uint8            { return 0 }
+func (v *testVia) GetLowLatches1() uint8            { return 0 }
+func (v *testVia) GetHighLatches2() uint8           { return 0 }
+func (v *testVia) GetHighLatches1() uint8           { return 0 }
+func (v *testVia) GetCounter2() uint16              { return 0 }
+func (v *testVia) GetCounter1() uint16              { return 0 }
+func (v *testVia) GetShiftRegister() uint8          { return 0 }
+func (v *testVia) GetAuxiliaryControl() uint8       { return v.acr }
+func (v *testVia) GetPeripheralControl() uint8      { return v.pcr }
+func (v *testVia) GetInterruptFlagValue() uint8     { return 0 }
+func (v *testVia) GetInterruptEnabledFlag() uint8   { return 0 }
+
+// Ticks the panel advancing the time 10 ms on each tick
+func tickPanel(panel *ioPanel, context *common.StepContext, ticks int) {
+	for range ticks {
+		panel.Tick(context)
+		context.T += 10_000_000
+	}
+}
+
+var testButtons []components.PanelButton = []components.PanelButton{
+	{Key: '0', Pin: components.PanelPortA},
+	{Key: '1', Pin: components.PanelPortA + 1, ActiveHigh: true},
+	{Key: 't', Pin: components.PanelPortB + 7, Toggle: true, ActiveHigh: true},
+	{Key: 'i', Pin: components.PanelCA1},
+}
+
+func TestPanelMomentaryButtonsAreHeld(t *testing.T) {
+	via := newTestVia()
+	panel, err := newIOPanel(via, testButtons)
+	require.NoError(t, err)
+
+	context := common.NewStepContext()
+
+	// Active low pins are pulled up
+	tickPanel(panel, &context, 1)
+	assert.Equal(t, uint8(0x01), via.portA.Read())
+	assert.True(t, via.controlLines[0].Enabled())
+
+	panel.PressKey('0')
+	panel.PressKey('i')
+	assert.True(t, panel.IsBusy())
+
+	tickPanel(panel, &context, 1)
+	assert.True(t, panel.IsPressed(0))
+	assert.Equal(t, uint8(0x00), via.portA.Read())
+	assert.False(t, via.controlLines[0].Enabled())
+
+	// Released after 100 ms
+	tickPanel(panel, &context, 10)
+	assert.False(t, panel.IsPressed(0))
+	assert.False(t, panel.IsBusy())
+	assert.Equal(t, uint8(0x01), via.portA.Read())
+	assert.True(t, via.controlLines[0].Enabled())
+
+	panel.PressKey('1')
+	tickPanel(panel, &context, 1)
+	assert.Equal(t, uint8(0x03), via.portA.Read())
+
+	// Keys without buttons are ignored
+	panel.PressKey('x')
+	assert.Equal(t, 0, int(panel.pending.Load()))
+}
+
+func TestPanelToggleButtonsAndOutputs(t *testing.T) {
+	via := newTestVia()
+	panel, err := newIOPanel(via, testButtons)
+	require.NoError(t, err)
+
+	context := common.NewStepContext()
+
+	panel.PressKey('t')
+	tickPanel(panel, &context, 20)
+	assert.True(t, panel.IsPressed(2))
+	assert.False(t, panel.IsBusy())
+	assert.Equal(t, uint8(0x80), via.portB.Read())
+
+	// The VIA drives the pin while it's an output
+	via.ddrb = 0x80
+	via.portB.Write(0x00)
+	panel.PressKey('t')
+	tickPanel(panel, &context, 1)
+	assert.False(t, panel.IsPressed(2))
+	assert.Equal(t, components.PanelPinStatus{Connected: true, Output: true, Level: false},
+		panel.GetPinStatus(components.PanelPortB+7))
+
+	panel.PressKey('t')
+	tickPanel(panel, &context, 1)
+	assert.Equal(t, uint8(0x00), via.portB.Read())
+
+	// Driven again when it's back to an input
+	via.ddrb = 0x00
+	tickPanel(panel, &context, 1)
+	assert.Equal(t, uint8(0x80), via.portB.Read())
+
+	// Other devices can change the pin until the button changes
+	via.portB.Write(0x00)
+	tickPanel(panel, &context, 1)
+	assert.Equal(t, uint8(0x00), via.portB.Read())
+}
+
+func TestPanelControlLines(t *testing.T) {
+	via := newTestVia()
+
+	_, err := NewIOPanel(via, []components.PanelButton{{Key: 'a', Pin: components.PanelCB2}})
+	assert.Error(t, err)
+
+	panel, err := newIOPanel(via, nil)
+	require.NoError(t, err)
+
+	assert.False(t, panel.GetPinStatus(components.PanelCB2).Connected)
+	assert.False(t, panel.GetPinStatus(components.PanelCA2).Output)
+	assert.False(t, panel.GetPinStatus(components.PanelCB1).Output)
+
+	via.pcr = 0x0E
+	via.acr = 0x18
+	via.controlLines[1].SetEnable(true)
+
+	assert.Equal(t, components.PanelPinStatus{Connected: true, Output: true, Level: true},
+		panel.GetPinStatus(components.PanelCA2))
+	assert.True(t, panel.GetPinStatus(components.PanelCB1).Output)
+	assert.True(t, panel.GetPinStatus(components.PanelCB2).Output)
+	assert.False(t, panel.GetPinStatus(components.PanelCA1).Output)
+}
+
+func TestPanelPressHandler(t *testing.T) {
+	panel, err := newIOPanel(newTestVia(), testButtons)
+	require.NoError(t, err)
+
+	var handled []uint8
+	panel.SetPressHandler(func(buttons []uint8) {
+		handled = append(handled, buttons...)
+	})
+
+	panel.PressKey('t')
+	assert.Equal(t, []uint8{2}, handled)
+	assert.False(t, panel.IsBusy())
+
+	panel.SetPressHandler(nil)
+	panel.PressKey('t')
+	assert.True(t, panel.IsBusy())
+}
+
+func TestPanelStateRestoresButtons(t *testing.T) {
+	via := newTestVia()
+	panel, err := newIOPanel(via, testButtons)
+	require.NoError(t, err)
+
+	context := common.NewStepContext()
+
+	panel.PressKey('0')
+	panel.PressKey('t')
+	tickPanel(panel, &context, 1)
+	panel.PressKey('t')
+
+	var state bytes.Buffer
+	require.NoError(t, panel.SaveState(&state))
+
+	restored, err := newIOPanel(via, testButtons)
+	require.NoError(t, err)
+	require.NoError(t, restored.LoadState(bytes.NewReader(state.Bytes())))
+
+	assert.True(t, restored.IsPressed(0))
+	assert.True(t, restored.IsPressed(2))
+	assert.True(t, restored.IsBusy())
+
+	tickPanel(restored, &context, 10)
+	assert.False(t, restored.IsPressed(0))
+	assert.False(t, restored.IsPressed(2))
+	assert.False(t, restored.IsBusy())
+
+	other, err := newIOPanel(via, testButtons[:1])
+	require.NoError(t, err)
+	assert.Error(t, other.LoadState(bytes.NewReader(state.Bytes())))
+}
+
+func TestParseButton(t *testing.T) {
+	button, err := ParseButton("a=pa3")
+	require.NoError(t, err)
+	assert.Equal(t, components.PanelButton{Key: 'a', Pin: components.PanelPortA + 3}, button)
+
+	button, err = ParseButton("==CB1,toggle,HIGH")
+	require.NoError(t, err)
+	assert.Equal(t, components.PanelButton{Key: '=', Pin: components.PanelCB1, Toggle: true, ActiveHigh: true}, button)
+
+	for _, spec := range []string{"a", "=PA0", "ab=PA0", "a=PA8", "a=PC0", "a=PA0,latch"} {
+		_, err := ParseButton(spec)
+		assert.Error(t, err, spec)
+	}
+
+	assert.Equal(t, "PB5", (components.PanelPortB + 5).String())
+	assert.Equal(t, "CA2", components.PanelCA2.String())
+}
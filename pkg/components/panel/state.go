@@ -0,0 +1,119 @@
+package panel
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Sizes of the state of the panel. This struct is written and read with encoding/binary, so it
+// must only have fixed size fields.
+type panelState struct {
+	Buttons    uint32
+	Pins       uint32
+	PressCount uint32
+}
+
+// Status of a button. This struct is written and read with encoding/binary, so it must only have
+// fixed size fields.
+type buttonState struct {
+	Pressed   bool
+	ReleaseAt int64
+}
+
+// Level driven on a pin. This struct is written and read with encoding/binary, so it must only
+// have fixed size fields.
+type pinState struct {
+	Driven bool
+	Level  bool
+}
+
+// SaveState writes the status of the buttons, the levels driven on their pins and the presses
+// waiting to be applied.
+func (p *ioPanel) SaveState(writer io.Writer) error {
+	p.mutex.Lock()
+	presses := p.presses
+	p.mutex.Unlock()
+
+	state := panelState{
+		Buttons:    uint32(len(p.buttons)),
+		Pins:       uint32(len(p.pins)),
+		PressCount: uint32(len(presses)),
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	for index := range p.buttons {
+		button := buttonState{Pressed: p.pressed[index], ReleaseAt: p.releaseAt[index]}
+
+		if err := binary.Write(writer, binary.LittleEndian, &button); err != nil {
+			return err
+		}
+	}
+
+	for _, pin := range p.pins {
+		if err := binary.Write(writer, binary.LittleEndian, &pinState{Driven: pin.driven, Level: pin.level}); err != nil {
+			return err
+		}
+	}
+
+	_, err := writer.Write(presses)
+
+	return err
+}
+
+// LoadState restores the status of the panel previously written by SaveState, replacing the
+// presses waiting to be applied. The panel must have the same buttons as when it was saved.
+func (p *ioPanel) LoadState(reader io.Reader) error {
+	var state panelState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	if int(state.Buttons) != len(p.buttons) || int(state.Pins) != len(p.pins) {
+		return fmt.Errorf("the state has %d panel buttons on %d pins, the panel has %d buttons on %d pins",
+			state.Buttons, state.Pins, len(p.buttons), len(p.pins))
+	}
+
+	buttons := make([]buttonState, state.Buttons)
+	if err := binary.Read(reader, binary.LittleEndian, buttons); err != nil {
+		return err
+	}
+
+	pins := make([]pinState, state.Pins)
+	if err := binary.Read(reader, binary.LittleEndian, pins); err != nil {
+		return err
+	}
+
+	presses := make([]uint8, state.PressCount)
+	if _, err := io.ReadFull(reader, presses); err != nil {
+		return err
+	}
+
+	p.holding = 0
+
+	for index, button := range buttons {
+		p.pressed[index] = button.Pressed
+		p.releaseAt[index] = button.ReleaseAt
+
+		if button.Pressed && !p.buttons[index].Toggle {
+			p.holding++
+		}
+	}
+
+	for index, pin := range pins {
+		p.pins[index].driven = pin.Driven
+		p.pins[index].level = pin.Level
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.presses = presses
+	p.pending.Store(int32(len(presses)))
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package ps2
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+)
+
+// Timing of the transmission of the scan codes
+const (
+	frameTime int64 = int64(880 * time.Microsecond) // 11 bits (start, 8 data, parity and stop) at 12.5 kHz
+	pulseTime int64 = int64(50 * time.Microsecond)  // Duration of the pulse on the interrupt line
+	byteGap   int64 = int64(500 * time.Microsecond) // Time from the end of a frame to the start of the next one
+)
+
+// keyboard emulates a PS/2 keyboard connected through the shift registers of Ben Eater's keyboard
+// interface. The keyboard sends each scan code in a frame of 11 bits that is shifted into the
+// registers, when the frame is complete the scan code is presented on the data bus and the
+// interrupt line goes high for 50 us. Frames are sent one at a time, in the order received,
+// with a gap of 500 us between them.
+type keyboard struct {
+	dataBus   *buses.BusConnector[uint8]
+	interrupt *buses.ConnectorEnabledHigh
+
+	mutex      sync.Mutex          // Guards the queue and the key handler, both set from the UI
+	pending    atomic.Int32        // Length of the queue, checked by each tick before locking
+	queue      []uint8             // Scan codes typed and not sent yet
+	keyHandler func(codes []uint8) // Receives the typed scan codes instead of the queue
+
+	sending    bool  // A frame is being sent
+	current    uint8 // Scan code of the frame being sent
+	frameStart int64 // Time when the frame being sent started
+	pulsing    bool  // The interrupt line is high
+	latchTime  int64 // Time when the last scan code was presented on the data bus
+}
+
+// NewKeyboard creates a new PS/2 keyboard with no keys pressed.
+func NewKeyboard() components.PS2Keyboard {
+	return newKeyboard()
+}
+
+// newKeyboard creates a new PS/2 keyboard initializing its bus connector and interrupt line.
+func newKeyboard() *keyboard {
+	return &keyboard{
+		dataBus:   buses.NewBusConnector[uint8](),
+		interrupt: buses.NewConnectorEnabledHigh(),
+	}
+}
+
+/************************************************************************************
+* Getters / Setters
+*************************************************************************************/
+
+// DataBus returns the connector to the outputs of the shift registers, where the last scan
+// code received is presented.
+func (k *keyboard) DataBus() *buses.BusConnector[uint8] {
+	return k.dataBus
+}
+
+// Interrupt returns the connector to the line that is pulsed high when a scan code is
+// presented on the data bus.
+func (k *keyboard) Interrupt() *buses.ConnectorEnabledHigh {
+	return k.interrupt
+}
+
+// IsBusy returns true while there are scan codes waiting to be sent, a frame is being sent or
+// the interrupt line is high.
+func (k *keyboard) IsBusy() bool {
+	return k.sending || k.pulsing || k.pending.Load() > 0
+}
+
+/************************************************************************************
+* Input
+*************************************************************************************/
+
+// SetKeyHandler routes the scan codes of the keys typed to the handler instead of sending
+// them, nil sends them as soon as they are typed. The handler is called from the goroutine
+// that types the keys.
+func (k *keyboard) SetKeyHandler(handler func(codes []uint8)) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.keyHandler = handler
+}
+
+// TypeScanCodes handles the scan codes of keys typed by the user. They are passed to the key
+// handler if set, otherwise they are sent.
+func (k *keyboard) TypeScanCodes(codes []uint8) {
+	k.mutex.Lock()
+	handler := k.keyHandler
+	k.mutex.Unlock()
+
+	if handler != nil {
+		handler(slices.Clone(codes))
+		return
+	}
+
+	k.SendScanCodes(codes)
+}
+
+// SendScanCodes adds the scan codes to the ones waiting to be sent by the keyboard.
+func (k *keyboard) SendScanCodes(codes []uint8) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.queue = append(k.queue, codes...)
+	k.pending.Store(int32(len(k.queue)))
+}
+
+// nextScanCode removes and returns the first scan code waiting to be sent, false if there is none.
+func (k *keyboard) nextScanCode() (uint8, bool) {
+	if k.pending.Load() == 0 {
+		return 0, false
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if len(k.queue) == 0 {
+		return 0, false
+	}
+
+	code := k.queue[0]
+	k.queue = k.queue[1:]
+	k.pending.Store(int32(len(k.queue)))
+
+	return code, true
+}
+
+/************************************************************************************
+* Tick methods
+*************************************************************************************/
+
+// Tick advances the transmission of the scan codes. The interrupt line is lowered at the end of
+// the pulse, and when a frame completes its scan code is presented on the data bus and the line
+// is raised. The pulse lasts at least one tick so the edge is always observed.
+func (k *keyboard) Tick(context *common.StepContext) {
+	if k.pulsing && context.T >= k.latchTime+pulseTime {
+		k.interrupt.SetEnable(false)
+		k.pulsing = false
+	}
+
+	if k.sending {
+		if context.T >= k.frameStart+frameTime {
+			k.dataBus.Write(k.current)
+			k.interrupt.SetEnable(true)
+
+			k.sending = false
+			k.pulsing = true
+			k.latchTime = context.T
+		}
+
+		return
+	}
+
+	if context.T < k.latchTime+byteGap {
+		return
+	}
+
+	if code, ok := k.nextScanCode(); ok {
+		k.sending = true
+		k.current = code
+		k.frameStart = context.T
+	}
+}
@@ -0,0 +1,139 @@
+package ps2
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Circuit to test the keyboard, each tick takes 10 us
+type keyboardTestCircuit struct {
+	portA     buses.Bus[uint8]
+	interrupt *buses.StandaloneLine
+
+	keyboard *keyboard
+	context  common.StepContext
+}
+
+// Creates the test circuit and connects the keyboard to it
+func newKeyboardTestCircuit() *keyboardTestCircuit {
+	circuit := &keyboardTestCircuit{
+		portA:     buses.New8BitStandaloneBus(),
+		interrupt: buses.NewStandaloneLine(false),
+		keyboard:  newKeyboard(),
+		context:   common.NewStepContext(),
+	}
+
+	circuit.keyboard.DataBus().Connect(circuit.portA)
+	circuit.keyboard.Interrupt().Connect(circuit.interrupt)
+
+	return circuit
+}
+
+// Ticks the keyboard until it's idle and returns the values of the port on each rising
+// edge of the interrupt line
+func (circuit *keyboardTestCircuit) receive(t *testing.T) []uint8 {
+	var received []uint8
+
+	previous := circuit.interrupt.Status()
+
+	for range 100_000 {
+		circuit.keyboard.Tick(&circuit.context)
+		circuit.context.T += 10_000
+
+		if status := circuit.interrupt.Status(); status && !previous {
+			received = append(received, circuit.portA.Read())
+		}
+
+		previous = circuit.interrupt.Status()
+
+		if !circuit.keyboard.IsBusy() {
+			return received
+		}
+	}
+
+	t.Fatal("the keyboard did not send all the scan codes")
+
+	return nil
+}
+
+func TestKeyboardSendsScanCodesWithInterruptPulses(t *testing.T) {
+	circuit := newKeyboardTestCircuit()
+
+	assert.False(t, circuit.keyboard.IsBusy())
+
+	circuit.keyboard.SendScanCodes([]uint8{0x1C, 0xF0, 0x1C})
+	assert.True(t, circuit.keyboard.IsBusy())
+
+	start := circuit.context.T
+	assert.Equal(t, []uint8{0x1C, 0xF0, 0x1C}, circuit.receive(t))
+	assert.False(t, circuit.interrupt.Status())
+
+	// 3 frames, the gaps between them and the last pulse
+	assert.GreaterOrEqual(t, circuit.context.T-start, 3*frameTime+2*byteGap+pulseTime)
+}
+
+func TestKeyboardTypedKeysGoToTheHandler(t *testing.T) {
+	circuit := newKeyboardTestCircuit()
+
+	var handled []uint8
+	circuit.keyboard.SetKeyHandler(func(codes []uint8) {
+		handled = append(handled, codes...)
+	})
+
+	circuit.keyboard.TypeScanCodes([]uint8{0x5A, 0xF0, 0x5A})
+	assert.Equal(t, []uint8{0x5A, 0xF0, 0x5A}, handled)
+	assert.False(t, circuit.keyboard.IsBusy())
+
+	circuit.keyboard.SetKeyHandler(nil)
+	circuit.keyboard.TypeScanCodes([]uint8{0x29})
+	assert.Equal(t, []uint8{0x29}, circuit.receive(t))
+}
+
+func TestKeyboardStateRestoresTransmission(t *testing.T) {
+	circuit := newKeyboardTestCircuit()
+	circuit.keyboard.SendScanCodes([]uint8{0x12, 0x1C, 0xF0, 0x1C, 0xF0, 0x12})
+
+	// In the middle of the first frame
+	for range 10 {
+		circuit.keyboard.Tick(&circuit.context)
+		circuit.context.T += 10_000
+	}
+
+	var state bytes.Buffer
+	require.NoError(t, circuit.keyboard.SaveState(&state))
+
+	restored := newKeyboardTestCircuit()
+	restored.context = circuit.context
+	require.NoError(t, restored.keyboard.LoadState(&state))
+
+	assert.Equal(t, []uint8{0x12, 0x1C, 0xF0, 0x1C, 0xF0, 0x12}, restored.receive(t))
+}
+
+func TestScanCodes(t *testing.T) {
+	codes, ok := RuneScanCodes('a')
+	assert.True(t, ok)
+	assert.Equal(t, []uint8{0x1C, 0xF0, 0x1C}, codes)
+
+	codes, ok = RuneScanCodes('A')
+	assert.True(t, ok)
+	assert.Equal(t, []uint8{0x12, 0x1C, 0xF0, 0x1C, 0xF0, 0x12}, codes)
+
+	codes, ok = RuneScanCodes('?')
+	assert.True(t, ok)
+	assert.Equal(t, []uint8{0x12, 0x4A, 0xF0, 0x4A, 0xF0, 0x12}, codes)
+
+	_, ok = RuneScanCodes('ñ')
+	assert.False(t, ok)
+
+	assert.Equal(t, []uint8{0x5A, 0xF0, 0x5A}, KeyScanCodes(KeyEnter))
+	assert.Equal(t, []uint8{0xE0, 0x75, 0xE0, 0xF0, 0x75}, KeyScanCodes(KeyUp))
+
+	codes, ok = ControlScanCodes('C')
+	assert.True(t, ok)
+	assert.Equal(t, []uint8{0x14, 0x21, 0xF0, 0x21, 0xF0, 0x14}, codes)
+}
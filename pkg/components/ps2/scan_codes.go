@@ -0,0 +1,178 @@
+package ps2
+
+// Prefixes of the scan code set 2
+const (
+	extendedPrefix uint8 = 0xE0 // Precedes the codes of the extended keys
+	breakPrefix    uint8 = 0xF0 // Precedes the code of the key released
+)
+
+// Scan codes of the modifier keys
+const (
+	leftShift   uint8 = 0x12
+	leftControl uint8 = 0x14
+)
+
+// Key identifies the keys that don't type a character.
+type Key int
+
+const (
+	KeyEnter Key = iota
+	KeyBackspace
+	KeyTab
+	KeyEscape
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPageUp
+	KeyPageDown
+	KeyInsert
+	KeyDelete
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+// scanCode is the code of a key in the scan code set 2.
+type scanCode struct {
+	code     uint8
+	extended bool // The code is preceded by E0
+}
+
+// Codes of the keys that don't type a character
+var keyCodes map[Key]scanCode = map[Key]scanCode{
+	KeyEnter:     {code: 0x5A},
+	KeyBackspace: {code: 0x66},
+	KeyTab:       {code: 0x0D},
+	KeyEscape:    {code: 0x76},
+	KeyUp:        {code: 0x75, extended: true},
+	KeyDown:      {code: 0x72, extended: true},
+	KeyLeft:      {code: 0x6B, extended: true},
+	KeyRight:     {code: 0x74, extended: true},
+	KeyHome:      {code: 0x6C, extended: true},
+	KeyEnd:       {code: 0x69, extended: true},
+	KeyPageUp:    {code: 0x7D, extended: true},
+	KeyPageDown:  {code: 0x7A, extended: true},
+	KeyInsert:    {code: 0x70, extended: true},
+	KeyDelete:    {code: 0x71, extended: true},
+	KeyF1:        {code: 0x05},
+	KeyF2:        {code: 0x06},
+	KeyF3:        {code: 0x04},
+	KeyF4:        {code: 0x0C},
+	KeyF5:        {code: 0x03},
+	KeyF6:        {code: 0x0B},
+	KeyF7:        {code: 0x83},
+	KeyF8:        {code: 0x0A},
+	KeyF9:        {code: 0x01},
+	KeyF10:       {code: 0x09},
+	KeyF11:       {code: 0x78},
+	KeyF12:       {code: 0x07},
+}
+
+// Codes of the keys of the US layout typing each character without shift
+var runeCodes map[rune]uint8 = map[rune]uint8{
+	'a': 0x1C, 'b': 0x32, 'c': 0x21, 'd': 0x23, 'e': 0x24, 'f': 0x2B, 'g': 0x34,
+	'h': 0x33, 'i': 0x43, 'j': 0x3B, 'k': 0x42, 'l': 0x4B, 'm': 0x3A, 'n': 0x31,
+	'o': 0x44, 'p': 0x4D, 'q': 0x15, 'r': 0x2D, 's': 0x1B, 't': 0x2C, 'u': 0x3C,
+	'v': 0x2A, 'w': 0x1D, 'x': 0x22, 'y': 0x35, 'z': 0x1A,
+	'1': 0x16, '2': 0x1E, '3': 0x26, '4': 0x25, '5': 0x2E,
+	'6': 0x36, '7': 0x3D, '8': 0x3E, '9': 0x46, '0': 0x45,
+	'`': 0x0E, '-': 0x4E, '=': 0x55, '[': 0x54, ']': 0x5B, '\\': 0x5D,
+	';': 0x4C, '\'': 0x52, ',': 0x41, '.': 0x49, '/': 0x4A, ' ': 0x29,
+}
+
+// Characters typed with shift and the character of the same key without it
+var shiftedRunes map[rune]rune = map[rune]rune{
+	'~': '`', '!': '1', '@': '2', '#': '3', '$': '4', '%': '5', '^': '6',
+	'&': '7', '*': '8', '(': '9', ')': '0', '_': '-', '+': '=', '{': '[',
+	'}': ']', '|': '\\', ':': ';', '"': '\'', '<': ',', '>': '.', '?': '/',
+}
+
+// press returns the codes sent when the key is pressed and released.
+func (key scanCode) press() []uint8 {
+	if key.extended {
+		return []uint8{extendedPrefix, key.code, extendedPrefix, breakPrefix, key.code}
+	}
+
+	return []uint8{key.code, breakPrefix, key.code}
+}
+
+// withModifier returns the codes of pressing the modifier, pressing and releasing the key
+// and then releasing the modifier.
+func withModifier(modifier uint8, codes []uint8) []uint8 {
+	result := append([]uint8{modifier}, codes...)
+	return append(result, breakPrefix, modifier)
+}
+
+// RuneScanCodes returns the scan codes sent when the character is typed on a keyboard with
+// the US layout, pressing and releasing the key and, if needed, shift around it.
+//
+// Parameters:
+//   - char: The character typed
+//
+// Returns:
+//   - The scan codes of the set 2
+//   - false if the character can't be typed
+func RuneScanCodes(char rune) ([]uint8, bool) {
+	if code, ok := runeCodes[char]; ok {
+		return scanCode{code: code}.press(), true
+	}
+
+	if char >= 'A' && char <= 'Z' {
+		return withModifier(leftShift, scanCode{code: runeCodes[char-'A'+'a']}.press()), true
+	}
+
+	if unshifted, ok := shiftedRunes[char]; ok {
+		return withModifier(leftShift, scanCode{code: runeCodes[unshifted]}.press()), true
+	}
+
+	return nil, false
+}
+
+// KeyScanCodes returns the scan codes sent when the key is pressed and released.
+//
+// Parameters:
+//   - key: The key pressed
+//
+// Returns:
+//   - The scan codes of the set 2, nil if the key is unknown
+func KeyScanCodes(key Key) []uint8 {
+	code, ok := keyCodes[key]
+	if !ok {
+		return nil
+	}
+
+	return code.press()
+}
+
+// ControlScanCodes returns the scan codes sent when the letter is typed holding the left
+// control key.
+//
+// Parameters:
+//   - letter: The letter typed, in lower or upper case
+//
+// Returns:
+//   - The scan codes of the set 2
+//   - false if the character is not a letter
+func ControlScanCodes(letter rune) ([]uint8, bool) {
+	if letter >= 'A' && letter <= 'Z' {
+		letter += 'a' - 'A'
+	}
+
+	if letter < 'a' || letter > 'z' {
+		return nil, false
+	}
+
+	return withModifier(leftControl, scanCode{code: runeCodes[letter]}.press()), true
+}
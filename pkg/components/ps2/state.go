@@ -0,0 +1,70 @@
+package ps2
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Values of the transmission in progress. This struct is written and read with
+// encoding/binary, so it must only have fixed size fields.
+type keyboardState struct {
+	Sending    bool
+	Current    uint8
+	FrameStart int64
+	Pulsing    bool
+	LatchTime  int64
+	QueueSize  uint32
+}
+
+// SaveState writes the status of the frame being sent and the scan codes waiting to be sent.
+func (k *keyboard) SaveState(writer io.Writer) error {
+	k.mutex.Lock()
+	queue := k.queue
+	k.mutex.Unlock()
+
+	state := keyboardState{
+		Sending:    k.sending,
+		Current:    k.current,
+		FrameStart: k.frameStart,
+		Pulsing:    k.pulsing,
+		LatchTime:  k.latchTime,
+		QueueSize:  uint32(len(queue)),
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	_, err := writer.Write(queue)
+
+	return err
+}
+
+// LoadState restores the status of the transmission previously written by SaveState,
+// replacing the scan codes waiting to be sent.
+func (k *keyboard) LoadState(reader io.Reader) error {
+	var state keyboardState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	queue := make([]uint8, state.QueueSize)
+	if _, err := io.ReadFull(reader, queue); err != nil {
+		return err
+	}
+
+	k.sending = state.Sending
+	k.current = state.Current
+	k.frameStart = state.FrameStart
+	k.pulsing = state.Pulsing
+	k.latchTime = state.LatchTime
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.queue = queue
+	k.pending.Store(int32(len(queue)))
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package via
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Status of a VIA timer. The counters and latches are part of the registers.
+type viaTimerState struct {
+	TimerEnabled                 bool
+	Line7OutputStatusWhenEnabled bool
+	HasCountedToZero             bool
+	HasCountedToZeroLow          bool
+}
+
+// Values of the VIA registers and the internal status of its timers, latches, control
+// lines and shift register. This struct is written and read with encoding/binary, so it
+// must only have fixed size fields.
+type viaState struct {
+	OutputRegisterA        uint8
+	OutputRegisterB        uint8
+	InputRegisterA         uint8
+	InputRegisterB         uint8
+	DataDirectionRegisterA uint8
+	DataDirectionRegisterB uint8
+	LowLatches2            uint8
+	LowLatches1            uint8
+	HighLatches2           uint8
+	HighLatches1           uint8
+	Counter2               uint16
+	Counter1               uint16
+	ShiftRegister          uint8
+	AuxiliaryControl       uint8
+	PeripheralControl      uint8
+	InterruptFlags         uint8
+	InterruptEnable        uint8
+
+	HandshakeInProgress   [2]bool
+	HandshakeCycleCounter [2]uint8
+
+	Timers [2]viaTimerState
+
+	ControlLinesPreviousStatus [2][2]bool
+
+	ShifterEnabled bool
+	BitCount       uint8
+	BitShifted     bool
+	ShiftingPhase  bool
+	OutputBit      bool
+}
+
+// SaveState writes the registers of the VIA and the internal status of its timers,
+// latches, control lines and shift register.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (via *via65C22S) SaveState(writer io.Writer) error {
+	registers := via.registers
+
+	state := viaState{
+		OutputRegisterA:        registers.outputRegisterA,
+		OutputRegisterB:        registers.outputRegisterB,
+		InputRegisterA:         registers.inputRegisterA,
+		InputRegisterB:         registers.inputRegisterB,
+		DataDirectionRegisterA: registers.dataDirectionRegisterA,
+		DataDirectionRegisterB: registers.dataDirectionRegisterB,
+		LowLatches2:            registers.lowLatches2,
+		LowLatches1:            registers.lowLatches1,
+		HighLatches2:           registers.highLatches2,
+		HighLatches1:           registers.highLatches1,
+		Counter2:               registers.counter2,
+		Counter1:               registers.counter1,
+		ShiftRegister:          registers.shiftRegister,
+		AuxiliaryControl:       registers.auxiliaryControl,
+		PeripheralControl:      registers.peripheralControl,
+		InterruptFlags:         registers.interrupts.value,
+		InterruptEnable:        registers.interrupts.interruptEnable,
+
+		HandshakeInProgress:   [2]bool{via.latchesA.handshakeInProgress, via.latchesB.handshakeInProgress},
+		HandshakeCycleCounter: [2]uint8{via.latchesA.handshakeCycleCounter, via.latchesB.handshakeCycleCounter},
+
+		ControlLinesPreviousStatus: [2][2]bool{via.controlLinesA.previousStatus, via.controlLinesB.previousStatus},
+
+		ShifterEnabled: via.shifter.shifterEnabled,
+		BitCount:       via.shifter.bitCount,
+		BitShifted:     via.shifter.bitShifted,
+		ShiftingPhase:  via.shifter.shiftingPhase,
+		OutputBit:      via.shifter.outputBit,
+	}
+
+	for i, timer := range []*viaTimer{via.timer1, via.timer2} {
+		state.Timers[i] = viaTimerState{
+			TimerEnabled:                 timer.timerEnabled,
+			Line7OutputStatusWhenEnabled: timer.line7OutputStatusWhenEnabled,
+			HasCountedToZero:             timer.hasCountedToZero,
+			HasCountedToZeroLow:          timer.hasCountedToZeroLow,
+		}
+	}
+
+	return binary.Write(writer, binary.LittleEndian, &state)
+}
+
+// LoadState restores the registers and internal status of the VIA from a state
+// previously written by SaveState.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read
+func (via *via65C22S) LoadState(reader io.Reader) error {
+	var state viaState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	registers := via.registers
+
+	registers.outputRegisterA = state.OutputRegisterA
+	registers.outputRegisterB = state.OutputRegisterB
+	registers.inputRegisterA = state.InputRegisterA
+	registers.inputRegisterB = state.InputRegisterB
+	registers.dataDirectionRegisterA = state.DataDirectionRegisterA
+	registers.dataDirectionRegisterB = state.DataDirectionRegisterB
+	registers.lowLatches2 = state.LowLatches2
+	registers.lowLatches1 = state.LowLatches1
+	registers.highLatches2 = state.HighLatches2
+	registers.highLatches1 = state.HighLatches1
+	registers.counter2 = state.Counter2
+	registers.counter1 = state.Counter1
+	registers.shiftRegister = state.ShiftRegister
+	registers.auxiliaryControl = state.AuxiliaryControl
+	registers.peripheralControl = state.PeripheralControl
+	registers.interrupts.value = state.InterruptFlags
+	registers.interrupts.interruptEnable = state.InterruptEnable
+
+	for i, latches := range []*viaLatches{via.latchesA, via.latchesB} {
+		latches.handshakeInProgress = state.HandshakeInProgress[i]
+		latches.handshakeCycleCounter = state.HandshakeCycleCounter[i]
+	}
+
+	for i, timer := range []*viaTimer{via.timer1, via.timer2} {
+		timer.timerEnabled = state.Timers[i].TimerEnabled
+		timer.line7OutputStatusWhenEnabled = state.Timers[i].Line7OutputStatusWhenEnabled
+		timer.hasCountedToZero = state.Timers[i].HasCountedToZero
+		timer.hasCountedToZeroLow = state.Timers[i].HasCountedToZeroLow
+	}
+
+	via.controlLinesA.previousStatus = state.ControlLinesPreviousStatus[0]
+	via.controlLinesB.previousStatus = state.ControlLinesPreviousStatus[1]
+
+	via.shifter.shifterEnabled = state.ShifterEnabled
+	via.shifter.bitCount = state.BitCount
+	via.shifter.bitShifted = state.BitShifted
+	via.shifter.shiftingPhase = state.ShiftingPhase
+	via.shifter.outputBit = state.OutputBit
+
+	return nil
+}
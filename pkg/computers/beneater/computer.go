@@ -1,12 +1,35 @@
 package beneater
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/fran150/clementina-6502/pkg/common"
 	"github.com/fran150/clementina-6502/pkg/components"
 	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
 	"go.bug.st/serial"
 )
 
+// DefaultTraceFile is the file where the executed instructions are logged when the trace is
+// started from the menu if no other file is set
+const DefaultTraceFile = "beneater.trace"
+
+// DefaultProfileFile is the file where the profile is exported from the menu if no other
+// file is set, the collapsed stacks are written to the same file with ".folded" added
+const DefaultProfileFile = "beneater.profile"
+
+// Memory regions included in the coverage listing
+var coverageRegions []core.CoverageRegion = []core.CoverageRegion{
+	{Name: "RAM", Start: 0x0000, End: 0x3FFF},
+	{Name: "ROM", Start: 0x8000, End: 0xFFFF},
+}
+
+// Maximum number of cycles executed on each tick of the fast mode
+const fastModeCycles int = 10_000
+
 /*******************************************************************************************
 * Structs definition
 ********************************************************************************************/
@@ -19,6 +42,15 @@ type chips struct {
 	lcd  components.LCDController
 	acia components.Acia65C51
 	nand components.LogicGateArray
+
+	// Keyboard attached to port A and CA1 through the shift registers of the keyboard interface
+	keyboard components.PS2Keyboard
+
+	// LEDs and buttons attached to the ports and control lines of the VIA
+	panel components.IOPanel
+
+	// The ROM when it's an EEPROM that can be written in-circuit, nil otherwise
+	eeprom components.EEPROM
 }
 
 type circuit struct {
@@ -27,9 +59,15 @@ type circuit struct {
 	cpuIRQ     *buses.StandaloneLine
 	cpuReset   *buses.StandaloneLine
 	cpuRW      *buses.StandaloneLine
+	cpuSync    *buses.StandaloneLine
 	u4dOut     *buses.StandaloneLine
 	u4cOut     *buses.StandaloneLine
 	u4bOut     *buses.StandaloneLine
+	u4aOut     *buses.StandaloneLine
+	viaCA1     *buses.StandaloneLine
+	viaCA2     *buses.StandaloneLine
+	viaCB1     *buses.StandaloneLine
+	viaCB2     *buses.StandaloneLine
 	fiveVolts  *buses.StandaloneLine
 	ground     *buses.StandaloneLine
 	portABus   buses.Bus[uint8]
@@ -39,17 +77,32 @@ type circuit struct {
 }
 
 // BenEaterComputerConfig holds configuration options for creating a new BenEaterComputer.
-// It specifies the serial port and modem line emulation settings.
+// It specifies the serial port, modem line emulation settings, the processor to use and
+// whether the ROM is an EEPROM that can be written in-circuit. If no processor is specified
+// the computer uses a WDC 65C02S.
 type BenEaterComputerConfig struct {
 	Port              serial.Port
 	EmulateModemLines bool
+	Processor         components.Cpu65C02
+	Eeprom            bool
+	Buttons           []components.PanelButton
 }
 
 // BenEaterComputer represents a complete emulation of Ben Eater's 6502 computer.
 // It contains all the necessary components and connections to simulate the hardware.
 type BenEaterComputer struct {
-	chips   *chips
-	circuit *circuit
+	chips       *chips
+	circuit     *circuit
+	symbols     core.SymbolTable
+	sourceMap   core.SourceMap
+	stateFile   string
+	traceFile   string
+	profileFile string
+	romImage    string // The raw ROM image loaded, empty if it was a program
+
+	fastProcessor cpu.FastProcessor
+	fastMemory    *cpu.MemoryMap
+	fastTick      bool
 }
 
 /*******************************************************************************************
@@ -61,11 +114,17 @@ type BenEaterComputer struct {
 // Parameters:
 //   - context: The current step context
 func (c *BenEaterComputer) Tick(context *common.StepContext) {
+	if c.fastMemory != nil && !c.isRomBusy() && !c.chips.keyboard.IsBusy() && !c.chips.panel.IsBusy() && c.executeFast(context) {
+		return
+	}
+
 	// Core emulation - keep this tight for performance
 	c.chips.cpu.Tick(context)
 	c.chips.nand.Tick(context)
 	c.chips.ram.Tick(context)
 	c.chips.rom.Tick(context)
+	c.chips.keyboard.Tick(context)
+	c.chips.panel.Tick(context)
 	c.chips.via.Tick(context)
 	c.chips.lcd.Tick(context)
 	c.chips.acia.Tick(context)
@@ -76,9 +135,26 @@ func (c *BenEaterComputer) Tick(context *common.StepContext) {
 // Parameters:
 //   - context: The current step context
 func (c *BenEaterComputer) PostTick(context *common.StepContext) {
+	if c.fastTick {
+		return
+	}
+
 	c.chips.cpu.PostTick(context)
 }
 
+// executeFast executes instructions directly on the memory map, the context is moved to the
+// last cycle executed. Returns false if no instruction could be executed without the bus.
+func (c *BenEaterComputer) executeFast(context *common.StepContext) bool {
+	cycles := c.fastProcessor.ExecuteInstructions(c.fastMemory, fastModeCycles)
+
+	c.fastTick = cycles > 0
+	if c.fastTick {
+		context.Cycle += uint64(cycles - 1)
+	}
+
+	return c.fastTick
+}
+
 // GetProgramCounter returns the current program counter value from the CPU.
 //
 // Returns:
@@ -95,6 +171,91 @@ func (c *BenEaterComputer) Reset(status bool) {
 	c.circuit.cpuReset.Set(!status)
 }
 
+/*******************************************************************************************
+* Input injection
+********************************************************************************************/
+
+// SetStimulusHandler routes the bytes received by the ACIA from the serial port, the keys
+// typed on the keyboard and the buttons pressed on the I/O panel to the handler instead of
+// receiving them, so they can be recorded and injected at a known cycle.
+//
+// Parameters:
+//   - handler: The function that receives the stimuli, nil to receive them directly
+func (c *BenEaterComputer) SetStimulusHandler(handler func(stimulus core.Stimulus)) {
+	if handler == nil {
+		c.chips.acia.SetReceiveHandler(nil)
+		c.chips.keyboard.SetKeyHandler(nil)
+		c.chips.panel.SetPressHandler(nil)
+		return
+	}
+
+	c.chips.acia.SetReceiveHandler(func(value uint8) {
+		handler(core.Stimulus{Kind: core.StimulusSerial, Data: []byte{value}})
+	})
+
+	c.chips.keyboard.SetKeyHandler(func(codes []uint8) {
+		handler(core.Stimulus{Kind: core.StimulusKeyboard, Data: codes})
+	})
+
+	c.chips.panel.SetPressHandler(func(buttons []uint8) {
+		handler(core.Stimulus{Kind: core.StimulusButton, Data: buttons})
+	})
+}
+
+// InjectStimulus receives the bytes of a serial stimulus in the ACIA as if they were read
+// from the serial port, sends the scan codes of a keyboard stimulus from the keyboard or
+// presses the buttons of a button stimulus on the I/O panel.
+//
+// Parameters:
+//   - stimulus: The stimulus to inject
+//
+// Returns:
+//   - An error if the stimulus is not received from the serial port, the keyboard or the panel
+func (c *BenEaterComputer) InjectStimulus(stimulus core.Stimulus) error {
+	switch stimulus.Kind {
+	case core.StimulusSerial:
+		for _, value := range stimulus.Data {
+			c.chips.acia.ReceiveByte(value)
+		}
+	case core.StimulusKeyboard:
+		c.chips.keyboard.SendScanCodes(stimulus.Data)
+	case core.StimulusButton:
+		c.chips.panel.PressButtons(stimulus.Data)
+	default:
+		return fmt.Errorf("the computer doesn't receive %s stimuli", stimulus.Kind)
+	}
+
+	return nil
+}
+
+// ReceivesStimulus returns true for the stimuli received from the serial port, the keyboard
+// and the panel.
+//
+// Parameters:
+//   - kind: The kind of stimulus
+//
+// Returns:
+//   - true if the stimulus can be injected
+func (c *BenEaterComputer) ReceivesStimulus(kind core.StimulusKind) bool {
+	return kind == core.StimulusSerial || kind == core.StimulusKeyboard || kind == core.StimulusButton
+}
+
+// TypeScanCodes types the keys of the scan codes on the keyboard attached to the VIA.
+//
+// Parameters:
+//   - codes: The scan codes of the set 2 of the keys pressed and released
+func (c *BenEaterComputer) TypeScanCodes(codes []uint8) {
+	c.chips.keyboard.TypeScanCodes(codes)
+}
+
+// PressPanelKey presses the buttons of the I/O panel mapped to the key.
+//
+// Parameters:
+//   - key: The key pressed on the host
+func (c *BenEaterComputer) PressPanelKey(key rune) {
+	c.chips.panel.PressKey(key)
+}
+
 /*******************************************************************************************
 * Miscellaneous functions
 ********************************************************************************************/
@@ -103,6 +264,126 @@ func (c *BenEaterComputer) Reset(status bool) {
 // It ensures that the ACIA component is properly closed to release resources.
 func (c *BenEaterComputer) Close() {
 	c.chips.acia.Close()
+
+	if c.chips.eeprom != nil {
+		c.chips.eeprom.Close()
+	}
+}
+
+// SetFastMode enables or disables the fast execution mode. In fast mode the processor executes
+// whole instructions directly on the RAM and ROM and only goes through the bus, cycle by cycle,
+// when the VIA or ACIA are accessed. Peripherals are not ticked while the instructions are
+// executed directly. If the processor doesn't support the fast mode it always runs cycle by cycle.
+//
+// Parameters:
+//   - enabled: True to execute the instructions directly on the memory
+func (c *BenEaterComputer) SetFastMode(enabled bool) {
+	c.fastMemory = nil
+	c.fastTick = false
+
+	processor, ok := c.chips.cpu.(cpu.FastProcessor)
+	if !enabled || !ok {
+		return
+	}
+
+	memoryMap := cpu.NewMemoryMap()
+	memoryMap.Map(0x0000, 0x3FFF, c.chips.ram.Contents()[:0x4000], true)
+
+	// Writes to the EEPROM go through the bus as they start its write cycle
+	if c.chips.eeprom != nil {
+		memoryMap.MapReads(0x8000, 0xFFFF, c.chips.rom.Contents()[:0x8000])
+	} else {
+		memoryMap.Map(0x8000, 0xFFFF, c.chips.rom.Contents()[:0x8000], false)
+	}
+
+	c.fastProcessor = processor
+	c.fastMemory = memoryMap
+}
+
+// WritesBypassBus returns true if in fast mode the writes to the address are done directly on
+// the memory, so they are not seen on the bus. Always false when fast mode is disabled.
+//
+// Parameters:
+//   - address: The address written
+func (c *BenEaterComputer) WritesBypassBus(address uint16) bool {
+	return c.fastMemory != nil && c.fastMemory.WritesBypassBus(address)
+}
+
+// SetSymbolTable sets the labels of the program being run, they are shown by the debugger
+// windows in place of the raw addresses. Must be called before creating the emulator.
+//
+// Parameters:
+//   - symbols: The symbol table of the program, nil to show only addresses
+func (c *BenEaterComputer) SetSymbolTable(symbols core.SymbolTable) {
+	c.symbols = symbols
+}
+
+// SetSourceMap sets the source line information of the program being run, it is used
+// by the source window and to step by source line. Must be called before creating the emulator.
+//
+// Parameters:
+//   - sourceMap: The source map of the program, nil if there is no line information
+func (c *BenEaterComputer) SetSourceMap(sourceMap core.SourceMap) {
+	c.sourceMap = sourceMap
+}
+
+// SetTraceFile sets the file where the executed instructions are logged when the trace is
+// started from the menu.
+//
+// Parameters:
+//   - path: Path of the trace file, empty to use DefaultTraceFile
+func (c *BenEaterComputer) SetTraceFile(path string) {
+	c.traceFile = path
+}
+
+// getTraceFile returns the file where the executed instructions are logged when the trace
+// is started from the menu.
+func (c *BenEaterComputer) getTraceFile() string {
+	if c.traceFile == "" {
+		return DefaultTraceFile
+	}
+
+	return c.traceFile
+}
+
+// SetProfileFile sets the file where the profile is exported from the menu.
+//
+// Parameters:
+//   - path: Path of the profile report, empty to use DefaultProfileFile
+func (c *BenEaterComputer) SetProfileFile(path string) {
+	c.profileFile = path
+}
+
+// getProfileFile returns the file where the profile is exported from the menu.
+func (c *BenEaterComputer) getProfileFile() string {
+	if c.profileFile == "" {
+		return DefaultProfileFile
+	}
+
+	return c.profileFile
+}
+
+// getWaveformSignals returns the buses and lines dumped by the waveform recorder, with the
+// names of the pins of the chips they are connected to.
+func (c *BenEaterComputer) getWaveformSignals() []core.WaveformSignal {
+	return []core.WaveformSignal{
+		managers.NewBusSignal("A", c.circuit.addressBus),
+		managers.NewBusSignal("D", c.circuit.dataBus),
+		managers.NewLineSignal("RWB", c.circuit.cpuRW),
+		managers.NewLineSignal("SYNC", c.circuit.cpuSync),
+		managers.NewLineSignal("IRQB", c.circuit.cpuIRQ),
+		managers.NewLineSignal("NMIB", c.chips.cpu.NonMaskableInterrupt().GetLine()),
+		managers.NewLineSignal("RESB", c.circuit.cpuReset),
+		managers.NewLineSignal("ROMCSB", c.circuit.u4dOut),
+		managers.NewLineSignal("RAMCSB", c.circuit.u4cOut),
+		managers.NewLineSignal("IOCSB", c.circuit.u4bOut),
+		managers.NewBusSignal("PA", c.circuit.portABus),
+		managers.NewLineSignal("CA1", c.circuit.viaCA1),
+		managers.NewLineSignal("CA2", c.circuit.viaCA2),
+		managers.NewLineSignal("CB1", c.circuit.viaCB1),
+		managers.NewLineSignal("CB2", c.circuit.viaCB2),
+		managers.NewBusSignal("PB", c.circuit.portBBus),
+	}
 }
 
 // getPotentialOperators retrieves the next two bytes from ROM at the given program counter.
@@ -114,7 +395,62 @@ func (c *BenEaterComputer) getPotentialOperators(programCounter uint16) [2]uint8
 	return [2]uint8{rom.Peek(uint32(operand1Address)), rom.Peek(uint32(operand2Address))}
 }
 
-// LoadRom loads a ROM image from the specified file path into the computer's ROM.
+// peekMappedMemory returns a byte from the current Ben Eater memory map without bus side effects.
+// RAM is mapped from 0x0000 to 0x3FFF and ROM from 0x8000 to 0xFFFF, I/O and unmapped
+// addresses return false.
+func (c *BenEaterComputer) peekMappedMemory(address uint16) (uint8, bool) {
+	switch {
+	case address < 0x4000:
+		return c.chips.ram.Peek(uint32(address)), true
+
+	case address >= 0x8000:
+		return c.chips.rom.Peek(uint32(address & 0x7FFF)), true
+	}
+
+	return 0, false
+}
+
+// PeekMemory returns a byte from the memory map without bus side effects,
+// unmapped addresses return 0.
+//
+// Parameters:
+//   - address: The address of the processor to read
+//
+// Returns:
+//   - The value at the address
+func (c *BenEaterComputer) PeekMemory(address uint16) uint8 {
+	value, _ := c.peekMappedMemory(address)
+	return value
+}
+
+// PokeMemory writes a byte to the memory map without bus side effects, the ROM can be
+// written too. Writes to I/O and unmapped addresses are ignored.
+//
+// Parameters:
+//   - address: The address of the processor to write
+//   - value: The value to write
+func (c *BenEaterComputer) PokeMemory(address uint16, value uint8) {
+	switch {
+	case address < 0x4000:
+		c.chips.ram.Poke(address, value)
+
+	case address >= 0x8000:
+		c.chips.rom.Poke(address&0x7FFF, value)
+	}
+}
+
+// GetLCDStatus returns the LCD controller to read the contents and state of the display.
+//
+// Returns:
+//   - The status of the LCD controller
+func (c *BenEaterComputer) GetLCDStatus() components.LCDStatus {
+	return c.chips.lcd
+}
+
+// LoadRom loads a ROM image from the specified file path into the computer's ROM. Raw
+// binaries are loaded at the start of the ROM, the files in the formats with addresses
+// are loaded as described in LoadProgram. Files that look like Intel HEX or S-record but
+// can't be parsed as such are loaded as raw binaries.
 //
 // Parameters:
 //   - romImagePath: The path to the ROM image file
@@ -122,10 +458,90 @@ func (c *BenEaterComputer) getPotentialOperators(programCounter uint16) [2]uint8
 // Returns:
 //   - An error if the ROM image could not be loaded, nil otherwise
 func (c *BenEaterComputer) LoadRom(romImagePath string) error {
-	err := c.chips.rom.Load(romImagePath)
+	c.romImage = ""
+
+	program, err := managers.LoadProgram(romImagePath, nil)
+	if errors.Is(err, managers.ErrNoLoadAddress) || errors.Is(err, managers.ErrInvalidTextProgram) {
+		if err := c.chips.rom.Load(romImagePath); err != nil {
+			return err
+		}
+
+		c.romImage = romImagePath
+		return nil
+	}
+
 	if err != nil {
 		return err
 	}
 
+	return c.LoadProgram(program)
+}
+
+// PersistRomWrites saves the pages written by the programs to the EEPROM in the ROM image
+// loaded, so they are kept between runs. It must be called after loading the ROM.
+//
+// Returns:
+//   - An error if the ROM is not an EEPROM, the ROM loaded is not a raw image or the image
+//     can't be opened for writing
+func (c *BenEaterComputer) PersistRomWrites() error {
+	if c.chips.eeprom == nil {
+		return errors.New("only the writes to the EEPROM can be persisted")
+	}
+
+	if c.romImage == "" {
+		return errors.New("the writes can only be persisted to a raw ROM image")
+	}
+
+	return c.chips.eeprom.PersistTo(c.romImage)
+}
+
+// getRomPersistError returns the error that stopped saving the writes to the EEPROM in the ROM
+// image, nil if there was none.
+func (c *BenEaterComputer) getRomPersistError() error {
+	if c.chips.eeprom == nil {
+		return nil
+	}
+
+	if err := c.chips.eeprom.GetPersistError(); err != nil {
+		return fmt.Errorf("the ROM writes are no longer persisted: %w", err)
+	}
+
+	return nil
+}
+
+// isRomBusy returns true if the ROM is an EEPROM writing a page, its reads return the status
+// of the write so the instructions can't be executed directly on its contents.
+func (c *BenEaterComputer) isRomBusy() bool {
+	return c.chips.eeprom != nil && c.chips.eeprom.IsBusy()
+}
+
+// LoadProgram writes the segments of the program to the RAM and ROM and, if the program
+// has an entry point, sets the program counter of the processor to it.
+//
+// Parameters:
+//   - program: The program to load
+//
+// Returns:
+//   - An error, without writing anything, if a segment is placed at I/O or unmapped addresses
+func (c *BenEaterComputer) LoadProgram(program core.Program) error {
+	for _, segment := range program.Segments {
+		for i := range segment.Data {
+			address := segment.Address + uint16(i)
+			if _, ok := c.peekMappedMemory(address); !ok {
+				return fmt.Errorf("the program writes to $%04X, which is not RAM or ROM", address)
+			}
+		}
+	}
+
+	for _, segment := range program.Segments {
+		for i, value := range segment.Data {
+			c.PokeMemory(segment.Address+uint16(i), value)
+		}
+	}
+
+	if program.HasEntry {
+		c.chips.cpu.ForceProgramCounter(program.Entry)
+	}
+
 	return nil
 }
@@ -1,6 +1,7 @@
 package beneater
 
 import (
+	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/fran150/clementina-6502/pkg/terminal"
 	"github.com/fran150/clementina-6502/pkg/terminal/ui"
 	"github.com/rivo/tview"
@@ -41,20 +42,40 @@ func newBenEaterEmulatorConsole(config benEaterEmulatorConsoleConfig) *benEaterE
 
 	// Initialize all windows
 	wm.AddWindow("lcd", ui.NewDisplayWindow(computer.chips.lcd))
-	wm.AddWindow("code", ui.NewCodeWindow(computer.chips.cpu, computer.getPotentialOperators))
+	codeWindow := ui.NewCodeWindow(computer.chips.cpu, computer.getPotentialOperators)
+	wm.AddWindow("code", codeWindow)
 	wm.AddWindow("speed", ui.NewSpeedWindow(config.emulator.speedController))
 	wm.AddWindow("cpu", ui.NewCpuWindow(computer.chips.cpu))
 	wm.AddWindow("via", ui.NewViaWindow(computer.chips.via))
 	wm.AddWindow("lcd_controller", ui.NewLcdWindow(computer.chips.lcd))
 	wm.AddWindow("acia", ui.NewAciaWindow(computer.chips.acia))
+	wm.AddWindow("panel", ui.NewPanelWindow(computer.chips.panel))
 	wm.AddWindow("ram", ui.NewMemoryWindow(computer.chips.ram))
 	wm.AddWindow("rom", ui.NewMemoryWindow(computer.chips.rom))
 	busWindow := ui.NewBusWindow()
 	wm.AddWindow("bus", busWindow)
-	wm.AddWindow("breakpoint", ui.NewBreakPointForm(config.emulator.breakpointManager))
-	wm.AddWindow("options", ui.NewOptionsWindow(menuOptions))
+	breakpointForm := ui.NewBreakPointForm(config.emulator.breakpointManager)
+	wm.AddWindow("breakpoint", breakpointForm)
+	wm.AddWindow("watchpoint", ui.NewWatchpointForm(config.emulator.watchpointManager))
+	callStackWindow := ui.NewCallStackWindow(computer.chips.cpu)
+	wm.AddWindow("callstack", callStackWindow)
+	wm.AddWindow("source", ui.NewSourceWindow(computer.chips.cpu, computer.sourceMap))
+	profilerWindow := ui.NewProfilerWindow(config.emulator.profiler)
+	wm.AddWindow("profiler", profilerWindow)
+	optionsWindow := ui.NewOptionsWindow(menuOptions)
+	optionsWindow.SetReloadStatus(func() core.ReloadStatus { return config.emulator.GetReloadStatus() })
+	optionsWindow.SetLastError(func() error { return config.emulator.GetLastError() })
+	wm.AddWindow("options", optionsWindow)
 
 	initializeBusWindow(computer, busWindow)
+
+	if computer.symbols != nil {
+		codeWindow.SetSymbolTable(computer.symbols)
+		breakpointForm.SetSymbolTable(computer.symbols)
+		callStackWindow.SetSymbolTable(computer.symbols)
+		profilerWindow.SetSymbolTable(computer.symbols)
+	}
+
 	console.initializeLayout()
 
 	// Set initial active window
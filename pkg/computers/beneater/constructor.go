@@ -7,28 +7,45 @@ import (
 	"github.com/fran150/clementina-6502/pkg/components/lcd"
 	"github.com/fran150/clementina-6502/pkg/components/memory"
 	"github.com/fran150/clementina-6502/pkg/components/other/gates"
+	"github.com/fran150/clementina-6502/pkg/components/panel"
+	"github.com/fran150/clementina-6502/pkg/components/ps2"
 	"github.com/fran150/clementina-6502/pkg/components/via"
 )
 
 // NewBenEaterComputer creates and initializes a new instance of the Ben Eater 6502 computer emulation.
 // It sets up all hardware components, connects them according to the original design, and configures
-// the serial port for communication.
+// the serial port for communication. With an EEPROM the ROM can be written: its write enable is
+// connected to the R/W line of the processor and its output enable to R/W inverted by the spare
+// NAND gate. A PS/2 keyboard is attached to port A and CA1 as in the keyboard interface, and an
+// I/O panel with the configured buttons to all the ports and control lines of the VIA.
 //
 // Parameters:
-//   - config: Configuration containing emulation settings, serial port, and modem line options
+//   - config: Configuration containing emulation settings, serial port, modem line options and processor
 //
 // Returns:
 //   - A pointer to the initialized BenEaterComputer
 //   - An error if initialization fails
 func NewBenEaterComputer(config *BenEaterComputerConfig) (*BenEaterComputer, error) {
+	processor := config.Processor
+	if processor == nil {
+		processor = cpu.NewCpu65C02S()
+	}
+
 	chips := &chips{
-		cpu:  cpu.NewCpu65C02S(),
+		cpu:  processor,
 		ram:  memory.NewRam(memory.RAM_SIZE_32K),
 		rom:  memory.NewRam(memory.RAM_SIZE_32K),
 		via:  via.NewVia65C22S(),
 		lcd:  lcd.NewLcdHD44780U(),
 		acia: acia.NewAcia65C51(config.EmulateModemLines),
 		nand: gates.NewNand74HC00(),
+
+		keyboard: ps2.NewKeyboard(),
+	}
+
+	if config.Eeprom {
+		chips.eeprom = memory.NewEepromAT28C256()
+		chips.rom = chips.eeprom
 	}
 
 	portABus := buses.New8BitStandaloneBus()
@@ -48,9 +65,15 @@ func NewBenEaterComputer(config *BenEaterComputerConfig) (*BenEaterComputer, err
 		cpuIRQ:     buses.NewStandaloneLine(true),
 		cpuReset:   buses.NewStandaloneLine(true),
 		cpuRW:      buses.NewStandaloneLine(false),
+		cpuSync:    buses.NewStandaloneLine(false),
 		u4dOut:     buses.NewStandaloneLine(false),
 		u4cOut:     buses.NewStandaloneLine(false),
 		u4bOut:     buses.NewStandaloneLine(false),
+		u4aOut:     buses.NewStandaloneLine(false),
+		viaCA1:     buses.NewStandaloneLine(false),
+		viaCA2:     buses.NewStandaloneLine(false),
+		viaCB1:     buses.NewStandaloneLine(false),
+		viaCB2:     buses.NewStandaloneLine(false),
 		fiveVolts:  buses.NewStandaloneLine(true),
 		ground:     buses.NewStandaloneLine(false),
 		portABus:   portABus,
@@ -77,12 +100,21 @@ func NewBenEaterComputer(config *BenEaterComputerConfig) (*BenEaterComputer, err
 	chips.cpu.BusEnable().Connect(circuit.fiveVolts)
 	chips.cpu.ReadWrite().Connect(circuit.cpuRW)
 
+	// SYNC is not used by other chips, the line allows to observe the opcode fetches
+	chips.cpu.Sync().Connect(circuit.cpuSync)
+
 	chips.rom.AddressBus().Connect(circuit.addressBus)
 	chips.rom.DataBus().Connect(circuit.dataBus)
-	chips.rom.WriteEnable().Connect(circuit.fiveVolts)
-	chips.rom.OutputEnable().Connect(circuit.ground)
 	chips.rom.ChipSelect().Connect(circuit.u4dOut)
 
+	if chips.eeprom != nil {
+		chips.rom.WriteEnable().Connect(circuit.cpuRW)
+		chips.rom.OutputEnable().Connect(circuit.u4aOut)
+	} else {
+		chips.rom.WriteEnable().Connect(circuit.fiveVolts)
+		chips.rom.OutputEnable().Connect(circuit.ground)
+	}
+
 	chips.ram.AddressBus().Connect(circuit.addressBus)
 	chips.ram.DataBus().Connect(circuit.dataBus)
 	chips.ram.WriteEnable().Connect(circuit.cpuRW)
@@ -99,7 +131,12 @@ func NewBenEaterComputer(config *BenEaterComputerConfig) (*BenEaterComputer, err
 	chips.via.RegisterSelect(2).Connect(addressBus2)
 	chips.via.RegisterSelect(1).Connect(addressBus1)
 	chips.via.RegisterSelect(0).Connect(addressBus0)
+	chips.via.PeripheralPortA().Connect(circuit.portABus)
 	chips.via.PeripheralPortB().Connect(circuit.portBBus)
+	chips.via.PeripheralAControlLines(0).Connect(circuit.viaCA1)
+	chips.via.PeripheralAControlLines(1).Connect(circuit.viaCA2)
+	chips.via.PeripheralBControlLines(0).Connect(circuit.viaCB1)
+	chips.via.PeripheralBControlLines(1).Connect(circuit.viaCB2)
 
 	viaPBAddress6 := circuit.portBBus.GetBusLine(6)
 	viaPBAddress5 := circuit.portBBus.GetBusLine(5)
@@ -110,6 +147,18 @@ func NewBenEaterComputer(config *BenEaterComputerConfig) (*BenEaterComputer, err
 	chips.lcd.RegisterSelect().Connect(viaPBAddress4)
 	chips.lcd.DataBus().Connect(circuit.lcdBus)
 
+	// The shift registers of the keyboard interface drive port A and the end of each scan
+	// code is signaled on CA1
+	chips.keyboard.DataBus().Connect(circuit.portABus)
+	chips.keyboard.Interrupt().Connect(circuit.viaCA1)
+
+	ioPanel, err := panel.NewIOPanel(chips.via, config.Buttons)
+	if err != nil {
+		return nil, err
+	}
+
+	chips.panel = ioPanel
+
 	chips.acia.DataBus().Connect(circuit.dataBus)
 	chips.acia.IrqRequest().Connect(circuit.cpuIRQ)
 	chips.acia.ReadWrite().Connect(circuit.cpuRW)
@@ -131,6 +180,13 @@ func NewBenEaterComputer(config *BenEaterComputerConfig) (*BenEaterComputer, err
 	chips.nand.BPin(2).Connect(circuit.u4dOut)
 	chips.nand.YPin(2).Connect(circuit.u4bOut)
 
+	// The spare gate inverts R/W to disable the outputs of the EEPROM when it's written
+	if chips.eeprom != nil {
+		chips.nand.APin(3).Connect(circuit.cpuRW)
+		chips.nand.BPin(3).Connect(circuit.cpuRW)
+		chips.nand.YPin(3).Connect(circuit.u4aOut)
+	}
+
 	if circuit.serial != nil {
 		if err := chips.acia.ConnectToPort(circuit.serial); err != nil {
 			return nil, err
@@ -18,6 +18,8 @@ type benEaterEmulator struct {
 	core.BaseEmulator
 	speedController   core.SpeedController
 	breakpointManager core.BreakpointManager
+	watchpointManager core.WatchpointManager
+	profiler          core.Profiler
 	computer          *BenEaterComputer
 }
 
@@ -35,14 +37,23 @@ type benEaterEmulator struct {
 //   - error: Any error that occurred during initialization
 func NewBenEaterEmulator(computer *BenEaterComputer, speed float64, displayFPS int) (core.BaseEmulator, error) {
 	speedController := controllers.NewSpeedController(speed)
-	breakPointManager := managers.NewBreakpointManager()
+	breakPointManager := managers.NewConditionalBreakpointManager(computer.chips.cpu, computer.PeekMemory)
+	watchpointManager := managers.NewWatchpointManager(computer.PeekMemory)
+	traceLogger := managers.NewTraceLogger(computer.chips.cpu, computer.PeekMemory)
+	waveformRecorder := managers.NewWaveformRecorder("beneater", computer.getWaveformSignals())
+	profiler := managers.NewProfiler(computer.chips.cpu, computer.symbols)
+	coverageRecorder := managers.NewCoverageRecorder(computer.chips.cpu, computer.PeekMemory, computer.symbols, coverageRegions)
+	inputRecorder := managers.NewInputRecorder()
 	windowManager := terminal.NewWindowManager()
 	navigationManager := managers.NewNavigationManager()
+	historyManager := managers.NewHistoryManager(computer, managers.DefaultSnapshotInterval, managers.DefaultSnapshotCount)
 
 	emulator := &benEaterEmulator{
 		computer:          computer,
 		speedController:   speedController,
 		breakpointManager: breakPointManager,
+		watchpointManager: watchpointManager,
+		profiler:          profiler,
 	}
 
 	console := newBenEaterEmulatorConsole(benEaterEmulatorConsoleConfig{
@@ -63,10 +74,20 @@ func NewBenEaterEmulator(computer *BenEaterComputer, speed float64, displayFPS i
 
 	emulatorConfig := emulation.EmulatorConfig{
 		Computer:          computer,
+		Processor:         computer.chips.cpu,
 		Console:           console,
 		Loop:              loop,
 		SpeedController:   speedController,
 		BreakpointManager: breakPointManager,
+		WatchpointManager: watchpointManager,
+		SourceMap:         computer.sourceMap,
+		HistoryManager:    historyManager,
+		TraceLogger:       traceLogger,
+		WaveformRecorder:  waveformRecorder,
+		Profiler:          profiler,
+		CoverageRecorder:  coverageRecorder,
+		InputRecorder:     inputRecorder,
+		FileWatcher:       managers.NewFileWatcher(managers.DefaultWatchInterval),
 	}
 
 	emulator.BaseEmulator = emulation.NewBaseEmulator(emulatorConfig)
@@ -79,3 +100,13 @@ func NewBenEaterEmulator(computer *BenEaterComputer, speed float64, displayFPS i
 
 	return emulator, nil
 }
+
+// GetLastError returns the last error that occurred while emulating or, if there was none,
+// the error that stopped saving the writes to the EEPROM in the ROM image.
+func (e *benEaterEmulator) GetLastError() error {
+	if err := e.BaseEmulator.GetLastError(); err != nil {
+		return err
+	}
+
+	return e.computer.getRomPersistError()
+}
@@ -0,0 +1,75 @@
+package beneater
+
+import (
+	"github.com/fran150/clementina-6502/pkg/components/ps2"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Keys of the console that don't type a character and the key of the keyboard they are typed on
+var keyboardKeys map[tcell.Key]ps2.Key = map[tcell.Key]ps2.Key{
+	tcell.KeyEnter:      ps2.KeyEnter,
+	tcell.KeyTab:        ps2.KeyTab,
+	tcell.KeyBackspace:  ps2.KeyBackspace,
+	tcell.KeyBackspace2: ps2.KeyBackspace,
+	tcell.KeyUp:         ps2.KeyUp,
+	tcell.KeyDown:       ps2.KeyDown,
+	tcell.KeyLeft:       ps2.KeyLeft,
+	tcell.KeyRight:      ps2.KeyRight,
+	tcell.KeyHome:       ps2.KeyHome,
+	tcell.KeyEnd:        ps2.KeyEnd,
+	tcell.KeyPgUp:       ps2.KeyPageUp,
+	tcell.KeyPgDn:       ps2.KeyPageDown,
+	tcell.KeyInsert:     ps2.KeyInsert,
+	tcell.KeyDelete:     ps2.KeyDelete,
+	tcell.KeyF1:         ps2.KeyF1,
+	tcell.KeyF2:         ps2.KeyF2,
+	tcell.KeyF3:         ps2.KeyF3,
+	tcell.KeyF4:         ps2.KeyF4,
+	tcell.KeyF5:         ps2.KeyF5,
+	tcell.KeyF6:         ps2.KeyF6,
+	tcell.KeyF7:         ps2.KeyF7,
+	tcell.KeyF8:         ps2.KeyF8,
+	tcell.KeyF9:         ps2.KeyF9,
+	tcell.KeyF10:        ps2.KeyF10,
+	tcell.KeyF11:        ps2.KeyF11,
+	tcell.KeyF12:        ps2.KeyF12,
+}
+
+// keyScanCodes translates a key pressed on the console to the scan codes the keyboard sends
+// when the key is pressed and released. Terminals don't report when keys are released, so
+// modifiers are pressed and released around each key.
+//
+// Parameters:
+//   - event: The key pressed on the console
+//
+// Returns:
+//   - The scan codes of the set 2, nil if the key can't be typed on the keyboard
+func keyScanCodes(event *tcell.EventKey) []uint8 {
+	// Enter, tab and backspace share their codes with control keys, so they are checked first
+	if key, ok := keyboardKeys[event.Key()]; ok {
+		return ps2.KeyScanCodes(key)
+	}
+
+	if event.Key() == tcell.KeyRune {
+		codes, _ := ps2.RuneScanCodes(event.Rune())
+		return codes
+	}
+
+	if event.Key() >= tcell.KeyCtrlA && event.Key() <= tcell.KeyCtrlZ {
+		codes, _ := ps2.ControlScanCodes(rune('a' + event.Key() - tcell.KeyCtrlA))
+		return codes
+	}
+
+	return nil
+}
+
+// typeOnKeyboard types the key pressed on the console on the keyboard of the computer.
+//
+// Parameters:
+//   - computer: The computer with the keyboard
+//   - event: The key pressed on the console
+func typeOnKeyboard(computer *BenEaterComputer, event *tcell.EventKey) {
+	if codes := keyScanCodes(event); codes != nil {
+		computer.TypeScanCodes(codes)
+	}
+}
@@ -0,0 +1,155 @@
+package beneater
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Name of the computer model written in the header of the saved states
+const stateModel = "beneater"
+
+// DefaultStateFile is the file used to save and load the state from the menu if no other
+// file is set
+const DefaultStateFile = "beneater.state"
+
+// Values of the buses and lines that connect the chips of the computer. This struct is
+// written and read with encoding/binary, so it must only have fixed size fields.
+type circuitState struct {
+	AddressBus uint16
+	DataBus    uint8
+	PortABus   uint8
+	PortBBus   uint8
+	LcdBus     uint8
+
+	CpuIRQ    bool
+	CpuReset  bool
+	CpuRW     bool
+	U4dOut    bool
+	U4cOut    bool
+	U4bOut    bool
+	ViaCA1    bool
+	ViaCA2    bool
+	ViaCB1    bool
+	ViaCB2    bool
+	FiveVolts bool
+	Ground    bool
+}
+
+// SaveState writes a header with the format version and computer model, followed by the
+// state of all the chips of the computer and the values of the buses and lines that
+// connect them, including the contents of RAM and ROM.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (c *BenEaterComputer) SaveState(writer io.Writer) error {
+	circuit := c.circuit
+
+	state := circuitState{
+		AddressBus: circuit.addressBus.Read(),
+		DataBus:    circuit.dataBus.Read(),
+		PortABus:   circuit.portABus.Read(),
+		PortBBus:   circuit.portBBus.Read(),
+		LcdBus:     circuit.lcdBus.Read(),
+
+		CpuIRQ:    circuit.cpuIRQ.Status(),
+		CpuReset:  circuit.cpuReset.Status(),
+		CpuRW:     circuit.cpuRW.Status(),
+		U4dOut:    circuit.u4dOut.Status(),
+		U4cOut:    circuit.u4cOut.Status(),
+		U4bOut:    circuit.u4bOut.Status(),
+		ViaCA1:    circuit.viaCA1.Status(),
+		ViaCA2:    circuit.viaCA2.Status(),
+		ViaCB1:    circuit.viaCB1.Status(),
+		ViaCB2:    circuit.viaCB2.Status(),
+		FiveVolts: circuit.fiveVolts.Status(),
+		Ground:    circuit.ground.Status(),
+	}
+
+	if err := core.WriteStateHeader(writer, stateModel); err != nil {
+		return err
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	return core.SaveStates(writer, c.getSnapshotables()...)
+}
+
+// LoadState restores the state of the chips, buses and lines of the computer from a
+// state previously written by SaveState. States saved by other computer models or
+// with a different format version are rejected.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read or is not valid
+func (c *BenEaterComputer) LoadState(reader io.Reader) error {
+	if err := core.ReadStateHeader(reader, stateModel); err != nil {
+		return err
+	}
+
+	var state circuitState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	if err := core.LoadStates(reader, c.getSnapshotables()...); err != nil {
+		return err
+	}
+
+	circuit := c.circuit
+
+	circuit.addressBus.Write(state.AddressBus)
+	circuit.dataBus.Write(state.DataBus)
+	circuit.portABus.Write(state.PortABus)
+	circuit.portBBus.Write(state.PortBBus)
+	circuit.lcdBus.Write(state.LcdBus)
+
+	circuit.cpuIRQ.Set(state.CpuIRQ)
+	circuit.cpuReset.Set(state.CpuReset)
+	circuit.cpuRW.Set(state.CpuRW)
+	circuit.u4dOut.Set(state.U4dOut)
+	circuit.u4cOut.Set(state.U4cOut)
+	circuit.u4bOut.Set(state.U4bOut)
+	circuit.viaCA1.Set(state.ViaCA1)
+	circuit.viaCA2.Set(state.ViaCA2)
+	circuit.viaCB1.Set(state.ViaCB1)
+	circuit.viaCB2.Set(state.ViaCB2)
+	circuit.fiveVolts.Set(state.FiveVolts)
+	circuit.ground.Set(state.Ground)
+
+	return nil
+}
+
+// getSnapshotables returns the chips that hold state in the order they are saved. The
+// NAND gates are not included as their outputs are computed again on every cycle.
+func (c *BenEaterComputer) getSnapshotables() []any {
+	chips := c.chips
+
+	return []any{chips.cpu, chips.ram, chips.rom, chips.via, chips.lcd, chips.acia, chips.keyboard, chips.panel}
+}
+
+// SetStateFile sets the file used to save and load the state of the computer from the menu.
+//
+// Parameters:
+//   - path: Path of the state file, empty to use DefaultStateFile
+func (c *BenEaterComputer) SetStateFile(path string) {
+	c.stateFile = path
+}
+
+// getStateFile returns the file used to save and load the state of the computer from the menu.
+func (c *BenEaterComputer) getStateFile() string {
+	if c.stateFile == "" {
+		return DefaultStateFile
+	}
+
+	return c.stateFile
+}
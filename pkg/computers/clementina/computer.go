@@ -1,13 +1,42 @@
 package clementina
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/fran150/clementina-6502/pkg/common"
 	"github.com/fran150/clementina-6502/pkg/components"
 	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/components/panel"
 	"github.com/fran150/clementina-6502/pkg/computers/clementina/modules"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
 	"go.bug.st/serial"
 )
 
+// DefaultTraceFile is the file where the executed instructions are logged when the trace is
+// started from the menu if no other file is set
+const DefaultTraceFile = "clementina.trace"
+
+// DefaultProfileFile is the file where the profile is exported from the menu if no other
+// file is set, the collapsed stacks are written to the same file with ".folded" added
+const DefaultProfileFile = "clementina.profile"
+
+// Memory regions included in the coverage listing, the extended RAM is listed
+// with the contents of the bank selected when the coverage is exported
+var coverageRegions []core.CoverageRegion = []core.CoverageRegion{
+	{Name: "Base RAM", Start: 0x0000, End: 0x7FFF},
+	{Name: "Extended RAM", Start: 0x8000, End: 0xBFFF},
+	{Name: "MIA", Start: 0xE000, End: 0xFFFF},
+}
+
+// Maximum number of cycles executed on each tick of the fast mode
+const fastModeCycles int = 10_000
+
+// Size of the window where the selected bank of the extended RAM is mapped
+const exRAMWindowSize uint32 = 0x4000
+
 /*******************************************************************************************
 * Structs definition
 ********************************************************************************************/
@@ -30,6 +59,7 @@ type chips struct {
 	exram    components.Memory
 	via      components.Via65C22
 	mia      components.MiaChip
+	panel    components.IOPanel
 	csLogic  *modules.ClementinaCSLogic
 	oeRWSync *modules.ClementinaOERWPHISync
 }
@@ -43,6 +73,7 @@ type circuit struct {
 	cpuReset        *buses.StandaloneLine
 	miaResetRequest *buses.StandaloneLine
 	cpuRW           *buses.StandaloneLine
+	cpuSync         *buses.StandaloneLine
 
 	miaBus       buses.Bus[uint8]
 	exramBus     buses.Bus[uint16]
@@ -63,7 +94,17 @@ type ClementinaComputer struct {
 	chips   *chips
 	circuit *circuit
 
-	mappers mappers
+	mappers     mappers
+	symbols     core.SymbolTable
+	sourceMap   core.SourceMap
+	stateFile   string
+	traceFile   string
+	profileFile string
+
+	fastProcessor   cpu.FastProcessor
+	fastMemory      *cpu.MemoryMap
+	fastExRAMOffset uint32
+	fastTick        bool
 }
 
 /*******************************************************************************************
@@ -75,12 +116,17 @@ type ClementinaComputer struct {
 // Parameters:
 //   - context: The current step context
 func (c *ClementinaComputer) Tick(context *common.StepContext) {
+	if c.fastMemory != nil && !c.chips.panel.IsBusy() && c.executeFast(context) {
+		return
+	}
+
 	// Core emulation - keep this tight for performance
 	c.chips.cpu.Tick(context)
 
 	c.chips.csLogic.Tick(context)
 	c.chips.oeRWSync.Tick(context)
 
+	c.chips.panel.Tick(context)
 	c.chips.via.Tick(context)
 
 	c.chips.baseram.Tick(context)
@@ -95,11 +141,40 @@ func (c *ClementinaComputer) Tick(context *common.StepContext) {
 // Parameters:
 //   - context: The current step context
 func (c *ClementinaComputer) PostTick(context *common.StepContext) {
+	if c.fastTick {
+		return
+	}
+
 	c.chips.mia.PostTick(context)
 	c.resolveIRQLine()
 	c.chips.cpu.PostTick(context)
 }
 
+// executeFast executes instructions directly on the memory map, the context is moved to the
+// last cycle executed. Returns false if no instruction could be executed without the bus.
+func (c *ClementinaComputer) executeFast(context *common.StepContext) bool {
+	// The bank of the extended RAM only changes when the VIA is written through the bus
+	if offset := c.mapExRAMAddress(0x8000); offset != c.fastExRAMOffset {
+		c.mapExRAMBank(offset)
+	}
+
+	cycles := c.fastProcessor.ExecuteInstructions(c.fastMemory, fastModeCycles)
+
+	c.fastTick = cycles > 0
+	if c.fastTick {
+		context.Cycle += uint64(cycles - 1)
+	}
+
+	return c.fastTick
+}
+
+// mapExRAMBank maps the extended RAM window of the fast mode memory map to the bank that
+// starts at the specified offset of the extended RAM.
+func (c *ClementinaComputer) mapExRAMBank(offset uint32) {
+	c.fastMemory.Map(0x8000, 0xBFFF, c.chips.exram.Contents()[offset:offset+exRAMWindowSize], true)
+	c.fastExRAMOffset = offset
+}
+
 func (c *ClementinaComputer) resolveIRQLine() {
 	c.circuit.cpuIRQ.Set(c.circuit.miaIRQ.Status() && c.circuit.viaIRQ.Status())
 }
@@ -169,6 +244,131 @@ func (c *ClementinaComputer) SetMiaPalette(name string) {
 	configurable.SetPalette(name)
 }
 
+// SetPanelButtons replaces the I/O panel attached to the VIA by one with the specified buttons.
+// Only port B is connected to the VIA, so the buttons can only be connected to its pins. It must
+// be called before the stimulus handler is set.
+//
+// Parameters:
+//   - buttons: The buttons of the panel
+//
+// Returns:
+//   - An error if a button is connected to a pin that is not connected to the circuit
+func (c *ClementinaComputer) SetPanelButtons(buttons []components.PanelButton) error {
+	ioPanel, err := panel.NewIOPanel(c.chips.via, buttons)
+	if err != nil {
+		return err
+	}
+
+	c.chips.panel = ioPanel
+
+	return nil
+}
+
+// PressPanelKey presses the buttons of the I/O panel mapped to the key.
+//
+// Parameters:
+//   - key: The key pressed on the host
+func (c *ClementinaComputer) PressPanelKey(key rune) {
+	c.chips.panel.PressKey(key)
+}
+
+// SetFastMode enables or disables the fast execution mode. In fast mode the processor executes
+// whole instructions directly on the base and extended RAM and only goes through the bus, cycle
+// by cycle, when the VIA or the MIA are accessed. The MIA and the VIA are not ticked while the
+// instructions are executed directly. If the processor doesn't support the fast mode it always
+// runs cycle by cycle.
+//
+// Parameters:
+//   - enabled: True to execute the instructions directly on the memory
+func (c *ClementinaComputer) SetFastMode(enabled bool) {
+	c.fastMemory = nil
+	c.fastTick = false
+
+	processor, ok := c.chips.cpu.(cpu.FastProcessor)
+	if !enabled || !ok {
+		return
+	}
+
+	c.fastProcessor = processor
+	c.fastMemory = cpu.NewMemoryMap()
+	c.fastMemory.Map(0x0000, 0x7FFF, c.chips.baseram.Contents()[:0x8000], true)
+	c.mapExRAMBank(c.mapExRAMAddress(0x8000))
+}
+
+// WritesBypassBus returns true if in fast mode the writes to the address are done directly on
+// the memory, so they are not seen on the bus. Always false when fast mode is disabled. All the banks of the ExRAM
+// are written directly, so the result doesn't change when the bank does.
+//
+// Parameters:
+//   - address: The address written
+func (c *ClementinaComputer) WritesBypassBus(address uint16) bool {
+	return c.fastMemory != nil && c.fastMemory.WritesBypassBus(address)
+}
+
+// SetSymbolTable sets the labels of the program being run. They are shown by the debugger
+// windows and by the emulated MIA monitor disassembler in place of the raw addresses.
+// Must be called before creating the emulator.
+//
+// Parameters:
+//   - symbols: The symbol table of the program, nil to show only addresses
+func (c *ClementinaComputer) SetSymbolTable(symbols core.SymbolTable) {
+	c.symbols = symbols
+
+	configurable, ok := c.chips.mia.(interface {
+		SetSymbolTable(core.SymbolTable)
+	})
+	if !ok {
+		return
+	}
+
+	configurable.SetSymbolTable(symbols)
+}
+
+// SetSourceMap sets the source line information of the program being run. It is used
+// by the source window and to step by source line. Must be called before creating the emulator.
+//
+// Parameters:
+//   - sourceMap: The source map of the program, nil if there is no line information
+func (c *ClementinaComputer) SetSourceMap(sourceMap core.SourceMap) {
+	c.sourceMap = sourceMap
+}
+
+// SetTraceFile sets the file where the executed instructions are logged when the trace is
+// started from the menu.
+//
+// Parameters:
+//   - path: Path of the trace file, empty to use DefaultTraceFile
+func (c *ClementinaComputer) SetTraceFile(path string) {
+	c.traceFile = path
+}
+
+// getTraceFile returns the file where the executed instructions are logged when the trace
+// is started from the menu.
+func (c *ClementinaComputer) getTraceFile() string {
+	if c.traceFile == "" {
+		return DefaultTraceFile
+	}
+
+	return c.traceFile
+}
+
+// SetProfileFile sets the file where the profile is exported from the menu.
+//
+// Parameters:
+//   - path: Path of the profile report, empty to use DefaultProfileFile
+func (c *ClementinaComputer) SetProfileFile(path string) {
+	c.profileFile = path
+}
+
+// getProfileFile returns the file where the profile is exported from the menu.
+func (c *ClementinaComputer) getProfileFile() string {
+	if c.profileFile == "" {
+		return DefaultProfileFile
+	}
+
+	return c.profileFile
+}
+
 // ConnectMiaConsole connects a host serial port to the emulated MIA console.
 func (c *ClementinaComputer) ConnectMiaConsole(port serial.Port) error {
 	connectable, ok := c.chips.mia.(interface {
@@ -219,10 +419,98 @@ func (c *ClementinaComputer) SetMiaExecPausedHandler(handler func(bool)) {
 	configurable.SetExecPausedHandler(handler)
 }
 
+// SetStimulusHandler routes the packets received by the MIA input service and the
+// buttons pressed on the I/O panel to the handler instead of processing them, so they
+// can be recorded and injected at a known cycle. The packets are not routed on MIA
+// implementations without an input service.
+func (c *ClementinaComputer) SetStimulusHandler(handler func(stimulus core.Stimulus)) {
+	if handler == nil {
+		c.chips.panel.SetPressHandler(nil)
+	} else {
+		c.chips.panel.SetPressHandler(func(buttons []uint8) {
+			handler(core.Stimulus{Kind: core.StimulusButton, Data: buttons})
+		})
+	}
+
+	configurable, ok := c.chips.mia.(interface {
+		SetInputPacketHandler(func(string, []byte))
+	})
+	if !ok {
+		return
+	}
+
+	if handler == nil {
+		configurable.SetInputPacketHandler(nil)
+		return
+	}
+
+	configurable.SetInputPacketHandler(func(remote string, packet []byte) {
+		handler(core.Stimulus{Kind: core.StimulusMiaInput, Source: remote, Data: packet})
+	})
+}
+
+// InjectStimulus processes a MIA input packet as if it was received from its sender, or
+// presses the buttons of a button stimulus on the I/O panel.
+func (c *ClementinaComputer) InjectStimulus(stimulus core.Stimulus) error {
+	if stimulus.Kind == core.StimulusButton {
+		c.chips.panel.PressButtons(stimulus.Data)
+		return nil
+	}
+
+	if stimulus.Kind != core.StimulusMiaInput {
+		return fmt.Errorf("the computer doesn't receive %s stimuli", stimulus.Kind)
+	}
+
+	injectable, ok := c.chips.mia.(interface {
+		InjectInputPacket(string, []byte) error
+	})
+	if !ok {
+		return errors.New("the MIA doesn't have an input service")
+	}
+
+	return injectable.InjectInputPacket(stimulus.Source, stimulus.Data)
+}
+
+// ReceivesStimulus returns true for the buttons pressed on the I/O panel and, if the MIA has
+// an input service, for its input packets.
+func (c *ClementinaComputer) ReceivesStimulus(kind core.StimulusKind) bool {
+	switch kind {
+	case core.StimulusButton:
+		return true
+	case core.StimulusMiaInput:
+		_, ok := c.chips.mia.(interface {
+			InjectInputPacket(string, []byte) error
+		})
+
+		return ok
+	default:
+		return false
+	}
+}
+
 /*******************************************************************************************
 * Miscellaneous functions
 ********************************************************************************************/
 
+// getWaveformSignals returns the buses and lines dumped by the waveform recorder, with the
+// names of the pins of the chips they are connected to.
+func (c *ClementinaComputer) getWaveformSignals() []core.WaveformSignal {
+	return []core.WaveformSignal{
+		managers.NewBusSignal("A", c.circuit.addressBus),
+		managers.NewBusSignal("D", c.circuit.dataBus),
+		managers.NewLineSignal("RWB", c.circuit.cpuRW),
+		managers.NewLineSignal("SYNC", c.circuit.cpuSync),
+		managers.NewLineSignal("IRQB", c.circuit.cpuIRQ),
+		managers.NewLineSignal("NMIB", c.chips.cpu.NonMaskableInterrupt().GetLine()),
+		managers.NewLineSignal("RESB", c.circuit.cpuReset),
+		managers.NewBusSignal("IOCSB", c.chips.csLogic.IOCS()),
+		managers.NewLineSignal("EXRAMCSB", c.chips.csLogic.ExRAMCS()),
+		managers.NewLineSignal("MIACS", c.chips.csLogic.MiaCS()),
+		managers.NewBusSignal("PA", c.circuit.portABus),
+		managers.NewBusSignal("PB", c.circuit.portBBus),
+	}
+}
+
 // getPotentialOperators retrieves the next two bytes from memory at the given program counter.
 // It handles mapped Clementina memory regions that support side-effect-free peeking.
 //
@@ -256,6 +544,19 @@ func (c *ClementinaComputer) peekMappedMemory(address uint16) (uint8, bool) {
 	return 0, false
 }
 
+// PeekMemory returns a byte from the memory map without bus side effects,
+// unmapped addresses return 0.
+//
+// Parameters:
+//   - address: The address of the processor to read
+//
+// Returns:
+//   - The value at the address
+func (c *ClementinaComputer) PeekMemory(address uint16) uint8 {
+	value, _ := c.peekMappedMemory(address)
+	return value
+}
+
 // mapExRAMAddress maps a CPU address in the extended RAM window to the physical RAM offset.
 func (c *ClementinaComputer) mapExRAMAddress(address uint16) uint32 {
 	portA := c.circuit.portABus.Read()
@@ -266,6 +567,52 @@ func (c *ClementinaComputer) mapExRAMAddress(address uint16) uint32 {
 	return uint32(addressLow) | (uint32(addressHi) << 16)
 }
 
+// PokeMemory writes a byte to the RAM of the memory map without bus side effects, the
+// extended RAM is written in the bank selected. Writes to I/O and the MIA are ignored.
+//
+// Parameters:
+//   - address: The address of the processor to write
+//   - value: The value to write
+func (c *ClementinaComputer) PokeMemory(address uint16, value uint8) {
+	switch {
+	case address < 0x8000:
+		c.chips.baseram.Poke(address, value)
+
+	case address < 0xC000:
+		c.chips.exram.Contents()[c.mapExRAMAddress(address)] = value
+	}
+}
+
+// LoadProgram writes the segments of the program to the base RAM and the bank selected of
+// the extended RAM and, if the program has an entry point, sets the program counter of the
+// processor to it.
+//
+// Parameters:
+//   - program: The program to load
+//
+// Returns:
+//   - An error, without writing anything, if a segment is placed at the I/O or MIA addresses
+func (c *ClementinaComputer) LoadProgram(program core.Program) error {
+	for _, segment := range program.Segments {
+		end := uint32(segment.Address) + uint32(len(segment.Data))
+		if end > 0xC000 {
+			return fmt.Errorf("the program writes to $%04X-$%04X, which is not RAM", max(segment.Address, 0xC000), end-1)
+		}
+	}
+
+	for _, segment := range program.Segments {
+		for i, value := range segment.Data {
+			c.PokeMemory(segment.Address+uint16(i), value)
+		}
+	}
+
+	if program.HasEntry {
+		c.chips.cpu.ForceProgramCounter(program.Entry)
+	}
+
+	return nil
+}
+
 // BaseRamPoke writes a value directly to the base RAM at the specified address.
 // This bypasses normal CPU memory access and is used for debugging or initialization.
 //
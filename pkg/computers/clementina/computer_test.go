@@ -1,6 +1,7 @@
 package clementina
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 	"time"
@@ -8,6 +9,8 @@ import (
 	"github.com/fran150/clementina-6502/assets"
 	"github.com/fran150/clementina-6502/internal/testutils"
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.bug.st/serial"
@@ -188,6 +191,117 @@ func TestClementinaEmulatorSpeedUpdatesMiaPhi2(t *testing.T) {
 	assert.Equal(t, uint32(2_500_000), phi2Reader.AppliedPhi2Hz())
 }
 
+// TestClementinaLoadStateRepeatsExecution verifies that restoring a saved state makes the
+// computer execute the same bus cycles again.
+func TestClementinaLoadStateRepeatsExecution(t *testing.T) {
+	computer, err := NewClementinaComputer()
+	require.NoError(t, err)
+	t.Cleanup(computer.Close)
+
+	step := common.NewStepContext()
+
+	computer.Reset(true)
+	for range 3 {
+		tickComputer(computer, &step)
+		step.NextCycle()
+	}
+	computer.Reset(false)
+
+	for range 5000 {
+		tickComputer(computer, &step)
+		step.NextCycle()
+	}
+
+	var state bytes.Buffer
+	require.NoError(t, computer.SaveState(&state))
+	saved := step
+
+	run := func() []uint32 {
+		values := make([]uint32, 0, 5000)
+		for range 5000 {
+			tickComputer(computer, &step)
+			values = append(values, uint32(computer.circuit.addressBus.Read())<<8|uint32(computer.circuit.dataBus.Read()))
+			step.NextCycle()
+		}
+		return values
+	}
+
+	expected := run()
+
+	require.NoError(t, computer.LoadState(&state))
+	step = saved
+
+	assert.Equal(t, expected, run())
+}
+
+// TestClementinaLoadStateRejectsOtherModels verifies that states saved by other computer
+// models or that are not states are not loaded.
+func TestClementinaLoadStateRejectsOtherModels(t *testing.T) {
+	computer, err := NewClementinaComputer()
+	require.NoError(t, err)
+	t.Cleanup(computer.Close)
+
+	var state bytes.Buffer
+	require.NoError(t, core.WriteStateHeader(&state, "beneater"))
+	assert.ErrorContains(t, computer.LoadState(&state), "beneater")
+
+	assert.ErrorIs(t, computer.LoadState(strings.NewReader("not a state file")), core.ErrInvalidState)
+}
+
+// TestClementinaFastModeExecutesOnMemoryAndSwitchesBanks verifies that the fast mode executes the
+// instructions directly on RAM, that I/O is accessed through the bus and that the window of the
+// extended RAM follows the selected bank.
+func TestClementinaFastModeExecutesOnMemoryAndSwitchesBanks(t *testing.T) {
+	computer, err := NewClementinaComputer()
+	require.NoError(t, err)
+	t.Cleanup(computer.Close)
+
+	program := []uint8{
+		0xA9, 0xAB, // LDA #$AB
+		0x8D, 0x00, 0x80, // STA $8000
+		0x8D, 0x00, 0x20, // STA $2000
+		0x8D, 0x0F, 0xC0, // STA $C00F
+		0x4C, 0x0B, 0x04, // JMP $040B
+	}
+	for i, value := range program {
+		computer.BaseRamPoke(0x0400+uint16(i), value)
+	}
+
+	computer.SetFastMode(true)
+
+	step := common.NewStepContext()
+	run := func() int {
+		ticks := 0
+		computer.chips.cpu.ForceProgramCounter(0x0400)
+
+		for start := step.Cycle; step.Cycle-start < 20_000; ticks++ {
+			tickComputer(computer, &step)
+			step.NextCycle()
+		}
+
+		return ticks
+	}
+
+	bank0 := computer.mapExRAMAddress(0x8000)
+
+	assert.Less(t, run(), 100)
+	assert.Equal(t, uint8(0xAB), computer.chips.exram.Peek(bank0))
+	assert.Equal(t, uint8(0xAB), computer.chips.baseram.Peek(0x2000))
+	assert.Equal(t, uint8(0xAB), computer.chips.via.GetOutputRegisterA())
+	assert.Equal(t, uint16(0x040B), computer.GetProgramCounter())
+
+	// Selects another bank of the extended RAM
+	computer.circuit.portABus.Write(0x01)
+	computer.BaseRamPoke(0x0401, 0xCD)
+
+	bank1 := computer.mapExRAMAddress(0x8000)
+
+	assert.Less(t, run(), 100)
+	assert.NotEqual(t, bank0, bank1)
+	assert.Equal(t, uint8(0xAB), computer.chips.exram.Peek(bank0))
+	assert.Equal(t, uint8(0xCD), computer.chips.exram.Peek(bank1))
+}
+
 func tickComputer(computer *ClementinaComputer, step *common.StepContext) {
 	computer.Tick(step)
 	computer.PostTick(step)
@@ -216,3 +330,69 @@ func waitForClementinaMiaConsoleOutput(t *testing.T, mock *testutils.SerialPortM
 		return strings.Contains(string(mock.PortTxBuffer.GetValues()), value)
 	}, time.Second, 10*time.Millisecond)
 }
+
+// TestClementinaLoadProgramWritesRamAndSetsEntry verifies that programs are loaded in the base
+// and extended RAM and that the ones placed at the I/O addresses are rejected.
+func TestClementinaLoadProgramWritesRamAndSetsEntry(t *testing.T) {
+	computer, err := NewClementinaComputer()
+	require.NoError(t, err)
+	t.Cleanup(computer.Close)
+
+	err = computer.LoadProgram(core.Program{
+		Segments: []core.ProgramSegment{
+			{Address: 0x0400, Data: []byte{0xA9, 0x01}},
+			{Address: 0xBFFF, Data: []byte{0x60}},
+		},
+		Entry:    0x0400,
+		HasEntry: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint8(0xA9), computer.chips.baseram.Peek(0x0400))
+	assert.Equal(t, uint8(0x01), computer.chips.baseram.Peek(0x0401))
+	assert.Equal(t, uint8(0x60), computer.chips.exram.Peek(computer.mapExRAMAddress(0xBFFF)))
+	assert.Equal(t, uint16(0x0400), computer.GetProgramCounter())
+
+	err = computer.LoadProgram(core.Program{
+		Segments: []core.ProgramSegment{
+			{Address: 0x0500, Data: []byte{0xEA}},
+			{Address: 0xBFFF, Data: []byte{0xEA, 0xEA}},
+		},
+	})
+	assert.ErrorContains(t, err, "$C000-$C000")
+	assert.Equal(t, uint8(0x00), computer.chips.baseram.Peek(0x0500))
+}
+
+// TestClementinaPanelButtonsDrivePortB verifies the panel buttons on port B, recorded presses
+// and that the unconnected port A is rejected.
+func TestClementinaPanelButtonsDrivePortB(t *testing.T) {
+	computer, err := NewClementinaComputer()
+	require.NoError(t, err)
+	t.Cleanup(computer.Close)
+
+	assert.Error(t, computer.SetPanelButtons([]components.PanelButton{{Key: 'a', Pin: components.PanelPortA}}))
+	require.NoError(t, computer.SetPanelButtons([]components.PanelButton{
+		{Key: 'a', Pin: components.PanelPortB + 2, Toggle: true, ActiveHigh: true},
+	}))
+
+	var stimuli []core.Stimulus
+	computer.SetStimulusHandler(func(stimulus core.Stimulus) {
+		stimuli = append(stimuli, stimulus)
+	})
+
+	computer.PressPanelKey('a')
+	require.Len(t, stimuli, 1)
+	assert.Equal(t, core.Stimulus{Kind: core.StimulusButton, Data: []byte{0}}, stimuli[0])
+
+	assert.True(t, computer.ReceivesStimulus(core.StimulusButton))
+	assert.True(t, computer.ReceivesStimulus(core.StimulusMiaInput))
+	assert.False(t, computer.ReceivesStimulus(core.StimulusKeyboard))
+
+	computer.SetStimulusHandler(nil)
+	require.NoError(t, computer.InjectStimulus(stimuli[0]))
+
+	context := common.NewStepContext()
+	computer.Tick(&context)
+
+	assert.Equal(t, uint8(0x04), computer.circuit.portBBus.Read())
+}
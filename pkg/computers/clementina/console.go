@@ -54,20 +54,41 @@ func newClementinaEmulatorConsole(config clementinaEmulatorConsoleConfig) *cleme
 	wm := config.WindowManager
 
 	// Initialize all windows
-	wm.AddWindow("code", ui.NewCodeWindow(computer.chips.cpu, computer.getPotentialOperators))
+	codeWindow := ui.NewCodeWindow(computer.chips.cpu, computer.getPotentialOperators)
+	wm.AddWindow("code", codeWindow)
 	wm.AddWindow("speed", ui.NewSpeedWindow(config.emulator.speedController))
 	wm.AddWindow("cpu", ui.NewCpuWindow(computer.chips.cpu))
 	wm.AddWindow("via", ui.NewViaWindow(computer.chips.via))
+	wm.AddWindow("panel", ui.NewPanelWindow(computer.chips.panel))
 	wm.AddWindow("baseram", ui.NewMemoryWindow(computer.chips.baseram))
 	wm.AddWindow("exram", ui.NewMemoryWindow(computer.chips.exram))
-	wm.AddWindow("goto", ui.NewMemoryWindowGoToForm())
+	gotoForm := ui.NewMemoryWindowGoToForm()
+	wm.AddWindow("goto", gotoForm)
 	busWindow := ui.NewBusWindow()
 	wm.AddWindow("bus", busWindow)
-	wm.AddWindow("breakpoint", ui.NewBreakPointForm(config.emulator.breakpointManager))
-	wm.AddWindow("options", ui.NewOptionsWindow(menuOptions))
+	breakpointForm := ui.NewBreakPointForm(config.emulator.breakpointManager)
+	wm.AddWindow("breakpoint", breakpointForm)
+	wm.AddWindow("watchpoint", ui.NewWatchpointForm(config.emulator.watchpointManager))
+	callStackWindow := ui.NewCallStackWindow(computer.chips.cpu)
+	wm.AddWindow("callstack", callStackWindow)
+	wm.AddWindow("source", ui.NewSourceWindow(computer.chips.cpu, computer.sourceMap))
+	profilerWindow := ui.NewProfilerWindow(config.emulator.profiler)
+	wm.AddWindow("profiler", profilerWindow)
+	optionsWindow := ui.NewOptionsWindow(menuOptions)
+	optionsWindow.SetReloadStatus(func() core.ReloadStatus { return config.emulator.GetReloadStatus() })
+	optionsWindow.SetLastError(func() error { return config.emulator.GetLastError() })
+	wm.AddWindow("options", optionsWindow)
 
 	initializeBusWindow(computer, busWindow)
 
+	if computer.symbols != nil {
+		codeWindow.SetSymbolTable(computer.symbols)
+		gotoForm.SetSymbolTable(computer.symbols)
+		breakpointForm.SetSymbolTable(computer.symbols)
+		callStackWindow.SetSymbolTable(computer.symbols)
+		profilerWindow.SetSymbolTable(computer.symbols)
+	}
+
 	console.initializeLayout()
 
 	// Set initial active window
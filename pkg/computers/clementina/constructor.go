@@ -6,12 +6,13 @@ import (
 	"github.com/fran150/clementina-6502/pkg/components/cpu"
 	"github.com/fran150/clementina-6502/pkg/components/memory"
 	"github.com/fran150/clementina-6502/pkg/components/mia"
+	"github.com/fran150/clementina-6502/pkg/components/panel"
 	"github.com/fran150/clementina-6502/pkg/components/via"
 	"github.com/fran150/clementina-6502/pkg/computers/clementina/modules"
 )
 
 func NewClementinaComputer() (*ClementinaComputer, error) {
-	return newClementinaComputer(mia.NewEmulatedMia())
+	return newClementinaComputer(nil, mia.NewEmulatedMia())
 }
 
 func NewClementinaComputerWithVideoUDP(bindAddress string) (*ClementinaComputer, error) {
@@ -20,15 +21,16 @@ func NewClementinaComputerWithVideoUDP(bindAddress string) (*ClementinaComputer,
 		return nil, err
 	}
 
-	return newClementinaComputer(chip)
+	return newClementinaComputer(nil, chip)
 }
 
 // NewClementinaComputerWithUDP creates a Clementina computer whose emulated MIA
 // runs the UDP video and Wi-Fi input services. An empty address disables the
-// matching service; if both are empty no UDP service is started.
-func NewClementinaComputerWithUDP(videoAddress, inputAddress string) (*ClementinaComputer, error) {
+// matching service; if both are empty no UDP service is started. If the processor
+// is nil the computer uses a WDC 65C02S.
+func NewClementinaComputerWithUDP(processor components.Cpu65C02, videoAddress, inputAddress string) (*ClementinaComputer, error) {
 	if videoAddress == "" && inputAddress == "" {
-		return NewClementinaComputer()
+		return newClementinaComputer(processor, mia.NewEmulatedMia())
 	}
 
 	chip, err := mia.NewEmulatedMiaWithUDP(videoAddress, inputAddress)
@@ -36,21 +38,28 @@ func NewClementinaComputerWithUDP(videoAddress, inputAddress string) (*Clementin
 		return nil, err
 	}
 
-	return newClementinaComputer(chip)
+	return newClementinaComputer(processor, chip)
 }
 
-func NewClementinaGPIOComputer(chipName string) (*ClementinaComputer, error) {
+// NewClementinaGPIOComputer creates a Clementina computer connected to a MIA running on
+// a Pico through the specified GPIO chip. If the processor is nil the computer uses a
+// WDC 65C02S.
+func NewClementinaGPIOComputer(processor components.Cpu65C02, chipName string) (*ClementinaComputer, error) {
 	chip, err := mia.NewPicoMia(chipName)
 	if err != nil {
 		return nil, err
 	}
 
-	return newClementinaComputer(chip)
+	return newClementinaComputer(processor, chip)
 }
 
-func newClementinaComputer(mia components.MiaChip) (*ClementinaComputer, error) {
+func newClementinaComputer(processor components.Cpu65C02, mia components.MiaChip) (*ClementinaComputer, error) {
+	if processor == nil {
+		processor = cpu.NewCpu65C02S()
+	}
+
 	chips := &chips{
-		cpu:      cpu.NewCpu65C02S(),
+		cpu:      processor,
 		baseram:  memory.NewRam(memory.RAM_SIZE_32K),
 		exram:    memory.NewRam(memory.RAM_SIZE_512K),
 		via:      via.NewVia65C22S(),
@@ -158,6 +167,7 @@ func newClementinaComputer(mia components.MiaChip) (*ClementinaComputer, error)
 		cpuReset:        buses.NewStandaloneLine(true),
 		miaResetRequest: buses.NewStandaloneLine(true),
 		cpuRW:           buses.NewStandaloneLine(true),
+		cpuSync:         buses.NewStandaloneLine(false),
 		miaBus:          miaBus,
 		exramBus:        exRamBus,
 		exramBusHigh:    exRamBusHigh,
@@ -193,6 +203,9 @@ func newClementinaComputer(mia components.MiaChip) (*ClementinaComputer, error)
 	chips.cpu.BusEnable().Connect(circuit.vcc)
 	chips.cpu.ReadWrite().Connect(circuit.cpuRW)
 
+	// SYNC is not used by other chips, the line allows to observe the opcode fetches
+	chips.cpu.Sync().Connect(circuit.cpuSync)
+
 	// Connect the CPU to the CS Logic
 	chips.csLogic.A1(0).Connect(addressBus10)
 	chips.csLogic.A1(1).Connect(addressBus11)
@@ -233,6 +246,14 @@ func newClementinaComputer(mia components.MiaChip) (*ClementinaComputer, error)
 	chips.via.RegisterSelect(0).Connect(addressBus0)
 	chips.via.PeripheralPortB().Connect(circuit.portBBus)
 
+	// I/O panel without buttons, they are added with SetPanelButtons
+	ioPanel, err := panel.NewIOPanel(chips.via, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	chips.panel = ioPanel
+
 	// EXRam connections
 	chips.exram.AddressBus().Connect(circuit.exramBus)
 	chips.exram.HiAddressBus().Connect(circuit.exramBusHigh)
@@ -19,6 +19,8 @@ type clementinaEmulator struct {
 	core.BaseEmulator
 	speedController   core.SpeedController
 	breakpointManager core.BreakpointManager
+	watchpointManager core.WatchpointManager
+	profiler          core.Profiler
 	computer          *ClementinaComputer
 }
 
@@ -36,14 +38,28 @@ type clementinaEmulator struct {
 //   - error: Any error that occurred during initialization
 func NewClemetinaEmulator(computer *ClementinaComputer, speed float64, displayFPS int) (core.BaseEmulator, error) {
 	speedController := newMiaSyncedSpeedController(computer, controllers.NewSpeedController(speed))
-	breakPointManager := managers.NewBreakpointManager()
+	breakPointManager := managers.NewConditionalBreakpointManager(computer.chips.cpu, computer.PeekMemory)
+	watchpointManager := managers.NewWatchpointManager(computer.PeekMemory)
+	traceLogger := managers.NewTraceLogger(computer.chips.cpu, computer.PeekMemory)
+	waveformRecorder := managers.NewWaveformRecorder("clementina", computer.getWaveformSignals())
+	profiler := managers.NewProfiler(computer.chips.cpu, computer.symbols)
+	coverageRecorder := managers.NewCoverageRecorder(computer.chips.cpu, computer.PeekMemory, computer.symbols, coverageRegions)
+	inputRecorder := managers.NewInputRecorder()
 	windowManager := terminal.NewWindowManager()
 	navigationManager := managers.NewNavigationManager()
 
+	// The execution can only be recorded if the MIA in use can save its state
+	var historyManager core.HistoryManager
+	if _, ok := computer.chips.mia.(core.Snapshotable); ok {
+		historyManager = managers.NewHistoryManager(computer, managers.DefaultSnapshotInterval, managers.DefaultSnapshotCount)
+	}
+
 	emulator := &clementinaEmulator{
 		computer:          computer,
 		speedController:   speedController,
 		breakpointManager: breakPointManager,
+		watchpointManager: watchpointManager,
+		profiler:          profiler,
 	}
 
 	console := newClementinaEmulatorConsole(clementinaEmulatorConsoleConfig{
@@ -64,10 +80,20 @@ func NewClemetinaEmulator(computer *ClementinaComputer, speed float64, displayFP
 
 	emulatorConfig := emulation.EmulatorConfig{
 		Computer:          computer,
+		Processor:         computer.chips.cpu,
 		Console:           console,
 		Loop:              loop,
 		SpeedController:   speedController,
 		BreakpointManager: breakPointManager,
+		WatchpointManager: watchpointManager,
+		SourceMap:         computer.sourceMap,
+		HistoryManager:    historyManager,
+		TraceLogger:       traceLogger,
+		WaveformRecorder:  waveformRecorder,
+		Profiler:          profiler,
+		CoverageRecorder:  coverageRecorder,
+		InputRecorder:     inputRecorder,
+		FileWatcher:       managers.NewFileWatcher(managers.DefaultWatchInterval),
 	}
 
 	emulator.BaseEmulator = emulation.NewBaseEmulator(emulatorConfig)
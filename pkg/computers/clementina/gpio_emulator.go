@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/fran150/clementina-6502/pkg/common"
 	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/fran150/clementina-6502/pkg/core/controllers"
 	"github.com/fran150/clementina-6502/pkg/core/emulation"
@@ -19,6 +20,8 @@ type clementinaGPIOEmulator struct {
 	core.BaseEmulator
 	speedController   core.SpeedController
 	breakpointManager core.BreakpointManager
+	watchpointManager core.WatchpointManager
+	profiler          core.Profiler
 	computer          *ClementinaComputer
 }
 
@@ -34,7 +37,11 @@ type clementinaGPIOEmulator struct {
 //   - error: Any error that occurred during initialization
 func NewClemetinaGPIOEmulator(computer *ClementinaComputer, displayFPS int, chipName string) (core.BaseEmulator, error) {
 	speedController := controllers.NewSpeedController(1.0) // Dummy speed controller for UI compatibility
-	breakPointManager := managers.NewBreakpointManager()
+	breakPointManager := managers.NewConditionalBreakpointManager(computer.chips.cpu, computer.PeekMemory)
+	watchpointManager := managers.NewWatchpointManager(computer.PeekMemory)
+	traceLogger := managers.NewTraceLogger(computer.chips.cpu, computer.PeekMemory)
+	profiler := managers.NewProfiler(computer.chips.cpu, computer.symbols)
+	coverageRecorder := managers.NewCoverageRecorder(computer.chips.cpu, computer.PeekMemory, computer.symbols, coverageRegions)
 	windowManager := terminal.NewWindowManager()
 	navigationManager := managers.NewNavigationManager()
 
@@ -42,6 +49,8 @@ func NewClemetinaGPIOEmulator(computer *ClementinaComputer, displayFPS int, chip
 		computer:          computer,
 		speedController:   speedController,
 		breakpointManager: breakPointManager,
+		watchpointManager: watchpointManager,
+		profiler:          profiler,
 	}
 
 	// Cast to *clementinaEmulator for console compatibility
@@ -70,10 +79,16 @@ func NewClemetinaGPIOEmulator(computer *ClementinaComputer, displayFPS int, chip
 
 	emulatorConfig := emulation.EmulatorConfig{
 		Computer:          computer,
+		Processor:         computer.chips.cpu,
 		Console:           console,
 		Loop:              loop,
 		SpeedController:   speedController,
 		BreakpointManager: breakPointManager,
+		WatchpointManager: watchpointManager,
+		SourceMap:         computer.sourceMap,
+		TraceLogger:       traceLogger,
+		Profiler:          profiler,
+		CoverageRecorder:  coverageRecorder,
 	}
 
 	emulator.BaseEmulator = emulation.NewBaseEmulator(emulatorConfig)
@@ -86,3 +101,8 @@ func NewClemetinaGPIOEmulator(computer *ClementinaComputer, displayFPS int, chip
 
 	return emulator, nil
 }
+
+// RunHeadless returns an error, the cycles of the real MIA are clocked by the GPIO loop.
+func (e *clementinaGPIOEmulator) RunHeadless(conditions core.StopConditions) (*common.StepContext, core.RunResult, error) {
+	return nil, core.RunResult{}, fmt.Errorf("headless mode is not available with the real MIA connected through GPIO")
+}
@@ -64,6 +64,62 @@ func createMenuOptions(console *clementinaEmulatorConsole, emulator *clementinaE
 								emulator.Step()
 							},
 						},
+						{
+							Rune:           'i',
+							KeyName:        "I",
+							KeyDescription: "Step Instruction",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.StepInstruction()
+							},
+						},
+						{
+							Rune:           'o',
+							KeyName:        "O",
+							KeyDescription: "Step Over",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.StepOver()
+							},
+						},
+						{
+							Rune:           'u',
+							KeyName:        "U",
+							KeyDescription: "Step Out",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.StepOut()
+							},
+						},
+						{
+							Rune:           'l',
+							KeyName:        "L",
+							KeyDescription: "Step Line",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.StepSourceLine()
+							},
+						},
+						{
+							Rune:           'k',
+							KeyName:        "K",
+							KeyDescription: "Step Back",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.StepBack()
+							},
+						},
+						{
+							Rune:           'j',
+							KeyName:        "J",
+							KeyDescription: "Step Back Cycle",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.StepBackCycle()
+							},
+						},
+						{
+							Rune:           'n',
+							KeyName:        "N",
+							KeyDescription: "Run Back to Breakpoint",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.RunBack()
+							},
+						},
 						{
 							Rune:           'b',
 							KeyName:        "B",
@@ -83,6 +139,36 @@ func createMenuOptions(console *clementinaEmulatorConsole, emulator *clementinaE
 										console.RemoveSelectedBreakpointAddress()
 									},
 								},
+								{
+									Key:            tcell.KeyCtrlE,
+									KeyName:        "Ctrl-E",
+									KeyDescription: "Edit Selected Breakpoint",
+									Action: func(option *ui.OptionsWindowMenuOption) {
+										console.EditSelectedBreakpointAddress()
+									},
+									DoNotForward: true,
+								},
+							},
+						},
+						{
+							Rune:           'w',
+							KeyName:        "W",
+							KeyDescription: "Watchpoints",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								console.SwitchToWatchpointConfigMode()
+							},
+							BackAction: func(option *ui.OptionsWindowMenuOption) {
+								console.ReturnToPreviousWindow()
+							},
+							SubMenu: []*ui.OptionsWindowMenuOption{
+								{
+									Rune:           'r',
+									KeyName:        "R",
+									KeyDescription: "Remove Selected Watchpoint",
+									Action: func(option *ui.OptionsWindowMenuOption) {
+										console.RemoveSelectedWatchpoint()
+									},
+								},
 							},
 						},
 					},
@@ -98,7 +184,7 @@ func createMenuOptions(console *clementinaEmulatorConsole, emulator *clementinaE
 							KeyDescription: "Speed Up",
 							Action: func(option *ui.OptionsWindowMenuOption) {
 								console.ShowEmulationSpeedPopup()
-								emulator.speedController.SpeedUp()
+								emulator.SpeedUp()
 							},
 							DoNotForward: true,
 						},
@@ -108,12 +194,83 @@ func createMenuOptions(console *clementinaEmulatorConsole, emulator *clementinaE
 							KeyDescription: "Speed Down",
 							Action: func(option *ui.OptionsWindowMenuOption) {
 								console.ShowEmulationSpeedPopup()
-								emulator.speedController.SpeedDown()
+								emulator.SpeedDown()
 							},
 							DoNotForward: true,
 						},
 					},
 				},
+				{
+					Rune:           't',
+					KeyName:        "T",
+					KeyDescription: "State",
+					SubMenu: []*ui.OptionsWindowMenuOption{
+						{
+							Rune:           's',
+							KeyName:        "S",
+							KeyDescription: "Save",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.SaveStateFile(emulator.computer.getStateFile(), showStateFileResult(option, "Saved", "Save Failed"))
+							},
+						},
+						{
+							Rune:           'l',
+							KeyName:        "L",
+							KeyDescription: "Load",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.LoadStateFile(emulator.computer.getStateFile(), showStateFileResult(option, "Loaded", "Load Failed"))
+							},
+						},
+					},
+				},
+				{
+					Rune:           'l',
+					KeyName:        "L",
+					KeyDescription: "Trace",
+					Action: func(option *ui.OptionsWindowMenuOption) {
+						if emulator.IsTracing() {
+							option.KeyDescription = traceResult(emulator.StopTrace(), "Trace Off")
+						} else {
+							option.KeyDescription = traceResult(emulator.StartTrace(emulator.computer.getTraceFile()), "Trace On")
+						}
+					},
+				},
+				{
+					Rune:           'p',
+					KeyName:        "P",
+					KeyDescription: "Profiler",
+					SubMenu: []*ui.OptionsWindowMenuOption{
+						{
+							Rune:           's',
+							KeyName:        "S",
+							KeyDescription: "Start/Stop",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								if emulator.IsProfiling() {
+									emulator.StopProfiling()
+									option.KeyDescription = "Profiling Off"
+								} else {
+									option.KeyDescription = profileResult(emulator.StartProfiling(), "Profiling On")
+								}
+							},
+						},
+						{
+							Rune:           'c',
+							KeyName:        "C",
+							KeyDescription: "Clear",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								emulator.ClearProfile()
+							},
+						},
+						{
+							Rune:           'x',
+							KeyName:        "X",
+							KeyDescription: "Export",
+							Action: func(option *ui.OptionsWindowMenuOption) {
+								option.KeyDescription = profileResult(emulator.ExportProfile(emulator.computer.getProfileFile()), "Exported")
+							},
+						},
+					},
+				},
 			},
 		},
 		{
@@ -163,7 +320,53 @@ func createMenuOptions(console *clementinaEmulatorConsole, emulator *clementinaE
 						console.ShowWindow("bus")
 					},
 				},
+				{
+					Key:            tcell.KeyF6,
+					KeyName:        "F6",
+					KeyDescription: "Call Stack",
+					Action: func(option *ui.OptionsWindowMenuOption) {
+						console.ShowWindow("callstack")
+					},
+				},
+				{
+					Key:            tcell.KeyF7,
+					KeyName:        "F7",
+					KeyDescription: "Source",
+					Action: func(option *ui.OptionsWindowMenuOption) {
+						console.ShowWindow("source")
+					},
+				},
+				{
+					Key:            tcell.KeyF8,
+					KeyName:        "F8",
+					KeyDescription: "Profiler",
+					Action: func(option *ui.OptionsWindowMenuOption) {
+						console.ShowWindow("profiler")
+					},
+				},
+				{
+					Key:            tcell.KeyF9,
+					KeyName:        "F9",
+					KeyDescription: "I/O Panel",
+					Action: func(option *ui.OptionsWindowMenuOption) {
+						console.ShowWindow("panel")
+					},
+				},
+			},
+		},
+		{
+			Rune:           'p',
+			KeyName:        "P",
+			KeyDescription: "Panel Buttons",
+			Action: func(option *ui.OptionsWindowMenuOption) {
+				console.ShowWindow("panel")
+			},
+			KeyCapture: func(event *tcell.EventKey) {
+				if event.Key() == tcell.KeyRune {
+					emulator.computer.PressPanelKey(event.Rune())
+				}
 			},
+			SubMenu: []*ui.OptionsWindowMenuOption{},
 		},
 		{
 			Rune:           'q',
@@ -176,6 +379,60 @@ func createMenuOptions(console *clementinaEmulatorConsole, emulator *clementinaE
 	}
 }
 
+// showStateFileResult returns a function that shows the result of saving or loading the
+// state file in the description of the menu option.
+//
+// Parameters:
+//   - option: The menu option that saves or loads the state file
+//   - success: The description shown if the operation succeeded
+//   - failure: The description shown if the operation failed
+//
+// Returns:
+//   - A function that receives the result of the operation
+func showStateFileResult(option *ui.OptionsWindowMenuOption, success string, failure string) func(err error) {
+	return func(err error) {
+		if err != nil {
+			option.KeyDescription = failure
+		} else {
+			option.KeyDescription = success
+		}
+	}
+}
+
+// traceResult returns the description of the trace menu option after starting or stopping
+// the trace.
+//
+// Parameters:
+//   - err: The error returned when starting or stopping the trace
+//   - success: The description shown if there was no error
+//
+// Returns:
+//   - The description of the menu option
+func traceResult(err error, success string) string {
+	if err != nil {
+		return "Trace Failed"
+	}
+
+	return success
+}
+
+// profileResult returns the description of a profiler menu option after starting the
+// profiler or exporting the profile.
+//
+// Parameters:
+//   - err: The error returned by the operation
+//   - success: The description shown if there was no error
+//
+// Returns:
+//   - The description of the menu option
+func profileResult(err error, success string) string {
+	if err != nil {
+		return "Profile Failed"
+	}
+
+	return success
+}
+
 // createMemoryWindowSubMenu creates navigation options for memory windows.
 // It provides scrolling functionality and go-to navigation for memory views.
 //
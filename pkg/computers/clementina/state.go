@@ -0,0 +1,146 @@
+package clementina
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Name of the computer model written in the header of the saved states
+const stateModel = "clementina"
+
+// DefaultStateFile is the file used to save and load the state from the menu if no other
+// file is set
+const DefaultStateFile = "clementina.state"
+
+// Values of the buses and lines that connect the chips of the computer. The mapped buses
+// are not included as their values are taken from the buses they map. This struct is
+// written and read with encoding/binary, so it must only have fixed size fields.
+type circuitState struct {
+	AddressBus uint16
+	DataBus    uint8
+	PortABus   uint8
+	PortBBus   uint8
+
+	CpuIRQ          bool
+	MiaIRQ          bool
+	ViaIRQ          bool
+	CpuReset        bool
+	MiaResetRequest bool
+	CpuRW           bool
+	MiaCS           bool
+	Vcc             bool
+	Ground          bool
+}
+
+// SaveState writes a header with the format version and computer model, followed by the
+// state of all the chips of the computer and the values of the buses and lines that
+// connect them. It fails if the MIA in use can't save its state, as it happens with the
+// real chip connected through GPIO.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//
+// Returns:
+//   - An error if the state can't be written
+func (c *ClementinaComputer) SaveState(writer io.Writer) error {
+	circuit := c.circuit
+
+	state := circuitState{
+		AddressBus: circuit.addressBus.Read(),
+		DataBus:    circuit.dataBus.Read(),
+		PortABus:   circuit.portABus.Read(),
+		PortBBus:   circuit.portBBus.Read(),
+
+		CpuIRQ:          circuit.cpuIRQ.Status(),
+		MiaIRQ:          circuit.miaIRQ.Status(),
+		ViaIRQ:          circuit.viaIRQ.Status(),
+		CpuReset:        circuit.cpuReset.Status(),
+		MiaResetRequest: circuit.miaResetRequest.Status(),
+		CpuRW:           circuit.cpuRW.Status(),
+		MiaCS:           circuit.miaCS.Status(),
+		Vcc:             circuit.vcc.Status(),
+		Ground:          circuit.ground.Status(),
+	}
+
+	if err := core.WriteStateHeader(writer, stateModel); err != nil {
+		return err
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	return core.SaveStates(writer, c.getSnapshotables()...)
+}
+
+// LoadState restores the state of the chips, buses and lines of the computer from a
+// state previously written by SaveState. States saved by other computer models or
+// with a different format version are rejected.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//
+// Returns:
+//   - An error if the state can't be read or is not valid
+func (c *ClementinaComputer) LoadState(reader io.Reader) error {
+	if err := core.ReadStateHeader(reader, stateModel); err != nil {
+		return err
+	}
+
+	var state circuitState
+
+	if err := binary.Read(reader, binary.LittleEndian, &state); err != nil {
+		return err
+	}
+
+	if err := core.LoadStates(reader, c.getSnapshotables()...); err != nil {
+		return err
+	}
+
+	circuit := c.circuit
+
+	circuit.addressBus.Write(state.AddressBus)
+	circuit.dataBus.Write(state.DataBus)
+	circuit.portABus.Write(state.PortABus)
+	circuit.portBBus.Write(state.PortBBus)
+
+	circuit.cpuIRQ.Set(state.CpuIRQ)
+	circuit.miaIRQ.Set(state.MiaIRQ)
+	circuit.viaIRQ.Set(state.ViaIRQ)
+	circuit.cpuReset.Set(state.CpuReset)
+	circuit.miaResetRequest.Set(state.MiaResetRequest)
+	circuit.cpuRW.Set(state.CpuRW)
+	circuit.miaCS.Set(state.MiaCS)
+	circuit.vcc.Set(state.Vcc)
+	circuit.ground.Set(state.Ground)
+
+	return nil
+}
+
+// getSnapshotables returns the chips that hold state in the order they are saved. The
+// chip select and OE / RW modules are not included as their outputs are computed again
+// on every cycle.
+func (c *ClementinaComputer) getSnapshotables() []any {
+	chips := c.chips
+
+	return []any{chips.cpu, chips.baseram, chips.exram, chips.via, chips.mia, chips.panel}
+}
+
+// SetStateFile sets the file used to save and load the state of the computer from the menu.
+//
+// Parameters:
+//   - path: Path of the state file, empty to use DefaultStateFile
+func (c *ClementinaComputer) SetStateFile(path string) {
+	c.stateFile = path
+}
+
+// getStateFile returns the file used to save and load the state of the computer from the menu.
+func (c *ClementinaComputer) getStateFile() string {
+	if c.stateFile == "" {
+		return DefaultStateFile
+	}
+
+	return c.stateFile
+}
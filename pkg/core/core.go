@@ -1,6 +1,11 @@
 package core
 
-import "github.com/fran150/clementina-6502/pkg/common"
+import (
+	"io"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+)
 
 // Ticker defines the core emulation logic interface.
 // This represents the pure emulation functionality without lifecycle concerns.
@@ -94,6 +99,47 @@ type ComputerCore interface {
 	Reset(status bool)
 }
 
+// Snapshotable is implemented by components and computers that can save their complete
+// internal state and restore it later.
+type Snapshotable interface {
+	// SaveState writes the current state. Returns an error if the state can't be written.
+	SaveState(writer io.Writer) error
+
+	// LoadState restores a state previously written by SaveState.
+	// Returns an error if the state can't be read or is not valid.
+	LoadState(reader io.Reader) error
+}
+
+// MemoryPeeker is implemented by the computers that allow reading their memory map without
+// the side effects of a bus access.
+type MemoryPeeker interface {
+	// PeekMemory returns the value at the address, unmapped addresses return 0.
+	PeekMemory(address uint16) uint8
+}
+
+// ProgramSegment is a block of bytes of a program placed at an address of the processor.
+type ProgramSegment struct {
+	Address uint16
+	Data    []byte
+}
+
+// Program is a program loaded from a file, with its segments and the address where its
+// execution starts if the file specifies one.
+type Program struct {
+	Segments []ProgramSegment
+	Entry    uint16
+	HasEntry bool
+}
+
+// ProgramLoadable is implemented by the computers that allow loading programs in their
+// memory map.
+type ProgramLoadable interface {
+	// LoadProgram writes the segments of the program to memory and, if the program has an
+	// entry point, sets the program counter of the processor to it. Returns an error, without
+	// writing anything, if a segment is placed at addresses that can't be written.
+	LoadProgram(program Program) error
+}
+
 // Runnable defines the interface for managing the execution state of an emulator.
 // This interface provides basic start/stop functionality and status checking.
 type Runnable interface {
@@ -130,10 +176,142 @@ type Steppable interface {
 	// Step executes a single emulation step and then pauses.
 	Step()
 
+	// StepInstruction runs until the processor fetches the next opcode and then pauses.
+	StepInstruction()
+
+	// StepOver works like StepInstruction but executes subroutine calls (JSR and BRK)
+	// until they return, pausing at the instruction following the call.
+	StepOver()
+
+	// StepOut runs until the RTS or RTI that returns from the current subroutine or
+	// interrupt handler and pauses at the instruction where the execution returns.
+	StepOut()
+
+	// StepSourceLine runs until the processor fetches an opcode generated by a source
+	// line different from the current one and then pauses.
+	StepSourceLine()
+
 	// IsStepping returns true if the emulator is executing a single emulation step and will pause when finished.
 	IsStepping() bool
 }
 
+// Rewindable defines the interface for running an emulator backwards using the recorded
+// execution history. All methods have no effect if the emulator is not paused or keeps no history.
+type Rewindable interface {
+	// StepBack rewinds the emulation to the previous opcode fetch and then pauses.
+	StepBack()
+
+	// StepBackCycle rewinds the emulation by a single cycle and then pauses.
+	StepBackCycle()
+
+	// RunBack rewinds the emulation to the most recent opcode fetch of an address with a
+	// breakpoint, or to the oldest cycle in the history if there is none, and then pauses.
+	RunBack()
+}
+
+// StatePersistable defines the interface for saving the state of an emulator to a file and
+// loading it back later. The files are saved and loaded by the emulation loop before the
+// next cycle or display refresh, and the result is reported to the done function, which
+// is also called from the emulation loop.
+type StatePersistable interface {
+	// SaveStateFile saves the state of the computer and the emulation to the file.
+	SaveStateFile(path string, done func(err error))
+
+	// LoadStateFile restores the state of the computer and the emulation from a file
+	// previously saved with SaveStateFile. If the file can't be loaded the computer is
+	// left as it was.
+	LoadStateFile(path string, done func(err error))
+}
+
+// Traceable defines the interface for logging the instructions executed by an emulator.
+type Traceable interface {
+	// StartTrace creates the file, or truncates it if it exists, and starts writing to it
+	// one line per instruction executed. Returns an error if the file can't be created.
+	StartTrace(path string) error
+
+	// StopTrace stops logging the executed instructions and closes the trace file.
+	// Returns an error if the end of the log can't be written, or the error that stopped
+	// the trace if the log couldn't be written while emulating.
+	StopTrace() error
+
+	// IsTracing returns true if the executed instructions are being logged.
+	IsTracing() bool
+}
+
+// WaveformRecordable defines the interface for dumping the signals of the computer in each
+// cycle to a Value Change Dump file.
+type WaveformRecordable interface {
+	// StartWaveform creates the file, or truncates it if it exists, and starts writing to it
+	// the changes of the signals of the computer. Returns an error if the file can't be created.
+	StartWaveform(path string) error
+
+	// StopWaveform stops dumping the signals and closes the file.
+	// Returns an error if the end of the dump can't be written, or the error that stopped
+	// the dump if it couldn't be written while emulating.
+	StopWaveform() error
+
+	// IsRecordingWaveform returns true if the signals are being dumped.
+	IsRecordingWaveform() bool
+}
+
+// Profileable defines the interface for profiling the execution of an emulator.
+type Profileable interface {
+	// StartProfiling starts counting the instructions and cycles executed at each address.
+	// Returns an error if the emulator doesn't support profiling.
+	StartProfiling() error
+
+	// StopProfiling stops counting, the collected profile is kept until it's cleared.
+	StopProfiling()
+
+	// IsProfiling returns true if the execution is being profiled.
+	IsProfiling() bool
+
+	// ClearProfile discards the collected profile.
+	ClearProfile()
+
+	// ExportProfile writes the flat report of the collected profile to the file and the
+	// stacks in collapsed format, used by flame graph tools, to the same file with the
+	// ".folded" extension added. Returns an error if the files can't be written.
+	ExportProfile(path string) error
+}
+
+// Coverable defines the interface for recording which addresses were executed, read or
+// written by the program running on an emulator.
+type Coverable interface {
+	// StartCoverage starts recording the accesses done by the processor to each address.
+	// Returns an error if the emulator doesn't support coverage.
+	StartCoverage() error
+
+	// StopCoverage stops recording, the collected coverage is kept.
+	StopCoverage()
+
+	// IsRecordingCoverage returns true if the accesses of the processor are being recorded.
+	IsRecordingCoverage() bool
+
+	// ExportCoverage writes the annotated listing of the memory of the computer to the file
+	// and, if line information of the program is loaded, the line coverage in lcov format
+	// to the same file with the ".info" extension added. Returns an error if the files
+	// can't be written.
+	ExportCoverage(path string) error
+}
+
+// HeadlessRunnable defines the interface for running an emulator without the console, as
+// fast as possible, until a stop condition is met.
+type HeadlessRunnable interface {
+	// RunHeadless executes the emulation in the calling goroutine until one of the conditions
+	// is met. Returns the context of the emulation and the condition that stopped it, or an
+	// error if the emulator can't be run headless.
+	RunHeadless(conditions StopConditions) (*common.StepContext, RunResult, error)
+}
+
+// VirtualTimeable defines the interface for emulators and loops whose time can be derived from
+// the cycle count instead of the wall clock, making the runs reproducible.
+type VirtualTimeable interface {
+	// SetVirtualTime sets the frequency of the clock in Hz used to calculate the time from
+	// the cycle count, 0 uses the wall clock. It applies from the next time the emulation starts.
+	SetVirtualTime(frequency uint64)
+}
+
 // Resetable defines the interface for managing reset functionality of an emulator.
 // This interface provides control over the reset state of the emulated computer.
 type Resetable interface {
@@ -147,11 +325,79 @@ type Resetable interface {
 	IsResetting() bool
 }
 
+// SpeedAdjustable defines the interface for changing the speed of an emulator from the console.
+// The changes are applied and recorded with the input of the computer.
+type SpeedAdjustable interface {
+	// SpeedUp increases the target speed of the emulation.
+	SpeedUp()
+
+	// SpeedDown decreases the target speed of the emulation.
+	SpeedDown()
+}
+
+// InputRecordable defines the interface for recording the input received by an emulated
+// computer from outside of the emulation and replaying it later at the same cycles.
+type InputRecordable interface {
+	// StartRecording creates the file, or truncates it if it exists, and starts writing to it
+	// every stimulus received with the cycle at which it's applied.
+	// Returns an error if the file can't be created or the emulator doesn't support recording.
+	StartRecording(path string) error
+
+	// StopRecording stops recording the input and closes the file.
+	// Returns an error if the end of the recording can't be written.
+	StopRecording() error
+
+	// IsRecording returns true if the input is being recorded.
+	IsRecording() bool
+
+	// StartReplay injects the stimuli at their cycles, ignoring the input received meanwhile.
+	// The stimuli must be sorted by cycle. Returns an error if the emulator doesn't support
+	// replays or the computer doesn't receive any of the kinds of stimuli. If a stimulus
+	// can't be injected the replay is stopped and the error is reported by GetLastError.
+	StartReplay(stimuli []Stimulus) error
+
+	// IsReplaying returns true if there are stimuli of a replay waiting to be injected.
+	IsReplaying() bool
+}
+
+// ReloadStatus describes the watch of the program files of an emulator and the result of
+// the last reload.
+type ReloadStatus struct {
+	Watching   bool      // The files are being watched
+	Reloads    int       // Number of reloads completed without error
+	LastReload time.Time // Time of the last reload completed without error
+	Err        error     // Error of the last reload, nil if it succeeded
+}
+
+// ProgramWatchable defines the interface for reloading the programs of an emulated computer
+// when their files change.
+type ProgramWatchable interface {
+	// WatchPrograms watches the files and, when any of them changes, calls the reload function
+	// from the emulation loop and resets the computer. Breakpoints are kept across reloads.
+	// Returns an error if the files can't be watched or the emulator doesn't support it.
+	WatchPrograms(paths []string, reload func() error) error
+
+	// StopWatchingPrograms stops watching the files. If they are not watched, this method has
+	// no effect.
+	StopWatchingPrograms()
+
+	// GetReloadStatus returns the status of the watch and the result of the last reload.
+	GetReloadStatus() ReloadStatus
+}
+
+// ErrorReportable defines the interface for reporting the errors that occur while emulating,
+// in the emulation loop, to the console.
+type ErrorReportable interface {
+	// GetLastError returns the last error that occurred while emulating, nil if there was none.
+	GetLastError() error
+}
+
 // EmulationLoop defines the interface for managing emulation execution.
 // This handles the lifecycle and timing of the emulation process.
 type EmulationLoop interface {
 	Runnable
 	Pausable
+	VirtualTimeable
 
 	// SetPanicHandler sets the panic handler for loop failures.
 	SetPanicHandler(handler func(loopType string, panicData any) bool)
@@ -165,7 +411,19 @@ type BaseEmulator interface {
 	Runnable
 	Pausable
 	Steppable
+	Rewindable
+	StatePersistable
+	Traceable
+	WaveformRecordable
+	Profileable
+	Coverable
+	HeadlessRunnable
+	VirtualTimeable
 	Resetable
+	SpeedAdjustable
+	InputRecordable
+	ProgramWatchable
+	ErrorReportable
 }
 
 // NavigationManager defines the interface for managing window navigation.
@@ -189,6 +447,19 @@ type BreakpointManager interface {
 	// If a breakpoint already exists at the address, it won't be added again.
 	AddBreakpoint(address uint16)
 
+	// AddConditionalBreakpoint adds a breakpoint at the specified address that only triggers
+	// when the condition holds. If a breakpoint already exists its condition is replaced.
+	// Returns an error if the condition is not a valid expression.
+	AddConditionalBreakpoint(address uint16, condition string) error
+
+	// GetBreakpointCondition returns the condition of the breakpoint at the specified address,
+	// or an empty string if it has no condition.
+	GetBreakpointCondition(address uint16) string
+
+	// ShouldBreak increments the hit count of the breakpoint at the specified address and
+	// returns true if the breakpoint exists and its condition holds.
+	ShouldBreak(address uint16) bool
+
 	// RemoveBreakpoint removes a breakpoint at the specified address.
 	// If no breakpoint exists at the address, this method has no effect.
 	RemoveBreakpoint(address uint16)
@@ -209,3 +480,442 @@ type BreakpointManager interface {
 	// ClearAllBreakpoints removes all breakpoints.
 	ClearAllBreakpoints()
 }
+
+// WatchpointType defines the kind of memory access that triggers a watchpoint.
+type WatchpointType uint8
+
+const (
+	// WatchRead triggers when the processor reads from the watched addresses.
+	WatchRead WatchpointType = 1 << iota
+	// WatchWrite triggers when the processor writes to the watched addresses.
+	WatchWrite
+	// WatchReadWrite triggers on any access to the watched addresses.
+	WatchReadWrite = WatchRead | WatchWrite
+)
+
+// String returns a short description of the access type.
+func (t WatchpointType) String() string {
+	switch t {
+	case WatchRead:
+		return "R"
+	case WatchWrite:
+		return "W"
+	case WatchReadWrite:
+		return "RW"
+	default:
+		return "?"
+	}
+}
+
+// Watchpoint defines a range of addresses (both ends included) that is watched for
+// the specified type of access.
+type Watchpoint struct {
+	StartAddress uint16
+	EndAddress   uint16
+	Type         WatchpointType
+}
+
+// WatchpointHit contains the details of an access that triggered a watchpoint.
+type WatchpointHit struct {
+	Watchpoint     Watchpoint
+	Address        uint16 // Address accessed
+	Write          bool   // True if the access was a write
+	ProgramCounter uint16 // Address of the instruction that performed the access
+	OldValue       uint8  // Last value known for the address before the access
+	NewValue       uint8  // Value read or written
+	Cycle          uint64 // Cycle in which the access happened
+}
+
+// WatchpointManager defines the contract for managing memory watchpoints for computer debugging.
+type WatchpointManager interface {
+	// AddWatchpoint watches the range of addresses (both included) for the specified access type.
+	AddWatchpoint(startAddress uint16, endAddress uint16, watchType WatchpointType)
+
+	// RemoveWatchpointByIndex removes the watchpoint at the specified index.
+	// If the index is out of bounds, this method has no effect.
+	RemoveWatchpointByIndex(index int)
+
+	// GetWatchpoints returns a copy of all watchpoints.
+	GetWatchpoints() []Watchpoint
+
+	// GetWatchpointCount returns the number of active watchpoints.
+	GetWatchpointCount() int
+
+	// ClearAllWatchpoints removes all watchpoints.
+	ClearAllWatchpoints()
+
+	// CheckAccess must be called on every bus access done by the processor. It returns true
+	// and records the hit if the access triggers any of the watchpoints.
+	CheckAccess(address uint16, write bool, value uint8, programCounter uint16, cycle uint64) bool
+
+	// GetHits returns the most recent watchpoint hits, oldest first.
+	GetHits() []WatchpointHit
+}
+
+// SymbolTable maps the labels defined in the program being debugged to their addresses,
+// allowing the debugger to show and accept labels in place of raw addresses.
+type SymbolTable interface {
+	// AddSymbol defines a label for the specified address. If the address already has
+	// a label, the new one can still be used to look up the address but the first
+	// label defined is the one shown for it.
+	AddSymbol(label string, address uint16)
+
+	// GetLabel returns the label defined for the specified address and true,
+	// or an empty string and false if there is none.
+	GetLabel(address uint16) (string, bool)
+
+	// GetAddress returns the address of the specified label and true,
+	// or zero and false if the label is not defined.
+	GetAddress(label string) (uint16, bool)
+
+	// GetSymbolCount returns the number of labels defined.
+	GetSymbolCount() int
+}
+
+// SourceLocation identifies a line in one of the source files of the program being debugged.
+type SourceLocation struct {
+	File string // Path of the source file as recorded in the debug information
+	Line int    // Line number, starting from 1
+}
+
+// SourceMap maps the addresses of the program being debugged to the lines of its source files.
+type SourceMap interface {
+	// GetSourceLocation returns the source line that generated the byte at the specified
+	// address and true, or false if the address is not covered by the debug information.
+	GetSourceLocation(address uint16) (SourceLocation, bool)
+
+	// GetSourceLines returns the lines of the specified source file.
+	// Returns an error if the file can't be read.
+	GetSourceLines(file string) ([]string, error)
+}
+
+// BusCycle contains the bus access done by the processor in one cycle of the emulation.
+type BusCycle struct {
+	T       int64  // Time of the cycle, required to execute the cycle again with the same timing
+	Address uint16 // Value of the address bus
+	Data    uint8  // Value of the data bus
+	Write   bool   // True if the processor was writing
+	Fetch   bool   // True if the processor was reading an opcode
+}
+
+// HistoryManager records the recent execution of the computer to allow running it backwards.
+// It takes periodic snapshots of the computer and keeps a journal with the bus access of every
+// cycle. To go back to a cycle the latest snapshot taken before it is restored and the cycles
+// between both are executed again.
+type HistoryManager interface {
+	// SaveSnapshot must be called before executing each cycle, it saves the state of the
+	// computer if a snapshot is due on the cycle. Returns an error if the state can't be saved.
+	SaveSnapshot(cycle uint64) error
+
+	// RecordCycle adds the bus access of the executed cycle to the journal. Recording a
+	// cycle discards any history after it left by a rewind.
+	RecordCycle(cycle uint64, access BusCycle)
+
+	// GetCycle returns the bus access recorded for the cycle and true,
+	// or false if the cycle is not in the journal.
+	GetCycle(cycle uint64) (BusCycle, bool)
+
+	// GetOldestCycle returns the oldest cycle that can be restored and true,
+	// or false if there is no history yet.
+	GetOldestCycle() (uint64, bool)
+
+	// Restore loads the latest snapshot taken on or before the cycle and discards the
+	// history from the cycle onwards. It returns the cycle of the snapshot, the cycles
+	// between it and the requested one must be executed again to complete the rewind.
+	// Returns an error if the cycle is no longer in the history or the snapshot can't be loaded.
+	Restore(cycle uint64) (uint64, error)
+
+	// Clear discards all the recorded history.
+	Clear()
+}
+
+// TraceLogger writes a log with one line per instruction executed by the processor. Each line
+// has the cycle, address, bytes and disassembly of the instruction, the registers before
+// executing it and the effective address accessed with the value read or written, in a
+// format close to the one of the nestest and VICE traces.
+type TraceLogger interface {
+	// SetWriter sets where the log is written, nil stops logging. The line of the instruction
+	// in progress is completed and written to the previous writer, that is flushed.
+	// Returns an error if the previous writer fails.
+	SetWriter(writer io.Writer) error
+
+	// IsEnabled returns true if the log is being written.
+	IsEnabled() bool
+
+	// TraceCycle must be called after every cycle in which the processor drives the bus with
+	// the access done. The line of an instruction is written when the next opcode is fetched.
+	// Returns an error if the log can't be written.
+	TraceCycle(cycle uint64, access BusCycle) error
+}
+
+// WaveformSignal is a bus or line of the computer sampled by the waveform recorder. The value
+// of a line is 1 when it's high, regardless of the level it's active on.
+type WaveformSignal struct {
+	Name  string
+	Width uint8 // Number of bits, 1 for lines
+	Read  func() uint16
+}
+
+// WaveformRecorder writes the values of the signals of the computer in Value Change Dump (VCD)
+// format, that can be opened with waveform viewers like GTKWave. The signals are sampled once
+// per cycle and only their changes are written, with the time of the cycle in nanoseconds.
+type WaveformRecorder interface {
+	// SetWriter sets where the dump is written, nil stops dumping. The header of the dump is
+	// written to the new writer and the previous one is flushed.
+	// Returns an error if the previous writer fails.
+	SetWriter(writer io.Writer) error
+
+	// IsEnabled returns true if the dump is being written.
+	IsEnabled() bool
+
+	// SampleCycle must be called after every cycle with the time of the cycle in nanoseconds,
+	// the values of the signals that changed since the previous cycle are written.
+	// Returns an error if the dump can't be written.
+	SampleCycle(t int64) error
+}
+
+// ProfileAddress has the instructions executed and the cycles consumed at an address.
+type ProfileAddress struct {
+	Address      uint16
+	Instructions uint64
+	Cycles       uint64
+}
+
+// ProfileSubroutine has the calls and the cycles consumed by a subroutine or interrupt handler,
+// identified by the address of its first instruction.
+type ProfileSubroutine struct {
+	Address      uint16
+	Calls        uint64
+	Instructions uint64 // Instructions executed by the subroutine itself
+	SelfCycles   uint64 // Cycles consumed by the instructions of the subroutine itself
+	TotalCycles  uint64 // Cycles consumed by the subroutine and the ones it calls
+}
+
+// Profiler counts the instructions executed and the cycles consumed at each address, and
+// rolls them up per subroutine following the calls, returns and interrupts of the processor.
+type Profiler interface {
+	// SetEnabled starts or stops counting, the counts are kept when stopped.
+	SetEnabled(enabled bool)
+
+	// IsEnabled returns true if the execution is being profiled.
+	IsEnabled() bool
+
+	// ProfileCycle must be called after every cycle executed, the cycle is attributed
+	// to the instruction being executed.
+	ProfileCycle()
+
+	// Clear discards the collected counts.
+	Clear()
+
+	// GetTotalCycles returns the number of cycles profiled.
+	GetTotalCycles() uint64
+
+	// GetAddresses returns the addresses that consumed cycles, sorted by cycles consumed.
+	GetAddresses() []ProfileAddress
+
+	// GetSubroutines returns the subroutines and interrupt handlers called, sorted by the
+	// total cycles consumed.
+	GetSubroutines() []ProfileSubroutine
+
+	// WriteReport writes a flat text report with the subroutines and the addresses profiled.
+	WriteReport(writer io.Writer) error
+
+	// WriteCollapsedStacks writes one line per call stack with the cycles consumed in it, in
+	// the collapsed format used by flame graph tools.
+	WriteCollapsedStacks(writer io.Writer) error
+}
+
+// CallType identifies how a call was entered.
+type CallType string
+
+const (
+	CallTypeJSR CallType = "JSR" // Subroutine call
+	CallTypeBRK CallType = "BRK" // Software interrupt
+	CallTypeIRQ CallType = "IRQ" // Hardware interrupt request
+	CallTypeNMI CallType = "NMI" // Non maskable interrupt
+)
+
+// CallFrame holds the data of a subroutine call or interrupt.
+type CallFrame struct {
+	Type          CallType
+	CallerAddress uint16 // Address of the call instruction or of the interrupted instruction
+	TargetAddress uint16 // Address of the subroutine or interrupt handler
+	ReturnAddress uint16 // Address where the execution is expected to continue after returning
+	StackPointer  uint8  // Stack pointer after the return address was pushed
+}
+
+// CallReturn holds the data of an RTS or RTI executed by the processor.
+type CallReturn struct {
+	Interrupt     bool   // The instruction is an RTI, otherwise it's an RTS
+	Address       uint16 // Address of the return instruction
+	TargetAddress uint16 // Address where the execution continues
+	StackPointer  uint8  // Stack pointer after the return address was pulled
+}
+
+// CallListener receives the calls and returns followed by a call tracker.
+type CallListener interface {
+	// OnCall is called when the first opcode of the subroutine or interrupt handler is fetched.
+	OnCall(frame CallFrame)
+
+	// OnReturn is called when the opcode where the execution continues is fetched.
+	OnReturn(ret CallReturn)
+
+	// OnReset is called when the processor is reset, no call is in progress anymore.
+	OnReset()
+}
+
+// CallTracker follows the subroutine calls, interrupts and returns executed by the processor
+// and passes them to its listener.
+type CallTracker interface {
+	// TrackCycle must be called after every cycle executed. It returns true if the processor
+	// fetched the opcode of a new instruction, the call or return of the previous one was
+	// already passed to the listener.
+	TrackCycle() bool
+
+	// GetInstructionAddress returns the address of the last opcode fetched.
+	GetInstructionAddress() uint16
+
+	// Reset forgets the instruction being executed without notifying the listener, tracking
+	// starts again on the next opcode fetch.
+	Reset()
+}
+
+// CoverageFlags has one bit set for each type of access done by the processor to an address.
+type CoverageFlags uint8
+
+const (
+	CoverageExecuted CoverageFlags = 1 << iota // An opcode was fetched from the address
+	CoverageOperand                            // Read as an operand of an instruction
+	CoverageRead                               // Read as data
+	CoverageWritten                            // Written as data
+)
+
+// CoverageRegion is a range of addresses of the computer included in the coverage listing.
+type CoverageRegion struct {
+	Name  string
+	Start uint16
+	End   uint16 // Last address of the region
+}
+
+// CoverageRecorder records the type of access done by the processor to each address to
+// find which parts of a program were executed, used as data or never touched.
+type CoverageRecorder interface {
+	// SetEnabled starts or stops recording, the collected coverage is kept when stopped.
+	SetEnabled(enabled bool)
+
+	// IsEnabled returns true if the accesses are being recorded.
+	IsEnabled() bool
+
+	// RecordCycle must be called after every cycle executed, it records the access done
+	// by the processor in the cycle.
+	RecordCycle()
+
+	// Clear discards the collected coverage.
+	Clear()
+
+	// GetCoverage returns the accesses done to the address.
+	GetCoverage(address uint16) CoverageFlags
+
+	// GetExecutions returns the number of times an opcode was fetched from the address.
+	GetExecutions(address uint16) uint64
+
+	// WriteListing writes the memory regions of the computer disassembled where opcodes were
+	// executed, with the accesses done to each address.
+	WriteListing(writer io.Writer) error
+
+	// WriteLcov writes the line coverage of the source files of the program in lcov format.
+	WriteLcov(writer io.Writer, sourceMap SourceMap) error
+}
+
+// StopConditions are the conditions that end a headless run, the run ends with the first
+// condition met. Conditions on opcodes are checked when the processor fetches them, before
+// the instruction is executed.
+type StopConditions struct {
+	MaxCycles      uint64   // Cycles executed before stopping, 0 for no limit
+	Addresses      []uint16 // Stop when an opcode is fetched from any of the addresses
+	OnBreak        bool     // Stop when a BRK opcode is fetched
+	OnStop         bool     // Stop when a STP (or JAM on the NMOS 6502) opcode is fetched
+	WriteAddresses []uint16 // Stop after the processor writes to any of the addresses
+}
+
+// StopReason identifies the condition that ended a headless run.
+type StopReason string
+
+const (
+	StopMaxCycles StopReason = "max-cycles" // The maximum number of cycles was executed
+	StopAddress   StopReason = "address"    // An opcode was fetched from a stop address
+	StopBreak     StopReason = "brk"        // A BRK opcode was fetched
+	StopStop      StopReason = "stp"        // A STP or JAM opcode was fetched
+	StopWrite     StopReason = "write"      // The processor wrote to a stop address
+)
+
+// RunResult describes how a headless run ended.
+type RunResult struct {
+	Reason  StopReason
+	Address uint16 // Address of the opcode fetched or written that stopped the run
+	Value   uint8  // Value written, only for StopWrite
+}
+
+// StimulusKind identifies the source of an input received by the computer from outside of
+// the emulation.
+type StimulusKind string
+
+const (
+	StimulusSerial   StimulusKind = "serial"    // Bytes received by the ACIA from the serial port
+	StimulusMiaInput StimulusKind = "mia-input" // MIIN packet received by the MIA input service
+	StimulusKeyboard StimulusKind = "keyboard"  // Scan codes of the keys typed on the PS/2 keyboard
+	StimulusButton   StimulusKind = "button"    // Indexes of the buttons pressed on the I/O panel
+	StimulusReset    StimulusKind = "reset"     // Reset pressed (Value 1) or released (Value 0)
+	StimulusSpeed    StimulusKind = "speed"     // Target speed changed to Value MHz
+)
+
+// Stimulus is an input received from outside of the emulation, stamped with the cycle at
+// which it was applied. Injecting the same stimuli at the same cycles reproduces a run.
+type Stimulus struct {
+	Cycle  uint64
+	Kind   StimulusKind
+	Source string  // Address of the sender, only for StimulusMiaInput
+	Data   []byte  // Bytes, packet, scan codes or buttons received, for StimulusSerial, StimulusMiaInput, StimulusKeyboard and StimulusButton
+	Value  float64 // Value of the reset line or target speed, for StimulusReset and StimulusSpeed
+}
+
+// StimulusInjectable is implemented by the computers with devices that receive input from
+// outside of the emulation, allowing to record the input and replay it later.
+type StimulusInjectable interface {
+	// SetStimulusHandler routes the input received by the devices to the handler instead of
+	// applying it, nil applies the input as soon as it's received.
+	SetStimulusHandler(handler func(stimulus Stimulus))
+
+	// InjectStimulus applies the input as if it was received by the device.
+	// Returns an error if the computer has no device that receives this kind of input.
+	InjectStimulus(stimulus Stimulus) error
+
+	// ReceivesStimulus returns true if the computer has a device that receives this kind of
+	// input, so the stimuli of a replay can be checked before injecting them.
+	ReceivesStimulus(kind StimulusKind) bool
+}
+
+// InputRecorder writes the stimuli received by the computer, one per line, in a text format
+// that can be read with managers.LoadInputRecording.
+type InputRecorder interface {
+	// SetWriter sets where the stimuli are written, nil stops recording. The previous writer
+	// is flushed. Returns an error if the previous writer fails.
+	SetWriter(writer io.Writer) error
+
+	// IsEnabled returns true if the stimuli are being written.
+	IsEnabled() bool
+
+	// RecordStimulus writes the stimulus. Returns an error if it can't be written.
+	RecordStimulus(stimulus Stimulus) error
+}
+
+// FileWatcher polls a set of files and reports when their contents change.
+type FileWatcher interface {
+	// Watch starts polling the files, calling onChange from the polling goroutine once the
+	// modified files stop changing. If files were being watched, they are replaced.
+	// Returns an error if any of the files doesn't exist.
+	Watch(paths []string, onChange func()) error
+
+	// Stop stops polling the files. If they are not watched, this method has no effect.
+	Stop()
+}
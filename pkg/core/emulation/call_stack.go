@@ -0,0 +1,86 @@
+package emulation
+
+import (
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
+)
+
+// Maximum number of calls kept, deeper calls discard the oldest ones
+const maxCallDepth int = 256
+
+// callStack keeps the subroutine calls and interrupts in progress, so a step out can wait
+// for the return of the call it was started in. The calls are followed by a call tracker.
+type callStack struct {
+	tracker core.CallTracker
+	frames  []core.CallFrame
+
+	returned   bool            // A return was executed in the last cycle tracked
+	lastReturn core.CallReturn // Last return executed
+}
+
+// newCallStack creates an empty call stack that follows the calls of the processor.
+func newCallStack(processor components.Cpu65C02) *callStack {
+	stack := &callStack{
+		frames: make([]core.CallFrame, 0, maxCallDepth),
+	}
+
+	stack.tracker = managers.NewCallTracker(processor, stack)
+
+	return stack
+}
+
+// track follows the processor in the cycle just executed.
+func (s *callStack) track() {
+	s.returned = false
+	s.tracker.TrackCycle()
+}
+
+// clear discards the calls in progress, used when the computer is restored to a state
+// whose calls are not known.
+func (s *callStack) clear() {
+	s.frames = s.frames[:0]
+	s.returned = false
+	s.tracker.Reset()
+}
+
+// top returns the innermost call in progress, false if there is none.
+func (s *callStack) top() (core.CallFrame, bool) {
+	if len(s.frames) == 0 {
+		return core.CallFrame{}, false
+	}
+
+	return s.frames[len(s.frames)-1], true
+}
+
+// hasReturnedFrom returns true if the last cycle tracked fetched the opcode where the
+// execution continues after returning from the call.
+func (s *callStack) hasReturnedFrom(frame core.CallFrame) bool {
+	return s.returned &&
+		s.lastReturn.TargetAddress == frame.ReturnAddress &&
+		s.lastReturn.StackPointer > frame.StackPointer
+}
+
+// OnCall adds the call on top of the stack.
+func (s *callStack) OnCall(frame core.CallFrame) {
+	if len(s.frames) >= maxCallDepth {
+		s.frames = s.frames[1:]
+	}
+
+	s.frames = append(s.frames, frame)
+}
+
+// OnReturn removes the calls whose return address was pulled from the processor stack.
+func (s *callStack) OnReturn(ret core.CallReturn) {
+	s.returned = true
+	s.lastReturn = ret
+
+	for len(s.frames) > 0 && s.frames[len(s.frames)-1].StackPointer < ret.StackPointer {
+		s.frames = s.frames[:len(s.frames)-1]
+	}
+}
+
+// OnReset discards the calls in progress as the processor was reset.
+func (s *callStack) OnReset() {
+	s.frames = s.frames[:0]
+}
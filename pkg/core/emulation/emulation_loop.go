@@ -31,6 +31,7 @@ type EmulationLoopConfig struct {
 type emulationLoop struct {
 	config       *EmulationLoopConfig
 	panicHandler func(loopType string, panicData any) bool
+	frequency    uint64
 
 	tickLoopRunning atomic.Bool
 	drawLoopRunning atomic.Bool
@@ -93,6 +94,15 @@ func (e *emulationLoop) SetPanicHandler(handler func(loopType string, panicData
 	e.panicHandler = handler
 }
 
+// SetVirtualTime sets the frequency of the clock used to derive the time of the context from
+// the cycle count. It applies from the next time the loop starts.
+//
+// Parameters:
+//   - frequency: The frequency of the clock in Hz, 0 uses the wall clock
+func (e *emulationLoop) SetVirtualTime(frequency uint64) {
+	e.frequency = frequency
+}
+
 /************************************************************************************
 * Getters
 *************************************************************************************/
@@ -126,7 +136,7 @@ func (e *emulationLoop) IsStopping() bool {
 //   - A StepContext that can be used to control and monitor the emulation
 func (e *emulationLoop) Start() (*common.StepContext, error) {
 	if !e.IsRunning() && e.config.Emulator != nil {
-		context := common.NewStepContext()
+		context := common.NewVirtualStepContext(e.frequency)
 
 		e.pause.Store(false)
 		e.stop.Store(false)
@@ -201,13 +211,14 @@ func (e *emulationLoop) executeLoop(context *common.StepContext) {
 			}
 		}
 
-		if (context.T - lastSpeedCheck) > e.config.RefreshNanos {
+		// The speed is controlled with the wall clock, the time of the components might be virtual
+		if (context.WallT - lastSpeedCheck) > e.config.RefreshNanos {
 			targetTPSNano = int64(e.config.SpeedController.GetNanosPerCycle())
-			lastSpeedCheck = context.T
+			lastSpeedCheck = context.WallT
 		}
 
-		if (context.T-lastTPSExecuted) > targetTPSNano && !e.pause.Load() {
-			lastTPSExecuted = context.T
+		if (context.WallT-lastTPSExecuted) > targetTPSNano && !e.pause.Load() {
+			lastTPSExecuted = context.WallT
 			e.config.Emulator.Tick(context)
 			e.config.Emulator.PostTick(context)
 			context.NextCycle()
@@ -1,18 +1,79 @@
 package emulation
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
 	"github.com/fran150/clementina-6502/pkg/core"
 )
 
+// stepMode defines the condition that ends a step requested by the user.
+type stepMode int
+
+const (
+	stepNone        stepMode = iota // Not stepping
+	stepCycle                       // Pause after the next cycle
+	stepInstruction                 // Pause after the next opcode fetch
+	stepOver                        // Pause after the opcode fetch of the instruction following a call
+	stepOut                         // Pause after returning from the current subroutine or interrupt
+	stepSourceLine                  // Pause after the opcode fetch of an instruction of other source line
+)
+
+// inputMode defines how the input received from outside of the emulation is handled.
+type inputMode int32
+
+const (
+	inputLive      inputMode = iota // Applied as soon as it's received
+	inputRecording                  // Applied and recorded at the start of the next cycle
+	inputReplaying                  // Ignored, the stimuli of the replay are injected instead
+)
+
 // EmulatorConfig holds the configuration for a DefaultEmulator instance.
 // It contains all the necessary components required to run the emulation.
+// Processor is optional, when set breakpoints are only evaluated when the processor
+// fetches an opcode, otherwise they are checked on every cycle. It is also required
+// to step by instruction, without it all steps execute a single cycle.
+// WatchpointManager is optional and requires the Processor to observe its bus accesses.
+// SourceMap is optional, without it stepping by source line steps a single instruction.
+// HistoryManager is optional and requires the Processor, it records the execution to allow
+// running the emulation backwards.
+// TraceLogger is optional and requires the Processor, it logs the executed instructions.
+// WaveformRecorder is optional, it dumps the signals of the computer on every cycle.
+// Profiler is optional and requires the Processor, it counts the cycles consumed per address.
+// CoverageRecorder is optional, it records the accesses of the processor to each address.
+// InputRecorder is optional and requires a Computer that implements core.StimulusInjectable,
+// it records the input received by the computer to replay it later.
+// FileWatcher is optional, it allows reloading the programs when their files change.
 type EmulatorConfig struct {
 	Computer          core.ComputerCore
+	Processor         components.Cpu65C02
 	Console           core.EmulationConsole
 	Loop              core.EmulationLoop
 	SpeedController   core.SpeedController
 	BreakpointManager core.BreakpointManager
+	WatchpointManager core.WatchpointManager
+	SourceMap         core.SourceMap
+	HistoryManager    core.HistoryManager
+	TraceLogger       core.TraceLogger
+	WaveformRecorder  core.WaveformRecorder
+	Profiler          core.Profiler
+	CoverageRecorder  core.CoverageRecorder
+	InputRecorder     core.InputRecorder
+	FileWatcher       core.FileWatcher
 }
 
 // baseEmulator is the main emulator implementation that orchestrates the execution
@@ -21,23 +82,95 @@ type EmulatorConfig struct {
 type baseEmulator struct {
 	config *EmulatorConfig
 
-	stepping  bool
+	stepping  stepMode
 	resetting bool
+	headless  bool       // A headless run is in progress, the console is not ticked
+	calls     *callStack // Calls in progress, nil without processor
+
+	stepTargetAddress   uint16
+	stepStackPointer    uint8
+	stepFrame           core.CallFrame // Call the step out was started in
+	stepHasFrame        bool
+	stepSourceLocation  core.SourceLocation
+	stepHasSource       bool
+	instructionAddress  uint16
+	currentInstruction  components.CpuInstructionData
+	previousInstruction components.CpuInstructionData
+
+	lastCycle   uint64
+	rewinding   bool
+	rewindCycle uint64
+
+	// Set by the emulation loop when the history fails, read by the UI to skip the rewinds
+	historyFailed atomic.Bool
+
+	stateRequest atomic.Pointer[stateFileRequest]
+
+	traceFile    outputFile
+	waveformFile outputFile
+
+	virtualTimeFrequency uint64
+
+	// While recording the input is queued and applied by the next cycle, the mode is read by
+	// every cycle without taking the input mutex
+	inputMode     atomic.Int32
+	inputMutex    sync.Mutex
+	inputFile     outputFile
+	pendingInput  []func() (core.Stimulus, error)
+	replayStimuli []core.Stimulus
+
+	// The reload is requested by the file watcher and done by the emulation loop, the mutex
+	// protects the function and status shared with the UI.
+	reloadRequested   atomic.Bool
+	reloadMutex       sync.Mutex
+	reloadFunc        func() error
+	reloadStatus      core.ReloadStatus
+	reloadResetCycles int
+
+	// The errors occur in the emulation loop and are shown by the UI
+	errorMutex sync.Mutex
+	lastError  error
+}
+
+// Number of cycles the computer is kept in reset after reloading the programs
+const reloadResetCycles int = 16
+
+// stateFileRequest is a request to save or load a state file, made from the UI and
+// completed by the emulation loop.
+type stateFileRequest struct {
+	path string
+	load bool
+	done func(err error)
+}
+
+// Values of the emulation saved after the state of the computer in the state files. This
+// struct is written and read with encoding/binary, so it must only have fixed size fields.
+type emulationState struct {
+	Cycle uint64
+	T     int64
 }
 
 /************************************************************************************
 * Constructor
 *************************************************************************************/
 // newBaseEmulator creates a new defaultBaseEmulator instance with the provided configuration.
-// It initializes the emulator with default state values (stepping and resetting set to false).
+// It initializes the emulator with default state values (not stepping and resetting set to false).
 // This is an internal constructor function used by NewBaseEmulator.
 func newBaseEmulator(config EmulatorConfig) *baseEmulator {
 	emulator := &baseEmulator{
 		config:    &config,
-		stepping:  false,
+		stepping:  stepNone,
 		resetting: false,
 	}
 
+	if config.Processor != nil {
+		emulator.calls = newCallStack(config.Processor)
+	}
+
+	emulator.traceFile.sink = config.TraceLogger
+	emulator.waveformFile.sink = config.WaveformRecorder
+	emulator.inputFile.sink = config.InputRecorder
+
 	return emulator
 }
 
@@ -71,9 +204,15 @@ func (e *baseEmulator) Start() (*common.StepContext, error) {
 
 // Stop terminates the emulator by stopping both the emulation loop and console.
 // This method should be called to cleanly shut down the emulator and release resources.
+// The trace, waveform and input recording files, if any, are completed and closed, and
+// the program files are no longer watched.
 func (e *baseEmulator) Stop() {
 	e.config.Loop.Stop()
 	e.config.Console.Stop()
+	e.StopTrace()
+	e.StopWaveform()
+	e.StopRecording()
+	e.StopWatchingPrograms()
 }
 
 // Pause pauses the emulation loop, stopping the execution of the computer system.
@@ -83,35 +222,984 @@ func (e *baseEmulator) Pause() {
 }
 
 // Resume resumes the emulation loop after it has been paused.
-// This continues the execution of the computer system from where it was paused.
+// This continues the execution of the computer system from where it was paused,
+// cancelling any step in progress.
 func (e *baseEmulator) Resume() {
+	e.stepping = stepNone
 	e.config.Loop.Resume()
 }
 
+// SetVirtualTime sets the frequency of the clock used to derive the time of the emulation
+// from the cycle count, for the loop and for headless runs. With the time decoupled from the
+// wall clock, the runs with the same inputs produce the same results at any speed.
+//
+// Parameters:
+//   - frequency: The frequency of the clock in Hz, 0 uses the wall clock
+func (e *baseEmulator) SetVirtualTime(frequency uint64) {
+	e.virtualTimeFrequency = frequency
+	e.config.Loop.SetVirtualTime(frequency)
+}
+
 // Step executes a single step of the emulation if the emulator is currently paused.
 // After executing one step, the emulator will automatically pause again.
 // If the emulator is not paused, this method has no effect.
 func (e *baseEmulator) Step() {
+	e.startStep(stepCycle)
+}
+
+// StepInstruction runs the emulation until the processor fetches the next opcode.
+// If the emulator is not paused, this method has no effect.
+func (e *baseEmulator) StepInstruction() {
+	e.startStep(stepInstruction)
+}
+
+// StepOver runs the emulation until the processor fetches the next opcode. If the
+// current instruction is a subroutine call (JSR or BRK) the emulation runs until the
+// subroutine returns to the instruction following the call.
+// If the emulator is not paused, this method has no effect.
+func (e *baseEmulator) StepOver() {
+	if e.config.Processor == nil {
+		e.Step()
+		return
+	}
+
+	if e.currentInstruction == nil {
+		e.StepInstruction()
+		return
+	}
+
+	switch e.currentInstruction.Mnemonic() {
+	case cpu.JSR:
+		e.stepTargetAddress = e.instructionAddress + 3
+	case cpu.BRK:
+		e.stepTargetAddress = e.instructionAddress + 2
+	default:
+		e.StepInstruction()
+		return
+	}
+
+	e.stepStackPointer = e.config.Processor.GetStackPointer()
+	e.startStep(stepOver)
+}
+
+// StepOut runs the emulation until the current subroutine or interrupt handler returns
+// to its caller, pausing at the instruction where the execution returns. If the call is not
+// known, as after a rewind or loading a state, it pauses after the first RTS or RTI that
+// pulls a return address pushed before the step started.
+// If the emulator is not paused, this method has no effect.
+func (e *baseEmulator) StepOut() {
+	if e.config.Processor != nil {
+		e.stepStackPointer = e.config.Processor.GetStackPointer()
+		e.stepFrame, e.stepHasFrame = e.calls.top()
+	}
+
+	e.startStep(stepOut)
+}
+
+// StepSourceLine runs the emulation until the processor fetches an opcode generated by
+// a source line different from the one of the current instruction. Instructions that
+// are not covered by the source map, such as library code built without debug
+// information, are executed without pausing.
+// If the emulator is not paused, this method has no effect.
+func (e *baseEmulator) StepSourceLine() {
+	if e.config.SourceMap == nil {
+		e.StepInstruction()
+		return
+	}
+
+	e.stepSourceLocation, e.stepHasSource = e.config.SourceMap.GetSourceLocation(e.instructionAddress)
+	e.startStep(stepSourceLine)
+}
+
+// startStep resumes the emulation until the condition of the specified step mode is met.
+// Instruction level steps fall back to cycle steps if there is no processor to observe.
+func (e *baseEmulator) startStep(mode stepMode) {
 	if e.IsPaused() {
-		e.stepping = true
-		e.Resume()
+		if e.config.Processor == nil {
+			mode = stepCycle
+		}
+
+		e.stepping = mode
+		e.config.Loop.Resume()
+	}
+}
+
+// StepBack rewinds the emulation to the previous opcode fetch. If the emulation is paused
+// in the middle of an instruction it rewinds to the opcode fetch of that instruction.
+// If the emulator is not paused or has no history, this method has no effect.
+func (e *baseEmulator) StepBack() {
+	if cycle, ok := e.findPreviousCycle(func(access core.BusCycle) bool {
+		return access.Fetch
+	}); ok {
+		e.startRewind(cycle)
+	}
+}
+
+// StepBackCycle rewinds the emulation by a single cycle.
+// If the emulator is not paused or has no history, this method has no effect.
+func (e *baseEmulator) StepBackCycle() {
+	if cycle, ok := e.findPreviousCycle(func(access core.BusCycle) bool {
+		return true
+	}); ok {
+		e.startRewind(cycle)
+	}
+}
+
+// RunBack rewinds the emulation to the most recent opcode fetch of an address with a
+// breakpoint. Breakpoint conditions are not evaluated. If there is no such fetch in the
+// history the emulation is rewound to the oldest cycle available.
+// If the emulator is not paused or has no history, this method has no effect.
+func (e *baseEmulator) RunBack() {
+	history := e.history()
+	if history == nil {
+		return
+	}
+
+	cycle, ok := e.findPreviousCycle(func(access core.BusCycle) bool {
+		return access.Fetch && e.config.BreakpointManager.HasBreakpoint(access.Address)
+	})
+
+	if !ok {
+		if cycle, ok = history.GetOldestCycle(); !ok || cycle > e.lastCycle {
+			return
+		}
+	}
+
+	e.startRewind(cycle)
+}
+
+// findPreviousCycle searches the history backwards, starting from the cycle before the
+// last one executed, for a cycle that can be restored and matches the condition.
+func (e *baseEmulator) findPreviousCycle(match func(access core.BusCycle) bool) (uint64, bool) {
+	history := e.history()
+	if history == nil {
+		return 0, false
+	}
+
+	oldest, ok := history.GetOldestCycle()
+	if !ok {
+		return 0, false
+	}
+
+	for cycle := e.lastCycle; cycle > oldest; {
+		cycle--
+
+		access, ok := history.GetCycle(cycle)
+		if !ok {
+			return 0, false
+		}
+
+		if match(access) {
+			return cycle, true
+		}
+	}
+
+	return 0, false
+}
+
+// startRewind resumes the emulation to go back to the specified cycle. The rewind is done
+// on the next tick, pausing after the cycle is executed again.
+func (e *baseEmulator) startRewind(cycle uint64) {
+	if e.IsPaused() {
+		e.rewindCycle = cycle
+		e.rewinding = true
+		e.startStep(stepCycle)
 	}
 }
 
 // Reset initiates a reset of the computer system by setting the resetting flag
 // and calling the computer's Reset method with true to begin the reset process.
+// The execution history is discarded as the reset can't be executed again when rewinding.
+// While the input is recorded the reset is applied at the start of the next cycle.
 func (e *baseEmulator) Reset() {
-	e.resetting = true
-	e.config.Computer.Reset(true)
+	e.handleInput(func() (core.Stimulus, error) {
+		e.reset()
+		return core.Stimulus{Kind: core.StimulusReset, Value: 1}, nil
+	})
 }
 
 // UnReset completes the reset process by clearing the resetting flag
 // and calling the computer's Reset method with false to finish the reset.
+// While the input is recorded the reset is released at the start of the next cycle.
 func (e *baseEmulator) UnReset() {
+	e.handleInput(func() (core.Stimulus, error) {
+		e.unReset()
+		return core.Stimulus{Kind: core.StimulusReset, Value: 0}, nil
+	})
+}
+
+// reset puts the computer in reset state discarding the execution history.
+func (e *baseEmulator) reset() {
+	if history := e.history(); history != nil {
+		history.Clear()
+	}
+
+	e.resetting = true
+	e.config.Computer.Reset(true)
+}
+
+// unReset releases the computer from reset state.
+func (e *baseEmulator) unReset() {
 	e.resetting = false
 	e.config.Computer.Reset(false)
 }
 
+// SpeedUp increases the target speed of the emulation.
+// While the input is recorded the speed is changed at the start of the next cycle.
+func (e *baseEmulator) SpeedUp() {
+	e.changeSpeed(e.config.SpeedController.SpeedUp)
+}
+
+// SpeedDown decreases the target speed of the emulation.
+// While the input is recorded the speed is changed at the start of the next cycle.
+func (e *baseEmulator) SpeedDown() {
+	e.changeSpeed(e.config.SpeedController.SpeedDown)
+}
+
+// changeSpeed handles a change of the target speed made from the console as an input, the
+// resulting speed is recorded so the replay doesn't depend on the initial speed.
+func (e *baseEmulator) changeSpeed(change func()) {
+	e.handleInput(func() (core.Stimulus, error) {
+		change()
+		return core.Stimulus{Kind: core.StimulusSpeed, Value: e.config.SpeedController.GetTargetSpeed()}, nil
+	})
+}
+
+/************************************************************************************
+* State files
+*************************************************************************************/
+
+// SaveStateFile requests saving the state of the computer, and the cycle and time of the
+// emulation, to the file. The file is saved by the emulation loop before the next cycle or
+// display refresh and the result is reported to the done function.
+func (e *baseEmulator) SaveStateFile(path string, done func(err error)) {
+	e.stateRequest.Store(&stateFileRequest{path: path, done: done})
+}
+
+// LoadStateFile requests restoring the state of the computer, and the cycle and time of the
+// emulation, from a file saved with SaveStateFile. The file is loaded by the emulation loop
+// before the next cycle or display refresh and the result is reported to the done function.
+// The execution history is discarded and any step in progress is cancelled.
+func (e *baseEmulator) LoadStateFile(path string, done func(err error)) {
+	e.stateRequest.Store(&stateFileRequest{path: path, load: true, done: done})
+}
+
+// processStateRequest completes the pending request to save or load a state file.
+func (e *baseEmulator) processStateRequest(context *common.StepContext) {
+	request := e.stateRequest.Swap(nil)
+	if request == nil {
+		return
+	}
+
+	var err error
+	if request.load {
+		err = e.loadStateFile(request.path, context)
+	} else {
+		err = e.saveStateFile(request.path, context)
+	}
+
+	if request.done != nil {
+		request.done(err)
+	}
+}
+
+// saveStateFile writes the state to a temporary file that replaces the specified one once
+// it's complete, so a failure never leaves a partially written state.
+func (e *baseEmulator) saveStateFile(path string, context *common.StepContext) error {
+	computer, ok := e.config.Computer.(core.Snapshotable)
+	if !ok {
+		return errors.New("the computer doesn't support saving its state")
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+
+	if err := computer.SaveState(writer); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := binary.Write(writer, binary.LittleEndian, &emulationState{Cycle: context.Cycle, T: context.T}); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+// loadStateFile restores the state from the file. The state of the computer is backed up
+// before loading the file to be restored if the file is not valid. If the backup can't be
+// restored either, the emulation is paused and both errors are returned.
+func (e *baseEmulator) loadStateFile(path string, context *common.StepContext) error {
+	computer, ok := e.config.Computer.(core.Snapshotable)
+	if !ok {
+		return errors.New("the computer doesn't support loading its state")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var backup bytes.Buffer
+	if err := computer.SaveState(&backup); err != nil {
+		return err
+	}
+
+	var state emulationState
+
+	reader := bufio.NewReader(file)
+
+	err = computer.LoadState(reader)
+	if err == nil {
+		err = binary.Read(reader, binary.LittleEndian, &state)
+	}
+
+	if err != nil {
+		// The computer is left in an unknown state, it's paused so it doesn't run from it
+		if restoreErr := computer.LoadState(&backup); restoreErr != nil {
+			e.pauseExecution()
+			return fmt.Errorf("%w, the previous state couldn't be restored: %w", err, restoreErr)
+		}
+
+		return err
+	}
+
+	// Components keep times taken from the context, so it continues from the saved time
+	context.Cycle = state.Cycle
+	context.AdvanceTime(state.T)
+
+	if history := e.history(); history != nil {
+		history.Clear()
+	}
+
+	e.rewinding = false
+	e.stepping = stepNone
+	e.lastCycle = state.Cycle
+
+	e.currentInstruction = nil
+	e.previousInstruction = nil
+
+	if e.calls != nil {
+		e.calls.clear()
+	}
+
+	e.trackInstruction()
+
+	return nil
+}
+
+/************************************************************************************
+* Trace
+*************************************************************************************/
+
+// StartTrace creates the trace file and starts logging the executed instructions to it.
+// If the file exists it's truncated, and if a trace was in progress its file is closed.
+func (e *baseEmulator) StartTrace(path string) error {
+	if e.config.TraceLogger == nil || e.config.Processor == nil {
+		return errors.New("the emulator doesn't support tracing")
+	}
+
+	return e.traceFile.start(path)
+}
+
+// StopTrace stops logging the executed instructions and closes the trace file. If the trace
+// was stopped because the file couldn't be written, the error is returned.
+// If there is no trace in progress, this method has no effect.
+func (e *baseEmulator) StopTrace() error {
+	return e.traceFile.stop()
+}
+
+// IsTracing returns true if the executed instructions are being logged.
+func (e *baseEmulator) IsTracing() bool {
+	return e.config.TraceLogger != nil && e.config.TraceLogger.IsEnabled()
+}
+
+/************************************************************************************
+* Waveform
+*************************************************************************************/
+
+// StartWaveform creates the Value Change Dump file and starts writing to it the changes of
+// the signals of the computer. If the file exists it's truncated, and if a dump was in
+// progress its file is closed.
+func (e *baseEmulator) StartWaveform(path string) error {
+	if e.config.WaveformRecorder == nil {
+		return errors.New("the emulator doesn't support waveforms")
+	}
+
+	return e.waveformFile.start(path)
+}
+
+// StopWaveform stops dumping the signals of the computer and closes the file. If the dump
+// was stopped because the file couldn't be written, the error is returned.
+// If there is no dump in progress, this method has no effect.
+func (e *baseEmulator) StopWaveform() error {
+	return e.waveformFile.stop()
+}
+
+// IsRecordingWaveform returns true if the signals of the computer are being dumped.
+func (e *baseEmulator) IsRecordingWaveform() bool {
+	return e.config.WaveformRecorder != nil && e.config.WaveformRecorder.IsEnabled()
+}
+
+/************************************************************************************
+* Input recording
+*************************************************************************************/
+
+// StartRecording creates the file and starts recording the input received by the computer,
+// the reset and the changes of speed. While recording, the input is applied at the start of
+// the next cycle, so a replay can inject it at the same cycle. If the file exists it's
+// truncated, and if a recording was in progress its file is closed.
+func (e *baseEmulator) StartRecording(path string) error {
+	injectable, ok := e.config.Computer.(core.StimulusInjectable)
+	if e.config.InputRecorder == nil || !ok {
+		return errors.New("the emulator doesn't support recording the input")
+	}
+
+	e.inputMutex.Lock()
+	defer e.inputMutex.Unlock()
+
+	if inputMode(e.inputMode.Load()) == inputReplaying {
+		return errors.New("the input can't be recorded during a replay")
+	}
+
+	if err := e.inputFile.start(path); err != nil {
+		return err
+	}
+
+	e.inputMode.Store(int32(inputRecording))
+	injectable.SetStimulusHandler(e.receiveStimulus)
+
+	return nil
+}
+
+// StopRecording stops recording the input and closes the file. The input waiting for the
+// next cycle is applied without recording it. If the recording was stopped because the file
+// couldn't be written, the error is returned. If there is no recording in progress, this
+// method has no effect.
+func (e *baseEmulator) StopRecording() error {
+	e.inputMutex.Lock()
+	defer e.inputMutex.Unlock()
+
+	e.stopRecordingInput()
+
+	return e.inputFile.stop()
+}
+
+// stopRecordingInput applies the input as soon as it's received again, applying the input
+// waiting for the next cycle without recording it. The input mutex must be held.
+func (e *baseEmulator) stopRecordingInput() {
+	if inputMode(e.inputMode.Load()) != inputRecording {
+		return
+	}
+
+	e.config.Computer.(core.StimulusInjectable).SetStimulusHandler(nil)
+	e.inputMode.Store(int32(inputLive))
+
+	for _, apply := range e.pendingInput {
+		if _, err := apply(); err != nil {
+			e.reportError(err)
+		}
+	}
+
+	e.pendingInput = nil
+}
+
+// failRecording stops recording the input after the recorder failed writing to the file,
+// the error is returned by StopRecording.
+func (e *baseEmulator) failRecording(err error) {
+	e.inputMutex.Lock()
+	e.stopRecordingInput()
+	e.inputMutex.Unlock()
+
+	e.inputFile.fail(err)
+}
+
+// IsRecording returns true if the input is being recorded.
+func (e *baseEmulator) IsRecording() bool {
+	return inputMode(e.inputMode.Load()) == inputRecording
+}
+
+// StartReplay injects the stimuli at the start of their cycles. Until the last one is
+// injected, the input received by the computer, the reset and the changes of speed are
+// ignored. The emulation must start from the same state as the recording to reproduce it.
+// If a stimulus can't be injected the replay is stopped and the error is reported by
+// GetLastError.
+func (e *baseEmulator) StartReplay(stimuli []core.Stimulus) error {
+	injectable, ok := e.config.Computer.(core.StimulusInjectable)
+	if !ok {
+		return errors.New("the emulator doesn't support replaying the input")
+	}
+
+	if !slices.IsSortedFunc(stimuli, func(a, b core.Stimulus) int { return cmp.Compare(a.Cycle, b.Cycle) }) {
+		return errors.New("the stimuli of the replay must be sorted by cycle")
+	}
+
+	for _, stimulus := range stimuli {
+		if !e.receivesStimulus(injectable, stimulus.Kind) {
+			return fmt.Errorf("the computer doesn't receive the %s stimulus of cycle %d", stimulus.Kind, stimulus.Cycle)
+		}
+	}
+
+	e.inputMutex.Lock()
+	defer e.inputMutex.Unlock()
+
+	if inputMode(e.inputMode.Load()) == inputRecording {
+		return errors.New("the input can't be replayed while it's being recorded")
+	}
+
+	e.replayStimuli = slices.Clone(stimuli)
+
+	if len(e.replayStimuli) > 0 {
+		e.inputMode.Store(int32(inputReplaying))
+		injectable.SetStimulusHandler(e.receiveStimulus)
+	}
+
+	return nil
+}
+
+// receivesStimulus returns true if the kind of stimulus can be injected, the reset and speed
+// are handled by the emulator and the rest of the input by the computer.
+func (e *baseEmulator) receivesStimulus(injectable core.StimulusInjectable, kind core.StimulusKind) bool {
+	return kind == core.StimulusReset || kind == core.StimulusSpeed || injectable.ReceivesStimulus(kind)
+}
+
+// IsReplaying returns true if there are stimuli of a replay waiting to be injected.
+func (e *baseEmulator) IsReplaying() bool {
+	return inputMode(e.inputMode.Load()) == inputReplaying
+}
+
+// receiveStimulus handles the input received by the devices of the computer.
+func (e *baseEmulator) receiveStimulus(stimulus core.Stimulus) {
+	e.handleInput(func() (core.Stimulus, error) {
+		return stimulus, e.injectStimulus(stimulus)
+	})
+}
+
+// handleInput applies an input received from outside of the emulation loop. While recording
+// it's queued to be applied and recorded at the start of the next cycle, and during a replay
+// it's ignored. The function applies the input and returns the stimulus to record, or an
+// error if the input can't be applied, which is reported by GetLastError.
+func (e *baseEmulator) handleInput(apply func() (core.Stimulus, error)) {
+	e.inputMutex.Lock()
+	defer e.inputMutex.Unlock()
+
+	switch inputMode(e.inputMode.Load()) {
+	case inputRecording:
+		e.pendingInput = append(e.pendingInput, apply)
+	case inputReplaying:
+	default:
+		if _, err := apply(); err != nil {
+			e.reportError(err)
+		}
+	}
+}
+
+// injectStimulus applies the stimulus, the reset and speed are handled by the emulator and the
+// rest of the input by the computer.
+func (e *baseEmulator) injectStimulus(stimulus core.Stimulus) error {
+	switch stimulus.Kind {
+	case core.StimulusReset:
+		if stimulus.Value != 0 {
+			e.reset()
+		} else {
+			e.unReset()
+		}
+	case core.StimulusSpeed:
+		e.config.SpeedController.SetTargetSpeed(stimulus.Value)
+	default:
+		injectable, ok := e.config.Computer.(core.StimulusInjectable)
+		if !ok {
+			return fmt.Errorf("the computer doesn't receive %s stimuli", stimulus.Kind)
+		}
+
+		return injectable.InjectStimulus(stimulus)
+	}
+
+	return nil
+}
+
+// applyInput applies and records the input received since the previous cycle or, during a
+// replay, injects the stimuli of this cycle. When the last stimulus is injected, the input
+// received by the computer is applied again as soon as it's received. The input that can't
+// be applied is not recorded, and if a stimulus of the replay can't be injected the replay
+// is stopped. If the recording can't be written it's stopped and the input is applied as
+// soon as it's received.
+func (e *baseEmulator) applyInput(context *common.StepContext) {
+	switch inputMode(e.inputMode.Load()) {
+	case inputRecording:
+		e.inputMutex.Lock()
+		pending := e.pendingInput
+		e.pendingInput = nil
+		e.inputMutex.Unlock()
+
+		for _, apply := range pending {
+			stimulus, err := apply()
+			if err != nil {
+				e.reportError(err)
+				continue
+			}
+
+			stimulus.Cycle = context.Cycle
+
+			if err := e.config.InputRecorder.RecordStimulus(stimulus); err != nil {
+				e.failRecording(err)
+			}
+		}
+	case inputReplaying:
+		e.inputMutex.Lock()
+		defer e.inputMutex.Unlock()
+
+		for len(e.replayStimuli) > 0 && e.replayStimuli[0].Cycle <= context.Cycle {
+			if err := e.injectStimulus(e.replayStimuli[0]); err != nil {
+				e.reportError(fmt.Errorf("the replay was stopped at cycle %d: %w", context.Cycle, err))
+				e.replayStimuli = nil
+				break
+			}
+
+			e.replayStimuli = e.replayStimuli[1:]
+		}
+
+		if len(e.replayStimuli) == 0 {
+			e.config.Computer.(core.StimulusInjectable).SetStimulusHandler(nil)
+			e.inputMode.Store(int32(inputLive))
+		}
+	}
+}
+
+/************************************************************************************
+* Errors
+*************************************************************************************/
+
+// GetLastError returns the last error that occurred while emulating, like the input that
+// couldn't be applied or a stimulus of a replay that couldn't be injected. It's shown by
+// the console until other error occurs.
+func (e *baseEmulator) GetLastError() error {
+	e.errorMutex.Lock()
+	defer e.errorMutex.Unlock()
+
+	return e.lastError
+}
+
+// reportError keeps the error to be returned by GetLastError.
+func (e *baseEmulator) reportError(err error) {
+	e.errorMutex.Lock()
+	defer e.errorMutex.Unlock()
+
+	e.lastError = err
+}
+
+/************************************************************************************
+* Program reload
+*************************************************************************************/
+
+// WatchPrograms watches the files and, when any of them changes, calls the reload function
+// from the emulation loop before the next cycle or display refresh. After a successful reload
+// the computer is kept in reset for a few cycles, unless the reset was already pressed.
+// Breakpoints are kept, the execution history is discarded by the reset.
+func (e *baseEmulator) WatchPrograms(paths []string, reload func() error) error {
+	if e.config.FileWatcher == nil {
+		return errors.New("the emulator doesn't support watching the programs")
+	}
+
+	e.reloadMutex.Lock()
+	defer e.reloadMutex.Unlock()
+
+	if err := e.config.FileWatcher.Watch(paths, func() { e.reloadRequested.Store(true) }); err != nil {
+		return err
+	}
+
+	e.reloadFunc = reload
+	e.reloadStatus = core.ReloadStatus{Watching: true}
+
+	return nil
+}
+
+// StopWatchingPrograms stops watching the program files. A reload already requested is
+// discarded. If the files are not watched, this method has no effect.
+func (e *baseEmulator) StopWatchingPrograms() {
+	if e.config.FileWatcher == nil {
+		return
+	}
+
+	e.config.FileWatcher.Stop()
+
+	e.reloadMutex.Lock()
+	defer e.reloadMutex.Unlock()
+
+	e.reloadFunc = nil
+	e.reloadStatus.Watching = false
+}
+
+// GetReloadStatus returns the status of the watch and the result of the last reload.
+func (e *baseEmulator) GetReloadStatus() core.ReloadStatus {
+	e.reloadMutex.Lock()
+	defer e.reloadMutex.Unlock()
+
+	return e.reloadStatus
+}
+
+// processReloadRequest reloads the programs if their files changed and resets the computer.
+func (e *baseEmulator) processReloadRequest() {
+	if !e.reloadRequested.Swap(false) {
+		return
+	}
+
+	e.reloadMutex.Lock()
+	reload := e.reloadFunc
+	e.reloadMutex.Unlock()
+
+	if reload == nil {
+		return
+	}
+
+	err := reload()
+
+	e.reloadMutex.Lock()
+	e.reloadStatus.Err = err
+	if err == nil {
+		e.reloadStatus.Reloads++
+		e.reloadStatus.LastReload = time.Now()
+	}
+	e.reloadMutex.Unlock()
+
+	if err != nil {
+		return
+	}
+
+	// The reset pressed from the console is released from the console
+	if e.reloadResetCycles == 0 && e.resetting {
+		return
+	}
+
+	if e.reloadResetCycles == 0 {
+		e.Reset()
+	}
+
+	e.reloadResetCycles = reloadResetCycles
+}
+
+// countReloadReset releases the reset of the computer once it was held the required cycles
+// after reloading the programs.
+func (e *baseEmulator) countReloadReset() {
+	if e.reloadResetCycles == 0 {
+		return
+	}
+
+	e.reloadResetCycles--
+	if e.reloadResetCycles == 0 {
+		e.UnReset()
+	}
+}
+
+/************************************************************************************
+* Profile
+*************************************************************************************/
+
+// StartProfiling starts counting the instructions and cycles executed at each address.
+// The counts collected before are kept, they are discarded by ClearProfile.
+func (e *baseEmulator) StartProfiling() error {
+	if e.config.Profiler == nil || e.config.Processor == nil {
+		return errors.New("the emulator doesn't support profiling")
+	}
+
+	e.config.Profiler.SetEnabled(true)
+
+	return nil
+}
+
+// StopProfiling stops counting the executed instructions and cycles.
+// If the profiler is not running, this method has no effect.
+func (e *baseEmulator) StopProfiling() {
+	if e.config.Profiler != nil {
+		e.config.Profiler.SetEnabled(false)
+	}
+}
+
+// IsProfiling returns true if the executed instructions and cycles are being counted.
+func (e *baseEmulator) IsProfiling() bool {
+	return e.config.Profiler != nil && e.config.Profiler.IsEnabled()
+}
+
+// ClearProfile discards the counts collected by the profiler.
+func (e *baseEmulator) ClearProfile() {
+	if e.config.Profiler != nil {
+		e.config.Profiler.Clear()
+	}
+}
+
+// ExportProfile writes the flat report of the profile to the specified path and the
+// collapsed stacks to the same path with the ".folded" extension added. Existing files
+// are truncated.
+func (e *baseEmulator) ExportProfile(path string) error {
+	if e.config.Profiler == nil {
+		return errors.New("the emulator doesn't support profiling")
+	}
+
+	if err := writeReportFile(path, e.config.Profiler.WriteReport); err != nil {
+		return err
+	}
+
+	return writeReportFile(path+".folded", e.config.Profiler.WriteCollapsedStacks)
+}
+
+// writeReportFile creates the file and writes to it using the specified function.
+func writeReportFile(path string, write func(writer io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+/************************************************************************************
+* Coverage
+*************************************************************************************/
+
+// StartCoverage starts recording the accesses done by the processor to each address.
+// The accesses recorded before are kept.
+func (e *baseEmulator) StartCoverage() error {
+	if e.config.CoverageRecorder == nil || e.config.Processor == nil {
+		return errors.New("the emulator doesn't support coverage")
+	}
+
+	e.config.CoverageRecorder.SetEnabled(true)
+
+	return nil
+}
+
+// StopCoverage stops recording the accesses done by the processor.
+// If the coverage is not being recorded, this method has no effect.
+func (e *baseEmulator) StopCoverage() {
+	if e.config.CoverageRecorder != nil {
+		e.config.CoverageRecorder.SetEnabled(false)
+	}
+}
+
+// IsRecordingCoverage returns true if the accesses of the processor are being recorded.
+func (e *baseEmulator) IsRecordingCoverage() bool {
+	return e.config.CoverageRecorder != nil && e.config.CoverageRecorder.IsEnabled()
+}
+
+// ExportCoverage writes the annotated listing to the specified path and, if a source map is
+// loaded, the line coverage in lcov format to the same path with the ".info" extension added.
+// Existing files are truncated.
+func (e *baseEmulator) ExportCoverage(path string) error {
+	if e.config.CoverageRecorder == nil {
+		return errors.New("the emulator doesn't support coverage")
+	}
+
+	if err := writeReportFile(path, e.config.CoverageRecorder.WriteListing); err != nil {
+		return err
+	}
+
+	if e.config.SourceMap == nil {
+		return nil
+	}
+
+	return writeReportFile(path+".info", func(writer io.Writer) error {
+		return e.config.CoverageRecorder.WriteLcov(writer, e.config.SourceMap)
+	})
+}
+
+/************************************************************************************
+* Headless
+*************************************************************************************/
+
+// RunHeadless executes the emulation in the calling goroutine, without speed control, until
+// one of the conditions is met. The trace, profiler, coverage and history keep working as in
+// the emulation loop, but breakpoints and watchpoints don't stop the run and the console is
+// not ticked. It must not be called while the emulation loop is running.
+func (e *baseEmulator) RunHeadless(conditions core.StopConditions) (*common.StepContext, core.RunResult, error) {
+	if e.config.Processor == nil {
+		return nil, core.RunResult{}, errors.New("the emulator doesn't support running headless")
+	}
+
+	e.headless = true
+	defer func() { e.headless = false }()
+
+	context := common.NewVirtualStepContext(e.virtualTimeFrequency)
+
+	for {
+		e.Tick(&context)
+		e.PostTick(&context)
+
+		result, stop := e.checkStopConditions(&conditions)
+
+		context.NextCycle()
+
+		if stop {
+			return &context, result, nil
+		}
+
+		if conditions.MaxCycles > 0 && context.Cycle >= conditions.MaxCycles {
+			return &context, core.RunResult{Reason: core.StopMaxCycles}, nil
+		}
+	}
+}
+
+// checkStopConditions returns the stop condition met by the access done by the processor
+// in this cycle, if any. Cycles in which the processor is not driving the bus are skipped.
+func (e *baseEmulator) checkStopConditions(conditions *core.StopConditions) (core.RunResult, bool) {
+	processor := e.config.Processor
+
+	if !processor.Ready().Enabled() || !processor.BusEnable().Enabled() {
+		return core.RunResult{}, false
+	}
+
+	address := processor.AddressBus().Read()
+
+	if processor.ReadWrite().Enabled() {
+		if slices.Contains(conditions.WriteAddresses, address) {
+			return core.RunResult{Reason: core.StopWrite, Address: address, Value: processor.DataBus().Read()}, true
+		}
+
+		return core.RunResult{}, false
+	}
+
+	if !processor.IsReadingOpcode() {
+		return core.RunResult{}, false
+	}
+
+	if slices.Contains(conditions.Addresses, address) {
+		return core.RunResult{Reason: core.StopAddress, Address: address}, true
+	}
+
+	switch processor.GetCurrentInstruction().Mnemonic() {
+	case cpu.BRK:
+		if conditions.OnBreak {
+			return core.RunResult{Reason: core.StopBreak, Address: address}, true
+		}
+	case cpu.STP, cpu.JAM:
+		if conditions.OnStop {
+			return core.RunResult{Reason: core.StopStop, Address: address}, true
+		}
+	}
+
+	return core.RunResult{}, false
+}
+
 /************************************************************************************
 * State Getters
 *************************************************************************************/
@@ -137,7 +1225,7 @@ func (e *baseEmulator) IsPaused() bool {
 // IsStepping returns true if the emulator is currently in stepping mode.
 // Stepping mode allows for single-step execution of the computer system.
 func (e *baseEmulator) IsStepping() bool {
-	return e.stepping
+	return e.stepping != stepNone
 }
 
 // IsResetting returns true if the computer system is currently being reset.
@@ -151,34 +1239,330 @@ func (e *baseEmulator) IsResetting() bool {
 *************************************************************************************/
 
 // Tick starts one emulation cycle by letting the computer drive buses and lines.
+// Pending requests to save or load a state file or reload the programs are completed
+// before, and if a rewind was requested, the computer is restored to the requested cycle.
+// The input recorded or replayed is applied right before the computer ticks. If the history
+// can't save or restore the state of the computer it's disabled and the error is reported.
 func (e *baseEmulator) Tick(context *common.StepContext) {
+	e.processStateRequest(context)
+	e.countReloadReset()
+	e.processReloadRequest()
+
+	if e.rewinding {
+		if err := e.rewind(context); err != nil {
+			e.disableHistory(err)
+		}
+	}
+
+	if history := e.history(); history != nil {
+		if err := history.SaveSnapshot(context.Cycle); err != nil {
+			e.disableHistory(err)
+		}
+	}
+
+	e.applyInput(context)
+
 	e.config.Computer.Tick(context)
 }
 
 // PostTick completes one emulation cycle and updates emulator state.
 func (e *baseEmulator) PostTick(context *common.StepContext) {
 	e.config.Computer.PostTick(context)
+	e.recordCycle(context)
+	e.traceCycle(context)
+	e.sampleWaveform(context)
+	e.profileCycle()
+	e.coverCycle()
 	e.afterComputerTick(context)
 }
 
+// recordCycle adds the bus access done by the processor in this cycle to the history.
+func (e *baseEmulator) recordCycle(context *common.StepContext) {
+	e.lastCycle = context.Cycle
+
+	history := e.history()
+	if history == nil || e.config.Processor == nil {
+		return
+	}
+
+	history.RecordCycle(context.Cycle, e.readBusCycle(context))
+}
+
+// history returns the history manager, nil if the emulator keeps no history or it was
+// disabled.
+func (e *baseEmulator) history() core.HistoryManager {
+	if e.historyFailed.Load() {
+		return nil
+	}
+
+	return e.config.HistoryManager
+}
+
+// disableHistory discards the history after it failed, the emulation continues without it
+// and the error is reported.
+func (e *baseEmulator) disableHistory(err error) {
+	e.config.HistoryManager.Clear()
+	e.historyFailed.Store(true)
+	e.reportError(fmt.Errorf("the history was disabled: %w", err))
+}
+
+// traceCycle passes the bus access done by the processor in this cycle to the trace logger.
+// Cycles in which the processor is not driving the bus are skipped. If the log can't be
+// written the trace is stopped and the error is returned by StopTrace.
+func (e *baseEmulator) traceCycle(context *common.StepContext) {
+	processor := e.config.Processor
+	if e.config.TraceLogger == nil || processor == nil || !e.config.TraceLogger.IsEnabled() {
+		return
+	}
+
+	if !processor.Ready().Enabled() || !processor.BusEnable().Enabled() {
+		return
+	}
+
+	if err := e.config.TraceLogger.TraceCycle(context.Cycle, e.readBusCycle(context)); err != nil {
+		e.traceFile.fail(err)
+	}
+}
+
+// sampleWaveform passes the time of the cycle to the waveform recorder, that samples the
+// signals of the computer. If the dump can't be written it's stopped and the error is
+// returned by StopWaveform.
+func (e *baseEmulator) sampleWaveform(context *common.StepContext) {
+	if e.config.WaveformRecorder == nil || !e.config.WaveformRecorder.IsEnabled() {
+		return
+	}
+
+	if err := e.config.WaveformRecorder.SampleCycle(context.T); err != nil {
+		e.waveformFile.fail(err)
+	}
+}
+
+// profileCycle attributes the cycle to the instruction being executed by the processor.
+// Cycles in which the processor is not driving the bus are skipped.
+func (e *baseEmulator) profileCycle() {
+	processor := e.config.Processor
+	if e.config.Profiler == nil || processor == nil || !e.config.Profiler.IsEnabled() {
+		return
+	}
+
+	if !processor.Ready().Enabled() || !processor.BusEnable().Enabled() {
+		return
+	}
+
+	e.config.Profiler.ProfileCycle()
+}
+
+// coverCycle records the access done by the processor in this cycle in the coverage.
+// Cycles in which the processor is not driving the bus are skipped.
+func (e *baseEmulator) coverCycle() {
+	processor := e.config.Processor
+	if e.config.CoverageRecorder == nil || processor == nil || !e.config.CoverageRecorder.IsEnabled() {
+		return
+	}
+
+	if !processor.Ready().Enabled() || !processor.BusEnable().Enabled() {
+		return
+	}
+
+	e.config.CoverageRecorder.RecordCycle()
+}
+
+// readBusCycle returns the bus access done by the processor in this cycle.
+func (e *baseEmulator) readBusCycle(context *common.StepContext) core.BusCycle {
+	processor := e.config.Processor
+
+	return core.BusCycle{
+		T:       context.T,
+		Address: processor.AddressBus().Read(),
+		Data:    processor.DataBus().Read(),
+		Write:   processor.ReadWrite().Enabled(),
+		Fetch:   processor.IsReadingOpcode(),
+	}
+}
+
+// rewind restores the computer to the state it had before executing the requested cycle.
+// The latest snapshot before the cycle is loaded and the cycles between both are executed
+// again with the timing they had originally. Breakpoints and watchpoints are not evaluated
+// while the cycles are executed again. The context is left ready to execute the requested
+// cycle with its original timing.
+//
+// Returns:
+//   - An error, without executing any cycle, if the snapshot can't be restored
+func (e *baseEmulator) rewind(context *common.StepContext) error {
+	e.rewinding = false
+
+	history := e.history()
+	if history == nil {
+		return nil
+	}
+
+	target, _ := history.GetCycle(e.rewindCycle)
+
+	cycle, err := history.Restore(e.rewindCycle)
+	if err != nil {
+		return err
+	}
+
+	// The calls in progress when the snapshot was taken are not known
+	if e.calls != nil {
+		e.calls.clear()
+	}
+
+	for context.Cycle = cycle; context.Cycle < e.rewindCycle; context.Cycle++ {
+		access, _ := history.GetCycle(context.Cycle)
+		context.T = access.T
+
+		e.config.Computer.Tick(context)
+		e.config.Computer.PostTick(context)
+		e.trackInstruction()
+		e.config.Console.Tick(context)
+	}
+
+	context.T = target.T
+
+	return nil
+}
+
 // afterComputerTick handles debugger and console updates after a completed cycle.
 func (e *baseEmulator) afterComputerTick(context *common.StepContext) {
+	fetching := e.trackInstruction()
+
 	// Clear stepping state
-	if e.IsStepping() {
-		e.Pause()
-		e.stepping = false
+	if e.isStepCompleted(fetching) {
+		e.pauseExecution()
+	}
+
+	if e.isOnBreakpoint() {
+		e.pauseExecution()
+	}
+
+	if e.isOnWatchpoint(context) {
+		e.pauseExecution()
+	}
+
+	if !e.headless {
+		e.config.Console.Tick(context)
+	}
+}
+
+// pauseExecution pauses the emulation cancelling any step in progress.
+func (e *baseEmulator) pauseExecution() {
+	e.Pause()
+	e.stepping = stepNone
+}
+
+// isStepCompleted returns true if the condition of the step in progress is met.
+func (e *baseEmulator) isStepCompleted(fetching bool) bool {
+	switch e.stepping {
+	case stepCycle:
+		return true
+
+	case stepInstruction:
+		return fetching
+
+	case stepOver:
+		return fetching &&
+			e.instructionAddress == e.stepTargetAddress &&
+			e.config.Processor.GetStackPointer() >= e.stepStackPointer
+
+	case stepOut:
+		if !fetching {
+			return false
+		}
+
+		if e.stepHasFrame {
+			return e.calls.hasReturnedFrom(e.stepFrame)
+		}
+
+		if e.previousInstruction == nil {
+			return false
+		}
+
+		mnemonic := e.previousInstruction.Mnemonic()
+
+		return (mnemonic == cpu.RTS || mnemonic == cpu.RTI) &&
+			e.config.Processor.GetStackPointer() > e.stepStackPointer
+
+	case stepSourceLine:
+		if !fetching {
+			return false
+		}
+
+		location, ok := e.config.SourceMap.GetSourceLocation(e.instructionAddress)
+
+		return ok && (!e.stepHasSource || location != e.stepSourceLocation)
+	}
+
+	return false
+}
+
+// isOnBreakpoint returns true if the processor reached an address with a breakpoint
+// whose condition holds. After the opcode fetch the program counter points to the
+// byte following the opcode.
+func (e *baseEmulator) isOnBreakpoint() bool {
+	address := e.config.Computer.GetProgramCounter() - 1
+
+	if e.config.Processor == nil {
+		return e.config.BreakpointManager.HasBreakpoint(address)
+	}
+
+	return e.config.Processor.IsReadingOpcode() && e.config.BreakpointManager.ShouldBreak(address)
+}
+
+// trackInstruction keeps the address of the instruction being executed by the processor
+// and the instruction executed before it, they are updated every time the processor
+// fetches an opcode. The calls in progress are followed on every cycle.
+//
+// Returns:
+//   - true if the processor fetched an opcode in this cycle
+func (e *baseEmulator) trackInstruction() bool {
+	if e.config.Processor == nil {
+		return false
+	}
+
+	e.calls.track()
+
+	if !e.config.Processor.IsReadingOpcode() {
+		return false
+	}
+
+	e.instructionAddress = e.config.Processor.GetProgramCounter() - 1
+	e.previousInstruction = e.currentInstruction
+	e.currentInstruction = e.config.Processor.GetCurrentInstruction()
+
+	return true
+}
+
+// isOnWatchpoint observes the processor address bus and R/W line and returns true if
+// the access performed in this cycle triggered a watchpoint.
+func (e *baseEmulator) isOnWatchpoint(context *common.StepContext) bool {
+	processor := e.config.Processor
+
+	if processor == nil || e.config.WatchpointManager == nil || e.config.WatchpointManager.GetWatchpointCount() == 0 {
+		return false
 	}
 
-	if e.config.BreakpointManager.HasBreakpoint(e.config.Computer.GetProgramCounter() - 1) {
-		e.Pause()
+	// Processor is not driving the bus
+	if !processor.Ready().Enabled() || !processor.BusEnable().Enabled() {
+		return false
 	}
 
-	e.config.Console.Tick(context)
+	return e.config.WatchpointManager.CheckAccess(
+		processor.AddressBus().Read(),
+		processor.ReadWrite().Enabled(),
+		processor.DataBus().Read(),
+		e.instructionAddress,
+		context.Cycle,
+	)
 }
 
 // Draw renders the current state of the emulation by delegating to the console's
 // draw method. This is typically called to update the visual representation
-// of the computer system's current state.
+// of the computer system's current state. Pending requests to save or load a state
+// file or reload the programs are completed before, as the emulation loop keeps drawing
+// while paused.
 func (e *baseEmulator) Draw(context *common.StepContext) {
+	e.processStateRequest(context)
+	e.processReloadRequest()
 	e.config.Console.Draw(context)
 }
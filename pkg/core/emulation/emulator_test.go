@@ -0,0 +1,1046 @@
+package emulation
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/components/memory"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Maximum number of cycles to run before considering that the emulator will not pause
+const maxTestCycles int = 1000
+
+// Test program:
+//
+//	0400: JSR $0410
+//	0403: LDA #$01
+//	0405: STA $0200
+//	0408: NOP
+//	0410: LDX #$05
+//	0412: JSR $0420
+//	0415: RTS
+//	0420: INX
+//	0421: RTS
+var testProgram map[uint16][]uint8 = map[uint16][]uint8{
+	0x0400: {0x20, 0x10, 0x04, 0xA9, 0x01, 0x8D, 0x00, 0x02, 0xEA},
+	0x0410: {0xA2, 0x05, 0x20, 0x20, 0x04, 0x60},
+	0x0420: {0xE8, 0x60},
+}
+
+type testComputer struct {
+	processor components.Cpu65C02
+	ram       components.Memory
+
+	// Input received by the computer
+	resets          []bool
+	injected        []core.Stimulus
+	stimulusHandler func(stimulus core.Stimulus)
+
+	// The state can't be saved or loaded
+	saveFails bool
+	loadFails bool
+}
+
+func newTestComputer() *testComputer {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+
+	alwaysHighLine := buses.NewStandaloneLine(true)
+	alwaysLowLine := buses.NewStandaloneLine(false)
+	writeEnableLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(alwaysLowLine)
+	ram.OutputEnable().Connect(alwaysLowLine)
+
+	processor := cpu.NewCpu65C02S()
+	processor.AddressBus().Connect(addressBus)
+	processor.DataBus().Connect(dataBus)
+	processor.BusEnable().Connect(alwaysHighLine)
+	processor.ReadWrite().Connect(writeEnableLine)
+	processor.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	processor.Sync().Connect(buses.NewStandaloneLine(false))
+	processor.Ready().Connect(alwaysHighLine)
+	processor.VectorPull().Connect(buses.NewStandaloneLine(false))
+	processor.SetOverflow().Connect(alwaysHighLine)
+	processor.Reset().Connect(alwaysHighLine)
+	processor.InterruptRequest().Connect(alwaysHighLine)
+	processor.NonMaskableInterrupt().Connect(alwaysHighLine)
+
+	for address, values := range testProgram {
+		for i, value := range values {
+			ram.Poke(address+uint16(i), value)
+		}
+	}
+
+	processor.ForceProgramCounter(0x0400)
+
+	return &testComputer{processor: processor, ram: ram}
+}
+
+func (c *testComputer) Tick(context *common.StepContext) {
+	c.processor.Tick(context)
+	c.ram.Tick(context)
+}
+
+func (c *testComputer) PostTick(context *common.StepContext) {
+	c.processor.PostTick(context)
+}
+
+func (c *testComputer) GetProgramCounter() uint16 {
+	return c.processor.GetProgramCounter()
+}
+
+func (c *testComputer) Reset(status bool) {
+	c.resets = append(c.resets, status)
+}
+
+func (c *testComputer) SetStimulusHandler(handler func(stimulus core.Stimulus)) {
+	c.stimulusHandler = handler
+}
+
+func (c *testComputer) InjectStimulus(stimulus core.Stimulus) error {
+	if stimulus.Kind != core.StimulusSerial || len(stimulus.Data) == 0 {
+		return errors.New("invalid stimulus")
+	}
+
+	c.injected = append(c.injected, stimulus)
+	return nil
+}
+
+func (c *testComputer) ReceivesStimulus(kind core.StimulusKind) bool {
+	return kind == core.StimulusSerial
+}
+
+// receive simulates input received by a device of the computer, it's injected directly
+// unless a handler is set
+func (c *testComputer) receive(value uint8) {
+	stimulus := core.Stimulus{Kind: core.StimulusSerial, Data: []byte{value}}
+
+	if c.stimulusHandler != nil {
+		c.stimulusHandler(stimulus)
+	} else {
+		c.InjectStimulus(stimulus)
+	}
+}
+
+func (c *testComputer) SaveState(writer io.Writer) error {
+	if c.saveFails {
+		return errors.New("state not available")
+	}
+
+	return core.SaveStates(writer, c.processor, c.ram)
+}
+
+func (c *testComputer) LoadState(reader io.Reader) error {
+	if c.loadFails {
+		return errors.New("state not available")
+	}
+
+	return core.LoadStates(reader, c.processor, c.ram)
+}
+
+type testLoop struct {
+	paused    bool
+	frequency uint64
+}
+
+func (l *testLoop) Start() (*common.StepContext, error)                               { return nil, nil }
+func (l *testLoop) Stop()                                                             {}
+func (l *testLoop) IsRunning() bool                                                   { return true }
+func (l *testLoop) IsStopping() bool                                                  { return false }
+func (l *testLoop) Pause()                                                            { l.paused = true }
+func (l *testLoop) Resume()                                                           { l.paused = false }
+func (l *testLoop) IsPaused() bool                                                    { return l.paused }
+func (l *testLoop) SetPanicHandler(handler func(loopType string, panicData any) bool) {}
+func (l *testLoop) SetVirtualTime(frequency uint64)                                   { l.frequency = frequency }
+
+type testConsole struct {
+	ticks int
+}
+
+func (c *testConsole) Tick(context *common.StepContext) { c.ticks++ }
+func (c *testConsole) Draw(context *common.StepContext) {}
+func (c *testConsole) Run() error                       { return nil }
+func (c *testConsole) Stop()                            {}
+
+// testSourceMap maps the test program to source lines, the subroutine at $0420
+// has no source lines as if it was library code built without debug information
+type testSourceMap map[uint16]int
+
+func (m testSourceMap) GetSourceLocation(address uint16) (core.SourceLocation, bool) {
+	line, ok := m[address]
+	return core.SourceLocation{File: "test.s", Line: line}, ok
+}
+
+func (m testSourceMap) GetSourceLines(file string) ([]string, error) { return nil, nil }
+
+var testProgramSource testSourceMap = testSourceMap{
+	0x0400: 1,
+	0x0403: 2, // LDA and STA in the same line as if generated by a macro
+	0x0405: 2,
+	0x0408: 3,
+	0x0410: 10,
+	0x0412: 11,
+	0x0415: 12,
+}
+
+type testEmulator struct {
+	*baseEmulator
+	computer    *testComputer
+	loop        *testLoop
+	breakpoints core.BreakpointManager
+	watchpoints core.WatchpointManager
+	context     common.StepContext
+}
+
+func newTestEmulator() *testEmulator {
+	computer := newTestComputer()
+	loop := &testLoop{}
+	peek := func(address uint16) uint8 { return computer.ram.Peek(uint32(address)) }
+
+	breakpoints := managers.NewConditionalBreakpointManager(computer.processor, peek)
+	watchpoints := managers.NewWatchpointManager(peek)
+
+	// Small interval to make the snapshots fall in the middle of the instructions
+	history := managers.NewHistoryManager(computer, 7, 20)
+
+	emulator := newBaseEmulator(EmulatorConfig{
+		Computer:          computer,
+		Processor:         computer.processor,
+		Console:           &testConsole{},
+		Loop:              loop,
+		SpeedController:   testSpeedController{},
+		BreakpointManager: breakpoints,
+		WatchpointManager: watchpoints,
+		SourceMap:         testProgramSource,
+		HistoryManager:    history,
+		TraceLogger:       managers.NewTraceLogger(computer.processor, peek),
+		WaveformRecorder: managers.NewWaveformRecorder("test", []core.WaveformSignal{
+			{Name: "A", Width: 16, Read: computer.processor.AddressBus().Read},
+		}),
+		Profiler: managers.NewProfiler(computer.processor, nil),
+		CoverageRecorder: managers.NewCoverageRecorder(computer.processor, peek, nil, []core.CoverageRegion{
+			{Name: "Program", Start: 0x0400, End: 0x042F},
+		}),
+		InputRecorder: managers.NewInputRecorder(),
+	})
+
+	return &testEmulator{
+		baseEmulator: emulator,
+		computer:     computer,
+		loop:         loop,
+		breakpoints:  breakpoints,
+		watchpoints:  watchpoints,
+		context:      common.NewStepContext(),
+	}
+}
+
+// run executes cycles until the emulator pauses, returning the number of cycles executed
+func (e *testEmulator) run(t *testing.T) int {
+	cycles := 0
+
+	for !e.loop.IsPaused() {
+		e.Tick(&e.context)
+		e.PostTick(&e.context)
+		e.context.NextCycle()
+
+		cycles++
+		if cycles > maxTestCycles {
+			t.Fatal("emulator did not pause")
+		}
+	}
+
+	return cycles
+}
+
+// runCycles executes the specified number of cycles
+func (e *testEmulator) runCycles(cycles int) {
+	for range cycles {
+		e.Tick(&e.context)
+		e.PostTick(&e.context)
+		e.context.NextCycle()
+	}
+}
+
+// runToBreakpoint adds a breakpoint at the specified address and runs until it is reached
+func (e *testEmulator) runToBreakpoint(t *testing.T, address uint16) {
+	e.breakpoints.AddBreakpoint(address)
+	e.Resume()
+	e.run(t)
+	e.breakpoints.RemoveBreakpoint(address)
+
+	assert.Equal(t, address, e.instructionAddress)
+}
+
+func TestBreakpointPausesOnOpcodeFetch(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0410)
+
+	assert.Equal(t, uint16(0x0411), e.computer.processor.GetProgramCounter())
+	assert.True(t, e.computer.processor.IsReadingOpcode())
+}
+
+func TestConditionalBreakpoint(t *testing.T) {
+	e := newTestEmulator()
+	assert.NoError(t, e.breakpoints.AddConditionalBreakpoint(0x0420, "X == 5"))
+	assert.NoError(t, e.breakpoints.AddConditionalBreakpoint(0x0421, "X == 5"))
+
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0420), e.instructionAddress)
+}
+
+func TestStepCycle(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+
+	e.Step()
+	assert.True(t, e.IsStepping())
+	assert.Equal(t, 1, e.run(t))
+	assert.False(t, e.IsStepping())
+}
+
+func TestStepInstruction(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+
+	// JSR takes 6 cycles
+	e.StepInstruction()
+	assert.Equal(t, 6, e.run(t))
+	assert.Equal(t, uint16(0x0410), e.instructionAddress)
+
+	e.StepInstruction()
+	assert.Equal(t, 2, e.run(t))
+	assert.Equal(t, uint16(0x0412), e.instructionAddress)
+	assert.False(t, e.IsStepping())
+}
+
+func TestStepOver(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+
+	e.StepOver()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0403), e.instructionAddress)
+	assert.Equal(t, uint8(0x06), e.computer.processor.GetXRegister())
+	assert.Equal(t, uint8(0xFD), e.computer.processor.GetStackPointer())
+
+	// Step over on a non call instruction behaves like step instruction
+	e.StepOver()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0405), e.instructionAddress)
+}
+
+func TestStepOverStopsOnBreakpoint(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+
+	e.breakpoints.AddBreakpoint(0x0420)
+	e.StepOver()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0420), e.instructionAddress)
+	assert.False(t, e.IsStepping())
+}
+
+func TestStepOut(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0420)
+
+	e.StepOut()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0415), e.instructionAddress)
+
+	e.StepOut()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0403), e.instructionAddress)
+	assert.Equal(t, uint8(0xFD), e.computer.processor.GetStackPointer())
+}
+
+func TestStepOutWaitsForTheReturnOfTheCall(t *testing.T) {
+	e := newTestEmulator()
+
+	// 0400: JSR $0430
+	// 0430: PHA
+	// 0431: PLA
+	// 0432: JSR $0420
+	// 0435: RTS
+	e.computer.ram.Poke(0x0401, 0x30)
+	for i, value := range []uint8{0x48, 0x68, 0x20, 0x20, 0x04, 0x60} {
+		e.computer.ram.Poke(0x0430+uint16(i), value)
+	}
+
+	// The stack pointer is below the one of the call when the step starts, the return of
+	// the nested call leaves it above but doesn't end the step
+	e.runToBreakpoint(t, 0x0431)
+
+	e.StepOut()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0403), e.instructionAddress)
+	assert.Equal(t, uint8(0xFD), e.computer.processor.GetStackPointer())
+}
+
+func TestResumeCancelsStep(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+
+	e.StepOut()
+	assert.True(t, e.IsStepping())
+
+	e.Pause()
+	e.Resume()
+	assert.False(t, e.IsStepping())
+}
+
+func TestWatchpointPausesOnAccess(t *testing.T) {
+	e := newTestEmulator()
+	e.watchpoints.AddWatchpoint(0x0200, 0x0200, core.WatchWrite)
+
+	e.run(t)
+
+	hits := e.watchpoints.GetHits()
+	assert.Equal(t, 1, len(hits))
+	assert.Equal(t, uint16(0x0405), hits[0].ProgramCounter)
+	assert.Equal(t, uint16(0x0200), hits[0].Address)
+	assert.True(t, hits[0].Write)
+	assert.Equal(t, uint8(0x00), hits[0].OldValue)
+	assert.Equal(t, uint8(0x01), hits[0].NewValue)
+	assert.Equal(t, uint8(0x01), e.computer.ram.Peek(0x0200))
+}
+
+func TestStepSourceLine(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+
+	expected := []uint16{0x0410, 0x0412, 0x0415, 0x0403, 0x0408}
+
+	for _, address := range expected {
+		e.StepSourceLine()
+		e.run(t)
+
+		assert.Equal(t, address, e.instructionAddress)
+		assert.False(t, e.IsStepping())
+	}
+}
+
+func TestStepSourceLineWithoutSourceMap(t *testing.T) {
+	e := newTestEmulator()
+	e.config.SourceMap = nil
+	e.runToBreakpoint(t, 0x0410)
+
+	e.StepSourceLine()
+	assert.Equal(t, 2, e.run(t))
+	assert.Equal(t, uint16(0x0412), e.instructionAddress)
+}
+
+func TestStepBack(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0400)
+	stackPointer := e.computer.processor.GetStackPointer()
+
+	e.runToBreakpoint(t, 0x0420)
+	assert.Equal(t, uint8(0x05), e.computer.processor.GetXRegister())
+
+	expected := []uint16{0x0412, 0x0410, 0x0400}
+
+	for _, address := range expected {
+		e.StepBack()
+		assert.True(t, e.IsStepping())
+		assert.Equal(t, 1, e.run(t))
+
+		assert.Equal(t, address, e.instructionAddress)
+		assert.True(t, e.computer.processor.IsReadingOpcode())
+		assert.False(t, e.IsStepping())
+	}
+
+	assert.Equal(t, uint8(0x00), e.computer.processor.GetXRegister())
+	assert.Equal(t, stackPointer, e.computer.processor.GetStackPointer())
+
+	// Execution continues normally after going back
+	e.runToBreakpoint(t, 0x0403)
+	assert.Equal(t, uint8(0x06), e.computer.processor.GetXRegister())
+	assert.Equal(t, stackPointer, e.computer.processor.GetStackPointer())
+}
+
+func TestStepBackCycle(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0403)
+
+	e.StepBackCycle()
+	assert.Equal(t, 1, e.run(t))
+	assert.False(t, e.computer.processor.IsReadingOpcode())
+
+	e.StepInstruction()
+	assert.Equal(t, 1, e.run(t))
+	assert.Equal(t, uint16(0x0403), e.instructionAddress)
+}
+
+func TestRunBack(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0408)
+	assert.Equal(t, uint8(0x01), e.computer.ram.Peek(0x0200))
+
+	e.breakpoints.AddBreakpoint(0x0410)
+	e.RunBack()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0410), e.instructionAddress)
+	assert.Equal(t, uint8(0x00), e.computer.ram.Peek(0x0200))
+
+	// Without breakpoints before the current cycle goes to the oldest cycle in history
+	e.breakpoints.RemoveBreakpoint(0x0410)
+	e.RunBack()
+	e.run(t)
+
+	assert.Equal(t, uint16(0x0400), e.instructionAddress)
+}
+
+func TestStepBackWithoutHistory(t *testing.T) {
+	e := newTestEmulator()
+	e.config.HistoryManager = nil
+	e.runToBreakpoint(t, 0x0410)
+
+	e.StepBack()
+	e.RunBack()
+	assert.True(t, e.IsPaused())
+	assert.False(t, e.IsStepping())
+}
+
+func TestHistoryIsDisabledWhenSnapshotFails(t *testing.T) {
+	e := newTestEmulator()
+	e.computer.saveFails = true
+
+	e.runToBreakpoint(t, 0x0410)
+	assert.ErrorContains(t, e.GetLastError(), "the history was disabled: state not available")
+
+	e.StepBack()
+	assert.False(t, e.IsStepping())
+}
+
+func TestHistoryIsDisabledWhenRestoreFails(t *testing.T) {
+	e := newTestEmulator()
+	e.runToBreakpoint(t, 0x0410)
+
+	e.computer.loadFails = true
+	e.StepBack()
+	assert.Equal(t, 1, e.run(t))
+
+	assert.ErrorContains(t, e.GetLastError(), "the history was disabled")
+	assert.Equal(t, uint16(0x0410), e.instructionAddress)
+
+	// Execution continues without history
+	e.computer.loadFails = false
+	e.runToBreakpoint(t, 0x0420)
+
+	e.StepBack()
+	assert.False(t, e.IsStepping())
+}
+
+func TestSaveAndLoadStateFile(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.state")
+
+	e.runToBreakpoint(t, 0x0408)
+	assert.Equal(t, uint8(0x01), e.computer.ram.Peek(0x0200))
+	savedCycle := e.context.Cycle
+
+	var saveErr error = io.EOF
+	e.SaveStateFile(path, func(err error) { saveErr = err })
+	e.Draw(&e.context)
+	require.NoError(t, saveErr)
+
+	e.computer.ram.Poke(0x0200, 0x00)
+	e.StepInstruction()
+	e.run(t)
+	assert.NotEqual(t, uint16(0x0408), e.instructionAddress)
+
+	var loadErr error = io.EOF
+	e.LoadStateFile(path, func(err error) { loadErr = err })
+	e.Draw(&e.context)
+	require.NoError(t, loadErr)
+
+	assert.Equal(t, uint16(0x0408), e.instructionAddress)
+	assert.Equal(t, uint8(0x01), e.computer.ram.Peek(0x0200))
+	assert.Equal(t, savedCycle, e.context.Cycle)
+
+	// The history before the load is discarded
+	e.StepBack()
+	assert.False(t, e.IsStepping())
+
+	// Requests are completed only once
+	loadErr = io.EOF
+	e.Draw(&e.context)
+	assert.Equal(t, io.EOF, loadErr)
+}
+
+func TestLoadInvalidStateFile(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.state")
+	require.NoError(t, os.WriteFile(path, []byte{0x01, 0x02}, 0o644))
+
+	e.runToBreakpoint(t, 0x0408)
+	accumulator := e.computer.processor.GetAccumulatorRegister()
+
+	var loadErr error
+	e.LoadStateFile(path, func(err error) { loadErr = err })
+	e.Tick(&e.context)
+
+	assert.Error(t, loadErr)
+	assert.Equal(t, accumulator, e.computer.processor.GetAccumulatorRegister())
+	assert.Equal(t, uint8(0x01), e.computer.ram.Peek(0x0200))
+}
+
+func TestLoadStateFilePausesWhenBackupCantBeRestored(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.state")
+	require.NoError(t, os.WriteFile(path, []byte{0x01, 0x02}, 0o644))
+
+	e.runToBreakpoint(t, 0x0408)
+	e.Resume()
+	e.computer.loadFails = true
+
+	var loadErr error
+	e.LoadStateFile(path, func(err error) { loadErr = err })
+	e.Tick(&e.context)
+
+	assert.ErrorContains(t, loadErr, "the previous state couldn't be restored")
+	assert.True(t, e.IsPaused())
+}
+
+func TestTraceFile(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.trace")
+
+	require.NoError(t, e.StartTrace(path))
+	assert.True(t, e.IsTracing())
+
+	e.runToBreakpoint(t, 0x0408)
+
+	require.NoError(t, e.StopTrace())
+	assert.False(t, e.IsTracing())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// The line of the NOP is written when the trace is stopped
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 9)
+	assert.True(t, strings.HasPrefix(lines[0], "0400  20 10 04  JSR $0410"), lines[0])
+	assert.True(t, strings.HasPrefix(lines[7], "0405  8D 00 02  STA $0200 = 01"), lines[7])
+	assert.True(t, strings.HasPrefix(lines[8], "0408  EA        NOP"), lines[8])
+}
+
+func TestTraceStopsOnWriteError(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.trace")
+
+	require.NoError(t, e.StartTrace(path))
+
+	// The writes fail once the buffer of the logger is flushed to the closed file
+	require.NoError(t, e.traceFile.file.Close())
+	e.runCycles(maxTestCycles)
+
+	assert.False(t, e.IsTracing())
+	assert.ErrorIs(t, e.StopTrace(), os.ErrClosed)
+
+	// The error is only reported once
+	assert.NoError(t, e.StopTrace())
+	require.NoError(t, e.StartTrace(path))
+	require.NoError(t, e.StopTrace())
+}
+
+func TestWaveformFile(t *testing.T) {
+	e := newTestEmulator()
+	e.context = common.NewVirtualStepContext(1_000_000)
+	path := filepath.Join(t.TempDir(), "test.vcd")
+
+	require.NoError(t, e.StartWaveform(path))
+	assert.True(t, e.IsRecordingWaveform())
+
+	e.runCycles(2)
+
+	require.NoError(t, e.StopWaveform())
+	assert.False(t, e.IsRecordingWaveform())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Each cycle takes 1000 ns, the address of the JSR and its first operand are read
+	assert.True(t, strings.HasSuffix(string(data), "$enddefinitions $end\n#0\n$dumpvars\nb10000000000 !\n$end\n#1000\nb10000000001 !\n#1001\n"), string(data))
+}
+
+func TestWaveformStopsOnWriteError(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.vcd")
+
+	require.NoError(t, e.StartWaveform(path))
+
+	// The writes fail once the buffer of the recorder is flushed to the closed file
+	require.NoError(t, e.waveformFile.file.Close())
+	e.runCycles(maxTestCycles)
+
+	assert.False(t, e.IsRecordingWaveform())
+	assert.ErrorIs(t, e.StopWaveform(), os.ErrClosed)
+	assert.NoError(t, e.StopWaveform())
+}
+
+func TestProfileFiles(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.profile")
+
+	require.NoError(t, e.StartProfiling())
+	assert.True(t, e.IsProfiling())
+
+	e.runToBreakpoint(t, 0x0408)
+
+	e.StopProfiling()
+	assert.False(t, e.IsProfiling())
+
+	require.NoError(t, e.ExportProfile(path))
+
+	report, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Regexp(t, `\$0410 +1 +3 +14 `, string(report))
+
+	stacks, err := os.ReadFile(path + ".folded")
+	require.NoError(t, err)
+	assert.Contains(t, string(stacks), "[root];$0410 14\n")
+	assert.Contains(t, string(stacks), "[root];$0410;$0420 8\n")
+
+	e.ClearProfile()
+	require.NoError(t, e.ExportProfile(path))
+
+	stacks, err = os.ReadFile(path + ".folded")
+	require.NoError(t, err)
+	assert.Empty(t, stacks)
+}
+
+func TestCoverageFiles(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.coverage")
+
+	require.NoError(t, e.StartCoverage())
+	assert.True(t, e.IsRecordingCoverage())
+
+	e.runToBreakpoint(t, 0x0408)
+
+	e.StopCoverage()
+	assert.False(t, e.IsRecordingCoverage())
+
+	require.NoError(t, e.ExportCoverage(path))
+
+	listing, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(listing), "0400  E---  20 10 04  JSR $0410\n")
+	assert.Contains(t, string(listing), "0422  ----            ; never touched $0422-$042F (14 bytes)\n")
+
+	lcov, err := os.ReadFile(path + ".info")
+	require.NoError(t, err)
+	assert.Equal(t, "TN:\nSF:test.s\nDA:1,1\nDA:2,2\nDA:3,1\nDA:10,1\nDA:11,1\nDA:12,1\nLF:6\nLH:6\nend_of_record\n", string(lcov))
+}
+
+func TestRunHeadlessStopsOnWrite(t *testing.T) {
+	e := newTestEmulator()
+
+	context, result, err := e.RunHeadless(core.StopConditions{MaxCycles: uint64(maxTestCycles), WriteAddresses: []uint16{0x0200}})
+	require.NoError(t, err)
+
+	assert.Equal(t, core.RunResult{Reason: core.StopWrite, Address: 0x0200, Value: 0x01}, result)
+	assert.Equal(t, uint8(0x01), e.computer.ram.Peek(0x0200))
+	assert.Equal(t, uint64(34), context.Cycle)
+}
+
+func TestRunHeadlessStopsOnOpcodes(t *testing.T) {
+	e := newTestEmulator()
+
+	_, result, err := e.RunHeadless(core.StopConditions{MaxCycles: uint64(maxTestCycles), Addresses: []uint16{0x0420}})
+	require.NoError(t, err)
+	assert.Equal(t, core.RunResult{Reason: core.StopAddress, Address: 0x0420}, result)
+	assert.Equal(t, uint8(0x05), e.computer.processor.GetXRegister())
+
+	// The byte following the NOP at $0408 is a BRK
+	_, result, err = e.RunHeadless(core.StopConditions{MaxCycles: uint64(maxTestCycles), OnBreak: true})
+	require.NoError(t, err)
+	assert.Equal(t, core.RunResult{Reason: core.StopBreak, Address: 0x0409}, result)
+}
+
+func TestRunHeadlessStopsOnMaxCycles(t *testing.T) {
+	e := newTestEmulator()
+
+	context, result, err := e.RunHeadless(core.StopConditions{MaxCycles: 10, OnStop: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, core.StopMaxCycles, result.Reason)
+	assert.Equal(t, uint64(10), context.Cycle)
+	assert.Zero(t, e.config.Console.(*testConsole).ticks)
+
+	// The console is ticked again by the emulation loop
+	e.Tick(context)
+	e.PostTick(context)
+	assert.Equal(t, 1, e.config.Console.(*testConsole).ticks)
+}
+
+func TestRunHeadlessWithVirtualTime(t *testing.T) {
+	e := newTestEmulator()
+	e.SetVirtualTime(2_000_000)
+
+	assert.Equal(t, uint64(2_000_000), e.config.Loop.(*testLoop).frequency)
+
+	context, _, err := e.RunHeadless(core.StopConditions{MaxCycles: 10})
+	require.NoError(t, err)
+
+	// Each cycle takes 500ns at 2 MHz
+	assert.Equal(t, int64(5*time.Microsecond), context.T)
+}
+
+func TestInputIsAppliedDirectlyWhenNotRecording(t *testing.T) {
+	e := newTestEmulator()
+
+	e.computer.receive('A')
+	e.Reset()
+
+	assert.Equal(t, []core.Stimulus{{Kind: core.StimulusSerial, Data: []byte{'A'}}}, e.computer.injected)
+	assert.Equal(t, []bool{true}, e.computer.resets)
+	assert.True(t, e.IsResetting())
+}
+
+func TestRecordInput(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.input")
+
+	require.NoError(t, e.StartRecording(path))
+	assert.True(t, e.IsRecording())
+
+	e.runCycles(5)
+
+	// The input is applied at the start of the next cycle
+	e.computer.receive('A')
+	e.Reset()
+	assert.Empty(t, e.computer.injected)
+	assert.Empty(t, e.computer.resets)
+
+	e.runCycles(1)
+	assert.Equal(t, []core.Stimulus{{Kind: core.StimulusSerial, Data: []byte{'A'}}}, e.computer.injected)
+	assert.Equal(t, []bool{true}, e.computer.resets)
+
+	e.runCycles(2)
+	e.UnReset()
+	e.runCycles(1)
+
+	require.NoError(t, e.StopRecording())
+	assert.False(t, e.IsRecording())
+	assert.Nil(t, e.computer.stimulusHandler)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# cycle kind payload\n5 serial 41\n5 reset on\n8 reset off\n", string(data))
+}
+
+func TestReplayInput(t *testing.T) {
+	e := newTestEmulator()
+
+	require.NoError(t, e.StartReplay([]core.Stimulus{
+		{Cycle: 2, Kind: core.StimulusSerial, Data: []byte{'A'}},
+		{Cycle: 2, Kind: core.StimulusReset, Value: 1},
+		{Cycle: 4, Kind: core.StimulusReset, Value: 0},
+	}))
+	assert.True(t, e.IsReplaying())
+
+	// The input received during the replay is ignored
+	e.computer.receive('B')
+	e.Reset()
+
+	e.runCycles(2)
+	assert.Empty(t, e.computer.injected)
+	assert.Empty(t, e.computer.resets)
+
+	e.runCycles(1)
+	assert.Equal(t, []core.Stimulus{{Cycle: 2, Kind: core.StimulusSerial, Data: []byte{'A'}}}, e.computer.injected)
+	assert.Equal(t, []bool{true}, e.computer.resets)
+	assert.True(t, e.IsReplaying())
+
+	e.runCycles(2)
+	assert.Equal(t, []bool{true, false}, e.computer.resets)
+
+	// Once the last stimulus is injected the input is applied again
+	assert.False(t, e.IsReplaying())
+	assert.Nil(t, e.computer.stimulusHandler)
+
+	e.computer.receive('C')
+	assert.Len(t, e.computer.injected, 2)
+}
+
+func TestReplayRejectsStimuliNotReceived(t *testing.T) {
+	e := newTestEmulator()
+
+	err := e.StartReplay([]core.Stimulus{
+		{Cycle: 2, Kind: core.StimulusSerial, Data: []byte{'A'}},
+		{Cycle: 4, Kind: core.StimulusKeyboard, Data: []byte{0x1C}},
+	})
+
+	assert.ErrorContains(t, err, "keyboard stimulus of cycle 4")
+	assert.False(t, e.IsReplaying())
+}
+
+func TestReplayStopsOnInjectionError(t *testing.T) {
+	e := newTestEmulator()
+
+	require.NoError(t, e.StartReplay([]core.Stimulus{
+		{Cycle: 2, Kind: core.StimulusSerial},
+		{Cycle: 4, Kind: core.StimulusSerial, Data: []byte{'A'}},
+	}))
+
+	e.runCycles(3)
+
+	assert.False(t, e.IsReplaying())
+	assert.ErrorContains(t, e.GetLastError(), "the replay was stopped at cycle 2")
+	assert.Nil(t, e.computer.stimulusHandler)
+
+	e.runCycles(2)
+	assert.Empty(t, e.computer.injected)
+}
+
+func TestRecordingStopsOnWriteError(t *testing.T) {
+	e := newTestEmulator()
+	path := filepath.Join(t.TempDir(), "test.input")
+
+	require.NoError(t, e.StartRecording(path))
+
+	// Invalid input is reported and not recorded
+	e.computer.stimulusHandler(core.Stimulus{Kind: core.StimulusSerial})
+	e.runCycles(1)
+	assert.Error(t, e.GetLastError())
+	assert.True(t, e.IsRecording())
+
+	// The writes fail once the buffer of the recorder is flushed to the closed file
+	require.NoError(t, e.inputFile.file.Close())
+	for range maxTestCycles {
+		e.computer.receive('A')
+		e.runCycles(1)
+	}
+
+	assert.False(t, e.IsRecording())
+	assert.Nil(t, e.computer.stimulusHandler)
+	assert.Len(t, e.computer.injected, maxTestCycles)
+	assert.ErrorIs(t, e.StopRecording(), os.ErrClosed)
+
+	// The input is applied as soon as it's received
+	e.computer.receive('B')
+	assert.Len(t, e.computer.injected, maxTestCycles+1)
+}
+
+func TestReplayRejectsUnsortedStimuli(t *testing.T) {
+	e := newTestEmulator()
+
+	err := e.StartReplay([]core.Stimulus{
+		{Cycle: 4, Kind: core.StimulusReset, Value: 1},
+		{Cycle: 2, Kind: core.StimulusReset, Value: 0},
+	})
+
+	assert.Error(t, err)
+	assert.False(t, e.IsReplaying())
+}
+
+// testFileWatcher reports the changes when requested by the test
+type testFileWatcher struct {
+	paths    []string
+	onChange func()
+}
+
+func (w *testFileWatcher) Watch(paths []string, onChange func()) error {
+	w.paths = paths
+	w.onChange = onChange
+	return nil
+}
+
+func (w *testFileWatcher) Stop() {
+	w.onChange = nil
+}
+
+func TestReloadProgramsResetsTheComputer(t *testing.T) {
+	e := newTestEmulator()
+	watcher := &testFileWatcher{}
+	e.config.FileWatcher = watcher
+
+	reloads := 0
+	var reloadErr error
+
+	require.NoError(t, e.WatchPrograms([]string{"rom.bin"}, func() error {
+		reloads++
+		return reloadErr
+	}))
+
+	assert.Equal(t, []string{"rom.bin"}, watcher.paths)
+	assert.Equal(t, core.ReloadStatus{Watching: true}, e.GetReloadStatus())
+
+	e.breakpoints.AddBreakpoint(0x1234)
+	watcher.onChange()
+	e.runCycles(1)
+
+	assert.Equal(t, 1, reloads)
+	assert.Equal(t, []bool{true}, e.computer.resets)
+	assert.True(t, e.IsResetting())
+
+	e.runCycles(reloadResetCycles)
+	assert.Equal(t, []bool{true, false}, e.computer.resets)
+	assert.False(t, e.IsResetting())
+	assert.True(t, e.breakpoints.HasBreakpoint(0x1234))
+
+	status := e.GetReloadStatus()
+	assert.Equal(t, 1, status.Reloads)
+	assert.NoError(t, status.Err)
+
+	// Reloaded while paused, the reset is released once resumed
+	e.Pause()
+	watcher.onChange()
+	e.Draw(&e.context)
+
+	assert.Equal(t, 2, reloads)
+	assert.True(t, e.IsResetting())
+
+	// A failed reload doesn't reset the computer
+	reloadErr = errors.New("invalid file")
+	e.Resume()
+	e.runCycles(reloadResetCycles)
+
+	watcher.onChange()
+	e.runCycles(1)
+
+	assert.Equal(t, 3, reloads)
+	assert.Equal(t, []bool{true, false, true, false}, e.computer.resets)
+	assert.ErrorContains(t, e.GetReloadStatus().Err, "invalid file")
+	assert.Equal(t, 2, e.GetReloadStatus().Reloads)
+
+	e.StopWatchingPrograms()
+	assert.False(t, e.GetReloadStatus().Watching)
+	assert.Nil(t, watcher.onChange)
+}
+
+func TestWatchProgramsWithoutFileWatcher(t *testing.T) {
+	e := newTestEmulator()
+
+	assert.Error(t, e.WatchPrograms([]string{"rom.bin"}, func() error { return nil }))
+	assert.False(t, e.GetReloadStatus().Watching)
+}
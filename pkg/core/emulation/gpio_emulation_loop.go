@@ -51,6 +51,7 @@ type gpioEmulationLoop struct {
 	stop            atomic.Bool
 	pause           atomic.Bool
 	stepMu          sync.Mutex
+	frequency       uint64
 
 	gpioController *common.GPIOController
 }
@@ -99,6 +100,15 @@ func (g *gpioEmulationLoop) SetPanicHandler(handler func(loopType string, panicD
 	g.panicHandler = handler
 }
 
+// SetVirtualTime sets the frequency of the external PHI2 clock used to derive the time of the
+// context from the cycle count. It applies from the next time the loop starts.
+//
+// Parameters:
+//   - frequency: The frequency of the clock in Hz, 0 uses the wall clock
+func (g *gpioEmulationLoop) SetVirtualTime(frequency uint64) {
+	g.frequency = frequency
+}
+
 // IsRunning returns true when either the GPIO loop or draw loop is running.
 //
 // Returns:
@@ -131,7 +141,7 @@ func (g *gpioEmulationLoop) IsStopping() bool {
 //   - An error if the loop is already running or no emulator is configured
 func (g *gpioEmulationLoop) Start() (*common.StepContext, error) {
 	if !g.IsRunning() && g.config.Emulator != nil {
-		context := common.NewStepContext()
+		context := common.NewVirtualStepContext(g.frequency)
 
 		g.pause.Store(false)
 		g.stop.Store(false)
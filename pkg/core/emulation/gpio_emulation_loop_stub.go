@@ -30,6 +30,7 @@ func (s *stubGPIOLoop) IsStopping() bool
 func (s *stubGPIOLoop) Stop()                                                             {}
 func (s *stubGPIOLoop) Resume()                                                           {}
 func (s *stubGPIOLoop) Pause()                                                            {}
+func (s *stubGPIOLoop) SetVirtualTime(frequency uint64)                                   {}
 
 func (s *stubGPIOLoop) Start() (*common.StepContext, error) {
 	return nil, fmt.Errorf("GPIO emulation is only supported on Linux systems")
@@ -0,0 +1,105 @@
+package emulation
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// outputSink is a manager that writes its output to the writer set by the emulator, like the
+// trace logger, the waveform recorder and the input recorder.
+type outputSink interface {
+	SetWriter(writer io.Writer) error
+}
+
+// outputFile owns the file where a sink writes its output. The mutex serializes starting and
+// stopping the output from the UI with the write errors reported by the emulation loop.
+type outputFile struct {
+	sink  outputSink
+	mutex sync.Mutex
+	file  *os.File
+	err   error // Error that stopped the output, returned when it's stopped or started again
+}
+
+// start creates the file and sets it as the writer of the sink. If the file exists it's
+// truncated, and if an output was in progress its file is closed. If the previous output
+// failed, its error is returned without starting a new one.
+func (f *outputFile) start(path string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.takeError(); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := f.close(); err != nil {
+		file.Close()
+		return err
+	}
+
+	// The setter only fails writing to the previous file, which was already closed
+	f.sink.SetWriter(file)
+	f.file = file
+
+	return nil
+}
+
+// stop completes the output in progress and closes its file. If the output failed, its
+// error is returned. If there is no output in progress, this method has no effect.
+func (f *outputFile) stop() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if err := f.takeError(); err != nil {
+		return err
+	}
+
+	return f.close()
+}
+
+// fail stops the output after the sink failed writing to the file, the emulation continues
+// without it. The error is kept until the output is stopped or started again. If there is no
+// output in progress, the error is ignored.
+func (f *outputFile) fail(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.file == nil {
+		return
+	}
+
+	// Writing the end of the output fails too, only the first error is kept
+	f.close()
+	f.err = err
+}
+
+// takeError returns and clears the error that stopped the output, if any. The mutex must be
+// held.
+func (f *outputFile) takeError() error {
+	err := f.err
+	f.err = nil
+
+	return err
+}
+
+// close removes the writer of the sink, so it writes the end of its output, and closes the
+// file. The mutex must be held.
+func (f *outputFile) close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.sink.SetWriter(nil)
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	f.file = nil
+
+	return err
+}
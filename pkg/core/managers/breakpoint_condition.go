@@ -0,0 +1,545 @@
+package managers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+)
+
+/*
+Breakpoint conditions are small expressions evaluated every time the program counter
+reaches a breakpoint address. The breakpoint only pauses the emulation when the
+expression evaluates to a non zero value.
+
+Supported syntax:
+
+	Numbers:    255, $FF, 0xFF, %11111111
+	Registers:  A, X, Y, SP, PC, P
+	Flags:      N, V, D, I, Z, C (evaluate to 0 or 1)
+	Counters:   hitcount (number of times the breakpoint was reached, including this one)
+	Memory:     [address] reads one byte, w[address] reads a little endian word
+	Operators:  || && | ^ & == != < <= > >= << >> + - * / % ! ~ and unary -
+
+Examples:
+
+	A == $FF && X > 3
+	[$0200] != 0
+	hitcount >= 10
+*/
+
+// conditionEnvironment holds the machine state breakpoint conditions are evaluated against.
+type conditionEnvironment struct {
+	registers components.CpuRegisters
+	peek      func(address uint16) uint8
+	hitCount  uint64
+}
+
+// conditionNode is a node of a parsed condition expression tree.
+type conditionNode interface {
+	evaluate(env *conditionEnvironment) int64
+}
+
+// breakpointCondition is a compiled breakpoint condition.
+type breakpointCondition struct {
+	text string
+	root conditionNode
+}
+
+// compileCondition parses the condition text returning the compiled expression.
+// An empty (or blank) text returns a nil condition, meaning the breakpoint is unconditional.
+func compileCondition(text string) (*breakpointCondition, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeCondition(text)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &conditionParser{tokens: tokens}
+
+	root, err := parser.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if !parser.done() {
+		return nil, fmt.Errorf("unexpected %q at position %d", parser.peek().text, parser.peek().position)
+	}
+
+	return &breakpointCondition{text: text, root: root}, nil
+}
+
+// evaluate returns true if the condition holds in the specified environment.
+func (c *breakpointCondition) evaluate(env *conditionEnvironment) bool {
+	if c == nil {
+		return true
+	}
+
+	return c.root.evaluate(env) != 0
+}
+
+/************************************************************************************
+* Tokenizer
+*************************************************************************************/
+
+type conditionTokenType int
+
+const (
+	tokenNumber conditionTokenType = iota
+	tokenIdentifier
+	tokenOperator
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+	tokenEnd
+)
+
+type conditionToken struct {
+	tokenType conditionTokenType
+	text      string
+	value     int64
+	position  int
+}
+
+// Operators are ordered so the longest ones are matched first.
+var conditionOperators = []string{
+	"||", "&&", "==", "!=", "<=", ">=", "<<", ">>",
+	"|", "^", "&", "<", ">", "+", "-", "*", "/", "%", "!", "~",
+}
+
+// tokenizeCondition splits the condition text into tokens.
+func tokenizeCondition(text string) ([]conditionToken, error) {
+	tokens := []conditionToken{}
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, conditionToken{tokenType: tokenOpenParen, text: "(", position: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, conditionToken{tokenType: tokenCloseParen, text: ")", position: i})
+			i++
+
+		case r == '[':
+			tokens = append(tokens, conditionToken{tokenType: tokenOpenBracket, text: "[", position: i})
+			i++
+
+		case r == ']':
+			tokens = append(tokens, conditionToken{tokenType: tokenCloseBracket, text: "]", position: i})
+			i++
+
+		case r == '$' || (r == '%' && i+1 < len(runes) && (runes[i+1] == '0' || runes[i+1] == '1') && expectsOperand(tokens)) || unicode.IsDigit(r):
+			start := i
+			base := 10
+
+			switch {
+			case r == '$':
+				base = 16
+				i++
+			case r == '%':
+				base = 2
+				i++
+			case r == '0' && i+1 < len(runes) && (runes[i+1] == 'x' || runes[i+1] == 'X'):
+				base = 16
+				i += 2
+			}
+
+			digitsStart := i
+			for i < len(runes) && isDigitOfBase(runes[i], base) {
+				i++
+			}
+
+			digits := string(runes[digitsStart:i])
+			value, err := strconv.ParseInt(digits, base, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", string(runes[start:i]), start)
+			}
+
+			tokens = append(tokens, conditionToken{tokenType: tokenNumber, text: string(runes[start:i]), value: value, position: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+
+			tokens = append(tokens, conditionToken{tokenType: tokenIdentifier, text: string(runes[start:i]), position: start})
+
+		default:
+			matched := false
+			for _, operator := range conditionOperators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, conditionToken{tokenType: tokenOperator, text: operator, position: i})
+					i += len([]rune(operator))
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", string(r), i)
+			}
+		}
+	}
+
+	tokens = append(tokens, conditionToken{tokenType: tokenEnd, text: "end of condition", position: len(runes)})
+
+	return tokens, nil
+}
+
+// expectsOperand returns true if the next token must be an operand. It is used to
+// distinguish the binary number prefix % from the modulo operator.
+func expectsOperand(tokens []conditionToken) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+
+	last := tokens[len(tokens)-1]
+
+	return last.tokenType == tokenOperator || last.tokenType == tokenOpenParen || last.tokenType == tokenOpenBracket
+}
+
+// isDigitOfBase returns true if the rune is a valid digit in the specified base.
+func isDigitOfBase(r rune, base int) bool {
+	switch base {
+	case 2:
+		return r == '0' || r == '1'
+	case 16:
+		return unicode.IsDigit(r) || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+	default:
+		return unicode.IsDigit(r)
+	}
+}
+
+/************************************************************************************
+* Parser
+*************************************************************************************/
+
+// Binary operator precedence, higher values bind tighter.
+var conditionPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"^":  4,
+	"&":  5,
+	"==": 6, "!=": 6,
+	"<": 7, "<=": 7, ">": 7, ">=": 7,
+	"<<": 8, ">>": 8,
+	"+": 9, "-": 9,
+	"*": 10, "/": 10, "%": 10,
+}
+
+// conditionParser is a precedence climbing parser for breakpoint conditions.
+type conditionParser struct {
+	tokens []conditionToken
+	index  int
+}
+
+func (p *conditionParser) peek() conditionToken {
+	return p.tokens[p.index]
+}
+
+func (p *conditionParser) next() conditionToken {
+	token := p.tokens[p.index]
+	if token.tokenType != tokenEnd {
+		p.index++
+	}
+
+	return token
+}
+
+func (p *conditionParser) done() bool {
+	return p.peek().tokenType == tokenEnd
+}
+
+// parseExpression parses binary operations whose precedence is higher than minPrecedence.
+func (p *conditionParser) parseExpression(minPrecedence int) (conditionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+		if token.tokenType != tokenOperator {
+			return left, nil
+		}
+
+		precedence, ok := conditionPrecedence[token.text]
+		if !ok || precedence <= minPrecedence {
+			return left, nil
+		}
+
+		p.next()
+
+		right, err := p.parseExpression(precedence)
+		if err != nil {
+			return nil, err
+		}
+
+		left = &binaryNode{operator: token.text, left: left, right: right}
+	}
+}
+
+// parseUnary parses unary operators followed by an operand.
+func (p *conditionParser) parseUnary() (conditionNode, error) {
+	token := p.peek()
+
+	if token.tokenType == tokenOperator && (token.text == "!" || token.text == "~" || token.text == "-") {
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &unaryNode{operator: token.text, operand: operand}, nil
+	}
+
+	return p.parseOperand()
+}
+
+// parseOperand parses numbers, identifiers, memory reads and parenthesized expressions.
+func (p *conditionParser) parseOperand() (conditionNode, error) {
+	token := p.next()
+
+	switch token.tokenType {
+	case tokenNumber:
+		return &numberNode{value: token.value}, nil
+
+	case tokenOpenParen:
+		node, err := p.parseExpression(0)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenCloseParen, ")"); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+
+	case tokenOpenBracket:
+		return p.parseMemory(false)
+
+	case tokenIdentifier:
+		name := strings.ToLower(token.text)
+
+		if name == "w" && p.peek().tokenType == tokenOpenBracket {
+			p.next()
+			return p.parseMemory(true)
+		}
+
+		if variable, ok := conditionVariables[name]; ok {
+			return &variableNode{name: name, read: variable}, nil
+		}
+
+		return nil, fmt.Errorf("unknown identifier %q at position %d", token.text, token.position)
+	}
+
+	return nil, fmt.Errorf("unexpected %q at position %d", token.text, token.position)
+}
+
+// parseMemory parses the address expression of a memory read after the opening bracket.
+func (p *conditionParser) parseMemory(word bool) (conditionNode, error) {
+	address, err := p.parseExpression(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenCloseBracket, "]"); err != nil {
+		return nil, err
+	}
+
+	return &memoryNode{address: address, word: word}, nil
+}
+
+// expect consumes the next token failing if it is not of the specified type.
+func (p *conditionParser) expect(tokenType conditionTokenType, text string) error {
+	token := p.next()
+	if token.tokenType != tokenType {
+		return fmt.Errorf("expected %q at position %d", text, token.position)
+	}
+
+	return nil
+}
+
+/************************************************************************************
+* Expression tree
+*************************************************************************************/
+
+// conditionVariables maps the identifiers available in conditions to the
+// functions that read their values.
+var conditionVariables = map[string]func(env *conditionEnvironment) int64{
+	"a":        func(env *conditionEnvironment) int64 { return int64(env.registers.GetAccumulatorRegister()) },
+	"x":        func(env *conditionEnvironment) int64 { return int64(env.registers.GetXRegister()) },
+	"y":        func(env *conditionEnvironment) int64 { return int64(env.registers.GetYRegister()) },
+	"sp":       func(env *conditionEnvironment) int64 { return int64(env.registers.GetStackPointer()) },
+	"pc":       func(env *conditionEnvironment) int64 { return int64(env.registers.GetProgramCounter()) },
+	"p":        readStatusRegister,
+	"c":        readFlag(0),
+	"z":        readFlag(1),
+	"i":        readFlag(2),
+	"d":        readFlag(3),
+	"v":        readFlag(6),
+	"n":        readFlag(7),
+	"hitcount": func(env *conditionEnvironment) int64 { return int64(env.hitCount) },
+}
+
+// readStatusRegister reads the value of the processor status register.
+func readStatusRegister(env *conditionEnvironment) int64 {
+	return int64(env.registers.GetProcessorStatusRegister().GetValue())
+}
+
+// readFlag returns a function that reads the specified status register flag as 0 or 1.
+func readFlag(bit components.StatusBit) func(env *conditionEnvironment) int64 {
+	return func(env *conditionEnvironment) int64 {
+		if env.registers.GetProcessorStatusRegister().Flag(bit) {
+			return 1
+		}
+
+		return 0
+	}
+}
+
+type numberNode struct {
+	value int64
+}
+
+func (n *numberNode) evaluate(env *conditionEnvironment) int64 {
+	return n.value
+}
+
+type variableNode struct {
+	name string
+	read func(env *conditionEnvironment) int64
+}
+
+func (n *variableNode) evaluate(env *conditionEnvironment) int64 {
+	if env.registers == nil && n.name != "hitcount" {
+		return 0
+	}
+
+	return n.read(env)
+}
+
+type memoryNode struct {
+	address conditionNode
+	word    bool
+}
+
+func (n *memoryNode) evaluate(env *conditionEnvironment) int64 {
+	if env.peek == nil {
+		return 0
+	}
+
+	address := uint16(n.address.evaluate(env))
+	value := int64(env.peek(address))
+
+	if n.word {
+		value |= int64(env.peek(address+1)) << 8
+	}
+
+	return value
+}
+
+type unaryNode struct {
+	operator string
+	operand  conditionNode
+}
+
+func (n *unaryNode) evaluate(env *conditionEnvironment) int64 {
+	value := n.operand.evaluate(env)
+
+	switch n.operator {
+	case "!":
+		return boolToInt(value == 0)
+	case "~":
+		return ^value
+	default:
+		return -value
+	}
+}
+
+type binaryNode struct {
+	operator string
+	left     conditionNode
+	right    conditionNode
+}
+
+func (n *binaryNode) evaluate(env *conditionEnvironment) int64 {
+	left := n.left.evaluate(env)
+
+	// Logical operators short circuit
+	switch n.operator {
+	case "&&":
+		return boolToInt(left != 0 && n.right.evaluate(env) != 0)
+	case "||":
+		return boolToInt(left != 0 || n.right.evaluate(env) != 0)
+	}
+
+	right := n.right.evaluate(env)
+
+	switch n.operator {
+	case "|":
+		return left | right
+	case "^":
+		return left ^ right
+	case "&":
+		return left & right
+	case "==":
+		return boolToInt(left == right)
+	case "!=":
+		return boolToInt(left != right)
+	case "<":
+		return boolToInt(left < right)
+	case "<=":
+		return boolToInt(left <= right)
+	case ">":
+		return boolToInt(left > right)
+	case ">=":
+		return boolToInt(left >= right)
+	case "<<":
+		return left << (uint64(right) & 63)
+	case ">>":
+		return left >> (uint64(right) & 63)
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	case "/":
+		if right == 0 {
+			return 0
+		}
+		return left / right
+	default:
+		if right == 0 {
+			return 0
+		}
+		return left % right
+	}
+}
+
+func boolToInt(value bool) int64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
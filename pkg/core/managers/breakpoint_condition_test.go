@@ -0,0 +1,126 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatusRegister uint8
+
+func (s testStatusRegister) Flag(bit components.StatusBit) bool {
+	return uint8(s)&(1<<bit) != 0
+}
+
+func (s testStatusRegister) GetValue() uint8 {
+	return uint8(s)
+}
+
+type testRegisters struct {
+	a, x, y, sp uint8
+	p           uint8
+	pc          uint16
+}
+
+func (r *testRegisters) GetAccumulatorRegister() uint8 { return r.a }
+func (r *testRegisters) GetXRegister() uint8           { return r.x }
+func (r *testRegisters) GetYRegister() uint8           { return r.y }
+func (r *testRegisters) GetStackPointer() uint8        { return r.sp }
+func (r *testRegisters) GetProcessorStatusRegister() components.StatusRegister {
+	return testStatusRegister(r.p)
+}
+func (r *testRegisters) GetProgramCounter() uint16        { return r.pc }
+func (r *testRegisters) ForceProgramCounter(value uint16) { r.pc = value }
+
+func newTestEnvironment() *conditionEnvironment {
+	memory := make([]uint8, 0x10000)
+	memory[0x0200] = 0x42
+	memory[0x0300] = 0x34
+	memory[0x0301] = 0x12
+
+	return &conditionEnvironment{
+		registers: &testRegisters{a: 0xFF, x: 4, y: 0x10, sp: 0xFD, p: 0b10000011, pc: 0x8000},
+		peek:      func(address uint16) uint8 { return memory[address] },
+		hitCount:  10,
+	}
+}
+
+func TestConditionEvaluation(t *testing.T) {
+	env := newTestEnvironment()
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"A == $FF && X > 3", true},
+		{"a == 0xff && x > 4", false},
+		{"[$0200] != 0", true},
+		{"[$0200] == 66", true},
+		{"[$0201] != 0", false},
+		{"w[$0300] == $1234", true},
+		{"[$01FF + 1] == $42", true},
+		{"hitcount >= 10", true},
+		{"hitcount > 10", false},
+		{"SP == $FD && PC == $8000", true},
+		{"Y == %00010000", true},
+		{"X % 3 == 1", true},
+		{"P == $83", true},
+		{"N && C && Z", true},
+		{"V || D || I", false},
+		{"!V", true},
+		{"(~A & $FF) == 0", true},
+		{"-X == 0 - 4", true},
+		{"(A >> 4) == $0F && (X << 1) == 8", true},
+		{"A + X * 2 == $FF + 8", true},
+		{"((A ^ $0F) | 1) == $F1", true},
+		{"X / 0 == 0", true},
+		{"1 || [$0200] == 0", true},
+		{"X <= 4 && X >= 4 && X < 5", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			condition, err := compileCondition(tt.condition)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, condition.evaluate(env))
+		})
+	}
+}
+
+func TestConditionEmptyIsUnconditional(t *testing.T) {
+	condition, err := compileCondition("   ")
+
+	assert.NoError(t, err)
+	assert.Nil(t, condition)
+	assert.True(t, condition.evaluate(newTestEnvironment()))
+}
+
+func TestConditionErrors(t *testing.T) {
+	tests := []string{
+		"A ==",
+		"(A == 1",
+		"[$0200",
+		"A == 1)",
+		"FOO == 1",
+		"A = 1",
+		"A == $",
+		"A == 1 2",
+		"A @ 1",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			condition, err := compileCondition(tt)
+			assert.Error(t, err)
+			assert.Nil(t, condition)
+		})
+	}
+}
+
+func TestConditionWithoutRegisters(t *testing.T) {
+	condition, err := compileCondition("A == 0 && [$1234] == 0 && hitcount == 3")
+	assert.NoError(t, err)
+
+	assert.True(t, condition.evaluate(&conditionEnvironment{hitCount: 3}))
+}
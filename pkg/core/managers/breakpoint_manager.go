@@ -3,13 +3,26 @@ package managers
 import (
 	"slices"
 
+	"github.com/fran150/clementina-6502/pkg/components"
 	"github.com/fran150/clementina-6502/pkg/core"
 )
 
+// breakpoint holds the data of a single breakpoint
+type breakpoint struct {
+	address   uint16
+	condition *breakpointCondition
+	hitCount  uint64
+}
+
 // breakpointManager manages breakpoints for debugging purposes.
 // It provides functionality to add, remove, and check breakpoints at specific addresses.
+// Breakpoints can optionally have a condition that is evaluated against the CPU
+// registers and memory when the breakpoint is reached.
 type breakpointManager struct {
-	breakpoints []uint16
+	breakpoints []*breakpoint
+
+	registers components.CpuRegisters
+	peek      func(address uint16) uint8
 }
 
 // newBreakpointManager creates a new breakpoint manager.
@@ -18,11 +31,13 @@ type breakpointManager struct {
 //   - A pointer to the initialized BreakpointManager
 func newBreakpointManager() *breakpointManager {
 	return &breakpointManager{
-		breakpoints: make([]uint16, 0),
+		breakpoints: make([]*breakpoint, 0),
 	}
 }
 
 // NewBreakpointManager creates a new breakpoint manager.
+// Conditions on breakpoints created by this manager can only use the hitcount variable,
+// registers and memory will always evaluate to 0.
 //
 // Returns:
 //   - A pointer to the initialized BreakpointManager
@@ -30,6 +45,23 @@ func NewBreakpointManager() core.BreakpointManager {
 	return newBreakpointManager()
 }
 
+// NewConditionalBreakpointManager creates a new breakpoint manager able to evaluate
+// conditions against the CPU registers and the computer's memory.
+//
+// Parameters:
+//   - registers: The CPU registers used to evaluate the conditions
+//   - peek: Function that returns the value of a memory address without side effects
+//
+// Returns:
+//   - A pointer to the initialized BreakpointManager
+func NewConditionalBreakpointManager(registers components.CpuRegisters, peek func(address uint16) uint8) core.BreakpointManager {
+	bm := newBreakpointManager()
+	bm.registers = registers
+	bm.peek = peek
+
+	return bm
+}
+
 // AddBreakpoint adds a breakpoint at the specified address.
 // If a breakpoint already exists at the address, it won't be added again.
 //
@@ -37,8 +69,36 @@ func NewBreakpointManager() core.BreakpointManager {
 //   - address: The address where the breakpoint should be set
 func (bm *breakpointManager) AddBreakpoint(address uint16) {
 	if !bm.HasBreakpoint(address) {
-		bm.breakpoints = append(bm.breakpoints, address)
+		bm.breakpoints = append(bm.breakpoints, &breakpoint{address: address})
+	}
+}
+
+// AddConditionalBreakpoint adds a breakpoint at the specified address that only
+// triggers when the condition evaluates to a non zero value. If a breakpoint already
+// exists at the address its condition is replaced and its hit count is reset.
+// An empty condition makes the breakpoint unconditional.
+//
+// Parameters:
+//   - address: The address where the breakpoint should be set
+//   - condition: The condition expression
+//
+// Returns:
+//   - An error if the condition can not be parsed, in which case no breakpoint is added or changed
+func (bm *breakpointManager) AddConditionalBreakpoint(address uint16, condition string) error {
+	compiled, err := compileCondition(condition)
+	if err != nil {
+		return err
+	}
+
+	if bp := bm.find(address); bp != nil {
+		bp.condition = compiled
+		bp.hitCount = 0
+		return nil
 	}
+
+	bm.breakpoints = append(bm.breakpoints, &breakpoint{address: address, condition: compiled})
+
+	return nil
 }
 
 // RemoveBreakpoint removes a breakpoint at the specified address.
@@ -48,7 +108,7 @@ func (bm *breakpointManager) AddBreakpoint(address uint16) {
 //   - address: The address where the breakpoint should be removed
 func (bm *breakpointManager) RemoveBreakpoint(address uint16) {
 	for i, bp := range bm.breakpoints {
-		if bp == address {
+		if bp.address == address {
 			bm.breakpoints = slices.Delete(bm.breakpoints, i, i+1)
 			break
 		}
@@ -74,7 +134,46 @@ func (bm *breakpointManager) RemoveBreakpointByIndex(index int) {
 // Returns:
 //   - true if a breakpoint exists at the address, false otherwise
 func (bm *breakpointManager) HasBreakpoint(address uint16) bool {
-	return slices.Contains(bm.breakpoints, address)
+	return bm.find(address) != nil
+}
+
+// ShouldBreak must be called when the processor reaches the specified address.
+// If there is a breakpoint at the address its hit count is incremented and its condition
+// evaluated.
+//
+// Parameters:
+//   - address: The address reached by the processor
+//
+// Returns:
+//   - true if there is a breakpoint at the address and its condition holds, false otherwise
+func (bm *breakpointManager) ShouldBreak(address uint16) bool {
+	bp := bm.find(address)
+	if bp == nil {
+		return false
+	}
+
+	bp.hitCount++
+
+	return bp.condition.evaluate(&conditionEnvironment{
+		registers: bm.registers,
+		peek:      bm.peek,
+		hitCount:  bp.hitCount,
+	})
+}
+
+// GetBreakpointCondition returns the condition of the breakpoint at the specified address.
+//
+// Parameters:
+//   - address: The address of the breakpoint
+//
+// Returns:
+//   - The condition text, or an empty string if the breakpoint is unconditional or doesn't exist
+func (bm *breakpointManager) GetBreakpointCondition(address uint16) string {
+	if bp := bm.find(address); bp != nil && bp.condition != nil {
+		return bp.condition.text
+	}
+
+	return ""
 }
 
 // GetBreakpoints returns a copy of all breakpoint addresses.
@@ -83,7 +182,9 @@ func (bm *breakpointManager) HasBreakpoint(address uint16) bool {
 //   - A slice containing all breakpoint addresses
 func (bm *breakpointManager) GetBreakpoints() []uint16 {
 	result := make([]uint16, len(bm.breakpoints))
-	copy(result, bm.breakpoints)
+	for i, bp := range bm.breakpoints {
+		result[i] = bp.address
+	}
 	return result
 }
 
@@ -99,3 +200,14 @@ func (bm *breakpointManager) GetBreakpointCount() int {
 func (bm *breakpointManager) ClearAllBreakpoints() {
 	bm.breakpoints = bm.breakpoints[:0]
 }
+
+// find returns the breakpoint at the specified address or nil if it doesn't exist.
+func (bm *breakpointManager) find(address uint16) *breakpoint {
+	for _, bp := range bm.breakpoints {
+		if bp.address == address {
+			return bp
+		}
+	}
+
+	return nil
+}
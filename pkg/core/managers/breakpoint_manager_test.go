@@ -121,3 +121,66 @@ func TestClearAllBreakpoints(t *testing.T) {
 	assert.Empty(t, bm.GetBreakpoints())
 	assert.False(t, bm.HasBreakpoint(0x1234))
 }
+
+func TestAddConditionalBreakpoint(t *testing.T) {
+	bm := NewBreakpointManager()
+
+	assert.NoError(t, bm.AddConditionalBreakpoint(0x1234, "hitcount >= 2"))
+	assert.True(t, bm.HasBreakpoint(0x1234))
+	assert.Equal(t, "hitcount >= 2", bm.GetBreakpointCondition(0x1234))
+
+	// Updating the condition of an existing breakpoint doesn't add a new one
+	assert.NoError(t, bm.AddConditionalBreakpoint(0x1234, " hitcount == 3 "))
+	assert.Equal(t, 1, bm.GetBreakpointCount())
+	assert.Equal(t, "hitcount == 3", bm.GetBreakpointCondition(0x1234))
+
+	// Invalid conditions are rejected
+	assert.Error(t, bm.AddConditionalBreakpoint(0x5678, "A =="))
+	assert.False(t, bm.HasBreakpoint(0x5678))
+	assert.Error(t, bm.AddConditionalBreakpoint(0x1234, "A =="))
+	assert.Equal(t, "hitcount == 3", bm.GetBreakpointCondition(0x1234))
+
+	// Unconditional breakpoints have no condition
+	bm.AddBreakpoint(0x9ABC)
+	assert.Empty(t, bm.GetBreakpointCondition(0x9ABC))
+	assert.Empty(t, bm.GetBreakpointCondition(0xDEAD))
+}
+
+func TestShouldBreak(t *testing.T) {
+	registers := &testRegisters{a: 0xFF, x: 2}
+	memory := map[uint16]uint8{0x0200: 0}
+
+	bm := NewConditionalBreakpointManager(registers, func(address uint16) uint8 {
+		return memory[address]
+	})
+
+	bm.AddBreakpoint(0x1000)
+	assert.NoError(t, bm.AddConditionalBreakpoint(0x2000, "A == $FF && X > 3"))
+	assert.NoError(t, bm.AddConditionalBreakpoint(0x3000, "[$0200] != 0"))
+	assert.NoError(t, bm.AddConditionalBreakpoint(0x4000, "hitcount >= 3"))
+
+	// Unconditional and missing breakpoints
+	assert.True(t, bm.ShouldBreak(0x1000))
+	assert.False(t, bm.ShouldBreak(0x1001))
+
+	// Register condition
+	assert.False(t, bm.ShouldBreak(0x2000))
+	registers.x = 4
+	assert.True(t, bm.ShouldBreak(0x2000))
+
+	// Memory condition
+	assert.False(t, bm.ShouldBreak(0x3000))
+	memory[0x0200] = 1
+	assert.True(t, bm.ShouldBreak(0x3000))
+
+	// Hit count condition
+	assert.False(t, bm.ShouldBreak(0x4000))
+	assert.False(t, bm.ShouldBreak(0x4000))
+	assert.True(t, bm.ShouldBreak(0x4000))
+	assert.True(t, bm.ShouldBreak(0x4000))
+
+	// Changing the condition resets the hit count
+	assert.NoError(t, bm.AddConditionalBreakpoint(0x4000, "hitcount >= 2"))
+	assert.False(t, bm.ShouldBreak(0x4000))
+	assert.True(t, bm.ShouldBreak(0x4000))
+}
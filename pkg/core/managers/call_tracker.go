@@ -0,0 +1,179 @@
+package managers
+
+import (
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// callTracker detects the calls and returns of the processor when it fetches the opcode that
+// follows them, once the address where the execution continues and the stack pointer after
+// the instruction are known. Interrupts don't fetch an opcode, they are detected by their
+// address mode and their call is passed when the first opcode of the handler is fetched.
+type callTracker struct {
+	processor TraceProcessor
+	listener  core.CallListener
+
+	fetched             bool
+	instructionAddress  uint16
+	previousInstruction components.CpuInstructionData
+	inInterrupt         bool
+	interruptType       core.CallType
+	interruptReturn     uint16
+}
+
+// newCallTracker creates a new call tracker.
+//
+// Parameters:
+//   - processor: The processor whose calls are followed
+//   - listener: Receives the calls and returns of the processor
+//
+// Returns:
+//   - A pointer to the initialized call tracker
+func newCallTracker(processor TraceProcessor, listener core.CallListener) *callTracker {
+	return &callTracker{
+		processor: processor,
+		listener:  listener,
+	}
+}
+
+// NewCallTracker creates a new call tracker. Tracking starts on the next opcode fetch.
+//
+// Parameters:
+//   - processor: The processor whose calls are followed
+//   - listener: Receives the calls and returns of the processor
+//
+// Returns:
+//   - A pointer to the initialized CallTracker
+func NewCallTracker(processor TraceProcessor, listener core.CallListener) core.CallTracker {
+	return newCallTracker(processor, listener)
+}
+
+// TrackCycle follows the processor in this cycle. On an opcode fetch the call or return of
+// the previous instruction is passed to the listener and, if an interrupt was being served,
+// its call too. On the first cycle of an interrupt the interrupted instruction is completed.
+//
+// Returns:
+//   - true if the opcode of a new instruction was fetched
+func (t *callTracker) TrackCycle() bool {
+	if t.processor.IsReadingOpcode() {
+		return t.onOpcodeFetch()
+	}
+
+	if !t.fetched {
+		return false
+	}
+
+	switch t.processor.GetCurrentAddressMode().Name() {
+	case cpu.AddressModeIRQ:
+		t.onInterrupt(core.CallTypeIRQ)
+	case cpu.AddressModeNMI:
+		t.onInterrupt(core.CallTypeNMI)
+	case cpu.AddressModeReset:
+		t.Reset()
+		t.listener.OnReset()
+	}
+
+	return false
+}
+
+// GetInstructionAddress returns the address of the last opcode fetched.
+//
+// Returns:
+//   - The address of the instruction being executed
+func (t *callTracker) GetInstructionAddress() uint16 {
+	return t.instructionAddress
+}
+
+// Reset forgets the instruction being executed and any interrupt in progress.
+func (t *callTracker) Reset() {
+	t.fetched = false
+	t.inInterrupt = false
+	t.previousInstruction = nil
+}
+
+// onInterrupt is called on every cycle of the interrupt sequence. On the first one it
+// completes the interrupted instruction and records the address where the execution
+// must continue after the interrupt.
+func (t *callTracker) onInterrupt(interruptType core.CallType) {
+	if t.inInterrupt {
+		return
+	}
+
+	t.completeInstruction(t.processor.GetProgramCounter(), t.processor.GetStackPointer())
+
+	t.inInterrupt = true
+	t.interruptType = interruptType
+	t.interruptReturn = t.processor.GetProgramCounter()
+}
+
+// onOpcodeFetch completes the previous instruction and, if an interrupt was being served,
+// passes its call as the processor is now fetching the first opcode of the handler.
+func (t *callTracker) onOpcodeFetch() bool {
+	instruction := t.processor.GetCurrentInstruction()
+	if instruction == nil {
+		return false
+	}
+
+	address := t.processor.GetProgramCounter() - 1
+	sp := t.processor.GetStackPointer()
+
+	t.completeInstruction(address, sp)
+
+	if t.inInterrupt {
+		t.listener.OnCall(core.CallFrame{
+			Type:          t.interruptType,
+			CallerAddress: t.interruptReturn,
+			TargetAddress: address,
+			ReturnAddress: t.interruptReturn,
+			StackPointer:  sp,
+		})
+
+		t.inInterrupt = false
+	}
+
+	t.fetched = true
+	t.instructionAddress = address
+	t.previousInstruction = instruction
+
+	return true
+}
+
+// completeInstruction passes the call or return of the last instruction fetched, if it was one.
+//
+// Parameters:
+//   - address: The address where the execution continues after the instruction
+//   - sp: The stack pointer after the instruction
+func (t *callTracker) completeInstruction(address uint16, sp uint8) {
+	if t.previousInstruction == nil {
+		return
+	}
+
+	switch t.previousInstruction.Mnemonic() {
+	case cpu.JSR:
+		t.listener.OnCall(core.CallFrame{
+			Type:          core.CallTypeJSR,
+			CallerAddress: t.instructionAddress,
+			TargetAddress: address,
+			ReturnAddress: t.instructionAddress + 3,
+			StackPointer:  sp,
+		})
+	case cpu.BRK:
+		t.listener.OnCall(core.CallFrame{
+			Type:          core.CallTypeBRK,
+			CallerAddress: t.instructionAddress,
+			TargetAddress: address,
+			ReturnAddress: t.instructionAddress + 2,
+			StackPointer:  sp,
+		})
+	case cpu.RTS, cpu.RTI:
+		t.listener.OnReturn(core.CallReturn{
+			Interrupt:     t.previousInstruction.Mnemonic() == cpu.RTI,
+			Address:       t.instructionAddress,
+			TargetAddress: address,
+			StackPointer:  sp,
+		})
+	}
+
+	t.previousInstruction = nil
+}
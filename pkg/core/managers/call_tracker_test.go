@@ -0,0 +1,72 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCallListener struct {
+	calls   []core.CallFrame
+	returns []core.CallReturn
+	resets  int
+}
+
+func (l *testCallListener) OnCall(frame core.CallFrame)  { l.calls = append(l.calls, frame) }
+func (l *testCallListener) OnReturn(ret core.CallReturn) { l.returns = append(l.returns, ret) }
+func (l *testCallListener) OnReset()                     { l.resets++ }
+
+// track executes the cycles calling the tracker after each one, it returns the number of
+// opcodes fetched
+func (c *traceTestComputer) track(tracker core.CallTracker, cycles int) int {
+	context := common.NewStepContext()
+	fetches := 0
+
+	for range cycles {
+		c.processor.Tick(&context)
+		c.ram.Tick(&context)
+		c.processor.PostTick(&context)
+
+		if tracker.TrackCycle() {
+			fetches++
+		}
+
+		context.NextCycle()
+	}
+
+	return fetches
+}
+
+func TestCallTracker_FollowsCallsAndReturns(t *testing.T) {
+	computer := newProfileTestComputer()
+	listener := &testCallListener{}
+	tracker := NewCallTracker(computer.processor, listener)
+
+	// LDX + TXS + JSR + NOP + RTS, the return is passed when JMP is fetched
+	fetches := computer.track(tracker, 19)
+
+	assert.Equal(t, 6, fetches)
+	assert.Equal(t, uint16(0x0406), tracker.GetInstructionAddress())
+	assert.Equal(t, []core.CallFrame{
+		{Type: core.CallTypeJSR, CallerAddress: 0x0403, TargetAddress: 0x0410, ReturnAddress: 0x0406, StackPointer: 0xFD},
+	}, listener.calls)
+	assert.Equal(t, []core.CallReturn{
+		{Interrupt: false, Address: 0x0411, TargetAddress: 0x0406, StackPointer: 0xFF},
+	}, listener.returns)
+}
+
+func TestCallTracker_ResetForgetsTheInstruction(t *testing.T) {
+	computer := newProfileTestComputer()
+	listener := &testCallListener{}
+	tracker := NewCallTracker(computer.processor, listener)
+
+	// Stops after fetching the JSR, its call is not passed after the reset
+	computer.track(tracker, 5)
+	tracker.Reset()
+	computer.track(tracker, 6)
+
+	assert.Empty(t, listener.calls)
+	assert.Zero(t, listener.resets)
+}
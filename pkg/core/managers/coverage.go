@@ -0,0 +1,474 @@
+package managers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Maximum number of bytes shown in each line of data of the coverage listing
+const coverageBytesPerLine int = 8
+
+// First address of the interrupt vectors, the only addresses read as data during the
+// interrupt sequences
+const interruptVectorsAddress uint16 = 0xFFFA
+
+// coverageRecorder records the accesses done by the processor watching its pins, as a logic
+// analyzer connected to it would do. Opcode fetches are detected with the SYNC pin, which must
+// be connected to a line. Reads of the bytes of the instruction being executed are operands,
+// other reads and writes are data accesses.
+type coverageRecorder struct {
+	processor components.Cpu65C02
+	peek      func(address uint16) uint8
+	symbols   core.SymbolTable
+	regions   []core.CoverageRegion
+
+	mutex   sync.Mutex  // Guards the arrays below, reported from the UI and filled every cycle
+	enabled atomic.Bool // Accesses are being recorded
+
+	flags        [0x10000]core.CoverageFlags
+	executions   [0x10000]uint64
+	instructions [0x10000]components.CpuInstructionData
+
+	fetched            bool
+	instructionAddress uint16
+	instructionSize    uint16
+	instructionMode    components.AddressMode
+}
+
+// newCoverageRecorder creates a new coverage recorder.
+//
+// Parameters:
+//   - processor: The processor whose accesses are recorded, its SYNC pin must be connected
+//   - peek: Function that returns the value of a memory address without side effects
+//   - symbols: The symbol table used to show labels in the listing, nil to show only addresses
+//   - regions: The memory regions of the computer included in the listing
+//
+// Returns:
+//   - A pointer to the initialized coverageRecorder
+func newCoverageRecorder(processor components.Cpu65C02, peek func(address uint16) uint8, symbols core.SymbolTable, regions []core.CoverageRegion) *coverageRecorder {
+	return &coverageRecorder{
+		processor: processor,
+		peek:      peek,
+		symbols:   symbols,
+		regions:   regions,
+	}
+}
+
+// NewCoverageRecorder creates a new coverage recorder. It doesn't record anything until
+// it's enabled.
+//
+// Parameters:
+//   - processor: The processor whose accesses are recorded, its SYNC pin must be connected
+//   - peek: Function that returns the value of a memory address without side effects
+//   - symbols: The symbol table used to show labels in the listing, nil to show only addresses
+//   - regions: The memory regions of the computer included in the listing
+//
+// Returns:
+//   - A pointer to the initialized CoverageRecorder
+func NewCoverageRecorder(processor components.Cpu65C02, peek func(address uint16) uint8, symbols core.SymbolTable, regions []core.CoverageRegion) core.CoverageRecorder {
+	return newCoverageRecorder(processor, peek, symbols, regions)
+}
+
+/************************************************************************************
+* Recording
+*************************************************************************************/
+
+// SetEnabled starts or stops recording. As the instruction in progress when recording starts
+// is not known, operands are recorded from the next opcode fetch.
+//
+// Parameters:
+//   - enabled: True to record the accesses of the processor
+func (c *coverageRecorder) SetEnabled(enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if enabled && !c.enabled.Load() {
+		c.fetched = false
+	}
+
+	c.enabled.Store(enabled)
+}
+
+// IsEnabled returns true if the accesses of the processor are being recorded.
+//
+// Returns:
+//   - true if the recorder is running
+func (c *coverageRecorder) IsEnabled() bool {
+	return c.enabled.Load()
+}
+
+// RecordCycle records the access done by the processor in the cycle. The dummy reads of the
+// byte following the instruction, done by single byte instructions, the dummy reads of
+// taken branches and the reads of the interrupt sequences other than the vectors are not
+// recorded.
+func (c *coverageRecorder) RecordCycle() {
+	if !c.enabled.Load() {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	processor := c.processor
+	address := processor.AddressBus().Read()
+
+	if processor.Sync().Enabled() {
+		c.recordFetch(address)
+		return
+	}
+
+	if processor.ReadWrite().Enabled() {
+		c.flags[address] |= core.CoverageWritten
+		return
+	}
+
+	switch processor.GetCurrentAddressMode().Name() {
+	case cpu.AddressModeIRQ, cpu.AddressModeNMI, cpu.AddressModeReset:
+		if address >= interruptVectorsAddress {
+			c.flags[address] |= core.CoverageRead
+		}
+
+		return
+	}
+
+	if c.fetched {
+		offset := address - c.instructionAddress
+
+		switch {
+		case offset > 0 && offset < c.instructionSize:
+			c.flags[address] |= core.CoverageOperand
+			return
+		case offset == c.instructionSize || c.instructionMode == cpu.AddressModeRelative:
+			// Dummy reads of the byte following the instruction or of the branch target
+			return
+		}
+	}
+
+	c.flags[address] |= core.CoverageRead
+}
+
+// recordFetch records the opcode fetched from the address and starts the instruction.
+func (c *coverageRecorder) recordFetch(address uint16) {
+	instruction := c.processor.GetCurrentInstruction()
+	if instruction == nil {
+		return
+	}
+
+	c.flags[address] |= core.CoverageExecuted
+	c.executions[address]++
+	c.instructions[address] = instruction
+
+	c.fetched = true
+	c.instructionAddress = address
+	c.instructionSize = uint16(cpu.GetAddressMode(instruction.AddressMode()).MemSize())
+	c.instructionMode = instruction.AddressMode()
+}
+
+// Clear discards the collected coverage.
+func (c *coverageRecorder) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.flags = [0x10000]core.CoverageFlags{}
+	c.executions = [0x10000]uint64{}
+	c.instructions = [0x10000]components.CpuInstructionData{}
+}
+
+// GetCoverage returns the accesses done to the address.
+//
+// Parameters:
+//   - address: The address to check
+//
+// Returns:
+//   - The flags of the accesses recorded
+func (c *coverageRecorder) GetCoverage(address uint16) core.CoverageFlags {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.flags[address]
+}
+
+// GetExecutions returns the number of times an opcode was fetched from the address.
+//
+// Parameters:
+//   - address: The address to check
+//
+// Returns:
+//   - The number of opcode fetches recorded
+func (c *coverageRecorder) GetExecutions(address uint16) uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.executions[address]
+}
+
+/************************************************************************************
+* Listing
+*************************************************************************************/
+
+// WriteListing writes each memory region with a summary of its coverage followed by one
+// line per instruction executed, lines of up to 8 bytes with the same accesses for the
+// data and one line per range of bytes never touched. Each line has the accesses done to
+// its first byte: E executed, O operand, R read and W written.
+//
+// Parameters:
+//   - writer: Where the listing is written
+//
+// Returns:
+//   - An error if the listing can't be written
+func (c *coverageRecorder) WriteListing(writer io.Writer) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	out := bufio.NewWriter(writer)
+
+	fmt.Fprintln(out, "; Coverage listing")
+	fmt.Fprintln(out, "; E: executed, O: operand, R: read as data, W: written as data")
+
+	for _, region := range c.regions {
+		c.writeRegionSummary(out, region)
+	}
+
+	for _, region := range c.regions {
+		fmt.Fprintf(out, "\n; %s\n\n", region.Name)
+		c.writeRegion(out, region)
+	}
+
+	return out.Flush()
+}
+
+// writeRegionSummary writes the number of bytes of the region with each access.
+func (c *coverageRecorder) writeRegionSummary(out *bufio.Writer, region core.CoverageRegion) {
+	var executed, operand, read, written, touched int
+
+	for address := int(region.Start); address <= int(region.End); address++ {
+		flags := c.flags[address]
+
+		if flags != 0 {
+			touched++
+		}
+		if flags&core.CoverageExecuted != 0 {
+			executed++
+		}
+		if flags&core.CoverageOperand != 0 {
+			operand++
+		}
+		if flags&core.CoverageRead != 0 {
+			read++
+		}
+		if flags&core.CoverageWritten != 0 {
+			written++
+		}
+	}
+
+	size := int(region.End) - int(region.Start) + 1
+
+	fmt.Fprintf(out, ";\n; %s $%04X-$%04X: %d of %d bytes touched (%.2f%%)\n",
+		region.Name, region.Start, region.End, touched, size, float64(touched)*100/float64(size))
+	fmt.Fprintf(out, ";   opcodes executed: %d, operands: %d, read: %d, written: %d, never touched: %d\n",
+		executed, operand, read, written, size-touched)
+}
+
+// writeRegion writes the lines of the listing of the region.
+func (c *coverageRecorder) writeRegion(out *bufio.Writer, region core.CoverageRegion) {
+	address := int(region.Start)
+
+	for address <= int(region.End) {
+		flags := c.flags[address]
+
+		switch {
+		case flags&core.CoverageExecuted != 0 && c.instructions[address] != nil:
+			address += c.writeInstruction(out, uint16(address), int(region.End))
+		case flags == 0:
+			address += c.writeUntouched(out, uint16(address), int(region.End))
+		default:
+			address += c.writeData(out, uint16(address), int(region.End))
+		}
+	}
+}
+
+// writeInstruction writes the line of the instruction executed at the address, returning
+// the number of bytes listed.
+func (c *coverageRecorder) writeInstruction(out *bufio.Writer, address uint16, end int) int {
+	instruction := c.instructions[address]
+
+	line := traceLine{
+		address:     address,
+		size:        uint16(cpu.GetAddressMode(instruction.AddressMode()).MemSize()),
+		instruction: instruction,
+	}
+
+	// Instructions that don't fit in the region are cut, the rest is listed in the next one
+	if int(address)+int(line.size)-1 > end {
+		line.size = uint16(end - int(address) + 1)
+	}
+
+	bytes := make([]string, 0, len(line.bytes))
+	for i := range line.size {
+		line.bytes[i] = c.peek(address + i)
+		bytes = append(bytes, fmt.Sprintf("%02X", line.bytes[i]))
+	}
+
+	c.writeLine(out, address, strings.Join(bytes, " "), disassembleTraceLine(&line))
+
+	return int(line.size)
+}
+
+// writeData writes a line with the bytes starting at the address that have the same
+// accesses and were not executed, returning the number of bytes listed.
+func (c *coverageRecorder) writeData(out *bufio.Writer, address uint16, end int) int {
+	flags := c.flags[address]
+
+	values := make([]string, 0, coverageBytesPerLine)
+	for i := 0; i < coverageBytesPerLine && int(address)+i <= end; i++ {
+		current := address + uint16(i)
+		if i > 0 && c.flags[current] != flags {
+			break
+		}
+
+		values = append(values, fmt.Sprintf("$%02X", c.peek(current)))
+	}
+
+	c.writeLine(out, address, "", ".byte "+strings.Join(values, ", "))
+
+	return len(values)
+}
+
+// writeUntouched writes a line with the range of bytes never touched starting at the address,
+// returning the number of bytes in the range.
+func (c *coverageRecorder) writeUntouched(out *bufio.Writer, address uint16, end int) int {
+	last := int(address)
+	for last < end && c.flags[last+1] == 0 {
+		last++
+	}
+
+	count := last - int(address) + 1
+
+	fmt.Fprintf(out, "%04X  ----            ; never touched $%04X-$%04X (%d bytes)\n", address, address, last, count)
+
+	return count
+}
+
+// writeLine writes a line of the listing with the accesses of the address and its label.
+func (c *coverageRecorder) writeLine(out *bufio.Writer, address uint16, bytes string, text string) {
+	label := ""
+	if c.symbols != nil {
+		if value, ok := c.symbols.GetLabel(address); ok {
+			label = "; " + value
+		}
+	}
+
+	line := fmt.Sprintf("%04X  %s  %-8s  %-31s %s", address, formatCoverageFlags(c.flags[address]), bytes, text, label)
+	fmt.Fprintln(out, strings.TrimRight(line, " "))
+}
+
+// formatCoverageFlags returns one letter for each access, or a dash if it was not done.
+func formatCoverageFlags(flags core.CoverageFlags) string {
+	letters := []byte("EORW")
+
+	for i := range letters {
+		if flags&(1<<i) == 0 {
+			letters[i] = '-'
+		}
+	}
+
+	return string(letters)
+}
+
+/************************************************************************************
+* lcov
+*************************************************************************************/
+
+// lineCoverage has the accesses done to the bytes generated by a source line.
+type lineCoverage struct {
+	executions uint64
+	code       bool
+	data       bool
+}
+
+// WriteLcov writes the line coverage of the source files in lcov format. The hit count of a
+// line is the number of times its instructions were executed. Lines whose bytes were only
+// read or written as data are left out as they are not code, the rest of the lines that
+// generated bytes are included.
+//
+// Parameters:
+//   - writer: Where the coverage is written
+//   - sourceMap: The source line information of the program
+//
+// Returns:
+//   - An error if the coverage can't be written
+func (c *coverageRecorder) WriteLcov(writer io.Writer, sourceMap core.SourceMap) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	files := make(map[string]map[int]*lineCoverage)
+
+	for address := range c.flags {
+		location, ok := sourceMap.GetSourceLocation(uint16(address))
+		if !ok {
+			continue
+		}
+
+		lines, ok := files[location.File]
+		if !ok {
+			lines = make(map[int]*lineCoverage)
+			files[location.File] = lines
+		}
+
+		line, ok := lines[location.Line]
+		if !ok {
+			line = &lineCoverage{}
+			lines[location.Line] = line
+		}
+
+		flags := c.flags[address]
+		line.executions += c.executions[address]
+		line.code = line.code || flags&(core.CoverageExecuted|core.CoverageOperand) != 0
+		line.data = line.data || flags&(core.CoverageRead|core.CoverageWritten) != 0
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := bufio.NewWriter(writer)
+
+	for _, name := range names {
+		lines := files[name]
+
+		numbers := make([]int, 0, len(lines))
+		for number, line := range lines {
+			if line.code || !line.data {
+				numbers = append(numbers, number)
+			}
+		}
+		sort.Ints(numbers)
+
+		fmt.Fprintf(out, "TN:\nSF:%s\n", name)
+
+		hit := 0
+		for _, number := range numbers {
+			executions := lines[number].executions
+			if executions > 0 {
+				hit++
+			}
+
+			fmt.Fprintf(out, "DA:%d,%d\n", number, executions)
+		}
+
+		fmt.Fprintf(out, "LF:%d\nLH:%d\nend_of_record\n", len(numbers), hit)
+	}
+
+	return out.Flush()
+}
@@ -0,0 +1,152 @@
+package managers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// Regions of the trace test computer listed in the coverage tests
+var coverageTestRegions []core.CoverageRegion = []core.CoverageRegion{
+	{Name: "Program", Start: 0x0400, End: 0x041F},
+}
+
+// cover executes the cycles recording the accesses after each one
+func (c *traceTestComputer) cover(recorder core.CoverageRecorder, cycles int) {
+	context := common.NewStepContext()
+
+	for range cycles {
+		c.processor.Tick(&context)
+		c.ram.Tick(&context)
+		c.processor.PostTick(&context)
+
+		recorder.RecordCycle()
+
+		context.NextCycle()
+	}
+}
+
+// testSourceMap maps each address of the trace test program to a line
+type testSourceMap map[uint16]int
+
+func (m testSourceMap) GetSourceLocation(address uint16) (core.SourceLocation, bool) {
+	line, ok := m[address]
+	return core.SourceLocation{File: "test.s", Line: line}, ok
+}
+
+func (m testSourceMap) GetSourceLines(file string) ([]string, error) {
+	return nil, nil
+}
+
+func TestCoverageRecorder_RecordsAccesses(t *testing.T) {
+	computer := newTraceTestComputer()
+	recorder := NewCoverageRecorder(computer.processor, computer.peek, nil, coverageTestRegions)
+	recorder.SetEnabled(true)
+
+	// LDX (2) + LDA (4) + STA (4) + ASL (2) + BNE taken (3) and the fetch of LDX again
+	computer.cover(recorder, 16)
+
+	assert.Equal(t, uint64(2), recorder.GetExecutions(0x0400))
+	assert.Equal(t, core.CoverageOperand, recorder.GetCoverage(0x0401))
+	assert.Equal(t, core.CoverageRead, recorder.GetCoverage(0x0010))
+	assert.Equal(t, core.CoverageRead, recorder.GetCoverage(0x0012))
+	assert.Equal(t, core.CoverageWritten, recorder.GetCoverage(0x0200))
+	assert.Equal(t, core.CoverageOperand, recorder.GetCoverage(0x0406))
+	assert.Equal(t, uint64(1), recorder.GetExecutions(0x0407))
+
+	// Dummy reads of the byte following ASL and of the taken branch are not recorded
+	assert.Equal(t, core.CoverageExecuted, recorder.GetCoverage(0x0408))
+	assert.Equal(t, core.CoverageFlags(0), recorder.GetCoverage(0x040A))
+	assert.Equal(t, core.CoverageExecuted, recorder.GetCoverage(0x0400))
+
+	recorder.SetEnabled(false)
+	computer.cover(recorder, 15)
+	assert.Equal(t, uint64(2), recorder.GetExecutions(0x0400))
+
+	recorder.Clear()
+	assert.Equal(t, core.CoverageFlags(0), recorder.GetCoverage(0x0400))
+	assert.Equal(t, uint64(0), recorder.GetExecutions(0x0400))
+}
+
+func TestCoverageRecorder_WritesListing(t *testing.T) {
+	computer := newTraceTestComputer()
+
+	symbols := NewSymbolTable()
+	symbols.AddSymbol("loop", 0x0400)
+
+	recorder := NewCoverageRecorder(computer.processor, computer.peek, symbols, coverageTestRegions)
+	recorder.SetEnabled(true)
+	computer.cover(recorder, 16)
+
+	var listing bytes.Buffer
+	assert.NoError(t, recorder.WriteListing(&listing))
+
+	text := listing.String()
+	assert.Contains(t, text, "; Program $0400-$041F: 10 of 32 bytes touched (31.25%)\n")
+	assert.Contains(t, text, ";   opcodes executed: 5, operands: 5, read: 0, written: 0, never touched: 22\n")
+	assert.Contains(t, text, "0400  E---  A2 02     LDX #$02                        ; loop\n")
+	assert.Contains(t, text, "0404  E---  8D 00 02  STA $0200\n")
+	assert.Contains(t, text, "0408  E---  D0 F6     BNE $0400\n")
+	assert.Contains(t, text, "040A  ----            ; never touched $040A-$041F (22 bytes)\n")
+}
+
+func TestCoverageRecorder_ListsData(t *testing.T) {
+	computer := newTraceTestComputer()
+
+	// LDX #$02, LDA $0410, X and NOP
+	for i, value := range []uint8{0xA2, 0x02, 0xBD, 0x10, 0x04, 0xEA} {
+		computer.ram.Poke(0x0400+uint16(i), value)
+	}
+	computer.ram.Poke(0x0412, 0x42)
+
+	recorder := NewCoverageRecorder(computer.processor, computer.peek, nil, coverageTestRegions)
+	recorder.SetEnabled(true)
+	computer.cover(recorder, 7)
+
+	var listing bytes.Buffer
+
+	assert.NoError(t, recorder.WriteListing(&listing))
+	assert.Contains(t, listing.String(), "0402  E---  BD 10 04  LDA $0410, X\n")
+	assert.Contains(t, listing.String(), "0405  E---  EA        NOP\n")
+	assert.Contains(t, listing.String(), "0412  --R-            .byte $42\n")
+}
+
+func TestCoverageRecorder_WritesLcov(t *testing.T) {
+	computer := newTraceTestComputer()
+
+	recorder := NewCoverageRecorder(computer.processor, computer.peek, nil, coverageTestRegions)
+	recorder.SetEnabled(true)
+	computer.cover(recorder, 16)
+
+	// One line per instruction, a line after the program never executed and a data line
+	sourceMap := testSourceMap{
+		0x0400: 1, 0x0401: 1,
+		0x0402: 2, 0x0403: 2,
+		0x0404: 3, 0x0405: 3, 0x0406: 3,
+		0x0407: 4,
+		0x0408: 5, 0x0409: 5,
+		0x040A: 6,
+		0x0012: 10,
+	}
+
+	var lcov bytes.Buffer
+	assert.NoError(t, recorder.WriteLcov(&lcov, sourceMap))
+
+	assert.Equal(t, []string{
+		"TN:",
+		"SF:test.s",
+		"DA:1,2",
+		"DA:2,1",
+		"DA:3,1",
+		"DA:4,1",
+		"DA:5,1",
+		"DA:6,0",
+		"LF:6",
+		"LH:5",
+		"end_of_record",
+	}, strings.Split(strings.TrimSuffix(lcov.String(), "\n"), "\n"))
+}
@@ -0,0 +1,173 @@
+package managers
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Default time between polls of the watched files
+const DefaultWatchInterval time.Duration = 250 * time.Millisecond
+
+// fileStamp identifies the contents of a file by its size and modification time.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// equal returns true if both stamps identify the same contents.
+func (stamp fileStamp) equal(other fileStamp) bool {
+	return stamp.exists == other.exists && stamp.size == other.size && stamp.modTime.Equal(other.modTime)
+}
+
+// watchedFiles holds the stamps of a set of files to detect when they change.
+type watchedFiles struct {
+	paths    []string
+	reported []fileStamp // Stamps of the contents last reported
+	previous []fileStamp // Stamps of the previous poll
+}
+
+// newWatchedFiles takes the current stamps of the files as the reported contents.
+func newWatchedFiles(paths []string) (*watchedFiles, error) {
+	files := &watchedFiles{paths: slices.Clone(paths)}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files.reported = append(files.reported, fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()})
+	}
+
+	files.previous = slices.Clone(files.reported)
+
+	return files, nil
+}
+
+// poll reads the stamps of the files and returns true when they differ from the ones last
+// reported. The change is only reported once the stamps are the same in two consecutive polls
+// and all the files exist, so files being rebuilt are not read while they are written.
+func (files *watchedFiles) poll() bool {
+	current := make([]fileStamp, len(files.paths))
+
+	for i, path := range files.paths {
+		if info, err := os.Stat(path); err == nil {
+			current[i] = fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+
+	stable := slices.EqualFunc(current, files.previous, fileStamp.equal)
+	files.previous = current
+
+	if !stable || slices.EqualFunc(current, files.reported, fileStamp.equal) {
+		return false
+	}
+
+	for _, stamp := range current {
+		if !stamp.exists {
+			return false
+		}
+	}
+
+	files.reported = current
+
+	return true
+}
+
+// fileWatcher polls the watched files from a goroutine.
+type fileWatcher struct {
+	interval time.Duration
+
+	mutex sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// newFileWatcher creates a new file watcher.
+//
+// Parameters:
+//   - interval: Time between polls of the files
+//
+// Returns:
+//   - A pointer to the initialized fileWatcher
+func newFileWatcher(interval time.Duration) *fileWatcher {
+	return &fileWatcher{interval: interval}
+}
+
+// NewFileWatcher creates a new file watcher. It doesn't poll any file until Watch is called.
+//
+// Parameters:
+//   - interval: Time between polls of the files
+//
+// Returns:
+//   - A pointer to the initialized FileWatcher
+func NewFileWatcher(interval time.Duration) core.FileWatcher {
+	return newFileWatcher(interval)
+}
+
+// Watch starts polling the files, calling onChange from the polling goroutine once the
+// modified files stop changing. If files were being watched, they are replaced.
+// Returns an error if any of the files doesn't exist.
+func (watcher *fileWatcher) Watch(paths []string, onChange func()) error {
+	files, err := newWatchedFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	watcher.stopPolling()
+
+	watcher.stop = make(chan struct{})
+	watcher.done = make(chan struct{})
+
+	go watcher.run(files, onChange, watcher.stop, watcher.done)
+
+	return nil
+}
+
+// Stop stops polling the files. If they are not watched, this method has no effect.
+func (watcher *fileWatcher) Stop() {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+
+	watcher.stopPolling()
+}
+
+// stopPolling stops the polling goroutine and waits for it to finish.
+func (watcher *fileWatcher) stopPolling() {
+	if watcher.stop == nil {
+		return
+	}
+
+	close(watcher.stop)
+	<-watcher.done
+
+	watcher.stop = nil
+	watcher.done = nil
+}
+
+// run polls the files until the stop channel is closed.
+func (watcher *fileWatcher) run(files *watchedFiles, onChange func(), stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if files.poll() {
+				onChange()
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Writes the file with the modification time set to the specified second
+func writeWatchedFile(t *testing.T, path string, data []byte, second int) {
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	modTime := time.Unix(int64(1_000_000+second), 0)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestWatchedFilesReportStableChanges(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "rom.bin")
+	program := filepath.Join(dir, "program.prg")
+
+	writeWatchedFile(t, rom, []byte{0xEA}, 0)
+	writeWatchedFile(t, program, []byte{0x00, 0x02, 0x60}, 0)
+
+	files, err := newWatchedFiles([]string{rom, program})
+	require.NoError(t, err)
+
+	assert.False(t, files.poll())
+
+	// Reported when the stamps don't change between two polls
+	writeWatchedFile(t, rom, []byte{0xEA, 0xEA}, 1)
+	assert.False(t, files.poll())
+	assert.True(t, files.poll())
+	assert.False(t, files.poll())
+
+	// Not reported while a file is missing
+	require.NoError(t, os.Remove(program))
+	assert.False(t, files.poll())
+	assert.False(t, files.poll())
+
+	writeWatchedFile(t, program, []byte{0x00, 0x02, 0x60}, 0)
+	assert.False(t, files.poll())
+
+	// Back to the contents last reported
+	assert.False(t, files.poll())
+
+	writeWatchedFile(t, program, []byte{0x00, 0x02, 0xEA}, 2)
+	assert.False(t, files.poll())
+	assert.True(t, files.poll())
+}
+
+func TestFileWatcherCallsOnChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rom.bin")
+	writeWatchedFile(t, path, []byte{0xEA}, 0)
+
+	watcher := newFileWatcher(time.Millisecond)
+	assert.Error(t, watcher.Watch([]string{filepath.Join(t.TempDir(), "missing.bin")}, func() {}))
+
+	changes := make(chan struct{}, 1)
+	require.NoError(t, watcher.Watch([]string{path}, func() {
+		changes <- struct{}{}
+	}))
+
+	writeWatchedFile(t, path, []byte{0xEA, 0xEA}, 1)
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the change was not reported")
+	}
+
+	watcher.Stop()
+	watcher.Stop()
+}
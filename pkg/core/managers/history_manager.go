@@ -0,0 +1,244 @@
+package managers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Default number of cycles between snapshots of the computer
+const DefaultSnapshotInterval uint64 = 100_000
+
+// Default number of snapshots kept in the history
+const DefaultSnapshotCount int = 10
+
+// snapshot holds the saved state of the computer before executing the cycle.
+type snapshot struct {
+	cycle uint64
+	data  bytes.Buffer
+}
+
+// historyManager keeps the recent execution history of a computer. Snapshots are taken every
+// fixed number of cycles and the bus access of every cycle is recorded in a ring buffer
+// large enough to cover the cycles since the oldest snapshot.
+type historyManager struct {
+	computer core.Snapshotable
+	interval uint64
+	count    int
+
+	// Snapshots ordered from oldest to newest and buffers of discarded snapshots for reuse
+	snapshots []*snapshot
+	free      []*snapshot
+
+	// The journal contains the cycles from first (included) to next (excluded)
+	journal []core.BusCycle
+	first   uint64
+	next    uint64
+}
+
+// newHistoryManager creates a new history manager.
+//
+// Parameters:
+//   - computer: The computer whose state is saved in the snapshots
+//   - interval: Number of cycles between snapshots
+//   - count: Maximum number of snapshots kept
+//
+// Returns:
+//   - A pointer to the initialized historyManager
+func newHistoryManager(computer core.Snapshotable, interval uint64, count int) *historyManager {
+	interval = max(interval, 1)
+	count = max(count, 1)
+
+	return &historyManager{
+		computer:  computer,
+		interval:  interval,
+		count:     count,
+		snapshots: make([]*snapshot, 0, count),
+		free:      make([]*snapshot, 0, count),
+		journal:   make([]core.BusCycle, interval*uint64(count)),
+	}
+}
+
+// NewHistoryManager creates a new history manager. The history covers approximately
+// interval * count cycles, using memory for count snapshots of the computer and a bus
+// access entry per cycle.
+//
+// Parameters:
+//   - computer: The computer whose state is saved in the snapshots
+//   - interval: Number of cycles between snapshots, it is also the maximum number of
+//     cycles that must be executed again to complete a rewind
+//   - count: Maximum number of snapshots kept
+//
+// Returns:
+//   - A pointer to the initialized HistoryManager
+func NewHistoryManager(computer core.Snapshotable, interval uint64, count int) core.HistoryManager {
+	return newHistoryManager(computer, interval, count)
+}
+
+// SaveSnapshot saves the state of the computer if the cycle is a multiple of the snapshot
+// interval. When the snapshot limit is reached the oldest snapshot is discarded.
+//
+// Parameters:
+//   - cycle: The cycle about to be executed
+//
+// Returns:
+//   - An error if the state of the computer can't be saved
+func (hm *historyManager) SaveSnapshot(cycle uint64) error {
+	if cycle%hm.interval != 0 {
+		return nil
+	}
+
+	if cycle != hm.next {
+		hm.restart(cycle)
+	}
+
+	// The snapshot might already exist if the emulation was rewound to this cycle
+	if last := len(hm.snapshots) - 1; last >= 0 && hm.snapshots[last].cycle == cycle {
+		return nil
+	}
+
+	snap := hm.getFreeSnapshot()
+	snap.cycle = cycle
+	snap.data.Reset()
+
+	if err := hm.computer.SaveState(&snap.data); err != nil {
+		hm.free = append(hm.free, snap)
+		return err
+	}
+
+	hm.snapshots = append(hm.snapshots, snap)
+
+	return nil
+}
+
+// RecordCycle adds the bus access of the executed cycle to the journal. If the cycle
+// is not the one following the last recorded, the history after it is discarded or,
+// if there is a gap, the history is restarted from the cycle.
+//
+// Parameters:
+//   - cycle: The cycle executed
+//   - access: The bus access done by the processor in the cycle
+func (hm *historyManager) RecordCycle(cycle uint64, access core.BusCycle) {
+	if cycle != hm.next {
+		if cycle >= hm.first && cycle < hm.next {
+			hm.truncate(cycle)
+		} else {
+			hm.restart(cycle)
+		}
+	}
+
+	size := uint64(len(hm.journal))
+
+	hm.journal[cycle%size] = access
+	hm.next = cycle + 1
+
+	if hm.next-hm.first > size {
+		hm.first = hm.next - size
+	}
+}
+
+// GetCycle returns the bus access recorded for the cycle.
+//
+// Parameters:
+//   - cycle: The cycle to look up
+//
+// Returns:
+//   - The bus access recorded for the cycle
+//   - true if the cycle is in the journal, false otherwise
+func (hm *historyManager) GetCycle(cycle uint64) (core.BusCycle, bool) {
+	if cycle < hm.first || cycle >= hm.next {
+		return core.BusCycle{}, false
+	}
+
+	return hm.journal[cycle%uint64(len(hm.journal))], true
+}
+
+// GetOldestCycle returns the cycle of the oldest snapshot that still has all the following
+// cycles in the journal.
+//
+// Returns:
+//   - The oldest cycle that can be restored
+//   - true if there is any cycle that can be restored, false otherwise
+func (hm *historyManager) GetOldestCycle() (uint64, bool) {
+	for _, snap := range hm.snapshots {
+		if snap.cycle >= hm.first {
+			return snap.cycle, true
+		}
+	}
+
+	return 0, false
+}
+
+// Restore loads the latest snapshot taken on or before the cycle and discards the
+// history from the cycle onwards.
+//
+// Parameters:
+//   - cycle: The cycle to rewind to
+//
+// Returns:
+//   - The cycle of the snapshot loaded
+//   - An error if the cycle is no longer in the history or the snapshot can't be loaded
+func (hm *historyManager) Restore(cycle uint64) (uint64, error) {
+	oldest, ok := hm.GetOldestCycle()
+	if !ok || cycle < oldest || cycle > hm.next {
+		return 0, fmt.Errorf("cycle %d is not in the history", cycle)
+	}
+
+	var snap *snapshot
+	for _, s := range hm.snapshots {
+		if s.cycle <= cycle {
+			snap = s
+		}
+	}
+
+	if err := hm.computer.LoadState(bytes.NewReader(snap.data.Bytes())); err != nil {
+		return 0, fmt.Errorf("error restoring the snapshot of cycle %d: %w", snap.cycle, err)
+	}
+
+	hm.truncate(cycle)
+
+	return snap.cycle, nil
+}
+
+// Clear discards all the recorded history.
+func (hm *historyManager) Clear() {
+	hm.restart(0)
+}
+
+// getFreeSnapshot returns a snapshot that can be used to save the state, discarding
+// the oldest one if the limit was reached.
+func (hm *historyManager) getFreeSnapshot() *snapshot {
+	if len(hm.snapshots) >= hm.count {
+		snap := hm.snapshots[0]
+		hm.snapshots = append(hm.snapshots[:0], hm.snapshots[1:]...)
+		return snap
+	}
+
+	if last := len(hm.free) - 1; last >= 0 {
+		snap := hm.free[last]
+		hm.free = hm.free[:last]
+		return snap
+	}
+
+	return &snapshot{}
+}
+
+// truncate discards the snapshots taken after the cycle and the journal from the cycle onwards.
+func (hm *historyManager) truncate(cycle uint64) {
+	for last := len(hm.snapshots) - 1; last >= 0 && hm.snapshots[last].cycle > cycle; last-- {
+		hm.free = append(hm.free, hm.snapshots[last])
+		hm.snapshots = hm.snapshots[:last]
+	}
+
+	hm.next = cycle
+}
+
+// restart discards all the history and starts recording again from the cycle.
+func (hm *historyManager) restart(cycle uint64) {
+	hm.free = append(hm.free, hm.snapshots...)
+	hm.snapshots = hm.snapshots[:0]
+
+	hm.first = cycle
+	hm.next = cycle
+}
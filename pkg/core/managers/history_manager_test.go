@@ -0,0 +1,141 @@
+package managers
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSnapshotable struct {
+	value uint8
+	fail  bool
+}
+
+func (s *testSnapshotable) SaveState(writer io.Writer) error {
+	if s.fail {
+		return errors.New("can't save")
+	}
+
+	_, err := writer.Write([]byte{s.value})
+	return err
+}
+
+func (s *testSnapshotable) LoadState(reader io.Reader) error {
+	value := make([]byte, 1)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return err
+	}
+
+	s.value = value[0]
+	return nil
+}
+
+// recordCycles simulates the execution of the cycles, the value of the computer is the
+// number of the cycle about to be executed
+func recordCycles(t *testing.T, hm core.HistoryManager, computer *testSnapshotable, from uint64, to uint64) {
+	for cycle := from; cycle < to; cycle++ {
+		computer.value = uint8(cycle)
+		assert.NoError(t, hm.SaveSnapshot(cycle))
+		hm.RecordCycle(cycle, core.BusCycle{Address: uint16(cycle)})
+	}
+}
+
+func TestHistoryManager_KeepsLatestCycles(t *testing.T) {
+	computer := &testSnapshotable{}
+	hm := NewHistoryManager(computer, 4, 3)
+
+	_, ok := hm.GetOldestCycle()
+	assert.False(t, ok)
+
+	// Snapshots at 8, 12 and 16 are kept and the journal covers cycles 8 to 19
+	recordCycles(t, hm, computer, 0, 20)
+
+	oldest, ok := hm.GetOldestCycle()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(8), oldest)
+
+	_, ok = hm.GetCycle(7)
+	assert.False(t, ok)
+
+	access, ok := hm.GetCycle(8)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(8), access.Address)
+
+	access, ok = hm.GetCycle(19)
+	assert.True(t, ok)
+	assert.Equal(t, uint16(19), access.Address)
+
+	_, ok = hm.GetCycle(20)
+	assert.False(t, ok)
+}
+
+func TestHistoryManager_Restore(t *testing.T) {
+	computer := &testSnapshotable{}
+	hm := NewHistoryManager(computer, 4, 3)
+	recordCycles(t, hm, computer, 0, 20)
+
+	_, err := hm.Restore(3)
+	assert.Error(t, err)
+
+	cycle, err := hm.Restore(14)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), cycle)
+	assert.Equal(t, uint8(12), computer.value)
+
+	// History from the restored cycle is discarded
+	_, ok := hm.GetCycle(14)
+	assert.False(t, ok)
+
+	_, ok = hm.GetCycle(13)
+	assert.True(t, ok)
+
+	_, err = hm.Restore(17)
+	assert.Error(t, err)
+
+	// Recording continues from the restored cycle
+	recordCycles(t, hm, computer, 14, 18)
+
+	cycle, err = hm.Restore(17)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(16), cycle)
+	assert.Equal(t, uint8(16), computer.value)
+}
+
+func TestHistoryManager_RestartsOnGap(t *testing.T) {
+	computer := &testSnapshotable{}
+	hm := NewHistoryManager(computer, 4, 3)
+	recordCycles(t, hm, computer, 0, 10)
+
+	hm.RecordCycle(50, core.BusCycle{})
+
+	_, ok := hm.GetOldestCycle()
+	assert.False(t, ok)
+
+	_, ok = hm.GetCycle(9)
+	assert.False(t, ok)
+
+	recordCycles(t, hm, computer, 51, 53)
+
+	oldest, ok := hm.GetOldestCycle()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(52), oldest)
+
+	hm.Clear()
+
+	_, ok = hm.GetOldestCycle()
+	assert.False(t, ok)
+}
+
+func TestHistoryManager_SaveError(t *testing.T) {
+	computer := &testSnapshotable{fail: true}
+	hm := NewHistoryManager(computer, 4, 3)
+
+	assert.NoError(t, hm.SaveSnapshot(1))
+	assert.Error(t, hm.SaveSnapshot(4))
+
+	_, ok := hm.GetOldestCycle()
+	assert.False(t, ok)
+}
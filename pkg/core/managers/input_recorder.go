@@ -0,0 +1,271 @@
+package managers
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// inputRecordingHeader is written at the start of every recording to document the format.
+const inputRecordingHeader = "# cycle kind payload\n"
+
+// Payloads of the reset stimuli
+const (
+	resetPressed  = "on"
+	resetReleased = "off"
+)
+
+// inputRecorder writes the stimuli received by the computer, one per line with the cycle,
+// the kind and the payload of the stimulus separated by spaces:
+//
+//	1200 serial 41
+//	5000 mia-input 127.0.0.1:51234 4d49494e011001004e3a1c6b48
+//	7000 keyboard 1cf01c
+//	8000 button 0002
+//	9000 reset on
+//	9016 reset off
+//	12000 speed 2.5
+//
+// Bytes are written in hexadecimal. Lines starting with # are comments.
+type inputRecorder struct {
+	mutex   sync.Mutex    // Held while the writer is replaced or a stimulus is written
+	enabled atomic.Bool   // There is a writer, so the stimuli must be recorded
+	writer  *bufio.Writer // Buffers the lines of the recording file
+}
+
+// newInputRecorder creates a new input recorder.
+//
+// Returns:
+//   - A pointer to the initialized inputRecorder
+func newInputRecorder() *inputRecorder {
+	return &inputRecorder{}
+}
+
+// NewInputRecorder creates a new input recorder. It doesn't write anything until a writer is set.
+//
+// Returns:
+//   - A pointer to the initialized InputRecorder
+func NewInputRecorder() core.InputRecorder {
+	return newInputRecorder()
+}
+
+// SetWriter sets where the stimuli are written, nil stops recording. The previous writer is
+// flushed and the header of the format is written to the new one.
+//
+// Parameters:
+//   - writer: Where the stimuli are written, nil to stop recording
+//
+// Returns:
+//   - An error if the pending output can't be written to the previous writer
+func (r *inputRecorder) SetWriter(writer io.Writer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	if r.writer != nil {
+		err = r.writer.Flush()
+	}
+
+	r.writer = nil
+
+	if writer != nil {
+		r.writer = bufio.NewWriter(writer)
+		r.writer.WriteString(inputRecordingHeader)
+	}
+
+	r.enabled.Store(writer != nil)
+
+	return err
+}
+
+// IsEnabled returns true if the stimuli are being written.
+//
+// Returns:
+//   - true if a writer is set
+func (r *inputRecorder) IsEnabled() bool {
+	return r.enabled.Load()
+}
+
+// RecordStimulus writes the line of the stimulus. It has no effect if no writer is set.
+//
+// Parameters:
+//   - stimulus: The stimulus received by the computer
+//
+// Returns:
+//   - An error if the stimulus is not valid or can't be written
+func (r *inputRecorder) RecordStimulus(stimulus core.Stimulus) error {
+	if !r.enabled.Load() {
+		return nil
+	}
+
+	line, err := formatStimulus(stimulus)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.writer == nil {
+		return nil
+	}
+
+	_, err = r.writer.WriteString(line)
+
+	return err
+}
+
+// formatStimulus returns the line of the stimulus, including the line feed.
+func formatStimulus(stimulus core.Stimulus) (string, error) {
+	var payload string
+
+	switch stimulus.Kind {
+	case core.StimulusSerial, core.StimulusKeyboard, core.StimulusButton:
+		payload = hex.EncodeToString(stimulus.Data)
+	case core.StimulusMiaInput:
+		payload = stimulus.Source + " " + hex.EncodeToString(stimulus.Data)
+	case core.StimulusReset:
+		payload = resetReleased
+		if stimulus.Value != 0 {
+			payload = resetPressed
+		}
+	case core.StimulusSpeed:
+		payload = strconv.FormatFloat(stimulus.Value, 'f', -1, 64)
+	default:
+		return "", fmt.Errorf("unknown stimulus kind %q", stimulus.Kind)
+	}
+
+	return fmt.Sprintf("%d %s %s\n", stimulus.Cycle, stimulus.Kind, payload), nil
+}
+
+/************************************************************************************
+* Replay
+*************************************************************************************/
+
+// LoadInputRecording loads the stimuli of a file written by the input recorder.
+//
+// Parameters:
+//   - path: The path of the file to load
+//
+// Returns:
+//   - The stimuli sorted by cycle
+//   - An error if the file can't be read or is not valid
+func LoadInputRecording(path string) ([]core.Stimulus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stimuli, err := ReadInputRecording(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return stimuli, nil
+}
+
+// ReadInputRecording reads the stimuli written by the input recorder. Blank lines and lines
+// starting with # are ignored.
+//
+// Parameters:
+//   - reader: The reader of the recording
+//
+// Returns:
+//   - The stimuli sorted by cycle
+//   - An error if a line is not valid or the stimuli are not sorted by cycle
+func ReadInputRecording(reader io.Reader) ([]core.Stimulus, error) {
+	var stimuli []core.Stimulus
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		stimulus, err := parseStimulus(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		if len(stimuli) > 0 && stimulus.Cycle < stimuli[len(stimuli)-1].Cycle {
+			return nil, fmt.Errorf("line %d: cycle %d is before the previous stimulus", lineNumber, stimulus.Cycle)
+		}
+
+		stimuli = append(stimuli, stimulus)
+	}
+
+	return stimuli, scanner.Err()
+}
+
+// parseStimulus parses the line of a stimulus written by formatStimulus.
+func parseStimulus(line string) (core.Stimulus, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return core.Stimulus{}, errors.New("expected \"<cycle> <kind> <payload>\"")
+	}
+
+	cycle, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return core.Stimulus{}, fmt.Errorf("invalid cycle %q", fields[0])
+	}
+
+	stimulus := core.Stimulus{Cycle: cycle, Kind: core.StimulusKind(fields[1])}
+	payload := fields[2:]
+
+	switch stimulus.Kind {
+	case core.StimulusSerial, core.StimulusKeyboard, core.StimulusButton:
+		if len(payload) != 1 {
+			return core.Stimulus{}, errors.New("expected the received bytes")
+		}
+
+		stimulus.Data, err = hex.DecodeString(payload[0])
+	case core.StimulusMiaInput:
+		if len(payload) != 2 {
+			return core.Stimulus{}, errors.New("expected the sender address and the packet")
+		}
+
+		stimulus.Source = payload[0]
+		stimulus.Data, err = hex.DecodeString(payload[1])
+	case core.StimulusReset:
+		switch {
+		case len(payload) == 1 && payload[0] == resetPressed:
+			stimulus.Value = 1
+		case len(payload) == 1 && payload[0] == resetReleased:
+			stimulus.Value = 0
+		default:
+			return core.Stimulus{}, fmt.Errorf("expected %q or %q", resetPressed, resetReleased)
+		}
+	case core.StimulusSpeed:
+		if len(payload) != 1 {
+			return core.Stimulus{}, errors.New("expected the speed in MHz")
+		}
+
+		stimulus.Value, err = strconv.ParseFloat(payload[0], 64)
+		if err == nil && stimulus.Value <= 0 {
+			err = errors.New("the speed must be greater than 0")
+		}
+	default:
+		return core.Stimulus{}, fmt.Errorf("unknown stimulus kind %q", fields[1])
+	}
+
+	if err != nil {
+		return core.Stimulus{}, fmt.Errorf("invalid %s payload: %w", stimulus.Kind, err)
+	}
+
+	return stimulus, nil
+}
@@ -0,0 +1,108 @@
+package managers
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testStimuli []core.Stimulus = []core.Stimulus{
+	{Cycle: 1200, Kind: core.StimulusSerial, Data: []byte{0x41}},
+	{Cycle: 5000, Kind: core.StimulusMiaInput, Source: "127.0.0.1:51234", Data: []byte("MIIN\x01\x10")},
+	{Cycle: 7000, Kind: core.StimulusKeyboard, Data: []byte{0x1C, 0xF0, 0x1C}},
+	{Cycle: 8000, Kind: core.StimulusButton, Data: []byte{0x00, 0x02}},
+	{Cycle: 9000, Kind: core.StimulusReset, Value: 1},
+	{Cycle: 9016, Kind: core.StimulusReset, Value: 0},
+	{Cycle: 12000, Kind: core.StimulusSpeed, Value: 2.5},
+}
+
+func TestInputRecorderWritesStimuli(t *testing.T) {
+	var output bytes.Buffer
+
+	recorder := NewInputRecorder()
+	assert.False(t, recorder.IsEnabled())
+
+	// Nothing is written without a writer
+	require.NoError(t, recorder.RecordStimulus(testStimuli[0]))
+
+	require.NoError(t, recorder.SetWriter(&output))
+	assert.True(t, recorder.IsEnabled())
+
+	for _, stimulus := range testStimuli {
+		require.NoError(t, recorder.RecordStimulus(stimulus))
+	}
+
+	require.NoError(t, recorder.SetWriter(nil))
+	assert.False(t, recorder.IsEnabled())
+
+	expected := "# cycle kind payload\n" +
+		"1200 serial 41\n" +
+		"5000 mia-input 127.0.0.1:51234 4d49494e0110\n" +
+		"7000 keyboard 1cf01c\n" +
+		"8000 button 0002\n" +
+		"9000 reset on\n" +
+		"9016 reset off\n" +
+		"12000 speed 2.5\n"
+
+	assert.Equal(t, expected, output.String())
+}
+
+func TestInputRecorderRejectsUnknownStimuli(t *testing.T) {
+	recorder := NewInputRecorder()
+	require.NoError(t, recorder.SetWriter(&bytes.Buffer{}))
+
+	assert.Error(t, recorder.RecordStimulus(core.Stimulus{Kind: "mouse"}))
+}
+
+func TestLoadInputRecording(t *testing.T) {
+	var output bytes.Buffer
+
+	recorder := NewInputRecorder()
+	require.NoError(t, recorder.SetWriter(&output))
+
+	for _, stimulus := range testStimuli {
+		require.NoError(t, recorder.RecordStimulus(stimulus))
+	}
+
+	require.NoError(t, recorder.SetWriter(nil))
+
+	path := filepath.Join(t.TempDir(), "test.input")
+	require.NoError(t, os.WriteFile(path, output.Bytes(), 0644))
+
+	stimuli, err := LoadInputRecording(path)
+	require.NoError(t, err)
+	assert.Equal(t, testStimuli, stimuli)
+}
+
+func TestReadInputRecordingErrors(t *testing.T) {
+	tests := map[string]string{
+		"1200 serial":                  "line 1: expected",
+		"12x0 serial 41":               "line 1: invalid cycle",
+		"1200 mouse 41":                "line 1: unknown stimulus kind",
+		"1200 serial 4":                "line 1: invalid serial payload",
+		"1200 mia-input 4d49":          "line 1: expected the sender address",
+		"1200 reset pressed":           "line 1: expected \"on\" or \"off\"",
+		"1200 speed 0":                 "line 1: invalid speed payload",
+		"1200 serial 41\n100 reset on": "line 2: cycle 100 is before the previous stimulus",
+	}
+
+	for input, expected := range tests {
+		_, err := ReadInputRecording(strings.NewReader(input))
+		if assert.Error(t, err, input) {
+			assert.Contains(t, err.Error(), expected, input)
+		}
+	}
+}
+
+func TestReadInputRecordingSkipsCommentsAndBlankLines(t *testing.T) {
+	stimuli, err := ReadInputRecording(strings.NewReader("# comment\n\n  10 reset on  \n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []core.Stimulus{{Cycle: 10, Kind: core.StimulusReset, Value: 1}}, stimuli)
+}
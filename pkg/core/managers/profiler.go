@@ -0,0 +1,444 @@
+package managers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Maximum depth of the call stack followed by the profiler, deeper calls are attributed
+// to the deepest subroutine followed
+const maxProfileDepth int = 256
+
+// Name of the frame that holds the cycles consumed outside any subroutine
+const profileRootName string = "[root]"
+
+// profileNode holds the counts of a subroutine called from a specific call stack. Its
+// children are the subroutines it called.
+type profileNode struct {
+	address      uint16
+	parent       *profileNode
+	children     map[uint16]*profileNode
+	calls        uint64
+	instructions uint64
+	cycles       uint64
+}
+
+// profileFrame is a call being executed, the stack pointer after pushing the return address
+// is used to detect when the call returns.
+type profileFrame struct {
+	node         *profileNode
+	stackPointer uint8
+}
+
+// profiler counts the instructions and cycles executed at each address and builds a tree
+// of the subroutines called. Calls are followed by a call tracker, the same way as in the
+// call stack window. A call returns when the stack pointer goes above the one it had after
+// pushing the return address.
+type profiler struct {
+	tracker core.CallTracker
+	symbols core.SymbolTable
+
+	// Reports are built from the UI while the counters below are updated every cycle
+	mutex   sync.Mutex
+	enabled atomic.Bool // Profiling is on, off means the cycles aren't even looked at
+
+	instructions [0x10000]uint64
+	cycles       [0x10000]uint64
+	totalCycles  uint64
+
+	root   *profileNode
+	frames []profileFrame
+
+	fetched bool   // An opcode was fetched since profiling started
+	address uint16 // Address of the instruction being executed
+}
+
+// newProfiler creates a new profiler.
+//
+// Parameters:
+//   - processor: The processor whose execution is profiled
+//   - symbols: The symbol table used to name the subroutines in the reports, nil to use addresses
+//
+// Returns:
+//   - A pointer to the initialized profiler
+func newProfiler(processor TraceProcessor, symbols core.SymbolTable) *profiler {
+	p := &profiler{
+		symbols: symbols,
+		root:    newProfileNode(0, nil),
+		frames:  make([]profileFrame, 0, maxProfileDepth),
+	}
+
+	p.tracker = newCallTracker(processor, p)
+
+	return p
+}
+
+// NewProfiler creates a new profiler. It doesn't count anything until it's enabled.
+//
+// Parameters:
+//   - processor: The processor whose execution is profiled
+//   - symbols: The symbol table used to name the subroutines in the reports, nil to use addresses
+//
+// Returns:
+//   - A pointer to the initialized Profiler
+func NewProfiler(processor TraceProcessor, symbols core.SymbolTable) core.Profiler {
+	return newProfiler(processor, symbols)
+}
+
+// newProfileNode creates the node of a subroutine called from the parent node.
+func newProfileNode(address uint16, parent *profileNode) *profileNode {
+	return &profileNode{
+		address:  address,
+		parent:   parent,
+		children: make(map[uint16]*profileNode),
+	}
+}
+
+/************************************************************************************
+* Profiling
+*************************************************************************************/
+
+// SetEnabled starts or stops counting. As the instruction in progress when profiling starts
+// is not known, counting starts on the next opcode fetch.
+//
+// Parameters:
+//   - enabled: True to count the executed instructions and cycles
+func (p *profiler) SetEnabled(enabled bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if enabled && !p.enabled.Load() {
+		p.fetched = false
+		p.tracker.Reset()
+	}
+
+	p.enabled.Store(enabled)
+}
+
+// IsEnabled returns true if the execution is being profiled.
+//
+// Returns:
+//   - true if the profiler is counting
+func (p *profiler) IsEnabled() bool {
+	return p.enabled.Load()
+}
+
+// ProfileCycle attributes the cycle to the instruction being executed. On an opcode fetch
+// the call stack is updated with the previous instruction and the new one is counted.
+// Interrupts complete the instruction they follow and their handler is called on its
+// first opcode fetch. A reset clears the call stack.
+func (p *profiler) ProfileCycle() {
+	if !p.enabled.Load() {
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.tracker.TrackCycle() {
+		p.fetched = true
+		p.address = p.tracker.GetInstructionAddress()
+
+		p.instructions[p.address]++
+		p.current().instructions++
+	}
+
+	if !p.fetched {
+		return
+	}
+
+	p.cycles[p.address]++
+	p.current().cycles++
+	p.totalCycles++
+}
+
+// OnCall adds a call to the subroutine or interrupt handler from the subroutine being executed.
+//
+// Parameters:
+//   - frame: The call detected by the call tracker
+func (p *profiler) OnCall(frame core.CallFrame) {
+	if len(p.frames) >= maxProfileDepth {
+		return
+	}
+
+	parent := p.current()
+
+	node, ok := parent.children[frame.TargetAddress]
+	if !ok {
+		node = newProfileNode(frame.TargetAddress, parent)
+		parent.children[frame.TargetAddress] = node
+	}
+
+	node.calls++
+	p.frames = append(p.frames, profileFrame{node: node, stackPointer: frame.StackPointer})
+}
+
+// OnReturn returns from the calls whose return address was pulled from the stack.
+//
+// Parameters:
+//   - ret: The return detected by the call tracker
+func (p *profiler) OnReturn(ret core.CallReturn) {
+	for len(p.frames) > 0 && p.frames[len(p.frames)-1].stackPointer < ret.StackPointer {
+		p.frames = p.frames[:len(p.frames)-1]
+	}
+}
+
+// OnReset clears the call stack as the processor was reset.
+func (p *profiler) OnReset() {
+	p.frames = p.frames[:0]
+}
+
+// current returns the node of the subroutine being executed.
+func (p *profiler) current() *profileNode {
+	if len(p.frames) == 0 {
+		return p.root
+	}
+
+	return p.frames[len(p.frames)-1].node
+}
+
+// Clear discards the collected counts and the call stack.
+func (p *profiler) Clear() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.instructions = [0x10000]uint64{}
+	p.cycles = [0x10000]uint64{}
+	p.totalCycles = 0
+
+	// The calls in progress are kept, their nodes are created again in the new tree
+	root := newProfileNode(0, nil)
+	parent := root
+
+	for i, frame := range p.frames {
+		node := newProfileNode(frame.node.address, parent)
+		parent.children[node.address] = node
+		p.frames[i].node = node
+		parent = node
+	}
+
+	p.root = root
+}
+
+/************************************************************************************
+* Reports
+*************************************************************************************/
+
+// GetTotalCycles returns the number of cycles profiled.
+//
+// Returns:
+//   - The number of cycles counted
+func (p *profiler) GetTotalCycles() uint64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.totalCycles
+}
+
+// GetAddresses returns the addresses that consumed cycles, the ones with more cycles first.
+//
+// Returns:
+//   - A slice with the instructions and cycles of each address
+func (p *profiler) GetAddresses() []core.ProfileAddress {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.getAddresses()
+}
+
+func (p *profiler) getAddresses() []core.ProfileAddress {
+	result := make([]core.ProfileAddress, 0)
+
+	for address, cycles := range p.cycles {
+		if cycles > 0 || p.instructions[address] > 0 {
+			result = append(result, core.ProfileAddress{
+				Address:      uint16(address),
+				Instructions: p.instructions[address],
+				Cycles:       cycles,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Cycles > result[j].Cycles
+	})
+
+	return result
+}
+
+// GetSubroutines returns the subroutines and interrupt handlers called, the ones with more
+// total cycles first. The counts of a subroutine called from different places are added, and
+// the total cycles of recursive calls are only counted once.
+//
+// Returns:
+//   - A slice with the calls and cycles of each subroutine
+func (p *profiler) GetSubroutines() []core.ProfileSubroutine {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.getSubroutines()
+}
+
+func (p *profiler) getSubroutines() []core.ProfileSubroutine {
+	subroutines := make(map[uint16]*core.ProfileSubroutine)
+	active := make(map[uint16]int)
+
+	var visit func(node *profileNode) uint64
+	visit = func(node *profileNode) uint64 {
+		active[node.address]++
+
+		total := node.cycles
+		for _, child := range node.children {
+			total += visit(child)
+		}
+
+		active[node.address]--
+
+		subroutine, ok := subroutines[node.address]
+		if !ok {
+			subroutine = &core.ProfileSubroutine{Address: node.address}
+			subroutines[node.address] = subroutine
+		}
+
+		subroutine.Calls += node.calls
+		subroutine.Instructions += node.instructions
+		subroutine.SelfCycles += node.cycles
+
+		// Cycles of recursive calls are already included in the outermost call
+		if active[node.address] == 0 {
+			subroutine.TotalCycles += total
+		}
+
+		return total
+	}
+
+	for _, child := range p.root.children {
+		visit(child)
+	}
+
+	result := make([]core.ProfileSubroutine, 0, len(subroutines))
+	for _, subroutine := range subroutines {
+		result = append(result, *subroutine)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalCycles != result[j].TotalCycles {
+			return result[i].TotalCycles > result[j].TotalCycles
+		}
+
+		return result[i].Address < result[j].Address
+	})
+
+	return result
+}
+
+// WriteReport writes a flat text report with the cycles consumed by each subroutine and the
+// instructions executed and cycles consumed at each address.
+//
+// Parameters:
+//   - writer: Where the report is written
+//
+// Returns:
+//   - An error if the report can't be written
+func (p *profiler) WriteReport(writer io.Writer) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	out := bufio.NewWriter(writer)
+
+	fmt.Fprintf(out, "Total cycles: %d\n\n", p.totalCycles)
+
+	fmt.Fprintf(out, "Subroutines\n\n")
+	fmt.Fprintf(out, "%-7s %10s %12s %12s %7s %12s %7s  %s\n", "Address", "Calls", "Instructions", "Self", "Self%", "Total", "Total%", "Name")
+
+	for _, subroutine := range p.getSubroutines() {
+		fmt.Fprintf(out, "$%04X   %10d %12d %12d %6.2f%% %12d %6.2f%%  %s\n",
+			subroutine.Address, subroutine.Calls, subroutine.Instructions,
+			subroutine.SelfCycles, p.percentage(subroutine.SelfCycles),
+			subroutine.TotalCycles, p.percentage(subroutine.TotalCycles),
+			p.label(subroutine.Address))
+	}
+
+	fmt.Fprintf(out, "\nAddresses\n\n")
+	fmt.Fprintf(out, "%-7s %12s %12s %7s  %s\n", "Address", "Instructions", "Cycles", "Cycles%", "Label")
+
+	for _, address := range p.getAddresses() {
+		fmt.Fprintf(out, "$%04X   %12d %12d %6.2f%%  %s\n",
+			address.Address, address.Instructions, address.Cycles, p.percentage(address.Cycles), p.label(address.Address))
+	}
+
+	return out.Flush()
+}
+
+// WriteCollapsedStacks writes one line per call stack with the cycles consumed by the
+// innermost subroutine, for example "[root];main;print 1234". Subroutines are named by
+// their label or by their address if they don't have one.
+//
+// Parameters:
+//   - writer: Where the stacks are written
+//
+// Returns:
+//   - An error if the stacks can't be written
+func (p *profiler) WriteCollapsedStacks(writer io.Writer) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	lines := make([]string, 0)
+
+	var visit func(node *profileNode, stack string)
+	visit = func(node *profileNode, stack string) {
+		if node.cycles > 0 {
+			lines = append(lines, fmt.Sprintf("%s %d", stack, node.cycles))
+		}
+
+		for _, child := range node.children {
+			visit(child, stack+";"+p.name(child.address))
+		}
+	}
+
+	visit(p.root, profileRootName)
+	sort.Strings(lines)
+
+	out := bufio.NewWriter(writer)
+	for _, line := range lines {
+		fmt.Fprintln(out, line)
+	}
+
+	return out.Flush()
+}
+
+// percentage returns the percentage of the profiled cycles that the value represents.
+func (p *profiler) percentage(cycles uint64) float64 {
+	if p.totalCycles == 0 {
+		return 0
+	}
+
+	return float64(cycles) * 100 / float64(p.totalCycles)
+}
+
+// label returns the label of the address, or an empty string if it has none.
+func (p *profiler) label(address uint16) string {
+	if p.symbols == nil {
+		return ""
+	}
+
+	label, _ := p.symbols.GetLabel(address)
+	return label
+}
+
+// name returns the name of the subroutine at the address in the collapsed stacks. The
+// characters used as separators by the format are replaced.
+func (p *profiler) name(address uint16) string {
+	if label := p.label(address); label != "" {
+		return strings.NewReplacer(";", "_", " ", "_").Replace(label)
+	}
+
+	return fmt.Sprintf("$%04X", address)
+}
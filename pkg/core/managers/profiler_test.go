@@ -0,0 +1,151 @@
+package managers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test program:
+//
+//	0400: LDX #$FF
+//	0402: TXS
+//	0403: JSR $0410
+//	0406: JMP $0403
+//	0410: NOP
+//	0411: RTS
+var profileTestProgram map[uint16][]uint8 = map[uint16][]uint8{
+	0x0400: {0xA2, 0xFF, 0x9A, 0x20, 0x10, 0x04, 0x4C, 0x03, 0x04},
+	0x0410: {0xEA, 0x60},
+}
+
+func newProfileTestComputer() *traceTestComputer {
+	computer := newTraceTestComputer()
+
+	for address, values := range profileTestProgram {
+		for i, value := range values {
+			computer.ram.Poke(address+uint16(i), value)
+		}
+	}
+
+	return computer
+}
+
+// profile executes the cycles calling the profiler after each one
+func (c *traceTestComputer) profile(p core.Profiler, cycles int) {
+	context := common.NewStepContext()
+
+	for range cycles {
+		c.processor.Tick(&context)
+		c.ram.Tick(&context)
+		c.processor.PostTick(&context)
+
+		p.ProfileCycle()
+
+		context.NextCycle()
+	}
+}
+
+func TestProfiler_CountsInstructionsAndCyclesPerAddress(t *testing.T) {
+	computer := newProfileTestComputer()
+	p := NewProfiler(computer.processor, nil)
+	p.SetEnabled(true)
+
+	// LDX (2) + TXS (2) and two loops of JSR (6) + NOP (2) + RTS (6) + JMP (3)
+	computer.profile(p, 38)
+
+	assert.Equal(t, uint64(38), p.GetTotalCycles())
+
+	addresses := p.GetAddresses()
+	assert.Len(t, addresses, 6)
+	assert.Equal(t, core.ProfileAddress{Address: 0x0403, Instructions: 2, Cycles: 12}, addresses[0])
+	assert.Equal(t, core.ProfileAddress{Address: 0x0411, Instructions: 2, Cycles: 12}, addresses[1])
+	assert.Equal(t, core.ProfileAddress{Address: 0x0406, Instructions: 2, Cycles: 6}, addresses[2])
+
+	subroutines := p.GetSubroutines()
+	assert.Equal(t, []core.ProfileSubroutine{
+		{Address: 0x0410, Calls: 2, Instructions: 4, SelfCycles: 16, TotalCycles: 16},
+	}, subroutines)
+}
+
+func TestProfiler_DoesNotCountWhenDisabled(t *testing.T) {
+	computer := newProfileTestComputer()
+	p := NewProfiler(computer.processor, nil)
+
+	// Executes LDX, TXS and the first cycle of JSR before profiling
+	computer.profile(p, 5)
+	assert.Equal(t, uint64(0), p.GetTotalCycles())
+
+	// Counting starts when NOP is fetched, completes JSR and executes NOP and RTS
+	p.SetEnabled(true)
+	computer.profile(p, 13)
+
+	assert.Equal(t, uint64(8), p.GetTotalCycles())
+	assert.Equal(t, []core.ProfileAddress{
+		{Address: 0x0411, Instructions: 1, Cycles: 6},
+		{Address: 0x0410, Instructions: 1, Cycles: 2},
+	}, p.GetAddresses())
+
+	p.SetEnabled(false)
+	computer.profile(p, 17)
+	assert.Equal(t, uint64(8), p.GetTotalCycles())
+
+	p.Clear()
+	assert.Equal(t, uint64(0), p.GetTotalCycles())
+	assert.Empty(t, p.GetAddresses())
+}
+
+func TestProfiler_WritesReportsWithSymbols(t *testing.T) {
+	computer := newProfileTestComputer()
+
+	symbols := NewSymbolTable()
+	symbols.AddSymbol("delay", 0x0410)
+
+	p := NewProfiler(computer.processor, symbols)
+	p.SetEnabled(true)
+	computer.profile(p, 38)
+
+	var report bytes.Buffer
+	assert.NoError(t, p.WriteReport(&report))
+	assert.Contains(t, report.String(), "Total cycles: 38")
+	assert.Regexp(t, `\$0410 +2 +4 +16 +42\.11% +16 +42\.11%  delay`, report.String())
+	assert.Regexp(t, `\$0403 +2 +12 +31\.58%`, report.String())
+
+	var stacks bytes.Buffer
+	assert.NoError(t, p.WriteCollapsedStacks(&stacks))
+
+	lines := strings.Split(strings.TrimSuffix(stacks.String(), "\n"), "\n")
+	assert.Equal(t, []string{"[root] 22", "[root];delay 16"}, lines)
+}
+
+func TestProfiler_AttributesInterruptHandlers(t *testing.T) {
+	computer := newProfileTestComputer()
+
+	// BRK inside the subroutine, the handler at $0420 only returns
+	computer.ram.Poke(0x0410, 0x00)
+	computer.ram.Poke(0x0412, 0x60)
+	computer.ram.Poke(0x0420, 0x40)
+	computer.ram.Poke(0xFFFE, 0x20)
+	computer.ram.Poke(0xFFFF, 0x04)
+
+	p := NewProfiler(computer.processor, nil)
+	p.SetEnabled(true)
+
+	// LDX (2) + TXS (2) + JSR (6) + BRK (7) + RTI (6) + RTS (6) + JMP (3)
+	computer.profile(p, 32)
+
+	var stacks bytes.Buffer
+	assert.NoError(t, p.WriteCollapsedStacks(&stacks))
+
+	lines := strings.Split(strings.TrimSuffix(stacks.String(), "\n"), "\n")
+	assert.Equal(t, []string{"[root] 13", "[root];$0410 13", "[root];$0410;$0420 6"}, lines)
+
+	subroutines := p.GetSubroutines()
+	assert.Len(t, subroutines, 2)
+	assert.Equal(t, core.ProfileSubroutine{Address: 0x0410, Calls: 1, Instructions: 2, SelfCycles: 13, TotalCycles: 19}, subroutines[0])
+	assert.Equal(t, core.ProfileSubroutine{Address: 0x0420, Calls: 1, Instructions: 1, SelfCycles: 6, TotalCycles: 6}, subroutines[1])
+}
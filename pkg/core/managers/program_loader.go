@@ -0,0 +1,640 @@
+package managers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// ErrNoLoadAddress is returned when loading a raw binary without specifying the address
+// where it's placed, as the file doesn't have one.
+var ErrNoLoadAddress = errors.New("the address where raw binaries are loaded is required")
+
+// ErrInvalidTextProgram is returned when a file detected as Intel HEX or S-record can't be
+// parsed, it might be a raw binary that happens to contain only text.
+var ErrInvalidTextProgram = errors.New("invalid Intel HEX or S-record file")
+
+// programFormat is the format of a program file.
+type programFormat string
+
+// Program formats detected by the loader
+const (
+	formatBinary   programFormat = "raw binary"
+	formatIntelHex programFormat = "Intel HEX"
+	formatSRecord  programFormat = "S-record"
+	formatPrg      programFormat = "PRG"
+	formatO65      programFormat = "o65"
+)
+
+// Marker and magic number at the start of the o65 files
+var o65Magic []byte = []byte{0x01, 0x00, 'o', '6', '5'}
+
+// Bits of the mode of the o65 files
+const (
+	o65Mode65816    uint16 = 0x8000 // Code for the 65816
+	o65ModePageWise uint16 = 0x4000 // Relocation by page, the low byte of HIGH entries is omitted
+	o65ModeSize32   uint16 = 0x2000 // Sizes and addresses of 32 bits
+	o65ModeChain    uint16 = 0x0400 // Other o65 file follows this one
+)
+
+// Types of the entries of the o65 relocation tables
+const (
+	o65RelocWord uint8 = 0x80
+	o65RelocHigh uint8 = 0x40
+	o65RelocLow  uint8 = 0x20
+)
+
+// Segments of the o65 files referenced by the relocation entries
+const (
+	o65SegmentUndefined uint8 = 0
+	o65SegmentText      uint8 = 2
+	o65SegmentData      uint8 = 3
+)
+
+// LoadProgram loads a program file, detecting its format from its content or, for PRG files,
+// from the ".prg" extension:
+//   - Intel HEX and Motorola S-record files are loaded at the addresses of their records, and
+//     the start address, if any, is the entry point
+//   - PRG files start with the address where the rest of the file is loaded
+//   - o65 files are loaded at the addresses of their text and data segments, or relocated
+//   - Files of any other format are raw binaries, loaded at the address specified
+//
+// Parameters:
+//   - path: The path of the file to load
+//   - address: Where the program is loaded, nil to use the addresses of the file. It's required
+//     for raw binaries and replaces the load address of PRG files. The text segment of o65
+//     files is relocated to it with the data segment right after it. Intel HEX and S-record
+//     files can't be loaded at other addresses.
+//
+// Returns:
+//   - The segments of the program and its entry point, if the file specifies one
+//   - ErrNoLoadAddress if the file is a raw binary and no address was specified,
+//     ErrInvalidTextProgram if the records of an Intel HEX or S-record file are not valid, or
+//     an error if the file can't be read, is not valid or doesn't fit in the 64K of the processor
+func LoadProgram(path string, address *uint16) (core.Program, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	program, err := parseProgram(path, data, address)
+	if err != nil {
+		return core.Program{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return program, nil
+}
+
+// parseProgram parses the program in the format detected for the file.
+func parseProgram(path string, data []byte, address *uint16) (core.Program, error) {
+	format := detectProgramFormat(path, data)
+
+	if address != nil && (format == formatIntelHex || format == formatSRecord) {
+		return core.Program{}, fmt.Errorf("%s files are loaded at the addresses of their records", format)
+	}
+
+	switch format {
+	case formatIntelHex, formatSRecord:
+		return parseTextProgram(format, data)
+	case formatPrg:
+		return parsePrg(data, address)
+	case formatO65:
+		return parseO65(data, address)
+	}
+
+	if address == nil {
+		return core.Program{}, ErrNoLoadAddress
+	}
+
+	var builder programBuilder
+	if err := builder.add(uint32(*address), data); err != nil {
+		return core.Program{}, err
+	}
+
+	return builder.program, nil
+}
+
+// parseTextProgram parses an Intel HEX or S-record file, wrapping the errors of its records
+// with ErrInvalidTextProgram.
+func parseTextProgram(format programFormat, data []byte) (core.Program, error) {
+	var program core.Program
+	var err error
+
+	if format == formatIntelHex {
+		program, err = parseIntelHex(data)
+	} else {
+		program, err = parseSRecord(data)
+	}
+
+	if err != nil {
+		return core.Program{}, fmt.Errorf("%w: %w", ErrInvalidTextProgram, err)
+	}
+
+	return program, nil
+}
+
+// detectProgramFormat returns the format of the file. The text formats are detected by the
+// start of their first record, only if the whole file is text, and PRG files, which have no
+// signature, by their extension.
+func detectProgramFormat(path string, data []byte) programFormat {
+	switch {
+	case bytes.HasPrefix(data, o65Magic):
+		return formatO65
+	case len(data) > 0 && data[0] == ':' && isText(data):
+		return formatIntelHex
+	case len(data) > 1 && data[0] == 'S' && data[1] >= '0' && data[1] <= '9' && isText(data):
+		return formatSRecord
+	case strings.EqualFold(filepath.Ext(path), ".prg"):
+		return formatPrg
+	}
+
+	return formatBinary
+}
+
+// isText returns true if the data only has printable ASCII characters, tabs and line breaks.
+func isText(data []byte) bool {
+	for _, value := range data {
+		if (value < 0x20 || value > 0x7E) && value != '\t' && value != '\r' && value != '\n' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// programBuilder adds the blocks of a program to its segments, the consecutive blocks are
+// merged in a single segment.
+type programBuilder struct {
+	program core.Program
+}
+
+// add adds a block of the program at the address, returning an error if it doesn't fit in
+// the 64K of the processor.
+func (b *programBuilder) add(address uint32, data []byte) error {
+	if address+uint32(len(data)) > 0x10000 {
+		return fmt.Errorf("the block of %d bytes at $%X exceeds the 64K of the processor", len(data), address)
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	segments := b.program.Segments
+	if last := len(segments) - 1; last >= 0 {
+		end := uint32(segments[last].Address) + uint32(len(segments[last].Data))
+		if end == address {
+			segments[last].Data = append(segments[last].Data, data...)
+			return nil
+		}
+	}
+
+	b.program.Segments = append(segments, core.ProgramSegment{
+		Address: uint16(address),
+		Data:    bytes.Clone(data),
+	})
+
+	return nil
+}
+
+// setEntry sets the entry point of the program, returning an error if it's out of the 64K
+// of the processor.
+func (b *programBuilder) setEntry(address uint32) error {
+	if address > 0xFFFF {
+		return fmt.Errorf("the start address $%X exceeds the 64K of the processor", address)
+	}
+
+	b.program.Entry = uint16(address)
+	b.program.HasEntry = true
+
+	return nil
+}
+
+// decodeRecords decodes the hexadecimal records of the text formats, one per line after a
+// start character, and passes their bytes to the handler. Blank lines are ignored.
+func decodeRecords(data []byte, start byte, handler func(kind byte, record []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if line[0] != start {
+			return fmt.Errorf("line %d: expected a record starting with %q", lineNumber, start)
+		}
+
+		// The S-records have the type of record as a character after the start
+		var kind byte
+		digits := line[1:]
+		if start == 'S' && len(digits) > 0 {
+			kind, digits = digits[0], digits[1:]
+		}
+
+		record, err := hex.DecodeString(digits)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid hexadecimal digits", lineNumber)
+		}
+
+		if err := handler(kind, record); err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseIntelHex parses an Intel HEX file. The records are ":LLAAAATT<data>CC" where LL is
+// the number of bytes of data, AAAA the address, TT the type and CC the checksum.
+func parseIntelHex(data []byte) (core.Program, error) {
+	var builder programBuilder
+	var base uint32
+	var ended bool
+
+	err := decodeRecords(data, ':', func(_ byte, record []byte) error {
+		if ended {
+			return errors.New("record after the end of file record")
+		}
+
+		if len(record) < 5 || len(record) != int(record[0])+5 {
+			return errors.New("invalid record length")
+		}
+
+		var sum uint8
+		for _, value := range record {
+			sum += value
+		}
+
+		if sum != 0 {
+			return errors.New("invalid checksum")
+		}
+
+		address := uint32(binary.BigEndian.Uint16(record[1:3]))
+		payload := record[4 : len(record)-1]
+
+		switch kind := record[3]; {
+		case kind == 0x00:
+			return builder.add(base+address, payload)
+		case kind == 0x01:
+			ended = true
+		case (kind == 0x02 || kind == 0x04) && len(payload) == 2:
+			// Extended segment address (multiplied by 16) and extended linear address
+			base = uint32(binary.BigEndian.Uint16(payload)) << 4
+			if kind == 0x04 {
+				base <<= 12
+			}
+		case kind == 0x03 && len(payload) == 4:
+			// Start segment address, CS:IP
+			return builder.setEntry(uint32(binary.BigEndian.Uint16(payload))<<4 + uint32(binary.BigEndian.Uint16(payload[2:])))
+		case kind == 0x05 && len(payload) == 4:
+			return builder.setEntry(binary.BigEndian.Uint32(payload))
+		default:
+			return fmt.Errorf("invalid record of type %02X", kind)
+		}
+
+		return nil
+	})
+
+	if err == nil && !ended {
+		err = errors.New("missing end of file record")
+	}
+
+	return builder.program, err
+}
+
+// parseSRecord parses a Motorola S-record file. The records are "S<type><count><address>
+// <data><checksum>", where the size of the address depends on the type. The start address
+// of the termination records is ignored if it's 0, as the tools write it when the program
+// has none.
+func parseSRecord(data []byte) (core.Program, error) {
+	var builder programBuilder
+
+	err := decodeRecords(data, 'S', func(kind byte, record []byte) error {
+		if len(record) < 1 || len(record) != int(record[0])+1 {
+			return errors.New("invalid record length")
+		}
+
+		var sum uint8
+		for _, value := range record {
+			sum += value
+		}
+
+		if sum != 0xFF {
+			return errors.New("invalid checksum")
+		}
+
+		var addressSize int
+
+		switch kind {
+		case '0', '5', '6':
+			// Header and count of records
+			return nil
+		case '1', '9':
+			addressSize = 2
+		case '2', '8':
+			addressSize = 3
+		case '3', '7':
+			addressSize = 4
+		default:
+			return fmt.Errorf("invalid record of type S%c", kind)
+		}
+
+		if len(record) < addressSize+2 {
+			return errors.New("invalid record length")
+		}
+
+		var address uint32
+		for _, value := range record[1 : addressSize+1] {
+			address = address<<8 | uint32(value)
+		}
+
+		if kind >= '7' {
+			if address == 0 {
+				return nil
+			}
+
+			return builder.setEntry(address)
+		}
+
+		return builder.add(address, record[addressSize+1:len(record)-1])
+	})
+
+	return builder.program, err
+}
+
+// parsePrg parses a PRG file, that has the address where it's loaded in its first 2 bytes.
+func parsePrg(data []byte, address *uint16) (core.Program, error) {
+	if len(data) < 2 {
+		return core.Program{}, errors.New("the PRG file doesn't have a load address")
+	}
+
+	load := binary.LittleEndian.Uint16(data)
+	if address != nil {
+		load = *address
+	}
+
+	var builder programBuilder
+	err := builder.add(uint32(load), data[2:])
+
+	return builder.program, err
+}
+
+// o65Reader reads the fields of an o65 file, the sizes and addresses are of 16 or 32 bits
+// depending on the mode of the file.
+type o65Reader struct {
+	data     []byte
+	position int
+	size32   bool
+}
+
+// bytes returns the next bytes of the file.
+func (r *o65Reader) bytes(count int) ([]byte, error) {
+	if count < 0 || r.position+count > len(r.data) {
+		return nil, errors.New("unexpected end of file")
+	}
+
+	value := r.data[r.position : r.position+count]
+	r.position += count
+
+	return value, nil
+}
+
+// byte returns the next byte of the file.
+func (r *o65Reader) byte() (uint8, error) {
+	value, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+
+	return value[0], nil
+}
+
+// word returns the next 16 bits value of the file.
+func (r *o65Reader) word() (uint16, error) {
+	value, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint16(value), nil
+}
+
+// size returns the next size or address of the file.
+func (r *o65Reader) size() (uint32, error) {
+	if !r.size32 {
+		value, err := r.word()
+		return uint32(value), err
+	}
+
+	value, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// sizes returns the next sizes or addresses of the file.
+func (r *o65Reader) sizes(count int) ([]uint32, error) {
+	values := make([]uint32, count)
+
+	for i := range values {
+		value, err := r.size()
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// parseO65 parses an o65 relocatable file. The text and data segments are loaded at their
+// addresses or, if an address is specified, relocated to it. The bss and zero page segments
+// are not relocated and the file can't reference undefined symbols.
+func parseO65(data []byte, address *uint16) (core.Program, error) {
+	reader := &o65Reader{data: data, position: len(o65Magic)}
+
+	version, err := reader.byte()
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	if version != 0 {
+		return core.Program{}, fmt.Errorf("unsupported o65 version %d", version)
+	}
+
+	mode, err := reader.word()
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	switch {
+	case mode&o65Mode65816 != 0:
+		return core.Program{}, errors.New("the o65 file has 65816 code")
+	case mode&o65ModeChain != 0:
+		return core.Program{}, errors.New("chained o65 files are not supported")
+	}
+
+	reader.size32 = mode&o65ModeSize32 != 0
+
+	// Text base and length, data base and length, bss, zero page and stack
+	header, err := reader.sizes(9)
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	textBase, textLength, dataBase, dataLength := header[0], header[1], header[2], header[3]
+
+	// Each option has its length, including the length byte, and a length of 0 ends them
+	for {
+		length, err := reader.byte()
+		if err != nil {
+			return core.Program{}, err
+		}
+
+		if length == 0 {
+			break
+		}
+
+		if _, err := reader.bytes(int(length) - 1); err != nil {
+			return core.Program{}, err
+		}
+	}
+
+	textSegment, err := reader.bytes(int(textLength))
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	dataSegment, err := reader.bytes(int(dataLength))
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	undefined, err := reader.size()
+	if err != nil {
+		return core.Program{}, err
+	}
+
+	if undefined > 0 {
+		return core.Program{}, fmt.Errorf("the o65 file references %d undefined symbols", undefined)
+	}
+
+	// The segments are copied to relocate them without changing the data of the file
+	textSegment = bytes.Clone(textSegment)
+	dataSegment = bytes.Clone(dataSegment)
+
+	newTextBase, newDataBase := textBase, dataBase
+	if address != nil {
+		newTextBase = uint32(*address)
+		newDataBase = newTextBase + textLength
+	}
+
+	deltas := map[uint8]uint32{
+		o65SegmentText: newTextBase - textBase,
+		o65SegmentData: newDataBase - dataBase,
+	}
+
+	pageWise := mode&o65ModePageWise != 0
+
+	if err := relocateO65(reader, textSegment, deltas, pageWise); err != nil {
+		return core.Program{}, fmt.Errorf("text relocation table: %w", err)
+	}
+
+	if err := relocateO65(reader, dataSegment, deltas, pageWise); err != nil {
+		return core.Program{}, fmt.Errorf("data relocation table: %w", err)
+	}
+
+	var builder programBuilder
+	if err := builder.add(newTextBase, textSegment); err != nil {
+		return core.Program{}, err
+	}
+
+	if err := builder.add(newDataBase, dataSegment); err != nil {
+		return core.Program{}, err
+	}
+
+	return builder.program, nil
+}
+
+// relocateO65 applies the entries of a relocation table to the segment. Each entry has the
+// offset from the previous one, or from the byte before the segment for the first one, and
+// the type of relocation with the segment of the address. An offset of 255 advances 254
+// bytes without relocating and an offset of 0 ends the table.
+func relocateO65(reader *o65Reader, segment []byte, deltas map[uint8]uint32, pageWise bool) error {
+	position := -1
+
+	for {
+		offset, err := reader.byte()
+		if err != nil {
+			return err
+		}
+
+		switch offset {
+		case 0:
+			return nil
+		case 255:
+			position += 254
+			continue
+		}
+
+		position += int(offset)
+
+		kind, err := reader.byte()
+		if err != nil {
+			return err
+		}
+
+		relocation, segmentID := kind&0xE0, kind&0x07
+		if segmentID == o65SegmentUndefined {
+			return errors.New("reference to an undefined symbol")
+		}
+
+		delta := deltas[segmentID]
+
+		switch relocation {
+		case o65RelocWord:
+			if position+2 > len(segment) {
+				return fmt.Errorf("relocation at offset %d out of the segment", position)
+			}
+
+			value := uint32(binary.LittleEndian.Uint16(segment[position:])) + delta
+			binary.LittleEndian.PutUint16(segment[position:], uint16(value))
+		case o65RelocHigh:
+			var low uint8
+			if !pageWise {
+				if low, err = reader.byte(); err != nil {
+					return err
+				}
+			}
+
+			if position >= len(segment) {
+				return fmt.Errorf("relocation at offset %d out of the segment", position)
+			}
+
+			value := (uint32(segment[position])<<8 | uint32(low)) + delta
+			segment[position] = uint8(value >> 8)
+		case o65RelocLow:
+			if position >= len(segment) {
+				return fmt.Errorf("relocation at offset %d out of the segment", position)
+			}
+
+			segment[position] += uint8(delta)
+		default:
+			return fmt.Errorf("unsupported relocation type %02X", relocation)
+		}
+	}
+}
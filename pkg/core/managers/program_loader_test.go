@@ -0,0 +1,167 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testO65 is an o65 file with the text segment at $1000 and the data segment at $1005. The
+// text has a jump to $1003 and loads the high byte of the address of the data, that has the
+// low byte of its own address.
+var testO65 []byte = []byte{
+	0x01, 0x00, 'o', '6', '5', 0x00, // Magic and version
+	0x00, 0x00, // Mode
+	0x00, 0x10, 0x05, 0x00, // Text base and length
+	0x05, 0x10, 0x02, 0x00, // Data base and length
+	0x00, 0x00, 0x00, 0x00, // Bss base and length
+	0x00, 0x00, 0x00, 0x00, // Zero page base and length
+	0x00, 0x00, // Stack
+	0x00,                         // End of options
+	0x4C, 0x03, 0x10, 0xA9, 0x10, // Text: JMP $1003, LDA #>$1005
+	0x05, 0xEA, // Data: <$1005, NOP
+	0x00, 0x00, // Undefined references
+	0x02, 0x82, 0x03, 0x43, 0x05, 0x00, // Text relocation: WORD of text, HIGH of data
+	0x01, 0x23, 0x00, // Data relocation: LOW of data
+	0x00, 0x00, // Exported globals
+}
+
+func writeProgramFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	return path
+}
+
+func TestLoadIntelHexProgram(t *testing.T) {
+	path := writeProgramFile(t, "test.hex", []byte(
+		":03800000A9FF6075\n"+
+			":01800300EA92\n"+
+			":0290000001026B\n"+
+			":040000050000800077\n"+
+			":00000001FF\n"))
+
+	program, err := LoadProgram(path, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, core.Program{
+		Segments: []core.ProgramSegment{
+			{Address: 0x8000, Data: []byte{0xA9, 0xFF, 0x60, 0xEA}},
+			{Address: 0x9000, Data: []byte{0x01, 0x02}},
+		},
+		Entry:    0x8000,
+		HasEntry: true,
+	}, program)
+
+	address := uint16(0x1000)
+	_, err = LoadProgram(path, &address)
+	assert.Error(t, err)
+}
+
+func TestLoadSRecordProgram(t *testing.T) {
+	path := writeProgramFile(t, "test.s19", []byte(
+		"S00600004844521B\r\n"+
+			"S1060200A90160ED\r\n"+
+			"S5030001FB\r\n"+
+			"S9030200FA\r\n"))
+
+	program, err := LoadProgram(path, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, core.Program{
+		Segments: []core.ProgramSegment{{Address: 0x0200, Data: []byte{0xA9, 0x01, 0x60}}},
+		Entry:    0x0200,
+		HasEntry: true,
+	}, program)
+}
+
+func TestLoadPrgProgram(t *testing.T) {
+	path := writeProgramFile(t, "test.prg", []byte{0x01, 0x08, 0xA9, 0x00, 0x60})
+
+	program, err := LoadProgram(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []core.ProgramSegment{{Address: 0x0801, Data: []byte{0xA9, 0x00, 0x60}}}, program.Segments)
+	assert.False(t, program.HasEntry)
+
+	address := uint16(0x4000)
+	program, err = LoadProgram(path, &address)
+	require.NoError(t, err)
+	assert.Equal(t, []core.ProgramSegment{{Address: 0x4000, Data: []byte{0xA9, 0x00, 0x60}}}, program.Segments)
+}
+
+func TestLoadO65Program(t *testing.T) {
+	path := writeProgramFile(t, "test.o65", testO65)
+
+	program, err := LoadProgram(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []core.ProgramSegment{
+		{Address: 0x1000, Data: []byte{0x4C, 0x03, 0x10, 0xA9, 0x10, 0x05, 0xEA}},
+	}, program.Segments)
+
+	// Relocated, the data segment follows the text
+	address := uint16(0x2080)
+	program, err = LoadProgram(path, &address)
+	require.NoError(t, err)
+	assert.Equal(t, []core.ProgramSegment{
+		{Address: 0x2080, Data: []byte{0x4C, 0x83, 0x20, 0xA9, 0x20, 0x85, 0xEA}},
+	}, program.Segments)
+
+	// The content of the file is not changed by the relocation
+	assert.Equal(t, byte(0x10), testO65[31])
+}
+
+func TestLoadBinaryProgram(t *testing.T) {
+	path := writeProgramFile(t, "test.bin", []byte{0xA9, 0x00, 0x60})
+
+	_, err := LoadProgram(path, nil)
+	assert.ErrorIs(t, err, ErrNoLoadAddress)
+
+	address := uint16(0xFFFE)
+	_, err = LoadProgram(path, &address)
+	assert.ErrorContains(t, err, "exceeds the 64K")
+
+	address = 0x0300
+	program, err := LoadProgram(path, &address)
+	require.NoError(t, err)
+	assert.Equal(t, []core.ProgramSegment{{Address: 0x0300, Data: []byte{0xA9, 0x00, 0x60}}}, program.Segments)
+}
+
+func TestLoadBinaryProgramStartingLikeText(t *testing.T) {
+	// A 32K ROM starting with JSR $803A, the space and colon are not a leading blank and the
+	// start of an Intel HEX record
+	rom := make([]byte, 0x8000)
+	copy(rom, []byte{0x20, 0x3A, 0x80})
+
+	_, err := LoadProgram(writeProgramFile(t, "rom.bin", rom), nil)
+	assert.ErrorIs(t, err, ErrNoLoadAddress)
+
+	// Raw binaries with only text are detected as text formats and fail to parse
+	_, err = LoadProgram(writeProgramFile(t, "text.bin", []byte(":JSR\n")), nil)
+	assert.ErrorIs(t, err, ErrInvalidTextProgram)
+}
+
+func TestParseProgramErrors(t *testing.T) {
+	tests := map[string]string{
+		":03800000A9FF6076\n:00000001FF\n":        "line 1: invalid checksum",
+		":03800000A9FF6075\n":                     "missing end of file record",
+		":00000001FF\n:03800000A9FF6075\n":        "line 2: record after the end of file record",
+		":03800000A9FF\n":                         "line 1: invalid record length",
+		":03800000A9FF6075\nS1060200A90160ED\n":   "line 2: expected a record starting with ':'",
+		"S1060200A90160EE\n":                      "line 1: invalid checksum",
+		"S1060200A90160ED\nS4030200FA\n":          "line 2: invalid record of type S4",
+		"S1060200A90160ED\nS1060200A901XXED\n":    "line 2: invalid hexadecimal digits",
+		"\x01\x00o65\x00\x00\x80":                 "65816 code",
+		"\x01\x00o65\x00\x00\x00\x00\x10\x05\x00": "unexpected end of file",
+	}
+
+	for input, expected := range tests {
+		_, err := parseProgram("test", []byte(input), nil)
+		if assert.Error(t, err, input) {
+			assert.Contains(t, err.Error(), expected, input)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package managers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// ErrNoLineInformation is returned when loading a source map from a file that doesn't
+// map addresses to source lines, such as a VICE label file.
+var ErrNoLineInformation = errors.New("no source line information found")
+
+// Line type used by ld65 for the lines generated by a macro expansion
+const debugInfoMacroLine int = 2
+
+// sourceLine is a line of a source file that generated code, as recorded in the
+// debug information.
+type sourceLine struct {
+	file     int
+	line     int
+	lineType int
+	spans    []int
+}
+
+// sourceSpan is a range of bytes generated by one or more source lines. The start is
+// relative to the start of the segment.
+type sourceSpan struct {
+	segment int
+	start   int
+	size    int
+}
+
+// sourceMap maps the addresses of the program being debugged to the source lines that
+// generated them. Source files are read when first requested and kept in memory.
+type sourceMap struct {
+	locations     map[uint16]sourceLine
+	files         map[int]string
+	baseDirectory string
+	cache         map[string][]string
+}
+
+// newSourceMap creates a new empty source map.
+//
+// Parameters:
+//   - baseDirectory: The directory used to resolve the relative paths of the source files
+//
+// Returns:
+//   - A pointer to the initialized sourceMap
+func newSourceMap(baseDirectory string) *sourceMap {
+	return &sourceMap{
+		locations:     make(map[uint16]sourceLine),
+		files:         make(map[int]string),
+		baseDirectory: baseDirectory,
+		cache:         make(map[string][]string),
+	}
+}
+
+// LoadSourceMap loads the source line information from a ld65 debug info file (generated
+// with the --dbgfile option). Relative source paths are resolved from the directory of
+// the debug info file and, if not found there, from the current directory.
+//
+// Parameters:
+//   - path: The path of the debug info file
+//
+// Returns:
+//   - The source map loaded from the file
+//   - ErrNoLineInformation if the file is not a debug info file or has no line information,
+//     or any other error if the file can't be read or is not valid
+func LoadSourceMap(path string) (core.SourceMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isDebugInfo(data) {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoLineInformation)
+	}
+
+	sourceMap := newSourceMap(filepath.Dir(path))
+
+	if err := sourceMap.loadDebugInfo(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(sourceMap.locations) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoLineInformation)
+	}
+
+	return sourceMap, nil
+}
+
+// loadDebugInfo reads the files, lines, segments and spans of a ld65 debug info file and
+// maps every address covered by a span to the line that generated it. When more than
+// one line generated the same bytes, the lines written by the user are preferred over
+// the lines of the macro expansions.
+//
+// The records used look like:
+//
+//	file	id=0,name="lcd.s",size=1402,mtime=0x66A0C1F2,mod=0
+//	line	id=12,file=0,line=31,span=4+5
+//	seg	id=0,name="CODE",start=0x008000,size=0x0112,addrsize=absolute,type=ro
+//	span	id=4,seg=0,start=18,size=3
+func (m *sourceMap) loadDebugInfo(data []byte) error {
+	lines := make([]sourceLine, 0)
+	segments := make(map[int]int)
+	spans := make(map[int]sourceSpan)
+
+	err := readDebugInfo(bytes.NewReader(data), func(kind string, record debugInfoRecord) error {
+		var err error
+
+		switch kind {
+		case "file":
+			var id int
+			if id, err = record.number("id"); err == nil {
+				m.files[id] = record["name"]
+			}
+
+		case "seg":
+			var id, start int
+			if id, err = record.number("id"); err == nil {
+				if start, err = record.number("start"); err == nil {
+					segments[id] = start
+				}
+			}
+
+		case "span":
+			var id int
+			var span sourceSpan
+			if id, err = record.number("id"); err == nil {
+				if span, err = parseSourceSpan(record); err == nil {
+					spans[id] = span
+				}
+			}
+
+		case "line":
+			// Lines that didn't generate any byte have no spans
+			if _, ok := record["span"]; ok {
+				var line sourceLine
+				if line, err = parseSourceLine(record); err == nil {
+					lines = append(lines, line)
+				}
+			}
+		}
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		for _, id := range line.spans {
+			span, ok := spans[id]
+			if !ok {
+				return fmt.Errorf("line %d references undefined span %d", line.line, id)
+			}
+
+			start := segments[span.segment] + span.start
+
+			for address := start; address < start+span.size; address++ {
+				current, exists := m.locations[uint16(address)]
+
+				if !exists || (current.lineType == debugInfoMacroLine && line.lineType != debugInfoMacroLine) {
+					m.locations[uint16(address)] = line
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseSourceSpan reads the segment, start and size of a span record.
+func parseSourceSpan(record debugInfoRecord) (sourceSpan, error) {
+	var err error
+	span := sourceSpan{}
+
+	if span.segment, err = record.number("seg"); err != nil {
+		return span, err
+	}
+
+	if span.start, err = record.number("start"); err != nil {
+		return span, err
+	}
+
+	span.size, err = record.number("size")
+
+	return span, err
+}
+
+// parseSourceLine reads the file, line number, type and the list of spans of a line
+// record. The spans are separated by "+" and the type is optional.
+func parseSourceLine(record debugInfoRecord) (sourceLine, error) {
+	var err error
+	line := sourceLine{}
+
+	if line.file, err = record.number("file"); err != nil {
+		return line, err
+	}
+
+	if line.line, err = record.number("line"); err != nil {
+		return line, err
+	}
+
+	if _, ok := record["type"]; ok {
+		if line.lineType, err = record.number("type"); err != nil {
+			return line, err
+		}
+	}
+
+	for _, text := range strings.Split(record["span"], "+") {
+		span, err := strconv.Atoi(text)
+		if err != nil {
+			return line, fmt.Errorf("invalid span %q", text)
+		}
+
+		line.spans = append(line.spans, span)
+	}
+
+	return line, nil
+}
+
+// GetSourceLocation returns the source line that generated the byte at the specified address.
+//
+// Parameters:
+//   - address: The address to look up
+//
+// Returns:
+//   - The file and line number that generated the address
+//   - true if the address is covered by the debug information, false otherwise
+func (m *sourceMap) GetSourceLocation(address uint16) (core.SourceLocation, bool) {
+	line, ok := m.locations[address]
+	if !ok {
+		return core.SourceLocation{}, false
+	}
+
+	return core.SourceLocation{File: m.files[line.file], Line: line.line}, true
+}
+
+// GetSourceLines returns the lines of the specified source file. The file is read the
+// first time it is requested and kept in memory.
+//
+// Parameters:
+//   - file: The path of the source file as returned in the source locations
+//
+// Returns:
+//   - The lines of the file
+//   - An error if the file can't be read
+func (m *sourceMap) GetSourceLines(file string) ([]string, error) {
+	if lines, ok := m.cache[file]; ok {
+		return lines, nil
+	}
+
+	data, err := os.ReadFile(m.resolvePath(file))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	m.cache[file] = lines
+
+	return lines, nil
+}
+
+// resolvePath returns the path where the source file can be found. Relative paths are
+// searched first in the directory of the debug info file.
+func (m *sourceMap) resolvePath(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+
+	path := filepath.Join(m.baseDirectory, file)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	return file
+}
@@ -0,0 +1,88 @@
+package managers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// Program used to test the source map:
+//
+//	main.s:3   reset:  LDX #$FF       ($8000, span 0)
+//	main.s:4           print          ($8002, span 1 + macro span 2)
+//	macros.inc:2       JSR lcd_print  ($8002, span 2)
+//	main.s:5           JMP reset      ($8005, span 3)
+const testSourceMapDebugInfo string = `version	major=2,minor=0
+file	id=0,name="main.s",size=60,mtime=0x5F000000,mod=0
+file	id=1,name="macros.inc",size=30,mtime=0x5F000000,mod=0
+line	id=0,file=0,line=3,span=0
+line	id=1,file=1,line=2,type=2,count=1,span=2
+line	id=2,file=0,line=4,span=1+2
+line	id=3,file=0,line=5,span=3
+line	id=4,file=0,line=1
+seg	id=0,name="CODE",start=0x008000,size=0x0008,addrsize=absolute,type=ro
+span	id=0,seg=0,start=0,size=2
+span	id=1,seg=0,start=2,size=3
+span	id=2,seg=0,start=2,size=3
+span	id=3,seg=0,start=5,size=3
+`
+
+const testSource string = "; Test program\r\n.segment \"CODE\"\r\nreset:  LDX #$FF\r\n        print\r\n        JMP reset\r\n"
+
+func TestLoadSourceMap(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "rom.dbg")
+
+	assert.NoError(t, os.WriteFile(path, []byte(testSourceMapDebugInfo), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, "main.s"), []byte(testSource), 0o644))
+
+	sm, err := LoadSourceMap(path)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		address  uint16
+		expected core.SourceLocation
+	}{
+		{0x8000, core.SourceLocation{File: "main.s", Line: 3}},
+		{0x8001, core.SourceLocation{File: "main.s", Line: 3}},
+		{0x8002, core.SourceLocation{File: "main.s", Line: 4}}, // Preferred over the macro line
+		{0x8004, core.SourceLocation{File: "main.s", Line: 4}},
+		{0x8007, core.SourceLocation{File: "main.s", Line: 5}},
+	}
+
+	for _, tt := range tests {
+		location, ok := sm.GetSourceLocation(tt.address)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, location)
+	}
+
+	_, ok := sm.GetSourceLocation(0x8008)
+	assert.False(t, ok)
+
+	// Relative paths are resolved from the debug info file directory
+	lines, err := sm.GetSourceLines("main.s")
+	assert.NoError(t, err)
+	assert.Equal(t, "        print", lines[3])
+
+	_, err = sm.GetSourceLines("macros.inc")
+	assert.Error(t, err)
+}
+
+func TestLoadSourceMap_Errors(t *testing.T) {
+	_, err := LoadSourceMap(writeSymbolFile(t, "rom.lbl", testViceLabels))
+	assert.True(t, errors.Is(err, ErrNoLineInformation))
+
+	_, err = LoadSourceMap(writeSymbolFile(t, "rom.dbg", testDebugInfo))
+	assert.True(t, errors.Is(err, ErrNoLineInformation))
+
+	_, err = LoadSourceMap(writeSymbolFile(t, "rom.dbg", "version\tmajor=2,minor=0\nline\tid=0,file=0,line=3,span=7\n"))
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNoLineInformation))
+
+	_, err = LoadSourceMap(filepath.Join(t.TempDir(), "missing.dbg"))
+	assert.Error(t, err)
+}
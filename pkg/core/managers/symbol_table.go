@@ -0,0 +1,306 @@
+package managers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// symbolTable holds the labels of the program being debugged. Labels are looked up
+// by exact name first and then ignoring case, so they can be typed in the forms
+// without remembering the exact capitalization used in the source.
+type symbolTable struct {
+	labels    map[uint16]string
+	addresses map[string]uint16
+	lowercase map[string]uint16
+}
+
+// newSymbolTable creates a new empty symbol table.
+//
+// Returns:
+//   - A pointer to the initialized symbolTable
+func newSymbolTable() *symbolTable {
+	return &symbolTable{
+		labels:    make(map[uint16]string),
+		addresses: make(map[string]uint16),
+		lowercase: make(map[string]uint16),
+	}
+}
+
+// NewSymbolTable creates a new empty symbol table.
+//
+// Returns:
+//   - A pointer to the initialized SymbolTable
+func NewSymbolTable() core.SymbolTable {
+	return newSymbolTable()
+}
+
+// LoadSymbolTable loads the labels from a ld65 debug info file (generated with the
+// --dbgfile option) or from a VICE label file (generated with the -Ln option).
+// The format is detected from the file content.
+//
+// Parameters:
+//   - path: The path of the file to load
+//
+// Returns:
+//   - The symbol table with the labels loaded from the file
+//   - An error if the file can't be read, is not valid or contains no labels
+func LoadSymbolTable(path string) (core.SymbolTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	table := newSymbolTable()
+
+	if isDebugInfo(data) {
+		err = table.loadDebugInfo(bytes.NewReader(data))
+	} else {
+		err = table.loadViceLabels(bytes.NewReader(data))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if table.GetSymbolCount() == 0 {
+		return nil, fmt.Errorf("%s: no symbols found", path)
+	}
+
+	return table, nil
+}
+
+// AddSymbol defines a label for the specified address. If the label was already defined
+// it keeps its original address.
+//
+// Parameters:
+//   - label: The label name
+//   - address: The address of the label
+func (t *symbolTable) AddSymbol(label string, address uint16) {
+	if _, exists := t.addresses[label]; exists {
+		return
+	}
+
+	t.addresses[label] = address
+
+	if _, exists := t.lowercase[strings.ToLower(label)]; !exists {
+		t.lowercase[strings.ToLower(label)] = address
+	}
+
+	if _, exists := t.labels[address]; !exists {
+		t.labels[address] = label
+	}
+}
+
+// GetLabel returns the label shown for the specified address.
+//
+// Parameters:
+//   - address: The address to look up
+//
+// Returns:
+//   - The label of the address
+//   - true if the address has a label, false otherwise
+func (t *symbolTable) GetLabel(address uint16) (string, bool) {
+	label, ok := t.labels[address]
+	return label, ok
+}
+
+// GetAddress returns the address of the specified label.
+//
+// Parameters:
+//   - label: The label to look up
+//
+// Returns:
+//   - The address of the label
+//   - true if the label is defined, false otherwise
+func (t *symbolTable) GetAddress(label string) (uint16, bool) {
+	if address, ok := t.addresses[label]; ok {
+		return address, true
+	}
+
+	address, ok := t.lowercase[strings.ToLower(label)]
+	return address, ok
+}
+
+// GetSymbolCount returns the number of labels defined.
+//
+// Returns:
+//   - The number of labels in the table
+func (t *symbolTable) GetSymbolCount() int {
+	return len(t.addresses)
+}
+
+/************************************************************************************
+* ld65 debug info files
+*************************************************************************************/
+
+// isDebugInfo returns true if the data looks like a ld65 debug info file, which
+// always starts with the version line.
+func isDebugInfo(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("version"))
+}
+
+// loadDebugInfo adds the symbols of a ld65 debug info file. Every code or data label
+// is added, but equates are only added when they are absolute addresses (such as I/O
+// registers) as the zero page ones are usually constants or bit masks.
+//
+// The symbols are defined in lines like:
+//
+//	sym	id=3,name="lcd_wait",addrsize=absolute,scope=0,def=12,ref=20,val=0x8012,seg=1,type=lab
+func (t *symbolTable) loadDebugInfo(reader io.Reader) error {
+	type equate struct {
+		label   string
+		address uint16
+	}
+
+	equates := make([]equate, 0)
+
+	err := readDebugInfo(reader, func(kind string, record debugInfoRecord) error {
+		// Imports have no value, they are defined in other module
+		if _, ok := record["val"]; kind != "sym" || !ok {
+			return nil
+		}
+
+		value, err := record.number("val")
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case record["type"] == "lab":
+			t.AddSymbol(record["name"], uint16(value))
+		case record["type"] == "equ" && record["addrsize"] == "absolute":
+			equates = append(equates, equate{record["name"], uint16(value)})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Equates are added last so labels are preferred when both share an address
+	for _, equate := range equates {
+		t.AddSymbol(equate.label, equate.address)
+	}
+
+	return nil
+}
+
+// debugInfoRecord holds the key=value attributes of a line of a ld65 debug info file.
+type debugInfoRecord map[string]string
+
+// number returns the numeric value of the attribute, values can be decimal or
+// hexadecimal with the "0x" prefix.
+func (r debugInfoRecord) number(key string) (int, error) {
+	value, err := strconv.ParseInt(r[key], 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", key, r[key])
+	}
+
+	return int(value), nil
+}
+
+// readDebugInfo parses every line of a ld65 debug info file calling the handler with
+// the kind of line (the first word, such as "sym" or "line") and its attributes.
+func readDebugInfo(reader io.Reader, handler func(kind string, record debugInfoRecord) error) error {
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		kind, attributes, _ := strings.Cut(strings.TrimSpace(scanner.Text()), "\t")
+		if kind == "" {
+			continue
+		}
+
+		record, err := parseDebugInfoRecord(attributes)
+		if err == nil {
+			err = handler(kind, record)
+		}
+
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// parseDebugInfoRecord parses the comma separated list of key=value attributes
+// of a debug info line. Values can be quoted strings that contain commas.
+func parseDebugInfoRecord(attributes string) (debugInfoRecord, error) {
+	record := debugInfoRecord{}
+
+	for attributes != "" {
+		key, rest, found := strings.Cut(attributes, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid attribute %q", attributes)
+		}
+
+		var value string
+
+		if strings.HasPrefix(rest, "\"") {
+			end := strings.Index(rest[1:], "\"")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in attribute %q", key)
+			}
+
+			value = rest[1 : end+1]
+			rest = strings.TrimPrefix(rest[end+2:], ",")
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		record[key] = value
+		attributes = rest
+	}
+
+	return record, nil
+}
+
+/************************************************************************************
+* VICE label files
+*************************************************************************************/
+
+// loadViceLabels adds the symbols of a VICE label file. Labels are defined in lines
+// like "al 008012 .lcd_wait", the address can also have a memory space prefix as in
+// "al C:8012 .lcd_wait". Blank lines and other VICE commands are ignored.
+func (t *symbolTable) loadViceLabels(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "al" {
+			continue
+		}
+
+		if len(fields) != 3 {
+			return fmt.Errorf("line %d: expected \"al <address> .<label>\"", lineNumber)
+		}
+
+		text := fields[1]
+		if _, address, found := strings.Cut(text, ":"); found {
+			text = address
+		}
+
+		address, err := strconv.ParseUint(text, 16, 32)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid address %q", lineNumber, fields[1])
+		}
+
+		t.AddSymbol(strings.TrimPrefix(fields[2], "."), uint16(address))
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,134 @@
+package managers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDebugInfo string = `version	major=2,minor=0
+info	csym=0,file=1,lib=0,line=4,mod=1,scope=1,seg=2,span=4,sym=7,type=3
+file	id=0,name="lcd, test.s",size=120,mtime=0x5F000000,mod=0
+seg	id=0,name="CODE",start=0x008000,size=0x0020,addrsize=absolute,type=ro,oname="rom.bin",ooffs=0
+sym	id=0,name="reset",addrsize=absolute,scope=0,def=0,ref=1,val=0x8000,seg=0,type=lab
+sym	id=1,name="lcd_wait",addrsize=absolute,scope=0,def=2,ref=3,val=0x8012,seg=0,type=lab
+sym	id=2,name="@busy",addrsize=absolute,parent=1,def=4,val=0x8014,seg=0,type=lab
+sym	id=3,name="PORTB",addrsize=absolute,scope=0,def=5,val=0x6000,type=equ
+sym	id=4,name="E",addrsize=zeropage,scope=0,def=6,val=0x80,type=equ
+sym	id=5,name="START",addrsize=absolute,scope=0,def=7,val=0x8000,type=equ
+sym	id=6,name="print",addrsize=absolute,scope=0,ref=8,type=imp
+`
+
+const testViceLabels string = `al 008000 .reset
+al C:8012 .lcd_wait
+
+al 006000 .PORTB
+`
+
+func writeSymbolFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestSymbolTable(t *testing.T) {
+	st := NewSymbolTable()
+
+	st.AddSymbol("reset", 0x8000)
+	st.AddSymbol("start", 0x8000)
+	st.AddSymbol("reset", 0x9000)
+
+	assert.Equal(t, 2, st.GetSymbolCount())
+
+	label, ok := st.GetLabel(0x8000)
+	assert.True(t, ok)
+	assert.Equal(t, "reset", label)
+
+	_, ok = st.GetLabel(0x9000)
+	assert.False(t, ok)
+
+	address, ok := st.GetAddress("start")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x8000), address)
+
+	// Lookup falls back to ignore case
+	address, ok = st.GetAddress("RESET")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x8000), address)
+
+	_, ok = st.GetAddress("missing")
+	assert.False(t, ok)
+}
+
+func TestLoadSymbolTable_DebugInfo(t *testing.T) {
+	st, err := LoadSymbolTable(writeSymbolFile(t, "rom.dbg", testDebugInfo))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		label   string
+		address uint16
+	}{
+		{"reset", 0x8000},
+		{"lcd_wait", 0x8012},
+		{"@busy", 0x8014},
+		{"PORTB", 0x6000},
+		{"START", 0x8000},
+	}
+
+	for _, tt := range tests {
+		address, ok := st.GetAddress(tt.label)
+		assert.True(t, ok, tt.label)
+		assert.Equal(t, tt.address, address, tt.label)
+	}
+
+	// Zero page equates and imports are not loaded
+	_, ok := st.GetAddress("E")
+	assert.False(t, ok)
+	_, ok = st.GetAddress("print")
+	assert.False(t, ok)
+
+	// Labels are preferred over equates
+	label, _ := st.GetLabel(0x8000)
+	assert.Equal(t, "reset", label)
+}
+
+func TestLoadSymbolTable_ViceLabels(t *testing.T) {
+	st, err := LoadSymbolTable(writeSymbolFile(t, "rom.lbl", testViceLabels))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, st.GetSymbolCount())
+
+	label, ok := st.GetLabel(0x8012)
+	assert.True(t, ok)
+	assert.Equal(t, "lcd_wait", label)
+
+	address, ok := st.GetAddress("PORTB")
+	assert.True(t, ok)
+	assert.Equal(t, uint16(0x6000), address)
+}
+
+func TestLoadSymbolTable_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Invalid VICE address", "al XYZ .reset\n"},
+		{"Missing VICE label", "al 8000\n"},
+		{"Invalid debug info value", "version\tmajor=2,minor=0\nsym\tid=0,name=\"reset\",val=zz,type=lab\n"},
+		{"Unterminated debug info string", "version\tmajor=2,minor=0\nsym\tid=0,name=\"reset,val=0x8000\n"},
+		{"No symbols", "; empty file\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSymbolTable(writeSymbolFile(t, "symbols", tt.content))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := LoadSymbolTable(filepath.Join(t.TempDir(), "missing.dbg"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,318 @@
+package managers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// TraceProcessor is the part of the processor observed by the trace logger.
+type TraceProcessor interface {
+	components.CpuRegisters
+	components.CpuState
+}
+
+// traceLine holds the values of the instruction being traced until its line is written.
+type traceLine struct {
+	cycle       uint64
+	address     uint16
+	bytes       [3]uint8
+	size        uint16
+	instruction components.CpuInstructionData
+	a, x, y     uint8
+	sp, p       uint8
+
+	// Bus access to the effective address of the instruction, if any
+	access    core.BusCycle
+	hasAccess bool
+}
+
+// traceLogger writes one line per instruction executed by the processor. The line of an
+// instruction is written when the next opcode is fetched, once the effective address
+// accessed by the instruction is known.
+type traceLogger struct {
+	processor TraceProcessor
+	peek      func(address uint16) uint8
+
+	mutex   sync.Mutex    // Guards the writer and the pending line
+	enabled atomic.Bool   // Set with the writer, so the cycles aren't traced without a file
+	writer  *bufio.Writer // Trace file, nil when not tracing
+
+	line    traceLine
+	pending bool
+}
+
+// newTraceLogger creates a new trace logger.
+//
+// Parameters:
+//   - processor: The processor whose instructions are traced
+//   - peek: Function that returns the value of a memory address without side effects
+//
+// Returns:
+//   - A pointer to the initialized traceLogger
+func newTraceLogger(processor TraceProcessor, peek func(address uint16) uint8) *traceLogger {
+	return &traceLogger{
+		processor: processor,
+		peek:      peek,
+	}
+}
+
+// NewTraceLogger creates a new trace logger. It doesn't write anything until a writer is set.
+//
+// Parameters:
+//   - processor: The processor whose instructions are traced
+//   - peek: Function that returns the value of a memory address without side effects,
+//     it is used to read the bytes of the instructions
+//
+// Returns:
+//   - A pointer to the initialized TraceLogger
+func NewTraceLogger(processor TraceProcessor, peek func(address uint16) uint8) core.TraceLogger {
+	return newTraceLogger(processor, peek)
+}
+
+// SetWriter sets where the log is written, nil stops logging. The line of the instruction
+// in progress is written to the previous writer before flushing it.
+//
+// Parameters:
+//   - writer: Where the log is written, nil to stop logging
+//
+// Returns:
+//   - An error if the pending output can't be written to the previous writer
+func (tl *traceLogger) SetWriter(writer io.Writer) error {
+	tl.mutex.Lock()
+	defer tl.mutex.Unlock()
+
+	var err error
+	if tl.writer != nil {
+		err = tl.writePendingLine()
+
+		if flushErr := tl.writer.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+
+	tl.pending = false
+	tl.writer = nil
+
+	if writer != nil {
+		tl.writer = bufio.NewWriter(writer)
+	}
+
+	tl.enabled.Store(writer != nil)
+
+	return err
+}
+
+// IsEnabled returns true if the log is being written.
+//
+// Returns:
+//   - true if a writer is set
+func (tl *traceLogger) IsEnabled() bool {
+	return tl.enabled.Load()
+}
+
+// TraceCycle observes the cycle executed by the processor. On an opcode fetch the line of
+// the previous instruction is written and the new instruction is recorded, on other cycles
+// the bus access is kept if it can be the effective address of the instruction. Interrupts
+// complete the line of the instruction they follow.
+//
+// Parameters:
+//   - cycle: The number of the cycle executed
+//   - access: The bus access done by the processor in the cycle
+//
+// Returns:
+//   - An error if the log can't be written
+func (tl *traceLogger) TraceCycle(cycle uint64, access core.BusCycle) error {
+	if !tl.enabled.Load() {
+		return nil
+	}
+
+	tl.mutex.Lock()
+	defer tl.mutex.Unlock()
+
+	if tl.writer == nil {
+		return nil
+	}
+
+	if tl.processor.IsReadingOpcode() {
+		err := tl.writePendingLine()
+		tl.startLine(cycle)
+		return err
+	}
+
+	if !tl.pending {
+		return nil
+	}
+
+	switch tl.processor.GetCurrentAddressMode().Name() {
+	case cpu.AddressModeIRQ, cpu.AddressModeNMI, cpu.AddressModeReset:
+		return tl.writePendingLine()
+	}
+
+	tl.observeAccess(access)
+
+	return nil
+}
+
+// startLine records the instruction whose opcode was fetched in the cycle. After the opcode
+// fetch the program counter points to the byte following the opcode and the registers still
+// have the values set by the previous instruction.
+func (tl *traceLogger) startLine(cycle uint64) {
+	processor := tl.processor
+	instruction := processor.GetCurrentInstruction()
+	address := processor.GetProgramCounter() - 1
+
+	tl.line = traceLine{
+		cycle:       cycle,
+		address:     address,
+		size:        uint16(cpu.GetAddressMode(instruction.AddressMode()).MemSize()),
+		instruction: instruction,
+		a:           processor.GetAccumulatorRegister(),
+		x:           processor.GetXRegister(),
+		y:           processor.GetYRegister(),
+		sp:          processor.GetStackPointer(),
+		p:           processor.GetProcessorStatusRegister().GetValue(),
+	}
+
+	for i := range tl.line.size {
+		tl.line.bytes[i] = tl.peek(address + i)
+	}
+
+	tl.pending = true
+}
+
+// observeAccess keeps the bus access if it can be the effective address of the instruction.
+// Reads of the bytes of the instruction are ignored. Indirect and indexed modes read pointers
+// and do dummy accesses before the final one, so the last access is kept, except for BBR and
+// BBS where the access to the tested byte is followed by the branch.
+func (tl *traceLogger) observeAccess(access core.BusCycle) {
+	line := &tl.line
+
+	if !hasEffectiveAddress(line.instruction.AddressMode()) {
+		return
+	}
+
+	if access.Address-line.address < line.size {
+		return
+	}
+
+	if line.hasAccess && line.instruction.AddressMode() == cpu.AddressModeRelativeExtended {
+		return
+	}
+
+	line.access = access
+	line.hasAccess = true
+}
+
+// writePendingLine writes the line of the instruction in progress, if any.
+func (tl *traceLogger) writePendingLine() error {
+	if !tl.pending {
+		return nil
+	}
+
+	tl.pending = false
+
+	line := &tl.line
+
+	bytes := make([]string, 0, len(line.bytes))
+	for i := range line.size {
+		bytes = append(bytes, fmt.Sprintf("%02X", line.bytes[i]))
+	}
+
+	_, err := fmt.Fprintf(tl.writer, "%04X  %-8s  %-31s A:%02X X:%02X Y:%02X P:%02X SP:%02X CYC:%d\n",
+		line.address,
+		strings.Join(bytes, " "),
+		disassembleTraceLine(line),
+		line.a, line.x, line.y, line.p, line.sp,
+		line.cycle,
+	)
+
+	return err
+}
+
+// disassembleTraceLine returns the instruction of the line in assembler followed by the
+// effective address and the value read or written. Branches show their target address.
+func disassembleTraceLine(line *traceLine) string {
+	sb := strings.Builder{}
+
+	addressMode := line.instruction.AddressMode()
+	addressModeDetails := cpu.GetAddressMode(addressMode)
+	operand := uint16(line.bytes[1]) | uint16(line.bytes[2])<<8
+
+	sb.WriteString(string(line.instruction.Mnemonic()))
+
+	switch {
+	case addressMode == cpu.AddressModeAccumulator:
+		sb.WriteString(" A")
+
+	case addressMode == cpu.AddressModeBreak:
+		// BRK is internally a 2 byte instruction but the signature byte is not an operand
+
+	case addressMode == cpu.AddressModeRelative:
+		fmt.Fprintf(&sb, " $%04X", branchTarget(line.address+2, line.bytes[1]))
+
+	case addressMode == cpu.AddressModeRelativeExtended:
+		fmt.Fprintf(&sb, " $%02X, $%04X", line.bytes[1], branchTarget(line.address+3, line.bytes[2]))
+
+	case line.size == 2:
+		sb.WriteString(" ")
+		fmt.Fprintf(&sb, addressModeDetails.Format(), line.bytes[1])
+
+	case line.size == 3:
+		sb.WriteString(" ")
+		fmt.Fprintf(&sb, addressModeDetails.Format(), operand)
+	}
+
+	if line.hasAccess {
+		if isIndexedOrIndirect(addressMode) {
+			fmt.Fprintf(&sb, " @ %04X", line.access.Address)
+		}
+
+		fmt.Fprintf(&sb, " = %02X", line.access.Data)
+	}
+
+	return sb.String()
+}
+
+// branchTarget returns the address to which a branch jumps given the address of the next
+// instruction and the relative offset.
+func branchTarget(next uint16, offset uint8) uint16 {
+	return next + uint16(int8(offset))
+}
+
+// hasEffectiveAddress returns true if instructions with the address mode read or write a
+// memory operand. Jumps and stack operations are not included.
+func hasEffectiveAddress(addressMode components.AddressMode) bool {
+	switch addressMode {
+	case cpu.AddressModeZeroPage, cpu.AddressModeZeroPageRMW, cpu.AddressModeZeroPageW,
+		cpu.AddressModeAbsolute, cpu.AddressModeAbsoluteRMW, cpu.AddressModeAbsoluteW,
+		cpu.AddressModeRelativeExtended:
+		return true
+	}
+
+	return isIndexedOrIndirect(addressMode)
+}
+
+// isIndexedOrIndirect returns true if the effective address of instructions with the address
+// mode is not the operand, in which case the trace shows it.
+func isIndexedOrIndirect(addressMode components.AddressMode) bool {
+	switch addressMode {
+	case cpu.AddressModeZeroPageX, cpu.AddressModeZeroPageXRMW, cpu.AddressModeZeroPageXW,
+		cpu.AddressModeZeroPageY, cpu.AddressModeZeroPageYW,
+		cpu.AddressModeAbsoluteX, cpu.AddressModeAbsoluteXRMW, cpu.AddressModeAbsoluteXW,
+		cpu.AddressModeAbsoluteY, cpu.AddressModeAbsoluteYRMW, cpu.AddressModeAbsoluteYW,
+		cpu.AddressModeIndirectZeroPage, cpu.AddressModeIndirectZeroPageW,
+		cpu.AddressModeZeroPageIndexedIndirectX, cpu.AddressModeZeroPageIndexedIndirectXRMW, cpu.AddressModeZeroPageIndexedIndirectXW,
+		cpu.AddressModeZeroPageIndirectIndexedY, cpu.AddressModeZeroPageIndirectIndexedYRMW, cpu.AddressModeZeroPageIndirectIndexedYW:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package managers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/components/memory"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test program:
+//
+//	0400: LDX #$02
+//	0402: LDA $10, X
+//	0404: STA $0200
+//	0407: ASL A
+//	0408: BNE $0400
+var traceTestProgram []uint8 = []uint8{0xA2, 0x02, 0xB5, 0x10, 0x8D, 0x00, 0x02, 0x0A, 0xD0, 0xF6}
+
+type traceTestComputer struct {
+	processor components.Cpu65C02
+	ram       components.Memory
+}
+
+func newTraceTestComputer() *traceTestComputer {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+
+	alwaysHighLine := buses.NewStandaloneLine(true)
+	alwaysLowLine := buses.NewStandaloneLine(false)
+	writeEnableLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(alwaysLowLine)
+	ram.OutputEnable().Connect(alwaysLowLine)
+
+	processor := cpu.NewCpu65C02S()
+	processor.AddressBus().Connect(addressBus)
+	processor.DataBus().Connect(dataBus)
+	processor.BusEnable().Connect(alwaysHighLine)
+	processor.ReadWrite().Connect(writeEnableLine)
+	processor.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	processor.Sync().Connect(buses.NewStandaloneLine(false))
+	processor.Ready().Connect(alwaysHighLine)
+	processor.VectorPull().Connect(buses.NewStandaloneLine(false))
+	processor.SetOverflow().Connect(alwaysHighLine)
+	processor.Reset().Connect(alwaysHighLine)
+	processor.InterruptRequest().Connect(alwaysHighLine)
+	processor.NonMaskableInterrupt().Connect(alwaysHighLine)
+
+	for i, value := range traceTestProgram {
+		ram.Poke(0x0400+uint16(i), value)
+	}
+	ram.Poke(0x0012, 0x42)
+
+	processor.ForceProgramCounter(0x0400)
+
+	return &traceTestComputer{processor: processor, ram: ram}
+}
+
+func (c *traceTestComputer) peek(address uint16) uint8 {
+	return c.ram.Peek(uint32(address))
+}
+
+// run executes the cycles passing the bus access of each one to the trace logger
+func (c *traceTestComputer) run(t *testing.T, tl core.TraceLogger, cycles int) {
+	context := common.NewStepContext()
+
+	for range cycles {
+		c.processor.Tick(&context)
+		c.ram.Tick(&context)
+		c.processor.PostTick(&context)
+
+		assert.NoError(t, tl.TraceCycle(context.Cycle, core.BusCycle{
+			Address: c.processor.AddressBus().Read(),
+			Data:    c.processor.DataBus().Read(),
+			Write:   c.processor.ReadWrite().Enabled(),
+			Fetch:   c.processor.IsReadingOpcode(),
+		}))
+
+		context.NextCycle()
+	}
+}
+
+func TestTraceLogger_WritesOneLinePerInstruction(t *testing.T) {
+	computer := newTraceTestComputer()
+	tl := NewTraceLogger(computer.processor, computer.peek)
+
+	var output bytes.Buffer
+	assert.NoError(t, tl.SetWriter(&output))
+	assert.True(t, tl.IsEnabled())
+
+	// LDX (2) + LDA (4) + STA (4) + ASL (2) + BNE taken (3) and the fetch of LDX again
+	computer.run(t, tl, 16)
+
+	// Stopping the trace writes the line of the instruction in progress
+	assert.NoError(t, tl.SetWriter(nil))
+	assert.False(t, tl.IsEnabled())
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	assert.Len(t, lines, 6)
+
+	expected := []string{
+		"0400  A2 02     LDX #$02                        A:00 X:00 Y:00 ",
+		"0402  B5 10     LDA $10, X @ 0012 = 42          A:00 X:02 Y:00 ",
+		"0404  8D 00 02  STA $0200 = 42                  A:42 X:02 Y:00 ",
+		"0407  0A        ASL A                           A:42 X:02 Y:00 ",
+		"0408  D0 F6     BNE $0400                       A:84 X:02 Y:00 ",
+		"0400  A2 02     LDX #$02                        A:84 X:02 Y:00 ",
+	}
+
+	for i, line := range expected {
+		assert.True(t, strings.HasPrefix(lines[i], line), "line %d: %q", i, lines[i])
+	}
+
+	assert.True(t, strings.HasSuffix(lines[0], " CYC:0"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[5], " CYC:15"), lines[5])
+}
+
+func TestTraceLogger_StartsOnNextOpcodeFetch(t *testing.T) {
+	computer := newTraceTestComputer()
+	tl := NewTraceLogger(computer.processor, computer.peek)
+
+	// Executes LDX and the first cycle of LDA before tracing
+	computer.run(t, tl, 3)
+
+	var output bytes.Buffer
+	assert.NoError(t, tl.SetWriter(&output))
+
+	// Completes LDA and executes STA
+	computer.run(t, tl, 7)
+	assert.NoError(t, tl.SetWriter(nil))
+
+	assert.True(t, strings.HasPrefix(output.String(), "0404  8D 00 02  STA $0200 = 42"), output.String())
+	assert.Equal(t, 1, strings.Count(output.String(), "\n"))
+}
@@ -0,0 +1,188 @@
+package managers
+
+import (
+	"slices"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Maximum number of hits kept by the watchpoint manager
+const maxWatchpointHits int = 100
+
+// watchpoint holds a watched range and the last values observed for each address
+// in the range. The values are used to report the old value on writes, as by the
+// time the access is detected the memory has already been updated.
+type watchpoint struct {
+	core.Watchpoint
+	values []uint8
+}
+
+// watchpointManager manages memory watchpoints for debugging purposes.
+// It provides functionality to add and remove watched address ranges and to check
+// the processor bus accesses against them.
+type watchpointManager struct {
+	watchpoints []*watchpoint
+	hits        []core.WatchpointHit
+
+	peek func(address uint16) uint8
+}
+
+// newWatchpointManager creates a new watchpoint manager.
+//
+// Parameters:
+//   - peek: Function that returns the value of a memory address without side effects
+//
+// Returns:
+//   - A pointer to the initialized watchpointManager
+func newWatchpointManager(peek func(address uint16) uint8) *watchpointManager {
+	return &watchpointManager{
+		watchpoints: make([]*watchpoint, 0),
+		hits:        make([]core.WatchpointHit, 0, maxWatchpointHits),
+		peek:        peek,
+	}
+}
+
+// NewWatchpointManager creates a new watchpoint manager.
+//
+// Parameters:
+//   - peek: Function that returns the value of a memory address without side effects,
+//     it is used to know the value of the watched addresses before they are written
+//
+// Returns:
+//   - A pointer to the initialized WatchpointManager
+func NewWatchpointManager(peek func(address uint16) uint8) core.WatchpointManager {
+	return newWatchpointManager(peek)
+}
+
+// AddWatchpoint watches the range of addresses for the specified access type.
+// If the start address is greater than the end address they are swapped.
+//
+// Parameters:
+//   - startAddress: The first address of the watched range
+//   - endAddress: The last address of the watched range (included)
+//   - watchType: The type of access that triggers the watchpoint
+func (wm *watchpointManager) AddWatchpoint(startAddress uint16, endAddress uint16, watchType core.WatchpointType) {
+	if startAddress > endAddress {
+		startAddress, endAddress = endAddress, startAddress
+	}
+
+	wp := &watchpoint{
+		Watchpoint: core.Watchpoint{
+			StartAddress: startAddress,
+			EndAddress:   endAddress,
+			Type:         watchType,
+		},
+		values: make([]uint8, int(endAddress-startAddress)+1),
+	}
+
+	if wm.peek != nil {
+		for i := range wp.values {
+			wp.values[i] = wm.peek(startAddress + uint16(i))
+		}
+	}
+
+	wm.watchpoints = append(wm.watchpoints, wp)
+}
+
+// RemoveWatchpointByIndex removes the watchpoint at the specified index.
+// If the index is out of bounds, this method has no effect.
+//
+// Parameters:
+//   - index: The index of the watchpoint to remove
+func (wm *watchpointManager) RemoveWatchpointByIndex(index int) {
+	if index >= 0 && index < len(wm.watchpoints) {
+		wm.watchpoints = slices.Delete(wm.watchpoints, index, index+1)
+	}
+}
+
+// GetWatchpoints returns a copy of all watchpoints.
+//
+// Returns:
+//   - A slice containing all watchpoints
+func (wm *watchpointManager) GetWatchpoints() []core.Watchpoint {
+	result := make([]core.Watchpoint, len(wm.watchpoints))
+	for i, wp := range wm.watchpoints {
+		result[i] = wp.Watchpoint
+	}
+	return result
+}
+
+// GetWatchpointCount returns the number of active watchpoints.
+//
+// Returns:
+//   - The number of watchpoints currently set
+func (wm *watchpointManager) GetWatchpointCount() int {
+	return len(wm.watchpoints)
+}
+
+// ClearAllWatchpoints removes all watchpoints.
+func (wm *watchpointManager) ClearAllWatchpoints() {
+	wm.watchpoints = wm.watchpoints[:0]
+}
+
+// CheckAccess must be called on every bus access done by the processor. If the access
+// triggers a watchpoint the hit is recorded. The last value known for the address is updated
+// on every watchpoint that contains it, even if the access type doesn't trigger it.
+//
+// Parameters:
+//   - address: The address accessed
+//   - write: True if the processor is writing to the address
+//   - value: The value read or written
+//   - programCounter: The address of the instruction performing the access
+//   - cycle: The current emulation cycle
+//
+// Returns:
+//   - true if the access triggered a watchpoint, false otherwise
+func (wm *watchpointManager) CheckAccess(address uint16, write bool, value uint8, programCounter uint16, cycle uint64) bool {
+	accessType := core.WatchRead
+	if write {
+		accessType = core.WatchWrite
+	}
+
+	triggered := false
+
+	for _, wp := range wm.watchpoints {
+		if address < wp.StartAddress || address > wp.EndAddress {
+			continue
+		}
+
+		offset := address - wp.StartAddress
+		oldValue := wp.values[offset]
+		wp.values[offset] = value
+
+		if !triggered && wp.Type&accessType != 0 {
+			triggered = true
+
+			wm.addHit(core.WatchpointHit{
+				Watchpoint:     wp.Watchpoint,
+				Address:        address,
+				Write:          write,
+				ProgramCounter: programCounter,
+				OldValue:       oldValue,
+				NewValue:       value,
+				Cycle:          cycle,
+			})
+		}
+	}
+
+	return triggered
+}
+
+// GetHits returns the most recent watchpoint hits, oldest first.
+//
+// Returns:
+//   - A slice containing up to the last 100 hits
+func (wm *watchpointManager) GetHits() []core.WatchpointHit {
+	result := make([]core.WatchpointHit, len(wm.hits))
+	copy(result, wm.hits)
+	return result
+}
+
+// addHit records the hit discarding the oldest one if the maximum is reached.
+func (wm *watchpointManager) addHit(hit core.WatchpointHit) {
+	if len(wm.hits) >= maxWatchpointHits {
+		wm.hits = slices.Delete(wm.hits, 0, 1)
+	}
+
+	wm.hits = append(wm.hits, hit)
+}
@@ -0,0 +1,96 @@
+package managers
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWatchpointManager(memory []uint8) core.WatchpointManager {
+	return NewWatchpointManager(func(address uint16) uint8 {
+		return memory[address]
+	})
+}
+
+func TestAddRemoveWatchpoints(t *testing.T) {
+	wm := newTestWatchpointManager(make([]uint8, 0x10000))
+
+	wm.AddWatchpoint(0x0200, 0x0200, core.WatchWrite)
+	wm.AddWatchpoint(0x0310, 0x0300, core.WatchRead)
+	wm.AddWatchpoint(0x6000, 0x600F, core.WatchReadWrite)
+
+	assert.Equal(t, 3, wm.GetWatchpointCount())
+	assert.Equal(t, core.Watchpoint{StartAddress: 0x0300, EndAddress: 0x0310, Type: core.WatchRead}, wm.GetWatchpoints()[1])
+
+	wm.RemoveWatchpointByIndex(1)
+	assert.Equal(t, 2, wm.GetWatchpointCount())
+	assert.Equal(t, uint16(0x6000), wm.GetWatchpoints()[1].StartAddress)
+
+	// Out of bounds should have no effect
+	wm.RemoveWatchpointByIndex(5)
+	wm.RemoveWatchpointByIndex(-1)
+	assert.Equal(t, 2, wm.GetWatchpointCount())
+
+	wm.ClearAllWatchpoints()
+	assert.Equal(t, 0, wm.GetWatchpointCount())
+}
+
+func TestCheckAccess(t *testing.T) {
+	memory := make([]uint8, 0x10000)
+	memory[0x0200] = 0x10
+	memory[0x0301] = 0x20
+
+	wm := newTestWatchpointManager(memory)
+	wm.AddWatchpoint(0x0200, 0x0200, core.WatchWrite)
+	wm.AddWatchpoint(0x0300, 0x030F, core.WatchRead)
+
+	// Reads don't trigger write watchpoints but update the known value
+	assert.False(t, wm.CheckAccess(0x0200, false, 0x11, 0x8000, 1))
+
+	// Writes report the old and new values
+	assert.True(t, wm.CheckAccess(0x0200, true, 0x12, 0x8003, 2))
+
+	// Writes don't trigger read watchpoints
+	assert.False(t, wm.CheckAccess(0x0301, true, 0x21, 0x8006, 3))
+	assert.True(t, wm.CheckAccess(0x0301, false, 0x21, 0x8009, 4))
+
+	// Accesses outside the ranges are ignored
+	assert.False(t, wm.CheckAccess(0x0310, false, 0x00, 0x800C, 5))
+
+	hits := wm.GetHits()
+	assert.Equal(t, 2, len(hits))
+
+	assert.Equal(t, uint16(0x0200), hits[0].Address)
+	assert.True(t, hits[0].Write)
+	assert.Equal(t, uint16(0x8003), hits[0].ProgramCounter)
+	assert.Equal(t, uint8(0x11), hits[0].OldValue)
+	assert.Equal(t, uint8(0x12), hits[0].NewValue)
+	assert.Equal(t, uint64(2), hits[0].Cycle)
+
+	assert.Equal(t, uint16(0x0301), hits[1].Address)
+	assert.False(t, hits[1].Write)
+	assert.Equal(t, uint16(0x8009), hits[1].ProgramCounter)
+	assert.Equal(t, uint8(0x21), hits[1].OldValue)
+	assert.Equal(t, uint8(0x21), hits[1].NewValue)
+}
+
+func TestWatchpointHitsAreLimited(t *testing.T) {
+	wm := newTestWatchpointManager(make([]uint8, 0x10000))
+	wm.AddWatchpoint(0x0000, 0x00FF, core.WatchReadWrite)
+
+	for i := range maxWatchpointHits + 10 {
+		assert.True(t, wm.CheckAccess(uint16(i%0x100), true, uint8(i), 0x8000, uint64(i)))
+	}
+
+	hits := wm.GetHits()
+	assert.Equal(t, maxWatchpointHits, len(hits))
+	assert.Equal(t, uint64(10), hits[0].Cycle)
+	assert.Equal(t, uint64(maxWatchpointHits+9), hits[len(hits)-1].Cycle)
+}
+
+func TestWatchpointTypeString(t *testing.T) {
+	assert.Equal(t, "R", core.WatchRead.String())
+	assert.Equal(t, "W", core.WatchWrite.String())
+	assert.Equal(t, "RW", core.WatchReadWrite.String())
+}
@@ -0,0 +1,302 @@
+package managers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Printable characters used to build the identifiers of the signals in the dump
+const (
+	firstIdentifierChar byte = '!'
+	identifierChars     int  = '~' - '!' + 1
+)
+
+// waveformRecorder writes the values of the signals in Value Change Dump format. The header
+// declares one variable per signal and is followed by the values of all the signals at the
+// first cycle sampled and then, for each cycle where any signal changed, the time of the cycle
+// and the new values:
+//
+//	$timescale 1 ns $end
+//	$scope module beneater $end
+//	$var wire 16 ! A [15:0] $end
+//	$var wire 1 " RWB $end
+//	$upscope $end
+//	$enddefinitions $end
+//	#0
+//	$dumpvars
+//	b1000000000000000 !
+//	1"
+//	$end
+//	#1000
+//	b1000000000000001 !
+//
+// The time is relative to the first cycle sampled and never goes backwards, if a cycle has the
+// same time or an earlier one than the previous, for example when the emulation is rewound, it
+// is written 1 ns after the previous one.
+type waveformRecorder struct {
+	scope   string
+	signals []core.WaveformSignal
+	ids     []string
+
+	mutex   sync.Mutex    // Held by SetWriter and by each sample
+	enabled atomic.Bool   // A dump is in progress, the signals are sampled only then
+	writer  *bufio.Writer // VCD file being written
+
+	values     []uint16
+	started    bool
+	start      int64 // Time of the first cycle sampled
+	lastTime   int64 // Time written of the last change
+	sampleTime int64 // Time of the last cycle sampled
+}
+
+// newWaveformRecorder creates a new waveform recorder.
+//
+// Parameters:
+//   - scope: Name of the module that contains the signals in the dump
+//   - signals: The signals sampled on each cycle
+//
+// Returns:
+//   - A pointer to the initialized waveformRecorder
+func newWaveformRecorder(scope string, signals []core.WaveformSignal) *waveformRecorder {
+	ids := make([]string, len(signals))
+	for i := range signals {
+		ids[i] = waveformIdentifier(i)
+	}
+
+	return &waveformRecorder{
+		scope:   scope,
+		signals: signals,
+		ids:     ids,
+		values:  make([]uint16, len(signals)),
+	}
+}
+
+// NewWaveformRecorder creates a new waveform recorder. It doesn't write anything until a
+// writer is set.
+//
+// Parameters:
+//   - scope: Name of the module that contains the signals in the dump, usually the computer
+//   - signals: The signals sampled on each cycle
+//
+// Returns:
+//   - A pointer to the initialized WaveformRecorder
+func NewWaveformRecorder(scope string, signals []core.WaveformSignal) core.WaveformRecorder {
+	return newWaveformRecorder(scope, signals)
+}
+
+// NewLineSignal creates a signal sampling a line.
+//
+// Parameters:
+//   - name: Name of the signal in the dump
+//   - line: The line sampled
+//
+// Returns:
+//   - The signal, 1 when the line is high
+func NewLineSignal(name string, line buses.Line) core.WaveformSignal {
+	return core.WaveformSignal{
+		Name:  name,
+		Width: 1,
+		Read: func() uint16 {
+			if line.Status() {
+				return 1
+			}
+
+			return 0
+		},
+	}
+}
+
+// NewBusSignal creates a signal sampling a bus.
+//
+// Parameters:
+//   - name: Name of the signal in the dump
+//   - bus: The bus sampled
+//
+// Returns:
+//   - The signal, as wide as the bus
+func NewBusSignal[T uint8 | uint16](name string, bus buses.Bus[T]) core.WaveformSignal {
+	var zero T
+
+	width := uint8(8)
+	if _, ok := any(zero).(uint16); ok {
+		width = 16
+	}
+
+	return core.WaveformSignal{
+		Name:  name,
+		Width: width,
+		Read: func() uint16 {
+			return uint16(bus.Read())
+		},
+	}
+}
+
+// SetWriter sets where the dump is written, nil stops dumping. The previous writer is flushed,
+// after writing the time of the end of the dump so the viewers show the last values, and the
+// header of the dump is written to the new one.
+//
+// Parameters:
+//   - writer: Where the dump is written, nil to stop dumping
+//
+// Returns:
+//   - An error if the pending output can't be written to the previous writer
+func (r *waveformRecorder) SetWriter(writer io.Writer) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var err error
+	if r.writer != nil {
+		if r.started {
+			fmt.Fprintf(r.writer, "#%d\n", max(r.sampleTime, r.lastTime+1))
+		}
+
+		err = r.writer.Flush()
+	}
+
+	r.writer = nil
+	r.started = false
+
+	if writer != nil {
+		r.writer = bufio.NewWriter(writer)
+		r.writeHeader()
+	}
+
+	r.enabled.Store(writer != nil)
+
+	return err
+}
+
+// IsEnabled returns true if the dump is being written.
+//
+// Returns:
+//   - true if a writer is set
+func (r *waveformRecorder) IsEnabled() bool {
+	return r.enabled.Load()
+}
+
+// SampleCycle reads the values of the signals and writes the ones that changed since the
+// previous cycle. The first cycle sampled writes the values of all the signals. It has no
+// effect if no writer is set.
+//
+// Parameters:
+//   - t: The time of the cycle in nanoseconds
+//
+// Returns:
+//   - An error if the dump can't be written
+func (r *waveformRecorder) SampleCycle(t int64) error {
+	if !r.enabled.Load() {
+		return nil
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.writer == nil {
+		return nil
+	}
+
+	if !r.started {
+		return r.writeInitialValues(t)
+	}
+
+	time := t - r.start
+	if time <= r.lastTime {
+		time = r.lastTime + 1
+	}
+
+	r.sampleTime = time
+
+	changed := false
+
+	// The errors of the writer are kept, checking the last write is enough
+	var err error
+	for i, signal := range r.signals {
+		value := signal.Read()
+		if value == r.values[i] {
+			continue
+		}
+
+		if !changed {
+			fmt.Fprintf(r.writer, "#%d\n", time)
+			r.lastTime = time
+			changed = true
+		}
+
+		r.values[i] = value
+		err = r.writeValue(i)
+	}
+
+	return err
+}
+
+// writeHeader writes the declarations of the signals.
+func (r *waveformRecorder) writeHeader() {
+	r.writer.WriteString("$version Clementina 6502 $end\n")
+	r.writer.WriteString("$timescale 1 ns $end\n")
+	fmt.Fprintf(r.writer, "$scope module %s $end\n", r.scope)
+
+	for i, signal := range r.signals {
+		if signal.Width == 1 {
+			fmt.Fprintf(r.writer, "$var wire 1 %s %s $end\n", r.ids[i], signal.Name)
+		} else {
+			fmt.Fprintf(r.writer, "$var wire %d %s %s [%d:0] $end\n", signal.Width, r.ids[i], signal.Name, signal.Width-1)
+		}
+	}
+
+	r.writer.WriteString("$upscope $end\n")
+	r.writer.WriteString("$enddefinitions $end\n")
+}
+
+// writeInitialValues writes the values of all the signals at the start of the dump.
+func (r *waveformRecorder) writeInitialValues(t int64) error {
+	r.started = true
+	r.start = t
+	r.lastTime = 0
+	r.sampleTime = 0
+
+	r.writer.WriteString("#0\n$dumpvars\n")
+
+	for i, signal := range r.signals {
+		r.values[i] = signal.Read()
+		r.writeValue(i)
+	}
+
+	_, err := r.writer.WriteString("$end\n")
+
+	return err
+}
+
+// writeValue writes the current value of the signal.
+func (r *waveformRecorder) writeValue(index int) error {
+	var err error
+
+	if r.signals[index].Width == 1 {
+		_, err = fmt.Fprintf(r.writer, "%d%s\n", r.values[index], r.ids[index])
+	} else {
+		_, err = fmt.Fprintf(r.writer, "b%s %s\n", strconv.FormatUint(uint64(r.values[index]), 2), r.ids[index])
+	}
+
+	return err
+}
+
+// waveformIdentifier returns the identifier of the signal in the dump, made of printable
+// characters as required by the format.
+func waveformIdentifier(index int) string {
+	var id []byte
+
+	for {
+		id = append(id, firstIdentifierChar+byte(index%identifierChars))
+
+		index = index/identifierChars - 1
+		if index < 0 {
+			return string(id)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package managers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaveformRecorderWritesChanges(t *testing.T) {
+	var output bytes.Buffer
+
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+	rw := buses.NewStandaloneLine(true)
+
+	recorder := NewWaveformRecorder("test", []core.WaveformSignal{
+		NewBusSignal("A", addressBus),
+		NewBusSignal("D", dataBus),
+		NewLineSignal("RWB", rw),
+	})
+
+	// Nothing is written without a writer
+	require.NoError(t, recorder.SampleCycle(0))
+	assert.False(t, recorder.IsEnabled())
+
+	require.NoError(t, recorder.SetWriter(&output))
+	assert.True(t, recorder.IsEnabled())
+
+	addressBus.Write(0xFFFC)
+	require.NoError(t, recorder.SampleCycle(5000))
+
+	addressBus.Write(0xFFFD)
+	require.NoError(t, recorder.SampleCycle(6000))
+
+	// Nothing changes
+	require.NoError(t, recorder.SampleCycle(7000))
+
+	dataBus.Write(0x80)
+	rw.Set(false)
+	require.NoError(t, recorder.SampleCycle(8000))
+
+	require.NoError(t, recorder.SampleCycle(9000))
+	require.NoError(t, recorder.SetWriter(nil))
+	assert.False(t, recorder.IsEnabled())
+
+	expected := "$version Clementina 6502 $end\n" +
+		"$timescale 1 ns $end\n" +
+		"$scope module test $end\n" +
+		"$var wire 16 ! A [15:0] $end\n" +
+		"$var wire 8 \" D [7:0] $end\n" +
+		"$var wire 1 # RWB $end\n" +
+		"$upscope $end\n" +
+		"$enddefinitions $end\n" +
+		"#0\n" +
+		"$dumpvars\n" +
+		"b1111111111111100 !\n" +
+		"b0 \"\n" +
+		"1#\n" +
+		"$end\n" +
+		"#1000\n" +
+		"b1111111111111101 !\n" +
+		"#3000\n" +
+		"b10000000 \"\n" +
+		"0#\n" +
+		"#4000\n"
+
+	assert.Equal(t, expected, output.String())
+}
+
+func TestWaveformRecorderTimeNeverGoesBackwards(t *testing.T) {
+	var output bytes.Buffer
+
+	line := buses.NewStandaloneLine(false)
+
+	recorder := NewWaveformRecorder("test", []core.WaveformSignal{NewLineSignal("IRQB", line)})
+	require.NoError(t, recorder.SetWriter(&output))
+
+	require.NoError(t, recorder.SampleCycle(1000))
+
+	line.Set(true)
+	require.NoError(t, recorder.SampleCycle(3000))
+
+	// A cycle executed again after rewinding
+	line.Set(false)
+	require.NoError(t, recorder.SampleCycle(2000))
+
+	require.NoError(t, recorder.SetWriter(nil))
+
+	assert.Contains(t, output.String(), "#0\n$dumpvars\n0!\n$end\n#2000\n1!\n#2001\n0!\n#2002\n")
+}
+
+func TestWaveformIdentifiers(t *testing.T) {
+	assert.Equal(t, "!", waveformIdentifier(0))
+	assert.Equal(t, "~", waveformIdentifier(93))
+	assert.Equal(t, "!!", waveformIdentifier(94))
+	assert.Equal(t, "\"!", waveformIdentifier(95))
+	assert.Equal(t, "!\"", waveformIdentifier(188))
+}
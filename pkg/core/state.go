@@ -0,0 +1,128 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StateVersion is the version of the state format. It must be incremented every time the
+// state saved by any computer or component changes, states of other versions can't be loaded.
+const StateVersion uint16 = 4
+
+// Identifies the start of a state
+var stateMagic = [4]byte{'C', '6', '5', 'S'}
+
+// ErrInvalidState is returned when loading data that is not a state saved by the emulator.
+var ErrInvalidState = errors.New("not a saved state")
+
+// Header written at the start of every state. This struct is written and read with
+// encoding/binary, so it must only have fixed size fields.
+type stateHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Model   [16]byte
+}
+
+// WriteStateHeader writes the header that identifies the format version and the computer
+// model of the state that follows.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//   - model: Name of the computer model whose state is saved, up to 16 characters
+//
+// Returns:
+//   - An error if the header can't be written
+func WriteStateHeader(writer io.Writer, model string) error {
+	header := stateHeader{
+		Magic:   stateMagic,
+		Version: StateVersion,
+	}
+	copy(header.Model[:], model)
+
+	return binary.Write(writer, binary.LittleEndian, &header)
+}
+
+// ReadStateHeader reads the header of a state and verifies that it was saved with the
+// current format version by the same computer model.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//   - model: Name of the computer model that is loading the state
+//
+// Returns:
+//   - An error if the header can't be read, or the state has a different version or model
+func ReadStateHeader(reader io.Reader, model string) error {
+	var header stateHeader
+
+	if err := binary.Read(reader, binary.LittleEndian, &header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrInvalidState
+		}
+
+		return err
+	}
+
+	if header.Magic != stateMagic {
+		return ErrInvalidState
+	}
+
+	if header.Version != StateVersion {
+		return fmt.Errorf("state version %d is not supported, expected version %d", header.Version, StateVersion)
+	}
+
+	if saved := string(bytes.TrimRight(header.Model[:], "\x00")); saved != model {
+		return fmt.Errorf("state was saved by the %q computer model, expected %q", saved, model)
+	}
+
+	return nil
+}
+
+// SaveStates writes the state of each of the parts in the specified order.
+//
+// Parameters:
+//   - writer: The writer where the state is saved
+//   - parts: The components to save, all of them must implement Snapshotable
+//
+// Returns:
+//   - An error if any of the parts doesn't support saving its state or the state can't be written
+func SaveStates(writer io.Writer, parts ...any) error {
+	for _, part := range parts {
+		snapshotable, ok := part.(Snapshotable)
+		if !ok {
+			return fmt.Errorf("%T doesn't support saving its state", part)
+		}
+
+		if err := snapshotable.SaveState(writer); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadStates restores the state of each of the parts, which must be in the same order
+// used to save them with SaveStates.
+//
+// Parameters:
+//   - reader: The reader from where the state is loaded
+//   - parts: The components to restore, all of them must implement Snapshotable
+//
+// Returns:
+//   - An error if any of the parts doesn't support loading its state or the state can't be read
+func LoadStates(reader io.Reader, parts ...any) error {
+	for _, part := range parts {
+		snapshotable, ok := part.(Snapshotable)
+		if !ok {
+			return fmt.Errorf("%T doesn't support loading its state", part)
+		}
+
+		if err := snapshotable.LoadState(reader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -39,7 +39,11 @@ func (dih *DefaultInputHandler) HandleKey(event *tcell.EventKey) *tcell.EventKey
 	// This maintains compatibility with the original implementation
 
 	if window := GetWindow[ui.OptionsWindow](dih.windowManager, "options"); window != nil {
-		window.ProcessKey(event)
+		// Captured keys are consumed so they don't reach the application (e.g. Ctrl-C)
+		capturing := window.IsCapturingKeys()
+		if window.ProcessKey(event) == nil && capturing {
+			return nil
+		}
 	}
 
 	return event
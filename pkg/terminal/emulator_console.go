@@ -91,6 +91,29 @@ func (c *baseEmulatorConsole) RemoveSelectedBreakpointAddress() {
 	}
 }
 
+// EditSelectedBreakpointAddress loads the currently selected breakpoint into the breakpoint configuration form.
+// It retrieves the breakpoint window and calls its EditSelectedItem method so the breakpoint condition can be changed.
+func (c *baseEmulatorConsole) EditSelectedBreakpointAddress() {
+	if window := GetWindow[ui.BreakPointForm](c.config.WindowManager, "breakpoint"); window != nil {
+		window.EditSelectedItem()
+	}
+}
+
+// SwitchToWatchpointConfigMode switches the console to watchpoint configuration mode.
+// It pushes "watchpoint" to the navigation history and switches to the watchpoint window.
+func (c *baseEmulatorConsole) SwitchToWatchpointConfigMode() {
+	c.config.NavigationManager.PushToHistory("watchpoint")
+	c.config.WindowManager.SwitchToPage("watchpoint")
+}
+
+// RemoveSelectedWatchpoint removes the currently selected watchpoint from the watchpoint configuration window.
+// It retrieves the watchpoint window and calls its RemoveSelectedItem method to remove the selected watchpoint.
+func (c *baseEmulatorConsole) RemoveSelectedWatchpoint() {
+	if window := GetWindow[ui.WatchpointForm](c.config.WindowManager, "watchpoint"); window != nil {
+		window.RemoveSelectedItem()
+	}
+}
+
 // ShowEmulationSpeedPopup displays the emulation speed configuration popup window.
 // It retrieves the speed window and calls its ShowConfig method to display the speed configuration interface.
 func (c *baseEmulatorConsole) ShowEmulationSpeedPopup() {
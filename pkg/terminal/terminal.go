@@ -93,7 +93,7 @@ type ScrollableActiveWindow interface {
 }
 
 // BreakpointConfigurator defines the interface for managing breakpoint configuration.
-// It provides methods for entering breakpoint configuration mode and removing breakpoints.
+// It provides methods for entering breakpoint and watchpoint configuration modes and removing them.
 type BreakpointConfigurator interface {
 	// SwitchToBreakpointConfigMode enters the breakpoint configuration mode,
 	// allowing the user to add, remove, or modify breakpoints.
@@ -101,6 +101,16 @@ type BreakpointConfigurator interface {
 
 	// RemoveSelectedBreakpointAddress removes the currently selected breakpoint address.
 	RemoveSelectedBreakpointAddress()
+
+	// EditSelectedBreakpointAddress loads the currently selected breakpoint into the form for editing.
+	EditSelectedBreakpointAddress()
+
+	// SwitchToWatchpointConfigMode enters the watchpoint configuration mode,
+	// allowing the user to add or remove memory watchpoints and review their hits.
+	SwitchToWatchpointConfigMode()
+
+	// RemoveSelectedWatchpoint removes the currently selected watchpoint.
+	RemoveSelectedWatchpoint()
 }
 
 // WindowManipulator defines the interface for window navigation and management.
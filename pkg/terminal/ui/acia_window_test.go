@@ -83,6 +83,10 @@ func (t *testAcia) ConnectRegisterSelectLines(lines [2]buses.Line) {
 
 func (t *testAcia) Close() {}
 
+// Serial receiving methods
+func (t *testAcia) SetReceiveHandler(handler func(value uint8)) {}
+func (t *testAcia) ReceiveByte(value uint8)                     {}
+
 // Emulation methods
 func (t *testAcia) Tick(context *common.StepContext) {}
 
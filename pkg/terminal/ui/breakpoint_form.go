@@ -2,23 +2,29 @@ package ui
 
 import (
 	"fmt"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/fran150/clementina-6502/pkg/common"
 	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+const breakpointFormTitle string = "Add a new breakpoint"
+
 // BreakPointForm represents a UI component for managing breakpoints in the debugger.
 // It provides a form for adding new breakpoints and a list for displaying and removing
-// existing breakpoints.
+// existing breakpoints. Breakpoints can have an optional condition that must hold
+// for the emulation to pause (for example "A == $FF && [$0200] != 0"). If a symbol
+// table is loaded, addresses can be entered as labels.
 type BreakPointForm struct {
 	grid *tview.Grid
 	form *tview.Form
 	list *tview.List
 
 	breakpointManager core.BreakpointManager
+	symbols           core.SymbolTable
 }
 
 // NewBreakPointForm creates and initializes a new breakpoint management form.
@@ -36,10 +42,11 @@ func NewBreakPointForm(breakpointManager core.BreakpointManager) *BreakPointForm
 
 	form := tview.NewForm().
 		AddInputField("Address", "", 5, breakPointForm.validateHexInput, nil).
+		AddInputField("Condition", "", 40, nil, nil).
 		AddButton("Add", breakPointForm.AddSelectedBreakpointAddress).
 		SetFocus(0)
 
-	form.SetBorder(true).SetTitle("Add a new breakpoint")
+	form.SetBorder(true).SetTitle(breakpointFormTitle)
 
 	list := tview.NewList()
 
@@ -47,7 +54,7 @@ func NewBreakPointForm(breakpointManager core.BreakpointManager) *BreakPointForm
 
 	grid := tview.NewGrid().
 		SetColumns(0).
-		SetRows(7, 0).
+		SetRows(9, 0).
 		AddItem(form, 0, 0, 1, 1, 0, 0, true).
 		AddItem(list, 1, 0, 1, 1, 0, 0, false)
 
@@ -58,6 +65,22 @@ func NewBreakPointForm(breakpointManager core.BreakpointManager) *BreakPointForm
 	return breakPointForm
 }
 
+// SetSymbolTable sets the symbol table used to accept labels as breakpoint addresses
+// and to show them next to the addresses in the list.
+//
+// Parameters:
+//   - symbols: The symbol table of the running program, nil to accept only addresses
+func (d *BreakPointForm) SetSymbolTable(symbols core.SymbolTable) {
+	d.symbols = symbols
+
+	width := 5
+	if symbols != nil {
+		width = maxLabelLength
+	}
+
+	d.form.GetFormItemByLabel("Address").(*tview.InputField).SetFieldWidth(width)
+}
+
 // RemoveSelectedItem removes the currently selected breakpoint from the list.
 // If the list is empty, this method has no effect.
 func (d *BreakPointForm) RemoveSelectedItem() {
@@ -90,39 +113,102 @@ func (d *BreakPointForm) CheckBreakpoint(address uint16) bool {
 	return d.breakpointManager.HasBreakpoint(address)
 }
 
-// AddBreakpointAddress adds a new breakpoint at the specified hexadecimal address.
-// The address is converted to uppercase and displayed with a "$" prefix.
+// AddBreakpointAddress adds a new breakpoint at the specified hexadecimal address or label.
+// The address is displayed with a "$" prefix.
 //
 // Parameters:
-//   - text: The hexadecimal address as a string
-func (d *BreakPointForm) AddBreakpointAddress(text string) {
-	text = strings.ToUpper(text)
-
-	value, err := strconv.ParseUint(text, 16, 16)
+//   - text: The hexadecimal address or the label as a string
+//
+// Returns:
+//   - An error if the text is neither a label nor a valid hexadecimal address
+func (d *BreakPointForm) AddBreakpointAddress(text string) error {
+	address, err := resolveAddress(d.symbols, text)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	address := uint16(value)
-	d.breakpointManager.AddBreakpoint(address)
+	return d.AddConditionalBreakpointAddress(address, "")
+}
+
+// AddConditionalBreakpointAddress adds a new breakpoint at the specified address that
+// only triggers when the condition holds. If a breakpoint already exists at the address
+// its condition is updated. The condition is displayed below the address in the list.
+//
+// Parameters:
+//   - address: The breakpoint address
+//   - condition: The breakpoint condition, empty for unconditional breakpoints
+//
+// Returns:
+//   - An error if the condition is not valid
+func (d *BreakPointForm) AddConditionalBreakpointAddress(address uint16, condition string) error {
+	index := slices.Index(d.breakpointManager.GetBreakpoints(), address)
+
+	if err := d.breakpointManager.AddConditionalBreakpoint(address, condition); err != nil {
+		return err
+	}
+
+	condition = d.breakpointManager.GetBreakpointCondition(address)
+
+	text := fmt.Sprintf("$%04X", address)
+	if label, ok := lookupLabel(d.symbols, address); ok {
+		text += " " + label
+	}
 
-	text = fmt.Sprintf("$%04s", text)
-	d.list.AddItem(text, "", ' ', nil)
+	if index >= 0 {
+		d.list.SetItemText(index, text, condition)
+	} else {
+		d.list.AddItem(text, condition, ' ', nil)
+	}
+
+	return nil
 }
 
-// AddSelectedBreakpointAddress adds the address currently entered in the form
-// as a new breakpoint.
+// AddSelectedBreakpointAddress adds the address and condition currently entered in the form
+// as a new breakpoint. If the address or the condition are not valid the error is shown in
+// the form title and the inputs are kept for correction.
 func (d *BreakPointForm) AddSelectedBreakpointAddress() {
-	input := d.form.GetFormItemByLabel("Address").(*tview.InputField)
-	text := input.GetText()
+	addressInput := d.form.GetFormItemByLabel("Address").(*tview.InputField)
+	conditionInput := d.form.GetFormItemByLabel("Condition").(*tview.InputField)
 
-	d.AddBreakpointAddress(text)
+	address, err := resolveAddress(d.symbols, addressInput.GetText())
+	if err != nil {
+		d.form.SetTitle(fmt.Sprintf("Invalid address: %v", err)).SetTitleColor(tcell.ColorRed)
+		return
+	}
 
-	input.SetText("")
+	if err := d.AddConditionalBreakpointAddress(address, conditionInput.GetText()); err != nil {
+		d.form.SetTitle(fmt.Sprintf("Invalid condition: %v", err)).SetTitleColor(tcell.ColorRed)
+		return
+	}
+
+	d.form.SetTitle(breakpointFormTitle).SetTitleColor(tview.Styles.TitleColor)
+
+	addressInput.SetText("")
+	conditionInput.SetText("")
+}
+
+// EditSelectedItem loads the breakpoint currently selected in the list into the form
+// so its condition can be changed. Adding it again will update the existing breakpoint.
+// If the list is empty, this method has no effect.
+func (d *BreakPointForm) EditSelectedItem() {
+	if d.list.GetItemCount() == 0 {
+		return
+	}
+
+	address := d.breakpointManager.GetBreakpoints()[d.list.GetCurrentItem()]
+
+	text := fmt.Sprintf("%04X", address)
+	if label, ok := lookupLabel(d.symbols, address); ok {
+		text = label
+	}
+
+	d.form.GetFormItemByLabel("Address").(*tview.InputField).SetText(text)
+	d.form.GetFormItemByLabel("Condition").(*tview.InputField).SetText(d.breakpointManager.GetBreakpointCondition(address))
+	d.form.SetFocus(1)
 }
 
 // validateHexInput returns true if adding the lastChar value to the input string
-// results in a valid hex number.
+// results in a valid hex number. If a symbol table is loaded any label is accepted.
 //
 // Parameters:
 //   - textToCheck: The current text in the input field
@@ -133,6 +219,10 @@ func (d *BreakPointForm) AddSelectedBreakpointAddress() {
 func (d *BreakPointForm) validateHexInput(textToCheck string, lastChar rune) bool {
 	const allowedChars string = "0123456789ABCDEFabcdef"
 
+	if d.symbols != nil {
+		return len(textToCheck) <= maxLabelLength && isLabelRune(lastChar)
+	}
+
 	if len(textToCheck) >= 5 {
 		return false
 	}
@@ -125,22 +125,26 @@ func TestAddBreakpointAddress(t *testing.T) {
 		inputValue    string
 		expectedValue uint16
 		expectedText  string
-		shouldPanic   bool
+		shouldFail    bool
 	}{
 		{"Add simple hex", "1234", 0x1234, "$1234", false},
 		{"Add lowercase hex", "abcd", 0xABCD, "$ABCD", false},
 		{"Add mixed case hex", "Ef12", 0xEF12, "$EF12", false},
 		{"Non parseable value", "ZZZZ", 0x0000, "$0000", true},
+		{"Empty value", "", 0x0000, "$0000", true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			input.SetText(tt.inputValue)
 
-			if tt.shouldPanic {
-				assert.Panics(t, func() {
-					form.AddSelectedBreakpointAddress()
-				}, "Expected panic for invalid hex value")
+			if tt.shouldFail {
+				initialCount := form.breakpointManager.GetBreakpointCount()
+				form.AddSelectedBreakpointAddress()
+
+				assert.Equal(t, initialCount, form.breakpointManager.GetBreakpointCount())
+				assert.Contains(t, form.form.GetTitle(), "Invalid address")
+				assert.Equal(t, tt.inputValue, input.GetText())
 				return
 			}
 
@@ -174,3 +178,107 @@ func TestBreakPointForm_Draw(t *testing.T) {
 
 	assert.Equal(t, form.grid, grid)
 }
+
+func TestAddConditionalBreakpointAddress(t *testing.T) {
+	var bm core.BreakpointManager = managers.NewBreakpointManager()
+	form := NewBreakPointForm(bm)
+	addressInput := form.form.GetFormItemByLabel("Address").(*tview.InputField)
+	conditionInput := form.form.GetFormItemByLabel("Condition").(*tview.InputField)
+
+	addressInput.SetText("1234")
+	conditionInput.SetText("hitcount >= 2")
+	form.AddSelectedBreakpointAddress()
+
+	assert.Equal(t, 1, form.list.GetItemCount())
+	text, secondary := form.list.GetItemText(0)
+	assert.Equal(t, "$1234", text)
+	assert.Equal(t, "hitcount >= 2", secondary)
+	assert.Equal(t, "hitcount >= 2", bm.GetBreakpointCondition(0x1234))
+	assert.Empty(t, addressInput.GetText())
+	assert.Empty(t, conditionInput.GetText())
+
+	// Adding the same address updates the existing breakpoint
+	addressInput.SetText("1234")
+	conditionInput.SetText("A == $FF")
+	form.AddSelectedBreakpointAddress()
+
+	assert.Equal(t, 1, form.list.GetItemCount())
+	_, secondary = form.list.GetItemText(0)
+	assert.Equal(t, "A == $FF", secondary)
+	assert.Equal(t, 1, bm.GetBreakpointCount())
+
+	// Invalid conditions are rejected keeping the inputs
+	addressInput.SetText("5678")
+	conditionInput.SetText("A ==")
+	form.AddSelectedBreakpointAddress()
+
+	assert.Equal(t, 1, form.list.GetItemCount())
+	assert.False(t, bm.HasBreakpoint(0x5678))
+	assert.Equal(t, "5678", addressInput.GetText())
+	assert.Contains(t, form.form.GetTitle(), "Invalid condition")
+
+	// A condition without an address is rejected keeping the condition
+	addressInput.SetText("")
+	conditionInput.SetText("X == 1")
+	form.AddSelectedBreakpointAddress()
+
+	assert.Equal(t, 1, form.list.GetItemCount())
+	assert.Equal(t, "X == 1", conditionInput.GetText())
+	assert.Contains(t, form.form.GetTitle(), "Invalid address")
+}
+
+func TestEditSelectedItem(t *testing.T) {
+	var bm core.BreakpointManager = managers.NewBreakpointManager()
+	form := NewBreakPointForm(bm)
+	addressInput := form.form.GetFormItemByLabel("Address").(*tview.InputField)
+	conditionInput := form.form.GetFormItemByLabel("Condition").(*tview.InputField)
+
+	// Empty list should not change the form
+	form.EditSelectedItem()
+	assert.Empty(t, addressInput.GetText())
+
+	assert.NoError(t, form.AddConditionalBreakpointAddress(0x00AB, "X > 3"))
+	form.EditSelectedItem()
+
+	assert.Equal(t, "00AB", addressInput.GetText())
+	assert.Equal(t, "X > 3", conditionInput.GetText())
+}
+
+func TestBreakPointForm_Symbols(t *testing.T) {
+	var bm core.BreakpointManager = managers.NewBreakpointManager()
+	form := NewBreakPointForm(bm)
+	addressInput := form.form.GetFormItemByLabel("Address").(*tview.InputField)
+
+	symbols := managers.NewSymbolTable()
+	symbols.AddSymbol("lcd_wait", 0x8012)
+	form.SetSymbolTable(symbols)
+
+	// Labels can be typed in the address input
+	assert.True(t, form.validateHexInput("lcd_wai", 't'))
+	assert.False(t, form.validateHexInput("lcd wai", ' '))
+
+	addressInput.SetText("lcd_wait")
+	form.AddSelectedBreakpointAddress()
+
+	assert.True(t, bm.HasBreakpoint(0x8012))
+	text, _ := form.list.GetItemText(0)
+	assert.Equal(t, "$8012 lcd_wait", text)
+
+	// Hexadecimal addresses are still accepted
+	addressInput.SetText("$1234")
+	form.AddSelectedBreakpointAddress()
+	assert.True(t, bm.HasBreakpoint(0x1234))
+
+	// Unknown labels are rejected keeping the input
+	addressInput.SetText("missing")
+	form.AddSelectedBreakpointAddress()
+
+	assert.Equal(t, 2, bm.GetBreakpointCount())
+	assert.Equal(t, "missing", addressInput.GetText())
+	assert.Contains(t, form.form.GetTitle(), "Invalid address")
+
+	// Editing shows the label
+	form.list.SetCurrentItem(0)
+	form.EditSelectedItem()
+	assert.Equal(t, "lcd_wait", addressInput.GetText())
+}
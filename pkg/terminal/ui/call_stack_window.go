@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/fran150/clementina-6502/internal/queue"
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
+	"github.com/rivo/tview"
+)
+
+// Maximum number of frames kept in the call stack, deeper calls discard the oldest frames
+const maxCallStackDepth = 256
+
+// Maximum number of stack imbalance warnings shown in the window
+const maxCallStackWarnings = 10
+
+// CallStackWindow represents a UI component that displays the chain of subroutine calls and
+// interrupts being executed by the processor. It follows every JSR, BRK, IRQ and NMI entry
+// and every RTS and RTI exit, flagging returns that don't match the frame on top of the stack.
+type CallStackWindow struct {
+	text    *tview.TextView
+	tracker core.CallTracker
+	symbols core.SymbolTable
+
+	frames   []core.CallFrame
+	warnings *queue.SimpleQueue[string]
+	cycle    uint64 // Cycle being tracked, shown in the warnings
+}
+
+// NewCallStackWindow creates a new call stack window that follows the execution of the processor.
+//
+// Parameters:
+//   - processor: The CPU chip to monitor
+//
+// Returns:
+//   - A pointer to the initialized CallStackWindow
+func NewCallStackWindow(processor components.Cpu65C02) *CallStackWindow {
+	text := tview.NewTextView()
+	text.SetScrollable(false).
+		SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Call Stack")
+
+	window := &CallStackWindow{
+		text:     text,
+		frames:   make([]core.CallFrame, 0, maxCallStackDepth),
+		warnings: queue.NewQueue[string](),
+	}
+
+	window.tracker = managers.NewCallTracker(processor, window)
+
+	return window
+}
+
+// SetSymbolTable sets the symbol table used to show labels in place of addresses.
+//
+// Parameters:
+//   - symbols: The symbol table of the running program, nil to show only addresses
+func (d *CallStackWindow) SetSymbolTable(symbols core.SymbolTable) {
+	d.symbols = symbols
+}
+
+// Tick follows the processor execution updating the call stack. Calls are detected when the
+// processor fetches the first opcode of the subroutine or interrupt handler and returns
+// when it fetches the opcode where the execution continues.
+//
+// Parameters:
+//   - context: The current step context
+func (d *CallStackWindow) Tick(context *common.StepContext) {
+	d.cycle = context.Cycle
+	d.tracker.TrackCycle()
+}
+
+// OnCall adds the frame of the call on top of the call stack, discarding the oldest frame
+// if the stack is full.
+//
+// Parameters:
+//   - frame: The call detected by the call tracker
+func (d *CallStackWindow) OnCall(frame core.CallFrame) {
+	if len(d.frames) >= maxCallStackDepth {
+		d.frames = d.frames[1:]
+	}
+
+	d.frames = append(d.frames, frame)
+}
+
+// OnReturn removes the frame on top of the call stack checking that the return matches it.
+// If it doesn't, a warning is added and the frames whose return address was removed
+// from the processor stack are discarded.
+//
+// Parameters:
+//   - ret: The return detected by the call tracker
+func (d *CallStackWindow) OnReturn(ret core.CallReturn) {
+	mnemonic := cpu.RTS
+	if ret.Interrupt {
+		mnemonic = cpu.RTI
+	}
+
+	if len(d.frames) == 0 {
+		d.addWarning("%s at $%04X returned to $%04X with an empty call stack", mnemonic, ret.Address, ret.TargetAddress)
+		return
+	}
+
+	top := d.frames[len(d.frames)-1]
+	expectedMnemonic := cpu.RTI
+	if top.Type == core.CallTypeJSR {
+		expectedMnemonic = cpu.RTS
+	}
+
+	if top.ReturnAddress == ret.TargetAddress && expectedMnemonic == mnemonic {
+		d.frames = d.frames[:len(d.frames)-1]
+		return
+	}
+
+	switch {
+	case expectedMnemonic != mnemonic:
+		d.addWarning("%s at $%04X returned from a %s frame", mnemonic, ret.Address, top.Type)
+	default:
+		d.addWarning("%s at $%04X returned to $%04X, expected $%04X", mnemonic, ret.Address, ret.TargetAddress, top.ReturnAddress)
+	}
+
+	// Discard frames that are no longer in the processor stack
+	for len(d.frames) > 0 && d.frames[len(d.frames)-1].StackPointer < ret.StackPointer {
+		d.frames = d.frames[:len(d.frames)-1]
+	}
+}
+
+// OnReset clears the call stack and the recorded warnings as the processor was reset.
+func (d *CallStackWindow) OnReset() {
+	d.frames = d.frames[:0]
+	d.warnings = queue.NewQueue[string]()
+}
+
+// addWarning adds a stack imbalance warning, keeping only the most recent ones.
+func (d *CallStackWindow) addWarning(format string, args ...any) {
+	message := fmt.Sprintf("cycle %d: ", d.cycle) + fmt.Sprintf(format, args...)
+
+	d.warnings.Queue(message)
+
+	if d.warnings.Size() > maxCallStackWarnings {
+		d.warnings.DeQueue()
+	}
+}
+
+// Reset clears the call stack and the recorded warnings.
+func (d *CallStackWindow) Reset() {
+	d.OnReset()
+	d.tracker.Reset()
+}
+
+// GetFrames returns a copy of the current call stack, the innermost call is the last frame.
+//
+// Returns:
+//   - A slice with the call stack frames
+func (d *CallStackWindow) GetFrames() []core.CallFrame {
+	result := make([]core.CallFrame, len(d.frames))
+	copy(result, d.frames)
+	return result
+}
+
+// GetWarnings returns the most recent stack imbalance warnings, oldest first.
+//
+// Returns:
+//   - A slice with the warning messages
+func (d *CallStackWindow) GetWarnings() []string {
+	return d.warnings.GetValues()
+}
+
+// Clear resets the call stack window, removing all text content.
+func (d *CallStackWindow) Clear() {
+	d.text.Clear()
+}
+
+// Draw updates the window with the current call stack, innermost call first, followed
+// by the most recent stack imbalance warnings.
+//
+// Parameters:
+//   - context: The current step context
+func (d *CallStackWindow) Draw(context *common.StepContext) {
+	fmt.Fprintf(d.text, "[yellow]Depth: [white]%d\n\n", len(d.frames))
+
+	for i := len(d.frames) - 1; i >= 0; i-- {
+		frame := d.frames[i]
+
+		fmt.Fprintf(d.text, "[grey]#%-3d [red]%s [blue]$%04X[white] -> %s [grey]ret [white]$%04X [grey]SP [white]$%02X\n",
+			len(d.frames)-1-i, frame.Type, frame.CallerAddress, formatAddress(d.symbols, frame.TargetAddress), frame.ReturnAddress, frame.StackPointer)
+	}
+
+	if !d.warnings.IsEmpty() {
+		fmt.Fprintf(d.text, "\n[yellow]Stack imbalances:\n")
+
+		for _, warning := range d.warnings.GetValues() {
+			fmt.Fprintf(d.text, "[red]![white] %s\n", warning)
+		}
+	}
+}
+
+// GetDrawArea returns the primitive that represents this window in the UI.
+// This is used by the layout manager to position and render the window.
+//
+// Returns:
+//   - The tview primitive for this window
+func (d *CallStackWindow) GetDrawArea() tview.Primitive {
+	return d.text
+}
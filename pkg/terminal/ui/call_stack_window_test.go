@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/components/memory"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
+	"github.com/stretchr/testify/assert"
+)
+
+type callStackTestComputer struct {
+	processor components.Cpu65C02
+	ram       components.Memory
+	irq       *buses.StandaloneLine
+	window    *CallStackWindow
+	context   common.StepContext
+}
+
+func newCallStackTestComputer(program map[uint16][]uint8) *callStackTestComputer {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+
+	alwaysHighLine := buses.NewStandaloneLine(true)
+	alwaysLowLine := buses.NewStandaloneLine(false)
+	writeEnableLine := buses.NewStandaloneLine(true)
+	irqLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(alwaysLowLine)
+	ram.OutputEnable().Connect(alwaysLowLine)
+
+	processor := cpu.NewCpu65C02S()
+	processor.AddressBus().Connect(addressBus)
+	processor.DataBus().Connect(dataBus)
+	processor.BusEnable().Connect(alwaysHighLine)
+	processor.ReadWrite().Connect(writeEnableLine)
+	processor.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	processor.Sync().Connect(buses.NewStandaloneLine(false))
+	processor.Ready().Connect(alwaysHighLine)
+	processor.VectorPull().Connect(buses.NewStandaloneLine(false))
+	processor.SetOverflow().Connect(alwaysHighLine)
+	processor.Reset().Connect(alwaysHighLine)
+	processor.InterruptRequest().Connect(irqLine)
+	processor.NonMaskableInterrupt().Connect(alwaysHighLine)
+
+	for address, values := range program {
+		for i, value := range values {
+			ram.Poke(address+uint16(i), value)
+		}
+	}
+
+	processor.ForceProgramCounter(0x0400)
+
+	return &callStackTestComputer{
+		processor: processor,
+		ram:       ram,
+		irq:       irqLine,
+		window:    NewCallStackWindow(processor),
+		context:   common.NewStepContext(),
+	}
+}
+
+// runUntilFetch runs the computer until the processor fetches the opcode at the specified address
+func (c *callStackTestComputer) runUntilFetch(t *testing.T, address uint16) {
+	for range 1000 {
+		c.processor.Tick(&c.context)
+		c.ram.Tick(&c.context)
+		c.processor.PostTick(&c.context)
+		c.window.Tick(&c.context)
+		c.context.NextCycle()
+
+		if c.processor.IsReadingOpcode() && c.processor.GetProgramCounter()-1 == address {
+			return
+		}
+	}
+
+	t.Fatalf("processor never fetched opcode at $%04X", address)
+}
+
+func TestCallStackWindow_NestedCalls(t *testing.T) {
+	// 0400: JSR $0410
+	// 0403: BRK
+	// 0405: NOP
+	// 0410: JSR $0420
+	// 0413: RTS
+	// 0420: NOP
+	// 0421: RTS
+	// 0500: RTI
+	c := newCallStackTestComputer(map[uint16][]uint8{
+		0x0400: {0x20, 0x10, 0x04, 0x00, 0x00, 0xEA},
+		0x0410: {0x20, 0x20, 0x04, 0x60},
+		0x0420: {0xEA, 0x60},
+		0x0500: {0x40},
+		0xFFFE: {0x00, 0x05},
+	})
+
+	c.runUntilFetch(t, 0x0420)
+
+	frames := c.window.GetFrames()
+	assert.Equal(t, 2, len(frames))
+	assert.Equal(t, core.CallFrame{Type: core.CallTypeJSR, CallerAddress: 0x0400, TargetAddress: 0x0410, ReturnAddress: 0x0403, StackPointer: 0xFB}, frames[0])
+	assert.Equal(t, core.CallFrame{Type: core.CallTypeJSR, CallerAddress: 0x0410, TargetAddress: 0x0420, ReturnAddress: 0x0413, StackPointer: 0xF9}, frames[1])
+
+	c.runUntilFetch(t, 0x0413)
+	assert.Equal(t, 1, len(c.window.GetFrames()))
+
+	c.runUntilFetch(t, 0x0500)
+	frames = c.window.GetFrames()
+	assert.Equal(t, 1, len(frames))
+	assert.Equal(t, core.CallFrame{Type: core.CallTypeBRK, CallerAddress: 0x0403, TargetAddress: 0x0500, ReturnAddress: 0x0405, StackPointer: 0xFA}, frames[0])
+
+	c.runUntilFetch(t, 0x0405)
+	assert.Empty(t, c.window.GetFrames())
+	assert.Empty(t, c.window.GetWarnings())
+}
+
+func TestCallStackWindow_Imbalance(t *testing.T) {
+	// 0400: JSR $0410
+	// 0403: NOP
+	// 0404: RTS
+	// 0410: JSR $0420
+	// 0420: PLA
+	// 0421: PLA
+	// 0422: RTS
+	c := newCallStackTestComputer(map[uint16][]uint8{
+		0x0400: {0x20, 0x10, 0x04, 0xEA, 0x60},
+		0x0410: {0x20, 0x20, 0x04},
+		0x0420: {0x68, 0x68, 0x60},
+		0x0000: {0xEA},
+	})
+
+	// The RTS at $0422 returns to $0403 discarding the inner frame
+	c.runUntilFetch(t, 0x0403)
+
+	assert.Empty(t, c.window.GetFrames())
+	warnings := c.window.GetWarnings()
+	assert.Equal(t, 1, len(warnings))
+	assert.Contains(t, warnings[0], "RTS at $0422 returned to $0403, expected $0413")
+
+	// The RTS at $0404 returns with an empty call stack
+	c.runUntilFetch(t, 0x0001)
+
+	warnings = c.window.GetWarnings()
+	assert.Equal(t, 2, len(warnings))
+	assert.Contains(t, warnings[1], "RTS at $0404 returned to $0001 with an empty call stack")
+}
+
+func TestCallStackWindow_Interrupt(t *testing.T) {
+	// 0400: CLI
+	// 0401: NOP
+	// 0402: JMP $0401
+	// 0600: RTI
+	c := newCallStackTestComputer(map[uint16][]uint8{
+		0x0400: {0x58, 0xEA, 0x4C, 0x01, 0x04},
+		0x0600: {0x40},
+		0xFFFE: {0x00, 0x06},
+	})
+
+	c.runUntilFetch(t, 0x0401)
+
+	c.irq.Set(false)
+	c.runUntilFetch(t, 0x0600)
+	c.irq.Set(true)
+
+	frames := c.window.GetFrames()
+	assert.Equal(t, 1, len(frames))
+	assert.Equal(t, core.CallTypeIRQ, frames[0].Type)
+	assert.Equal(t, uint8(0xFA), frames[0].StackPointer)
+	assert.Equal(t, uint16(0x0600), frames[0].TargetAddress)
+
+	returnAddress := frames[0].ReturnAddress
+	c.runUntilFetch(t, returnAddress)
+
+	assert.Empty(t, c.window.GetFrames())
+	assert.Empty(t, c.window.GetWarnings())
+}
+
+func TestCallStackWindow_Draw(t *testing.T) {
+	c := newCallStackTestComputer(map[uint16][]uint8{
+		0x0400: {0x20, 0x10, 0x04},
+		0x0410: {0xEA},
+	})
+
+	c.runUntilFetch(t, 0x0410)
+
+	c.window.Draw(&c.context)
+	text := c.window.text.GetText(true)
+
+	assert.Contains(t, text, "Depth: 1")
+	assert.Contains(t, text, "JSR $0400 -> $0410 ret $0403 SP $FB")
+
+	c.window.Clear()
+	assert.Empty(t, c.window.text.GetText(true))
+	assert.Equal(t, c.window.text, c.window.GetDrawArea())
+
+	c.window.Reset()
+	assert.Empty(t, c.window.GetFrames())
+}
+
+func TestCallStackWindow_Symbols(t *testing.T) {
+	c := newCallStackTestComputer(map[uint16][]uint8{
+		0x0400: {0x20, 0x10, 0x04},
+		0x0410: {0xEA},
+	})
+
+	symbols := managers.NewSymbolTable()
+	symbols.AddSymbol("lcd_wait", 0x0410)
+	c.window.SetSymbolTable(symbols)
+
+	c.runUntilFetch(t, 0x0410)
+
+	c.window.Draw(&c.context)
+	assert.Contains(t, c.window.text.GetText(true), "JSR $0400 -> lcd_wait ret $0403")
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/fran150/clementina-6502/pkg/common"
 	"github.com/fran150/clementina-6502/pkg/components"
 	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/rivo/tview"
 )
 
@@ -15,10 +16,12 @@ const maxLinesOfCode = 30
 
 // CodeWindow represents a UI component that displays the disassembled code being executed.
 // It shows the current instruction and recent execution history with syntax highlighting.
+// If a symbol table is loaded, labels are shown in place of the addresses that have one.
 type CodeWindow struct {
 	text      *tview.TextView
 	lines     *queue.SimpleQueue[string]
 	processor components.Cpu65C02
+	symbols   core.SymbolTable
 
 	operandsGetter func(programCounter uint16) [2]uint8
 }
@@ -48,7 +51,15 @@ func NewCodeWindow(processor components.Cpu65C02, operandsGetter func(programCou
 	}
 }
 
-func showCurrentInstruction(programCounter uint16, instruction components.CpuInstructionData, potentialOperands [2]uint8) string {
+// SetSymbolTable sets the symbol table used to show labels in place of addresses.
+//
+// Parameters:
+//   - symbols: The symbol table of the running program, nil to show only addresses
+func (d *CodeWindow) SetSymbolTable(symbols core.SymbolTable) {
+	d.symbols = symbols
+}
+
+func showCurrentInstruction(programCounter uint16, instruction components.CpuInstructionData, potentialOperands [2]uint8, symbols core.SymbolTable) string {
 	sb := strings.Builder{}
 
 	addressMode := instruction.AddressMode()
@@ -64,10 +75,15 @@ func showCurrentInstruction(programCounter uint16, instruction components.CpuIns
 		size = addressModeDetails.MemSize() - 1
 	}
 
+	// Write the label of the current address in its own line
+	if label, ok := lookupLabel(symbols, programCounter-1); ok {
+		fmt.Fprintf(&sb, "[yellow]%s:\r\n", label)
+	}
+
 	// Write current address
 	fmt.Fprintf(&sb, "[blue]$%04X: [red]%s [white]", (programCounter - 1), instruction.Mnemonic())
 
-	// Write operands
+	// Write operands, absolute addresses are replaced by their labels
 	switch size {
 	case 0:
 	case 1:
@@ -75,7 +91,12 @@ func showCurrentInstruction(programCounter uint16, instruction components.CpuIns
 	case 2:
 		msb := uint16(potentialOperands[1]) << 8
 		lsb := uint16(potentialOperands[0])
-		fmt.Fprintf(&sb, addressModeDetails.Format(), msb|lsb)
+
+		if label, ok := lookupLabel(symbols, msb|lsb); ok && addressMode != cpu.AddressModeRelativeExtended {
+			fmt.Fprint(&sb, strings.Replace(addressModeDetails.Format(), "$%04X", label, 1))
+		} else {
+			fmt.Fprintf(&sb, addressModeDetails.Format(), msb|lsb)
+		}
 	}
 
 	// If the address mode is relative we will show the value to which the CPU will jump
@@ -92,7 +113,7 @@ func showCurrentInstruction(programCounter uint16, instruction components.CpuIns
 		value = programCounter + value + 1
 
 		// Print the relative jump
-		fmt.Fprintf(&sb, "[green] ($%04X)", value)
+		fmt.Fprintf(&sb, "[green] (%s)", formatAddress(symbols, value))
 	}
 
 	fmt.Fprint(&sb, "\r\n")
@@ -101,7 +122,7 @@ func showCurrentInstruction(programCounter uint16, instruction components.CpuIns
 }
 
 func (d *CodeWindow) addLineOfCode(programCounter uint16, instruction components.CpuInstructionData, potentialOperands [2]uint8) {
-	codeLine := showCurrentInstruction(programCounter, instruction, potentialOperands)
+	codeLine := showCurrentInstruction(programCounter, instruction, potentialOperands, d.symbols)
 
 	d.lines.Queue(codeLine)
 
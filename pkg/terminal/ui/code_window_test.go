@@ -7,6 +7,7 @@ import (
 	"github.com/fran150/clementina-6502/pkg/components"
 	"github.com/fran150/clementina-6502/pkg/components/buses"
 	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -290,3 +291,45 @@ func TestCodeWindow_BRKInstruction(t *testing.T) {
 	// BRK should be shown as a single byte instruction
 	assert.Contains(t, text, "BRK")
 }
+
+func TestCodeWindow_Symbols(t *testing.T) {
+	instructions := cpu.NewInstructionSet()
+
+	symbols := managers.NewSymbolTable()
+	symbols.AddSymbol("reset", 0x0FFF)
+	symbols.AddSymbol("lcd_wait", 0x1234)
+	symbols.AddSymbol("loop", 0x1011)
+
+	tests := []struct {
+		name          string
+		opcode        uint8
+		operands      [2]uint8
+		expectedValue string
+	}{
+		{"JSR to label", 0x20, [2]uint8{0x34, 0x12}, "$0FFF: JSR lcd_wait"},
+		{"Indexed label", 0xBD, [2]uint8{0x34, 0x12}, "$0FFF: LDA lcd_wait, X"},
+		{"Address without label", 0x4C, [2]uint8{0x00, 0x20}, "$0FFF: JMP $2000"},
+		{"Branch to label", 0xF0, [2]uint8{0x10, 0x00}, "$0FFF: BEQ $10 (loop)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCpu := &mockCpu{
+				programCounter:     0x1000,
+				currentInstruction: instructions.GetByOpCode(components.OpCode(tt.opcode)),
+				isReadingOpcode:    true,
+			}
+
+			codeWindow := NewCodeWindow(mockCpu, func(pc uint16) [2]uint8 { return tt.operands })
+			codeWindow.SetSymbolTable(symbols)
+			context := &common.StepContext{}
+
+			codeWindow.Tick(context)
+			codeWindow.Draw(context)
+
+			text := codeWindow.text.GetText(true)
+			assert.Contains(t, text, "reset:")
+			assert.Contains(t, text, tt.expectedValue)
+		})
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"strings"
 
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/rivo/tview"
 )
 
 // MemoryWindowGoToForm represents a form dialog for navigating to a specific memory address.
 // It provides an input field for entering hexadecimal addresses and handles validation.
+// If a symbol table is loaded, labels can be entered in place of the address.
 type MemoryWindowGoToForm struct {
 	grid *tview.Grid
 	form *tview.Form
@@ -18,6 +20,7 @@ type MemoryWindowGoToForm struct {
 	selectedMemoryWindow *MemoryWindow
 	size                 int
 	onSelect             func()
+	symbols              core.SymbolTable
 }
 
 // NewMemoryWindowGoToForm creates and initializes a new memory window goto form.
@@ -45,8 +48,17 @@ func NewMemoryWindowGoToForm() *MemoryWindowGoToForm {
 	return gotoForm
 }
 
+// SetSymbolTable sets the symbol table used to accept labels as addresses.
+//
+// Parameters:
+//   - symbols: The symbol table of the running program, nil to accept only addresses
+func (d *MemoryWindowGoToForm) SetSymbolTable(symbols core.SymbolTable) {
+	d.symbols = symbols
+}
+
 // validateHexInput validates that the input contains only valid hexadecimal characters
-// and does not exceed the maximum address size for the memory window.
+// and does not exceed the maximum address size for the memory window. If a symbol table
+// is loaded any label is accepted.
 //
 // Parameters:
 //   - textToCheck: The current text in the input field
@@ -57,6 +69,10 @@ func NewMemoryWindowGoToForm() *MemoryWindowGoToForm {
 func (d *MemoryWindowGoToForm) validateHexInput(textToCheck string, lastChar rune) bool {
 	const allowedChars string = "0123456789ABCDEFabcdef"
 
+	if d.symbols != nil {
+		return len(textToCheck) <= maxLabelLength && isLabelRune(lastChar)
+	}
+
 	if len(textToCheck) > d.size {
 		return false
 	}
@@ -77,6 +93,11 @@ func (d *MemoryWindowGoToForm) InitForm(memoryWindow *MemoryWindow, onSelect fun
 
 	input := d.form.GetFormItemByLabel("Address").(*tview.InputField)
 	input.SetFieldWidth(d.size + 1)
+
+	if d.symbols != nil {
+		input.SetFieldWidth(maxLabelLength)
+	}
+
 	input.SetText(fmt.Sprintf("%X", memoryWindow.GetStartAddress()))
 
 	d.onSelect = onSelect
@@ -84,13 +105,28 @@ func (d *MemoryWindowGoToForm) InitForm(memoryWindow *MemoryWindow, onSelect fun
 
 // selectValue processes the entered address and updates the memory window's start position.
 // It parses the hexadecimal input and calls the onSelect callback if provided.
+// Labels are processor addresses, they are wrapped to the size of the memory chip
+// in the same way the chip only decodes the lower lines of the address bus.
 func (d *MemoryWindowGoToForm) selectValue() {
 	input := d.form.GetFormItemByLabel("Address").(*tview.InputField)
 	text := input.GetText()
 
-	value, err := strconv.ParseUint(text, 16, 32)
-	if err != nil {
-		panic(err)
+	var value uint64
+
+	if address, ok := d.lookupAddress(text); ok {
+		value = uint64(address) % uint64(d.selectedMemoryWindow.Size())
+	} else {
+		var err error
+
+		value, err = strconv.ParseUint(text, 16, 32)
+		if err != nil {
+			// Unknown labels are kept in the input so they can be corrected
+			if d.symbols != nil {
+				return
+			}
+
+			panic(err)
+		}
 	}
 
 	// Find the closest value that is multiple of 8
@@ -105,6 +141,15 @@ func (d *MemoryWindowGoToForm) selectValue() {
 	}
 }
 
+// lookupAddress returns the address of the label if a symbol table is loaded.
+func (d *MemoryWindowGoToForm) lookupAddress(label string) (uint16, bool) {
+	if d.symbols == nil {
+		return 0, false
+	}
+
+	return d.symbols.GetAddress(label)
+}
+
 // Draw updates the form display.
 // This is a placeholder implementation as the form is static.
 //
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
 	"github.com/rivo/tview"
 	"github.com/stretchr/testify/assert"
 )
@@ -161,3 +162,40 @@ func TestMemoryWindowGoToForm_GetDrawArea(t *testing.T) {
 	assert.NotNil(t, drawArea)
 	assert.Equal(t, form.grid, drawArea)
 }
+
+func TestMemoryWindowGoToForm_Symbols(t *testing.T) {
+	form := NewMemoryWindowGoToForm()
+	memory := NewMockMemoryChip(0x8000)
+	memoryWindow := NewMemoryWindow(memory)
+
+	symbols := managers.NewSymbolTable()
+	symbols.AddSymbol("buffer", 0x0213)
+	symbols.AddSymbol("lcd_wait", 0x8012)
+	form.SetSymbolTable(symbols)
+
+	form.InitForm(memoryWindow, nil)
+	input := form.form.GetFormItemByLabel("Address").(*tview.InputField)
+
+	assert.True(t, form.validateHexInput("buffe", 'r'))
+
+	input.SetText("buffer")
+	form.selectValue()
+	assert.Equal(t, uint32(0x0210), memoryWindow.start)
+
+	// Labels out of the memory size are wrapped as the chip decodes the lower address lines
+	input.SetText("lcd_wait")
+	form.selectValue()
+	assert.Equal(t, uint32(0x0010), memoryWindow.start)
+
+	// Hexadecimal addresses are still accepted
+	input.SetText("1234")
+	form.selectValue()
+	assert.Equal(t, uint32(0x1230), memoryWindow.start)
+
+	// Unknown labels are ignored
+	input.SetText("missing")
+	assert.NotPanics(t, func() {
+		form.selectValue()
+	})
+	assert.Equal(t, uint32(0x1230), memoryWindow.start)
+}
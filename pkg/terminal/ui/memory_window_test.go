@@ -91,6 +91,10 @@ func (m *MockMemoryChip) Size() int {
 	return m.size
 }
 
+func (m *MockMemoryChip) Contents() []uint8 {
+	return m.data
+}
+
 // Emulation method
 func (m *MockMemoryChip) Tick(context *common.StepContext) {
 	// Mock implementation - do nothing
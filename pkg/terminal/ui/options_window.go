@@ -2,8 +2,10 @@ package ui
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -15,6 +17,9 @@ type OptionsWindow struct {
 
 	mainMenu []*OptionsWindowMenuOption
 	active   *OptionsWindowMenuOption
+
+	reloadStatus func() core.ReloadStatus
+	lastError    func() error
 }
 
 // OptionsWindowMenuOption represents a single menu option in the options window.
@@ -28,6 +33,10 @@ type OptionsWindowMenuOption struct {
 	BackAction     func(option *OptionsWindowMenuOption)
 	DoNotForward   bool
 
+	// KeyCapture receives every key but ESC while the option is the active menu, instead of
+	// looking for the options of its sub menu.
+	KeyCapture func(event *tcell.EventKey)
+
 	SubMenu []*OptionsWindowMenuOption
 
 	parent *OptionsWindowMenuOption
@@ -71,6 +80,24 @@ func (d *OptionsWindow) SetActiveMenu(menu *OptionsWindowMenuOption) {
 	d.active = menu
 }
 
+// SetReloadStatus sets the function that returns the status of the reload of the programs,
+// shown after the options while the program files are watched.
+//
+// Parameters:
+//   - status: The function returning the reload status, nil hides it
+func (d *OptionsWindow) SetReloadStatus(status func() core.ReloadStatus) {
+	d.reloadStatus = status
+}
+
+// SetLastError sets the function that returns the last error that occurred while emulating,
+// shown after the options.
+//
+// Parameters:
+//   - lastError: The function returning the last error, nil hides it
+func (d *OptionsWindow) SetLastError(lastError func() error) {
+	d.lastError = lastError
+}
+
 // ProcessKey handles keyboard input for the options window.
 // It processes key events to navigate menus and execute menu actions.
 //
@@ -88,6 +115,11 @@ func (d *OptionsWindow) ProcessKey(event *tcell.EventKey) *tcell.EventKey {
 		return event
 	}
 
+	if d.IsCapturingKeys() {
+		d.active.KeyCapture(event)
+		return nil
+	}
+
 	for _, option := range options {
 		if (event.Key() == tcell.KeyRune && option.Rune == event.Rune()) ||
 			(event.Key() != tcell.KeyRune && option.Key == event.Key()) {
@@ -109,6 +141,14 @@ func (d *OptionsWindow) ProcessKey(event *tcell.EventKey) *tcell.EventKey {
 	return event
 }
 
+// IsCapturingKeys returns true if the active menu captures the keys pressed.
+//
+// Returns:
+//   - true if the keys are passed to the key capture function of the active menu
+func (d *OptionsWindow) IsCapturingKeys() bool {
+	return d.active != nil && d.active.KeyCapture != nil
+}
+
 // GoToPreviousMenu navigates one level up in the menu tree.
 func (d *OptionsWindow) GoToPreviousMenu() {
 	active := d.GetActiveMenu()
@@ -140,6 +180,32 @@ func (d *OptionsWindow) Draw(context *common.StepContext) {
 	if activeMenu != nil {
 		fmt.Fprintf(d.text, " [white::r]ESC[white:-:-] Back ")
 	}
+
+	if d.reloadStatus != nil {
+		d.drawReloadStatus(d.reloadStatus())
+	}
+
+	if d.lastError != nil {
+		if err := d.lastError(); err != nil {
+			fmt.Fprintf(d.text, " [red]Error: %s[white]", tview.Escape(err.Error()))
+		}
+	}
+}
+
+// drawReloadStatus shows if the program files are watched and the result of the last reload.
+//
+// Parameters:
+//   - status: The reload status to show
+func (d *OptionsWindow) drawReloadStatus(status core.ReloadStatus) {
+	switch {
+	case !status.Watching:
+	case status.Err != nil:
+		fmt.Fprintf(d.text, " [red]Reload failed: %s[white]", tview.Escape(status.Err.Error()))
+	case status.Reloads > 0:
+		fmt.Fprintf(d.text, " [green]Reloaded at %s (%d)[white]", status.LastReload.Format(time.TimeOnly), status.Reloads)
+	default:
+		fmt.Fprintf(d.text, " [green]Watching[white]")
+	}
 }
 
 // GetDrawArea returns the primitive that represents this window in the UI.
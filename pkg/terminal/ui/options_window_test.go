@@ -1,9 +1,12 @@
 package ui
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
 	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
@@ -114,6 +117,45 @@ func TestOptionsWindow_ProcessKey(t *testing.T) {
 		result = window.ProcessKey(event)
 		assert.Equal(t, event, result, "Event should be forwarded when DoNotForward is false")
 	})
+
+	t.Run("Test KeyCapture", func(t *testing.T) {
+		var captured []*tcell.EventKey
+
+		menu := []*OptionsWindowMenuOption{
+			{
+				Rune:           'k',
+				KeyName:        "K",
+				KeyDescription: "Capturing Option",
+				KeyCapture: func(event *tcell.EventKey) {
+					captured = append(captured, event)
+				},
+				SubMenu: []*OptionsWindowMenuOption{},
+			},
+		}
+
+		window := NewOptionsWindow(menu)
+		assert.False(t, window.IsCapturingKeys())
+
+		// Enters the menu without capturing the key
+		event := tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModNone)
+		window.ProcessKey(event)
+		assert.True(t, window.IsCapturingKeys())
+		assert.Empty(t, captured)
+
+		// Every key is captured and consumed
+		event = tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModNone)
+		assert.Nil(t, window.ProcessKey(event))
+
+		ctrlC := tcell.NewEventKey(tcell.KeyCtrlC, 0, tcell.ModCtrl)
+		assert.Nil(t, window.ProcessKey(ctrlC))
+		assert.Equal(t, []*tcell.EventKey{event, ctrlC}, captured)
+
+		// ESC goes back to the main menu
+		event = tcell.NewEventKey(tcell.KeyESC, 0, tcell.ModNone)
+		assert.Equal(t, event, window.ProcessKey(event))
+		assert.False(t, window.IsCapturingKeys())
+		assert.Len(t, captured, 2)
+	})
 }
 
 func TestOptionsWindow_GetActiveOptions(t *testing.T) {
@@ -309,3 +351,46 @@ func TestOptionsWindow_Draw(t *testing.T) {
 		assert.Contains(t, text, "ESC Back")
 	})
 }
+
+func TestOptionsWindow_DrawReloadStatus(t *testing.T) {
+	status := core.ReloadStatus{}
+
+	window := NewOptionsWindow(nil)
+	window.SetReloadStatus(func() core.ReloadStatus { return status })
+
+	draw := func() string {
+		window.Clear()
+		window.Draw(&common.StepContext{})
+		return window.text.GetText(true)
+	}
+
+	assert.NotContains(t, draw(), "Watching")
+
+	status.Watching = true
+	assert.Contains(t, draw(), "Watching")
+
+	status.Reloads = 2
+	status.LastReload = time.Date(2024, 1, 1, 10, 20, 30, 0, time.Local)
+	assert.Contains(t, draw(), "Reloaded at 10:20:30 (2)")
+
+	status.Err = errors.New("invalid checksum [line 3]")
+	assert.Contains(t, draw(), "Reload failed: invalid checksum [line 3]")
+}
+
+func TestOptionsWindow_DrawLastError(t *testing.T) {
+	var lastError error
+
+	window := NewOptionsWindow(nil)
+	window.SetLastError(func() error { return lastError })
+
+	draw := func() string {
+		window.Clear()
+		window.Draw(&common.StepContext{})
+		return window.text.GetText(true)
+	}
+
+	assert.NotContains(t, draw(), "Error")
+
+	lastError = errors.New("the replay was stopped [cycle 10]")
+	assert.Contains(t, draw(), "Error: the replay was stopped [cycle 10]")
+}
@@ -0,0 +1,147 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/rivo/tview"
+)
+
+// PanelWindow represents a UI component that displays the I/O panel attached to the VIA.
+// It shows a LED for each pin driven as output, the level of the input pins and the buttons
+// with the keys that press them.
+type PanelWindow struct {
+	text  *tview.TextView
+	panel components.IOPanel
+}
+
+// NewPanelWindow creates a new I/O panel display window.
+// It initializes the UI component and connects it to the provided panel.
+//
+// Parameters:
+//   - panel: The I/O panel to display
+//
+// Returns:
+//   - A pointer to the initialized PanelWindow
+func NewPanelWindow(panel components.IOPanel) *PanelWindow {
+	text := tview.NewTextView()
+	text.SetScrollable(false).
+		SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("I/O Panel")
+
+	return &PanelWindow{
+		text:  text,
+		panel: panel,
+	}
+}
+
+// Clear resets the panel window, removing all text content.
+func (d *PanelWindow) Clear() {
+	d.text.Clear()
+}
+
+// Draw updates the panel window with the current status of the pins and buttons.
+// Outputs are shown as LEDs, lit when high, inputs as H or L and the pins that are not
+// connected as a dash.
+//
+// Parameters:
+//   - context: The current step context containing system state information
+func (d *PanelWindow) Draw(context *common.StepContext) {
+	fmt.Fprintf(d.text, "[yellow]      7 6 5 4 3 2 1 0\n")
+	d.drawPort("PA", components.PanelPortA)
+	d.drawPort("PB", components.PanelPortB)
+
+	fmt.Fprintf(d.text, "\n")
+	for pin := components.PanelCA1; int(pin) < components.PanelPinCount; pin++ {
+		fmt.Fprintf(d.text, "[yellow] %s[white] %s", pin, d.pinSymbol(pin))
+	}
+	fmt.Fprintf(d.text, "\n")
+
+	buttons := d.panel.Buttons()
+	if len(buttons) == 0 {
+		fmt.Fprintf(d.text, "\n[white] No buttons\n")
+		return
+	}
+
+	fmt.Fprintf(d.text, "\n[yellow] Buttons\n")
+	for index, button := range buttons {
+		fmt.Fprintf(d.text, "[white::r]%s[white:-:-] %-4s %s\n",
+			tview.Escape(strconv.QuoteRune(button.Key)), button.Pin, d.buttonStatus(index, button))
+	}
+}
+
+// drawPort draws the symbols of the pins of the port from the 7th to the first one.
+//
+// Parameters:
+//   - name: The name of the port
+//   - first: The first pin of the port
+func (d *PanelWindow) drawPort(name string, first components.PanelPin) {
+	fmt.Fprintf(d.text, "[yellow] %s  ", name)
+
+	for bit := 7; bit >= 0; bit-- {
+		fmt.Fprintf(d.text, " %s", d.pinSymbol(first+components.PanelPin(bit)))
+	}
+
+	fmt.Fprintf(d.text, "\n")
+}
+
+// pinSymbol returns the symbol of the pin, with its color tags.
+//
+// Parameters:
+//   - pin: The pin of the VIA
+//
+// Returns:
+//   - A lit or unlit LED for outputs, H or L for inputs or a dash if not connected
+func (d *PanelWindow) pinSymbol(pin components.PanelPin) string {
+	status := d.panel.GetPinStatus(pin)
+
+	switch {
+	case !status.Connected:
+		return "[gray]-[white]"
+	case status.Output && status.Level:
+		return "[red]●[white]"
+	case status.Output:
+		return "[gray]○[white]"
+	case status.Level:
+		return "[green]H[white]"
+	default:
+		return "[green]L[white]"
+	}
+}
+
+// buttonStatus returns the type of the button and if it's pressed.
+//
+// Parameters:
+//   - index: The index of the button in the panel
+//   - button: The button
+//
+// Returns:
+//   - The description of the status of the button
+func (d *PanelWindow) buttonStatus(index int, button components.PanelButton) string {
+	kind := "momentary"
+	if button.Toggle {
+		kind = "toggle"
+	}
+
+	if button.ActiveHigh {
+		kind += ", high"
+	}
+
+	if d.panel.IsPressed(index) {
+		return fmt.Sprintf("%-16s [red]Pressed[white]", kind)
+	}
+
+	return fmt.Sprintf("%-16s Released", kind)
+}
+
+// GetDrawArea returns the primitive that represents this window in the UI.
+// This is used by the layout manager to position and render the window.
+//
+// Returns:
+//   - The tview primitive for this window
+func (d *PanelWindow) GetDrawArea() tview.Primitive {
+	return d.text
+}
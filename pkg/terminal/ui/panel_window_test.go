@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/stretchr/testify/assert"
+)
+
+// MockIOPanel implements the IOPanel interface for testing
+type MockIOPanel struct {
+	buttons []components.PanelButton
+	pressed []bool
+	pins    map[components.PanelPin]components.PanelPinStatus
+}
+
+func (m *MockIOPanel) Tick(context *common.StepContext)      {}
+func (m *MockIOPanel) Buttons() []components.PanelButton     { return m.buttons }
+func (m *MockIOPanel) IsPressed(button int) bool             { return m.pressed[button] }
+func (m *MockIOPanel) IsBusy() bool                          { return false }
+func (m *MockIOPanel) SetPressHandler(handler func([]uint8)) {}
+func (m *MockIOPanel) PressKey(key rune)                     {}
+func (m *MockIOPanel) PressButtons(buttons []uint8)          {}
+func (m *MockIOPanel) GetPinStatus(pin components.PanelPin) components.PanelPinStatus {
+	return m.pins[pin]
+}
+
+func TestNewPanelWindow(t *testing.T) {
+	panel := &MockIOPanel{}
+	window := NewPanelWindow(panel)
+
+	assert.NotNil(t, window)
+	assert.Equal(t, panel, window.panel)
+	assert.Equal(t, window.text, window.GetDrawArea())
+}
+
+func TestPanelWindow_Draw(t *testing.T) {
+	panel := &MockIOPanel{
+		buttons: []components.PanelButton{
+			{Key: '0', Pin: components.PanelPortA},
+			{Key: 'i', Pin: components.PanelCA1, Toggle: true, ActiveHigh: true},
+		},
+		pressed: []bool{true, false},
+		pins: map[components.PanelPin]components.PanelPinStatus{
+			components.PanelPortA:     {Connected: true, Level: false},
+			components.PanelPortA + 1: {Connected: true, Level: true},
+			components.PanelPortB + 7: {Connected: true, Output: true, Level: true},
+			components.PanelPortB + 6: {Connected: true, Output: true, Level: false},
+			components.PanelCA1:       {Connected: true, Level: true},
+		},
+	}
+
+	window := NewPanelWindow(panel)
+	window.Draw(&common.StepContext{})
+
+	text := window.text.GetText(true)
+	assert.Contains(t, text, " PA   - - - - - - H L")
+	assert.Contains(t, text, " PB   ● ○ - - - - - -")
+	assert.Contains(t, text, " CA1 H CA2 - CB1 - CB2 -")
+	assert.Contains(t, text, "'0' PA0  momentary        Pressed")
+	assert.Contains(t, text, "'i' CA1  toggle, high     Released")
+
+	window.Clear()
+	assert.Equal(t, "", window.text.GetText(true))
+}
+
+func TestPanelWindow_DrawWithoutButtons(t *testing.T) {
+	window := NewPanelWindow(&MockIOPanel{})
+	window.Draw(&common.StepContext{})
+
+	assert.Contains(t, window.text.GetText(true), "No buttons")
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/rivo/tview"
+)
+
+// Maximum number of subroutines and addresses listed in the profiler window
+const maxProfilerRows = 20
+
+// ProfilerWindow represents a UI component that displays the subroutines and addresses
+// where the processor spent most of its cycles, as counted by the profiler.
+type ProfilerWindow struct {
+	text     *tview.TextView
+	profiler core.Profiler
+	symbols  core.SymbolTable
+}
+
+// NewProfilerWindow creates a new profiler window that shows the counts of the profiler.
+//
+// Parameters:
+//   - profiler: The profiler whose counts are shown
+//
+// Returns:
+//   - A pointer to the initialized ProfilerWindow
+func NewProfilerWindow(profiler core.Profiler) *ProfilerWindow {
+	text := tview.NewTextView()
+	text.SetScrollable(false).
+		SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Profiler")
+
+	return &ProfilerWindow{
+		text:     text,
+		profiler: profiler,
+	}
+}
+
+// SetSymbolTable sets the symbol table used to show labels in place of addresses.
+//
+// Parameters:
+//   - symbols: The symbol table of the running program, nil to show only addresses
+func (d *ProfilerWindow) SetSymbolTable(symbols core.SymbolTable) {
+	d.symbols = symbols
+}
+
+// Clear resets the profiler window, removing all text content.
+func (d *ProfilerWindow) Clear() {
+	d.text.Clear()
+}
+
+// Draw updates the window with the subroutines that consumed more cycles, including the
+// ones they called, followed by the addresses that consumed more cycles.
+//
+// Parameters:
+//   - context: The current step context
+func (d *ProfilerWindow) Draw(context *common.StepContext) {
+	state := "[red]Stopped"
+	if d.profiler.IsEnabled() {
+		state = "[green]Running"
+	}
+
+	total := d.profiler.GetTotalCycles()
+
+	fmt.Fprintf(d.text, "[yellow]Profiler: %s [yellow]Cycles: [white]%d\n\n", state, total)
+
+	fmt.Fprintf(d.text, "[yellow]%-20s %8s %12s %7s %7s\n", "Subroutine", "Calls", "Cycles", "Self", "Total")
+
+	for i, subroutine := range d.profiler.GetSubroutines() {
+		if i >= maxProfilerRows {
+			break
+		}
+
+		fmt.Fprintf(d.text, "[white]%-20s %8d %12d %6.2f%% %6.2f%%\n",
+			formatAddress(d.symbols, subroutine.Address), subroutine.Calls, subroutine.TotalCycles,
+			percentage(subroutine.SelfCycles, total), percentage(subroutine.TotalCycles, total))
+	}
+
+	fmt.Fprintf(d.text, "\n[yellow]%-20s %8s %12s %7s\n", "Address", "Instr", "Cycles", "Cycles")
+
+	for i, address := range d.profiler.GetAddresses() {
+		if i >= maxProfilerRows {
+			break
+		}
+
+		fmt.Fprintf(d.text, "[white]%-20s %8d %12d %6.2f%%\n",
+			formatAddress(d.symbols, address.Address), address.Instructions, address.Cycles,
+			percentage(address.Cycles, total))
+	}
+}
+
+// percentage returns the percentage of the total that the value represents.
+func percentage(value uint64, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(value) * 100 / float64(total)
+}
+
+// GetDrawArea returns the primitive that represents this window in the UI.
+// This is used by the layout manager to position and render the window.
+//
+// Returns:
+//   - The tview primitive for this window
+func (d *ProfilerWindow) GetDrawArea() tview.Primitive {
+	return d.text
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
+	"github.com/stretchr/testify/assert"
+)
+
+// profilerStub returns fixed counts, the methods not used by the window are not implemented
+type profilerStub struct {
+	core.Profiler
+	enabled     bool
+	subroutines []core.ProfileSubroutine
+	addresses   []core.ProfileAddress
+}
+
+func (p *profilerStub) IsEnabled() bool                          { return p.enabled }
+func (p *profilerStub) GetTotalCycles() uint64                   { return 200 }
+func (p *profilerStub) GetSubroutines() []core.ProfileSubroutine { return p.subroutines }
+func (p *profilerStub) GetAddresses() []core.ProfileAddress      { return p.addresses }
+
+func TestProfilerWindow_Draw(t *testing.T) {
+	profiler := &profilerStub{
+		enabled: true,
+		subroutines: []core.ProfileSubroutine{
+			{Address: 0x8000, Calls: 3, Instructions: 40, SelfCycles: 50, TotalCycles: 150},
+			{Address: 0x8100, Calls: 10, Instructions: 30, SelfCycles: 100, TotalCycles: 100},
+		},
+		addresses: []core.ProfileAddress{
+			{Address: 0x8102, Instructions: 20, Cycles: 80},
+		},
+	}
+
+	symbols := managers.NewSymbolTable()
+	symbols.AddSymbol("wait_mia", 0x8100)
+
+	window := NewProfilerWindow(profiler)
+	window.SetSymbolTable(symbols)
+
+	context := common.NewStepContext()
+	window.Draw(&context)
+	text := window.text.GetText(true)
+
+	assert.Contains(t, text, "Profiler: Running Cycles: 200")
+	assert.Regexp(t, `\$8000 +3 +150 +25\.00% +75\.00%`, text)
+	assert.Regexp(t, `wait_mia +10 +100 +50\.00% +50\.00%`, text)
+	assert.Regexp(t, `\$8102 +20 +80 +40\.00%`, text)
+
+	window.Clear()
+	assert.Empty(t, window.text.GetText(true))
+
+	profiler.enabled = false
+	window.Draw(&context)
+	assert.Contains(t, window.text.GetText(true), "Profiler: Stopped")
+	assert.NotNil(t, window.GetDrawArea())
+}
+
+func TestProfilerWindow_LimitsRows(t *testing.T) {
+	profiler := &profilerStub{}
+
+	for i := range maxProfilerRows + 5 {
+		profiler.addresses = append(profiler.addresses, core.ProfileAddress{Address: uint16(0x9000 + i), Cycles: 1})
+	}
+
+	window := NewProfilerWindow(profiler)
+
+	context := common.NewStepContext()
+	window.Draw(&context)
+	text := window.text.GetText(true)
+
+	assert.Contains(t, text, "$9013")
+	assert.NotContains(t, text, "$9014")
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/rivo/tview"
+)
+
+// Number of source lines shown when the size of the window is not known yet
+const defaultSourceWindowLines int = 21
+
+// SourceWindow represents a UI component that displays the source file line that generated
+// the instruction being executed, surrounded by the lines before and after it.
+type SourceWindow struct {
+	text      *tview.TextView
+	processor components.Cpu65C02
+	sourceMap core.SourceMap
+
+	fetched            bool
+	instructionAddress uint16
+}
+
+// NewSourceWindow creates a new source window that follows the execution of the processor.
+//
+// Parameters:
+//   - processor: The CPU chip to monitor
+//   - sourceMap: The map from addresses to source lines, nil if no debug information is loaded
+//
+// Returns:
+//   - A pointer to the initialized SourceWindow
+func NewSourceWindow(processor components.Cpu65C02, sourceMap core.SourceMap) *SourceWindow {
+	text := tview.NewTextView()
+	text.SetScrollable(false).
+		SetDynamicColors(true).
+		SetWrap(false).
+		SetBorder(true).
+		SetTitle("Source")
+
+	return &SourceWindow{
+		text:      text,
+		processor: processor,
+		sourceMap: sourceMap,
+	}
+}
+
+// Tick keeps the address of the instruction being executed, it is updated every time
+// the processor fetches an opcode.
+//
+// Parameters:
+//   - context: The current step context
+func (d *SourceWindow) Tick(context *common.StepContext) {
+	if d.processor.IsReadingOpcode() {
+		d.fetched = true
+		d.instructionAddress = d.processor.GetProgramCounter() - 1
+	}
+}
+
+// Clear resets the source window, removing all text content.
+func (d *SourceWindow) Clear() {
+	d.text.Clear()
+}
+
+// Draw shows the lines of the source file around the line of the instruction being
+// executed, highlighting it.
+//
+// Parameters:
+//   - context: The current step context
+func (d *SourceWindow) Draw(context *common.StepContext) {
+	if d.sourceMap == nil {
+		fmt.Fprint(d.text, "[grey]No source information loaded, use --symbols with a ld65 debug info file")
+		return
+	}
+
+	if !d.fetched {
+		return
+	}
+
+	location, ok := d.sourceMap.GetSourceLocation(d.instructionAddress)
+	if !ok {
+		d.text.SetTitle("Source")
+		fmt.Fprintf(d.text, "[grey]No source line for $%04X", d.instructionAddress)
+		return
+	}
+
+	d.text.SetTitle(fmt.Sprintf("Source: %s", location.File))
+
+	lines, err := d.sourceMap.GetSourceLines(location.File)
+	if err != nil {
+		fmt.Fprintf(d.text, "[red]%s", tview.Escape(err.Error()))
+		return
+	}
+
+	first, last := d.visibleLines(location.Line, len(lines))
+
+	for number := first; number <= last; number++ {
+		line := tview.Escape(strings.ReplaceAll(lines[number-1], "\t", "    "))
+
+		if number == location.Line {
+			fmt.Fprintf(d.text, "[black:yellow]%5d  %s[-:-]\n", number, line)
+		} else {
+			fmt.Fprintf(d.text, "[grey]%5d  [white]%s\n", number, line)
+		}
+	}
+}
+
+// visibleLines returns the first and last line numbers shown in the window, keeping
+// the active line centered when possible.
+func (d *SourceWindow) visibleLines(active int, count int) (int, int) {
+	_, _, _, height := d.text.GetInnerRect()
+	if height <= 0 {
+		height = defaultSourceWindowLines
+	}
+
+	first := max(active-height/2, 1)
+	last := min(first+height-1, count)
+	first = max(last-height+1, 1)
+
+	return first, last
+}
+
+// GetDrawArea returns the primitive that represents this window in the UI.
+// This is used by the layout manager to position and render the window.
+//
+// Returns:
+//   - The tview primitive for this window
+func (d *SourceWindow) GetDrawArea() tview.Primitive {
+	return d.text
+}
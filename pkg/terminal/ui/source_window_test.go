@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSourceMap struct {
+	locations map[uint16]core.SourceLocation
+	files     map[string][]string
+}
+
+func (m *mockSourceMap) GetSourceLocation(address uint16) (core.SourceLocation, bool) {
+	location, ok := m.locations[address]
+	return location, ok
+}
+
+func (m *mockSourceMap) GetSourceLines(file string) ([]string, error) {
+	lines, ok := m.files[file]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+
+	return lines, nil
+}
+
+func newTestSourceMap() *mockSourceMap {
+	lines := make([]string, 100)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line_%d:\tNOP", i+1)
+	}
+
+	return &mockSourceMap{
+		locations: map[uint16]core.SourceLocation{
+			0x8000: {File: "main.s", Line: 50},
+			0x8001: {File: "main.s", Line: 2},
+			0x8002: {File: "missing.s", Line: 1},
+		},
+		files: map[string][]string{"main.s": lines},
+	}
+}
+
+func TestSourceWindow_Draw(t *testing.T) {
+	processor := &mockCpu{programCounter: 0x8001, isReadingOpcode: true}
+	window := NewSourceWindow(processor, newTestSourceMap())
+	window.text.SetRect(0, 0, 80, 12)
+	context := &common.StepContext{}
+
+	// Nothing is shown until the first opcode fetch
+	window.Draw(context)
+	assert.Empty(t, window.text.GetText(true))
+
+	window.Tick(context)
+	window.Draw(context)
+
+	// The active line is centered in the window
+	text := window.text.GetText(true)
+	assert.Equal(t, "Source: main.s", window.text.GetTitle())
+	assert.Contains(t, text, "   45  line_45:    NOP")
+	assert.Contains(t, text, "   54  line_54:    NOP")
+	assert.NotContains(t, text, "   44  line_44:")
+	assert.NotContains(t, text, "   55  line_55:")
+
+	// Lines at the start of the file fill the window from the first line
+	window.Clear()
+	processor.programCounter = 0x8002
+	window.Tick(context)
+	window.Draw(context)
+
+	text = window.text.GetText(true)
+	assert.Contains(t, text, "    1  line_1:    NOP")
+	assert.Contains(t, text, "    2  line_2:    NOP")
+	assert.Contains(t, text, "   10  line_10:    NOP")
+	assert.NotContains(t, text, "   11  line_11:")
+}
+
+func TestSourceWindow_NoSource(t *testing.T) {
+	processor := &mockCpu{programCounter: 0x8004, isReadingOpcode: true}
+	window := NewSourceWindow(processor, newTestSourceMap())
+	context := &common.StepContext{}
+
+	window.Tick(context)
+	window.Draw(context)
+	assert.Equal(t, "No source line for $8003", window.text.GetText(true))
+
+	window.Clear()
+	processor.programCounter = 0x8003
+	window.Tick(context)
+	window.Draw(context)
+	assert.Equal(t, "file not found", window.text.GetText(true))
+
+	window = NewSourceWindow(processor, nil)
+	window.Draw(context)
+	assert.Contains(t, window.text.GetText(true), "No source information loaded")
+	assert.Equal(t, window.text, window.GetDrawArea())
+}
@@ -65,10 +65,10 @@ func (s *SpeedWindow) Clear() {
 func (s *SpeedWindow) Draw(context *common.StepContext) {
 	if s.showConfig {
 		if s.showConfigStart == 0 {
-			s.showConfigStart = context.T
+			s.showConfigStart = context.WallT
 		}
 
-		if context.T-s.showConfigStart > (int64(time.Second) * 3) {
+		if context.WallT-s.showConfigStart > (int64(time.Second) * 3) {
 			s.showConfig = false
 			s.showConfigStart = 0
 		}
@@ -77,14 +77,14 @@ func (s *SpeedWindow) Draw(context *common.StepContext) {
 	} else {
 		if s.previousT != 0 {
 			cycles := context.Cycle - s.previousC
-			elapsedMicro := (float64(context.T) - float64(s.previousT)) / float64(time.Microsecond)
+			elapsedMicro := (float64(context.WallT) - float64(s.previousT)) / float64(time.Microsecond)
 
 			mhz := (float64(cycles) / elapsedMicro)
 
 			fmt.Fprintf(s.text, "[white]%0.8f Mhz", mhz)
 		}
 
-		s.previousT = context.T
+		s.previousT = context.WallT
 		s.previousC = context.Cycle
 	}
 }
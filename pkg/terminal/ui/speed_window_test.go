@@ -83,7 +83,7 @@ func TestSpeedWindow_Draw(t *testing.T) {
 			sw.previousC = tt.initialC
 
 			context := &common.StepContext{
-				T:     tt.contextT,
+				WallT: tt.contextT,
 				Cycle: tt.contextCycle,
 			}
 
@@ -131,7 +131,7 @@ func TestSpeedWindow_ShowConfig(t *testing.T) {
 	sw := NewSpeedWindow(controller)
 
 	context := &common.StepContext{
-		T:     1000000, // 1ms in nanoseconds
+		WallT: 1000000, // 1ms in nanoseconds
 		Cycle: 1000,
 	}
 
@@ -139,7 +139,7 @@ func TestSpeedWindow_ShowConfig(t *testing.T) {
 	sw.ShowConfig()
 	sw.Draw(context)
 	assert.True(t, sw.IsConfigVisible())
-	assert.Equal(t, context.T, sw.showConfigStart)
+	assert.Equal(t, context.WallT, sw.showConfigStart)
 
 	// Draw should show target speed
 	sw.Draw(context)
@@ -148,7 +148,7 @@ func TestSpeedWindow_ShowConfig(t *testing.T) {
 
 	// Test config timeout
 	newContext := &common.StepContext{
-		T:     context.T + (int64(time.Second) * 4), // 4 seconds later
+		WallT: context.WallT + (int64(time.Second) * 4), // 4 seconds later
 		Cycle: 2000,
 	}
 	sw.Draw(newContext)
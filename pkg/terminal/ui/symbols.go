@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// Maximum length of the labels that can be typed in the address inputs
+const maxLabelLength int = 32
+
+// lookupLabel returns the label of the address if a symbol table is loaded and the
+// address has one.
+func lookupLabel(symbols core.SymbolTable, address uint16) (string, bool) {
+	if symbols == nil {
+		return "", false
+	}
+
+	return symbols.GetLabel(address)
+}
+
+// formatAddress returns the label of the address or, if it has none, its value in
+// hexadecimal prefixed with "$".
+func formatAddress(symbols core.SymbolTable, address uint16) string {
+	if label, ok := lookupLabel(symbols, address); ok {
+		return label
+	}
+
+	return fmt.Sprintf("$%04X", address)
+}
+
+// resolveAddress converts the text entered by the user to an address. If a symbol
+// table is loaded and the text is a label its address is returned, otherwise the
+// text is parsed as an hexadecimal value with an optional "$" prefix.
+//
+// Returns:
+//   - The address entered
+//   - An error if the text is neither a label nor a valid hexadecimal address
+func resolveAddress(symbols core.SymbolTable, text string) (uint16, error) {
+	text = strings.TrimSpace(text)
+
+	if symbols != nil {
+		if address, ok := symbols.GetAddress(text); ok {
+			return address, nil
+		}
+	}
+
+	value, err := strconv.ParseUint(strings.TrimPrefix(text, "$"), 16, 16)
+	if err != nil {
+		if symbols != nil {
+			return 0, fmt.Errorf("unknown label or address %q", text)
+		}
+
+		return 0, err
+	}
+
+	return uint16(value), nil
+}
+
+// isLabelRune returns true if the character can be part of a ca65 label, including
+// the "@" of cheap local labels and the ":" of scoped names.
+func isLabelRune(char rune) bool {
+	return char == '_' || char == '@' || char == '.' || char == ':' || char == '$' ||
+		unicode.IsLetter(char) || unicode.IsDigit(char)
+}
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/rivo/tview"
+)
+
+// Access types in the order they are shown in the form drop down
+var watchpointTypes []core.WatchpointType = []core.WatchpointType{
+	core.WatchWrite,
+	core.WatchRead,
+	core.WatchReadWrite,
+}
+
+// WatchpointForm represents a UI component for managing memory watchpoints in the debugger.
+// It provides a form for adding new watchpoints, a list for displaying and removing
+// existing ones and the list of the most recent accesses that triggered them.
+type WatchpointForm struct {
+	grid *tview.Grid
+	form *tview.Form
+	list *tview.List
+	hits *tview.TextView
+
+	watchpointManager core.WatchpointManager
+}
+
+// NewWatchpointForm creates and initializes a new watchpoint management form.
+// It sets up the UI components for adding, displaying, and removing watchpoints.
+//
+// Parameters:
+//   - watchpointManager: The watchpoint manager to use for managing watchpoints
+//
+// Returns:
+//   - A pointer to the initialized WatchpointForm
+func NewWatchpointForm(watchpointManager core.WatchpointManager) *WatchpointForm {
+	watchpointForm := &WatchpointForm{
+		watchpointManager: watchpointManager,
+	}
+
+	options := make([]string, len(watchpointTypes))
+	for i, watchType := range watchpointTypes {
+		options[i] = watchpointTypeName(watchType)
+	}
+
+	form := tview.NewForm().
+		AddInputField("Start", "", 5, watchpointForm.validateHexInput, nil).
+		AddInputField("End", "", 5, watchpointForm.validateHexInput, nil).
+		AddDropDown("Access", options, 0, nil).
+		AddButton("Add", watchpointForm.AddSelectedWatchpoint).
+		SetFocus(0)
+
+	form.SetBorder(true).SetTitle("Add a new watchpoint (End is optional)")
+
+	list := tview.NewList().ShowSecondaryText(false)
+
+	list.SetBorder(true).SetTitle("Active Watchpoints")
+
+	hits := tview.NewTextView()
+	hits.SetScrollable(false).
+		SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle("Watchpoint Hits")
+
+	grid := tview.NewGrid().
+		SetColumns(0, 0).
+		SetRows(11, 0).
+		AddItem(form, 0, 0, 1, 1, 0, 0, true).
+		AddItem(list, 1, 0, 1, 1, 0, 0, false).
+		AddItem(hits, 0, 1, 2, 1, 0, 0, false)
+
+	watchpointForm.grid = grid
+	watchpointForm.form = form
+	watchpointForm.list = list
+	watchpointForm.hits = hits
+
+	return watchpointForm
+}
+
+// RemoveSelectedItem removes the currently selected watchpoint from the list.
+// If the list is empty, this method has no effect.
+func (d *WatchpointForm) RemoveSelectedItem() {
+	if d.list.GetItemCount() == 0 {
+		return
+	}
+
+	current := d.list.GetCurrentItem()
+
+	d.watchpointManager.RemoveWatchpointByIndex(current)
+	d.list.RemoveItem(current)
+}
+
+// AddWatchpoint adds a new watchpoint for the specified range and access type.
+//
+// Parameters:
+//   - startAddress: The first address of the watched range
+//   - endAddress: The last address of the watched range (included)
+//   - watchType: The type of access that triggers the watchpoint
+func (d *WatchpointForm) AddWatchpoint(startAddress uint16, endAddress uint16, watchType core.WatchpointType) {
+	if startAddress > endAddress {
+		startAddress, endAddress = endAddress, startAddress
+	}
+
+	d.watchpointManager.AddWatchpoint(startAddress, endAddress, watchType)
+
+	var text string
+	if startAddress == endAddress {
+		text = fmt.Sprintf("$%04X       %s", startAddress, watchpointTypeName(watchType))
+	} else {
+		text = fmt.Sprintf("$%04X-$%04X %s", startAddress, endAddress, watchpointTypeName(watchType))
+	}
+
+	d.list.AddItem(text, "", ' ', nil)
+}
+
+// AddSelectedWatchpoint adds the range and access type currently entered in the form
+// as a new watchpoint. If the start address is empty this method has no effect.
+func (d *WatchpointForm) AddSelectedWatchpoint() {
+	startInput := d.form.GetFormItemByLabel("Start").(*tview.InputField)
+	endInput := d.form.GetFormItemByLabel("End").(*tview.InputField)
+	accessInput := d.form.GetFormItemByLabel("Access").(*tview.DropDown)
+
+	if startInput.GetText() == "" {
+		return
+	}
+
+	startAddress := parseWatchpointAddress(startInput.GetText())
+	endAddress := startAddress
+
+	if endInput.GetText() != "" {
+		endAddress = parseWatchpointAddress(endInput.GetText())
+	}
+
+	index, _ := accessInput.GetCurrentOption()
+	if index < 0 {
+		index = 0
+	}
+
+	d.AddWatchpoint(startAddress, endAddress, watchpointTypes[index])
+
+	startInput.SetText("")
+	endInput.SetText("")
+}
+
+// parseWatchpointAddress converts the hexadecimal text to an address. The input field
+// validation guarantees that it will only contain hexadecimal digits.
+func parseWatchpointAddress(text string) uint16 {
+	value, err := strconv.ParseUint(strings.ToUpper(text), 16, 16)
+	if err != nil {
+		panic(err)
+	}
+
+	return uint16(value)
+}
+
+// watchpointTypeName returns the description of the access type shown in the UI.
+func watchpointTypeName(watchType core.WatchpointType) string {
+	switch watchType {
+	case core.WatchRead:
+		return "Read"
+	case core.WatchWrite:
+		return "Write"
+	default:
+		return "Read/Write"
+	}
+}
+
+// validateHexInput returns true if adding the lastChar value to the input string
+// results in a valid hex number.
+//
+// Parameters:
+//   - textToCheck: The current text in the input field
+//   - lastChar: The character being added to the input
+//
+// Returns:
+//   - true if the resulting text would be valid hexadecimal, false otherwise
+func (d *WatchpointForm) validateHexInput(textToCheck string, lastChar rune) bool {
+	const allowedChars string = "0123456789ABCDEFabcdef"
+
+	if len(textToCheck) >= 5 {
+		return false
+	}
+
+	return strings.ContainsRune(allowedChars, lastChar)
+}
+
+// Draw updates the list of watchpoint hits, most recent first. For every hit it shows
+// the address of the instruction that performed the access and the old and new values.
+//
+// Parameters:
+//   - context: The current step context
+func (d *WatchpointForm) Draw(context *common.StepContext) {
+	hits := d.watchpointManager.GetHits()
+
+	for i := len(hits) - 1; i >= 0; i-- {
+		hit := hits[i]
+
+		if hit.Write {
+			fmt.Fprintf(d.hits, "[yellow]$%04X[white] W $%04X: [grey]$%02X[white] -> $%02X [grey](cycle %d)\n",
+				hit.ProgramCounter, hit.Address, hit.OldValue, hit.NewValue, hit.Cycle)
+		} else {
+			fmt.Fprintf(d.hits, "[yellow]$%04X[white] R $%04X: $%02X [grey](cycle %d)\n",
+				hit.ProgramCounter, hit.Address, hit.NewValue, hit.Cycle)
+		}
+	}
+}
+
+// Clear resets the watchpoint hits display.
+func (d *WatchpointForm) Clear() {
+	d.hits.Clear()
+}
+
+// GetDrawArea returns the primitive that represents this form in the UI.
+// This is used by the layout manager to position and render the form.
+//
+// Returns:
+//   - The tview primitive for this form
+func (d *WatchpointForm) GetDrawArea() tview.Primitive {
+	return d.grid
+}
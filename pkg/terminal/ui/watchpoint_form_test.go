@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/core"
+	"github.com/fran150/clementina-6502/pkg/core/managers"
+	"github.com/rivo/tview"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWatchpointForm() (*WatchpointForm, core.WatchpointManager) {
+	memory := make([]uint8, 0x10000)
+	wm := managers.NewWatchpointManager(func(address uint16) uint8 {
+		return memory[address]
+	})
+
+	return NewWatchpointForm(wm), wm
+}
+
+func TestNewWatchpointForm(t *testing.T) {
+	form, _ := newTestWatchpointForm()
+
+	assert.NotNil(t, form.grid)
+	assert.NotNil(t, form.form)
+	assert.NotNil(t, form.list)
+	assert.NotNil(t, form.hits)
+	assert.Equal(t, form.grid, form.GetDrawArea())
+}
+
+func TestAddSelectedWatchpoint(t *testing.T) {
+	form, wm := newTestWatchpointForm()
+	startInput := form.form.GetFormItemByLabel("Start").(*tview.InputField)
+	endInput := form.form.GetFormItemByLabel("End").(*tview.InputField)
+	accessInput := form.form.GetFormItemByLabel("Access").(*tview.DropDown)
+
+	// Empty start address does nothing
+	form.AddSelectedWatchpoint()
+	assert.Equal(t, 0, wm.GetWatchpointCount())
+
+	// Single address, default access is write
+	startInput.SetText("200")
+	form.AddSelectedWatchpoint()
+
+	assert.Equal(t, core.Watchpoint{StartAddress: 0x0200, EndAddress: 0x0200, Type: core.WatchWrite}, wm.GetWatchpoints()[0])
+	text, _ := form.list.GetItemText(0)
+	assert.Equal(t, "$0200       Write", text)
+	assert.Empty(t, startInput.GetText())
+
+	// Range with read access
+	startInput.SetText("6010")
+	endInput.SetText("6000")
+	accessInput.SetCurrentOption(1)
+	form.AddSelectedWatchpoint()
+
+	assert.Equal(t, core.Watchpoint{StartAddress: 0x6000, EndAddress: 0x6010, Type: core.WatchRead}, wm.GetWatchpoints()[1])
+	text, _ = form.list.GetItemText(1)
+	assert.Equal(t, "$6000-$6010 Read", text)
+	assert.Empty(t, endInput.GetText())
+}
+
+func TestRemoveSelectedWatchpoint(t *testing.T) {
+	form, wm := newTestWatchpointForm()
+
+	// Empty list should not panic
+	form.RemoveSelectedItem()
+
+	form.AddWatchpoint(0x0200, 0x0200, core.WatchWrite)
+	form.AddWatchpoint(0x0300, 0x0310, core.WatchReadWrite)
+
+	form.RemoveSelectedItem()
+	assert.Equal(t, 1, wm.GetWatchpointCount())
+	assert.Equal(t, 1, form.list.GetItemCount())
+	assert.Equal(t, uint16(0x0300), wm.GetWatchpoints()[0].StartAddress)
+}
+
+func TestWatchpointFormValidateHexInput(t *testing.T) {
+	form, _ := newTestWatchpointForm()
+
+	assert.True(t, form.validateHexInput("12a", 'a'))
+	assert.False(t, form.validateHexInput("12G", 'G'))
+	assert.False(t, form.validateHexInput("FFFF5", '5'))
+}
+
+func TestWatchpointFormDraw(t *testing.T) {
+	form, wm := newTestWatchpointForm()
+	form.AddWatchpoint(0x0200, 0x0200, core.WatchReadWrite)
+
+	wm.CheckAccess(0x0200, true, 0x42, 0x8003, 10)
+	wm.CheckAccess(0x0200, false, 0x42, 0x8010, 20)
+
+	form.Draw(&common.StepContext{})
+	text := form.hits.GetText(true)
+
+	assert.Contains(t, text, "$8003 W $0200: $00 -> $42 (cycle 10)")
+	assert.Contains(t, text, "$8010 R $0200: $42 (cycle 20)")
+
+	form.Clear()
+	assert.Empty(t, form.hits.GetText(true))
+}
@@ -0,0 +1,344 @@
+// Package testbench runs 6502 programs from Go tests.
+//
+// A bench connects a WDC 65C02S to a computer, loads the ROM under test and runs it until a
+// condition is met, allowing the tests to prepare the registers and memory, call the routines
+// of the ROM and check their results. The time of the bench is derived from the cycles, so the
+// components that depend on time, like the LCD, behave the same way on every run.
+//
+// Usage:
+//
+//	bench, err := testbench.NewBench(rom)
+//	require.NoError(t, err)
+//
+//	registers, err := bench.CallSubroutine(0xF000, 0x12, 0x34, 0x00)
+//	require.NoError(t, err)
+//	assert.Equal(t, uint8(0x46), registers.A)
+package testbench
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fran150/clementina-6502/pkg/common"
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/buses"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/components/memory"
+	"github.com/fran150/clementina-6502/pkg/core"
+)
+
+// DefaultCycleLimit is the number of cycles a run can execute before failing, it stops the
+// programs that never meet the condition of the run.
+const DefaultCycleLimit uint64 = 10_000_000
+
+// ClockFrequency is the frequency in Hz of the clock of the bench, used to calculate the time
+// of the components from the cycles executed.
+const ClockFrequency uint64 = 1_000_000
+
+// ErrCycleLimit is returned when a run executes the cycle limit without meeting its condition.
+var ErrCycleLimit = errors.New("cycle limit reached")
+
+// Registers holds the values of the registers of the processor.
+type Registers struct {
+	A  uint8
+	X  uint8
+	Y  uint8
+	SP uint8
+	P  uint8
+	PC uint16
+}
+
+// machine is the computer run by the bench, the processor of the bench must be part of it.
+type machine interface {
+	core.Ticker
+	core.PostTicker
+	core.MemoryPeeker
+
+	// PokeMemory writes a byte to the memory map without bus side effects.
+	PokeMemory(address uint16, value uint8)
+}
+
+// Bench runs a program on a processor, cycle by cycle, and gives access to the registers of
+// the processor and the memory of the computer between runs.
+type Bench struct {
+	processor  cpu.ControllableProcessor
+	machine    machine
+	context    common.StepContext
+	cycleLimit uint64
+}
+
+// newBench creates a bench to run the machine. The program counter is set to the reset vector,
+// so the first instruction executed is the one where the program starts.
+func newBench(processor cpu.ControllableProcessor, machine machine) *Bench {
+	bench := &Bench{
+		processor:  processor,
+		machine:    machine,
+		context:    common.NewVirtualStepContext(ClockFrequency),
+		cycleLimit: DefaultCycleLimit,
+	}
+
+	processor.ForceProgramCounter(bench.peekWord(0xFFFC))
+
+	return bench
+}
+
+// NewBench creates a bench with a WDC 65C02S connected to 64K of RAM. The ROM is loaded at the
+// end of the memory, so its last bytes are the interrupt vectors, and the program counter is
+// set to the address of the reset vector.
+//
+// Parameters:
+//   - rom: The image of the ROM, up to 64K
+//
+// Returns:
+//   - A pointer to the bench
+//   - An error if the ROM doesn't fit in the memory
+func NewBench(rom []byte) (*Bench, error) {
+	if len(rom) == 0 || len(rom) > 0x10000 {
+		return nil, fmt.Errorf("the ROM must have between 1 and 65536 bytes, it has %d", len(rom))
+	}
+
+	computer := newRamComputer()
+
+	start := 0x10000 - len(rom)
+	for i, value := range rom {
+		computer.PokeMemory(uint16(start+i), value)
+	}
+
+	return newBench(computer.processor, computer), nil
+}
+
+/************************************************************************************
+* Getters and setters
+*************************************************************************************/
+
+// GetProcessor returns the processor of the bench.
+func (b *Bench) GetProcessor() components.Cpu65C02 {
+	return b.processor
+}
+
+// GetCycles returns the number of cycles executed since the bench was created.
+func (b *Bench) GetCycles() uint64 {
+	return b.context.Cycle
+}
+
+// SetCycleLimit sets the number of cycles each run can execute before failing with
+// ErrCycleLimit, DefaultCycleLimit if not set.
+//
+// Parameters:
+//   - cycles: Cycles each run can execute
+func (b *Bench) SetCycleLimit(cycles uint64) {
+	b.cycleLimit = cycles
+}
+
+// GetRegisters returns the current values of the registers of the processor.
+func (b *Bench) GetRegisters() Registers {
+	return Registers{
+		A:  b.processor.GetAccumulatorRegister(),
+		X:  b.processor.GetXRegister(),
+		Y:  b.processor.GetYRegister(),
+		SP: b.processor.GetStackPointer(),
+		P:  b.processor.GetProcessorStatusRegister().GetValue(),
+		PC: b.processor.GetProgramCounter(),
+	}
+}
+
+// SetRegisters completes the instruction in progress, if any, and sets the registers of the
+// processor. The execution continues at the program counter set.
+//
+// Parameters:
+//   - registers: The values of the registers
+//
+// Returns:
+//   - ErrCycleLimit if the instruction in progress doesn't complete
+func (b *Bench) SetRegisters(registers Registers) error {
+	if err := b.completeInstruction(); err != nil {
+		return err
+	}
+
+	b.processor.ForceRegisters(registers.A, registers.X, registers.Y, registers.SP, registers.P)
+	b.processor.ForceProgramCounter(registers.PC)
+
+	return nil
+}
+
+// Peek returns the value at the address without bus side effects.
+func (b *Bench) Peek(address uint16) uint8 {
+	return b.machine.PeekMemory(address)
+}
+
+// Poke writes the values starting at the address, without bus side effects. ROM can be
+// written, the writes to I/O are ignored.
+//
+// Parameters:
+//   - address: The address where the first value is written
+//   - values: The values to write to consecutive addresses
+func (b *Bench) Poke(address uint16, values ...uint8) {
+	for i, value := range values {
+		b.machine.PokeMemory(address+uint16(i), value)
+	}
+}
+
+// peekWord returns the little endian word at the address.
+func (b *Bench) peekWord(address uint16) uint16 {
+	return uint16(b.Peek(address)) | uint16(b.Peek(address+1))<<8
+}
+
+/************************************************************************************
+* Runs
+*************************************************************************************/
+
+// RunCycles executes the number of cycles, the last one can be in the middle of an instruction.
+//
+// Parameters:
+//   - cycles: Number of cycles to execute
+func (b *Bench) RunCycles(cycles uint64) {
+	for range cycles {
+		b.cycle()
+	}
+}
+
+// RunUntil executes instructions until the condition is true. The condition is evaluated
+// between instructions, when the program counter has the address of the next instruction,
+// starting with the current one.
+//
+// Parameters:
+//   - condition: Function that returns true when the run must stop
+//
+// Returns:
+//   - ErrCycleLimit if the condition is not met before the cycle limit
+func (b *Bench) RunUntil(condition func(processor components.Cpu65C02) bool) error {
+	start := b.context.Cycle
+
+	for !b.processor.IsAtInstructionBoundary() || !condition(b.processor) {
+		if b.context.Cycle-start >= b.cycleLimit {
+			return ErrCycleLimit
+		}
+
+		b.cycle()
+	}
+
+	return nil
+}
+
+// RunUntilPC executes instructions until the next one to execute is the one at the address.
+//
+// Parameters:
+//   - address: Address of the instruction where the run stops, before executing it
+//
+// Returns:
+//   - ErrCycleLimit if the address is not reached before the cycle limit
+func (b *Bench) RunUntilPC(address uint16) error {
+	return b.RunUntil(func(processor components.Cpu65C02) bool {
+		return processor.GetProgramCounter() == address
+	})
+}
+
+// CallSubroutine calls the subroutine at the address with the values of the registers, as a
+// JSR from the next instruction would, and runs until it returns. The status register is not
+// changed and the execution continues at the next instruction.
+//
+// Parameters:
+//   - address: Address of the subroutine
+//   - a: Value of the accumulator
+//   - x: Value of the X register
+//   - y: Value of the Y register
+//
+// Returns:
+//   - The registers when the subroutine returned
+//   - ErrCycleLimit if the subroutine doesn't return before the cycle limit
+func (b *Bench) CallSubroutine(address uint16, a uint8, x uint8, y uint8) (Registers, error) {
+	if err := b.completeInstruction(); err != nil {
+		return b.GetRegisters(), err
+	}
+
+	registers := b.GetRegisters()
+
+	// Pushes the address of the next instruction minus one, where RTS returns
+	returnAddress := registers.PC - 1
+	b.Poke(0x0100|uint16(registers.SP), uint8(returnAddress>>8))
+	b.Poke(0x0100|uint16(registers.SP-1), uint8(returnAddress))
+
+	if err := b.SetRegisters(Registers{A: a, X: x, Y: y, SP: registers.SP - 2, P: registers.P, PC: address}); err != nil {
+		return b.GetRegisters(), err
+	}
+
+	err := b.RunUntil(func(processor components.Cpu65C02) bool {
+		return processor.GetProgramCounter() == registers.PC && processor.GetStackPointer() == registers.SP
+	})
+
+	return b.GetRegisters(), err
+}
+
+// completeInstruction executes the cycles of the instruction in progress, if any.
+func (b *Bench) completeInstruction() error {
+	return b.RunUntil(func(processor components.Cpu65C02) bool {
+		return true
+	})
+}
+
+// cycle executes one cycle of the machine.
+func (b *Bench) cycle() {
+	b.machine.Tick(&b.context)
+	b.machine.PostTick(&b.context)
+	b.context.NextCycle()
+}
+
+/************************************************************************************
+* RAM computer
+*************************************************************************************/
+
+// ramComputer is a processor connected to 64K of RAM.
+type ramComputer struct {
+	processor cpu.ControllableProcessor
+	ram       components.Memory
+}
+
+// newRamComputer creates a WDC 65C02S connected to 64K of RAM, the interrupt lines are not used.
+func newRamComputer() *ramComputer {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+
+	alwaysHighLine := buses.NewStandaloneLine(true)
+	alwaysLowLine := buses.NewStandaloneLine(false)
+	writeEnableLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(alwaysLowLine)
+	ram.OutputEnable().Connect(alwaysLowLine)
+
+	processor := cpu.NewCpu65C02S().(cpu.ControllableProcessor)
+	processor.AddressBus().Connect(addressBus)
+	processor.DataBus().Connect(dataBus)
+	processor.BusEnable().Connect(alwaysHighLine)
+	processor.ReadWrite().Connect(writeEnableLine)
+	processor.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	processor.Sync().Connect(buses.NewStandaloneLine(false))
+	processor.Ready().Connect(alwaysHighLine)
+	processor.VectorPull().Connect(buses.NewStandaloneLine(false))
+	processor.SetOverflow().Connect(alwaysHighLine)
+	processor.Reset().Connect(alwaysHighLine)
+	processor.InterruptRequest().Connect(alwaysHighLine)
+	processor.NonMaskableInterrupt().Connect(alwaysHighLine)
+
+	return &ramComputer{processor: processor, ram: ram}
+}
+
+func (c *ramComputer) Tick(context *common.StepContext) {
+	c.processor.Tick(context)
+	c.ram.Tick(context)
+}
+
+func (c *ramComputer) PostTick(context *common.StepContext) {
+	c.processor.PostTick(context)
+}
+
+func (c *ramComputer) PeekMemory(address uint16) uint8 {
+	return c.ram.Peek(uint32(address))
+}
+
+func (c *ramComputer) PokeMemory(address uint16, value uint8) {
+	c.ram.Poke(address, value)
+}
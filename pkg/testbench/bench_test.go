@@ -0,0 +1,135 @@
+package testbench
+
+import (
+	"testing"
+
+	"github.com/fran150/clementina-6502/pkg/components"
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRom creates a 4K ROM loaded at $F000 with an addition subroutine and a main program
+// that stores 5 in $0200 and loops forever
+func newTestRom() []byte {
+	rom := make([]byte, 0x1000)
+
+	program := map[uint16][]byte{
+		// add: STX $00, CLC, ADC $00, RTS
+		0xF000: {0x86, 0x00, 0x18, 0x65, 0x00, 0x60},
+		// reset: LDA #$05, STA $0200
+		0xF010: {0xA9, 0x05, 0x8D, 0x00, 0x02},
+		// loop: JMP loop
+		0xF015: {0x4C, 0x15, 0xF0},
+		// NMI, reset and IRQ vectors
+		0xFFFA: {0x15, 0xF0, 0x10, 0xF0, 0x15, 0xF0},
+	}
+
+	for address, values := range program {
+		copy(rom[address-0xF000:], values)
+	}
+
+	return rom
+}
+
+func TestBenchRunsUntilPC(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(0xF010), bench.GetRegisters().PC)
+
+	require.NoError(t, bench.RunUntilPC(0xF015))
+	assert.Equal(t, uint8(0x05), bench.GetRegisters().A)
+	assert.Equal(t, uint8(0x05), bench.Peek(0x0200))
+	assert.Equal(t, uint64(6), bench.GetCycles())
+}
+
+func TestBenchRunsUntilCondition(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	err = bench.RunUntil(func(processor components.Cpu65C02) bool {
+		return processor.GetAccumulatorRegister() == 0x05
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0xF012), bench.GetRegisters().PC)
+}
+
+func TestBenchFailsOnCycleLimit(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	bench.SetCycleLimit(100)
+
+	assert.ErrorIs(t, bench.RunUntilPC(0xF000), ErrCycleLimit)
+	assert.Equal(t, uint64(100), bench.GetCycles())
+}
+
+func TestBenchRunsCycles(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	// LDA takes 2 cycles and STA 4, the store is done in the last cycle
+	bench.RunCycles(5)
+	assert.Equal(t, uint8(0x00), bench.Peek(0x0200))
+
+	bench.RunCycles(1)
+	assert.Equal(t, uint8(0x05), bench.Peek(0x0200))
+}
+
+func TestBenchCallsSubroutine(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	require.NoError(t, bench.RunUntilPC(0xF015))
+	stackPointer := bench.GetRegisters().SP
+
+	registers, err := bench.CallSubroutine(0xF000, 0x12, 0x34, 0x56)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint8(0x46), registers.A)
+	assert.Equal(t, uint8(0x34), registers.X)
+	assert.Equal(t, uint8(0x56), registers.Y)
+	assert.Equal(t, stackPointer, registers.SP)
+	assert.Equal(t, uint16(0xF015), registers.PC)
+	assert.Equal(t, uint8(0x34), bench.Peek(0x0000))
+}
+
+func TestBenchCallsSubroutineInTheMiddleOfAnInstruction(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	// Stops in the middle of STA, that must complete before the call
+	bench.RunCycles(3)
+
+	registers, err := bench.CallSubroutine(0xF000, 0xFF, 0x01, 0x00)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint8(0x00), registers.A)
+	assert.True(t, bench.GetProcessor().GetProcessorStatusRegister().Flag(cpu.CarryFlagBit))
+	assert.Equal(t, uint16(0xF015), registers.PC)
+	assert.Equal(t, uint8(0x05), bench.Peek(0x0200))
+}
+
+func TestBenchSetsRegistersAndMemory(t *testing.T) {
+	bench, err := NewBench(newTestRom())
+	require.NoError(t, err)
+
+	// Replaces the program with STA $0300, X and loop
+	bench.Poke(0xF010, 0x9D, 0x00, 0x03, 0x4C, 0x13, 0xF0)
+
+	require.NoError(t, bench.SetRegisters(Registers{A: 0xAA, X: 0x02, Y: 0x03, SP: 0xF0, P: 0x30, PC: 0xF010}))
+	require.NoError(t, bench.RunUntilPC(0xF013))
+
+	assert.Equal(t, uint8(0xAA), bench.Peek(0x0302))
+	assert.Equal(t, Registers{A: 0xAA, X: 0x02, Y: 0x03, SP: 0xF0, P: 0x30, PC: 0xF013}, bench.GetRegisters())
+}
+
+func TestBenchRejectsInvalidRoms(t *testing.T) {
+	_, err := NewBench(nil)
+	assert.Error(t, err)
+
+	_, err = NewBench(make([]byte, 0x10001))
+	assert.Error(t, err)
+}
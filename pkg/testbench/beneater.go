@@ -0,0 +1,180 @@
+package testbench
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fran150/clementina-6502/pkg/components/cpu"
+	"github.com/fran150/clementina-6502/pkg/computers/beneater"
+	"github.com/stretchr/testify/assert"
+)
+
+// serialOutputTimeout is the time the ACIA has to transmit the expected output, the bytes are
+// written to the port by a goroutine of the ACIA.
+const serialOutputTimeout = time.Second
+
+// Range of the DDRAM of each line of the LCD in two line mode
+const (
+	lcdLine1Start, lcdLine1End = 0, 40
+	lcdLine2Start, lcdLine2End = 40, 80
+	lcdVisibleChars            = 16
+)
+
+// BenEaterBench runs a program on Ben Eater's computer. Besides the memory and the processor,
+// it gives access to the serial port connected to the ACIA and the contents of the LCD.
+type BenEaterBench struct {
+	*Bench
+
+	computer *beneater.BenEaterComputer
+	port     *serialPort
+}
+
+// NewBenEaterBench creates Ben Eater's computer with a WDC 65C02S and an in memory serial port
+// connected to the ACIA. The ROM is loaded at the end of the ROM chip, so its last bytes are the
+// interrupt vectors, and the program counter is set to the address of the reset vector.
+//
+// Parameters:
+//   - rom: The image of the ROM, up to 32K
+//
+// Returns:
+//   - A pointer to the bench, it must be closed after the test
+//   - An error if the ROM doesn't fit in the ROM chip or the computer can't be created
+func NewBenEaterBench(rom []byte) (*BenEaterBench, error) {
+	if len(rom) == 0 || len(rom) > 0x8000 {
+		return nil, fmt.Errorf("the ROM must have between 1 and 32768 bytes, it has %d", len(rom))
+	}
+
+	processor := cpu.NewCpu65C02S().(cpu.ControllableProcessor)
+	port := newSerialPort()
+
+	computer, err := beneater.NewBenEaterComputer(&beneater.BenEaterComputerConfig{
+		Port:      port,
+		Processor: processor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0x10000 - len(rom)
+	for i, value := range rom {
+		computer.PokeMemory(uint16(start+i), value)
+	}
+
+	return &BenEaterBench{
+		Bench:    newBench(processor, computer),
+		computer: computer,
+		port:     port,
+	}, nil
+}
+
+// Close stops the goroutines of the ACIA and releases the serial port.
+func (b *BenEaterBench) Close() {
+	b.port.Close()
+	b.computer.Close()
+}
+
+/************************************************************************************
+* Serial port
+*************************************************************************************/
+
+// SendSerial sends the text to the ACIA, it receives one byte each time it reads the port. The
+// program must read the bytes as fast as they are sent or the ACIA reports an overrun.
+//
+// Parameters:
+//   - text: The text to send
+func (b *BenEaterBench) SendSerial(text string) {
+	b.port.send([]byte(text))
+}
+
+// GetSerialOutput returns all the bytes transmitted by the ACIA since the bench was created.
+// The bytes are written to the port asynchronously, the last ones transmitted by the program
+// might not be there yet.
+func (b *BenEaterBench) GetSerialOutput() string {
+	return string(b.port.getOutput())
+}
+
+// AssertSerialOutput asserts that the bytes transmitted by the ACIA are the expected ones,
+// waiting for the ACIA to write the pending bytes to the port.
+//
+// Parameters:
+//   - t: The test
+//   - expected: The text that the program must have transmitted
+//
+// Returns:
+//   - True if the output is the expected one
+func (b *BenEaterBench) AssertSerialOutput(t testing.TB, expected string) bool {
+	t.Helper()
+
+	// Waits for the output to have at least the expected length before comparing it
+	assert.Eventually(t, func() bool {
+		return len(b.GetSerialOutput()) >= len(expected)
+	}, serialOutputTimeout, serialPortPollInterval)
+
+	return assert.Equal(t, expected, b.GetSerialOutput())
+}
+
+/************************************************************************************
+* LCD
+*************************************************************************************/
+
+// GetDDRAM returns a copy of the display data RAM of the LCD controller. In two line mode the
+// first line is stored at indexes 0 to 39 and the second one at 40 to 79.
+func (b *BenEaterBench) GetDDRAM() []uint8 {
+	return append([]uint8(nil), b.computer.GetLCDStatus().GetDisplayStatus().DDRAM...)
+}
+
+// GetLCDLines returns the 16 characters visible on each line of the LCD, considering the
+// display shift. The lines are empty if the display is off.
+func (b *BenEaterBench) GetLCDLines() [2]string {
+	status := b.computer.GetLCDStatus().GetDisplayStatus()
+
+	if !status.DisplayOn {
+		return [2]string{}
+	}
+
+	return [2]string{
+		readLcdLine(status.DDRAM, status.Line1Start, lcdLine1Start, lcdLine1End),
+		readLcdLine(status.DDRAM, status.Line2Start, lcdLine2Start, lcdLine2End),
+	}
+}
+
+// AssertLCDText asserts that the visible text of each line of the LCD is the expected one.
+// Trailing spaces are ignored, so the lines can be shorter than the display.
+//
+// Parameters:
+//   - t: The test
+//   - line1: The text expected on the first line
+//   - line2: The text expected on the second line
+//
+// Returns:
+//   - True if both lines have the expected text
+func (b *BenEaterBench) AssertLCDText(t testing.TB, line1 string, line2 string) bool {
+	t.Helper()
+
+	lines := b.GetLCDLines()
+
+	return assert.Equal(t,
+		[2]string{strings.TrimRight(line1, " "), strings.TrimRight(line2, " ")},
+		[2]string{strings.TrimRight(lines[0], " "), strings.TrimRight(lines[1], " ")},
+	)
+}
+
+// readLcdLine reads the visible characters of a line of the LCD, wrapping around at the end of
+// the range of the line as the controller does.
+func readLcdLine(ddram []uint8, lineStart uint8, min uint8, max uint8) string {
+	var line strings.Builder
+
+	index := lineStart
+	for range lcdVisibleChars {
+		if index >= max {
+			index = min
+		}
+
+		line.WriteByte(ddram[index])
+		index++
+	}
+
+	return line.String()
+}
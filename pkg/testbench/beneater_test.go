@@ -0,0 +1,129 @@
+package testbench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBenEaterTestRom creates a 32K ROM that initializes the LCD in 4 bit mode, prints "Hello"
+// on the first line and "World" on the second one and loops forever at $804C. It also has
+// subroutines to send the accumulator through the ACIA and to receive a byte in it.
+func newBenEaterTestRom() []byte {
+	rom := make([]byte, 0x8000)
+
+	program := map[uint16][]byte{
+		// reset: LDX #$FF, TXS, LDA #$FF, STA DDRB
+		0x8000: {0xA2, 0xFF, 0x9A, 0xA9, 0xFF, 0x8D, 0x02, 0x60},
+		// Sets 4 bit mode: LDA #$02, STA PORTB, ORA #E, STA PORTB, AND #$0F, STA PORTB
+		0x8008: {0xA9, 0x02, 0x8D, 0x00, 0x60, 0x09, 0x40, 0x8D, 0x00, 0x60, 0x29, 0x0F, 0x8D, 0x00, 0x60},
+		// 2 lines, display on, increment and clear: LDA #instruction, JSR lcd_instruction
+		0x8017: {0xA9, 0x28, 0x20, 0x81, 0x80, 0xA9, 0x0C, 0x20, 0x81, 0x80, 0xA9, 0x06, 0x20, 0x81, 0x80, 0xA9, 0x01, 0x20, 0x81, 0x80},
+		// LDX #$00, print1: LDA line1, X, BEQ line2, JSR print_char, INX, JMP print1
+		0x802B: {0xA2, 0x00, 0xBD, 0xD1, 0x80, 0xF0, 0x07, 0x20, 0xA7, 0x80, 0xE8, 0x4C, 0x2D, 0x80},
+		// line2: LDA #$C0, JSR lcd_instruction, LDX #$00
+		0x8039: {0xA9, 0xC0, 0x20, 0x81, 0x80, 0xA2, 0x00},
+		// print2: LDA line2, X, BEQ loop, JSR print_char, INX, JMP print2
+		0x8040: {0xBD, 0xD7, 0x80, 0xF0, 0x07, 0x20, 0xA7, 0x80, 0xE8, 0x4C, 0x40, 0x80},
+		// loop: JMP loop
+		0x804C: {0x4C, 0x4C, 0x80},
+		// lcd_wait: PHA, LDA #$F0, STA DDRB
+		0x804F: {0x48, 0xA9, 0xF0, 0x8D, 0x02, 0x60},
+		// busy: reads the high nibble (LDA #RW, STA PORTB, LDA #RW|E, STA PORTB, LDA PORTB, PHA)
+		// and the low nibble (LDA #RW, STA PORTB, LDA #RW|E, STA PORTB, LDA PORTB, PLA)
+		0x8055: {
+			0xA9, 0x20, 0x8D, 0x00, 0x60, 0xA9, 0x60, 0x8D, 0x00, 0x60, 0xAD, 0x00, 0x60, 0x48,
+			0xA9, 0x20, 0x8D, 0x00, 0x60, 0xA9, 0x60, 0x8D, 0x00, 0x60, 0xAD, 0x00, 0x60, 0x68,
+		},
+		// AND #$08, BNE busy, LDA #RW, STA PORTB, LDA #$FF, STA DDRB, PLA, RTS
+		0x8071: {0x29, 0x08, 0xD0, 0xE0, 0xA9, 0x20, 0x8D, 0x00, 0x60, 0xA9, 0xFF, 0x8D, 0x02, 0x60, 0x68, 0x60},
+		// lcd_instruction: JSR lcd_wait, PHA, LSR x 4, STA PORTB, ORA #E, STA PORTB, EOR #E, STA PORTB,
+		// PLA, AND #$0F, STA PORTB, ORA #E, STA PORTB, EOR #E, STA PORTB, RTS
+		0x8081: {
+			0x20, 0x4F, 0x80, 0x48, 0x4A, 0x4A, 0x4A, 0x4A, 0x8D, 0x00, 0x60, 0x09, 0x40, 0x8D, 0x00, 0x60, 0x49, 0x40, 0x8D, 0x00, 0x60,
+			0x68, 0x29, 0x0F, 0x8D, 0x00, 0x60, 0x09, 0x40, 0x8D, 0x00, 0x60, 0x49, 0x40, 0x8D, 0x00, 0x60, 0x60,
+		},
+		// print_char: same as lcd_instruction with ORA #RS before writing each nibble
+		0x80A7: {
+			0x20, 0x4F, 0x80, 0x48, 0x4A, 0x4A, 0x4A, 0x4A, 0x09, 0x10, 0x8D, 0x00, 0x60, 0x09, 0x40, 0x8D, 0x00, 0x60, 0x49, 0x40, 0x8D, 0x00, 0x60,
+			0x68, 0x29, 0x0F, 0x09, 0x10, 0x8D, 0x00, 0x60, 0x09, 0x40, 0x8D, 0x00, 0x60, 0x49, 0x40, 0x8D, 0x00, 0x60, 0x60,
+		},
+		// line1 and line2 texts
+		0x80D1: []byte("Hello\x00World\x00"),
+		// send: PHA, LDA #$1F, STA ACIA_CTRL, LDA #$0B, STA ACIA_CMD, PLA, STA ACIA_DATA, RTS
+		0x80E0: {0x48, 0xA9, 0x1F, 0x8D, 0x03, 0x50, 0xA9, 0x0B, 0x8D, 0x02, 0x50, 0x68, 0x8D, 0x00, 0x50, 0x60},
+		// receive: LDA ACIA_STATUS, AND #$08, BEQ receive, LDA ACIA_DATA, RTS
+		0x80F0: {0xAD, 0x01, 0x50, 0x29, 0x08, 0xF0, 0xF9, 0xAD, 0x00, 0x50, 0x60},
+		// NMI, reset and IRQ vectors
+		0xFFFA: {0x4C, 0x80, 0x00, 0x80, 0x4C, 0x80},
+	}
+
+	for address, values := range program {
+		copy(rom[address-0x8000:], values)
+	}
+
+	return rom
+}
+
+func TestBenEaterBenchShowsTextOnLCD(t *testing.T) {
+	bench, err := NewBenEaterBench(newBenEaterTestRom())
+	require.NoError(t, err)
+	defer bench.Close()
+
+	require.NoError(t, bench.RunUntilPC(0x804C))
+
+	bench.AssertLCDText(t, "Hello", "World")
+	assert.Equal(t, [2]string{"Hello           ", "World           "}, bench.GetLCDLines())
+
+	ddram := bench.GetDDRAM()
+	assert.Equal(t, "Hello", string(ddram[0:5]))
+	assert.Equal(t, "World", string(ddram[40:45]))
+}
+
+func TestBenEaterBenchIsReproducible(t *testing.T) {
+	var cycles [2]uint64
+
+	for i := range cycles {
+		bench, err := NewBenEaterBench(newBenEaterTestRom())
+		require.NoError(t, err)
+
+		require.NoError(t, bench.RunUntilPC(0x804C))
+		cycles[i] = bench.GetCycles()
+
+		bench.Close()
+	}
+
+	// The program waits for the LCD, its busy periods take the same cycles on every run
+	assert.Equal(t, cycles[0], cycles[1])
+}
+
+func TestBenEaterBenchSendsAndReceivesSerial(t *testing.T) {
+	bench, err := NewBenEaterBench(newBenEaterTestRom())
+	require.NoError(t, err)
+	defer bench.Close()
+
+	require.NoError(t, bench.RunUntilPC(0x804C))
+
+	_, err = bench.CallSubroutine(0x80E0, 'O', 0x00, 0x00)
+	require.NoError(t, err)
+	bench.AssertSerialOutput(t, "O")
+
+	_, err = bench.CallSubroutine(0x80E0, 'K', 0x00, 0x00)
+	require.NoError(t, err)
+	bench.AssertSerialOutput(t, "OK")
+
+	bench.SendSerial("A")
+
+	registers, err := bench.CallSubroutine(0x80F0, 0x00, 0x00, 0x00)
+	require.NoError(t, err)
+	assert.Equal(t, uint8('A'), registers.A)
+}
+
+func TestBenEaterBenchRejectsInvalidRoms(t *testing.T) {
+	_, err := NewBenEaterBench(nil)
+	assert.Error(t, err)
+
+	_, err = NewBenEaterBench(make([]byte, 0x8001))
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package testbench
+
+import (
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// serialPortPollInterval is the time between checks for input while a read is waiting.
+const serialPortPollInterval = time.Millisecond
+
+// serialPort is an in memory serial port connected to the ACIA of the bench. It keeps the bytes
+// transmitted by the ACIA and gives it the bytes sent by the test, one on each read.
+type serialPort struct {
+	mu          sync.Mutex
+	output      []byte
+	input       []byte
+	readTimeout time.Duration
+	closed      bool
+}
+
+// newSerialPort creates a serial port with no timeout on the reads.
+func newSerialPort() *serialPort {
+	return &serialPort{
+		readTimeout: serial.NoTimeout,
+	}
+}
+
+// Write keeps the bytes transmitted by the ACIA.
+func (p *serialPort) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.output = append(p.output, data...)
+
+	return len(data), nil
+}
+
+// Read gives the next byte sent by the test, waiting for it until the read timeout expires or
+// the port is closed.
+func (p *serialPort) Read(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	start := time.Now()
+
+	for {
+		p.mu.Lock()
+
+		if len(p.input) > 0 {
+			data[0] = p.input[0]
+			p.input = p.input[1:]
+			p.mu.Unlock()
+
+			return 1, nil
+		}
+
+		expired := p.closed || (p.readTimeout != serial.NoTimeout && time.Since(start) >= p.readTimeout)
+		p.mu.Unlock()
+
+		if expired {
+			return 0, nil
+		}
+
+		time.Sleep(serialPortPollInterval)
+	}
+}
+
+// send queues the bytes to be received by the ACIA.
+func (p *serialPort) send(data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.input = append(p.input, data...)
+}
+
+// getOutput returns a copy of the bytes transmitted by the ACIA.
+func (p *serialPort) getOutput() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]byte(nil), p.output...)
+}
+
+// SetReadTimeout sets the time a read waits for input.
+func (p *serialPort) SetReadTimeout(t time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.readTimeout = t
+
+	return nil
+}
+
+// Close stops the reads waiting for input.
+func (p *serialPort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+
+	return nil
+}
+
+// GetModemStatusBits reports the port as ready to receive, CTS and DSR are always active.
+func (p *serialPort) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{CTS: true, DSR: true}, nil
+}
+
+func (p *serialPort) SetMode(mode *serial.Mode) error {
+	return nil
+}
+
+func (p *serialPort) Drain() error {
+	return nil
+}
+
+func (p *serialPort) ResetInputBuffer() error {
+	return nil
+}
+
+func (p *serialPort) ResetOutputBuffer() error {
+	return nil
+}
+
+func (p *serialPort) SetDTR(dtr bool) error {
+	return nil
+}
+
+func (p *serialPort) SetRTS(rts bool) error {
+	return nil
+}
+
+func (p *serialPort) Break(duration time.Duration) error {
+	return nil
+}
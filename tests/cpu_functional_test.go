@@ -10,6 +10,7 @@ import (
 	"github.com/fran150/clementina-6502/pkg/components/buses"
 	"github.com/fran150/clementina-6502/pkg/components/cpu"
 	"github.com/fran150/clementina-6502/pkg/components/memory"
+	"github.com/fran150/clementina-6502/pkg/core"
 )
 
 /******************************************************************************************************
@@ -26,10 +27,21 @@ const successPcValue uint16 = 0x346B
 // Max number of cycles allowed to copmlete execution
 const maxAllowedCycles uint64 = 100_000_000
 
+// Number of cycles counted until the success trap is detected when running cycle by cycle
+const functionalTestCycles uint64 = 96_241_272
+
 /******************************************************************************************************
 * Support functions
 *******************************************************************************************************/
 
+// Processor that can run the functional tests
+type functionalTestProcessor interface {
+	core.Ticker
+	core.PostTicker
+	components.CpuRegisters
+	components.CpuState
+}
+
 // Creates a CPU connected to a RAM memory
 func NewComputer() (components.Cpu65C02, components.Memory) {
 	addressBus := buses.New16BitStandaloneBus()
@@ -70,6 +82,46 @@ func NewComputer() (components.Cpu65C02, components.Memory) {
 	return processor, ram
 }
 
+// Creates a 65C816S connected to a RAM memory. The bank address is not connected, in emulation mode
+// all the accesses are done on bank 0.
+func NewComputer65C816S() (components.Cpu65C816, components.Memory) {
+	addressBus := buses.New16BitStandaloneBus()
+	dataBus := buses.New8BitStandaloneBus()
+
+	alwaysHighLine := buses.NewStandaloneLine(true)
+	alwaysLowLine := buses.NewStandaloneLine(false)
+
+	writeEnableLine := buses.NewStandaloneLine(true)
+
+	ram := memory.NewRam(memory.RAM_SIZE_64K)
+	ram.AddressBus().Connect(addressBus)
+	ram.DataBus().Connect(dataBus)
+	ram.WriteEnable().Connect(writeEnableLine)
+	ram.ChipSelect().Connect(alwaysLowLine)
+	ram.OutputEnable().Connect(alwaysLowLine)
+
+	processor := cpu.NewCpu65C816S()
+	processor.AddressBus().Connect(addressBus)
+	processor.DataBus().Connect(dataBus)
+
+	processor.BusEnable().Connect(alwaysHighLine)
+	processor.ReadWrite().Connect(writeEnableLine)
+	processor.MemoryLock().Connect(buses.NewStandaloneLine(false))
+	processor.ValidDataAddress().Connect(buses.NewStandaloneLine(false))
+	processor.ValidProgramAddress().Connect(buses.NewStandaloneLine(false))
+	processor.VectorPull().Connect(buses.NewStandaloneLine(false))
+	processor.Emulation().Connect(buses.NewStandaloneLine(false))
+	processor.MemoryIndexSelect().Connect(buses.NewStandaloneLine(false))
+	processor.Ready().Connect(alwaysHighLine)
+	processor.Reset().Connect(alwaysHighLine)
+	processor.Abort().Connect(alwaysHighLine)
+
+	processor.InterruptRequest().Connect(alwaysHighLine)
+	processor.NonMaskableInterrupt().Connect(alwaysHighLine)
+
+	return processor, ram
+}
+
 // In case of error the test code does not fail but is usually trapped in a repeating loop
 // Detecting when the code is "stuck" is necessary to determine success / failure of the tests
 
@@ -80,12 +132,12 @@ var previousOpCode components.OpCode
 var repeats int = 0
 
 // Error trap opcodes are usually JMP * instructions or branch instructions BNE, BCS, etc (address mode relative)
-func isTrapOpCode(processor components.Cpu65C02) bool {
+func isTrapOpCode(processor functionalTestProcessor) bool {
 	return processor.GetCurrentInstruction().Mnemonic() == cpu.JMP || processor.GetCurrentAddressMode().Name() == cpu.AddressModeRelative
 }
 
 // Counts how many times a "trap" opcode (JMP or branches) is being repeated.
-func verifyAndCountRepeats(processor components.Cpu65C02) bool {
+func verifyAndCountRepeats(processor functionalTestProcessor) bool {
 	if previousOpCode == processor.GetCurrentInstruction().OpCode() && isTrapOpCode(processor) {
 		repeats++
 	} else {
@@ -99,7 +151,7 @@ func verifyAndCountRepeats(processor components.Cpu65C02) bool {
 }
 
 // Validates the status of the processor when the test finish and fails the test if required.
-func showFinishCondition(processor components.Cpu65C02, context *common.StepContext, b *testing.B, elapsed time.Duration) {
+func showFinishCondition(processor functionalTestProcessor, context *common.StepContext, b *testing.B, elapsed time.Duration) {
 	// If processor is trapped in SUCCESS_PC_VALUE, this means that the tests were completed successfully
 	// Otherwise this is an error condition and must fail the tests
 	if processor.GetProgramCounter() != successPcValue {
@@ -108,7 +160,7 @@ func showFinishCondition(processor components.Cpu65C02, context *common.StepCont
 
 	// If the execution was cancelled due to exceeding the number of allowed cycles then fail the tests
 	if context.Cycle >= maxAllowedCycles {
-		b.Errorf("Maximum limit of %v cycles was reached, typical execution is 96,241,272", context.Cycle)
+		b.Errorf("Maximum limit of %v cycles was reached, typical execution is %v", context.Cycle, functionalTestCycles)
 	}
 
 	showExecutionSpeed(context, elapsed)
@@ -133,6 +185,80 @@ func showExecutionSpeed(context *common.StepContext, elapsed time.Duration) {
 func BenchmarkProcessor(b *testing.B) {
 	processor, ram := NewComputer()
 
+	runFunctionalTests(b, processor, ram)
+}
+
+// Runs the same functional tests on the 65C816S, which starts in emulation mode.
+func BenchmarkProcessor65C816S(b *testing.B) {
+	processor, ram := NewComputer65C816S()
+
+	runFunctionalTests(b, processor, ram)
+}
+
+// Runs the functional tests executing whole instructions on the fast mode of the processor. The number
+// of cycles taken must be the same as when running cycle by cycle. As the repeats of the trap are
+// counted per instruction instead of per cycle, the cycles are counted up to the point where the
+// cycle by cycle execution detects the trap.
+func BenchmarkProcessorFastMode(b *testing.B) {
+	processor, ram := NewComputer()
+
+	if err := ram.Load("../tests/6502_functional_test.bin"); err != nil {
+		b.Error(err)
+	}
+
+	processor.ForceProgramCounter(0x0400)
+
+	memoryMap := cpu.NewMemoryMap()
+	memoryMap.Map(0x0000, 0xFFFF, ram.Contents(), true)
+
+	fastProcessor := processor.(cpu.FastProcessor)
+
+	previousOpCode = components.OpCode(0)
+	repeats = 0
+
+	context := common.NewStepContext()
+	var start = time.Now()
+
+	// Cycle where the instruction repeated since the last change of opcode started
+	var repeatStart uint64
+
+	for i := 0; i < b.N; i++ {
+		for context.Cycle < maxAllowedCycles {
+			// Executes one instruction at a time to detect the trap
+			cycles := fastProcessor.ExecuteInstructions(memoryMap, 1)
+			if cycles == 0 {
+				b.Fatalf("Instruction at %04X not executed in fast mode", processor.GetProgramCounter())
+			}
+
+			if verifyAndCountRepeats(processor) {
+				context.Cycle = repeatStart + uint64(maxRepeats)
+				break
+			}
+
+			if repeats == 0 {
+				repeatStart = context.Cycle
+			}
+
+			context.Cycle += uint64(cycles)
+		}
+	}
+
+	elapsed := time.Since(start)
+
+	// The success trap is a JMP to itself, after executing it the program counter is the address of the JMP
+	if processor.GetProgramCounter() != successPcValue-2 {
+		b.Errorf("Possible ERROR trap found with PC in %04X", processor.GetProgramCounter())
+	}
+
+	if context.Cycle != functionalTestCycles {
+		b.Errorf("Execution took %v cycles, running cycle by cycle takes %v", context.Cycle, functionalTestCycles)
+	}
+
+	showExecutionSpeed(&context, elapsed)
+}
+
+// Loads and runs the functional tests on the processor until the code is trapped
+func runFunctionalTests(b *testing.B, processor functionalTestProcessor, ram components.Memory) {
 	// Loads Klaus2m5 functional tests. See repository mentioned above for reference
 	if err := ram.Load("../tests/6502_functional_test.bin"); err != nil {
 		b.Error(err)